@@ -0,0 +1,286 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// metrics generates the catalog of metrics emitted by Karpenter, along with the recommended Prometheus recording and
+// alerting rules built on top of it. Metrics are discovered from their definitions in code, so the generated artifacts
+// change whenever a metric is added, renamed or relabeled.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	module            = "sigs.k8s.io/karpenter"
+	operatorpkgImport = "github.com/awslabs/operatorpkg/metrics"
+)
+
+var metricTypes = map[string]string{
+	"NewPrometheusCounter":   metrics.CatalogCounter,
+	"NewPrometheusGauge":     metrics.CatalogGauge,
+	"NewPrometheusHistogram": metrics.CatalogHistogram,
+	"NewPrometheusSummary":   metrics.CatalogSummary,
+}
+
+func main() {
+	root := flag.String("root", ".", "root of the karpenter module")
+	catalogPath := flag.String("catalog", "pkg/metrics/catalog.json", "path to write the metrics catalog to")
+	rulesPath := flag.String("rules", "pkg/metrics/prometheus-rules.yaml", "path to write the prometheus rules to")
+	flag.Parse()
+
+	catalog, err := parse(*root)
+	if err != nil {
+		log.Fatalf("parsing metrics, %s", err)
+	}
+	raw, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		log.Fatalf("marshaling catalog, %s", err)
+	}
+	if err = os.WriteFile(*catalogPath, append(raw, '\n'), 0600); err != nil {
+		log.Fatalf("writing catalog, %s", err)
+	}
+	rules, err := ruleGroups(catalog)
+	if err != nil {
+		log.Fatalf("generating rules, %s", err)
+	}
+	raw, err = yaml.Marshal(rules)
+	if err != nil {
+		log.Fatalf("marshaling rules, %s", err)
+	}
+	header := "# Code generated by hack/metrics. DO NOT EDIT.\n"
+	if err = os.WriteFile(*rulesPath, append([]byte(header), raw...), 0600); err != nil {
+		log.Fatalf("writing rules, %s", err)
+	}
+}
+
+type file struct {
+	pkg     string
+	path    string
+	ast     *ast.File
+	imports map[string]string // map[name] -> import path
+}
+
+// parse discovers the metrics that are defined with operatorpkg's prometheus constructors under pkg/
+func parse(root string) ([]metrics.CatalogMetric, error) {
+	var files []file
+	fset := token.NewFileSet()
+	if err := filepath.WalkDir(filepath.Join(root, "pkg"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		rel := lo.Must(filepath.Rel(root, path))
+		files = append(files, file{
+			pkg:     module + "/" + filepath.ToSlash(filepath.Dir(rel)),
+			path:    filepath.ToSlash(rel),
+			ast:     f,
+			imports: imports(f),
+		})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	consts := constants(files)
+	var catalog []metrics.CatalogMetric
+	for _, f := range files {
+		var errs []error
+		ast.Inspect(f.ast, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			pkg, ok := sel.X.(*ast.Ident)
+			if !ok || f.imports[pkg.Name] != operatorpkgImport {
+				return true
+			}
+			metricType, ok := metricTypes[sel.Sel.Name]
+			if !ok || len(call.Args) != 3 {
+				return true
+			}
+			metric, err := catalogMetric(f, consts, metricType, call.Args[1], call.Args[2])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s, %w", fset.Position(call.Pos()), err))
+				return false
+			}
+			catalog = append(catalog, metric)
+			return false
+		})
+		if len(errs) > 0 {
+			return nil, errs[0]
+		}
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].Name < catalog[j].Name })
+	for i := 1; i < len(catalog); i++ {
+		if catalog[i].Name == catalog[i-1].Name {
+			return nil, fmt.Errorf("metric %q is defined in both %s and %s", catalog[i].Name, catalog[i-1].Source, catalog[i].Source)
+		}
+	}
+	return catalog, nil
+}
+
+func catalogMetric(f file, consts map[string]map[string]string, metricType string, opts ast.Expr, labels ast.Expr) (metrics.CatalogMetric, error) {
+	lit, ok := opts.(*ast.CompositeLit)
+	if !ok {
+		return metrics.CatalogMetric{}, fmt.Errorf("metric options must be a composite literal")
+	}
+	fields := map[string]string{}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key := kv.Key.(*ast.Ident).Name
+		if !lo.Contains([]string{"Namespace", "Subsystem", "Name", "Help"}, key) {
+			continue
+		}
+		value, err := resolve(f, consts, kv.Value)
+		if err != nil {
+			return metrics.CatalogMetric{}, fmt.Errorf("resolving %s, %w", key, err)
+		}
+		fields[key] = value
+	}
+	metric := metrics.CatalogMetric{
+		Name:   prometheus.BuildFQName(fields["Namespace"], fields["Subsystem"], fields["Name"]),
+		Type:   metricType,
+		Help:   fields["Help"],
+		Source: f.path,
+	}
+	// Labels that are computed at runtime, like the node metrics' well-known labels, can't be resolved statically
+	if labelsLit, ok := labels.(*ast.CompositeLit); ok {
+		for _, elt := range labelsLit.Elts {
+			label, err := resolve(f, consts, elt)
+			if err != nil {
+				return metrics.CatalogMetric{}, fmt.Errorf("resolving label, %w", err)
+			}
+			metric.Labels = append(metric.Labels, label)
+		}
+		sort.Strings(metric.Labels)
+	} else {
+		metric.DynamicLabels = true
+	}
+	return metric, nil
+}
+
+// resolve evaluates string literals and constants, including constants declared in other packages of the module
+func resolve(f file, consts map[string]map[string]string, expr ast.Expr) (string, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			return strconv.Unquote(e.Value)
+		}
+	case *ast.Ident:
+		if value, ok := consts[f.pkg][e.Name]; ok {
+			return value, nil
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := e.X.(*ast.Ident); ok {
+			if value, ok := consts[f.imports[pkg.Name]][e.Sel.Name]; ok {
+				return value, nil
+			}
+		}
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			x, err := resolve(f, consts, e.X)
+			if err != nil {
+				return "", err
+			}
+			y, err := resolve(f, consts, e.Y)
+			if err != nil {
+				return "", err
+			}
+			return x + y, nil
+		}
+	case *ast.ParenExpr:
+		return resolve(f, consts, e.X)
+	}
+	return "", fmt.Errorf("unable to resolve %T to a string constant", expr)
+}
+
+// constants returns the string constants declared in each package, keyed by import path. Constants are resolved
+// repeatedly so that those defined in terms of other constants are included regardless of declaration order.
+func constants(files []file) map[string]map[string]string {
+	consts := map[string]map[string]string{}
+	for changed := true; changed; {
+		changed = false
+		for _, f := range files {
+			for _, decl := range f.ast.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					vs := spec.(*ast.ValueSpec)
+					for i, name := range vs.Names {
+						if i >= len(vs.Values) {
+							continue
+						}
+						if _, ok := consts[f.pkg][name.Name]; ok {
+							continue
+						}
+						value, err := resolve(f, consts, vs.Values[i])
+						if err != nil {
+							continue
+						}
+						if consts[f.pkg] == nil {
+							consts[f.pkg] = map[string]string{}
+						}
+						consts[f.pkg][name.Name] = value
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return consts
+}
+
+func imports(f *ast.File) map[string]string {
+	result := map[string]string{}
+	for _, spec := range f.Imports {
+		path := lo.Must(strconv.Unquote(spec.Path.Value))
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		result[name] = path
+	}
+	return result
+}
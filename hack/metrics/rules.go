@@ -0,0 +1,181 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+// RuleFile is the Prometheus rule file format, https://prometheus.io/docs/prometheus/latest/configuration/recording_rules/
+type RuleFile struct {
+	Groups []RuleGroup `json:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Record      string            `json:"record,omitempty"`
+	Alert       string            `json:"alert,omitempty"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// alert is a recommended alert. Labels are the labels that the expression relies on, which must be present on every
+// metric that the expression references.
+type alert struct {
+	Rule
+	labels []string
+}
+
+// Labels which identify individual pods are left out of recording rules to bound their cardinality
+var unaggregatedLabels = []string{"name", "namespace"}
+
+var histogramQuantiles = []float64{0.5, 0.99}
+
+var alerts = []alert{
+	{
+		Rule: Rule{
+			Alert:       "KarpenterCloudProviderErrors",
+			Expr:        `sum by (controller, method, provider) (rate(karpenter_cloudprovider_errors_total{error!="NodeClaimNotFoundError"}[5m])) > 0`,
+			For:         "15m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "CloudProvider {{ $labels.method }} calls from the {{ $labels.controller }} controller are failing."},
+		},
+		labels: []string{"controller", "method", "provider", "error"},
+	},
+	{
+		Rule: Rule{
+			Alert:       "KarpenterClusterStateUnsynced",
+			Expr:        `max(karpenter_cluster_state_synced) == 0`,
+			For:         "15m",
+			Labels:      map[string]string{"severity": "critical"},
+			Annotations: map[string]string{"summary": "Karpenter's cluster state hasn't synced with the cluster, so provisioning and disruption are blocked."},
+		},
+	},
+	{
+		Rule: Rule{
+			Alert:       "KarpenterUnschedulablePods",
+			Expr:        `max by (controller) (karpenter_scheduler_unschedulable_pods_count) > 0`,
+			For:         "30m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "{{ $value }} pods can't be scheduled by the {{ $labels.controller }} controller."},
+		},
+		labels: []string{"controller"},
+	},
+	{
+		Rule: Rule{
+			Alert:       "KarpenterNodePoolNearLimit",
+			Expr:        `karpenter_nodepools_usage / on (nodepool, resource_type) (karpenter_nodepools_limit > 0) > 0.9`,
+			For:         "15m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "NodePool {{ $labels.nodepool }} is using over 90% of its {{ $labels.resource_type }} limit."},
+		},
+		labels: []string{"nodepool", "resource_type"},
+	},
+	{
+		Rule: Rule{
+			Alert:       "KarpenterStaleInstanceTypes",
+			Expr:        `max by (nodepool) (karpenter_scheduler_instance_types_staleness_seconds) > 900`,
+			For:         "5m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "NodePool {{ $labels.nodepool }} is provisioning from instance types that haven't been refreshed in over 15 minutes."},
+		},
+		labels: []string{"nodepool"},
+	},
+	{
+		Rule: Rule{
+			Alert:       "KarpenterDisruptionQueueFailures",
+			Expr:        `sum by (reason) (rate(karpenter_voluntary_disruption_queue_failures_total[15m])) > 0`,
+			For:         "30m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "Disruption commands for {{ $labels.reason }} are failing to complete."},
+		},
+		labels: []string{"reason"},
+	},
+	{
+		Rule: Rule{
+			Alert:       "KarpenterMetricsStale",
+			Expr:        `time() - max(karpenter_metrics_snapshot_timestamp_seconds) > 120`,
+			For:         "5m",
+			Labels:      map[string]string{"severity": "warning"},
+			Annotations: map[string]string{"summary": "Karpenter's metrics haven't been refreshed in {{ $value | humanizeDuration }}, its controllers may be wedged."},
+		},
+	},
+}
+
+var metricName = regexp.MustCompile(`\bkarpenter_[a-z_]+\b`)
+
+// ruleGroups builds recording rules for every counter and histogram in the catalog, along with the recommended alerts.
+// Alerts are validated against the catalog so that renaming or relabeling a metric fails generation rather than
+// silently breaking the alert.
+func ruleGroups(catalog []metrics.CatalogMetric) (RuleFile, error) {
+	byName := lo.SliceToMap(catalog, func(m metrics.CatalogMetric) (string, metrics.CatalogMetric) { return m.Name, m })
+	var recording []Rule
+	for _, m := range catalog {
+		by := lo.Without(m.Labels, unaggregatedLabels...)
+		level := lo.Ternary(len(by) == 0, "cluster", strings.Join(by, "_"))
+		switch m.Type {
+		case metrics.CatalogCounter:
+			recording = append(recording, Rule{
+				Record: fmt.Sprintf("%s:%s:rate5m", level, m.Name),
+				Expr:   fmt.Sprintf("%s (rate(%s[5m]))", sumBy(by), m.Name),
+			})
+		case metrics.CatalogHistogram:
+			for _, q := range histogramQuantiles {
+				recording = append(recording, Rule{
+					Record: fmt.Sprintf("%s:%s:p%g_rate5m", level, m.Name, q*100),
+					Expr:   fmt.Sprintf("histogram_quantile(%g, %s (rate(%s_bucket[5m])))", q, sumBy(append([]string{"le"}, by...)), m.Name),
+				})
+			}
+		}
+	}
+	var alerting []Rule
+	for _, a := range alerts {
+		for _, name := range metricName.FindAllString(a.Expr, -1) {
+			m, ok := byName[name]
+			if !ok {
+				return RuleFile{}, fmt.Errorf("alert %s references metric %s, which isn't defined", a.Alert, name)
+			}
+			if missing, _ := lo.Difference(a.labels, m.Labels); len(missing) > 0 && !m.DynamicLabels {
+				return RuleFile{}, fmt.Errorf("alert %s references labels %v, which metric %s doesn't have", a.Alert, missing, name)
+			}
+		}
+		alerting = append(alerting, a.Rule)
+	}
+	return RuleFile{Groups: []RuleGroup{
+		{Name: "karpenter.rules", Rules: recording},
+		{Name: "karpenter.alerts", Rules: alerting},
+	}}, nil
+}
+
+func sumBy(labels []string) string {
+	if len(labels) == 0 {
+		return "sum"
+	}
+	return fmt.Sprintf("sum by (%s)", strings.Join(labels, ", "))
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package kwok
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"os"
 	"regexp"
 
 	"github.com/samber/lo"
@@ -27,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"sigs.k8s.io/karpenter/kwok/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/kwok/options"
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -63,12 +66,20 @@ type InstanceTypeOptions struct {
 //go:embed instance_types.json
 var rawInstanceTypes []byte
 
-// ConstructInstanceTypes create many instance types based on the embedded instance type data
-func ConstructInstanceTypes() ([]*cloudprovider.InstanceType, error) {
+// ConstructInstanceTypes create many instance types based on the instance type data in the file at
+// --instance-types-file-path, or the embedded instance type data if it isn't set
+func ConstructInstanceTypes(ctx context.Context) ([]*cloudprovider.InstanceType, error) {
 	var instanceTypes []*cloudprovider.InstanceType
 	var instanceTypeOptions []InstanceTypeOptions
 
-	if err := json.Unmarshal(rawInstanceTypes, &instanceTypeOptions); err != nil {
+	raw := rawInstanceTypes
+	if path := options.FromContext(ctx).InstanceTypesFilePath; path != "" {
+		var err error
+		if raw, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("reading instance types file, %w", err)
+		}
+	}
+	if err := json.Unmarshal(raw, &instanceTypeOptions); err != nil {
 		return nil, fmt.Errorf("could not parse JSON data: %w", err)
 	}
 
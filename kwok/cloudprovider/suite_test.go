@@ -0,0 +1,87 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kwok_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	kwok "sigs.k8s.io/karpenter/kwok/cloudprovider"
+	"sigs.k8s.io/karpenter/kwok/options"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+
+func TestKWOK(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "KWOK")
+}
+
+var _ = Describe("ConstructInstanceTypes", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should construct the embedded instance types when no file path is set", func() {
+		instanceTypes, err := kwok.ConstructInstanceTypes(options.ToContext(ctx, &options.Options{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).ToNot(BeEmpty())
+	})
+	It("should construct the instance types from a valid file", func() {
+		path := filepath.Join(dir, "instance_types.json")
+		Expect(os.WriteFile(path, []byte(`[
+			{
+				"name": "test-instance-type",
+				"offerings": [{"capacityType": "on-demand", "zone": "test-zone-a", "price": 1.5}],
+				"architecture": "amd64",
+				"operatingSystems": ["linux"],
+				"resources": {"cpu": "4", "memory": "16Gi", "pods": "110"}
+			}
+		]`), 0600)).To(Succeed())
+
+		instanceTypes, err := kwok.ConstructInstanceTypes(options.ToContext(ctx, &options.Options{InstanceTypesFilePath: path}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).To(ConsistOf("test-instance-type"))
+		Expect(instanceTypes[0].Offerings).To(HaveLen(1))
+		Expect(instanceTypes[0].Offerings[0].Price).To(Equal(1.5))
+		Expect(instanceTypes[0].Capacity.Cpu().String()).To(Equal("4"))
+	})
+	It("should return an error when the file doesn't exist", func() {
+		_, err := kwok.ConstructInstanceTypes(options.ToContext(ctx, &options.Options{InstanceTypesFilePath: filepath.Join(dir, "missing.json")}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("reading instance types file"))
+	})
+	It("should return an error when the file is malformed", func() {
+		path := filepath.Join(dir, "instance_types.json")
+		Expect(os.WriteFile(path, []byte(`[{"name": "test-instance-type",`), 0600)).To(Succeed())
+
+		_, err := kwok.ConstructInstanceTypes(options.ToContext(ctx, &options.Options{InstanceTypesFilePath: path}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("could not parse JSON data"))
+	})
+})
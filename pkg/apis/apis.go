@@ -34,8 +34,17 @@ var (
 	NodePoolCRD []byte
 	//go:embed crds/karpenter.sh_nodeclaims.yaml
 	NodeClaimCRD []byte
-	CRDs         = []*apiextensionsv1.CustomResourceDefinition{
+	//go:embed crds/karpenter.sh_simulationpolicies.yaml
+	SimulationPolicyCRD []byte
+	//go:embed crds/karpenter.sh_provisioningdecisions.yaml
+	ProvisioningDecisionCRD []byte
+	//go:embed crds/karpenter.sh_clusterheadrooms.yaml
+	ClusterHeadroomCRD []byte
+	CRDs               = []*apiextensionsv1.CustomResourceDefinition{
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodePoolCRD),
 		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](NodeClaimCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](SimulationPolicyCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](ProvisioningDecisionCRD),
+		object.Unmarshal[apiextensionsv1.CustomResourceDefinition](ClusterHeadroomCRD),
 	}
 )
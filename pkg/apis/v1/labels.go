@@ -33,23 +33,66 @@ const (
 	ArchitectureArm64    = "arm64"
 	CapacityTypeSpot     = "spot"
 	CapacityTypeOnDemand = "on-demand"
+	CapacityTypeReserved = "reserved"
 )
 
 // Karpenter specific domains and labels
 const (
-	NodePoolLabelKey        = apis.Group + "/nodepool"
-	NodeInitializedLabelKey = apis.Group + "/initialized"
-	NodeRegisteredLabelKey  = apis.Group + "/registered"
-	CapacityTypeLabelKey    = apis.Group + "/capacity-type"
+	NodePoolLabelKey          = apis.Group + "/nodepool"
+	NodeInitializedLabelKey   = apis.Group + "/initialized"
+	NodeRegisteredLabelKey    = apis.Group + "/registered"
+	CapacityTypeLabelKey      = apis.Group + "/capacity-type"
+	DisruptionProfileLabelKey = apis.Group + "/disruption-profile"
+	CPUBurstableLabelKey      = apis.Group + "/cpu-burstable"
+)
+
+// Labels that Karpenter stamps on nodes as they move through their lifecycle, so that workloads and operators can react
+// to a stage with a label watch. Their values are Unix timestamps in seconds, since label values can't hold RFC3339.
+const (
+	NodeInitializedAtLabelKey = apis.Group + "/initialized-at"
+	NodeExpiringAtLabelKey    = apis.Group + "/expiring-at"
+	NodeDrainingSinceLabelKey = apis.Group + "/draining-since"
 )
 
 // Karpenter specific annotations
 const (
 	DoNotDisruptAnnotationKey                  = apis.Group + "/do-not-disrupt"
+	ExclusiveNodeAnnotationKey                 = apis.Group + "/exclusive-node"
+	DriftBudgetAnnotationKey                   = apis.Group + "/drift-budget"
 	ProviderCompatibilityAnnotationKey         = apis.CompatibilityGroup + "/provider"
 	NodePoolHashAnnotationKey                  = apis.Group + "/nodepool-hash"
 	NodePoolHashVersionAnnotationKey           = apis.Group + "/nodepool-hash-version"
+	NodePoolFieldHashesAnnotationKey           = apis.Group + "/nodepool-field-hashes"
 	NodeClaimTerminationTimestampAnnotationKey = apis.Group + "/nodeclaim-termination-timestamp"
+	NodeClaimRebootTimestampAnnotationKey      = apis.Group + "/nodeclaim-reboot-timestamp"
+	NodeClaimExpireAtAnnotationKey             = apis.Group + "/expire-at"
+	NodeClaimFirstPodScheduledAnnotationKey    = apis.Group + "/first-pod-scheduled"
+	AllocationStrategyAnnotationKey            = apis.Group + "/allocation-strategy"
+	CapacityFallbackAnnotationKey              = apis.Group + "/capacity-fallback"
+	DrainPodsRemainingAnnotationKey            = apis.Group + "/drain-pods-remaining"
+	DrainBlockingPDBsAnnotationKey             = apis.Group + "/drain-blocking-pdbs"
+	DrainEstimatedCompletionAnnotationKey      = apis.Group + "/drain-estimated-completion"
+	ExpectedCompletionTimeAnnotationKey        = apis.Group + "/expected-completion-time"
+	DisruptionProfileAnnotationKey             = apis.Group + "/disruption-profile-defaulted"
+	SimulateDeletionAnnotationKey              = apis.Group + "/simulate-deletion"
+	DeletionSimulationAnnotationKey            = apis.Group + "/deletion-simulation"
+	EvictionPlanAnnotationKey                  = apis.Group + "/eviction-plan"
+	EvictionPlanOrderAnnotationKey             = apis.Group + "/eviction-plan-order"
+	DryRunResultAnnotationKey                  = apis.Group + "/dry-run-result"
+	DisruptionReasonAnnotationKey              = apis.Group + "/disruption-reason"
+	AdoptAnnotationKey                         = apis.Group + "/adopt"
+	AdoptedProviderIDAnnotationKey             = apis.Group + "/adopted-provider-id"
+)
+
+// DryRunSchedulingGate gates pods that Karpenter only simulates scheduling for. The gate keeps them from being
+// scheduled, and Karpenter writes where they would have been scheduled to the karpenter.sh/dry-run-result annotation.
+const DryRunSchedulingGate = apis.Group + "/dry-run"
+
+// Allocation strategies that are hinted to the CloudProvider with the karpenter.sh/allocation-strategy annotation
+const (
+	AllocationStrategyPriceOptimized         = "price-optimized"
+	AllocationStrategyCapacityOptimized      = "capacity-optimized"
+	AllocationStrategyPriceCapacityOptimized = "price-capacity-optimized"
 )
 
 // Karpenter specific finalizers
@@ -84,6 +127,7 @@ var (
 		v1.LabelArchStable,
 		v1.LabelOSStable,
 		CapacityTypeLabelKey,
+		CPUBurstableLabelKey,
 		v1.LabelWindowsBuild,
 	)
 
@@ -93,6 +137,29 @@ var (
 		v1.LabelHostname,
 	)
 
+	// KubeletLabels are labels in the kubelet's restricted domains that the kubelet may still set on itself through
+	// its --node-labels flag
+	KubeletLabels = sets.New(
+		v1.LabelHostname,
+		v1.LabelTopologyZone,
+		v1.LabelTopologyRegion,
+		v1.LabelFailureDomainBetaZone,
+		v1.LabelFailureDomainBetaRegion,
+		v1.LabelInstanceType,
+		v1.LabelInstanceTypeStable,
+		v1.LabelOSStable,
+		v1.LabelArchStable,
+		"beta.kubernetes.io/os",
+		"beta.kubernetes.io/arch",
+	)
+
+	// KubeletLabelNamespaces are label namespaces in the kubelet's restricted domains that the kubelet may still set
+	// on itself through its --node-labels flag
+	KubeletLabelNamespaces = sets.New(
+		"kubelet.kubernetes.io",
+		v1.LabelNamespaceSuffixNode,
+	)
+
 	// NormalizedLabels translate aliased concepts into the controller's
 	// WellKnownLabels. Pod requirements are translated for compatibility.
 	NormalizedLabels = map[string]string{
@@ -136,6 +203,23 @@ func IsRestrictedNodeLabel(key string) bool {
 	return RestrictedLabels.Has(key)
 }
 
+// IsKubeletLabel returns true if the kubelet accepts the label through its --node-labels flag. The kubelet refuses
+// labels in the kubernetes.io and k8s.io domains unless they're one of the KubeletLabels or in one of the
+// KubeletLabelNamespaces, e.g. node-role.kubernetes.io or node-restriction.kubernetes.io labels.
+func IsKubeletLabel(key string) bool {
+	if KubeletLabels.Has(key) {
+		return true
+	}
+	labelDomain := GetLabelDomain(key)
+	for namespace := range KubeletLabelNamespaces {
+		if labelDomain == namespace || strings.HasSuffix(labelDomain, "."+namespace) {
+			return true
+		}
+	}
+	return labelDomain != "kubernetes.io" && !strings.HasSuffix(labelDomain, ".kubernetes.io") &&
+		labelDomain != "k8s.io" && !strings.HasSuffix(labelDomain, ".k8s.io")
+}
+
 func GetLabelDomain(key string) string {
 	if parts := strings.SplitN(key, "/", 2); len(parts) == 2 {
 		return parts[0]
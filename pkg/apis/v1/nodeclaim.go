@@ -32,6 +32,8 @@ type NodeClaimSpec struct {
 	// within a short period of time, typically by a DaemonSet that tolerates the taint. These are commonly used by
 	// daemonsets to allow initialization and enforce startup ordering.  StartupTaints are ignored for provisioning
 	// purposes in that pods are not required to tolerate a StartupTaint in order to have nodes provisioned for them.
+	// The karpenter.sh/uninitialized:NoSchedule StartupTaint is removed by Karpenter once the node is otherwise initialized,
+	// so that only pods tolerating it, such as bootstrap-critical DaemonSets, schedule to the node before then.
 	// +optional
 	StartupTaints []v1.Taint `json:"startupTaints,omitempty"`
 	// Requirements are layered with GetLabels and applied to every node.
@@ -74,8 +76,54 @@ type NodeClaimSpec struct {
 	// +kubebuilder:validation:Schemaless
 	// +optional
 	ExpireAfter NillableDuration `json:"expireAfter,omitempty"`
+	// PlacementGroup is a hint to the CloudProvider to launch the NodeClaim on hardware that's co-located with the other
+	// NodeClaims in the same placement group, for tightly-coupled workloads that need low latency networking between
+	// their nodes. The nodes of a placement group are torn down together: consolidation only removes them once all of
+	// them are empty.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	PlacementGroup string `json:"placementGroup,omitempty"`
+	// RegistrationTimeout is the duration the controller will wait for a launched NodeClaim's node to register
+	// before deleting the NodeClaim and launching a replacement. This overrides the controller's default of 15m, and
+	// is useful for nodes with long bootstrap times, such as GPU nodes that install large drivers on startup.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:validation:XValidation:message="registrationTimeout must be at least 1m",rule="duration(self) >= duration('1m')"
+	// +optional
+	RegistrationTimeout *metav1.Duration `json:"registrationTimeout,omitempty"`
+	// TerminationPolicy is the action taken once the NodeClaim expires. Replace deletes the NodeClaim and launches
+	// replacement capacity for its pods if they don't fit elsewhere, Delete only deletes the NodeClaim once its pods
+	// fit on other nodes, and Hold cordons the node and leaves it for manual action. Defaults to Replace.
+	// +kubebuilder:validation:Enum:={Replace,Delete,Hold}
+	// +optional
+	TerminationPolicy TerminationPolicy `json:"terminationPolicy,omitempty"`
+	// TemplateVariables are resolved by the controller when the NodeClaim is created and passed to the CloudProvider
+	// so that it can interpolate them into bootstrap configuration. See the TemplateVariable* keys for the variables
+	// that are populated.
+	// +optional
+	TemplateVariables map[string]string `json:"templateVariables,omitempty"`
 }
 
+// Keys of the template variables populated on NodeClaims. Labels and taints are serialized in the formats accepted by
+// the kubelet's --node-labels and --register-with-taints flags.
+const (
+	TemplateVariableClusterName   = "clusterName"
+	TemplateVariableNodePool      = "nodePool"
+	TemplateVariableLabels        = "labels"
+	TemplateVariableTaints        = "taints"
+	TemplateVariableStartupTaints = "startupTaints"
+)
+
+// TerminationPolicy is the action taken once a NodeClaim expires
+type TerminationPolicy string
+
+const (
+	TerminationPolicyReplace TerminationPolicy = "Replace"
+	TerminationPolicyDelete  TerminationPolicy = "Delete"
+	TerminationPolicyHold    TerminationPolicy = "Hold"
+)
+
 // A node selector requirement with min values is a selector that contains values, a key, an operator that relates the key and values
 // and minValues that represent the requirement to have at least that many values.
 type NodeSelectorRequirementWithMinValues struct {
@@ -30,6 +30,9 @@ const (
 	ConditionTypeDrifted              = "Drifted"
 	ConditionTypeInstanceTerminating  = "InstanceTerminating"
 	ConditionTypeConsistentStateFound = "ConsistentStateFound"
+	ConditionTypeExpired              = "Expired"
+	ConditionTypeProviderIDMismatch   = "ProviderIDMismatch"
+	ConditionTypeDisruptionObserved   = "DisruptionObserved"
 )
 
 // NodeClaimStatus defines the observed state of NodeClaim
@@ -52,11 +55,40 @@ type NodeClaimStatus struct {
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []status.Condition `json:"conditions,omitempty"`
+	// DriftedFields are the fields that differ between the NodeClaim and its NodePool or NodeClass while the
+	// NodeClaim is Drifted
+	// +optional
+	DriftedFields []string `json:"driftedFields,omitempty"`
 	// LastPodEventTime is updated with the last time a pod was scheduled
 	// or removed from the node. A pod going terminal or terminating
 	// is also considered as removed.
 	// +optional
 	LastPodEventTime metav1.Time `json:"lastPodEventTime,omitempty"`
+	// Provenance records how the NodeClaim came to be created
+	// +optional
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance is a record of the inputs that the NodeClaim was created from, intended for auditing
+type Provenance struct {
+	// ControllerVersion is the version of the controller that created the NodeClaim
+	// +optional
+	ControllerVersion string `json:"controllerVersion,omitempty"`
+	// NodePoolGeneration is the generation of the NodePool when the NodeClaim was created
+	// +optional
+	NodePoolGeneration int64 `json:"nodePoolGeneration,omitempty"`
+	// NodePoolHash is the static drift hash of the NodePool when the NodeClaim was created
+	// +optional
+	NodePoolHash string `json:"nodePoolHash,omitempty"`
+	// NodeClassHash is a hash of the NodeClass spec when the NodeClaim was created
+	// +optional
+	NodeClassHash string `json:"nodeClassHash,omitempty"`
+	// RequesterPodsDigest is a sha256 digest of the pods that the NodeClaim was launched for
+	// +optional
+	RequesterPodsDigest string `json:"requesterPodsDigest,omitempty"`
+	// Digest is an unsigned sha256 digest over the other provenance fields and the NodeClaim's identity. It detects
+	// accidental changes to the record, but doesn't authenticate it.
+	Digest string `json:"digest"`
 }
 
 func (in *NodeClaim) StatusConditions() status.ConditionSet {
@@ -253,4 +253,18 @@ var _ = Describe("Validation", func() {
 			Expect(env.Client.Create(ctx, nodeClaim)).ToNot(Succeed())
 		})
 	})
+	Context("PlacementGroup", func() {
+		It("should succeed on a valid placement group", func() {
+			nodeClaim.Spec.PlacementGroup = "training-cluster.1"
+			Expect(env.Client.Create(ctx, nodeClaim)).To(Succeed())
+		})
+		It("should fail on an invalid placement group", func() {
+			nodeClaim.Spec.PlacementGroup = "-training/cluster"
+			Expect(env.Client.Create(ctx, nodeClaim)).ToNot(Succeed())
+		})
+		It("should fail on a placement group that's too long", func() {
+			nodeClaim.Spec.PlacementGroup = strings.Repeat("a", 64)
+			Expect(env.Client.Create(ctx, nodeClaim)).ToNot(Succeed())
+		})
+	})
 })
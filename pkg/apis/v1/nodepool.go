@@ -17,15 +17,19 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/robfig/cron/v3"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/clock"
@@ -44,9 +48,28 @@ type NodePoolSpec struct {
 	// +kubebuilder:default:={consolidateAfter: "0s"}
 	// +optional
 	Disruption Disruption `json:"disruption"`
+	// Headroom is the number of vacant nodes, which have no pods other than daemonsets, that Karpenter keeps launched
+	// from the NodePool so that pods can start on them immediately. It's either a number of nodes or a percentage of
+	// the NodePool's nodes that have pods, rounded up. Vacant nodes are replenished once pods fill them, and
+	// consolidation won't remove vacant nodes that are part of the headroom. The NodePool's limits still apply.
+	// +kubebuilder:validation:Pattern:="^((100|[0-9]{1,2})%|[0-9]+)$"
+	// +optional
+	Headroom string `json:"headroom,omitempty" hash:"ignore"`
 	// Limits define a set of bounds for provisioning capacity.
 	// +optional
 	Limits Limits `json:"limits,omitempty"`
+	// ZoneLimits bound the capacity that the NodePool provisions in each zone, e.g. at most 100 CPUs in every zone, so
+	// that capacity that can't be launched in one zone doesn't concentrate the NodePool's limits in the others. Capacity
+	// that's still being launched counts towards every zone that it could launch into.
+	// +optional
+	ZoneLimits Limits `json:"zoneLimits,omitempty"`
+	// MinNodes is the number of nodes that Karpenter keeps launched from the NodePool, even when there are no pods
+	// for them, so that bursts of pods can start without waiting on new capacity. Nodes are launched from the
+	// template to make up any shortfall, and consolidation won't take the NodePool below it. The NodePool's limits
+	// still apply.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	MinNodes *int32 `json:"minNodes,omitempty" hash:"ignore"`
 	// Weight is the priority given to the nodepool during scheduling. A higher
 	// numerical weight indicates that this nodepool will be ordered
 	// ahead of other nodepools with lower weights. A nodepool with no weight
@@ -55,6 +78,165 @@ type NodePoolSpec struct {
 	// +kubebuilder:validation:Maximum:=100
 	// +optional
 	Weight *int32 `json:"weight,omitempty"`
+	// CapacityFallback changes how the NodePool launches nodes after repeated insufficient capacity errors. The
+	// CloudProvider is first asked to prefer capacity-optimized over price-optimized allocation, and if launches keep
+	// failing, nodes are launched from a last resort set of requirements. The NodePool goes back to launching nodes as
+	// usual once the errors stop.
+	// +optional
+	CapacityFallback *CapacityFallback `json:"capacityFallback,omitempty"`
+	// MetadataFidelity decides what happens when the labels or taints that a node was launched with are changed or
+	// removed out-of-band, e.g. by another controller. Labels and taints without a policy are restored.
+	// +kubebuilder:validation:MaxItems:=100
+	// +optional
+	MetadataFidelity []MetadataFidelityPolicy `json:"metadataFidelity,omitempty"`
+	// Repair is a list of node conditions, like NotReady or conditions set by node-problem-detector, that mark the
+	// NodePool's nodes as unhealthy. Nodes that have been unhealthy for a policy's toleration duration have their
+	// NodeClaims deleted and replaced. Policies take precedence over the CloudProvider's policies for the same
+	// condition type. Requires the NodeRepair feature gate.
+	// +kubebuilder:validation:XValidation:message="'conditionType' must be unique",rule="self.all(x, self.exists_one(y, x.conditionType == y.conditionType))"
+	// +kubebuilder:validation:MaxItems:=20
+	// +optional
+	Repair []NodeRepairPolicy `json:"repair,omitempty" hash:"ignore"`
+	// SpotDiversification is the minimum number of instance types and zones that the spot offerings of each NodeClaim
+	// are spread across when it's launched. Instance types sent to the CloudProvider are normally the cheapest that
+	// fit, which can leave spot launches concentrated in a few capacity pools that are interrupted together.
+	// +optional
+	SpotDiversification *SpotDiversification `json:"spotDiversification,omitempty"`
+	// BurstableCPU decides how pods are packed onto burstable instance types, which can burst above the CPU that they're
+	// able to sustain. Peak packs pods against their full CPU capacity, while Baseline packs them against their
+	// baseline CPU so that pods aren't throttled once the instance runs out of burst. Defaults to Peak.
+	// +kubebuilder:validation:Enum:={Baseline,Peak}
+	// +optional
+	BurstableCPU BurstableCPUPacking `json:"burstableCPU,omitempty" hash:"ignore"`
+	// BinPacking decides how pods are packed onto the NodeClaims launched for them. FewestNodes packs a pod onto any
+	// NodeClaim that an instance type can still fit it on. LowestPrice only packs a pod onto a NodeClaim if that raises
+	// the NodeClaim's price by less than a new NodeClaim for the pod would cost. LeastWaste packs a pod onto the NodeClaim
+	// it fits most tightly, and Balanced packs like LeastWaste while keeping to the price bound of LowestPrice. Defaults
+	// to the strategy that Karpenter was configured with.
+	// +kubebuilder:validation:Enum:={FewestNodes,LowestPrice,LeastWaste,Balanced}
+	// +optional
+	BinPacking BinPackingStrategy `json:"binPacking,omitempty" hash:"ignore"`
+	// SpotAllocationStrategy decides how spot instance types are chosen for the NodeClaims launched for the NodePool.
+	// LowestPrice prefers the cheapest spot offerings, while PriceCapacityOptimized weighs the price of each spot offering
+	// by how likely it is to be interrupted, so that slightly more expensive but more stable offerings are preferred.
+	// Interruption likelihood is only known if the CloudProvider reports it. Defaults to LowestPrice.
+	// +kubebuilder:validation:Enum:={LowestPrice,PriceCapacityOptimized}
+	// +optional
+	SpotAllocationStrategy SpotAllocationStrategy `json:"spotAllocationStrategy,omitempty" hash:"ignore"`
+}
+
+type BurstableCPUPacking string
+
+const (
+	BurstableCPUPackingBaseline BurstableCPUPacking = "Baseline"
+	BurstableCPUPackingPeak     BurstableCPUPacking = "Peak"
+)
+
+type BinPackingStrategy string
+
+const (
+	BinPackingStrategyFewestNodes BinPackingStrategy = "FewestNodes"
+	BinPackingStrategyLowestPrice BinPackingStrategy = "LowestPrice"
+	BinPackingStrategyLeastWaste  BinPackingStrategy = "LeastWaste"
+	BinPackingStrategyBalanced    BinPackingStrategy = "Balanced"
+)
+
+type SpotAllocationStrategy string
+
+const (
+	SpotAllocationStrategyLowestPrice            SpotAllocationStrategy = "LowestPrice"
+	SpotAllocationStrategyPriceCapacityOptimized SpotAllocationStrategy = "PriceCapacityOptimized"
+)
+
+// SpotDiversification is a floor on the diversity of the spot offerings that a NodeClaim is launched with. Like
+// minValues, it's enforced on the set of instance types passed to the CloudProvider, but it's a preference rather
+// than a requirement: NodeClaims that can't meet it are still launched, and an event is emitted for them.
+type SpotDiversification struct {
+	// MinInstanceTypes is the minimum number of instance types with an available spot offering
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=60
+	// +optional
+	MinInstanceTypes int32 `json:"minInstanceTypes,omitempty"`
+	// MinZones is the minimum number of zones with an available spot offering
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	MinZones int32 `json:"minZones,omitempty"`
+}
+
+// MetadataFidelityPolicy is the action taken when a node's label or taint with the given key no longer matches its
+// NodeClaim
+type MetadataFidelityPolicy struct {
+	// Key is the label or taint key that the policy applies to
+	// +kubebuilder:validation:MaxLength:=316
+	// +required
+	Key string `json:"key"`
+	// Action is Restore, which puts the label or taint back on the node, Drift, which replaces the node, or Ignore.
+	// +kubebuilder:validation:Enum:={Restore,Drift,Ignore}
+	// +required
+	Action MetadataFidelityAction `json:"action"`
+}
+
+type MetadataFidelityAction string
+
+const (
+	MetadataFidelityActionRestore MetadataFidelityAction = "Restore"
+	MetadataFidelityActionDrift   MetadataFidelityAction = "Drift"
+	MetadataFidelityActionIgnore  MetadataFidelityAction = "Ignore"
+)
+
+// NodeRepairPolicy is a node condition that marks a node as unhealthy, and how long it's tolerated for before the node
+// is repaired
+type NodeRepairPolicy struct {
+	// ConditionType is the type of the node condition
+	// +kubebuilder:validation:MinLength:=1
+	// +kubebuilder:validation:MaxLength:=316
+	// +required
+	ConditionType v1.NodeConditionType `json:"conditionType"`
+	// ConditionStatus is the status of the node condition when the node is unhealthy
+	// +kubebuilder:validation:Enum:={True,False,Unknown}
+	// +required
+	ConditionStatus v1.ConditionStatus `json:"conditionStatus"`
+	// TolerationDuration is how long the node condition has to have had the status for before the node is repaired
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +required
+	TolerationDuration metav1.Duration `json:"tolerationDuration"`
+}
+
+// CapacityFallback configures how a NodePool responds to insufficient capacity errors
+type CapacityFallback struct {
+	// InsufficientCapacityThreshold is the number of insufficient capacity errors within the Window that moves the
+	// NodePool on to the next fallback stage.
+	// +kubebuilder:default:=3
+	// +kubebuilder:validation:Minimum:=1
+	// +optional
+	InsufficientCapacityThreshold int32 `json:"insufficientCapacityThreshold,omitempty"`
+	// Window is the period that insufficient capacity errors are counted over. The fallback lapses once a Window
+	// passes without any insufficient capacity errors.
+	// +kubebuilder:default:="10m"
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+	// LastResortRequirements replace the template's requirements when capacity-optimized launches are also failing,
+	// e.g. to allow other instance families or capacity types. NodeClaims launched from them aren't drifted by
+	// the template's requirements until the fallback lapses.
+	// +kubebuilder:validation:MaxItems:=100
+	// +optional
+	LastResortRequirements []NodeSelectorRequirementWithMinValues `json:"lastResortRequirements,omitempty"`
+}
+
+const (
+	defaultInsufficientCapacityThreshold = 3
+	defaultCapacityFallbackWindow        = 10 * time.Minute
+)
+
+func (in *CapacityFallback) threshold() int32 {
+	return lo.Ternary(in.InsufficientCapacityThreshold > 0, in.InsufficientCapacityThreshold, defaultInsufficientCapacityThreshold)
+}
+
+func (in *CapacityFallback) window() time.Duration {
+	return lo.Ternary(in.Window != nil, lo.FromPtr(in.Window).Duration, defaultCapacityFallbackWindow)
 }
 
 type Disruption struct {
@@ -72,6 +254,18 @@ type Disruption struct {
 	// +kubebuilder:validation:Enum:={WhenEmpty,WhenEmptyOrUnderutilized}
 	// +optional
 	ConsolidationPolicy ConsolidationPolicy `json:"consolidationPolicy,omitempty"`
+	// ConsolidationObjective is what consolidation optimizes for when choosing which underutilized nodes to consolidate
+	// first. Cost prefers the nodes that are cheapest to disrupt, while LeastDisruption prefers the nodes that move the
+	// fewest pods per dollar saved, for NodePools where each pod restart has a cost of its own. Defaults to Cost.
+	// +kubebuilder:validation:Enum:={Cost,LeastDisruption}
+	// +optional
+	ConsolidationObjective ConsolidationObjective `json:"consolidationObjective,omitempty" hash:"ignore"`
+	// ConsolidationThreshold is the estimated savings that consolidating underutilized nodes must exceed. It's either a
+	// percentage of the price of the nodes being consolidated, or an absolute hourly price delta that applies to each
+	// node consolidated. Empty nodes are consolidated regardless, since removing them doesn't restart any pods.
+	// +kubebuilder:validation:Pattern:=`^((100|[0-9]{1,2})%|[0-9]+(\.[0-9]+)?)$`
+	// +optional
+	ConsolidationThreshold string `json:"consolidationThreshold,omitempty" hash:"ignore"`
 	// Budgets is a list of Budgets.
 	// If there are multiple active budgets, Karpenter uses
 	// the most restrictive value. If left undefined,
@@ -81,6 +275,50 @@ type Disruption struct {
 	// +kubebuilder:validation:MaxItems=50
 	// +optional
 	Budgets []Budget `json:"budgets,omitempty" hash:"ignore"`
+	// MaintenanceWindows restricts voluntary disruption to recurring windows. Disruption for a reason that one or more
+	// windows apply to is denied unless one of those windows is open, and budgets still apply inside an open window.
+	// Reasons that no window applies to aren't restricted. If left undefined, disruption isn't restricted to windows.
+	// +kubebuilder:validation:XValidation:message="'name' must be unique",rule="self.all(x, self.exists_one(y, x.name == y.name))"
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty" hash:"ignore"`
+	// MinNodes is a list of per capacity type minimums. Consolidation won't remove nodes of a listed
+	// capacity type from the NodePool, even if they're empty, once the number of nodes of that capacity type
+	// is at or below the minimum. Capacity types which aren't listed can be consolidated down to zero.
+	// +kubebuilder:validation:XValidation:message="'capacityType' must be unique",rule="self.all(x, self.exists_one(y, x.capacityType == y.capacityType))"
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	MinNodes []CapacityTypeMinimum `json:"minNodes,omitempty" hash:"ignore"`
+	// Priority orders drift disruption across NodePools. Drifted nodes of NodePools with a lower priority are
+	// disrupted before those of NodePools with a higher priority, so NodePools running critical infrastructure
+	// should be given the highest priority to be disrupted last. NodePools default to a priority of 0.
+	// +optional
+	Priority int32 `json:"priority,omitempty" hash:"ignore"`
+	// Mode is whether Karpenter acts on its disruption decisions for the NodePool. In ObserveOnly mode, Karpenter still
+	// computes which nodes it would consolidate, drift or expire, and reports them through events, metrics and the
+	// DisruptionObserved NodeClaim condition, but never taints or deletes them. Defaults to Enforce.
+	// +kubebuilder:validation:Enum:={Enforce,ObserveOnly}
+	// +optional
+	Mode DisruptionMode `json:"mode,omitempty" hash:"ignore"`
+	// WarmupPeriod is how long after a node is initialized that its pod activity is ignored for consolidation. Pods
+	// churning while workloads warm up don't reset the node's ConsolidateAfter timer, and a node that goes quiet right
+	// after warming up is only considered for consolidation once ConsolidateAfter passes after the WarmupPeriod.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	WarmupPeriod *metav1.Duration `json:"warmupPeriod,omitempty" hash:"ignore"`
+}
+
+// CapacityTypeMinimum is the minimum number of nodes of a capacity type that consolidation keeps in a NodePool
+type CapacityTypeMinimum struct {
+	// CapacityType is the value of the karpenter.sh/capacity-type label this minimum applies to
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	CapacityType string `json:"capacityType" hash:"ignore"`
+	// Nodes is the number of nodes of the capacity type that consolidation won't remove
+	// +kubebuilder:validation:Minimum:=0
+	// +required
+	Nodes int32 `json:"nodes" hash:"ignore"`
 }
 
 // Budget defines when Karpenter will restrict the
@@ -88,7 +326,7 @@ type Disruption struct {
 type Budget struct {
 	// Reasons is a list of disruption methods that this budget applies to. If Reasons is not set, this budget applies to all methods.
 	// Otherwise, this will apply to each reason defined.
-	// allowed reasons are Underutilized, Empty, and Drifted.
+	// allowed reasons are Underutilized, Empty, Drifted, and Rebalanced.
 	// +optional
 	Reasons []DisruptionReason `json:"reasons,omitempty"`
 	// Nodes dictates the maximum number of NodeClaims owned by this NodePool
@@ -101,6 +339,13 @@ type Budget struct {
 	// +kubebuilder:validation:Pattern:="^((100|[0-9]{1,2})%|[0-9]+)$"
 	// +kubebuilder:default:="10%"
 	Nodes string `json:"nodes" hash:"ignore"`
+	// Resources dictates the maximum amount of each resource, summed over the allocatable of the
+	// NodeClaims owned by this NodePool, that can be terminating at once. Values are either a quantity
+	// (e.g. "64" for cpu or "256Gi" for memory) or a percentage of the NodePool's allocatable. This
+	// applies in addition to Nodes, so that pools with a wide range of node sizes can be budgeted by capacity.
+	// +kubebuilder:validation:XValidation:message="only cpu and memory can be budgeted",rule="self.all(x, x in ['cpu', 'memory'])"
+	// +optional
+	Resources map[v1.ResourceName]string `json:"resources,omitempty" hash:"ignore"`
 	// Schedule specifies when a budget begins being active, following
 	// the upstream cronjob syntax. If omitted, the budget is always active.
 	// Timezones are not supported.
@@ -120,6 +365,33 @@ type Budget struct {
 	Duration *metav1.Duration `json:"duration,omitempty" hash:"ignore"`
 }
 
+// MaintenanceWindow is a recurring window in which Karpenter may voluntarily disrupt the nodes of a NodePool.
+type MaintenanceWindow struct {
+	// Name identifies the window, e.g. in events and logs.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +required
+	Name string `json:"name" hash:"ignore"`
+	// Reasons is a list of disruption methods that this window applies to. If Reasons is not set, this window applies to all methods.
+	// +optional
+	Reasons []DisruptionReason `json:"reasons,omitempty" hash:"ignore"`
+	// Schedule specifies when the window opens, following the upstream cronjob syntax. It's evaluated in the
+	// window's TimeZone.
+	// +kubebuilder:validation:Pattern:=`^(@(annually|yearly|monthly|weekly|daily|midnight|hourly))|((.+)\s(.+)\s(.+)\s(.+)\s(.+))$`
+	// +required
+	Schedule string `json:"schedule" hash:"ignore"`
+	// Duration determines how long the window stays open since each Schedule hit.
+	// Only minutes and hours are accepted, as cron does not work in seconds.
+	// +kubebuilder:validation:Pattern=`^((([0-9]+(h|m))|([0-9]+h[0-9]+m))(0s)?)$`
+	// +kubebuilder:validation:Type="string"
+	// +required
+	Duration metav1.Duration `json:"duration" hash:"ignore"`
+	// TimeZone is the IANA time zone name that the Schedule is evaluated in, e.g. "Europe/Berlin". Defaults to UTC.
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty" hash:"ignore"`
+}
+
 type ConsolidationPolicy string
 
 const (
@@ -127,14 +399,31 @@ const (
 	ConsolidationPolicyWhenEmptyOrUnderutilized ConsolidationPolicy = "WhenEmptyOrUnderutilized"
 )
 
+// ConsolidationObjective is what consolidation optimizes for when ordering a NodePool's nodes
+type ConsolidationObjective string
+
+const (
+	ConsolidationObjectiveCost            ConsolidationObjective = "Cost"
+	ConsolidationObjectiveLeastDisruption ConsolidationObjective = "LeastDisruption"
+)
+
+// DisruptionMode is whether Karpenter acts on the disruption decisions it makes for a NodePool
+type DisruptionMode string
+
+const (
+	DisruptionModeEnforce     DisruptionMode = "Enforce"
+	DisruptionModeObserveOnly DisruptionMode = "ObserveOnly"
+)
+
 // DisruptionReason defines valid reasons for disruption budgets.
-// +kubebuilder:validation:Enum={Underutilized,Empty,Drifted}
+// +kubebuilder:validation:Enum={Underutilized,Empty,Drifted,Rebalanced}
 type DisruptionReason string
 
 const (
 	DisruptionReasonUnderutilized DisruptionReason = "Underutilized"
 	DisruptionReasonEmpty         DisruptionReason = "Empty"
 	DisruptionReasonDrifted       DisruptionReason = "Drifted"
+	DisruptionReasonRebalanced    DisruptionReason = "Rebalanced"
 )
 
 type Limits v1.ResourceList
@@ -170,6 +459,8 @@ type NodeClaimTemplateSpec struct {
 	// within a short period of time, typically by a DaemonSet that tolerates the taint. These are commonly used by
 	// daemonsets to allow initialization and enforce startup ordering.  StartupTaints are ignored for provisioning
 	// purposes in that pods are not required to tolerate a StartupTaint in order to have nodes provisioned for them.
+	// The karpenter.sh/uninitialized:NoSchedule StartupTaint is removed by Karpenter once the node is otherwise initialized,
+	// so that only pods tolerating it, such as bootstrap-critical DaemonSets, schedule to the node before then.
 	// +optional
 	StartupTaints []v1.Taint `json:"startupTaints,omitempty"`
 	// Requirements are layered with GetLabels and applied to every node.
@@ -211,6 +502,28 @@ type NodeClaimTemplateSpec struct {
 	// +kubebuilder:validation:Schemaless
 	// +optional
 	ExpireAfter NillableDuration `json:"expireAfter,omitempty"`
+	// PlacementGroup is a hint to the CloudProvider to launch the NodeClaim on hardware that's co-located with the other
+	// NodeClaims in the same placement group, for tightly-coupled workloads that need low latency networking between
+	// their nodes. The nodes of a placement group are torn down together: consolidation only removes them once all of
+	// them are empty.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([-a-zA-Z0-9_.]*[a-zA-Z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	PlacementGroup string `json:"placementGroup,omitempty"`
+	// RegistrationTimeout is the duration the controller will wait for a launched NodeClaim's node to register
+	// before deleting the NodeClaim and launching a replacement. This overrides the controller's default of 15m, and
+	// is useful for nodes with long bootstrap times, such as GPU nodes that install large drivers on startup.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +kubebuilder:validation:XValidation:message="registrationTimeout must be at least 1m",rule="duration(self) >= duration('1m')"
+	// +optional
+	RegistrationTimeout *metav1.Duration `json:"registrationTimeout,omitempty" hash:"ignore"`
+	// TerminationPolicy is the action taken once the NodeClaim expires. Replace deletes the NodeClaim and launches
+	// replacement capacity for its pods if they don't fit elsewhere, Delete only deletes the NodeClaim once its pods
+	// fit on other nodes, and Hold cordons the node and leaves it for manual action. Defaults to Replace.
+	// +kubebuilder:validation:Enum:={Replace,Delete,Hold}
+	// +optional
+	TerminationPolicy TerminationPolicy `json:"terminationPolicy,omitempty"`
 }
 
 // This is used to convert between the NodeClaim's NodeClaimSpec to the Nodepool NodeClaimTemplate's NodeClaimSpec.
@@ -227,6 +540,9 @@ func (in *NodeClaimTemplate) ToNodeClaim() *NodeClaim {
 			NodeClassRef:           in.Spec.NodeClassRef,
 			TerminationGracePeriod: in.Spec.TerminationGracePeriod,
 			ExpireAfter:            in.Spec.ExpireAfter,
+			PlacementGroup:         in.Spec.PlacementGroup,
+			RegistrationTimeout:    in.Spec.RegistrationTimeout,
+			TerminationPolicy:      in.Spec.TerminationPolicy,
 		},
 	}
 }
@@ -245,6 +561,14 @@ type ObjectMeta struct {
 	// More info: https://kubernetes.io/docs/concepts/overview/working-with-objects/annotations
 	// +optional
 	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// PropagateNodePoolLabels is a list of label keys on the NodePool itself whose values are copied onto the NodeClaims
+	// and nodes that it launches, so that dimensions like cost allocation can be managed in one place. CloudProviders may
+	// apply them to instances as well, e.g. as tags. Labels set in the template take precedence, and labels in restricted
+	// domains are never propagated. Since these labels are only applied at launch, changing them doesn't drift nodes.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	PropagateNodePoolLabels []string `json:"propagateNodePoolLabels,omitempty" hash:"ignore"`
 }
 
 // NodePool is the Schema for the NodePools API
@@ -258,6 +582,8 @@ type ObjectMeta struct {
 // +kubebuilder:printcolumn:name="Weight",type="integer",JSONPath=".spec.weight",priority=1,description=""
 // +kubebuilder:printcolumn:name="CPU",type="string",JSONPath=".status.resources.cpu",priority=1,description=""
 // +kubebuilder:printcolumn:name="Memory",type="string",JSONPath=".status.resources.memory",priority=1,description=""
+// +kubebuilder:printcolumn:name="Launching",type="integer",JSONPath=".status.nodeCounts.launching",priority=1,description=""
+// +kubebuilder:printcolumn:name="Draining",type="integer",JSONPath=".status.nodeCounts.draining",priority=1,description=""
 // +kubebuilder:subresource:status
 type NodePool struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -272,7 +598,7 @@ type NodePool struct {
 // 1. A field changes its default value for an existing field that is already hashed
 // 2. A field is added to the hash calculation with an already-set value
 // 3. A field is removed from the hash calculations
-const NodePoolHashVersion = "v3"
+const NodePoolHashVersion = "v4"
 
 func (in *NodePool) Hash() string {
 	return fmt.Sprint(lo.Must(hashstructure.Hash(in.Spec.Template, hashstructure.FormatV2, &hashstructure.HashOptions{
@@ -282,6 +608,93 @@ func (in *NodePool) Hash() string {
 	})))
 }
 
+// FieldHashes returns a hash of each field that's included in the NodePool's Hash, keyed by the field's path. These
+// are stored alongside the hash on NodeClaims so that static drift can be traced back to the fields that changed.
+func (in *NodePool) FieldHashes() map[string]string {
+	hash := func(v any) string {
+		return fmt.Sprint(lo.Must(hashstructure.Hash(v, hashstructure.FormatV2, &hashstructure.HashOptions{
+			SlicesAsSets:    true,
+			IgnoreZeroValue: true,
+			ZeroNil:         true,
+		})))
+	}
+	return map[string]string{
+		"spec.template.metadata.labels":             hash(in.Spec.Template.Labels),
+		"spec.template.metadata.annotations":        hash(in.Spec.Template.Annotations),
+		"spec.template.spec.taints":                 hash(in.Spec.Template.Spec.Taints),
+		"spec.template.spec.startupTaints":          hash(in.Spec.Template.Spec.StartupTaints),
+		"spec.template.spec.nodeClassRef":           hash(in.Spec.Template.Spec.NodeClassRef),
+		"spec.template.spec.terminationGracePeriod": hash(in.Spec.Template.Spec.TerminationGracePeriod),
+		"spec.template.spec.expireAfter":            hash(in.Spec.Template.Spec.ExpireAfter),
+		"spec.template.spec.terminationPolicy":      hash(in.Spec.Template.Spec.TerminationPolicy),
+		"spec.template.spec.placementGroup":         hash(in.Spec.Template.Spec.PlacementGroup),
+	}
+}
+
+// PropagatedLabels returns the NodePool's own labels that are selected by spec.template.metadata.propagateNodePoolLabels
+func (in *NodePool) PropagatedLabels() map[string]string {
+	labels := map[string]string{}
+	for _, key := range in.Spec.Template.PropagateNodePoolLabels {
+		if value, ok := in.Labels[key]; ok && !IsRestrictedNodeLabel(key) {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// MetadataFidelityAction returns the action that's taken when the node label or taint with the given key has been
+// changed out-of-band
+func (in *NodePool) MetadataFidelityAction(key string) MetadataFidelityAction {
+	if policy, ok := lo.Find(in.Spec.MetadataFidelity, func(p MetadataFidelityPolicy) bool { return p.Key == key }); ok {
+		return policy.Action
+	}
+	return MetadataFidelityActionRestore
+}
+
+// CapacityFallbackStage returns the capacity fallback stage that the NodePool should launch nodes with, which lapses
+// once a Window passes without an insufficient capacity error
+func (in *NodePool) CapacityFallbackStage(now time.Time) CapacityFallbackStage {
+	if in.Spec.CapacityFallback == nil || in.Status.CapacityFallback == nil {
+		return CapacityFallbackStageNone
+	}
+	if !now.Before(in.Status.CapacityFallback.LastInsufficientCapacityTime.Add(in.Spec.CapacityFallback.window())) {
+		return CapacityFallbackStageNone
+	}
+	return in.Status.CapacityFallback.Stage
+}
+
+// RecordInsufficientCapacity counts an insufficient capacity error in the NodePool's status, moving on to the next
+// capacity fallback stage when the errors reach the threshold within a Window
+func (in *NodePool) RecordInsufficientCapacity(now time.Time) {
+	if in.Spec.CapacityFallback == nil {
+		return
+	}
+	fallback := lo.FromPtr(in.Status.CapacityFallback)
+	fallback.Stage = in.CapacityFallbackStage(now)
+	if !now.Before(fallback.WindowStart.Add(in.Spec.CapacityFallback.window())) {
+		fallback.WindowStart = metav1.NewTime(now)
+		fallback.InsufficientCapacityErrors = 0
+	}
+	fallback.InsufficientCapacityErrors++
+	fallback.LastInsufficientCapacityTime = metav1.NewTime(now)
+	if fallback.InsufficientCapacityErrors >= in.Spec.CapacityFallback.threshold() {
+		switch {
+		case fallback.Stage == CapacityFallbackStageNone:
+			fallback.Stage = CapacityFallbackStageCapacityOptimized
+		case fallback.Stage == CapacityFallbackStageCapacityOptimized && len(in.Spec.CapacityFallback.LastResortRequirements) > 0:
+			fallback.Stage = CapacityFallbackStageLastResort
+		}
+		fallback.WindowStart = metav1.NewTime(now)
+		fallback.InsufficientCapacityErrors = 0
+	}
+	in.Status.CapacityFallback = &fallback
+}
+
+// FieldHashesAnnotation serializes the NodePool's FieldHashes for the karpenter.sh/nodepool-field-hashes annotation
+func (in *NodePool) FieldHashesAnnotation() string {
+	return string(lo.Must(json.Marshal(in.FieldHashes())))
+}
+
 // NodePoolList contains a list of NodePool
 // +kubebuilder:object:root=true
 type NodePoolList struct {
@@ -303,6 +716,11 @@ func (in *NodePool) MustGetAllowedDisruptions(c clock.Clock, numNodes int, reaso
 
 // GetAllowedDisruptionsByReason returns the minimum allowed disruptions across all disruption budgets, for all disruption methods for a given nodepool
 func (in *NodePool) GetAllowedDisruptionsByReason(c clock.Clock, numNodes int, reason DisruptionReason) (int, error) {
+	open, err := in.Spec.Disruption.InMaintenanceWindow(c, reason)
+	// If a maintenance window is misconfigured, fail closed.
+	if err != nil || !open {
+		return 0, err
+	}
 	allowedNodes := math.MaxInt32
 	var multiErr error
 	for _, budget := range in.Spec.Disruption.Budgets {
@@ -317,6 +735,78 @@ func (in *NodePool) GetAllowedDisruptionsByReason(c clock.Clock, numNodes int, r
 	return allowedNodes, multiErr
 }
 
+// GetAllowedDisruptionResourcesByReason returns the minimum of each resource that's allowed to be disrupted across the
+// active budgets for the reason, given the allocatable of the NodePool's nodes. Resources that aren't limited by any
+// budget are left out of the returned list.
+func (in *NodePool) GetAllowedDisruptionResourcesByReason(c clock.Clock, allocatable v1.ResourceList, reason DisruptionReason) (v1.ResourceList, error) {
+	allowed := v1.ResourceList{}
+	var multiErr error
+	for _, budget := range in.Spec.Disruption.Budgets {
+		if budget.Reasons != nil && !lo.Contains(budget.Reasons, reason) {
+			continue
+		}
+		resources, err := budget.GetAllowedDisruptionResources(c, allocatable)
+		if err != nil {
+			multiErr = multierr.Append(multiErr, err)
+		}
+		for name, quantity := range resources {
+			if current, ok := allowed[name]; !ok || quantity.Cmp(current) < 0 {
+				allowed[name] = quantity
+			}
+		}
+	}
+	return allowed, multiErr
+}
+
+// InMaintenanceWindow returns whether the maintenance windows allow voluntary disruption for the reason. Reasons that no
+// window applies to are always allowed, otherwise one of the windows that applies to the reason must be open.
+func (in *Disruption) InMaintenanceWindow(c clock.Clock, reason DisruptionReason) (bool, error) {
+	windows := lo.Filter(in.MaintenanceWindows, func(w MaintenanceWindow, _ int) bool {
+		return w.Reasons == nil || lo.Contains(w.Reasons, reason)
+	})
+	if len(windows) == 0 {
+		return true, nil
+	}
+	var multiErr error
+	for _, window := range windows {
+		active, err := window.IsActive(c)
+		if err != nil {
+			multiErr = multierr.Append(multiErr, err)
+			continue
+		}
+		if active {
+			return true, nil
+		}
+	}
+	return false, multiErr
+}
+
+// GetHeadroom returns the number of vacant nodes to keep for the NodePool, given the number of its nodes that have pods
+func (in *NodePool) GetHeadroom(occupiedNodes int) int {
+	if in.Spec.Headroom == "" {
+		return 0
+	}
+	// Errors are ignored since the headroom is validated when the nodepool is applied
+	res, _ := intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(GetIntStrFromValue(in.Spec.Headroom)), occupiedNodes, true)
+	return res
+}
+
+// GetConsolidationThreshold returns the savings that consolidating nodes of the NodePool whose prices sum to price must
+// exceed
+func (in *NodePool) GetConsolidationThreshold(price float64) float64 {
+	threshold := in.Spec.Disruption.ConsolidationThreshold
+	if threshold == "" {
+		return 0
+	}
+	// Errors are ignored since the threshold is validated when the nodepool is applied
+	if percent, ok := strings.CutSuffix(threshold, "%"); ok {
+		p, _ := strconv.ParseFloat(percent, 64)
+		return price * p / 100
+	}
+	delta, _ := strconv.ParseFloat(threshold, 64)
+	return delta
+}
+
 // GetAllowedDisruptions returns an intstr.IntOrString that can be used a comparison
 // for calculating if a disruption action is allowed. It returns an error if the
 // schedule is invalid. This returns MAXINT if the value is unbounded.
@@ -344,6 +834,49 @@ func (in *Budget) GetAllowedDisruptions(c clock.Clock, numNodes int) (int, error
 	return res, nil
 }
 
+// GetAllowedDisruptionResources returns the amount of each resource in the budget that can be disrupted, given the
+// allocatable of the NodePool's nodes. Percentages are rounded up like Nodes. It returns an empty list if the budget
+// is inactive or doesn't limit resources.
+func (in *Budget) GetAllowedDisruptionResources(c clock.Clock, allocatable v1.ResourceList) (v1.ResourceList, error) {
+	if len(in.Resources) == 0 {
+		return nil, nil
+	}
+	active, err := in.IsActive(c)
+	// If the budget is misconfigured, fail closed.
+	if err != nil {
+		return lo.MapValues(in.Resources, func(string, v1.ResourceName) resource.Quantity { return resource.Quantity{} }), err
+	}
+	if !active {
+		return nil, nil
+	}
+	allowed := v1.ResourceList{}
+	var multiErr error
+	for name, value := range in.Resources {
+		quantity, err := parseBudgetResource(value, allocatable[name])
+		if err != nil {
+			// Should never happen since this is validated when the nodepool is applied
+			multiErr = multierr.Append(multiErr, fmt.Errorf("invalid budget for %s, %w", name, err))
+		}
+		allowed[name] = quantity
+	}
+	return allowed, multiErr
+}
+
+func parseBudgetResource(value string, allocatable resource.Quantity) (resource.Quantity, error) {
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseInt(percent, 10, 64)
+		if err != nil || p < 0 || p > 100 {
+			return resource.Quantity{}, fmt.Errorf("invalid percentage %q", value)
+		}
+		return *resource.NewMilliQuantity(int64(math.Ceil(allocatable.AsApproximateFloat64()*float64(p)*10)), allocatable.Format), nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil || quantity.Sign() < 0 {
+		return resource.Quantity{}, fmt.Errorf("invalid quantity %q", value)
+	}
+	return quantity, nil
+}
+
 // IsActive takes a clock as input and returns if a budget is active.
 // It walks back in time the time.Duration associated with the schedule,
 // and checks if the next time the schedule will hit is before the current time.
@@ -354,14 +887,27 @@ func (in *Budget) IsActive(c clock.Clock) (bool, error) {
 	if in.Schedule == nil && in.Duration == nil {
 		return true, nil
 	}
-	schedule, err := cron.ParseStandard(fmt.Sprintf("TZ=UTC %s", lo.FromPtr(in.Schedule)))
+	return isScheduleActive(c, "UTC", lo.FromPtr(in.Schedule), lo.FromPtr(in.Duration).Duration)
+}
+
+// IsActive returns if the maintenance window is open, evaluating its schedule in the window's time zone.
+func (in *MaintenanceWindow) IsActive(c clock.Clock) (bool, error) {
+	timeZone := lo.Ternary(in.TimeZone != nil, lo.FromPtr(in.TimeZone), "UTC")
+	if _, err := time.LoadLocation(timeZone); err != nil {
+		return false, fmt.Errorf("invalid time zone %q for maintenance window %q, %w", timeZone, in.Name, err)
+	}
+	return isScheduleActive(c, timeZone, in.Schedule, in.Duration.Duration)
+}
+
+func isScheduleActive(c clock.Clock, timeZone string, cronSchedule string, duration time.Duration) (bool, error) {
+	schedule, err := cron.ParseStandard(fmt.Sprintf("TZ=%s %s", timeZone, cronSchedule))
 	if err != nil {
 		// Should only occur if there's a discrepancy
 		// with the validation regex and the cron package.
-		return false, fmt.Errorf("invariant violated, invalid cron %s", schedule)
+		return false, fmt.Errorf("invariant violated, invalid cron %s", cronSchedule)
 	}
 	// Walk back in time for the duration associated with the schedule
-	checkPoint := c.Now().UTC().Add(-lo.FromPtr(in.Duration).Duration)
+	checkPoint := c.Now().UTC().Add(-duration)
 	nextHit := schedule.Next(checkPoint)
 	return !nextHit.After(c.Now().UTC()), nil
 }
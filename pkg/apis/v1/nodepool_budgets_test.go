@@ -25,6 +25,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clock "k8s.io/utils/clock/testing"
 
@@ -208,6 +210,61 @@ var _ = Describe("Budgets", func() {
 		})
 	})
 
+	Context("AllowedDisruptionResources", func() {
+		var allocatable corev1.ResourceList
+		BeforeEach(func() {
+			allocatable = corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100"),
+				corev1.ResourceMemory: resource.MustParse("400Gi"),
+			}
+		})
+		It("should return no resources when a budget doesn't limit resources", func() {
+			val, err := budgets[0].GetAllowedDisruptionResources(fakeClock, allocatable)
+			Expect(err).To(Succeed())
+			Expect(val).To(BeEmpty())
+		})
+		It("should return no resources when a budget is inactive", func() {
+			budgets[0].Schedule = lo.ToPtr("@yearly")
+			budgets[0].Resources = map[corev1.ResourceName]string{corev1.ResourceCPU: "8"}
+			val, err := budgets[0].GetAllowedDisruptionResources(fakeClock, allocatable)
+			Expect(err).To(Succeed())
+			Expect(val).To(BeEmpty())
+		})
+		It("should return zero values if a schedule is invalid", func() {
+			budgets[0].Schedule = lo.ToPtr("@wrongly")
+			budgets[0].Resources = map[corev1.ResourceName]string{corev1.ResourceCPU: "8"}
+			val, err := budgets[0].GetAllowedDisruptionResources(fakeClock, allocatable)
+			Expect(err).ToNot(Succeed())
+			Expect(val.Cpu().IsZero()).To(BeTrue())
+		})
+		It("should return quantities and percentages of the allocatable", func() {
+			budgets[0].Resources = map[corev1.ResourceName]string{corev1.ResourceCPU: "8", corev1.ResourceMemory: "10%"}
+			val, err := budgets[0].GetAllowedDisruptionResources(fakeClock, allocatable)
+			Expect(err).To(Succeed())
+			Expect(val.Cpu().Equal(resource.MustParse("8"))).To(BeTrue())
+			Expect(val.Memory().Equal(resource.MustParse("40Gi"))).To(BeTrue())
+		})
+		It("should round percentages up", func() {
+			budgets[0].Resources = map[corev1.ResourceName]string{corev1.ResourceCPU: "1%"}
+			val, err := budgets[0].GetAllowedDisruptionResources(fakeClock, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50")})
+			Expect(err).To(Succeed())
+			Expect(val.Cpu().Equal(resource.MustParse("500m"))).To(BeTrue())
+		})
+		It("should get the minimum of each resource across the budgets for a reason", func() {
+			budgets[0].Resources = map[corev1.ResourceName]string{corev1.ResourceCPU: "16", corev1.ResourceMemory: "64Gi"}
+			budgets[1].Resources = map[corev1.ResourceName]string{corev1.ResourceCPU: "8"}
+			budgets[4].Resources = map[corev1.ResourceName]string{corev1.ResourceCPU: "4"}
+			val, err := nodePool.GetAllowedDisruptionResourcesByReason(fakeClock, allocatable, DisruptionReasonEmpty)
+			Expect(err).To(Succeed())
+			Expect(val.Cpu().Equal(resource.MustParse("8"))).To(BeTrue())
+			Expect(val.Memory().Equal(resource.MustParse("64Gi"))).To(BeTrue())
+
+			val, err = nodePool.GetAllowedDisruptionResourcesByReason(fakeClock, allocatable, DisruptionReasonDrifted)
+			Expect(err).To(Succeed())
+			Expect(val.Cpu().Equal(resource.MustParse("4"))).To(BeTrue())
+		})
+	})
+
 	Context("IsActive", func() {
 		It("should always consider a schedule and time in UTC", func() {
 			// Set the time to start of June 2000 in a time zone 1 hour ahead of UTC
@@ -265,4 +322,74 @@ var _ = Describe("Budgets", func() {
 			Expect(active).ToNot(BeTrue())
 		})
 	})
+	Context("MaintenanceWindows", func() {
+		var window MaintenanceWindow
+		BeforeEach(func() {
+			// The fake clock is 14:30 in Europe/Berlin, which is two hours ahead of UTC in June
+			window = MaintenanceWindow{
+				Name:     "afternoon",
+				Schedule: "0 14 * * *",
+				Duration: metav1.Duration{Duration: lo.Must(time.ParseDuration("1h"))},
+				TimeZone: lo.ToPtr("Europe/Berlin"),
+			}
+		})
+		It("should evaluate the schedule in the window's time zone", func() {
+			active, err := window.IsActive(fakeClock)
+			Expect(err).To(Succeed())
+			Expect(active).To(BeTrue())
+		})
+		It("should evaluate the schedule in UTC when the time zone is unset", func() {
+			window.TimeZone = nil
+			active, err := window.IsActive(fakeClock)
+			Expect(err).To(Succeed())
+			Expect(active).To(BeFalse())
+		})
+		It("should return an error for an unknown time zone", func() {
+			window.TimeZone = lo.ToPtr("Mars/Olympus_Mons")
+			_, err := window.IsActive(fakeClock)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should apply budgets when a window is open", func() {
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{window}
+			allowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonDrifted)
+			Expect(err).To(BeNil())
+			Expect(allowedDisruption).To(Equal(5))
+		})
+		It("should deny disruption when no window is open", func() {
+			window.Schedule = "0 2 * * *"
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{window}
+			for _, reason := range allKnownDisruptionReasons {
+				allowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, reason)
+				Expect(err).To(BeNil())
+				Expect(allowedDisruption).To(Equal(0))
+			}
+		})
+		It("should allow disruption when any window that applies to the reason is open", func() {
+			closed := *window.DeepCopy()
+			closed.Name = "night"
+			closed.Schedule = "0 2 * * *"
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{closed, window}
+			allowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonUnderutilized)
+			Expect(err).To(BeNil())
+			Expect(allowedDisruption).To(Equal(10))
+		})
+		It("should only restrict the reasons that a window applies to", func() {
+			window.Schedule = "0 2 * * *"
+			window.Reasons = []DisruptionReason{DisruptionReasonDrifted}
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{window}
+			allowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonDrifted)
+			Expect(err).To(BeNil())
+			Expect(allowedDisruption).To(Equal(0))
+			allowedDisruption, err = nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonEmpty)
+			Expect(err).To(BeNil())
+			Expect(allowedDisruption).To(Equal(10))
+		})
+		It("should fail closed when a window is misconfigured", func() {
+			window.TimeZone = lo.ToPtr("Mars/Olympus_Mons")
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{window}
+			allowedDisruption, err := nodePool.GetAllowedDisruptionsByReason(fakeClock, 100, DisruptionReasonEmpty)
+			Expect(err).To(HaveOccurred())
+			Expect(allowedDisruption).To(Equal(0))
+		})
+	})
 })
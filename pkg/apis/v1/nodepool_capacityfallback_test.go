@@ -0,0 +1,102 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	. "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+var _ = Describe("CapacityFallback", func() {
+	var nodePool *NodePool
+	var fakeClock *clock.FakeClock
+
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Date(2000, time.June, 15, 12, 30, 30, 0, time.UTC))
+		nodePool = &NodePool{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+			Spec: NodePoolSpec{
+				CapacityFallback: &CapacityFallback{
+					InsufficientCapacityThreshold: 2,
+					Window:                        &metav1.Duration{Duration: 10 * time.Minute},
+					LastResortRequirements: []NodeSelectorRequirementWithMinValues{{
+						NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{CapacityTypeOnDemand}},
+					}},
+				},
+			},
+		}
+	})
+
+	It("should not fall back without a capacity fallback policy", func() {
+		nodePool.Spec.CapacityFallback = nil
+		for range 5 {
+			nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		}
+		Expect(nodePool.Status.CapacityFallback).To(BeNil())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageNone))
+	})
+	It("should fall back to capacity-optimized and then the last resort once the threshold is reached", func() {
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageNone))
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageCapacityOptimized))
+
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageCapacityOptimized))
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageLastResort))
+
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageLastResort))
+	})
+	It("should not fall back to the last resort if there are no last resort requirements", func() {
+		nodePool.Spec.CapacityFallback.LastResortRequirements = nil
+		for range 6 {
+			nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		}
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageCapacityOptimized))
+	})
+	It("should only count insufficient capacity errors within the window", func() {
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		fakeClock.Step(11 * time.Minute)
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageNone))
+		Expect(nodePool.Status.CapacityFallback.InsufficientCapacityErrors).To(BeNumerically("==", 1))
+	})
+	It("should lapse once a window passes without insufficient capacity errors", func() {
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageCapacityOptimized))
+
+		fakeClock.Step(9 * time.Minute)
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageCapacityOptimized))
+		fakeClock.Step(time.Minute)
+		Expect(nodePool.CapacityFallbackStage(fakeClock.Now())).To(Equal(CapacityFallbackStageNone))
+
+		// Falling back again starts from the first stage
+		nodePool.RecordInsufficientCapacity(fakeClock.Now())
+		Expect(nodePool.Status.CapacityFallback.Stage).To(Equal(CapacityFallbackStageNone))
+	})
+})
@@ -19,6 +19,7 @@ package v1
 import (
 	"github.com/awslabs/operatorpkg/status"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -27,6 +28,16 @@ const (
 	ConditionTypeValidationSucceeded = "ValidationSucceeded"
 	// ConditionTypeNodeClassReady = "NodeClassReady" condition indicates that underlying nodeClass was resolved and is reporting as Ready
 	ConditionTypeNodeClassReady = "NodeClassReady"
+	// ConditionTypePreflightChecksSucceeded = "PreflightChecksSucceeded" condition indicates that the CloudProvider's
+	// preflight checks passed for the NodePool and its NodeClass. It isn't part of the NodePool's readiness, since it's
+	// only set when the CloudProvider implements preflight checks, instead NodePools are skipped while it's false.
+	ConditionTypePreflightChecksSucceeded = "PreflightChecksSucceeded"
+	// ConditionTypeDriftPending = "DriftPending" condition indicates that changes to the NodePool have drifted existing
+	// nodes, which are going to be replaced
+	ConditionTypeDriftPending = "DriftPending"
+	// ConditionTypeQuotaExceeded = "QuotaExceeded" condition indicates that the NodePool's last launch failed because
+	// a CloudProvider quota would have been exceeded
+	ConditionTypeQuotaExceeded = "QuotaExceeded"
 )
 
 // NodePoolStatus defines the observed state of NodePool
@@ -34,11 +45,124 @@ type NodePoolStatus struct {
 	// Resources is the list of resources that have been provisioned.
 	// +optional
 	Resources v1.ResourceList `json:"resources,omitempty"`
+	// NodeCount is the number of nodes owned by the NodePool, including nodes that are still launching and nodes
+	// that are draining.
+	// +optional
+	NodeCount int64 `json:"nodeCount,omitempty"`
+	// NodeCounts breaks down the NodeCount by the lifecycle phase of each node.
+	// +optional
+	NodeCounts NodeCounts `json:"nodeCounts,omitempty"`
+	// CapacityFallback tracks the insufficient capacity errors that drive the NodePool's capacity fallback
+	// +optional
+	CapacityFallback *CapacityFallbackStatus `json:"capacityFallback,omitempty"`
+	// Effective is the configuration that Karpenter runs the NodePool with, once its operator-level settings like flags
+	// and feature gates are applied to the NodePool's spec
+	// +optional
+	Effective *EffectiveConfiguration `json:"effective,omitempty"`
 	// Conditions contains signals for health and readiness
 	// +optional
 	Conditions []status.Condition `json:"conditions,omitempty"`
 }
 
+// CapacityFallbackStage is how far a NodePool has fallen back from its usual launches
+// +kubebuilder:validation:Enum:={CapacityOptimized,LastResort}
+type CapacityFallbackStage string
+
+const (
+	CapacityFallbackStageNone              CapacityFallbackStage = ""
+	CapacityFallbackStageCapacityOptimized CapacityFallbackStage = "CapacityOptimized"
+	CapacityFallbackStageLastResort        CapacityFallbackStage = "LastResort"
+)
+
+// CapacityFallbackStatus is the state of a NodePool's capacity fallback
+type CapacityFallbackStatus struct {
+	// Stage is the fallback stage that the NodePool launches nodes with, until LastInsufficientCapacityTime is a
+	// Window in the past
+	// +optional
+	Stage CapacityFallbackStage `json:"stage,omitempty"`
+	// InsufficientCapacityErrors is the number of insufficient capacity errors since WindowStart
+	// +optional
+	InsufficientCapacityErrors int32 `json:"insufficientCapacityErrors,omitempty"`
+	// WindowStart is when insufficient capacity errors started being counted towards the next stage
+	// +optional
+	WindowStart metav1.Time `json:"windowStart,omitempty"`
+	// LastInsufficientCapacityTime is when the NodePool last failed to launch a node for insufficient capacity
+	// +optional
+	LastInsufficientCapacityTime metav1.Time `json:"lastInsufficientCapacityTime,omitempty"`
+}
+
+// EffectiveConfiguration is the configuration that Karpenter runs a NodePool with
+type EffectiveConfiguration struct {
+	// BinPacking is how pods are packed onto the NodeClaims launched for the NodePool
+	// +optional
+	BinPacking BinPackingStrategy `json:"binPacking,omitempty"`
+	// DisruptionMode is whether Karpenter acts on its disruption decisions for the NodePool
+	// +optional
+	DisruptionMode DisruptionMode `json:"disruptionMode,omitempty"`
+	// PreferNewerGenerations is whether instance types of the same price are ordered by newest generation
+	// +optional
+	PreferNewerGenerations bool `json:"preferNewerGenerations,omitempty"`
+	// SpotToSpotConsolidation is whether spot nodes can be consolidated by replacing them with cheaper spot nodes
+	// +optional
+	SpotToSpotConsolidation bool `json:"spotToSpotConsolidation,omitempty"`
+	// NodeRepair is whether nodes that stay unhealthy are replaced
+	// +optional
+	NodeRepair bool `json:"nodeRepair,omitempty"`
+	// ZoneRebalance is whether nodes are disrupted to rebalance the NodePool across zones
+	// +optional
+	ZoneRebalance bool `json:"zoneRebalance,omitempty"`
+	// MaxNodes is the maximum number of Karpenter-managed nodes across all NodePools, or 0 for no limit
+	// +optional
+	MaxNodes int64 `json:"maxNodes,omitempty"`
+	// MaxVCPU is the maximum number of vCPUs of Karpenter-managed nodes across all NodePools, or 0 for no limit
+	// +optional
+	MaxVCPU int64 `json:"maxVCPU,omitempty"`
+	// PreemptionSimulation is whether provisioning is skipped for pods that can schedule by preempting lower priority pods
+	// +optional
+	PreemptionSimulation bool `json:"preemptionSimulation,omitempty"`
+	// DisruptionAdmissionWebhook is whether disruption commands are admitted by a webhook before they're executed
+	// +optional
+	DisruptionAdmissionWebhook bool `json:"disruptionAdmissionWebhook,omitempty"`
+	// EmptinessIgnoredPods are the selectors of pods that don't keep a node from being considered empty
+	// +optional
+	EmptinessIgnoredPods string `json:"emptinessIgnoredPods,omitempty"`
+	// EvictionOrder is the order of the pod QoS classes that are evicted from nodes drained for voluntary disruption
+	// +optional
+	EvictionOrder string `json:"evictionOrder,omitempty"`
+	// MaxSchedulingSafetyMargin is the maximum percentage that pod requests are scaled up by when packed onto new NodeClaims
+	// +optional
+	MaxSchedulingSafetyMargin int64 `json:"maxSchedulingSafetyMargin,omitempty"`
+	// Overrides are the fields of the NodePool's spec that operator-level settings changed
+	// +optional
+	Overrides []ConfigurationOverride `json:"overrides,omitempty"`
+}
+
+// ConfigurationOverride is a field of a NodePool's spec that an operator-level setting changed
+type ConfigurationOverride struct {
+	// Field is the path of the field in the NodePool's spec
+	Field string `json:"field"`
+	// Setting is the operator-level setting that changed the field
+	Setting string `json:"setting"`
+	// Value is the value that the NodePool runs with
+	Value string `json:"value"`
+}
+
+// NodeCounts is the number of nodes owned by a NodePool in each lifecycle phase
+type NodeCounts struct {
+	// Launching is the number of nodes that have been launched but haven't registered with the cluster.
+	// +optional
+	Launching int64 `json:"launching,omitempty"`
+	// Registered is the number of nodes that have registered with the cluster but haven't initialized.
+	// +optional
+	Registered int64 `json:"registered,omitempty"`
+	// Initialized is the number of nodes that are initialized and ready to run pods.
+	// +optional
+	Initialized int64 `json:"initialized,omitempty"`
+	// Draining is the number of nodes that are being disrupted or deleted.
+	// +optional
+	Draining int64 `json:"draining,omitempty"`
+}
+
 func (in *NodePool) StatusConditions() status.ConditionSet {
 	return status.NewReadyConditions(
 		ConditionTypeValidationSucceeded,
@@ -18,14 +18,39 @@ package v1
 
 import (
 	"fmt"
+	"time"
 
 	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // RuntimeValidate will be used to validate any part of the CRD that can not be validated at CRD creation
 func (in *NodePool) RuntimeValidate() (errs error) {
-	errs = multierr.Combine(in.Spec.Template.validateLabels(), in.Spec.Template.Spec.validateTaints(), in.Spec.Template.Spec.validateRequirements(), in.Spec.Template.validateRequirementsNodePoolKeyDoesNotExist())
+	errs = multierr.Combine(in.Spec.Template.validateLabels(), in.Spec.Template.Spec.validateTaints(), in.Spec.Template.Spec.validateRequirements(), in.Spec.Template.validateRequirementsNodePoolKeyDoesNotExist(), in.Spec.Disruption.validateMaintenanceWindows(), in.Spec.Disruption.validateBudgetResources())
+	return errs
+}
+
+func (in *Disruption) validateMaintenanceWindows() (errs error) {
+	for _, window := range in.MaintenanceWindows {
+		if window.TimeZone == nil {
+			continue
+		}
+		if _, err := time.LoadLocation(*window.TimeZone); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("invalid time zone %q in maintenance window %q, %w", *window.TimeZone, window.Name, err))
+		}
+	}
+	return errs
+}
+
+func (in *Disruption) validateBudgetResources() (errs error) {
+	for _, budget := range in.Budgets {
+		for name, value := range budget.Resources {
+			if _, err := parseBudgetResource(value, resource.Quantity{}); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("invalid budget for %s, %w", name, err))
+			}
+		}
+	}
 	return errs
 }
 
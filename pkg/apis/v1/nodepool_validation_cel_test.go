@@ -109,6 +109,39 @@ var _ = Describe("CEL/Validation", func() {
 			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenEmpty
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 		})
+		It("should succeed when setting consolidationObjective=LeastDisruption", func() {
+			nodePool.Spec.Disruption.ConsolidationObjective = ConsolidationObjectiveLeastDisruption
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when setting an unknown consolidationObjective", func() {
+			nodePool.Spec.Disruption.ConsolidationObjective = "Fastest"
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should succeed when setting mode=ObserveOnly", func() {
+			nodePool.Spec.Disruption.Mode = DisruptionModeObserveOnly
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when setting an unknown mode", func() {
+			nodePool.Spec.Disruption.Mode = "DryRun"
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		DescribeTable("should succeed when setting a valid consolidationThreshold", func(threshold string) {
+			nodePool.Spec.Disruption.ConsolidationThreshold = threshold
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		},
+			Entry("percentage", "10%"),
+			Entry("whole price delta", "1"),
+			Entry("fractional price delta", "0.05"),
+		)
+		DescribeTable("should fail when setting an invalid consolidationThreshold", func(threshold string) {
+			nodePool.Spec.Disruption.ConsolidationThreshold = threshold
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		},
+			Entry("percentage over 100", "101%"),
+			Entry("fractional percentage", "2.5%"),
+			Entry("negative price delta", "-0.05"),
+			Entry("currency", "$0.05"),
+		)
 		It("should fail when creating a budget with an invalid cron", func() {
 			nodePool.Spec.Disruption.Budgets = []Budget{{
 				Nodes:    "10",
@@ -159,6 +192,30 @@ var _ = Describe("CEL/Validation", func() {
 			}}
 			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
 		})
+		It("should succeed when creating a budget with cpu and memory resources", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{{
+				Nodes:     "100%",
+				Resources: map[v1.ResourceName]string{v1.ResourceCPU: "64", v1.ResourceMemory: "10%"},
+			}}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+			Expect(nodePool.RuntimeValidate()).To(Succeed())
+		})
+		It("should fail when creating a budget with resources other than cpu and memory", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{{
+				Nodes:     "100%",
+				Resources: map[v1.ResourceName]string{v1.ResourcePods: "10"},
+			}}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail runtime validation for a budget with an invalid resource value", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{{
+				Nodes:     "100%",
+				Resources: map[v1.ResourceName]string{v1.ResourceCPU: "110%"},
+			}}
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+			nodePool.Spec.Disruption.Budgets[0].Resources[v1.ResourceCPU] = "-4"
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
 		It("should fail when creating a budget with a cron but no duration", func() {
 			nodePool.Spec.Disruption.Budgets = []Budget{{
 				Nodes:    "10",
@@ -244,6 +301,7 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 		},
 			Entry("should allow disruption reason Drifted", DisruptionReasonDrifted),
+			Entry("should allow disruption reason Rebalanced", DisruptionReasonRebalanced),
 			Entry("should allow disruption reason Underutilized", DisruptionReasonUnderutilized),
 			Entry("should allow disruption reason Empty", DisruptionReasonEmpty),
 		)
@@ -271,6 +329,58 @@ var _ = Describe("CEL/Validation", func() {
 			}}
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 		})
+		It("should succeed when creating maintenance windows", func() {
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{
+				{
+					Name:     "nightly",
+					Schedule: "0 2 * * *",
+					Duration: metav1.Duration{Duration: lo.Must(time.ParseDuration("4h"))},
+					TimeZone: lo.ToPtr("America/New_York"),
+				},
+				{
+					Name:     "weekend",
+					Schedule: "@weekly",
+					Duration: metav1.Duration{Duration: lo.Must(time.ParseDuration("24h"))},
+					Reasons:  []DisruptionReason{DisruptionReasonDrifted},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+			Expect(nodePool.RuntimeValidate()).To(Succeed())
+		})
+		It("should fail when creating maintenance windows with duplicate names", func() {
+			window := MaintenanceWindow{
+				Name:     "nightly",
+				Schedule: "0 2 * * *",
+				Duration: metav1.Duration{Duration: lo.Must(time.ParseDuration("4h"))},
+			}
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{window, window}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail when creating a maintenance window with an invalid schedule", func() {
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{{
+				Name:     "nightly",
+				Schedule: "*",
+				Duration: metav1.Duration{Duration: lo.Must(time.ParseDuration("4h"))},
+			}}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail when creating a maintenance window with a duration in seconds", func() {
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{{
+				Name:     "nightly",
+				Schedule: "0 2 * * *",
+				Duration: metav1.Duration{Duration: lo.Must(time.ParseDuration("30s"))},
+			}}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail at runtime for a maintenance window with an unknown time zone", func() {
+			nodePool.Spec.Disruption.MaintenanceWindows = []MaintenanceWindow{{
+				Name:     "nightly",
+				Schedule: "0 2 * * *",
+				Duration: metav1.Duration{Duration: lo.Must(time.ParseDuration("4h"))},
+				TimeZone: lo.ToPtr("Mars/Olympus_Mons"),
+			}}
+			Expect(nodePool.RuntimeValidate()).ToNot(Succeed())
+		})
 	})
 	Context("Taints", func() {
 		It("should succeed for valid taints", func() {
@@ -620,6 +730,37 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
 		})
 	})
+	Context("RegistrationTimeout", func() {
+		It("should succeed on a registrationTimeout of at least a minute", func() {
+			nodePool.Spec.Template.Spec.RegistrationTimeout = &metav1.Duration{Duration: time.Minute * 45}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail on a registrationTimeout of less than a minute", func() {
+			nodePool.Spec.Template.Spec.RegistrationTimeout = &metav1.Duration{Duration: time.Second * 30}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail on a negative registrationTimeout", func() {
+			nodePool.Spec.Template.Spec.RegistrationTimeout = &metav1.Duration{Duration: time.Minute * -30}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+	})
+	Context("Repair", func() {
+		It("should succeed with a repair policy", func() {
+			nodePool.Spec.Repair = []NodeRepairPolicy{{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}}}
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail with an invalid condition status", func() {
+			nodePool.Spec.Repair = []NodeRepairPolicy{{ConditionType: "KernelDeadlock", ConditionStatus: "Broken", TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}}}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail with duplicate condition types", func() {
+			nodePool.Spec.Repair = []NodeRepairPolicy{
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionTrue, TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}},
+				{ConditionType: "KernelDeadlock", ConditionStatus: v1.ConditionUnknown, TolerationDuration: metav1.Duration{Duration: 10 * time.Minute}},
+			}
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+	})
 	Context("NodeClassRef", func() {
 		It("should fail to mutate group", func() {
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
@@ -18,14 +18,17 @@ package v1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/karpenter/pkg/apis"
 )
 
 // Karpenter specific taints
 const (
-	DisruptedTaintKey    = apis.Group + "/disrupted"
-	UnregisteredTaintKey = apis.Group + "/unregistered"
+	DisruptedTaintKey     = apis.Group + "/disrupted"
+	UnregisteredTaintKey  = apis.Group + "/unregistered"
+	UninitializedTaintKey = apis.Group + "/uninitialized"
+	ExclusiveTaintKey     = apis.Group + "/exclusive"
 )
 
 var (
@@ -39,4 +42,31 @@ var (
 		Key:    UnregisteredTaintKey,
 		Effect: v1.TaintEffectNoExecute,
 	}
+	// UninitializedNoScheduleTaint can be configured as a startup taint so that only pods which tolerate it, such as
+	// DaemonSets that bootstrap the node, schedule before the node is initialized. Unlike other startup taints, it
+	// doesn't block initialization and is removed by Karpenter once the node is otherwise initialized.
+	UninitializedNoScheduleTaint = v1.Taint{
+		Key:    UninitializedTaintKey,
+		Effect: v1.TaintEffectNoSchedule,
+	}
 )
+
+// ExclusiveNoScheduleTaint is applied to nodes launched for a pod with the "karpenter.sh/exclusive-node" annotation.
+// The value is the UID of the owning pod so that only that pod tolerates the taint.
+func ExclusiveNoScheduleTaint(uid types.UID) v1.Taint {
+	return v1.Taint{
+		Key:    ExclusiveTaintKey,
+		Value:  string(uid),
+		Effect: v1.TaintEffectNoSchedule,
+	}
+}
+
+// ExclusiveToleration tolerates the ExclusiveNoScheduleTaint for the pod with the given UID
+func ExclusiveToleration(uid types.UID) v1.Toleration {
+	return v1.Toleration{
+		Key:      ExclusiveTaintKey,
+		Operator: v1.TolerationOpEqual,
+		Value:    string(uid),
+		Effect:   v1.TaintEffectNoSchedule,
+	}
+}
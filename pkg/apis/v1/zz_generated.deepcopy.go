@@ -36,6 +36,13 @@ func (in *Budget) DeepCopyInto(out *Budget) {
 		*out = make([]DisruptionReason, len(*in))
 		copy(*out, *in)
 	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make(map[corev1.ResourceName]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Schedule != nil {
 		in, out := &in.Schedule, &out.Schedule
 		*out = new(string)
@@ -58,6 +65,80 @@ func (in *Budget) DeepCopy() *Budget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacityFallback) DeepCopyInto(out *CapacityFallback) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.LastResortRequirements != nil {
+		in, out := &in.LastResortRequirements, &out.LastResortRequirements
+		*out = make([]NodeSelectorRequirementWithMinValues, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacityFallback.
+func (in *CapacityFallback) DeepCopy() *CapacityFallback {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityFallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacityFallbackStatus) DeepCopyInto(out *CapacityFallbackStatus) {
+	*out = *in
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	in.LastInsufficientCapacityTime.DeepCopyInto(&out.LastInsufficientCapacityTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacityFallbackStatus.
+func (in *CapacityFallbackStatus) DeepCopy() *CapacityFallbackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityFallbackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CapacityTypeMinimum) DeepCopyInto(out *CapacityTypeMinimum) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CapacityTypeMinimum.
+func (in *CapacityTypeMinimum) DeepCopy() *CapacityTypeMinimum {
+	if in == nil {
+		return nil
+	}
+	out := new(CapacityTypeMinimum)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigurationOverride) DeepCopyInto(out *ConfigurationOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigurationOverride.
+func (in *ConfigurationOverride) DeepCopy() *ConfigurationOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigurationOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Disruption) DeepCopyInto(out *Disruption) {
 	*out = *in
@@ -69,6 +150,23 @@ func (in *Disruption) DeepCopyInto(out *Disruption) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MinNodes != nil {
+		in, out := &in.MinNodes, &out.MinNodes
+		*out = make([]CapacityTypeMinimum, len(*in))
+		copy(*out, *in)
+	}
+	if in.WarmupPeriod != nil {
+		in, out := &in.WarmupPeriod, &out.WarmupPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Disruption.
@@ -102,6 +200,67 @@ func (in Limits) DeepCopy() Limits {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveConfiguration) DeepCopyInto(out *EffectiveConfiguration) {
+	*out = *in
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]ConfigurationOverride, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveConfiguration.
+func (in *EffectiveConfiguration) DeepCopy() *EffectiveConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]DisruptionReason, len(*in))
+		copy(*out, *in)
+	}
+	out.Duration = in.Duration
+	if in.TimeZone != nil {
+		in, out := &in.TimeZone, &out.TimeZone
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetadataFidelityPolicy) DeepCopyInto(out *MetadataFidelityPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetadataFidelityPolicy.
+func (in *MetadataFidelityPolicy) DeepCopy() *MetadataFidelityPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MetadataFidelityPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NillableDuration) DeepCopyInto(out *NillableDuration) {
 	*out = *in
@@ -222,6 +381,18 @@ func (in *NodeClaimSpec) DeepCopyInto(out *NodeClaimSpec) {
 		**out = **in
 	}
 	in.ExpireAfter.DeepCopyInto(&out.ExpireAfter)
+	if in.RegistrationTimeout != nil {
+		in, out := &in.RegistrationTimeout, &out.RegistrationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TemplateVariables != nil {
+		in, out := &in.TemplateVariables, &out.TemplateVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeClaimSpec.
@@ -258,7 +429,17 @@ func (in *NodeClaimStatus) DeepCopyInto(out *NodeClaimStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DriftedFields != nil {
+		in, out := &in.DriftedFields, &out.DriftedFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.LastPodEventTime.DeepCopyInto(&out.LastPodEventTime)
+	if in.Provenance != nil {
+		in, out := &in.Provenance, &out.Provenance
+		*out = new(Provenance)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeClaimStatus.
@@ -323,6 +504,11 @@ func (in *NodeClaimTemplateSpec) DeepCopyInto(out *NodeClaimTemplateSpec) {
 		**out = **in
 	}
 	in.ExpireAfter.DeepCopyInto(&out.ExpireAfter)
+	if in.RegistrationTimeout != nil {
+		in, out := &in.RegistrationTimeout, &out.RegistrationTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeClaimTemplateSpec.
@@ -350,6 +536,21 @@ func (in *NodeClassReference) DeepCopy() *NodeClassReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCounts) DeepCopyInto(out *NodeCounts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCounts.
+func (in *NodeCounts) DeepCopy() *NodeCounts {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodePool) DeepCopyInto(out *NodePool) {
 	*out = *in
@@ -421,11 +622,43 @@ func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.ZoneLimits != nil {
+		in, out := &in.ZoneLimits, &out.ZoneLimits
+		*out = make(Limits, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MinNodes != nil {
+		in, out := &in.MinNodes, &out.MinNodes
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Weight != nil {
 		in, out := &in.Weight, &out.Weight
 		*out = new(int32)
 		**out = **in
 	}
+	if in.CapacityFallback != nil {
+		in, out := &in.CapacityFallback, &out.CapacityFallback
+		*out = new(CapacityFallback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MetadataFidelity != nil {
+		in, out := &in.MetadataFidelity, &out.MetadataFidelity
+		*out = make([]MetadataFidelityPolicy, len(*in))
+		copy(*out, *in)
+	}
+	if in.Repair != nil {
+		in, out := &in.Repair, &out.Repair
+		*out = make([]NodeRepairPolicy, len(*in))
+		copy(*out, *in)
+	}
+	if in.SpotDiversification != nil {
+		in, out := &in.SpotDiversification, &out.SpotDiversification
+		*out = new(SpotDiversification)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolSpec.
@@ -448,6 +681,17 @@ func (in *NodePoolStatus) DeepCopyInto(out *NodePoolStatus) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	out.NodeCounts = in.NodeCounts
+	if in.CapacityFallback != nil {
+		in, out := &in.CapacityFallback, &out.CapacityFallback
+		*out = new(CapacityFallbackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Effective != nil {
+		in, out := &in.Effective, &out.Effective
+		*out = new(EffectiveConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]status.Condition, len(*in))
@@ -467,6 +711,22 @@ func (in *NodePoolStatus) DeepCopy() *NodePoolStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeRepairPolicy) DeepCopyInto(out *NodeRepairPolicy) {
+	*out = *in
+	out.TolerationDuration = in.TolerationDuration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeRepairPolicy.
+func (in *NodeRepairPolicy) DeepCopy() *NodeRepairPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeRepairPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeSelectorRequirementWithMinValues) DeepCopyInto(out *NodeSelectorRequirementWithMinValues) {
 	*out = *in
@@ -505,6 +765,11 @@ func (in *ObjectMeta) DeepCopyInto(out *ObjectMeta) {
 			(*out)[key] = val
 		}
 	}
+	if in.PropagateNodePoolLabels != nil {
+		in, out := &in.PropagateNodePoolLabels, &out.PropagateNodePoolLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectMeta.
@@ -517,6 +782,21 @@ func (in *ObjectMeta) DeepCopy() *ObjectMeta {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Provenance) DeepCopyInto(out *Provenance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Provenance.
+func (in *Provenance) DeepCopy() *Provenance {
+	if in == nil {
+		return nil
+	}
+	out := new(Provenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequirements) DeepCopyInto(out *ResourceRequirements) {
 	*out = *in
@@ -538,3 +818,18 @@ func (in *ResourceRequirements) DeepCopy() *ResourceRequirements {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpotDiversification) DeepCopyInto(out *SpotDiversification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpotDiversification.
+func (in *SpotDiversification) DeepCopy() *SpotDiversification {
+	if in == nil {
+		return nil
+	}
+	out := new(SpotDiversification)
+	in.DeepCopyInto(out)
+	return out
+}
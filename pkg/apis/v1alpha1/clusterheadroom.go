@@ -0,0 +1,123 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultPodShapes are the pod shapes that headroom is computed for when a ClusterHeadroom doesn't specify any
+var DefaultPodShapes = []PodShape{
+	{Name: "small", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("512Mi")}},
+	{Name: "medium", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
+	{Name: "large", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("8Gi")}},
+}
+
+// ClusterHeadroomSpec describes the pod shapes that Karpenter reports the cluster's headroom for
+type ClusterHeadroomSpec struct {
+	// Shapes are the pod shapes to compute headroom for. The small, medium and large default shapes are used if empty.
+	// +kubebuilder:validation:XValidation:message="shape names must be unique",rule="self.all(x, self.exists_one(y, x.name == y.name))"
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	Shapes []PodShape `json:"shapes,omitempty"`
+}
+
+// PodShape is a named set of resource requests
+type PodShape struct {
+	// Name identifies the shape in the status and in the headroom metrics
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +required
+	Name string `json:"name"`
+	// Requests are the resources requested by a pod of this shape
+	// +kubebuilder:validation:MinProperties=1
+	// +required
+	Requests corev1.ResourceList `json:"requests"`
+	// NodeSelector limits the shape's headroom to the nodes with all of these labels
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are the taints that pods of this shape tolerate. Nodes with NoSchedule or NoExecute taints that the
+	// shape doesn't tolerate don't count towards its headroom.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// ClusterHeadroomStatus is the headroom of the cluster, which is how many more pods of each shape could schedule onto
+// the existing nodes without Karpenter launching new ones
+type ClusterHeadroomStatus struct {
+	// Shapes is the cluster-wide headroom for each pod shape
+	// +optional
+	Shapes []ShapeHeadroom `json:"shapes,omitempty"`
+	// NodePools is the headroom for each pod shape on the nodes of each NodePool
+	// +optional
+	NodePools []NodePoolHeadroom `json:"nodePools,omitempty"`
+	// LastUpdateTime is when the headroom was last computed
+	// +optional
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// ShapeHeadroom is the number of pods of a shape that fit onto existing nodes
+type ShapeHeadroom struct {
+	// +required
+	Name string `json:"name"`
+	// +required
+	Pods int64 `json:"pods"`
+}
+
+// NodePoolHeadroom is the headroom of a single NodePool's nodes
+type NodePoolHeadroom struct {
+	// +required
+	NodePool string `json:"nodePool"`
+	// +optional
+	Shapes []ShapeHeadroom `json:"shapes,omitempty"`
+}
+
+// ClusterHeadroom is the Schema for the ClusterHeadrooms API. Karpenter keeps the status of every ClusterHeadroom up
+// to date with the headroom of the cluster, and publishes it as the karpenter_cluster_headroom_pods and
+// karpenter_nodepools_headroom_pods metrics.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=clusterheadrooms,scope=Cluster,categories=karpenter
+// +kubebuilder:printcolumn:name="Updated",type="date",JSONPath=".status.lastUpdateTime",description=""
+// +kubebuilder:subresource:status
+type ClusterHeadroom struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec ClusterHeadroomSpec `json:"spec,omitempty"`
+	// +optional
+	Status ClusterHeadroomStatus `json:"status,omitempty"`
+}
+
+// PodShapes returns the pod shapes that the ClusterHeadroom reports headroom for
+func (in *ClusterHeadroom) PodShapes() []PodShape {
+	if len(in.Spec.Shapes) == 0 {
+		return DefaultPodShapes
+	}
+	return in.Spec.Shapes
+}
+
+// ClusterHeadroomList contains a list of ClusterHeadroom
+// +kubebuilder:object:root=true
+type ClusterHeadroomList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterHeadroom `json:"items"`
+}
@@ -0,0 +1,121 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DecisionType is the kind of decision that a ProvisioningDecision records
+// +kubebuilder:validation:Enum:={Provisioning,Disruption}
+type DecisionType string
+
+const (
+	DecisionTypeProvisioning DecisionType = "Provisioning"
+	DecisionTypeDisruption   DecisionType = "Disruption"
+)
+
+// ProvisioningDecisionSpec is a record of a single provisioning or disruption decision made by Karpenter. It's written
+// once, when the decision is acted on, and never updated.
+type ProvisioningDecisionSpec struct {
+	// Type is whether the decision launched capacity for pending pods or disrupted existing nodes
+	// +required
+	Type DecisionType `json:"type"`
+	// Reason is why the decision was made, like provisioned, underutilized or drifted
+	// +required
+	Reason string `json:"reason"`
+	// Pods are the pods that triggered the decision. For disruption decisions, these are the pods that are rescheduled
+	// off of the candidates.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	Pods []DecisionPod `json:"pods,omitempty"`
+	// TruncatedPods is the number of triggering pods left out of Pods
+	// +optional
+	TruncatedPods int `json:"truncatedPods,omitempty"`
+	// Candidates are the names of the nodes disrupted by the decision
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	Candidates []string `json:"candidates,omitempty"`
+	// NodeClaims are the NodeClaims launched by the decision, along with the instance types that were considered for them
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	NodeClaims []DecisionNodeClaim `json:"nodeClaims,omitempty"`
+	// Rejections are the pods that couldn't be scheduled while making the decision, and why
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	Rejections []DecisionRejection `json:"rejections,omitempty"`
+}
+
+// DecisionPod identifies a pod that triggered a decision
+type DecisionPod struct {
+	// +required
+	Namespace string `json:"namespace"`
+	// +required
+	Name string `json:"name"`
+}
+
+// DecisionNodeClaim is a NodeClaim launched by a decision
+type DecisionNodeClaim struct {
+	// Name is the name of the created NodeClaim. It's empty if the NodeClaim failed to be created.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// NodePool is the NodePool that the NodeClaim was launched from
+	// +required
+	NodePool string `json:"nodePool"`
+	// InstanceTypes are the candidate instance types that the NodeClaim was launched with, cheapest first. The
+	// CloudProvider makes the final choice between them at launch.
+	// +kubebuilder:validation:MaxItems=60
+	// +optional
+	InstanceTypes []string `json:"instanceTypes,omitempty"`
+	// CheapestInstanceType is the cheapest of InstanceTypes with an offering compatible with the NodeClaim
+	// +optional
+	CheapestInstanceType string `json:"cheapestInstanceType,omitempty"`
+	// EstimatedPrice is the hourly price of the cheapest compatible offering, formatted as a decimal
+	// +optional
+	EstimatedPrice string `json:"estimatedPrice,omitempty"`
+}
+
+// DecisionRejection is a pod that couldn't be scheduled while making a decision
+type DecisionRejection struct {
+	DecisionPod `json:",inline"`
+	// Reason is the scheduling error for the pod
+	// +required
+	Reason string `json:"reason"`
+}
+
+// ProvisioningDecision is the Schema for the ProvisioningDecisions API. ProvisioningDecisions are created in
+// Karpenter's namespace when decision recording is enabled, and garbage collected after the configured TTL.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=provisioningdecisions,scope=Namespaced,categories=karpenter
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description=""
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".spec.reason",description=""
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+type ProvisioningDecision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec ProvisioningDecisionSpec `json:"spec"`
+}
+
+// ProvisioningDecisionList contains a list of ProvisioningDecision
+// +kubebuilder:object:root=true
+type ProvisioningDecisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ProvisioningDecision `json:"items"`
+}
@@ -0,0 +1,67 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SimulationPolicySpec describes how Karpenter should transform the pods it selects when simulating scheduling them.
+// The transformations only apply to Karpenter's simulation, the pods themselves are never mutated.
+type SimulationPolicySpec struct {
+	// Selector selects the pods that the mutations apply to by their labels. An empty selector selects every pod.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Namespaces limits the mutations to pods in the given namespaces. Pods in every namespace are selected if empty.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Mutations are the transformations applied to the selected pods
+	// +required
+	Mutations PodMutations `json:"mutations"`
+}
+
+// PodMutations are the transformations that can be applied to a pod during simulation
+type PodMutations struct {
+	// UseLimitsAsRequests simulates each container as requesting its limits for every resource that it has a limit for
+	// +optional
+	UseLimitsAsRequests bool `json:"useLimitsAsRequests,omitempty"`
+	// Tolerations are added to the pod's tolerations
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// SimulationPolicy is the Schema for the SimulationPolicies API
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=simulationpolicies,scope=Cluster,categories=karpenter
+type SimulationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +required
+	Spec SimulationPolicySpec `json:"spec"`
+}
+
+// SimulationPolicyList contains a list of SimulationPolicy
+// +kubebuilder:object:root=true
+type SimulationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SimulationPolicy `json:"items"`
+}
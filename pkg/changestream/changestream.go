@@ -0,0 +1,295 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package changestream tails changes to NodePools and NodeClaims for systems outside of Karpenter, like billing and
+// capacity planning. Changes are delivered as typed events that carry the fields that changed and a resume token. The
+// token can be persisted once an event is handled, so that the stream can be resumed from it after a restart without
+// missing or replaying changes.
+package changestream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+type EventType string
+
+const (
+	Added    EventType = "Added"
+	Modified EventType = "Modified"
+	Deleted  EventType = "Deleted"
+	// Bookmark events don't carry an object. They advance the resume token while nothing changes, so that a stream
+	// that's resumed doesn't start from a token that the API server has already forgotten.
+	Bookmark EventType = "Bookmark"
+)
+
+// ErrResumeTokenExpired is returned when the API server no longer has the changes since the resume token. The stream
+// has to be started again without a token, which replays every object as an Added event.
+var ErrResumeTokenExpired = errors.New("resume token expired")
+
+// Event is a change to an object
+type Event[T client.Object] struct {
+	Type EventType
+	// Object is the object after the change, or the last state of the object if it was deleted
+	Object T
+	// Previous is the object before the change. It's only set for Modified and Deleted events of objects that were seen
+	// earlier in the stream, so it's unset for the first change of each object after the stream is resumed.
+	Previous T
+	// Changes are the fields that changed, if Previous is set
+	Changes []Change
+	// ResumeToken is the token that the stream can be resumed from once the event is handled. Added events of the
+	// objects that are replayed when the stream is started without a token don't carry one, except for the last, since
+	// resuming from the middle of the replay would miss the remaining objects.
+	ResumeToken string
+}
+
+// Change is a field of an object that changed. Only the labels, annotations, finalizers and deletion timestamp of the
+// object's metadata are compared, along with its spec and status.
+type Change struct {
+	// Path is the path to the field, e.g. ["spec", "limits", "cpu"]
+	Path []string
+	// Old is the value of the field before the change, or nil if the field was added
+	Old any
+	// New is the value of the field after the change, or nil if the field was removed
+	New any
+}
+
+// Stream tails the changes to a kind of object
+type Stream[T client.Object] struct {
+	kubeClient client.WithWatch
+	newList    func() client.ObjectList
+	items      func(client.ObjectList) []T
+	last       map[types.UID]T
+}
+
+// NodePools returns a Stream of the changes to NodePools
+func NodePools(kubeClient client.WithWatch) *Stream[*v1.NodePool] {
+	return &Stream[*v1.NodePool]{
+		kubeClient: kubeClient,
+		newList:    func() client.ObjectList { return &v1.NodePoolList{} },
+		items: func(list client.ObjectList) []*v1.NodePool {
+			return toPointers(list.(*v1.NodePoolList).Items)
+		},
+		last: map[types.UID]*v1.NodePool{},
+	}
+}
+
+// NodeClaims returns a Stream of the changes to NodeClaims
+func NodeClaims(kubeClient client.WithWatch) *Stream[*v1.NodeClaim] {
+	return &Stream[*v1.NodeClaim]{
+		kubeClient: kubeClient,
+		newList:    func() client.ObjectList { return &v1.NodeClaimList{} },
+		items: func(list client.ObjectList) []*v1.NodeClaim {
+			return toPointers(list.(*v1.NodeClaimList).Items)
+		},
+		last: map[types.UID]*v1.NodeClaim{},
+	}
+}
+
+// Run passes each change to the handler, in order, until the context is canceled or the handler returns an error. If
+// the resume token is empty, every object is first replayed as an Added event. The watch is reestablished from the
+// latest resume token whenever the API server closes it. ErrResumeTokenExpired is returned if the API server no longer
+// has the changes since the token.
+func (s *Stream[T]) Run(ctx context.Context, resumeToken string, handler func(context.Context, Event[T]) error) error {
+	token := resumeToken
+	if token == "" {
+		var err error
+		if token, err = s.replay(ctx, handler); err != nil {
+			return err
+		}
+	}
+	for ctx.Err() == nil {
+		var err error
+		if token, err = s.watch(ctx, token, handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Stream[T]) replay(ctx context.Context, handler func(context.Context, Event[T]) error) (string, error) {
+	list := s.newList()
+	if err := s.kubeClient.List(ctx, list); err != nil {
+		return "", fmt.Errorf("listing objects, %w", err)
+	}
+	objs := s.items(list)
+	for i, obj := range objs {
+		s.last[obj.GetUID()] = obj
+		event := Event[T]{Type: Added, Object: obj}
+		if i == len(objs)-1 {
+			event.ResumeToken = list.GetResourceVersion()
+		}
+		if err := handler(ctx, event); err != nil {
+			return "", err
+		}
+	}
+	return list.GetResourceVersion(), nil
+}
+
+// watch passes changes to the handler until the watch is closed, returning the latest resume token
+func (s *Stream[T]) watch(ctx context.Context, token string, handler func(context.Context, Event[T]) error) (string, error) {
+	w, err := s.kubeClient.Watch(ctx, s.newList(), &client.ListOptions{Raw: &metav1.ListOptions{ResourceVersion: token, AllowWatchBookmarks: true}})
+	if err != nil {
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			return token, ErrResumeTokenExpired
+		}
+		return token, fmt.Errorf("watching objects, %w", err)
+	}
+	defer w.Stop()
+	for {
+		var e watch.Event
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return token, nil
+		case e, ok = <-w.ResultChan():
+			if !ok {
+				return token, nil
+			}
+		}
+		var event Event[T]
+		switch e.Type {
+		case watch.Error:
+			err := apierrors.FromObject(e.Object)
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				return token, ErrResumeTokenExpired
+			}
+			return token, fmt.Errorf("watching objects, %w", err)
+		case watch.Bookmark:
+			accessor, err := meta.Accessor(e.Object)
+			if err != nil {
+				return token, fmt.Errorf("reading bookmark, %w", err)
+			}
+			if accessor.GetResourceVersion() == "" {
+				continue
+			}
+			event = Event[T]{Type: Bookmark, ResumeToken: accessor.GetResourceVersion()}
+		case watch.Added, watch.Modified, watch.Deleted:
+			obj, ok := e.Object.(T)
+			if !ok {
+				return token, fmt.Errorf("unexpected object %T", e.Object)
+			}
+			event = s.event(e.Type, obj)
+		default:
+			continue
+		}
+		if err := handler(ctx, event); err != nil {
+			return token, err
+		}
+		token = event.ResumeToken
+	}
+}
+
+// event builds the event for a change to the object from the last state of the object that was seen
+func (s *Stream[T]) event(eventType watch.EventType, obj T) Event[T] {
+	event := Event[T]{Object: obj, ResumeToken: obj.GetResourceVersion()}
+	previous, seen := s.last[obj.GetUID()]
+	switch eventType {
+	case watch.Added:
+		event.Type = Added
+	case watch.Modified:
+		event.Type = Modified
+	case watch.Deleted:
+		event.Type = Deleted
+	}
+	if eventType == watch.Deleted {
+		delete(s.last, obj.GetUID())
+	} else {
+		s.last[obj.GetUID()] = obj
+	}
+	if seen && eventType != watch.Added {
+		event.Previous = previous
+		event.Changes = Diff(previous, obj)
+	}
+	return event
+}
+
+// Diff returns the fields that changed between two versions of an object, ordered by their path
+func Diff(before, after client.Object) []Change {
+	oldFields, err := comparedFields(before)
+	if err != nil {
+		return nil
+	}
+	newFields, err := comparedFields(after)
+	if err != nil {
+		return nil
+	}
+	return diff(nil, oldFields, newFields)
+}
+
+func diff(path []string, before, after map[string]any) []Change {
+	var changes []Change
+	for _, key := range sets.List(sets.KeySet(before).Union(sets.KeySet(after))) {
+		fieldPath := append(slices.Clone(path), key)
+		oldValue, oldFound := before[key]
+		newValue, newFound := after[key]
+		oldMap, oldIsMap := oldValue.(map[string]any)
+		newMap, newIsMap := newValue.(map[string]any)
+		switch {
+		case oldIsMap && newIsMap:
+			changes = append(changes, diff(fieldPath, oldMap, newMap)...)
+		case oldFound != newFound || !equality.Semantic.DeepEqual(oldValue, newValue):
+			changes = append(changes, Change{Path: fieldPath, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+// comparedFields returns the fields of the object that changes are reported for
+func comparedFields(obj client.Object) (map[string]any, error) {
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	compared := map[string]any{}
+	metadata := map[string]any{}
+	if m, ok := fields["metadata"].(map[string]any); ok {
+		for _, key := range []string{"labels", "annotations", "finalizers", "deletionTimestamp"} {
+			if value, ok := m[key]; ok {
+				metadata[key] = value
+			}
+		}
+	}
+	compared["metadata"] = metadata
+	for _, key := range []string{"spec", "status"} {
+		if value, ok := fields[key]; ok {
+			compared[key] = value
+		}
+	}
+	return compared, nil
+}
+
+func toPointers[T any](items []T) []*T {
+	pointers := make([]*T, len(items))
+	for i := range items {
+		pointers[i] = &items[i]
+	}
+	return pointers
+}
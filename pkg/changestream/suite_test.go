@@ -0,0 +1,200 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package changestream_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/changestream"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+func TestChangeStream(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ChangeStream")
+}
+
+// watchClient serves watches from a fake watcher, so that tests control the events, bookmarks and errors of the watch
+type watchClient struct {
+	client.WithWatch
+	watcher     *watch.RaceFreeFakeWatcher
+	watchOpts   []*metav1.ListOptions
+	watchCalled chan struct{}
+}
+
+func (c *watchClient) Watch(_ context.Context, _ client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	c.watchOpts = append(c.watchOpts, listOpts.AsListOptions())
+	c.watchCalled <- struct{}{}
+	return c.watcher, nil
+}
+
+var _ = Describe("ChangeStream", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var kubeClient *watchClient
+	var nodePool *v1.NodePool
+	var events chan changestream.Event[*v1.NodePool]
+	var handler func(context.Context, changestream.Event[*v1.NodePool]) error
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		nodePool = test.NodePool()
+		nodePool.UID = "nodepool-uid"
+		kubeClient = &watchClient{
+			WithWatch:   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+			watcher:     watch.NewRaceFreeFake(),
+			watchCalled: make(chan struct{}, 10),
+		}
+		events = make(chan changestream.Event[*v1.NodePool], 10)
+		handler = func(_ context.Context, event changestream.Event[*v1.NodePool]) error {
+			events <- event
+			return nil
+		}
+	})
+	AfterEach(func() {
+		cancel()
+	})
+	run := func(token string) chan error {
+		done := make(chan error, 1)
+		stream, ctx, handler := changestream.NodePools(kubeClient), ctx, handler
+		go func() {
+			defer GinkgoRecover()
+			done <- stream.Run(ctx, token, handler)
+		}()
+		return done
+	}
+
+	It("should replay every object as an Added event when started without a resume token", func() {
+		other := test.NodePool()
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		Expect(kubeClient.Create(ctx, other)).To(Succeed())
+		run("")
+		replayed := []changestream.Event[*v1.NodePool]{<-events, <-events}
+		Expect(lo.Map(replayed, func(e changestream.Event[*v1.NodePool], _ int) string { return e.Object.Name })).To(ConsistOf(nodePool.Name, other.Name))
+		Expect(replayed[0].Type).To(Equal(changestream.Added))
+		Expect(replayed[0].ResumeToken).To(BeEmpty())
+		Expect(replayed[1].Type).To(Equal(changestream.Added))
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		Expect(kubeClient.watchOpts[0].ResourceVersion).To(Equal(replayed[1].ResumeToken))
+		Expect(kubeClient.watchOpts[0].AllowWatchBookmarks).To(BeTrue())
+	})
+	It("should resume from the resume token without replaying objects", func() {
+		Expect(kubeClient.Create(ctx, nodePool)).To(Succeed())
+		run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		Expect(kubeClient.watchOpts[0].ResourceVersion).To(Equal("5"))
+		Consistently(events).ShouldNot(Receive())
+	})
+	It("should report the fields that changed", func() {
+		nodePool.ResourceVersion = "10"
+		run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		kubeClient.watcher.Add(nodePool)
+		added := <-events
+		Expect(added.Type).To(Equal(changestream.Added))
+		Expect(added.ResumeToken).To(Equal("10"))
+		Expect(added.Changes).To(BeEmpty())
+
+		updated := nodePool.DeepCopy()
+		updated.ResourceVersion = "11"
+		updated.Spec.Limits = v1.Limits{corev1.ResourceCPU: resource.MustParse("100")}
+		updated.Labels = lo.Assign(updated.Labels, map[string]string{"team.example.com/owner": "billing"})
+		kubeClient.watcher.Modify(updated)
+		modified := <-events
+		Expect(modified.Type).To(Equal(changestream.Modified))
+		Expect(modified.ResumeToken).To(Equal("11"))
+		Expect(modified.Previous).To(Equal(nodePool))
+		Expect(modified.Changes).To(ConsistOf(
+			changestream.Change{Path: []string{"metadata", "labels", "team.example.com/owner"}, New: "billing"},
+			changestream.Change{Path: []string{"spec", "limits", "cpu"}, Old: "2k", New: "100"},
+		))
+
+		deleted := updated.DeepCopy()
+		deleted.ResourceVersion = "12"
+		kubeClient.watcher.Delete(deleted)
+		Eventually(events).Should(Receive(And(
+			HaveField("Type", changestream.Deleted),
+			HaveField("Previous", updated),
+			HaveField("Changes", BeEmpty()),
+		)))
+	})
+	It("should leave Previous unset for the first change of an object after resuming", func() {
+		run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		kubeClient.watcher.Modify(nodePool)
+		modified := <-events
+		Expect(modified.Type).To(Equal(changestream.Modified))
+		Expect(modified.Previous).To(BeNil())
+		Expect(modified.Changes).To(BeEmpty())
+	})
+	It("should advance the resume token with bookmarks", func() {
+		run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		kubeClient.watcher.Action(watch.Bookmark, &v1.NodePool{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "20"}})
+		bookmark := <-events
+		Expect(bookmark.Type).To(Equal(changestream.Bookmark))
+		Expect(bookmark.ResumeToken).To(Equal("20"))
+		Expect(bookmark.Object).To(BeNil())
+	})
+	It("should return ErrResumeTokenExpired when the API server no longer has the changes since the token", func() {
+		done := run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		status := apierrors.NewResourceExpired("too old resource version").Status()
+		kubeClient.watcher.Error(&status)
+		Eventually(done).Should(Receive(MatchError(changestream.ErrResumeTokenExpired)))
+	})
+	It("should stop when the handler returns an error", func() {
+		handlerErr := errors.New("billing system unavailable")
+		handler = func(context.Context, changestream.Event[*v1.NodePool]) error { return handlerErr }
+		done := run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		kubeClient.watcher.Add(nodePool)
+		Eventually(done).Should(Receive(MatchError(handlerErr)))
+	})
+	It("should stop when the context is canceled", func() {
+		done := run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+	It("should return the errors of the API server", func() {
+		status := apierrors.NewForbidden(schema.GroupResource{Group: apis.Group, Resource: "nodepools"}, "", errors.New("forbidden")).Status()
+		done := run("5")
+		Eventually(kubeClient.watchCalled).Should(Receive())
+		kubeClient.watcher.Error(&status)
+		Eventually(done).Should(Receive(Satisfy(apierrors.IsForbidden)))
+	})
+})
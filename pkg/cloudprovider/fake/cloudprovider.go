@@ -41,6 +41,12 @@ import (
 )
 
 var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
+var _ cloudprovider.PreflightChecker = (*CloudProvider)(nil)
+var _ cloudprovider.InstanceHealthChecker = (*CloudProvider)(nil)
+var _ cloudprovider.Rebooter = (*CloudProvider)(nil)
+var _ cloudprovider.InterruptionNotifier = (*CloudProvider)(nil)
+var _ cloudprovider.SpotStabilityReporter = (*CloudProvider)(nil)
+var _ cloudprovider.BatchCreator = (*CloudProvider)(nil)
 
 type CloudProvider struct {
 	InstanceTypes            []*cloudprovider.InstanceType
@@ -51,11 +57,25 @@ type CloudProvider struct {
 	// CreateCalls contains the arguments for every create call that was made since it was cleared
 	CreateCalls        []*v1.NodeClaim
 	AllowedCreateCalls int
-	NextCreateErr      error
-	NextGetErr         error
-	NextDeleteErr      error
-	DeleteCalls        []*v1.NodeClaim
-	GetCalls           []string
+	// BatchCreateCalls contains the arguments for every batch create call, whose NodeClaims are also in CreateCalls
+	BatchCreateCalls [][]*v1.NodeClaim
+	NextCreateErr    error
+	NextGetErr       error
+	NextDeleteErr    error
+	DeleteCalls      []*v1.NodeClaim
+	GetCalls         []string
+	PreflightErr     error
+	PreflightCalls   int
+	Health           cloudprovider.InstanceHealth
+	NextHealthErr    error
+	RebootCalls      []*v1.NodeClaim
+	NextRebootErr    error
+	// Interruptions are returned and cleared by the next InterruptionEvents call
+	Interruptions        []cloudprovider.InterruptionEvent
+	NextInterruptionsErr error
+	// InterruptionRates are the spot interruption rates by instance type name and then zone
+	InterruptionRates        map[string]map[string]float64
+	NextInterruptionRatesErr error
 
 	CreatedNodeClaims         map[string]*v1.NodeClaim
 	Drifted                   cloudprovider.DriftReason
@@ -77,6 +97,7 @@ func (c *CloudProvider) Reset() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.CreateCalls = nil
+	c.BatchCreateCalls = nil
 	c.CreatedNodeClaims = map[string]*v1.NodeClaim{}
 	c.InstanceTypes = nil
 	c.InstanceTypesForNodePool = map[string][]*cloudprovider.InstanceType{}
@@ -87,6 +108,16 @@ func (c *CloudProvider) Reset() {
 	c.NextGetErr = nil
 	c.DeleteCalls = []*v1.NodeClaim{}
 	c.GetCalls = nil
+	c.PreflightErr = nil
+	c.PreflightCalls = 0
+	c.Health = cloudprovider.InstanceHealthUnknown
+	c.NextHealthErr = nil
+	c.RebootCalls = nil
+	c.NextRebootErr = nil
+	c.Interruptions = nil
+	c.NextInterruptionsErr = nil
+	c.InterruptionRates = nil
+	c.NextInterruptionRatesErr = nil
 	c.Drifted = "drifted"
 	c.NodeClassGroupVersionKind = []schema.GroupVersionKind{
 		{
@@ -120,7 +151,7 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v
 	}
 	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
 	np := &v1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: nodeClaim.Labels[v1.NodePoolLabelKey]}}
-	instanceTypes := lo.Filter(lo.Must(c.GetInstanceTypes(ctx, np)), func(i *cloudprovider.InstanceType, _ int) bool {
+	instanceTypes := lo.Filter(c.instanceTypes(np), func(i *cloudprovider.InstanceType, _ int) bool {
 		return reqs.IsCompatible(i.Requirements, scheduling.AllowUndefinedWellKnownLabels) &&
 			i.Offerings.Available().HasCompatible(reqs) &&
 			resources.Fits(nodeClaim.Spec.Resources.Requests, i.Allocatable())
@@ -192,15 +223,24 @@ func (c *CloudProvider) List(_ context.Context) ([]*v1.NodeClaim, error) {
 func (c *CloudProvider) GetInstanceTypes(_ context.Context, np *v1.NodePool) ([]*cloudprovider.InstanceType, error) {
 	if np != nil {
 		if err, ok := c.ErrorsForNodePool[np.Name]; ok {
+			// Stale instance types are returned alongside the error, like a CloudProvider serving them from its cache
+			if cloudprovider.IsStaleInstanceTypesError(err) {
+				return c.instanceTypes(np), err
+			}
 			return nil, err
 		}
+	}
+	return c.instanceTypes(np), nil
+}
 
+func (c *CloudProvider) instanceTypes(np *v1.NodePool) []*cloudprovider.InstanceType {
+	if np != nil {
 		if v, ok := c.InstanceTypesForNodePool[np.Name]; ok {
-			return v, nil
+			return v
 		}
 	}
 	if c.InstanceTypes != nil {
-		return c.InstanceTypes, nil
+		return c.InstanceTypes
 	}
 	return []*cloudprovider.InstanceType{
 		NewInstanceType(InstanceTypeOptions{
@@ -239,7 +279,7 @@ func (c *CloudProvider) GetInstanceTypes(_ context.Context, np *v1.NodePool) ([]
 				corev1.ResourcePods: resource.MustParse("1"),
 			},
 		}),
-	}, nil
+	}
 }
 
 func (c *CloudProvider) Delete(_ context.Context, nc *v1.NodeClaim) error {
@@ -267,6 +307,78 @@ func (c *CloudProvider) IsDrifted(context.Context, *v1.NodeClaim) (cloudprovider
 	return c.Drifted, nil
 }
 
+func (c *CloudProvider) PreflightChecks(context.Context, *v1.NodePool, status.Object) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.PreflightCalls++
+	return c.PreflightErr
+}
+
+func (c *CloudProvider) InstanceHealth(context.Context, *v1.NodeClaim) (cloudprovider.InstanceHealth, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.NextHealthErr != nil {
+		tempError := c.NextHealthErr
+		c.NextHealthErr = nil
+		return cloudprovider.InstanceHealthUnknown, tempError
+	}
+	return c.Health, nil
+}
+
+func (c *CloudProvider) BatchCreate(ctx context.Context, nodeClaims []*v1.NodeClaim) ([]*v1.NodeClaim, []error) {
+	c.mu.Lock()
+	c.BatchCreateCalls = append(c.BatchCreateCalls, nodeClaims)
+	c.mu.Unlock()
+
+	created := make([]*v1.NodeClaim, len(nodeClaims))
+	errs := make([]error, len(nodeClaims))
+	for i := range nodeClaims {
+		created[i], errs[i] = c.Create(ctx, nodeClaims[i])
+	}
+	return created, errs
+}
+
+func (c *CloudProvider) Reboot(_ context.Context, nodeClaim *v1.NodeClaim) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.RebootCalls = append(c.RebootCalls, nodeClaim)
+	if c.NextRebootErr != nil {
+		tempError := c.NextRebootErr
+		c.NextRebootErr = nil
+		return tempError
+	}
+	return nil
+}
+
+func (c *CloudProvider) InterruptionEvents(context.Context) ([]cloudprovider.InterruptionEvent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.NextInterruptionsErr != nil {
+		tempError := c.NextInterruptionsErr
+		c.NextInterruptionsErr = nil
+		return nil, tempError
+	}
+	interruptions := c.Interruptions
+	c.Interruptions = nil
+	return interruptions, nil
+}
+
+func (c *CloudProvider) SpotInterruptionRates(context.Context, *v1.NodePool) (map[string]map[string]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.NextInterruptionRatesErr != nil {
+		tempError := c.NextInterruptionRatesErr
+		c.NextInterruptionRatesErr = nil
+		return nil, tempError
+	}
+	return c.InterruptionRates, nil
+}
+
 func (c *CloudProvider) RepairPolicies() []cloudprovider.RepairPolicy {
 	return c.RepairPolicy
 }
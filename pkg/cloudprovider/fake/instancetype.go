@@ -103,6 +103,7 @@ func NewInstanceTypeWithCustomRequirement(options InstanceTypeOptions, customReq
 		scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, lo.Map(options.Offerings.Available(), func(o cloudprovider.Offering, _ int) string {
 			return o.Requirements.Get(v1.CapacityTypeLabelKey).Any()
 		})...),
+		scheduling.NewRequirement(v1.CPUBurstableLabelKey, corev1.NodeSelectorOpIn, fmt.Sprint(options.BaselineCPU != nil)),
 		scheduling.NewRequirement(LabelInstanceSize, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(ExoticInstanceLabelKey, corev1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(IntegerInstanceLabelKey, corev1.NodeSelectorOpIn, fmt.Sprint(options.Resources.Cpu().Value())),
@@ -123,6 +124,8 @@ func NewInstanceTypeWithCustomRequirement(options InstanceTypeOptions, customReq
 		Requirements: requirements,
 		Offerings:    options.Offerings,
 		Capacity:     options.Resources,
+		Generation:   options.Generation,
+		BaselineCPU:  options.BaselineCPU,
 		Overhead: &cloudprovider.InstanceTypeOverhead{
 			KubeReserved: corev1.ResourceList{
 				corev1.ResourceCPU:    resource.MustParse("100m"),
@@ -198,6 +201,8 @@ type InstanceTypeOptions struct {
 	Architecture     string
 	OperatingSystems sets.Set[string]
 	Resources        corev1.ResourceList
+	Generation       int
+	BaselineCPU      *resource.Quantity
 }
 
 func PriceFromResources(resources corev1.ResourceList) float64 {
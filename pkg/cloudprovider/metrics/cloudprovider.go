@@ -18,15 +18,21 @@ package metrics
 
 import (
 	"context"
+	"fmt"
 
 	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/awslabs/operatorpkg/status"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 )
 
 const (
@@ -40,10 +46,23 @@ const (
 	NodeClaimNotFoundError    = "NodeClaimNotFoundError"
 	NodeClassNotReadyError    = "NodeClassNotReadyError"
 	InsufficientCapacityError = "InsufficientCapacityError"
+	StaleInstanceTypesError   = "StaleInstanceTypesError"
+	QuotaExceededError        = "QuotaExceededError"
+	ThrottledError            = "ThrottledError"
+	UnauthorizedError         = "UnauthorizedError"
 )
 
-// decorator implements CloudProvider
-var _ cloudprovider.CloudProvider = (*decorator)(nil)
+// decorator implements CloudProvider and every optional CloudProvider interface, which it only forwards when the
+// CloudProvider that it decorates implements them as well. Callers use cloudprovider.As to check for these.
+var (
+	_ cloudprovider.CloudProvider         = (*decorator)(nil)
+	_ cloudprovider.BatchCreator          = (*decorator)(nil)
+	_ cloudprovider.PreflightChecker      = (*decorator)(nil)
+	_ cloudprovider.InstanceHealthChecker = (*decorator)(nil)
+	_ cloudprovider.Rebooter              = (*decorator)(nil)
+	_ cloudprovider.InterruptionNotifier  = (*decorator)(nil)
+	_ cloudprovider.SpotStabilityReporter = (*decorator)(nil)
+)
 
 var MethodDuration = opmetrics.NewPrometheusHistogram(
 	crmetrics.Registry,
@@ -93,22 +112,72 @@ func Decorate(cloudProvider cloudprovider.CloudProvider) cloudprovider.CloudProv
 	return &decorator{cloudProvider}
 }
 
+// Unwrap returns the decorated CloudProvider, which cloudprovider.As uses to check which optional interfaces are
+// really implemented
+func (d *decorator) Unwrap() cloudprovider.CloudProvider {
+	return d.CloudProvider
+}
+
+func (d *decorator) BatchCreate(ctx context.Context, nodeClaims []*v1.NodeClaim) ([]*v1.NodeClaim, []error) {
+	method := "BatchCreate"
+	ctx, span := tracing.Tracer().Start(ctx, "CloudProvider."+method, trace.WithAttributes(
+		attribute.String("cloudprovider", d.Name()),
+		attribute.Int("nodeclaims", len(nodeClaims)),
+	))
+	defer span.End()
+	defer metrics.MeasureContext(ctx, MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	creator, ok := d.CloudProvider.(cloudprovider.BatchCreator)
+	if !ok {
+		errs := make([]error, len(nodeClaims))
+		for i := range errs {
+			errs[i] = fmt.Errorf("cloudprovider %s doesn't support batch create", d.Name())
+		}
+		return make([]*v1.NodeClaim, len(nodeClaims)), errs
+	}
+	created, errs := creator.BatchCreate(ctx, nodeClaims)
+	for _, err := range errs {
+		if err != nil {
+			ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+			span.RecordError(err)
+		}
+	}
+	return created, errs
+}
+
+func (d *decorator) PreflightChecks(ctx context.Context, nodePool *v1.NodePool, nodeClass status.Object) error {
+	method := "PreflightChecks"
+	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	err := d.CloudProvider.(cloudprovider.PreflightChecker).PreflightChecks(ctx, nodePool, nodeClass)
+	if err != nil {
+		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+	}
+	return err
+}
+
 func (d *decorator) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
 	method := "Create"
-	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	ctx, span := startSpan(ctx, d, method, nodeClaim)
+	defer span.End()
+	defer metrics.MeasureContext(ctx, MethodDuration, getLabelsMapForDuration(ctx, d, method))()
 	nodeClaim, err := d.CloudProvider.Create(ctx, nodeClaim)
 	if err != nil {
 		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
 	return nodeClaim, err
 }
 
 func (d *decorator) Delete(ctx context.Context, nodeClaim *v1.NodeClaim) error {
 	method := "Delete"
-	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	ctx, span := startSpan(ctx, d, method, nodeClaim)
+	defer span.End()
+	defer metrics.MeasureContext(ctx, MethodDuration, getLabelsMapForDuration(ctx, d, method))()
 	err := d.CloudProvider.Delete(ctx, nodeClaim)
 	if err != nil {
 		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
 	return err
 }
@@ -153,6 +222,50 @@ func (d *decorator) IsDrifted(ctx context.Context, nodeClaim *v1.NodeClaim) (clo
 	return isDrifted, err
 }
 
+func (d *decorator) InstanceHealth(ctx context.Context, nodeClaim *v1.NodeClaim) (cloudprovider.InstanceHealth, error) {
+	method := "InstanceHealth"
+	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	health, err := d.CloudProvider.(cloudprovider.InstanceHealthChecker).InstanceHealth(ctx, nodeClaim)
+	if err != nil {
+		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+	}
+	return health, err
+}
+
+func (d *decorator) Reboot(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	method := "Reboot"
+	ctx, span := startSpan(ctx, d, method, nodeClaim)
+	defer span.End()
+	defer metrics.MeasureContext(ctx, MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	err := d.CloudProvider.(cloudprovider.Rebooter).Reboot(ctx, nodeClaim)
+	if err != nil {
+		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (d *decorator) InterruptionEvents(ctx context.Context) ([]cloudprovider.InterruptionEvent, error) {
+	method := "InterruptionEvents"
+	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	events, err := d.CloudProvider.(cloudprovider.InterruptionNotifier).InterruptionEvents(ctx)
+	if err != nil {
+		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+	}
+	return events, err
+}
+
+func (d *decorator) SpotInterruptionRates(ctx context.Context, nodePool *v1.NodePool) (map[string]map[string]float64, error) {
+	method := "SpotInterruptionRates"
+	defer metrics.Measure(MethodDuration, getLabelsMapForDuration(ctx, d, method))()
+	rates, err := d.CloudProvider.(cloudprovider.SpotStabilityReporter).SpotInterruptionRates(ctx, nodePool)
+	if err != nil {
+		ErrorsTotal.Inc(getLabelsMapForError(ctx, d, method, err))
+	}
+	return rates, err
+}
+
 // getLabelsMapForDuration is a convenience func that constructs a map[string]string
 // for a prometheus Label map used to compose a duration metric spec
 func getLabelsMapForDuration(ctx context.Context, d *decorator, method string) map[string]string {
@@ -184,7 +297,25 @@ func GetErrorTypeLabelValue(err error) string {
 		return NodeClaimNotFoundError
 	case cloudprovider.IsNodeClassNotReadyError(err):
 		return NodeClassNotReadyError
+	case cloudprovider.IsStaleInstanceTypesError(err):
+		return StaleInstanceTypesError
+	case cloudprovider.IsQuotaExceededError(err):
+		return QuotaExceededError
+	case cloudprovider.IsThrottledError(err):
+		return ThrottledError
+	case cloudprovider.IsUnauthorizedError(err):
+		return UnauthorizedError
 	default:
 		return MetricLabelErrorDefaultVal
 	}
 }
+
+// startSpan traces a CloudProvider call that launches or terminates the capacity for a NodeClaim
+func startSpan(ctx context.Context, d *decorator, method string, nodeClaim *v1.NodeClaim) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "CloudProvider."+method, trace.WithAttributes(
+		attribute.String("cloudprovider", d.Name()),
+		attribute.String("nodeclaim", nodeClaim.Name),
+		attribute.String("nodepool", nodeClaim.Labels[v1.NodePoolLabelKey]),
+		attribute.String("providerid", nodeClaim.Status.ProviderID),
+	))
+}
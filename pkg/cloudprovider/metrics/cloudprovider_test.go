@@ -17,21 +17,109 @@ limitations under the License.
 package metrics_test
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/metrics"
+	"sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 )
 
 var _ = Describe("Cloudprovider", func() {
 	var nodeClaimNotFoundErr = cloudprovider.NewNodeClaimNotFoundError(errors.New("not found"))
 	var insufficientCapacityErr = cloudprovider.NewInsufficientCapacityError(errors.New("not enough capacity"))
 	var nodeClassNotReadyErr = cloudprovider.NewNodeClassNotReadyError(errors.New("not ready"))
+	var staleInstanceTypesErr = cloudprovider.NewStaleInstanceTypesError(errors.New("throttled"), time.Now())
+	var quotaExceededErr = cloudprovider.NewQuotaExceededError(errors.New("vcpu limit reached"))
+	var throttledErr = cloudprovider.NewThrottledError(errors.New("request limit exceeded"), time.Second)
+	var unauthorizedErr = cloudprovider.NewUnauthorizedError(errors.New("access denied"))
 	var unknownErr = errors.New("this is an error we don't know about")
 
+	Describe("Decorate", func() {
+		var cloudProvider *fake.CloudProvider
+		BeforeEach(func() {
+			cloudProvider = fake.NewCloudProvider()
+		})
+		It("should launch nodeclaims in batches if the cloudprovider does", func() {
+			creator, ok := cloudprovider.As[cloudprovider.BatchCreator](metrics.Decorate(cloudProvider))
+			Expect(ok).To(BeTrue())
+			creator.BatchCreate(context.Background(), []*v1.NodeClaim{test.NodeClaim()})
+			Expect(cloudProvider.BatchCreateCalls).To(HaveLen(1))
+		})
+		It("should not launch nodeclaims in batches if the cloudprovider doesn't", func() {
+			_, ok := cloudprovider.As[cloudprovider.BatchCreator](metrics.Decorate(struct{ cloudprovider.CloudProvider }{cloudProvider}))
+			Expect(ok).To(BeFalse())
+		})
+		It("should fail to launch nodeclaims in batches when called directly if the cloudprovider doesn't", func() {
+			creator, ok := metrics.Decorate(struct{ cloudprovider.CloudProvider }{cloudProvider}).(cloudprovider.BatchCreator)
+			Expect(ok).To(BeTrue())
+			created, errs := creator.BatchCreate(context.Background(), []*v1.NodeClaim{test.NodeClaim(), test.NodeClaim()})
+			Expect(created).To(HaveLen(2))
+			Expect(errs).To(HaveLen(2))
+			for _, err := range errs {
+				Expect(err).To(MatchError(ContainSubstring("doesn't support batch create")))
+			}
+			Expect(cloudProvider.BatchCreateCalls).To(BeEmpty())
+		})
+		It("should run preflight checks if the cloudprovider does", func() {
+			cloudProvider.PreflightErr = errors.New("image not found")
+			checker, ok := cloudprovider.As[cloudprovider.PreflightChecker](metrics.Decorate(cloudProvider))
+			Expect(ok).To(BeTrue())
+			Expect(checker.PreflightChecks(context.Background(), test.NodePool(), &v1alpha1.TestNodeClass{})).To(MatchError("image not found"))
+			Expect(cloudProvider.PreflightCalls).To(Equal(1))
+		})
+		It("should not run preflight checks if the cloudprovider doesn't", func() {
+			_, ok := cloudprovider.As[cloudprovider.PreflightChecker](metrics.Decorate(struct{ cloudprovider.CloudProvider }{cloudProvider}))
+			Expect(ok).To(BeFalse())
+		})
+		It("should check instance health if the cloudprovider does", func() {
+			cloudProvider.Health = cloudprovider.InstanceImpaired
+			checker, ok := cloudprovider.As[cloudprovider.InstanceHealthChecker](metrics.Decorate(cloudProvider))
+			Expect(ok).To(BeTrue())
+			Expect(checker.InstanceHealth(context.Background(), test.NodeClaim())).To(Equal(cloudprovider.InstanceImpaired))
+		})
+		It("should not check instance health if the cloudprovider doesn't", func() {
+			_, ok := cloudprovider.As[cloudprovider.InstanceHealthChecker](metrics.Decorate(struct{ cloudprovider.CloudProvider }{cloudProvider}))
+			Expect(ok).To(BeFalse())
+		})
+		It("should reboot instances if the cloudprovider does", func() {
+			rebooter, ok := cloudprovider.As[cloudprovider.Rebooter](metrics.Decorate(cloudProvider))
+			Expect(ok).To(BeTrue())
+			Expect(rebooter.Reboot(context.Background(), test.NodeClaim())).To(Succeed())
+			Expect(cloudProvider.RebootCalls).To(HaveLen(1))
+		})
+		It("should not reboot instances if the cloudprovider doesn't", func() {
+			_, ok := cloudprovider.As[cloudprovider.Rebooter](metrics.Decorate(struct{ cloudprovider.CloudProvider }{cloudProvider}))
+			Expect(ok).To(BeFalse())
+		})
+		It("should receive interruption events if the cloudprovider sends them", func() {
+			cloudProvider.Interruptions = []cloudprovider.InterruptionEvent{{ProviderID: "fake:///test", Kind: cloudprovider.InterruptionSpot}}
+			notifier, ok := cloudprovider.As[cloudprovider.InterruptionNotifier](metrics.Decorate(cloudProvider))
+			Expect(ok).To(BeTrue())
+			Expect(notifier.InterruptionEvents(context.Background())).To(HaveLen(1))
+		})
+		It("should not receive interruption events if the cloudprovider doesn't send them", func() {
+			_, ok := cloudprovider.As[cloudprovider.InterruptionNotifier](metrics.Decorate(struct{ cloudprovider.CloudProvider }{cloudProvider}))
+			Expect(ok).To(BeFalse())
+		})
+		It("should report spot interruption rates if the cloudprovider does", func() {
+			cloudProvider.InterruptionRates = map[string]map[string]float64{"default-instance-type": {"test-zone-1": 0.1}}
+			reporter, ok := cloudprovider.As[cloudprovider.SpotStabilityReporter](metrics.Decorate(cloudProvider))
+			Expect(ok).To(BeTrue())
+			Expect(reporter.SpotInterruptionRates(context.Background(), test.NodePool())).To(Equal(cloudProvider.InterruptionRates))
+		})
+		It("should not report spot interruption rates if the cloudprovider doesn't", func() {
+			_, ok := cloudprovider.As[cloudprovider.SpotStabilityReporter](metrics.Decorate(struct{ cloudprovider.CloudProvider }{cloudProvider}))
+			Expect(ok).To(BeFalse())
+		})
+	})
 	Describe("CloudProvider nodeclaim errors via GetErrorTypeLabelValue()", func() {
 		Context("when the error is known", func() {
 			It("nodeclaim not found should be recognized", func() {
@@ -43,6 +131,18 @@ var _ = Describe("Cloudprovider", func() {
 			It("nodeclass not ready should be recognized", func() {
 				Expect(metrics.GetErrorTypeLabelValue(nodeClassNotReadyErr)).To(Equal(metrics.NodeClassNotReadyError))
 			})
+			It("stale instance types should be recognized", func() {
+				Expect(metrics.GetErrorTypeLabelValue(staleInstanceTypesErr)).To(Equal(metrics.StaleInstanceTypesError))
+			})
+			It("quota exceeded should be recognized", func() {
+				Expect(metrics.GetErrorTypeLabelValue(quotaExceededErr)).To(Equal(metrics.QuotaExceededError))
+			})
+			It("throttled should be recognized", func() {
+				Expect(metrics.GetErrorTypeLabelValue(throttledErr)).To(Equal(metrics.ThrottledError))
+			})
+			It("unauthorized should be recognized", func() {
+				Expect(metrics.GetErrorTypeLabelValue(unauthorizedErr)).To(Equal(metrics.UnauthorizedError))
+			})
 		})
 		Context("when the error is unknown", func() {
 			It("should always return empty string", func() {
@@ -25,9 +25,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/awslabs/operatorpkg/option"
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -38,6 +40,7 @@ import (
 var (
 	SpotRequirement     = scheduling.NewRequirements(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeSpot))
 	OnDemandRequirement = scheduling.NewRequirements(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeOnDemand))
+	ReservedRequirement = scheduling.NewRequirements(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved))
 )
 
 type DriftReason string
@@ -66,7 +69,8 @@ type CloudProvider interface {
 	// GetInstanceTypes returns instance types supported by the cloudprovider.
 	// Availability of types or zone may vary by nodepool or over time.  Regardless of
 	// availability, the GetInstanceTypes method should always return all instance types,
-	// even those with no offerings available.
+	// even those with no offerings available. If the instance types can only be partially
+	// resolved, a StaleInstanceTypesError should be returned along with the cached instance types.
 	GetInstanceTypes(context.Context, *v1.NodePool) ([]*InstanceType, error)
 	// IsDrifted returns whether a NodeClaim has drifted from the provisioning requirements
 	// it is tied to.
@@ -81,6 +85,102 @@ type CloudProvider interface {
 	GetSupportedNodeClasses() []status.Object
 }
 
+// PreflightChecker is an optional interface which CloudProviders can implement to verify that nodes can be launched
+// for a NodePool and its NodeClass (e.g. the image exists or the subnet has free addresses) before the first launch.
+// Failures are surfaced on the NodePool's PreflightChecksSucceeded condition, which keeps the NodePool from being
+// used for provisioning while the checks fail.
+type PreflightChecker interface {
+	PreflightChecks(context.Context, *v1.NodePool, status.Object) error
+}
+
+// As returns the CloudProvider as the optional interface T if it implements it. CloudProviders which wrap another
+// CloudProvider, like the metrics decorator, implement every optional interface and return the CloudProvider that they
+// wrap from Unwrap, so they're only treated as implementing T when the CloudProvider that they wrap implements it too.
+func As[T any](cloudProvider CloudProvider) (T, bool) {
+	t, ok := cloudProvider.(T)
+	if !ok {
+		return t, false
+	}
+	if wrapper, ok := cloudProvider.(interface{ Unwrap() CloudProvider }); ok {
+		if _, ok = As[T](wrapper.Unwrap()); !ok {
+			var zero T
+			return zero, false
+		}
+	}
+	return t, true
+}
+
+// BatchCreator is an optional interface which CloudProviders can implement to launch identical NodeClaims with a
+// single call, e.g. through a fleet-style API. When many identical NodeClaims are launched at once, the NodeClaims that
+// are waiting on an in-flight launch are batched together rather than being created one at a time. BatchCreate returns
+// the hydrated NodeClaim or the error for each NodeClaim, in the same order as the NodeClaims that were passed to it.
+type BatchCreator interface {
+	BatchCreate(context.Context, []*v1.NodeClaim) ([]*v1.NodeClaim, []error)
+}
+
+// InstanceHealth is the health of a NodeClaim's instance, as observed by the CloudProvider rather than the kubelet
+type InstanceHealth string
+
+const (
+	// InstanceHealthy instances are running and passing the CloudProvider's status checks
+	InstanceHealthy InstanceHealth = "Healthy"
+	// InstanceImpaired instances are running, but failing the CloudProvider's status checks
+	InstanceImpaired InstanceHealth = "Impaired"
+	// InstanceHealthUnknown is reported when the CloudProvider can't determine the instance's health
+	InstanceHealthUnknown InstanceHealth = "Unknown"
+)
+
+// InstanceHealthChecker is an optional interface which CloudProviders can implement to report the health of a
+// NodeClaim's instance. Node repair uses it to tell a node that's lost its connection to the API server, but is still
+// running, apart from one whose instance is broken. Instances that no longer exist should return a NodeClaimNotFoundError.
+type InstanceHealthChecker interface {
+	InstanceHealth(context.Context, *v1.NodeClaim) (InstanceHealth, error)
+}
+
+// Rebooter is an optional interface which CloudProviders can implement to reboot a NodeClaim's instance in place.
+// Node repair reboots impaired instances once before replacing them.
+type Rebooter interface {
+	Reboot(context.Context, *v1.NodeClaim) error
+}
+
+// InterruptionKind is the kind of interruption that a CloudProvider has given notice of
+type InterruptionKind string
+
+const (
+	// InterruptionSpot notices are given before a spot instance is reclaimed
+	InterruptionSpot InterruptionKind = "SpotInterruption"
+	// InterruptionScheduledMaintenance notices are given for maintenance that will stop or retire the instance
+	InterruptionScheduledMaintenance InterruptionKind = "ScheduledMaintenance"
+	// InterruptionInstanceStopping notices are given when the instance is being stopped or terminated outside of Karpenter
+	InterruptionInstanceStopping InterruptionKind = "InstanceStopping"
+)
+
+// InterruptionEvent is a notice from the CloudProvider that an instance is about to be interrupted
+type InterruptionEvent struct {
+	// ProviderID of the instance that will be interrupted
+	ProviderID string
+	// Kind of the interruption
+	Kind InterruptionKind
+	// Time is when the instance will be interrupted, or zero if the CloudProvider doesn't know
+	Time time.Time
+}
+
+// InterruptionNotifier is an optional interface which CloudProviders can implement to give notice of instances that are
+// about to be interrupted, e.g. when spot capacity is reclaimed. The interruption controller polls it and drains the
+// NodeClaims of those instances before they go away. Events are considered handled once they've been returned, so each
+// notice should only be returned once.
+type InterruptionNotifier interface {
+	InterruptionEvents(context.Context) ([]InterruptionEvent, error)
+}
+
+// SpotStabilityReporter is an optional interface which CloudProviders can implement to report how likely instances
+// launched into spot offerings are to be interrupted. Rates range from 0 to 1 and are keyed by instance type name and
+// then zone. NodePools with the PriceCapacityOptimized spot allocation strategy weigh spot prices by these rates.
+// Offerings without a rate are weighed as if they're never interrupted.
+type SpotStabilityReporter interface {
+	SpotInterruptionRates(context.Context, *v1.NodePool) (map[string]map[string]float64, error)
+}
+
 // InstanceType describes the properties of a potential node (either concrete attributes of an instance of this type
 // or supported options in the case of arrays)
 type InstanceType struct {
@@ -96,6 +196,13 @@ type InstanceType struct {
 	// Overhead is the amount of resource overhead expected to be used by kubelet and any other system daemons outside
 	// of Kubernetes.
 	Overhead *InstanceTypeOverhead
+	// Generation of the instance type within its family, where a higher generation is newer. Zero if the
+	// CloudProvider doesn't know the generation.
+	Generation int
+	// BaselineCPU is the CPU that a burstable instance type can sustain, which is less than its CPU capacity. Nil if
+	// the instance type isn't burstable. Burstable instance types should also have a karpenter.sh/cpu-burstable
+	// requirement of "true".
+	BaselineCPU *resource.Quantity
 
 	once        sync.Once
 	allocatable corev1.ResourceList
@@ -114,21 +221,91 @@ func (i *InstanceType) Allocatable() corev1.ResourceList {
 	return i.allocatable.DeepCopy()
 }
 
-func (its InstanceTypes) OrderByPrice(reqs scheduling.Requirements) InstanceTypes {
+// WithBaselineCPU returns a copy of the instance type with its baseline CPU as its CPU capacity, so that pods are
+// packed against the CPU it can sustain rather than the CPU it can burst to. Instance types that aren't burstable are
+// returned as is.
+func (i *InstanceType) WithBaselineCPU() *InstanceType {
+	if i.BaselineCPU == nil || i.BaselineCPU.Cmp(*i.Capacity.Cpu()) >= 0 {
+		return i
+	}
+	capacity := i.Capacity.DeepCopy()
+	capacity[corev1.ResourceCPU] = i.BaselineCPU.DeepCopy()
+	return &InstanceType{
+		Name:         i.Name,
+		Requirements: i.Requirements,
+		Offerings:    i.Offerings,
+		Capacity:     capacity,
+		Overhead:     i.Overhead,
+		Generation:   i.Generation,
+		BaselineCPU:  i.BaselineCPU,
+	}
+}
+
+// WithSpotInterruptionRates returns a copy of the instance type with the interruption rates of its spot offerings set
+// from rates, which are keyed by zone. Instance types without any rates are returned as is.
+func (i *InstanceType) WithSpotInterruptionRates(rates map[string]float64) *InstanceType {
+	if len(rates) == 0 {
+		return i
+	}
+	offerings := lo.Map(i.Offerings, func(o Offering, _ int) Offering {
+		if o.Requirements.Get(v1.CapacityTypeLabelKey).Any() == v1.CapacityTypeSpot {
+			o.InterruptionRate = rates[o.Requirements.Get(corev1.LabelTopologyZone).Any()]
+		}
+		return o
+	})
+	return &InstanceType{
+		Name:         i.Name,
+		Requirements: i.Requirements,
+		Offerings:    offerings,
+		Capacity:     i.Capacity,
+		Overhead:     i.Overhead,
+		Generation:   i.Generation,
+		BaselineCPU:  i.BaselineCPU,
+	}
+}
+
+// OrderOptions configure how instance types are ordered by price
+type OrderOptions struct {
+	PreferNewerGenerations bool
+	PriceCapacityOptimized bool
+}
+
+// PreferNewerGenerations orders instance types of the same price by newest generation before name
+func PreferNewerGenerations(prefer bool) func(*OrderOptions) {
+	return func(o *OrderOptions) { o.PreferNewerGenerations = prefer }
+}
+
+// PriceCapacityOptimized orders instance types by the price of their offerings weighted by their interruption rate
+func PriceCapacityOptimized(optimize bool) func(*OrderOptions) {
+	return func(o *OrderOptions) { o.PriceCapacityOptimized = optimize }
+}
+
+// OrderByPrice orders instance types by the price of their cheapest compatible offering. If PriceCapacityOptimized is
+// set, the price of each offering is weighted by its interruption rate, so that an offering that's always interrupted
+// costs twice its price. Ties are broken by generation if PreferNewerGenerations is set, and then by name.
+func (its InstanceTypes) OrderByPrice(reqs scheduling.Requirements, opts ...option.Function[OrderOptions]) InstanceTypes {
+	o := option.Resolve(opts...)
+	price := func(it *InstanceType) float64 {
+		ofs := it.Offerings.Available().Compatible(reqs)
+		if len(ofs) == 0 {
+			return math.MaxFloat64
+		}
+		if o.PriceCapacityOptimized {
+			return lo.Min(lo.Map(ofs, func(of Offering, _ int) float64 { return of.WeightedPrice() }))
+		}
+		return ofs.Cheapest().Price
+	}
 	// Order instance types so that we get the cheapest instance types of the available offerings
 	sort.Slice(its, func(i, j int) bool {
-		iPrice := math.MaxFloat64
-		jPrice := math.MaxFloat64
-		if ofs := its[i].Offerings.Available().Compatible(reqs); len(ofs) > 0 {
-			iPrice = ofs.Cheapest().Price
-		}
-		if ofs := its[j].Offerings.Available().Compatible(reqs); len(ofs) > 0 {
-			jPrice = ofs.Cheapest().Price
+		iPrice := price(its[i])
+		jPrice := price(its[j])
+		if iPrice != jPrice {
+			return iPrice < jPrice
 		}
-		if iPrice == jPrice {
-			return its[i].Name < its[j].Name
+		if o.PreferNewerGenerations && its[i].Generation != its[j].Generation {
+			return its[i].Generation > its[j].Generation
 		}
-		return iPrice < jPrice
+		return its[i].Name < its[j].Name
 	})
 	return its
 }
@@ -213,8 +390,8 @@ func (its InstanceTypes) SatisfiesMinValues(requirements scheduling.Requirements
 
 // Truncate truncates the InstanceTypes based on the passed-in requirements
 // It returns an error if it isn't possible to truncate the instance types on maxItems without violating minValues
-func (its InstanceTypes) Truncate(requirements scheduling.Requirements, maxItems int) (InstanceTypes, error) {
-	truncatedInstanceTypes := lo.Slice(its.OrderByPrice(requirements), 0, maxItems)
+func (its InstanceTypes) Truncate(requirements scheduling.Requirements, maxItems int, opts ...option.Function[OrderOptions]) (InstanceTypes, error) {
+	truncatedInstanceTypes := lo.Slice(its.OrderByPrice(requirements, opts...), 0, maxItems)
 	// Only check for a validity of NodeClaim if its requirement has minValues in it.
 	if requirements.HasMinValues() {
 		if _, err := truncatedInstanceTypes.SatisfiesMinValues(requirements); err != nil {
@@ -224,6 +401,50 @@ func (its InstanceTypes) Truncate(requirements scheduling.Requirements, maxItems
 	return truncatedInstanceTypes, nil
 }
 
+// SpotDiversity returns the number of instance types, and the number of zones, that have an available spot offering
+// compatible with the requirements
+func (its InstanceTypes) SpotDiversity(requirements scheduling.Requirements) (instanceTypes int, zones int) {
+	spotZones := sets.New[string]()
+	for _, it := range its {
+		ofs := it.Offerings.Available().Compatible(requirements).Compatible(SpotRequirement)
+		if len(ofs) == 0 {
+			continue
+		}
+		instanceTypes++
+		spotZones.Insert(ofs.Zones().UnsortedList()...)
+	}
+	return instanceTypes, spotZones.Len()
+}
+
+// Diversify selects at most maxItems instance types, ordered by price, like Truncate. Rather than taking the cheapest
+// instance types outright, it first takes the cheapest ones needed for their spot offerings to span minInstanceTypes
+// instance types and minZones zones, and then fills the remainder by price.
+func (its InstanceTypes) Diversify(requirements scheduling.Requirements, maxItems, minInstanceTypes, minZones int, opts ...option.Function[OrderOptions]) InstanceTypes {
+	ordered := its.OrderByPrice(requirements, opts...)
+	selected := sets.New[string]()
+	spotZones := sets.New[string]()
+	for _, it := range ordered {
+		if selected.Len() >= maxItems || (selected.Len() >= minInstanceTypes && spotZones.Len() >= minZones) {
+			break
+		}
+		ofs := it.Offerings.Available().Compatible(requirements).Compatible(SpotRequirement)
+		if len(ofs) == 0 {
+			continue
+		}
+		if selected.Len() < minInstanceTypes || !spotZones.IsSuperset(ofs.Zones()) {
+			selected.Insert(it.Name)
+			spotZones.Insert(ofs.Zones().UnsortedList()...)
+		}
+	}
+	for _, it := range ordered {
+		if selected.Len() >= maxItems {
+			break
+		}
+		selected.Insert(it.Name)
+	}
+	return lo.Filter(ordered, func(it *InstanceType, _ int) bool { return selected.Has(it.Name) })
+}
+
 type InstanceTypeOverhead struct {
 	// KubeReserved returns the default resources allocated to kubernetes system daemons by default
 	KubeReserved corev1.ResourceList
@@ -247,6 +468,17 @@ type Offering struct {
 	// Available is added so that Offerings can return all offerings that have ever existed for an instance type,
 	// so we can get historical pricing data for calculating savings in consolidation
 	Available bool
+	// ReservationCapacity is the number of instances that can still be launched into the capacity reservation of a
+	// reserved offering. It's ignored for other capacity types.
+	ReservationCapacity int
+	// InterruptionRate is the likelihood, from 0 to 1, that an instance launched into a spot offering is interrupted.
+	// It's set from the rates reported by a SpotStabilityReporter and is ignored for other capacity types.
+	InterruptionRate float64
+}
+
+// WeightedPrice is the price of the offering weighted by its interruption rate
+func (o Offering) WeightedPrice() float64 {
+	return o.Price * (1 + o.InterruptionRate)
 }
 
 type Offerings []Offering
@@ -282,6 +514,11 @@ func (ofs Offerings) Cheapest() Offering {
 	})
 }
 
+// Zones returns the zones that the offerings are in
+func (ofs Offerings) Zones() sets.Set[string] {
+	return sets.New(lo.Map(ofs, func(o Offering, _ int) string { return o.Requirements.Get(corev1.LabelTopologyZone).Any() })...)
+}
+
 // MostExpensive returns the most expensive offering from the return offerings
 func (ofs Offerings) MostExpensive() Offering {
 	return lo.MaxBy(ofs, func(a, b Offering) bool {
@@ -290,9 +527,15 @@ func (ofs Offerings) MostExpensive() Offering {
 }
 
 // WorstLaunchPrice gets the worst-case launch price from the offerings that are offered
-// on an instance type. If the instance type has a spot offering available, then it uses the spot offering
-// to get the launch price; else, it uses the on-demand launch price
+// on an instance type. Reserved offerings are preferred, followed by spot offerings, so if the instance type has one
+// of those available, it's used to get the launch price; else, it uses the on-demand launch price
 func (ofs Offerings) WorstLaunchPrice(reqs scheduling.Requirements) float64 {
+	if reqs.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeReserved) {
+		reservedOfferings := ofs.Compatible(reqs).Compatible(ReservedRequirement)
+		if len(reservedOfferings) > 0 {
+			return reservedOfferings.MostExpensive().Price
+		}
+	}
 	// We prefer to launch spot offerings, so we will get the worst price based on the node requirements
 	if reqs.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeSpot) {
 		spotOfferings := ofs.Compatible(reqs).Compatible(SpotRequirement)
@@ -339,14 +582,25 @@ func IgnoreNodeClaimNotFoundError(err error) error {
 	return err
 }
 
-// InsufficientCapacityError is an error type returned by CloudProviders when a launch fails due to a lack of capacity from NodeClaim requirements
+// OfferingKey identifies a single offering of an instance type
+type OfferingKey struct {
+	InstanceType string
+	Zone         string
+	CapacityType string
+}
+
+// InsufficientCapacityError is an error type returned by CloudProviders when a launch fails due to a lack of capacity from NodeClaim requirements.
+// Offerings are the offerings that were out of capacity, which aren't launched again until the capacity is likely to
+// have recovered.
 type InsufficientCapacityError struct {
 	error
+	Offerings []OfferingKey
 }
 
-func NewInsufficientCapacityError(err error) *InsufficientCapacityError {
+func NewInsufficientCapacityError(err error, offerings ...OfferingKey) *InsufficientCapacityError {
 	return &InsufficientCapacityError{
-		error: err,
+		error:     err,
+		Offerings: offerings,
 	}
 }
 
@@ -362,6 +616,81 @@ func IsInsufficientCapacityError(err error) bool {
 	return errors.As(err, &icErr)
 }
 
+// QuotaExceededError is an error type returned by CloudProviders when a launch fails because an account or project
+// quota would be exceeded. Unlike insufficient capacity, it doesn't go away until the quota is raised or other nodes
+// are removed, so the NodePool isn't launched from for a while.
+type QuotaExceededError struct {
+	error
+}
+
+func NewQuotaExceededError(err error) *QuotaExceededError {
+	return &QuotaExceededError{
+		error: err,
+	}
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded, %s", e.error)
+}
+
+func IsQuotaExceededError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var qeErr *QuotaExceededError
+	return errors.As(err, &qeErr)
+}
+
+// ThrottledError is an error type returned by CloudProviders when a call is rate limited by the provider's API.
+// RetryAfter is how long the provider asked callers to wait, or zero if it didn't say.
+type ThrottledError struct {
+	error
+	RetryAfter time.Duration
+}
+
+func NewThrottledError(err error, retryAfter time.Duration) *ThrottledError {
+	return &ThrottledError{
+		error:      err,
+		RetryAfter: retryAfter,
+	}
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("throttled, %s", e.error)
+}
+
+func IsThrottledError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var tErr *ThrottledError
+	return errors.As(err, &tErr)
+}
+
+// UnauthorizedError is an error type returned by CloudProviders when their credentials don't permit a call, which
+// won't succeed on retry until the credentials or permissions are fixed
+type UnauthorizedError struct {
+	error
+}
+
+func NewUnauthorizedError(err error) *UnauthorizedError {
+	return &UnauthorizedError{
+		error: err,
+	}
+}
+
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized, %s", e.error)
+}
+
+func IsUnauthorizedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var uErr *UnauthorizedError
+	return errors.As(err, &uErr)
+}
+
 // NodeClassNotReadyError is an error type returned by CloudProviders when a NodeClass that is used by the launch process doesn't have all its resolved fields
 type NodeClassNotReadyError struct {
 	error
@@ -385,6 +714,33 @@ func IsNodeClassNotReadyError(err error) bool {
 	return errors.As(err, &nrError)
 }
 
+// StaleInstanceTypesError is an error type returned by CloudProviders from GetInstanceTypes alongside a partial or
+// cached set of instance types when they couldn't all be resolved, e.g. during a provider API brownout. LastUpdated
+// is when the returned instance types were last fully resolved.
+type StaleInstanceTypesError struct {
+	error
+	LastUpdated time.Time
+}
+
+func NewStaleInstanceTypesError(err error, lastUpdated time.Time) *StaleInstanceTypesError {
+	return &StaleInstanceTypesError{
+		error:       err,
+		LastUpdated: lastUpdated,
+	}
+}
+
+func (e *StaleInstanceTypesError) Error() string {
+	return fmt.Sprintf("instance types are stale since %s, %s", e.LastUpdated.Format(time.RFC3339), e.error)
+}
+
+func IsStaleInstanceTypesError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var siErr *StaleInstanceTypesError
+	return errors.As(err, &siErr)
+}
+
 // CreateError is an error type returned by CloudProviders when instance creation fails
 type CreateError struct {
 	error
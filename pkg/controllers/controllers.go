@@ -33,9 +33,13 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
+	metricsheadroom "sigs.k8s.io/karpenter/pkg/controllers/metrics/headroom"
 	metricsnode "sigs.k8s.io/karpenter/pkg/controllers/metrics/node"
 	metricsnodepool "sigs.k8s.io/karpenter/pkg/controllers/metrics/nodepool"
 	metricspod "sigs.k8s.io/karpenter/pkg/controllers/metrics/pod"
+	nodeadoption "sigs.k8s.io/karpenter/pkg/controllers/node/adoption"
+	nodeexclusive "sigs.k8s.io/karpenter/pkg/controllers/node/exclusive"
+	nodefidelity "sigs.k8s.io/karpenter/pkg/controllers/node/fidelity"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/health"
 	nodehydration "sigs.k8s.io/karpenter/pkg/controllers/node/hydration"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination"
@@ -45,13 +49,25 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/expiration"
 	nodeclaimgarbagecollection "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/garbagecollection"
 	nodeclaimhydration "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/hydration"
+	nodeclaiminterruption "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/interruption"
 	nodeclaimlifecycle "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
 	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/podevents"
+	nodeclaimproviderid "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/providerid"
 	nodepoolcounter "sigs.k8s.io/karpenter/pkg/controllers/nodepool/counter"
+	nodepooldeletionsimulation "sigs.k8s.io/karpenter/pkg/controllers/nodepool/deletionsimulation"
+	nodepooldisruptionprofile "sigs.k8s.io/karpenter/pkg/controllers/nodepool/disruptionprofile"
+	nodepooldriftimpact "sigs.k8s.io/karpenter/pkg/controllers/nodepool/driftimpact"
+	nodepooleffective "sigs.k8s.io/karpenter/pkg/controllers/nodepool/effective"
 	nodepoolhash "sigs.k8s.io/karpenter/pkg/controllers/nodepool/hash"
+	nodepoolheadroom "sigs.k8s.io/karpenter/pkg/controllers/nodepool/headroom"
+	nodepoolminnodes "sigs.k8s.io/karpenter/pkg/controllers/nodepool/minnodes"
+	nodepoolpreflight "sigs.k8s.io/karpenter/pkg/controllers/nodepool/preflight"
 	nodepoolreadiness "sigs.k8s.io/karpenter/pkg/controllers/nodepool/readiness"
 	nodepoolvalidation "sigs.k8s.io/karpenter/pkg/controllers/nodepool/validation"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	provisioningdecision "sigs.k8s.io/karpenter/pkg/controllers/provisioning/decision"
+	provisioningdivergence "sigs.k8s.io/karpenter/pkg/controllers/provisioning/divergence"
+	provisioningdryrun "sigs.k8s.io/karpenter/pkg/controllers/provisioning/dryrun"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -76,34 +92,50 @@ func NewControllers(
 		disruption.NewController(clock, kubeClient, p, cloudProvider, recorder, cluster, disruptionQueue),
 		provisioning.NewPodController(kubeClient, p, cluster),
 		provisioning.NewNodeController(kubeClient, p),
+		provisioningdryrun.NewController(kubeClient, cluster, p),
+		provisioningdivergence.NewController(clock, cluster),
+		provisioningdecision.NewController(clock, kubeClient),
 		nodepoolhash.NewController(kubeClient, cloudProvider),
-		expiration.NewController(clock, kubeClient, cloudProvider),
+		nodepooldriftimpact.NewController(kubeClient, cloudProvider, recorder),
+		nodepooldisruptionprofile.NewController(kubeClient, cloudProvider),
+		nodepooldeletionsimulation.NewController(kubeClient, cloudProvider, cluster, p, recorder),
+		nodepoolminnodes.NewController(kubeClient, cloudProvider, cluster, p),
+		nodepoolheadroom.NewController(clock, kubeClient, cloudProvider, cluster, p),
+		expiration.NewController(clock, kubeClient, cloudProvider, cluster, p, recorder),
 		informer.NewDaemonSetController(kubeClient, cluster),
 		informer.NewNodeController(kubeClient, cluster),
 		informer.NewPodController(kubeClient, cluster),
 		informer.NewNodePoolController(kubeClient, cloudProvider, cluster),
 		informer.NewNodeClaimController(kubeClient, cloudProvider, cluster),
 		termination.NewController(clock, kubeClient, cloudProvider, terminator.NewTerminator(clock, kubeClient, evictionQueue, recorder), recorder),
-		metricspod.NewController(kubeClient, cluster),
+		metricspod.NewController(clock, kubeClient, cluster),
 		metricsnodepool.NewController(kubeClient, cloudProvider),
-		metricsnode.NewController(cluster),
+		metricsnode.NewController(clock, cluster),
+		metricsheadroom.NewController(clock, kubeClient, cluster),
+		nodepoolpreflight.NewController(kubeClient, cloudProvider),
 		nodepoolreadiness.NewController(kubeClient, cloudProvider),
 		nodepoolcounter.NewController(kubeClient, cloudProvider, cluster),
 		nodepoolvalidation.NewController(kubeClient, cloudProvider),
+		nodepooleffective.NewController(kubeClient, cloudProvider),
 		podevents.NewController(clock, kubeClient, cloudProvider),
 		nodeclaimconsistency.NewController(clock, kubeClient, cloudProvider, recorder),
-		nodeclaimlifecycle.NewController(clock, kubeClient, cloudProvider, recorder),
+		nodeclaimlifecycle.NewController(clock, kubeClient, cloudProvider, cluster, recorder),
 		nodeclaimgarbagecollection.NewController(clock, kubeClient, cloudProvider),
+		nodeclaiminterruption.NewController(clock, kubeClient, cloudProvider, recorder),
 		nodeclaimdisruption.NewController(clock, kubeClient, cloudProvider),
 		nodeclaimhydration.NewController(kubeClient, cloudProvider),
+		nodeclaimproviderid.NewController(clock, kubeClient, cloudProvider, recorder),
 		nodehydration.NewController(kubeClient, cloudProvider),
+		nodeadoption.NewController(kubeClient, cloudProvider),
+		nodeexclusive.NewController(clock, kubeClient, cloudProvider),
+		nodefidelity.NewController(kubeClient, cloudProvider, recorder),
 		status.NewController[*v1.NodeClaim](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics, status.WithLabels(append(lo.Map(cloudProvider.GetSupportedNodeClasses(), func(obj status.Object, _ int) string { return v1.NodeClassLabelKey(object.GVK(obj).GroupKind()) }), v1.NodePoolLabelKey)...)),
 		status.NewController[*v1.NodePool](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.EmitDeprecatedMetrics),
 		status.NewGenericObjectController[*corev1.Node](kubeClient, mgr.GetEventRecorderFor("karpenter"), status.WithLabels(append(lo.Map(cloudProvider.GetSupportedNodeClasses(), func(obj status.Object, _ int) string { return v1.NodeClassLabelKey(object.GVK(obj).GroupKind()) }), v1.NodePoolLabelKey, v1.NodeInitializedLabelKey)...)),
 	}
 
-	// The cloud provider must define status conditions for the node repair controller to use to detect unhealthy nodes
-	if len(cloudProvider.RepairPolicies()) != 0 && options.FromContext(ctx).FeatureGates.NodeRepair {
+	// Unhealthy nodes are detected from the repair policies of the cloud provider and of each NodePool
+	if options.FromContext(ctx).FeatureGates.NodeRepair {
 		controllers = append(controllers, health.NewController(kubeClient, cloudProvider, clock, recorder))
 	}
 
@@ -0,0 +1,168 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// admissionWebhookTimeout bounds how long a disruption decision can wait on the admission webhook
+const admissionWebhookTimeout = 5 * time.Second
+
+// AdmissionRequest is POSTed to the disruption admission webhook for every planned disruption command
+type AdmissionRequest struct {
+	Reason            string                 `json:"reason"`
+	ConsolidationType string                 `json:"consolidationType,omitempty"`
+	Decision          Decision               `json:"decision"`
+	Candidates        []AdmissionCandidate   `json:"candidates"`
+	Replacements      []AdmissionReplacement `json:"replacements,omitempty"`
+}
+
+// AdmissionCandidate is a node that the command would disrupt
+type AdmissionCandidate struct {
+	NodeClaim    string `json:"nodeClaim"`
+	Node         string `json:"node,omitempty"`
+	NodePool     string `json:"nodePool"`
+	ProviderID   string `json:"providerID"`
+	InstanceType string `json:"instanceType,omitempty"`
+	CapacityType string `json:"capacityType,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	Pods         int    `json:"pods"`
+}
+
+// AdmissionReplacement is a NodeClaim that the command would launch before disrupting its candidates
+type AdmissionReplacement struct {
+	NodePool      string   `json:"nodePool"`
+	InstanceTypes []string `json:"instanceTypes"`
+}
+
+// AdmissionResponse is the webhook's verdict on a disruption command. Commands that aren't allowed are dropped, and
+// setting RetryAfterSeconds also delays the disruption method that planned the command until then.
+type AdmissionResponse struct {
+	Allowed           bool   `json:"allowed"`
+	Message           string `json:"message,omitempty"`
+	RetryAfterSeconds int64  `json:"retryAfterSeconds,omitempty"`
+}
+
+// admit asks the disruption admission webhook, if one is configured, whether the command can be executed. Commands
+// are denied when the webhook can't be reached so that an outage doesn't let disruption bypass change management.
+func (c *Controller) admit(ctx context.Context, m Method, cmd Command) bool {
+	url := options.FromContext(ctx).DisruptionAdmissionWebhookURL
+	if url == "" {
+		return true
+	}
+	resp, err := review(ctx, url, NewAdmissionRequest(m, cmd))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed calling disruption admission webhook")
+		resp = AdmissionResponse{Message: "disruption admission webhook is unavailable"}
+	}
+	if resp.Allowed {
+		return true
+	}
+	if resp.RetryAfterSeconds > 0 {
+		c.admissionDelays[m.Reason()] = c.clock.Now().Add(time.Duration(resp.RetryAfterSeconds) * time.Second)
+	}
+	log.FromContext(ctx).WithValues("reason", strings.ToLower(string(m.Reason())), "message", resp.Message).Info(fmt.Sprintf("disruption admission webhook denied %s", cmd))
+	for _, candidate := range cmd.candidates {
+		c.recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, fmt.Sprintf("denied by admission webhook, %s", lo.Ternary(resp.Message == "", "no message", resp.Message)))...)
+	}
+	return false
+}
+
+// admissionDelayed returns true if the admission webhook has asked for the disruption method to hold off
+func (c *Controller) admissionDelayed(m Method) bool {
+	until, ok := c.admissionDelays[m.Reason()]
+	if ok && !c.clock.Now().Before(until) {
+		delete(c.admissionDelays, m.Reason())
+		return false
+	}
+	return ok
+}
+
+// NewAdmissionRequest describes the command for the admission webhook
+func NewAdmissionRequest(m Method, cmd Command) AdmissionRequest {
+	return AdmissionRequest{
+		Reason:            strings.ToLower(string(m.Reason())),
+		ConsolidationType: m.ConsolidationType(),
+		Decision:          cmd.Decision(),
+		Candidates: lo.Map(cmd.candidates, func(c *Candidate, _ int) AdmissionCandidate {
+			candidate := AdmissionCandidate{
+				NodePool:     c.nodePool.Name,
+				ProviderID:   c.ProviderID(),
+				InstanceType: c.Labels()[corev1.LabelInstanceTypeStable],
+				CapacityType: c.capacityType,
+				Zone:         c.zone,
+				Pods:         len(c.reschedulablePods),
+			}
+			if c.NodeClaim != nil {
+				candidate.NodeClaim = c.NodeClaim.Name
+			}
+			if c.Node != nil {
+				candidate.Node = c.Node.Name
+			}
+			return candidate
+		}),
+		Replacements: lo.Map(cmd.replacements, func(r *scheduling.NodeClaim, _ int) AdmissionReplacement {
+			return AdmissionReplacement{
+				NodePool:      r.NodePoolName,
+				InstanceTypes: lo.Map(r.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) string { return it.Name }),
+			}
+		}),
+	}
+}
+
+func review(ctx context.Context, url string, request AdmissionRequest) (AdmissionResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return AdmissionResponse{}, fmt.Errorf("marshaling admission request, %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, admissionWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return AdmissionResponse{}, fmt.Errorf("building admission request, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return AdmissionResponse{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return AdmissionResponse{}, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	response := AdmissionResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return AdmissionResponse{}, fmt.Errorf("decoding admission response, %w", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,171 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+// ConditionMethod describes a disruption method for NodeClaims that an external controller marks with a status
+// condition, e.g. a scanner that sets a SecurityPatchRequired condition. NodeClaims with the condition set to True are
+// disrupted through the same budgets, orchestration queue and replacement simulation as the built-in methods.
+type ConditionMethod struct {
+	// Reason identifies the method in metrics, events and logs. Since budgets can only select the built-in reasons,
+	// the method is limited by the NodePool's budgets that don't specify any reasons.
+	Reason v1.DisruptionReason
+	// ConditionType is the NodeClaim status condition that marks a NodeClaim for disruption
+	ConditionType string
+	// Class is either GracefulDisruptionClass or EventualDisruptionClass
+	Class string
+}
+
+var (
+	conditionMethodsMu sync.RWMutex
+	conditionMethods   []ConditionMethod
+)
+
+// RegisterConditionMethod adds a method that's evaluated after drift by every disruption controller constructed
+// afterwards. Methods should be registered before the operator's controllers are constructed.
+func RegisterConditionMethod(method ConditionMethod) {
+	conditionMethodsMu.Lock()
+	defer conditionMethodsMu.Unlock()
+	if err := validateConditionMethod(method); err != nil {
+		panic(err)
+	}
+	conditionMethods = append(conditionMethods, method)
+}
+
+// UnregisterConditionMethod removes a method that was registered with RegisterConditionMethod
+func UnregisterConditionMethod(reason v1.DisruptionReason) {
+	conditionMethodsMu.Lock()
+	defer conditionMethodsMu.Unlock()
+	conditionMethods = lo.Reject(conditionMethods, func(m ConditionMethod, _ int) bool { return m.Reason == reason })
+}
+
+func validateConditionMethod(method ConditionMethod) error {
+	if method.Reason == "" || method.ConditionType == "" {
+		return fmt.Errorf("condition method must specify a reason and a condition type")
+	}
+	if method.Class != GracefulDisruptionClass && method.Class != EventualDisruptionClass {
+		return fmt.Errorf("condition method %q has invalid class %q", method.Reason, method.Class)
+	}
+	if lo.Contains([]v1.DisruptionReason{v1.DisruptionReasonUnderutilized, v1.DisruptionReasonEmpty, v1.DisruptionReasonDrifted, v1.DisruptionReasonRebalanced}, method.Reason) || lo.ContainsBy(conditionMethods, func(m ConditionMethod) bool { return m.Reason == method.Reason }) {
+		return fmt.Errorf("disruption reason %q is already registered", method.Reason)
+	}
+	return nil
+}
+
+func registeredConditionMethods() []ConditionMethod {
+	conditionMethodsMu.RLock()
+	defer conditionMethodsMu.RUnlock()
+	return append([]ConditionMethod{}, conditionMethods...)
+}
+
+// Condition is a subreconciler that deletes candidates whose NodeClaims have a registered ConditionMethod's
+// condition set.
+type Condition struct {
+	kubeClient  client.Client
+	cluster     *state.Cluster
+	provisioner *provisioning.Provisioner
+	recorder    events.Recorder
+	method      ConditionMethod
+}
+
+func NewCondition(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder, method ConditionMethod) *Condition {
+	return &Condition{
+		kubeClient:  kubeClient,
+		cluster:     cluster,
+		provisioner: provisioner,
+		recorder:    recorder,
+		method:      method,
+	}
+}
+
+// ShouldDisrupt is a predicate used to filter candidates
+func (c *Condition) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
+	return cn.NodeClaim.StatusConditions().Get(c.method.ConditionType).IsTrue()
+}
+
+// ComputeCommand generates a disruption command given candidates. Empty candidates are disrupted together, otherwise
+// a single candidate is replaced at a time.
+func (c *Condition) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
+	// Candidates from NodePools with a lower disruption priority go first, and within a priority, the longest marked
+	sort.Slice(candidates, func(i int, j int) bool {
+		if pi, pj := candidates[i].nodePool.Spec.Disruption.Priority, candidates[j].nodePool.Spec.Disruption.Priority; pi != pj {
+			return pi < pj
+		}
+		return candidates[i].NodeClaim.StatusConditions().Get(c.method.ConditionType).LastTransitionTime.Time.Before(
+			candidates[j].NodeClaim.StatusConditions().Get(c.method.ConditionType).LastTransitionTime.Time)
+	})
+	var empty []*Candidate
+	for _, candidate := range candidates {
+		if len(candidate.reschedulablePods) == 0 && disruptionBudgetMapping[candidate.nodePool.Name] > 0 {
+			empty = append(empty, candidate)
+			disruptionBudgetMapping[candidate.nodePool.Name]--
+		}
+	}
+	if len(empty) > 0 {
+		return Command{candidates: empty}, scheduling.Results{}, nil
+	}
+	for _, candidate := range candidates {
+		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
+			continue
+		}
+		results, err := SimulateScheduling(ctx, c.kubeClient, c.cluster, c.provisioner, candidate)
+		if err != nil {
+			if errors.Is(err, errCandidateDeleting) {
+				continue
+			}
+			return Command{}, scheduling.Results{}, err
+		}
+		if !results.AllNonPendingPodsScheduled() {
+			c.recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, results.NonPendingPodSchedulingErrors())...)
+			continue
+		}
+		return Command{
+			candidates:   []*Candidate{candidate},
+			replacements: results.NewNodeClaims,
+		}, results, nil
+	}
+	return Command{}, scheduling.Results{}, nil
+}
+
+func (c *Condition) Reason() v1.DisruptionReason {
+	return c.method.Reason
+}
+
+func (c *Condition) Class() string {
+	return c.method.Class
+}
+
+func (c *Condition) ConsolidationType() string {
+	return ""
+}
@@ -0,0 +1,119 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Condition Methods", func() {
+	const conditionType = "SecurityPatchRequired"
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var conditionController *disruption.Controller
+
+	BeforeEach(func() {
+		disruption.RegisterConditionMethod(disruption.ConditionMethod{
+			Reason:        "SecurityPatchRequired",
+			ConditionType: conditionType,
+			Class:         disruption.EventualDisruptionClass,
+		})
+		DeferCleanup(disruption.UnregisterConditionMethod, v1.DisruptionReason("SecurityPatchRequired"))
+		conditionController = disruption.NewController(fakeClock, env.Client, prov, cloudProvider, recorder, cluster, queue)
+
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					ConsolidateAfter: v1.MustParseNillableDuration("Never"),
+					Budgets: []v1.Budget{{
+						Nodes: "100%",
+					}},
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				ProviderID: test.RandomProviderID(),
+				Allocatable: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+	})
+	It("should disrupt nodes with the registered condition", func() {
+		nodeClaim.StatusConditions().SetTrue(conditionType)
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectSingletonReconciled(ctx, conditionController)
+		ExpectSingletonReconciled(ctx, queue)
+		ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+		ExpectNotFound(ctx, env.Client, nodeClaim, node)
+	})
+	It("should ignore nodes without the registered condition", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectSingletonReconciled(ctx, conditionController)
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should respect the NodePool's budgets that don't specify reasons", func() {
+		nodePool.Spec.Disruption.Budgets = []v1.Budget{
+			{Nodes: "0"},
+			{Nodes: "100%", Reasons: []v1.DisruptionReason{v1.DisruptionReasonDrifted}},
+		}
+		nodeClaim.StatusConditions().SetTrue(conditionType)
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectSingletonReconciled(ctx, conditionController)
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should not allow registering a built-in disruption reason", func() {
+		Expect(func() {
+			disruption.RegisterConditionMethod(disruption.ConditionMethod{
+				Reason:        v1.DisruptionReasonDrifted,
+				ConditionType: conditionType,
+				Class:         disruption.EventualDisruptionClass,
+			})
+		}).To(Panic())
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"time"
 
@@ -58,6 +59,8 @@ type consolidation struct {
 	cloudProvider          cloudprovider.CloudProvider
 	recorder               events.Recorder
 	lastConsolidationState time.Time
+	// consolidationType is set by each consolidation method so that rejections can be attributed to it
+	consolidationType string
 }
 
 func MakeConsolidation(clock clock.Clock, cluster *state.Cluster, kubeClient client.Client, provisioner *provisioning.Provisioner,
@@ -83,6 +86,14 @@ func (c *consolidation) markConsolidated() {
 	c.lastConsolidationState = c.cluster.ConsolidationState()
 }
 
+// reject records that a candidate was evaluated and not consolidated for the given reason
+func (c *consolidation) reject(rejectionReason string) {
+	ConsolidationCandidateRejectionsTotal.Inc(map[string]string{
+		consolidationTypeLabel: c.consolidationType,
+		rejectionReasonLabel:   rejectionReason,
+	})
+}
+
 // ShouldDisrupt is a predicate used to filter candidates
 func (c *consolidation) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
 	// We need the following to know what the price of the instance for price comparison. If one of these doesn't exist, we can't
@@ -113,18 +124,56 @@ func (c *consolidation) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
 		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("NodePool %q has non-empty consolidation disabled", cn.nodePool.Name))...)
 		return false
 	}
+	// Replacing or removing part of a placement group would break up the co-located capacity, so its nodes are only
+	// removed by emptiness, once all of them are empty
+	if cn.NodeClaim.Spec.PlacementGroup != "" {
+		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("NodeClaim is part of placement group %q", cn.NodeClaim.Spec.PlacementGroup))...)
+		return false
+	}
 	// return true if consolidatable
-	return cn.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()
+	if !cn.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue() {
+		c.reject(RejectionReasonCooldown)
+		return false
+	}
+	return true
 }
 
-// sortCandidates sorts candidates by disruption cost (where the lowest disruption cost is first) and returns the result
+// sortCandidates sorts candidates by disruption cost (where the lowest disruption cost is first) and returns the result.
+// Candidates from NodePools with the LeastDisruption objective are then reordered amongst the positions they hold, by
+// the number of pods they'd move per dollar saved, so that the order of other NodePools' candidates is unaffected.
 func (c *consolidation) sortCandidates(candidates []*Candidate) []*Candidate {
 	sort.Slice(candidates, func(i int, j int) bool {
 		return candidates[i].disruptionCost < candidates[j].disruptionCost
 	})
+	var positions []int
+	podsPerDollar := map[*Candidate]float64{}
+	for i, cn := range candidates {
+		if cn.nodePool.Spec.Disruption.ConsolidationObjective != v1.ConsolidationObjectiveLeastDisruption {
+			continue
+		}
+		positions = append(positions, i)
+		podsPerDollar[cn] = podsMovedPerDollar(cn)
+	}
+	leastDisruption := lo.Map(positions, func(i int, _ int) *Candidate { return candidates[i] })
+	sort.SliceStable(leastDisruption, func(i int, j int) bool {
+		return podsPerDollar[leastDisruption[i]] < podsPerDollar[leastDisruption[j]]
+	})
+	for i, position := range positions {
+		candidates[position] = leastDisruption[i]
+	}
 	return candidates
 }
 
+// podsMovedPerDollar is the number of pods that consolidating the candidate moves for each dollar it could save. The
+// candidate's price is the most that consolidating it can save, so candidates without a price are ordered last.
+func podsMovedPerDollar(cn *Candidate) float64 {
+	price, err := getCandidatePrices([]*Candidate{cn})
+	if err != nil || price <= 0 {
+		return math.Inf(1)
+	}
+	return float64(len(cn.reschedulablePods)) / price
+}
+
 // computeConsolidation computes a consolidation action to take
 //
 // nolint:gocyclo
@@ -145,12 +194,31 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 		// This method is used by multi-node consolidation as well, so we'll only report in the single node case
 		if len(candidates) == 1 {
 			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, results.NonPendingPodSchedulingErrors())...)
+			c.reject(RejectionReasonScheduling)
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
 
+	threshold, err := consolidationThreshold(candidates)
+	if err != nil {
+		return Command{}, pscheduling.Results{}, fmt.Errorf("getting consolidation threshold, %w", err)
+	}
+
 	// were we able to schedule all the pods on the inflight candidates?
 	if len(results.NewNodeClaims) == 0 {
+		if threshold > 0 {
+			savings, err := getCandidatePrices(candidates)
+			if err != nil {
+				return Command{}, pscheduling.Results{}, fmt.Errorf("getting offering price from candidate node, %w", err)
+			}
+			if savings <= threshold {
+				if len(candidates) == 1 {
+					c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Savings of %s don't exceed the consolidation threshold of %s", formatPrice(savings), formatPrice(threshold)))...)
+					c.reject(RejectionReasonCost)
+				}
+				return Command{}, pscheduling.Results{}, nil
+			}
+		}
 		return Command{
 			candidates: candidates,
 		}, results, nil
@@ -160,6 +228,7 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 	if len(results.NewNodeClaims) != 1 {
 		if len(candidates) == 1 {
 			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Can't remove without creating %d candidates", len(results.NewNodeClaims)))...)
+			c.reject(RejectionReasonCost)
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
@@ -170,6 +239,8 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 	if err != nil {
 		return Command{}, pscheduling.Results{}, fmt.Errorf("getting offering price from candidate node, %w", err)
 	}
+	// replacements have to be cheaper than the candidates by more than the threshold
+	maxPrice := candidatePrice - threshold
 
 	allExistingAreSpot := true
 	for _, cn := range candidates {
@@ -180,28 +251,31 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 
 	// sort the instanceTypes by price before we take any actions like truncation for spot-to-spot consolidation or finding the nodeclaim
 	// that meets the minimum requirement after filteringByPrice
-	results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions = results.NewNodeClaims[0].InstanceTypeOptions.OrderByPrice(results.NewNodeClaims[0].Requirements)
+	results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions = results.NewNodeClaims[0].InstanceTypeOptions.OrderByPrice(results.NewNodeClaims[0].Requirements,
+		cloudprovider.PreferNewerGenerations(results.NewNodeClaims[0].PreferNewerGenerations))
 
 	if allExistingAreSpot &&
 		results.NewNodeClaims[0].Requirements.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeSpot) {
-		return c.computeSpotToSpotConsolidation(ctx, candidates, results, candidatePrice)
+		return c.computeSpotToSpotConsolidation(ctx, candidates, results, maxPrice, threshold)
 	}
 
 	// filterByPrice returns the instanceTypes that are lower priced than the current candidate and any error that indicates the input couldn't be filtered.
 	// If we use this directly for spot-to-spot consolidation, we are bound to get repeated consolidations because the strategy that chooses to launch the spot instance from the list does
 	// it based on availability and price which could result in selection/launch of non-lowest priced instance in the list. So, we would keep repeating this loop till we get to lowest priced instance
 	// causing churns and landing onto lower available spot instance ultimately resulting in higher interruptions.
-	results.NewNodeClaims[0], err = results.NewNodeClaims[0].RemoveInstanceTypeOptionsByPriceAndMinValues(results.NewNodeClaims[0].Requirements, candidatePrice)
+	results.NewNodeClaims[0], err = results.NewNodeClaims[0].RemoveInstanceTypeOptionsByPriceAndMinValues(results.NewNodeClaims[0].Requirements, maxPrice)
 
 	if err != nil {
 		if len(candidates) == 1 {
 			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Filtering by price: %v", err))...)
+			c.reject(RejectionReasonCost)
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
 	if len(results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions) == 0 {
 		if len(candidates) == 1 {
-			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "Can't replace with a cheaper node")...)
+			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, noCheaperReplacement(threshold))...)
+			c.reject(RejectionReasonCost)
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
@@ -227,12 +301,13 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 //     a. There are at least 15 cheapest instance type replacement options to consolidate.
 //     b. The current candidate is NOT part of the first 15 cheapest instance types inorder to avoid repeated consolidation.
 func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, candidates []*Candidate, results pscheduling.Results,
-	candidatePrice float64) (Command, pscheduling.Results, error) {
+	maxPrice, threshold float64) (Command, pscheduling.Results, error) {
 
 	// Spot consolidation is turned off.
 	if !options.FromContext(ctx).FeatureGates.SpotToSpotConsolidation {
 		if len(candidates) == 1 {
 			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "SpotToSpotConsolidation is disabled, can't replace a spot node with a spot node")...)
+			c.reject(RejectionReasonCost)
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
@@ -244,16 +319,18 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 
 	// filterByPrice returns the instanceTypes that are lower priced than the current candidate and any error that indicates the input couldn't be filtered.
 	var err error
-	results.NewNodeClaims[0], err = results.NewNodeClaims[0].RemoveInstanceTypeOptionsByPriceAndMinValues(results.NewNodeClaims[0].Requirements, candidatePrice)
+	results.NewNodeClaims[0], err = results.NewNodeClaims[0].RemoveInstanceTypeOptionsByPriceAndMinValues(results.NewNodeClaims[0].Requirements, maxPrice)
 	if err != nil {
 		if len(candidates) == 1 {
 			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("Filtering by price: %v", err))...)
+			c.reject(RejectionReasonCost)
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
 	if len(results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions) == 0 {
 		if len(candidates) == 1 {
-			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "Can't replace with a cheaper node")...)
+			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, noCheaperReplacement(threshold))...)
+			c.reject(RejectionReasonCost)
 		}
 		return Command{}, pscheduling.Results{}, nil
 	}
@@ -275,6 +352,7 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 	if len(results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions) < MinInstanceTypesForSpotToSpotConsolidation {
 		c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("SpotToSpotConsolidation requires %d cheaper instance type options than the current candidate to consolidate, got %d",
 			MinInstanceTypesForSpotToSpotConsolidation, len(results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions)))...)
+		c.reject(RejectionReasonCost)
 		return Command{}, pscheduling.Results{}, nil
 	}
 
@@ -301,6 +379,34 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 	}, results, nil
 }
 
+// consolidationThreshold returns the savings that consolidating the candidates must exceed. It's the sum of each
+// candidate's NodePool threshold against the candidate's price, and empty candidates don't count towards it.
+func consolidationThreshold(candidates []*Candidate) (float64, error) {
+	var threshold float64
+	for _, cn := range candidates {
+		if cn.empty || cn.nodePool.Spec.Disruption.ConsolidationThreshold == "" {
+			continue
+		}
+		price, err := getCandidatePrices([]*Candidate{cn})
+		if err != nil {
+			return 0.0, err
+		}
+		threshold += cn.nodePool.GetConsolidationThreshold(price)
+	}
+	return threshold, nil
+}
+
+func noCheaperReplacement(threshold float64) string {
+	if threshold > 0 {
+		return fmt.Sprintf("Can't replace with a node that's cheaper by more than the consolidation threshold of %s", formatPrice(threshold))
+	}
+	return "Can't replace with a cheaper node"
+}
+
+func formatPrice(price float64) string {
+	return fmt.Sprintf("$%.4f/hr", price)
+}
+
 // getCandidatePrices returns the sum of the prices of the given candidates
 func getCandidatePrices(candidates []*Candidate) (float64, error) {
 	var price float64
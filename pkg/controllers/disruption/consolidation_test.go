@@ -176,6 +176,19 @@ var _ = Describe("Consolidation", func() {
 			// We get four calls since we only care about this since we don't emit for empty node consolidation
 			Expect(recorder.Calls("Unconsolidatable")).To(Equal(4))
 		})
+		It("should fire an event and not consolidate when a candidate is part of a placement group", func() {
+			pod := test.Pod()
+			nodeClaim.Spec.PlacementGroup = "training"
+
+			ExpectApplied(ctx, env.Client, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+			ExpectSingletonReconciled(ctx, disruptionController)
+			// We get four calls since we only care about this since we don't emit for empty node consolidation
+			Expect(recorder.Calls("Unconsolidatable")).To(Equal(4))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
 	})
 	Context("Metrics", func() {
 		It("should correctly report eligible nodes", func() {
@@ -213,6 +226,38 @@ var _ = Describe("Consolidation", func() {
 				metrics.ReasonLabel: "underutilized",
 			})
 		})
+		It("should report candidates rejected by disruption budgets", func() {
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "0"}}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectMetricCounterValue(disruption.ConsolidationCandidateRejectionsTotal, 1, map[string]string{
+				"consolidation_type": "empty",
+				"rejection_reason":   disruption.RejectionReasonBudget,
+			})
+		})
+		It("should report candidates rejected by cooldown", func() {
+			nodeClaim.StatusConditions().SetFalse(v1.ConditionTypeConsolidatable, "NotConsolidatable", "NotConsolidatable")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectMetricCounterValue(disruption.ConsolidationCandidateRejectionsTotal, 1, map[string]string{
+				"consolidation_type": "empty",
+				"rejection_reason":   disruption.RejectionReasonCooldown,
+			})
+		})
 	})
 	Context("Budgets", func() {
 		var numNodes = 10
@@ -361,6 +406,52 @@ var _ = Describe("Consolidation", func() {
 			ExpectSingletonReconciled(ctx, queue)
 			Expect(len(ExpectNodeClaims(ctx, env.Client))).To(Equal(7))
 		})
+		It("should only consider the nodes that fit in the resource budget in multi node consolidation", func() {
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "100%", Resources: map[corev1.ResourceName]string{corev1.ResourceCPU: "96"}}}
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			for i := 0; i < numNodes; i++ {
+				ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+			}
+			// every pod fits onto one node, but the resource budget only leaves room to disrupt 3 of the 32 vCPU nodes
+			pods := test.Pods(numNodes, test.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{
+					Requests: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceCPU: resource.MustParse("100m"),
+					},
+				},
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+			for i := 0; i < numNodes; i++ {
+				ExpectApplied(ctx, env.Client, pods[i])
+				ExpectManualBinding(ctx, env.Client, pods[i], nodes[i])
+			}
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+			multiConsolidation := disruption.NewMultiNodeConsolidation(disruption.MakeConsolidation(fakeClock, cluster, env.Client, prov, cloudProvider, recorder, queue))
+			budgets, err := disruption.BuildDisruptionBudgetMapping(ctx, cluster, fakeClock, env.Client, cloudProvider, recorder, multiConsolidation.Reason())
+			Expect(err).To(Succeed())
+
+			candidates, err := disruption.GetCandidates(ctx, cluster, env.Client, recorder, fakeClock, cloudProvider, multiConsolidation.ShouldDisrupt, multiConsolidation.Class(), queue)
+			Expect(err).To(Succeed())
+
+			cmd, _, err := multiConsolidation.ComputeCommand(ctx, budgets, candidates...)
+			Expect(err).To(Succeed())
+			Expect(cmd.Decision()).To(Equal(disruption.DeleteDecision))
+			Expect(cmd.String()).To(HavePrefix("delete, terminating 3 nodes"))
+			Expect(multiConsolidation.IsConsolidated()).To(BeFalse())
+		})
 		It("should only allow 3 nodes to be deleted in single node consolidation delete", func() {
 			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "30%"}}
 
@@ -3594,6 +3685,59 @@ var _ = Describe("Consolidation", func() {
 			Entry("if the candidate is on-demand node", false),
 			Entry("if the candidate is spot node", true),
 		)
+		It("can merge nodes from different nodepools into 1", func() {
+			// NodePools aren't isolated from one another during consolidation, so pods from nodes in different NodePools
+			// can be moved onto a single node from any NodePool that they're compatible with
+			other := test.NodePool(v1.NodePool{Spec: *nodePool.Spec.DeepCopy()})
+			for _, i := range []int{1, 2} {
+				nodeClaims[i].Labels[v1.NodePoolLabelKey] = other.Name
+				nodes[i].Labels[v1.NodePoolLabelKey] = other.Name
+			}
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			pods := test.Pods(3, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool, other)
+			ExpectMakeNodesInitialized(ctx, env.Client, nodes[0], nodes[1], nodes[2])
+
+			// bind pods to nodes
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[2])
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{nodes[0], nodes[1], nodes[2]}, []*v1.NodeClaim{nodeClaims[0], nodeClaims[1], nodeClaims[2]})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+
+			// three nodeclaims from two nodepools should be replaced with a single nodeclaim
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2])
+		})
 		It("can merge 3 nodes into 1 if the candidates have both spot and on-demand", func() {
 			// By default all the 3 nodeClaims are OD.
 			nodeClaims = lo.Ternary(false, spotNodeClaims, nodeClaims)
@@ -4063,6 +4207,180 @@ var _ = Describe("Consolidation", func() {
 			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
 		})
 	})
+	Context("Consolidation Objective", func() {
+		var cheapNodeClaim, expensiveNodeClaim *v1.NodeClaim
+		var cheapNode, expensiveNode *corev1.Node
+
+		BeforeEach(func() {
+			nodeClaimAndNode := func(instance *cloudprovider.InstanceType, offering cloudprovider.Offering) (*v1.NodeClaim, *corev1.Node) {
+				nc, n := test.NodeClaimAndNode(v1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							v1.NodePoolLabelKey:            nodePool.Name,
+							corev1.LabelInstanceTypeStable: instance.Name,
+							v1.CapacityTypeLabelKey:        offering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+							corev1.LabelTopologyZone:       offering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+						},
+					},
+					Status: v1.NodeClaimStatus{
+						Allocatable: map[corev1.ResourceName]resource.Quantity{
+							corev1.ResourceCPU:  resource.MustParse("32"),
+							corev1.ResourcePods: resource.MustParse("100"),
+						},
+					},
+				})
+				nc.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+				return nc, n
+			}
+			cheapNodeClaim, cheapNode = nodeClaimAndNode(leastExpensiveInstance, leastExpensiveOffering)
+			expensiveNodeClaim, expensiveNode = nodeClaimAndNode(mostExpensiveInstance, mostExpensiveOffering)
+		})
+		// computeSingleNodeCommand binds one pod to the cheap node and two to the expensive node, so the cheap node is
+		// cheaper to disrupt but the expensive node moves fewer pods per dollar saved
+		computeSingleNodeCommand := func() disruption.Command {
+			GinkgoHelper()
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			pods := test.Pods(3, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					}}})
+			ExpectApplied(ctx, env.Client, pods[0], pods[1], pods[2], nodePool, cheapNodeClaim, cheapNode, expensiveNodeClaim, expensiveNode)
+			ExpectManualBinding(ctx, env.Client, pods[0], cheapNode)
+			ExpectManualBinding(ctx, env.Client, pods[1], expensiveNode)
+			ExpectManualBinding(ctx, env.Client, pods[2], expensiveNode)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{cheapNode, expensiveNode}, []*v1.NodeClaim{cheapNodeClaim, expensiveNodeClaim})
+
+			singleConsolidation := disruption.NewSingleNodeConsolidation(disruption.MakeConsolidation(fakeClock, cluster, env.Client, prov, cloudProvider, recorder, queue))
+			budgets, err := disruption.BuildDisruptionBudgetMapping(ctx, cluster, fakeClock, env.Client, cloudProvider, recorder, singleConsolidation.Reason())
+			Expect(err).To(Succeed())
+			candidates, err := disruption.GetCandidates(ctx, cluster, env.Client, recorder, fakeClock, cloudProvider, singleConsolidation.ShouldDisrupt, singleConsolidation.Class(), queue)
+			Expect(err).To(Succeed())
+			Expect(candidates).To(HaveLen(2))
+
+			var wg sync.WaitGroup
+			ExpectToWait(fakeClock, &wg)
+			cmd, _, err := singleConsolidation.ComputeCommand(ctx, budgets, candidates...)
+			wg.Wait()
+			Expect(err).To(Succeed())
+			Expect(cmd.Decision()).To(Equal(disruption.DeleteDecision))
+			return cmd
+		}
+		It("should consolidate the node that is cheapest to disrupt by default", func() {
+			Expect(computeSingleNodeCommand().String()).To(ContainSubstring(cheapNode.Name))
+		})
+		It("should consolidate the node that moves the fewest pods per dollar saved with the LeastDisruption objective", func() {
+			nodePool.Spec.Disruption.ConsolidationObjective = v1.ConsolidationObjectiveLeastDisruption
+			Expect(computeSingleNodeCommand().String()).To(ContainSubstring(expensiveNode.Name))
+		})
+		DescribeTable("should consolidate the same node regardless of the number of evaluation workers",
+			func(workers int) {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					DisruptionEvaluationWorkers: lo.ToPtr(workers),
+					FeatureGates:                test.FeatureGates{SpotToSpotConsolidation: lo.ToPtr(true)},
+				}))
+				Expect(computeSingleNodeCommand().String()).To(ContainSubstring(cheapNode.Name))
+			},
+			Entry("with a single worker", 1),
+			Entry("with more workers than candidates", 10),
+		)
+	})
+	Context("Consolidation Threshold", func() {
+		var cheapNodeClaim *v1.NodeClaim
+		var cheapNode *corev1.Node
+
+		BeforeEach(func() {
+			cheapNodeClaim, cheapNode = test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: leastExpensiveInstance.Name,
+						v1.CapacityTypeLabelKey:        leastExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+						corev1.LabelTopologyZone:       leastExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceCPU:  resource.MustParse("32"),
+						corev1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+			cheapNodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+		})
+		// computeCommand binds a pod to each of the nodes and computes a single node consolidation command for them
+		computeCommand := func(expectCommand bool, nodeClaims []*v1.NodeClaim, nodes []*corev1.Node) disruption.Command {
+			GinkgoHelper()
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs, nodePool)
+			for i := range nodes {
+				pod := test.Pod(test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "ReplicaSet",
+								Name:               rs.Name,
+								UID:                rs.UID,
+								Controller:         lo.ToPtr(true),
+								BlockOwnerDeletion: lo.ToPtr(true),
+							},
+						}}})
+				ExpectApplied(ctx, env.Client, pod, nodeClaims[i], nodes[i])
+				ExpectManualBinding(ctx, env.Client, pod, nodes[i])
+			}
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+			singleConsolidation := disruption.NewSingleNodeConsolidation(disruption.MakeConsolidation(fakeClock, cluster, env.Client, prov, cloudProvider, recorder, queue))
+			budgets, err := disruption.BuildDisruptionBudgetMapping(ctx, cluster, fakeClock, env.Client, cloudProvider, recorder, singleConsolidation.Reason())
+			Expect(err).To(Succeed())
+			candidates, err := disruption.GetCandidates(ctx, cluster, env.Client, recorder, fakeClock, cloudProvider, singleConsolidation.ShouldDisrupt, singleConsolidation.Class(), queue)
+			Expect(err).To(Succeed())
+			Expect(candidates).To(HaveLen(len(nodes)))
+
+			var wg sync.WaitGroup
+			if expectCommand {
+				ExpectToWait(fakeClock, &wg)
+			}
+			cmd, _, err := singleConsolidation.ComputeCommand(ctx, budgets, candidates...)
+			wg.Wait()
+			Expect(err).To(Succeed())
+			return cmd
+		}
+		It("should replace a node when the savings exceed a percentage threshold", func() {
+			nodePool.Spec.Disruption.ConsolidationThreshold = "10%"
+			cmd := computeCommand(true, []*v1.NodeClaim{nodeClaim}, []*corev1.Node{node})
+			Expect(cmd.Decision()).To(Equal(disruption.ReplaceDecision))
+		})
+		It("should not replace a node when no replacement saves more than an absolute threshold", func() {
+			nodePool.Spec.Disruption.ConsolidationThreshold = fmt.Sprint(mostExpensiveOffering.Price)
+			cmd := computeCommand(false, []*v1.NodeClaim{nodeClaim}, []*corev1.Node{node})
+			Expect(cmd.Decision()).To(Equal(disruption.NoOpDecision))
+		})
+		It("should not replace a node when the threshold is 100%", func() {
+			nodePool.Spec.Disruption.ConsolidationThreshold = "100%"
+			cmd := computeCommand(false, []*v1.NodeClaim{nodeClaim}, []*corev1.Node{node})
+			Expect(cmd.Decision()).To(Equal(disruption.NoOpDecision))
+		})
+		It("should delete a node when the savings exceed the threshold", func() {
+			nodePool.Spec.Disruption.ConsolidationThreshold = "50%"
+			cmd := computeCommand(true, []*v1.NodeClaim{nodeClaim, cheapNodeClaim}, []*corev1.Node{node, cheapNode})
+			Expect(cmd.Decision()).To(Equal(disruption.DeleteDecision))
+		})
+		It("should not delete a node when the savings don't exceed an absolute threshold", func() {
+			nodePool.Spec.Disruption.ConsolidationThreshold = fmt.Sprint(mostExpensiveOffering.Price + 1)
+			cmd := computeCommand(false, []*v1.NodeClaim{nodeClaim, cheapNodeClaim}, []*corev1.Node{node, cheapNode})
+			Expect(cmd.Decision()).To(Equal(disruption.NoOpDecision))
+		})
+	})
 	Context("Topology Consideration", func() {
 		var nodeClaims []*v1.NodeClaim
 		var nodes []*corev1.Node
@@ -4361,7 +4679,7 @@ var _ = Describe("Consolidation", func() {
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nil)
 
 			// Wait for the nomination cache to expire
-			time.Sleep(time.Second * 11)
+			ExpectClockStepped(fakeClock, time.Second*11)
 
 			// Re-create the pods to re-bind them
 			for i := 0; i < 2; i++ {
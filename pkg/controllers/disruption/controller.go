@@ -27,6 +27,9 @@ import (
 
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/utils/clock"
@@ -36,13 +39,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/decision"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -57,15 +63,25 @@ type Controller struct {
 	cluster       *state.Cluster
 	provisioner   *provisioning.Provisioner
 	recorder      events.Recorder
+	decisions     *decision.Recorder
 	clock         clock.Clock
 	cloudProvider cloudprovider.CloudProvider
 	methods       []Method
 	mu            sync.Mutex
 	lastRun       map[string]time.Time
+	// admissionDelays holds back disruption methods that the admission webhook has asked to retry later
+	admissionDelays map[v1.DisruptionReason]time.Time
+	// observed holds when candidates of ObserveOnly NodePools were last reported, keyed by providerID
+	observed map[string]time.Time
 }
 
-// pollingPeriod that we inspect cluster to look for opportunities to disrupt
-const pollingPeriod = 10 * time.Second
+const (
+	// pollingPeriod that we inspect cluster to look for opportunities to disrupt
+	pollingPeriod = 10 * time.Second
+	// observationPeriod is how long candidates of ObserveOnly NodePools are left out of disruption after they're
+	// reported, so that the same command isn't observed on every loop and other candidates get evaluated
+	observationPeriod = 5 * time.Minute
+)
 
 func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provisioning.Provisioner,
 	cp cloudprovider.CloudProvider, recorder events.Recorder, cluster *state.Cluster, queue *orchestration.Queue,
@@ -73,24 +89,37 @@ func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provi
 	c := MakeConsolidation(clk, cluster, kubeClient, provisioner, cp, recorder, queue)
 
 	return &Controller{
-		queue:         queue,
-		clock:         clk,
-		kubeClient:    kubeClient,
-		cluster:       cluster,
-		provisioner:   provisioner,
-		recorder:      recorder,
-		cloudProvider: cp,
-		lastRun:       map[string]time.Time{},
-		methods: []Method{
-			// Terminate any NodeClaims that have drifted from provisioning specifications, allowing the pods to reschedule.
-			NewDrift(kubeClient, cluster, provisioner, recorder),
-			// Delete any empty NodeClaims as there is zero cost in terms of disruption.
-			NewEmptiness(c),
-			// Attempt to identify multiple NodeClaims that we can consolidate simultaneously to reduce pod churn
-			NewMultiNodeConsolidation(c),
-			// And finally fall back our single NodeClaim consolidation to further reduce cluster cost.
-			NewSingleNodeConsolidation(c),
-		},
+		queue:           queue,
+		clock:           clk,
+		kubeClient:      kubeClient,
+		cluster:         cluster,
+		provisioner:     provisioner,
+		recorder:        recorder,
+		decisions:       decision.NewRecorder(kubeClient),
+		cloudProvider:   cp,
+		lastRun:         map[string]time.Time{},
+		admissionDelays: map[v1.DisruptionReason]time.Time{},
+		observed:        map[string]time.Time{},
+		methods: lo.Flatten([][]Method{
+			{
+				// Terminate any NodeClaims that have drifted from provisioning specifications, allowing the pods to reschedule.
+				NewDrift(kubeClient, cluster, provisioner, cp, recorder),
+			},
+			// Then terminate any NodeClaims that were marked for disruption by an external controller's status condition.
+			lo.Map(registeredConditionMethods(), func(m ConditionMethod, _ int) Method {
+				return NewCondition(kubeClient, cluster, provisioner, recorder, m)
+			}),
+			{
+				// Delete any empty NodeClaims as there is zero cost in terms of disruption.
+				NewEmptiness(c),
+				// Attempt to identify multiple NodeClaims that we can consolidate simultaneously to reduce pod churn
+				NewMultiNodeConsolidation(c),
+				// And finally fall back our single NodeClaim consolidation to further reduce cluster cost.
+				NewSingleNodeConsolidation(c),
+				// Once there's nothing left to consolidate, gradually replace nodes in zones left over-weighted by a zone outage.
+				NewZoneRebalance(kubeClient, cluster, provisioner, cp, recorder),
+			},
+		}),
 	}
 }
 
@@ -135,7 +164,7 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 
 	// Attempt different disruption methods. We'll only let one method perform an action
 	for _, m := range c.methods {
-		c.recordRun(fmt.Sprintf("%T", m))
+		c.recordRun(fmt.Sprintf("%T/%s", m, m.Reason()))
 		success, err := c.disrupt(ctx, m)
 		if err != nil {
 			if errors.IsConflict(err) {
@@ -153,18 +182,42 @@ func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
 }
 
 func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, error) {
-	defer metrics.Measure(EvaluationDurationSeconds, map[string]string{
+	if c.admissionDelayed(disruption) {
+		return false, nil
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "Disruption.disrupt", trace.WithAttributes(
+		attribute.String("reason", string(disruption.Reason())),
+		attribute.String("consolidation.type", disruption.ConsolidationType()),
+	))
+	defer span.End()
+	defer metrics.MeasureContext(ctx, EvaluationDurationSeconds, map[string]string{
 		metrics.ReasonLabel:    strings.ToLower(string(disruption.Reason())),
 		consolidationTypeLabel: disruption.ConsolidationType(),
 	})()
-	candidates, err := GetCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, disruption.Class(), c.queue)
+	candidates, err := getCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, disruption.Class(), c.queue,
+		func(_ *state.StateNode, err error) {
+			// Nodes blocked by PDBs never make it to the consolidation methods, so we record the rejection here
+			if disruption.ConsolidationType() != "" && state.IsPDBBlockEvictionError(err) {
+				ConsolidationCandidateRejectionsTotal.Inc(map[string]string{
+					consolidationTypeLabel: disruption.ConsolidationType(),
+					rejectionReasonLabel:   RejectionReasonPDB,
+				})
+			}
+		})
 	if err != nil {
+		span.RecordError(err)
 		return false, fmt.Errorf("determining candidates, %w", err)
 	}
 	EligibleNodes.Set(float64(len(candidates)), map[string]string{
 		metrics.ReasonLabel: strings.ToLower(string(disruption.Reason())),
 	})
+	c.pruneObserved()
+	candidates = lo.Reject(candidates, func(cn *Candidate, _ int) bool {
+		observedAt, ok := c.observed[cn.ProviderID()]
+		return ok && nodepoolutils.IsObserveOnly(ctx, cn.nodePool) && c.clock.Since(observedAt) < observationPeriod
+	})
 
+	span.SetAttributes(attribute.Int("candidates", len(candidates)))
 	// If there are no candidates, move to the next disruption
 	if len(candidates) == 0 {
 		return false, nil
@@ -173,17 +226,43 @@ func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, erro
 	if err != nil {
 		return false, fmt.Errorf("building disruption budgets, %w", err)
 	}
+	resourceBudgetMapping, err := BuildResourceDisruptionBudgetMapping(ctx, c.cluster, c.clock, c.kubeClient, c.cloudProvider, disruption.Reason())
+	if err != nil {
+		return false, fmt.Errorf("building resource disruption budgets, %w", err)
+	}
+	// Candidates that are larger than what's left of their NodePool's resource budget can't be part of any command
+	candidates = lo.Filter(candidates, func(cn *Candidate, _ int) bool {
+		return len(fitsResourceBudgets(Command{candidates: []*Candidate{cn}}, resourceBudgetMapping).candidates) > 0
+	})
+	if len(candidates) == 0 {
+		return false, nil
+	}
 	// Determine the disruption action
 	cmd, schedulingResults, err := disruption.ComputeCommand(ctx, disruptionBudgetMapping, candidates...)
 	if err != nil {
+		span.RecordError(err)
 		return false, fmt.Errorf("computing disruption decision, %w", err)
 	}
+	cmd = fitsResourceBudgets(cmd, resourceBudgetMapping)
+	span.SetAttributes(attribute.String("decision", string(cmd.Decision())), attribute.Int("candidates.disrupted", len(cmd.candidates)))
 	if cmd.Decision() == NoOpDecision {
 		return false, nil
 	}
+	// A command that includes candidates of an ObserveOnly NodePool is only reported, even if some of its other
+	// candidates could be disrupted
+	if lo.ContainsBy(cmd.candidates, func(cn *Candidate) bool { return nodepoolutils.IsObserveOnly(ctx, cn.nodePool) }) {
+		if err := c.observe(ctx, disruption, cmd); err != nil {
+			return false, fmt.Errorf("observing candidates, %w", err)
+		}
+		return true, nil
+	}
+	if !c.admit(ctx, disruption, cmd) {
+		return false, nil
+	}
 
 	// Attempt to disrupt
 	if err := c.executeCommand(ctx, disruption, cmd, schedulingResults); err != nil {
+		span.RecordError(err)
 		return false, fmt.Errorf("disrupting candidates, %w", err)
 	}
 	return true, nil
@@ -237,6 +316,9 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 	}
 
 	// An action is only performed and pods/nodes are only disrupted after a successful add to the queue
+	c.decisions.RecordDisruption(ctx, m.Reason(), stateNodes, lo.FlatMap(cmd.candidates, func(c *Candidate, _ int) []*corev1.Pod {
+		return c.reschedulablePods
+	}), cmd.replacements, nodeClaimNames)
 	DecisionsPerformedTotal.Inc(map[string]string{
 		decisionLabel:          string(cmd.Decision()),
 		metrics.ReasonLabel:    strings.ToLower(string(m.Reason())),
@@ -245,6 +327,44 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 	return nil
 }
 
+// observe reports the command through events, metrics and the DisruptionObserved condition of the candidates'
+// NodeClaims, without tainting the candidates or launching replacements
+func (c *Controller) observe(ctx context.Context, m Method, cmd Command) error {
+	log.FromContext(ctx).WithValues("reason", strings.ToLower(string(m.Reason()))).Info(fmt.Sprintf("observed disruption of nodeclaim(s) via %s", cmd))
+	message := fmt.Sprintf("Would have disrupted via %s", cmd)
+	for _, candidate := range cmd.candidates {
+		c.observed[candidate.ProviderID()] = c.clock.Now()
+		c.recorder.Publish(disruptionevents.Observed(candidate.Node, candidate.NodeClaim, message)...)
+		stored := candidate.NodeClaim.DeepCopy()
+		nodeClaim := candidate.NodeClaim.DeepCopy()
+		if !nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionObserved, string(m.Reason()), message) {
+			continue
+		}
+		metrics.NodeClaimsDisruptionObservedTotal.Inc(map[string]string{
+			metrics.ReasonLabel:       strings.ToLower(string(m.Reason())),
+			metrics.NodePoolLabel:     candidate.nodePool.Name,
+			metrics.CapacityTypeLabel: candidate.capacityType,
+		})
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the status condition list
+		if err := c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneObserved forgets candidates whose observation period has passed, so that candidates that were deleted or
+// whose NodePools stopped observing don't accumulate
+func (c *Controller) pruneObserved() {
+	for providerID, observedAt := range c.observed {
+		if c.clock.Since(observedAt) >= observationPeriod {
+			delete(c.observed, providerID)
+		}
+	}
+}
+
 // createReplacementNodeClaims creates replacement NodeClaims
 func (c *Controller) createReplacementNodeClaims(ctx context.Context, m Method, cmd Command) ([]string, error) {
 	nodeClaimNames, err := c.provisioner.CreateNodeClaims(ctx, cmd.replacements, provisioning.WithReason(strings.ToLower(string(m.Reason()))))
@@ -19,11 +19,13 @@ package disruption
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
@@ -33,18 +35,20 @@ import (
 
 // Drift is a subreconciler that deletes drifted candidates.
 type Drift struct {
-	kubeClient  client.Client
-	cluster     *state.Cluster
-	provisioner *provisioning.Provisioner
-	recorder    events.Recorder
+	kubeClient    client.Client
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
 }
 
-func NewDrift(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Drift {
+func NewDrift(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Drift {
 	return &Drift{
-		kubeClient:  kubeClient,
-		cluster:     cluster,
-		provisioner: provisioner,
-		recorder:    recorder,
+		kubeClient:    kubeClient,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
 	}
 }
 
@@ -55,10 +59,27 @@ func (d *Drift) ShouldDisrupt(ctx context.Context, c *Candidate) bool {
 
 // ComputeCommand generates a disruption command given candidates
 func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
+	// Candidates from NodePools with a lower disruption priority go first, and within a priority, the longest drifted
 	sort.Slice(candidates, func(i int, j int) bool {
+		if pi, pj := candidates[i].nodePool.Spec.Disruption.Priority, candidates[j].nodePool.Spec.Disruption.Priority; pi != pj {
+			return pi < pj
+		}
 		return candidates[i].NodeClaim.StatusConditions().Get(string(d.Reason())).LastTransitionTime.Time.Before(
 			candidates[j].NodeClaim.StatusConditions().Get(string(d.Reason())).LastTransitionTime.Time)
 	})
+	// NodePools sharing a NodeClass may also share a drift budget set on the NodeClass. Since candidates are ordered by
+	// when they drifted, a NodeClass change is rolled out across its NodePools in the order they were drifted.
+	nodeClassBudgetMapping, err := BuildNodeClassDisruptionBudgetMapping(ctx, d.cluster, d.kubeClient, d.cloudProvider)
+	if err != nil {
+		return Command{}, scheduling.Results{}, fmt.Errorf("building nodeclass disruption budgets, %w", err)
+	}
+	allowed := func(c *Candidate) bool {
+		if disruptionBudgetMapping[c.nodePool.Name] == 0 {
+			return false
+		}
+		budget, ok := nodeClassBudgetMapping[nodeClassKey(c.NodeClaim.Spec.NodeClassRef)]
+		return !ok || budget > 0
+	}
 
 	// Do a quick check through the candidates to see if they're empty.
 	// For each candidate that is empty with a nodePool allowing its disruption
@@ -70,9 +91,12 @@ func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[
 		}
 		// If there's disruptions allowed for the candidate's nodepool,
 		// add it to the list of candidates, and decrement the budget.
-		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 {
+		if allowed(candidate) {
 			empty = append(empty, candidate)
 			disruptionBudgetMapping[candidate.nodePool.Name]--
+			if _, ok := nodeClassBudgetMapping[nodeClassKey(candidate.NodeClaim.Spec.NodeClassRef)]; ok {
+				nodeClassBudgetMapping[nodeClassKey(candidate.NodeClaim.Spec.NodeClassRef)]--
+			}
 		}
 	}
 	// Disrupt all empty drifted candidates, as they require no scheduling simulations.
@@ -86,7 +110,7 @@ func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[
 		// If the disruption budget doesn't allow this candidate to be disrupted,
 		// continue to the next candidate. We don't need to decrement any budget
 		// counter since drift commands can only have one candidate.
-		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
+		if !allowed(candidate) {
 			continue
 		}
 		// Check if we need to create any NodeClaims.
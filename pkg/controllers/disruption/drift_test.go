@@ -38,6 +38,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
 )
 
 var _ = Describe("Drift", func() {
@@ -200,6 +201,72 @@ var _ = Describe("Drift", func() {
 			ExpectSingletonReconciled(ctx, queue)
 			Expect(len(ExpectNodeClaims(ctx, env.Client))).To(Equal(numNodes))
 		})
+		It("should only allow empty nodes within the cpu budget to be disrupted", func() {
+			nodeClaims, nodes = test.NodeClaimsAndNodes(numNodes, v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+						v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+						corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceCPU:  resource.MustParse("32"),
+						corev1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "100%", Resources: map[corev1.ResourceName]string{corev1.ResourceCPU: "96"}}}
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			for i := 0; i < numNodes; i++ {
+				nodeClaims[i].StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+				ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+			}
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			// Execute command, thus deleting the nodes that fit in the cpu budget
+			ExpectSingletonReconciled(ctx, queue)
+			Expect(len(ExpectNodeClaims(ctx, env.Client))).To(Equal(numNodes - 3))
+		})
+		It("should only allow empty nodes within a percentage of the allocatable cpu to be disrupted", func() {
+			nodeClaims, nodes = test.NodeClaimsAndNodes(numNodes, v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+						v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+						corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceCPU:  resource.MustParse("32"),
+						corev1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "100%", Resources: map[corev1.ResourceName]string{corev1.ResourceCPU: "10%"}}}
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			for i := 0; i < numNodes; i++ {
+				nodeClaims[i].StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+				ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+			}
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			// Execute command, thus deleting the nodes that fit in the cpu budget
+			ExpectSingletonReconciled(ctx, queue)
+			Expect(len(ExpectNodeClaims(ctx, env.Client))).To(Equal(numNodes - 1))
+		})
 		It("should only allow 3 empty nodes to be disrupted", func() {
 			nodeClaims, nodes = test.NodeClaimsAndNodes(numNodes, v1.NodeClaim{
 				ObjectMeta: metav1.ObjectMeta{
@@ -438,6 +505,67 @@ var _ = Describe("Drift", func() {
 			ExpectSingletonReconciled(ctx, queue)
 			Expect(len(ExpectNodeClaims(ctx, env.Client))).To(Equal(0))
 		})
+		It("should share a nodeClass drift budget across all nodePools using the nodeClass", func() {
+			// Create 10 nodepools which would each allow all of their nodes to be disrupted
+			nps := test.NodePools(10, v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Disruption: v1.Disruption{
+						ConsolidateAfter: v1.MustParseNillableDuration("Never"),
+						Budgets: []v1.Budget{{
+							Nodes: "100%",
+						}},
+					},
+				},
+			})
+			// The nodeClass shared by all nodepools only allows 5 nodes to be drifted at once
+			nodeClass := test.NodeClass(v1alpha1.TestNodeClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: nps[0].Spec.Template.Spec.NodeClassRef.Name,
+					Annotations: map[string]string{
+						v1.DriftBudgetAnnotationKey: "5",
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			for i := 0; i < len(nps); i++ {
+				ExpectApplied(ctx, env.Client, nps[i])
+			}
+			nodeClaims = make([]*v1.NodeClaim, 0, 30)
+			nodes = make([]*corev1.Node, 0, 30)
+			// Create 3 nodes for each nodePool
+			for _, np := range nps {
+				ncs, ns := test.NodeClaimsAndNodes(3, v1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							v1.NodePoolLabelKey:            np.Name,
+							corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+							v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+							corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+						},
+					},
+					Status: v1.NodeClaimStatus{
+						Allocatable: map[corev1.ResourceName]resource.Quantity{
+							corev1.ResourceCPU:  resource.MustParse("32"),
+							corev1.ResourcePods: resource.MustParse("100"),
+						},
+					},
+				})
+				nodeClaims = append(nodeClaims, ncs...)
+				nodes = append(nodes, ns...)
+			}
+			for i := 0; i < len(nodeClaims); i++ {
+				nodeClaims[i].StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+				ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+			}
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			// Execute the command in the queue, only deleting 5 nodes across all nodepools
+			ExpectSingletonReconciled(ctx, queue)
+			Expect(len(ExpectNodeClaims(ctx, env.Client))).To(Equal(25))
+		})
 	})
 	Context("Drift", func() {
 		BeforeEach(func() {
@@ -940,6 +1068,87 @@ var _ = Describe("Drift", func() {
 			ExpectExists(ctx, env.Client, nodeClaim)
 			ExpectExists(ctx, env.Client, node)
 		})
+		It("should drift nodes from NodePools with a lower disruption priority first", func() {
+			labels := map[string]string{
+				"app": "test",
+			}
+
+			// create our RS so we can link a pod to it
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+
+			pods := test.Pods(2, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					},
+				},
+				// Make each pod request only fit on a single node
+				ResourceRequirements: corev1.ResourceRequirements{
+					Requests: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("30")},
+				},
+			})
+
+			// The critical NodePool's node drifted first, but its priority should hold it back
+			criticalNodePool := test.NodePool()
+			criticalNodePool.Spec.Disruption.Priority = 10
+			criticalNodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("Never")
+			criticalNodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "100%"}}
+			nodeClaim2, node2 := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            criticalNodePool.Name,
+						corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+						v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+						corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					ProviderID:  test.RandomProviderID(),
+					Allocatable: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("32")},
+				},
+			})
+			nodeClaim2.Status.Conditions = append(nodeClaim2.Status.Conditions, status.Condition{
+				Type:               v1.ConditionTypeDrifted,
+				Status:             metav1.ConditionTrue,
+				Reason:             v1.ConditionTypeDrifted,
+				Message:            v1.ConditionTypeDrifted,
+				LastTransitionTime: metav1.Time{Time: time.Now().Add(-time.Hour)},
+			})
+
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], nodeClaim, node, nodeClaim2, node2, nodePool, criticalNodePool)
+
+			// bind pods to node so that they're not empty and don't disrupt in parallel.
+			ExpectManualBinding(ctx, env.Client, pods[0], node)
+			ExpectManualBinding(ctx, env.Client, pods[1], node2)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			// disruption won't delete the old node until the new node is ready
+			var wg sync.WaitGroup
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectSingletonReconciled(ctx, queue)
+			// Cascade any deletion of the nodeClaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(2))
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			ExpectNotFound(ctx, env.Client, nodeClaim, node)
+			ExpectExists(ctx, env.Client, nodeClaim2)
+			ExpectExists(ctx, env.Client, node2)
+		})
 		It("should delete nodes with the karpenter.sh/do-not-disrupt annotation set to false", func() {
 			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1.DoNotDisruptAnnotationKey: "false"})
 			ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
@@ -22,11 +22,13 @@ import (
 	"fmt"
 
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 )
 
 // Emptiness is a subreconciler that deletes empty candidates.
@@ -35,6 +37,7 @@ type Emptiness struct {
 }
 
 func NewEmptiness(c consolidation) *Emptiness {
+	c.consolidationType = "empty"
 	return &Emptiness{
 		consolidation: c,
 	}
@@ -47,8 +50,15 @@ func (e *Emptiness) ShouldDisrupt(_ context.Context, c *Candidate) bool {
 		e.recorder.Publish(disruptionevents.Unconsolidatable(c.Node, c.NodeClaim, fmt.Sprintf("NodePool %q has consolidation disabled", c.nodePool.Name))...)
 		return false
 	}
-	// return true if there are no pods and the nodeclaim is consolidatable
-	return len(c.reschedulablePods) == 0 && c.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()
+	if !c.empty {
+		return false
+	}
+	// return true if the nodeclaim is consolidatable
+	if !c.NodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue() {
+		e.reject(RejectionReasonCooldown)
+		return false
+	}
+	return true
 }
 
 // ComputeCommand generates a disruption command given candidates
@@ -62,19 +72,48 @@ func (e *Emptiness) ComputeCommand(ctx context.Context, disruptionBudgetMapping
 
 	empty := make([]*Candidate, 0, len(candidates))
 	constrainedByBudgets := false
+	floorMapping := BuildCapacityTypeFloorMapping(e.clock, e.cluster, candidates)
+	// The nodes of a placement group are only removed once all of them are empty, so that the group isn't broken up
+	emptyGroups := emptyPlacementGroups(e.cluster, candidates)
 	for _, candidate := range candidates {
-		if len(candidate.reschedulablePods) > 0 {
+		if !candidate.empty {
+			continue
+		}
+		if group := candidate.NodeClaim.Spec.PlacementGroup; group != "" {
+			// The whole group is considered when its first member is reached, and only removed if all of it fits
+			if !emptyGroups.Has(group) {
+				continue
+			}
+			emptyGroups.Delete(group)
+			members := lo.Filter(candidates, func(c *Candidate, _ int) bool { return c.NodeClaim.Spec.PlacementGroup == group })
+			if !fitsEmptyBudgets(disruptionBudgetMapping, floorMapping, members) {
+				constrainedByBudgets = true
+				e.reject(RejectionReasonBudget)
+				continue
+			}
+			for _, member := range members {
+				disruptionBudgetMapping[member.nodePool.Name]--
+				decrementCapacityTypeFloor(floorMapping, member)
+			}
+			empty = append(empty, members...)
 			continue
 		}
 		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
 			// set constrainedByBudgets to true if any node was a candidate but was constrained by a budget
 			constrainedByBudgets = true
+			e.reject(RejectionReasonBudget)
+			continue
+		}
+		// Keep empty nodes around if the NodePool needs them to stay at its minimum for the capacity type
+		if atCapacityTypeFloor(floorMapping, candidate) {
+			e.reject(RejectionReasonBudget)
 			continue
 		}
 		// If there's disruptions allowed for the candidate's nodepool,
 		// add it to the list of candidates, and decrement the budget.
 		empty = append(empty, candidate)
 		disruptionBudgetMapping[candidate.nodePool.Name]--
+		decrementCapacityTypeFloor(floorMapping, candidate)
 	}
 	// none empty, so do nothing
 	if len(empty) == 0 {
@@ -112,7 +151,7 @@ func (e *Emptiness) ComputeCommand(ctx context.Context, disruptionBudgetMapping
 
 	// TODO (jmdeal@): better encapsulate within validation
 	if lo.ContainsBy(validatedCandidates, func(c *Candidate) bool {
-		return len(c.reschedulablePods) != 0
+		return !c.empty
 	}) {
 		log.FromContext(ctx).V(1).Info(fmt.Sprintf("abandoning empty node consolidation attempt due to pod churn, command is no longer valid, %s", cmd))
 		return Command{}, scheduling.Results{}, nil
@@ -121,6 +160,36 @@ func (e *Emptiness) ComputeCommand(ctx context.Context, disruptionBudgetMapping
 	return cmd, scheduling.Results{}, nil
 }
 
+// emptyPlacementGroups returns the placement groups whose nodes are all empty candidates
+func emptyPlacementGroups(cluster *state.Cluster, candidates []*Candidate) sets.Set[string] {
+	members := map[string]int{}
+	for _, node := range cluster.Nodes() {
+		if node.NodeClaim == nil || node.NodeClaim.Spec.PlacementGroup == "" || node.MarkedForDeletion() {
+			continue
+		}
+		members[node.NodeClaim.Spec.PlacementGroup]++
+	}
+	empty := lo.CountValuesBy(lo.Filter(candidates, func(c *Candidate, _ int) bool {
+		return c.empty && c.NodeClaim.Spec.PlacementGroup != ""
+	}), func(c *Candidate) string { return c.NodeClaim.Spec.PlacementGroup })
+	return sets.New(lo.Keys(lo.PickBy(empty, func(group string, count int) bool { return count == members[group] }))...)
+}
+
+// fitsEmptyBudgets returns true if all of the candidates can be removed together without exceeding their NodePools'
+// disruption budgets or dropping below their capacity type floors
+func fitsEmptyBudgets(disruptionBudgetMapping map[string]int, floorMapping map[string]int, candidates []*Candidate) bool {
+	budgets := lo.Assign(disruptionBudgetMapping)
+	floors := lo.Assign(floorMapping)
+	for _, candidate := range candidates {
+		if budgets[candidate.nodePool.Name] == 0 || atCapacityTypeFloor(floors, candidate) {
+			return false
+		}
+		budgets[candidate.nodePool.Name]--
+		decrementCapacityTypeFloor(floors, candidate)
+	}
+	return true
+}
+
 func (e *Emptiness) Reason() v1.DisruptionReason {
 	return v1.DisruptionReasonEmpty
 }
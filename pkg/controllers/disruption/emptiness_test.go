@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
@@ -454,6 +455,48 @@ var _ = Describe("Emptiness", func() {
 			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
 			ExpectExists(ctx, env.Client, nodeClaim)
 		})
+		It("can delete nodes whose only pods match an emptiness ignored pod selector", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EmptinessIgnoredPods: lo.ToPtr("monitoring:app=agent")}))
+			pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Labels: map[string]string{"app": "agent"}}})
+			ExpectApplied(ctx, env.Client, test.Namespace(test.NamespaceOptions{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}}))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			wg := sync.WaitGroup{}
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+			// Cascade any deletion of the nodeClaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+			ExpectNotFound(ctx, env.Client, nodeClaim, node)
+		})
+		It("should ignore nodes with pods that don't match an emptiness ignored pod selector", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EmptinessIgnoredPods: lo.ToPtr("monitoring:app=agent")}))
+			ignored := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Labels: map[string]string{"app": "agent"}}})
+			pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "agent"}}})
+			ExpectApplied(ctx, env.Client, test.Namespace(test.NamespaceOptions{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}}))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool, ignored, pod)
+			ExpectManualBinding(ctx, env.Client, ignored, node)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			// Expect to not create or delete more nodeclaims
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
 		It("should ignore nodes with the consolidatable status condition set to false", func() {
 			nodeClaim.StatusConditions().SetFalse(v1.ConditionTypeConsolidatable, "NotEmpty", "NotEmpty")
 			ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
@@ -494,6 +537,187 @@ var _ = Describe("Emptiness", func() {
 		ExpectNotFound(ctx, env.Client, nodeClaim)
 		ExpectNotFound(ctx, env.Client, nodeClaim2)
 	})
+	Context("Placement Groups", func() {
+		BeforeEach(func() {
+			nodeClaim.Spec.PlacementGroup = "training"
+			nodeClaim2.Spec.PlacementGroup = "training"
+		})
+		It("should keep the empty nodes of a placement group while any of its nodes have pods", func() {
+			pod := test.Pod()
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node2)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			// Expect to not create or delete more nodeclaims
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(2))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim2)
+		})
+		It("should delete the nodes of a placement group once all of them are empty", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+
+			wg := sync.WaitGroup{}
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(0))
+			ExpectNotFound(ctx, env.Client, nodeClaim, nodeClaim2)
+		})
+		It("should keep the nodes of an empty placement group if the budget doesn't allow all of them to be deleted", func() {
+			nodePool.Spec.Disruption.Budgets = []v1.Budget{{Nodes: "1"}}
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectSingletonReconciled(ctx, disruptionController)
+
+			// Expect to not create or delete more nodeclaims
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(2))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim2)
+		})
+		It("should delete empty nodes that aren't in the placement group", func() {
+			nodeClaim.Spec.PlacementGroup = ""
+			pod := test.Pod()
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node2)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+
+			wg := sync.WaitGroup{}
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, nodeClaim2)
+		})
+	})
+	Context("Capacity Type Minimums", func() {
+		It("should keep empty nodes needed for a capacity type minimum", func() {
+			capacityType := leastExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any()
+			nodePool.Spec.Disruption.MinNodes = []v1.CapacityTypeMinimum{{CapacityType: capacityType, Nodes: 1}}
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+
+			wg := sync.WaitGroup{}
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+
+			// we should only delete one of the empty nodes
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should delete empty nodes of capacity types without a minimum", func() {
+			capacityType := leastExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any()
+			otherCapacityType := lo.Ternary(capacityType == v1.CapacityTypeSpot, v1.CapacityTypeOnDemand, v1.CapacityTypeSpot)
+			nodePool.Spec.Disruption.MinNodes = []v1.CapacityTypeMinimum{{CapacityType: otherCapacityType, Nodes: 2}}
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+
+			wg := sync.WaitGroup{}
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+
+			// the minimum is for a different capacity type, so both empty nodes can be deleted
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(0))
+		})
+		It("should keep empty nodes needed for the NodePool's minimum", func() {
+			nodePool.Spec.MinNodes = lo.ToPtr[int32](1)
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+
+			wg := sync.WaitGroup{}
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+
+			// the NodePool's minimum applies regardless of capacity type, so only one of the empty nodes is deleted
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should keep empty nodes needed for the NodePool's headroom", func() {
+			nodePool.Spec.Headroom = "1"
+			ExpectApplied(ctx, env.Client, nodeClaim, node, nodeClaim2, node2, nodePool)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2}, []*v1.NodeClaim{nodeClaim, nodeClaim2})
+
+			fakeClock.Step(10 * time.Minute)
+
+			wg := sync.WaitGroup{}
+			ExpectToWait(fakeClock, &wg)
+			ExpectSingletonReconciled(ctx, disruptionController)
+			wg.Wait()
+
+			ExpectSingletonReconciled(ctx, queue)
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim, nodeClaim2)
+
+			// one of the empty nodes is kept as the NodePool's headroom
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+		})
+	})
 	It("considers pending pods when consolidating", func() {
 		largeTypes := lo.Filter(cloudProvider.InstanceTypes, func(item *cloudprovider.InstanceType, index int) bool {
 			return item.Capacity.Cpu().Cmp(resource.MustParse("64")) >= 0
@@ -114,6 +114,30 @@ func Blocked(node *corev1.Node, nodeClaim *v1.NodeClaim, reason string) (evs []e
 	return evs
 }
 
+// Observed is an event that informs the user that a NodeClaim/Node combination of an ObserveOnly NodePool would have
+// been disrupted
+func Observed(node *corev1.Node, nodeClaim *v1.NodeClaim, message string) (evs []events.Event) {
+	if node != nil {
+		evs = append(evs, events.Event{
+			InvolvedObject: node,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionObserved",
+			Message:        message,
+			DedupeValues:   []string{string(node.UID), message},
+		})
+	}
+	if nodeClaim != nil {
+		evs = append(evs, events.Event{
+			InvolvedObject: nodeClaim,
+			Type:           corev1.EventTypeNormal,
+			Reason:         "DisruptionObserved",
+			Message:        message,
+			DedupeValues:   []string{string(nodeClaim.UID), message},
+		})
+	}
+	return evs
+}
+
 func NodePoolBlockedForDisruptionReason(nodePool *v1.NodePool, reason v1.DisruptionReason) events.Event {
 	return events.Event{
 		InvolvedObject: nodePool,
@@ -21,9 +21,18 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -38,9 +47,12 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 	"sigs.k8s.io/karpenter/pkg/utils/pdb"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
 var errCandidateDeleting = fmt.Errorf("candidate is deleting")
@@ -49,6 +61,8 @@ var errCandidateDeleting = fmt.Errorf("candidate is deleting")
 func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner,
 	candidates ...*Candidate,
 ) (pscheduling.Results, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SimulateScheduling", trace.WithAttributes(attribute.Int("candidates", len(candidates))))
+	defer span.End()
 	candidateNames := sets.NewString(lo.Map(candidates, func(t *Candidate, i int) string { return t.Name() })...)
 	nodes := cluster.Nodes()
 	deletingNodes := nodes.Deleting()
@@ -143,6 +157,14 @@ func instanceTypesAreSubset(lhs []*cloudprovider.InstanceType, rhs []*cloudprovi
 // GetCandidates returns nodes that appear to be currently deprovisionable based off of their nodePool
 func GetCandidates(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, recorder events.Recorder, clk clock.Clock,
 	cloudProvider cloudprovider.CloudProvider, shouldDisrupt CandidateFilter, disruptionClass string, queue *orchestration.Queue,
+) ([]*Candidate, error) {
+	return getCandidates(ctx, cluster, kubeClient, recorder, clk, cloudProvider, shouldDisrupt, disruptionClass, queue, nil)
+}
+
+// getCandidates is GetCandidates with an optional callback for each node that couldn't be made into a candidate
+func getCandidates(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, recorder events.Recorder, clk clock.Clock,
+	cloudProvider cloudprovider.CloudProvider, shouldDisrupt CandidateFilter, disruptionClass string, queue *orchestration.Queue,
+	onInvalidCandidate func(*state.StateNode, error),
 ) ([]*Candidate, error) {
 	nodePoolMap, nodePoolToInstanceTypesMap, err := BuildNodePoolMap(ctx, kubeClient, cloudProvider)
 	if err != nil {
@@ -152,14 +174,46 @@ func GetCandidates(ctx context.Context, cluster *state.Cluster, kubeClient clien
 	if err != nil {
 		return nil, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
 	}
-	candidates := lo.FilterMap(cluster.Nodes(), func(n *state.StateNode, _ int) (*Candidate, bool) {
+	type evaluation struct {
+		candidate *Candidate
+		err       error
+	}
+	nodes := cluster.Nodes()
+	evaluations, err := evaluateConcurrently(ctx, nodes, func(n *state.StateNode) evaluation {
 		cn, e := NewCandidate(ctx, kubeClient, recorder, clk, n, pdbs, nodePoolMap, nodePoolToInstanceTypesMap, queue, disruptionClass)
-		return cn, e == nil
+		return evaluation{candidate: cn, err: e}
 	})
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Candidate
+	for i, e := range evaluations {
+		if e.err != nil {
+			if onInvalidCandidate != nil {
+				onInvalidCandidate(nodes[i], e.err)
+			}
+			continue
+		}
+		candidates = append(candidates, e.candidate)
+	}
 	// Filter only the valid candidates that we should disrupt
 	return lo.Filter(candidates, func(c *Candidate, _ int) bool { return shouldDisrupt(ctx, c) }), nil
 }
 
+// evaluateConcurrently evaluates each of the items with up to the configured number of disruption evaluation workers.
+// The results are in the order of the items, regardless of the order that the evaluations finish in.
+func evaluateConcurrently[T, R any](ctx context.Context, items []T, evaluate func(T) R) ([]R, error) {
+	results := make([]R, len(items))
+	workqueue.ParallelizeUntil(ctx, max(options.FromContext(ctx).DisruptionEvaluationWorkers, 1), len(items), func(i int) {
+		results[i] = evaluate(items[i])
+	})
+	// Evaluations stop being started once the context is canceled, leaving the remaining results unset
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // BuildNodePoolMap builds a provName -> nodePool map and a provName -> instanceName -> instance type map
 func BuildNodePoolMap(ctx context.Context, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) (map[string]*v1.NodePool, map[string]map[string]*cloudprovider.InstanceType, error) {
 	nodePoolMap := map[string]*v1.NodePool{}
@@ -199,31 +253,12 @@ func BuildDisruptionBudgetMapping(ctx context.Context, cluster *state.Cluster, c
 	numNodes := map[string]int{}   // map[nodepool] -> node count in nodepool
 	disrupting := map[string]int{} // map[nodepool] -> nodes undergoing disruption
 	for _, node := range cluster.Nodes() {
-		// We only consider nodes that we own and are initialized towards the total.
-		// If a node is launched/registered, but not initialized, pods aren't scheduled
-		// to the node, and these are treated as unhealthy until they're cleaned up.
-		// This prevents odd roundup cases with percentages where replacement nodes that
-		// aren't initialized could be counted towards the total, resulting in more disruptions
-		// to active nodes than desired, where Karpenter should wait for these nodes to be
-		// healthy before continuing.
-		if !node.Managed() || !node.Initialized() {
+		if !countsTowardsDisruptionBudget(node) {
 			continue
 		}
-
-		// Additionally, don't consider nodeclaims that have the terminating condition. A nodeclaim should have
-		// the Terminating condition only when the node is drained and cloudprovider.Delete() was successful
-		// on the underlying cloud provider machine.
-		if node.NodeClaim.StatusConditions().Get(v1.ConditionTypeInstanceTerminating).IsTrue() {
-			continue
-		}
-
 		nodePool := node.Labels()[v1.NodePoolLabelKey]
 		numNodes[nodePool]++
-
-		// If the node satisfies one of the following, we subtract it from the allowed disruptions.
-		// 1. Has a NotReady conditiion
-		// 2. Is marked as disrupting
-		if cond := nodeutils.GetCondition(node.Node, corev1.NodeReady); cond.Status != corev1.ConditionTrue || node.MarkedForDeletion() {
+		if isDisruptingForBudget(node) {
 			disrupting[nodePool]++
 		}
 	}
@@ -244,6 +279,226 @@ func BuildDisruptionBudgetMapping(ctx context.Context, cluster *state.Cluster, c
 	return disruptionBudgetMapping, nil
 }
 
+// BuildNodeClassDisruptionBudgetMapping returns the number of drift disruptions that are still allowed for each NodeClass
+// which sets a drift budget through the karpenter.sh/drift-budget annotation, keyed by nodeClassKey. The budget is shared
+// by all NodePools referencing the NodeClass so that a NodeClass change doesn't replace nodes in every NodePool at once.
+func BuildNodeClassDisruptionBudgetMapping(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) (map[string]int, error) {
+	numNodes := map[string]int{}   // map[nodeclass] -> node count across all nodepools using the nodeclass
+	disrupting := map[string]int{} // map[nodeclass] -> nodes undergoing disruption
+	refs := map[string]*v1.NodeClassReference{}
+	for _, node := range cluster.Nodes() {
+		if !countsTowardsDisruptionBudget(node) || node.NodeClaim.Spec.NodeClassRef == nil {
+			continue
+		}
+		key := nodeClassKey(node.NodeClaim.Spec.NodeClassRef)
+		refs[key] = node.NodeClaim.Spec.NodeClassRef
+		numNodes[key]++
+		if isDisruptingForBudget(node) {
+			disrupting[key]++
+		}
+	}
+	budgetMapping := map[string]int{}
+	for key, ref := range refs {
+		nodeClass, ok := lo.Find(cloudProvider.GetSupportedNodeClasses(), func(nc status.Object) bool {
+			return object.GVK(nc).GroupKind() == ref.GroupKind()
+		})
+		if !ok {
+			continue
+		}
+		nodeClass = nodeClass.DeepCopyObject().(status.Object)
+		if err := kubeClient.Get(ctx, client.ObjectKey{Name: ref.Name}, nodeClass); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("getting nodeclass, %w", err)
+		}
+		budget, ok := nodeClass.GetAnnotations()[v1.DriftBudgetAnnotationKey]
+		if !ok {
+			continue
+		}
+		// Round up like NodePool budgets so that a small percentage doesn't block all disruptions
+		allowed, err := intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(v1.GetIntStrFromValue(budget)), numNodes[key], true)
+		if err != nil {
+			// If the budget is misconfigured, fail closed since we don't know what the user wants here
+			log.FromContext(ctx).WithValues(strings.ToLower(ref.Kind), ref.Name).Error(err, "parsing drift budget")
+			allowed = 0
+		}
+		budgetMapping[key] = lo.Max([]int{allowed - disrupting[key], 0})
+	}
+	return budgetMapping, nil
+}
+
+// BuildResourceDisruptionBudgetMapping returns the amount of each resource that can still be disrupted for the NodePools
+// with budgets that limit resources, keyed by NodePool name. Budgets are scaled with the allocatable of the NodePool's
+// nodes, and the allocatable of nodes that are already disrupting is subtracted like it is for node counts.
+func BuildResourceDisruptionBudgetMapping(ctx context.Context, cluster *state.Cluster, clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, reason v1.DisruptionReason) (map[string]corev1.ResourceList, error) {
+	allocatable := map[string]corev1.ResourceList{} // map[nodepool] -> allocatable of the nodes in the nodepool
+	disrupting := map[string]corev1.ResourceList{}  // map[nodepool] -> allocatable of the nodes undergoing disruption
+	for _, node := range cluster.Nodes() {
+		if !countsTowardsDisruptionBudget(node) {
+			continue
+		}
+		nodePool := node.Labels()[v1.NodePoolLabelKey]
+		allocatable[nodePool] = resources.Merge(allocatable[nodePool], node.Allocatable())
+		if isDisruptingForBudget(node) {
+			disrupting[nodePool] = resources.Merge(disrupting[nodePool], node.Allocatable())
+		}
+	}
+	nodePools, err := nodepoolutils.ListManaged(ctx, kubeClient, cloudProvider)
+	if err != nil {
+		return nil, fmt.Errorf("listing node pools, %w", err)
+	}
+	budgetMapping := map[string]corev1.ResourceList{}
+	for _, nodePool := range nodePools {
+		allowed, err := nodePool.GetAllowedDisruptionResourcesByReason(clk, allocatable[nodePool.Name], reason)
+		if err != nil {
+			// If the budget is misconfigured, fail closed since we don't know what the user wants here
+			log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool)).Error(err, "parsing disruption budget resources")
+		}
+		if len(allowed) == 0 {
+			continue
+		}
+		remaining := resources.Subtract(allowed, disrupting[nodePool.Name])
+		for name, quantity := range remaining {
+			if quantity.Sign() < 0 {
+				remaining[name] = resource.Quantity{}
+			}
+		}
+		budgetMapping[nodePool.Name] = remaining
+	}
+	return budgetMapping, nil
+}
+
+// fitsResourceBudgets returns the command limited to the candidates that fit within the remaining resource budgets of
+// their NodePools. Candidates are only dropped from commands without replacements, since deleting fewer candidates
+// never invalidates the scheduling simulation, while a replacement is sized for all of the command's candidates.
+func fitsResourceBudgets(cmd Command, budgetMapping map[string]corev1.ResourceList) Command {
+	remaining := copyResourceBudgets(budgetMapping)
+	fits := lo.Filter(cmd.candidates, func(c *Candidate, _ int) bool { return reserveResourceBudget(remaining, c) })
+	if len(fits) == len(cmd.candidates) {
+		return cmd
+	}
+	if len(cmd.replacements) > 0 {
+		return Command{}
+	}
+	return Command{candidates: fits}
+}
+
+func copyResourceBudgets(budgetMapping map[string]corev1.ResourceList) map[string]corev1.ResourceList {
+	return lo.MapValues(budgetMapping, func(r corev1.ResourceList, _ string) corev1.ResourceList { return r.DeepCopy() })
+}
+
+// reserveResourceBudget subtracts the candidate's allocatable from the remaining resource budget of its NodePool,
+// returning false without changing the budget if the candidate doesn't fit
+func reserveResourceBudget(remaining map[string]corev1.ResourceList, c *Candidate) bool {
+	budget, ok := remaining[c.nodePool.Name]
+	if !ok {
+		return true
+	}
+	if !resources.Fits(lo.PickByKeys(c.Allocatable(), lo.Keys(budget)), budget) {
+		return false
+	}
+	remaining[c.nodePool.Name] = resources.Subtract(budget, c.Allocatable())
+	return true
+}
+
+// countsTowardsDisruptionBudget returns whether the node is counted towards the total node count that budgets scale with.
+// We only consider nodes that we own and are initialized towards the total.
+// If a node is launched/registered, but not initialized, pods aren't scheduled
+// to the node, and these are treated as unhealthy until they're cleaned up.
+// This prevents odd roundup cases with percentages where replacement nodes that
+// aren't initialized could be counted towards the total, resulting in more disruptions
+// to active nodes than desired, where Karpenter should wait for these nodes to be
+// healthy before continuing.
+// Additionally, don't consider nodeclaims that have the terminating condition. A nodeclaim should have
+// the Terminating condition only when the node is drained and cloudprovider.Delete() was successful
+// on the underlying cloud provider machine.
+func countsTowardsDisruptionBudget(node *state.StateNode) bool {
+	return node.Managed() && node.Initialized() && !node.NodeClaim.StatusConditions().Get(v1.ConditionTypeInstanceTerminating).IsTrue()
+}
+
+// isDisruptingForBudget returns whether the node should be subtracted from the allowed disruptions, which is the case
+// if it either has a NotReady condition or is marked as disrupting.
+func isDisruptingForBudget(node *state.StateNode) bool {
+	cond := nodeutils.GetCondition(node.Node, corev1.NodeReady)
+	return cond.Status != corev1.ConditionTrue || node.MarkedForDeletion()
+}
+
+// BuildCapacityTypeFloorMapping returns how many more nodes of each capacity type can be removed from the candidates'
+// NodePools before a NodePool drops to the minimum set in spec.disruption.minNodes, keyed by capacityTypeFloorKey.
+// The NodePool's spec.minNodes is a floor across all capacity types, which is keyed by anyCapacityType, and its
+// spec.headroom is a floor on empty nodes, which is keyed by emptyNodes.
+// NodePool and capacity type combinations without a minimum aren't included in the mapping.
+func BuildCapacityTypeFloorMapping(clk clock.Clock, cluster *state.Cluster, candidates []*Candidate) map[string]int {
+	numNodes := map[string]int{} // map[nodepool/capacitytype] -> nodes which aren't already being removed
+	for _, node := range cluster.Nodes() {
+		if !countsTowardsDisruptionBudget(node) || node.MarkedForDeletion() {
+			continue
+		}
+		numNodes[capacityTypeFloorKey(node.Labels()[v1.NodePoolLabelKey], node.Labels()[v1.CapacityTypeLabelKey])]++
+		numNodes[capacityTypeFloorKey(node.Labels()[v1.NodePoolLabelKey], anyCapacityType)]++
+		if node.Vacant(clk) {
+			numNodes[capacityTypeFloorKey(node.Labels()[v1.NodePoolLabelKey], emptyNodes)]++
+		}
+	}
+	floorMapping := map[string]int{}
+	for _, candidate := range candidates {
+		for _, minimum := range candidate.nodePool.Spec.Disruption.MinNodes {
+			key := capacityTypeFloorKey(candidate.nodePool.Name, minimum.CapacityType)
+			floorMapping[key] = lo.Max([]int{numNodes[key] - int(minimum.Nodes), 0})
+		}
+		if minimum := candidate.nodePool.Spec.MinNodes; minimum != nil {
+			key := capacityTypeFloorKey(candidate.nodePool.Name, anyCapacityType)
+			floorMapping[key] = lo.Max([]int{numNodes[key] - int(*minimum), 0})
+		}
+		if candidate.nodePool.Spec.Headroom != "" {
+			key := capacityTypeFloorKey(candidate.nodePool.Name, emptyNodes)
+			occupied := numNodes[capacityTypeFloorKey(candidate.nodePool.Name, anyCapacityType)] - numNodes[key]
+			floorMapping[key] = lo.Max([]int{numNodes[key] - candidate.nodePool.GetHeadroom(occupied), 0})
+		}
+	}
+	return floorMapping
+}
+
+// atCapacityTypeFloor returns whether removing the candidate would take its NodePool below the minimum for the
+// candidate's capacity type, below the NodePool's minimum, or below its headroom if the candidate is empty
+func atCapacityTypeFloor(floorMapping map[string]int, candidate *Candidate) bool {
+	return lo.ContainsBy(floorKeys(candidate), func(capacityType string) bool {
+		remaining, ok := floorMapping[capacityTypeFloorKey(candidate.nodePool.Name, capacityType)]
+		return ok && remaining == 0
+	})
+}
+
+// decrementCapacityTypeFloor counts the candidate's removal against the floor mapping
+func decrementCapacityTypeFloor(floorMapping map[string]int, candidate *Candidate) {
+	for _, capacityType := range floorKeys(candidate) {
+		key := capacityTypeFloorKey(candidate.nodePool.Name, capacityType)
+		if _, ok := floorMapping[key]; ok {
+			floorMapping[key]--
+		}
+	}
+}
+
+// anyCapacityType keys the floor that applies to a NodePool's nodes regardless of their capacity type. It isn't a
+// valid label value, so it can't collide with a capacity type.
+const anyCapacityType = "*"
+
+// emptyNodes keys the floor on a NodePool's empty nodes that's kept for its headroom
+const emptyNodes = "*empty"
+
+// floorKeys returns the floors that the candidate counts against
+func floorKeys(candidate *Candidate) []string {
+	return lo.Ternary(candidate.empty, []string{candidate.capacityType, anyCapacityType, emptyNodes}, []string{candidate.capacityType, anyCapacityType})
+}
+
+func capacityTypeFloorKey(nodePool, capacityType string) string {
+	return fmt.Sprintf("%s/%s", nodePool, capacityType)
+}
+
+func nodeClassKey(ref *v1.NodeClassReference) string {
+	return fmt.Sprintf("%s/%s/%s", ref.Group, ref.Kind, ref.Name)
+}
+
 // mapCandidates maps the list of proposed candidates with the current state
 func mapCandidates(proposed, current []*Candidate) []*Candidate {
 	proposedNames := sets.NewString(lo.Map(proposed, func(c *Candidate, i int) string { return c.Name() })...)
@@ -28,6 +28,21 @@ const (
 	voluntaryDisruptionSubsystem = "voluntary_disruption"
 	decisionLabel                = "decision"
 	consolidationTypeLabel       = "consolidation_type"
+	rejectionReasonLabel         = "rejection_reason"
+)
+
+// Rejection reasons recorded by ConsolidationCandidateRejectionsTotal
+const (
+	// RejectionReasonBudget is used when a disruption budget or capacity type minimum doesn't allow the candidate to be disrupted
+	RejectionReasonBudget = "budget"
+	// RejectionReasonPDB is used when a PodDisruptionBudget doesn't allow the candidate's pods to be evicted
+	RejectionReasonPDB = "pdb"
+	// RejectionReasonCost is used when there's no replacement cheaper than the candidate
+	RejectionReasonCost = "cost"
+	// RejectionReasonScheduling is used when the candidate's pods can't be rescheduled, e.g. because it would violate topology spread
+	RejectionReasonScheduling = "scheduling"
+	// RejectionReasonCooldown is used when the candidate hasn't been consolidatable for the NodePool's consolidateAfter yet
+	RejectionReasonCooldown = "cooldown"
 )
 
 func init() {
@@ -87,4 +102,14 @@ var (
 		},
 		[]string{metrics.NodePoolLabel, metrics.ReasonLabel},
 	)
+	ConsolidationCandidateRejectionsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: voluntaryDisruptionSubsystem,
+			Name:      "consolidation_candidate_rejections_total",
+			Help:      "Number of times a node was evaluated for consolidation and rejected. Labeled by consolidation type and rejection reason.",
+		},
+		[]string{consolidationTypeLabel, rejectionReasonLabel},
+	)
 )
@@ -40,6 +40,7 @@ type MultiNodeConsolidation struct {
 }
 
 func NewMultiNodeConsolidation(consolidation consolidation) *MultiNodeConsolidation {
+	consolidation.consolidationType = MultiNodeConsolidationType
 	return &MultiNodeConsolidation{consolidation: consolidation}
 }
 
@@ -55,14 +56,23 @@ func (m *MultiNodeConsolidation) ComputeCommand(ctx context.Context, disruptionB
 	// simulateScheduling(nodes[0, n]), doing a binary search on n to find
 	// the optimal consolidation command, this pre-filters out nodes that
 	// would have violated the budget anyway, preserving the ordering
-	// and only considering a number of nodes that can be disrupted.
+	// and only considering a number of nodes that can be disrupted. The same
+	// applies to candidates that NodePools need to stay at their capacity type minimums, and to candidates that would
+	// exceed what's left of their NodePool's resource budget once the candidates before them are disrupted.
+	resourceBudgetMapping, err := BuildResourceDisruptionBudgetMapping(ctx, m.cluster, m.clock, m.kubeClient, m.cloudProvider, m.Reason())
+	if err != nil {
+		return Command{}, scheduling.Results{}, fmt.Errorf("building resource disruption budgets, %w", err)
+	}
 	disruptableCandidates := make([]*Candidate, 0, len(candidates))
 	constrainedByBudgets := false
+	floorMapping := BuildCapacityTypeFloorMapping(m.clock, m.cluster, candidates)
+	remainingResources := copyResourceBudgets(resourceBudgetMapping)
 	for _, candidate := range candidates {
 		// If there's disruptions allowed for the candidate's nodepool,
 		// add it to the list of candidates, and decrement the budget.
 		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
 			constrainedByBudgets = true
+			m.reject(RejectionReasonBudget)
 			continue
 		}
 		// Filter out empty candidates. If there was an empty node that wasn't consolidated before this, we should
@@ -71,9 +81,19 @@ func (m *MultiNodeConsolidation) ComputeCommand(ctx context.Context, disruptionB
 		if len(candidate.reschedulablePods) == 0 {
 			continue
 		}
+		if atCapacityTypeFloor(floorMapping, candidate) {
+			m.reject(RejectionReasonBudget)
+			continue
+		}
+		if !reserveResourceBudget(remainingResources, candidate) {
+			constrainedByBudgets = true
+			m.reject(RejectionReasonBudget)
+			continue
+		}
 		// set constrainedByBudgets to true if any node was a candidate but was constrained by a budget
 		disruptableCandidates = append(disruptableCandidates, candidate)
 		disruptionBudgetMapping[candidate.nodePool.Name]--
+		decrementCapacityTypeFloor(floorMapping, candidate)
 	}
 
 	// Only consider a maximum batch of 100 NodeClaims to save on computation.
@@ -20,6 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,8 +29,10 @@ import (
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -44,6 +48,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/utils/pdb"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
@@ -200,7 +205,7 @@ func (q *Queue) Reconcile(ctx context.Context) (reconcile.Result, error) {
 			metrics.ReasonLabel:    pretty.ToSnakeCase(string(cmd.reason)),
 			consolidationTypeLabel: cmd.consolidationType,
 		})
-		multiErr := multierr.Combine(err, cmd.lastError, state.RequireNoScheduleTaint(ctx, q.kubeClient, false, cmd.candidates...))
+		multiErr := multierr.Combine(err, cmd.lastError, state.RequireNoScheduleTaint(ctx, q.kubeClient, false, cmd.candidates...), q.clearEvictionPlan(ctx, cmd))
 		// Log the error
 		log.FromContext(ctx).WithValues("nodes", strings.Join(lo.Map(cmd.candidates, func(s *state.StateNode, _ int) string {
 			return s.Name()
@@ -256,6 +261,12 @@ func (q *Queue) waitOrTerminate(ctx context.Context, cmd *Command) error {
 	}
 
 	// All replacements have been provisioned.
+	if err := q.planEvictions(ctx, cmd); err != nil {
+		return fmt.Errorf("planning evictions, %w", err)
+	}
+	if err := q.annotateDisruptionReason(ctx, cmd); err != nil {
+		return fmt.Errorf("annotating disruption reason, %w", err)
+	}
 	// All we need to do now is get a successful delete call for each node claim,
 	// then the termination controller will handle the eventual deletion of the nodes.
 	var multiErr error
@@ -280,6 +291,95 @@ func (q *Queue) waitOrTerminate(ctx context.Context, cmd *Command) error {
 	return nil
 }
 
+// planEvictions records the order in which the candidates of a multi-node command that share PDBs should be drained.
+// Draining them concurrently has every node competing for the same disruption budgets, so none of them finishes
+// draining until the others do. Candidates that are governed by the fewest shared PDBs go first since they'll drain
+// the soonest, and the termination controller holds back evictions from later candidates until earlier ones are done.
+func (q *Queue) planEvictions(ctx context.Context, cmd *Command) error {
+	if len(cmd.candidates) < 2 {
+		return nil
+	}
+	limits, err := pdb.NewLimits(ctx, q.clock, q.kubeClient)
+	if err != nil {
+		return fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
+	}
+	governing := make([]sets.Set[client.ObjectKey], len(cmd.candidates))
+	candidatesPerPDB := map[client.ObjectKey]int{}
+	for i, candidate := range cmd.candidates {
+		pods, err := candidate.Pods(ctx, q.kubeClient)
+		if err != nil {
+			return fmt.Errorf("listing pods, %w", err)
+		}
+		governing[i] = limits.Governing(pods...)
+		for key := range governing[i] {
+			candidatesPerPDB[key]++
+		}
+	}
+	shared := make([]int, len(cmd.candidates))
+	for i := range cmd.candidates {
+		shared[i] = lo.CountBy(governing[i].UnsortedList(), func(key client.ObjectKey) bool { return candidatesPerPDB[key] > 1 })
+	}
+	order := lo.Filter(lo.Range(len(cmd.candidates)), func(i int, _ int) bool { return shared[i] > 0 })
+	sort.SliceStable(order, func(a, b int) bool { return shared[order[a]] < shared[order[b]] })
+	for position, i := range order {
+		nodeClaim := cmd.candidates[i].NodeClaim.DeepCopy()
+		stored := nodeClaim.DeepCopy()
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1.EvictionPlanAnnotationKey:      string(cmd.id),
+			v1.EvictionPlanOrderAnnotationKey: strconv.Itoa(position),
+		})
+		if equality.Semantic.DeepEqual(stored, nodeClaim) {
+			continue
+		}
+		if err := q.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearEvictionPlan removes the eviction plan from the candidates of a failed command that weren't deleted, so that they
+// aren't mistaken for members of the plan if they're drained later on
+func (q *Queue) clearEvictionPlan(ctx context.Context, cmd *Command) error {
+	var errs error
+	for _, candidate := range cmd.candidates {
+		nodeClaim := &v1.NodeClaim{}
+		if err := q.kubeClient.Get(ctx, client.ObjectKeyFromObject(candidate.NodeClaim), nodeClaim); err != nil {
+			errs = multierr.Append(errs, client.IgnoreNotFound(err))
+			continue
+		}
+		if nodeClaim.Annotations[v1.EvictionPlanAnnotationKey] != string(cmd.id) || !nodeClaim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		stored := nodeClaim.DeepCopy()
+		delete(nodeClaim.Annotations, v1.EvictionPlanAnnotationKey)
+		delete(nodeClaim.Annotations, v1.EvictionPlanOrderAnnotationKey)
+		if err := q.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// annotateDisruptionReason records why the candidates are disrupted, so that the termination controller knows to drain
+// them as a voluntary disruption
+func (q *Queue) annotateDisruptionReason(ctx context.Context, cmd *Command) error {
+	for _, candidate := range cmd.candidates {
+		nodeClaim := candidate.NodeClaim.DeepCopy()
+		stored := nodeClaim.DeepCopy()
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1.DisruptionReasonAnnotationKey: string(cmd.reason),
+		})
+		if equality.Semantic.DeepEqual(stored, nodeClaim) {
+			continue
+		}
+		if err := q.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Add adds commands to the Queue
 // Each command added to the queue should already be validated and ready for execution.
 func (q *Queue) Add(cmd *Command) error {
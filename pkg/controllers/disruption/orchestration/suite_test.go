@@ -33,6 +33,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	clock "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -206,6 +207,40 @@ var _ = Describe("Queue", func() {
 			node1 = ExpectNodeExists(ctx, env.Client, node1.Name)
 			Expect(node1.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
 		})
+		It("should clear the eviction plan from candidates when a command times out", func() {
+			nodeClaim1.Annotations = lo.Assign(nodeClaim1.Annotations, map[string]string{v1.EvictionPlanAnnotationKey: "test-command", v1.EvictionPlanOrderAnnotationKey: "0"})
+			nodeClaim2.Annotations = lo.Assign(nodeClaim2.Annotations, map[string]string{v1.EvictionPlanAnnotationKey: "test-command", v1.EvictionPlanOrderAnnotationKey: "1"})
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodeClaim2, node2, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1, node2}, []*v1.NodeClaim{nodeClaim1, nodeClaim2})
+			stateNodes := []*state.StateNode{ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1), ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim2)}
+
+			Expect(queue.Add(orchestration.NewCommand(replacements, stateNodes, "test-command", "test-method", "fake-type"))).To(BeNil())
+			fakeClock.Step(11 * time.Minute)
+			ExpectSingletonReconciled(ctx, queue)
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			nodeClaim2 = ExpectExists(ctx, env.Client, nodeClaim2)
+			Expect(nodeClaim1.Annotations).ToNot(HaveKey(v1.EvictionPlanAnnotationKey))
+			Expect(nodeClaim1.Annotations).ToNot(HaveKey(v1.EvictionPlanOrderAnnotationKey))
+			Expect(nodeClaim2.Annotations).ToNot(HaveKey(v1.EvictionPlanAnnotationKey))
+			Expect(nodeClaim2.Annotations).ToNot(HaveKey(v1.EvictionPlanOrderAnnotationKey))
+		})
+		It("should keep the eviction plan of candidates that are already being deleted when a command times out", func() {
+			nodeClaim1.Annotations = lo.Assign(nodeClaim1.Annotations, map[string]string{v1.EvictionPlanAnnotationKey: "test-command", v1.EvictionPlanOrderAnnotationKey: "0"})
+			nodeClaim1.Finalizers = []string{v1.TerminationFinalizer}
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+			Expect(env.Client.Delete(ctx, nodeClaim1)).To(Succeed())
+
+			Expect(queue.Add(orchestration.NewCommand(replacements, []*state.StateNode{stateNode}, "test-command", "test-method", "fake-type"))).To(BeNil())
+			fakeClock.Step(11 * time.Minute)
+			ExpectSingletonReconciled(ctx, queue)
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			Expect(nodeClaim1.Annotations).To(HaveKeyWithValue(v1.EvictionPlanAnnotationKey, "test-command"))
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim1)
+		})
 		It("should fully handle a command when replacements are initialized", func() {
 			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool, replacementNodeClaim, replacementNode)
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
@@ -290,6 +325,54 @@ var _ = Describe("Queue", func() {
 			// And expect the nodeClaim and node to be deleted
 			ExpectNotFound(ctx, env.Client, nodeClaim1, node1)
 		})
+		It("should annotate candidates with the disruption reason", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1}, []*v1.NodeClaim{nodeClaim1})
+			stateNode := ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1)
+			Expect(queue.Add(orchestration.NewCommand([]string{}, []*state.StateNode{stateNode}, "", v1.DisruptionReasonUnderutilized, "fake-type"))).To(BeNil())
+			ExpectSingletonReconciled(ctx, queue)
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			Expect(nodeClaim1.Annotations).To(HaveKeyWithValue(v1.DisruptionReasonAnnotationKey, string(v1.DisruptionReasonUnderutilized)))
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim1)
+		})
+		It("should plan the evictions of candidates that share a PDB", func() {
+			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
+			pdb := test.PodDisruptionBudget(test.PDBOptions{Labels: labelSelector, MaxUnavailable: lo.ToPtr(intstr.FromInt32(1))})
+			pod1 := test.Pod(test.PodOptions{NodeName: node1.Name, ObjectMeta: metav1.ObjectMeta{Labels: labelSelector}})
+			pod2 := test.Pod(test.PodOptions{NodeName: node2.Name, ObjectMeta: metav1.ObjectMeta{Labels: labelSelector}})
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodeClaim2, node2, nodePool, pdb, pod1, pod2)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1, node2}, []*v1.NodeClaim{nodeClaim1, nodeClaim2})
+			stateNodes := []*state.StateNode{ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1), ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim2)}
+
+			Expect(queue.Add(orchestration.NewCommand([]string{}, stateNodes, "test-command", "test-method", "fake-type"))).To(BeNil())
+			ExpectSingletonReconciled(ctx, queue)
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			nodeClaim2 = ExpectExists(ctx, env.Client, nodeClaim2)
+			Expect(nodeClaim1.Annotations).To(HaveKeyWithValue(v1.EvictionPlanAnnotationKey, "test-command"))
+			Expect(nodeClaim2.Annotations).To(HaveKeyWithValue(v1.EvictionPlanAnnotationKey, "test-command"))
+			Expect([]string{nodeClaim1.Annotations[v1.EvictionPlanOrderAnnotationKey], nodeClaim2.Annotations[v1.EvictionPlanOrderAnnotationKey]}).To(ConsistOf("0", "1"))
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim1, nodeClaim2)
+		})
+		It("should not plan the evictions of candidates that don't share a PDB", func() {
+			pdb1 := test.PodDisruptionBudget(test.PDBOptions{Labels: map[string]string{"app": "one"}, MaxUnavailable: lo.ToPtr(intstr.FromInt32(1))})
+			pdb2 := test.PodDisruptionBudget(test.PDBOptions{Labels: map[string]string{"app": "two"}, MaxUnavailable: lo.ToPtr(intstr.FromInt32(1))})
+			pod1 := test.Pod(test.PodOptions{NodeName: node1.Name, ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "one"}}})
+			pod2 := test.Pod(test.PodOptions{NodeName: node2.Name, ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "two"}}})
+			ExpectApplied(ctx, env.Client, nodeClaim1, node1, nodeClaim2, node2, nodePool, pdb1, pdb2, pod1, pod2)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node1, node2}, []*v1.NodeClaim{nodeClaim1, nodeClaim2})
+			stateNodes := []*state.StateNode{ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim1), ExpectStateNodeExistsForNodeClaim(cluster, nodeClaim2)}
+
+			Expect(queue.Add(orchestration.NewCommand([]string{}, stateNodes, "test-command", "test-method", "fake-type"))).To(BeNil())
+			ExpectSingletonReconciled(ctx, queue)
+
+			nodeClaim1 = ExpectExists(ctx, env.Client, nodeClaim1)
+			nodeClaim2 = ExpectExists(ctx, env.Client, nodeClaim2)
+			Expect(nodeClaim1.Annotations).ToNot(HaveKey(v1.EvictionPlanAnnotationKey))
+			Expect(nodeClaim2.Annotations).ToNot(HaveKey(v1.EvictionPlanAnnotationKey))
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim1, nodeClaim2)
+		})
 		It("should finish two commands in order as replacements are intialized", func() {
 			ncName2 := test.RandomName()
 			replacements2 := []string{ncName2}
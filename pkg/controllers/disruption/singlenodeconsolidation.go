@@ -21,10 +21,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
 const SingleNodeConsolidationTimeoutDuration = 3 * time.Minute
@@ -36,6 +38,7 @@ type SingleNodeConsolidation struct {
 }
 
 func NewSingleNodeConsolidation(consolidation consolidation) *SingleNodeConsolidation {
+	consolidation.consolidationType = SingleNodeConsolidationType
 	return &SingleNodeConsolidation{consolidation: consolidation}
 }
 
@@ -52,14 +55,16 @@ func (s *SingleNodeConsolidation) ComputeCommand(ctx context.Context, disruption
 	// Set a timeout
 	timeout := s.clock.Now().Add(SingleNodeConsolidationTimeoutDuration)
 	constrainedByBudgets := false
+	floorMapping := BuildCapacityTypeFloorMapping(s.clock, s.cluster, candidates)
 
-	// binary search to find the maximum number of NodeClaims we can terminate
-	for i, candidate := range candidates {
+	var eligible []*Candidate
+	for _, candidate := range candidates {
 		// If the disruption budget doesn't allow this candidate to be disrupted,
 		// continue to the next candidate. We don't need to decrement any budget
 		// counter since single node consolidation commands can only have one candidate.
 		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
 			constrainedByBudgets = true
+			s.reject(RejectionReasonBudget)
 			continue
 		}
 		// Filter out empty candidates. If there was an empty node that wasn't consolidated before this, we should
@@ -68,28 +73,54 @@ func (s *SingleNodeConsolidation) ComputeCommand(ctx context.Context, disruption
 		if len(candidate.reschedulablePods) == 0 {
 			continue
 		}
+		// Don't consider candidates which the NodePool needs to stay at its minimum for the candidate's capacity type
+		if atCapacityTypeFloor(floorMapping, candidate) {
+			s.reject(RejectionReasonBudget)
+			continue
+		}
+		eligible = append(eligible, candidate)
+	}
+
+	// Candidates are simulated concurrently in batches of the number of evaluation workers. The results of a batch are
+	// considered in the order of its candidates, so the command that's chosen doesn't depend on which simulation
+	// finishes first.
+	type evaluation struct {
+		cmd     Command
+		results scheduling.Results
+		err     error
+	}
+	evaluated := 0
+	for _, batch := range lo.Chunk(eligible, max(options.FromContext(ctx).DisruptionEvaluationWorkers, 1)) {
 		if s.clock.Now().After(timeout) {
 			ConsolidationTimeoutsTotal.Inc(map[string]string{consolidationTypeLabel: s.ConsolidationType()})
-			log.FromContext(ctx).V(1).Info(fmt.Sprintf("abandoning single-node consolidation due to timeout after evaluating %d candidates", i))
+			log.FromContext(ctx).V(1).Info(fmt.Sprintf("abandoning single-node consolidation due to timeout after evaluating %d candidates", evaluated))
 			return Command{}, scheduling.Results{}, nil
 		}
-		// compute a possible consolidation option
-		cmd, results, err := s.computeConsolidation(ctx, candidate)
+		evaluations, err := evaluateConcurrently(ctx, batch, func(candidate *Candidate) evaluation {
+			cmd, results, err := s.computeConsolidation(ctx, candidate)
+			return evaluation{cmd: cmd, results: results, err: err}
+		})
 		if err != nil {
-			log.FromContext(ctx).Error(err, "failed computing consolidation")
-			continue
-		}
-		if cmd.Decision() == NoOpDecision {
-			continue
+			return Command{}, scheduling.Results{}, err
 		}
-		if err := v.IsValid(ctx, cmd, consolidationTTL); err != nil {
-			if IsValidationError(err) {
-				log.FromContext(ctx).V(1).Info(fmt.Sprintf("abandoning single-node consolidation attempt due to pod churn, command is no longer valid, %s", cmd))
-				return Command{}, scheduling.Results{}, nil
+		evaluated += len(batch)
+		for _, e := range evaluations {
+			if e.err != nil {
+				log.FromContext(ctx).Error(e.err, "failed computing consolidation")
+				continue
+			}
+			if e.cmd.Decision() == NoOpDecision {
+				continue
+			}
+			if err := v.IsValid(ctx, e.cmd, consolidationTTL); err != nil {
+				if IsValidationError(err) {
+					log.FromContext(ctx).V(1).Info(fmt.Sprintf("abandoning single-node consolidation attempt due to pod churn, command is no longer valid, %s", e.cmd))
+					return Command{}, scheduling.Results{}, nil
+				}
+				return Command{}, scheduling.Results{}, fmt.Errorf("validating consolidation, %w", err)
 			}
-			return Command{}, scheduling.Results{}, fmt.Errorf("validating consolidation, %w", err)
+			return e.cmd, e.results, nil
 		}
-		return cmd, results, nil
 	}
 	if !constrainedByBudgets {
 		// if there are no candidates because of a budget, don't mark
@@ -18,7 +18,10 @@ package disruption_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"sync"
 	"testing"
@@ -35,6 +38,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -163,6 +167,7 @@ var _ = AfterEach(func() {
 
 	// Reset the metrics collectors
 	disruption.DecisionsPerformedTotal.Reset()
+	disruption.ConsolidationCandidateRejectionsTotal.Reset()
 })
 
 var _ = Describe("Simulate Scheduling", func() {
@@ -829,6 +834,61 @@ var _ = Describe("Pod Eviction Cost", func() {
 		})
 		Expect(cost).To(BeNumerically("<", standardPodCost))
 	})
+	It("should have higher costs for pods whose QoS classes are evicted later", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionEvictionOrder: lo.ToPtr("BestEffort,Burstable,Guaranteed")}))
+		burstable := disruptionutils.EvictionCost(ctx, &corev1.Pod{Status: corev1.PodStatus{QOSClass: corev1.PodQOSBurstable}})
+		guaranteed := disruptionutils.EvictionCost(ctx, &corev1.Pod{Status: corev1.PodStatus{QOSClass: corev1.PodQOSGuaranteed}})
+		Expect(burstable).To(BeNumerically(">", standardPodCost))
+		Expect(guaranteed).To(BeNumerically(">", burstable))
+	})
+	It("should have the same cost for pods of every QoS class when there's no eviction order", func() {
+		cost := disruptionutils.EvictionCost(ctx, &corev1.Pod{Status: corev1.PodStatus{QOSClass: corev1.PodQOSGuaranteed}})
+		Expect(cost).To(BeNumerically("==", standardPodCost))
+	})
+})
+
+var _ = Describe("Job Progress", func() {
+	It("should have no progress for a pod which isn't part of a job", func() {
+		Expect(disruptionutils.JobProgress(ctx, env.Client, fakeClock, test.Pod())).To(BeNumerically("==", 0.0))
+	})
+	It("should estimate progress from the expected completion time annotation", func() {
+		pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			v1.ExpectedCompletionTimeAnnotationKey: fakeClock.Now().Add(time.Hour).Format(time.RFC3339),
+		}}})
+		pod.Status.StartTime = lo.ToPtr(metav1.NewTime(fakeClock.Now().Add(-3 * time.Hour)))
+		Expect(disruptionutils.JobProgress(ctx, env.Client, fakeClock, pod)).To(BeNumerically("~", 0.75, 0.01))
+	})
+	It("should estimate progress from the completions of the job which owns the pod", func() {
+		job := &batchv1.Job{
+			ObjectMeta: test.ObjectMeta(),
+			Spec: batchv1.JobSpec{
+				Completions: lo.ToPtr(int32(20)),
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						Containers:    []corev1.Container{{Name: "job", Image: "job"}},
+					},
+				},
+			},
+		}
+		job.Status.StartTime = lo.ToPtr(metav1.NewTime(fakeClock.Now()))
+		job.Status.Succeeded = 19
+		ExpectApplied(ctx, env.Client, job)
+		pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+			Namespace: job.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion:         "batch/v1",
+				Kind:               "Job",
+				Name:               job.Name,
+				UID:                job.UID,
+				Controller:         lo.ToPtr(true),
+				BlockOwnerDeletion: lo.ToPtr(true),
+			}},
+		}})
+		Expect(disruptionutils.JobProgress(ctx, env.Client, fakeClock, pod)).To(BeNumerically("~", 0.95, 0.01))
+		// Evicting pods from a nearly complete job is more costly than evicting other pods
+		Expect(disruptionutils.JobProgressCost(ctx, env.Client, fakeClock, []*corev1.Pod{pod})).To(BeNumerically(">", disruptionutils.JobProgressCost(ctx, env.Client, fakeClock, []*corev1.Pod{test.Pod()})))
+	})
 })
 
 var _ = Describe("Candidate Filtering", func() {
@@ -955,6 +1015,73 @@ var _ = Describe("Candidate Filtering", func() {
 		Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod))))
 		Expect(recorder.DetectedEvent(fmt.Sprintf(`Cannot disrupt Node: pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod)))).To(BeTrue())
 	})
+	It("should not consider candidates that have pods scheduled from a do-not-disrupt namespace", func() {
+		namespace := test.Namespace(test.NamespaceOptions{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				v1.DoNotDisruptAnnotationKey: "true",
+			},
+		}})
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name}})
+		ExpectApplied(ctx, env.Client, namespace, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal(fmt.Sprintf(`pod %q is in namespace %q, which has the "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod), namespace.Name)))
+		Expect(recorder.DetectedEvent(fmt.Sprintf(`Cannot disrupt Node: pod %q is in namespace %q, which has the "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(pod), namespace.Name))).To(BeTrue())
+	})
+	It("should consider candidates that only have daemonset pods scheduled from a do-not-disrupt namespace", func() {
+		namespace := test.Namespace(test.NamespaceOptions{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				v1.DoNotDisruptAnnotationKey: "true",
+			},
+		}})
+		daemonSet := test.DaemonSet(test.DaemonSetOptions{ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name}})
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, namespace, nodePool, nodeClaim, node, daemonSet)
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace.Name,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "apps/v1",
+						Kind:       "DaemonSet",
+						Name:       daemonSet.Name,
+						UID:        daemonSet.UID,
+						Controller: lo.ToPtr(true),
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).ToNot(HaveOccurred())
+	})
 	It("should consider candidates that have do-not-disrupt pods scheduled with a terminationGracePeriod set for eventual disruption", func() {
 		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
 			ObjectMeta: metav1.ObjectMeta{
@@ -1613,6 +1740,26 @@ var _ = Describe("Candidate Filtering", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(Equal("state node is marked for deletion"))
 	})
+	It("should not consider candidates whose providerID doesn't match their node or instance", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+					v1.CapacityTypeLabelKey:        mostExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       mostExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeProviderIDMismatch, "InstanceOwnerMismatch", "")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		Expect(cluster.Nodes()).To(HaveLen(1))
+		_, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, cluster.Nodes()[0], pdbLimits, nodePoolMap, nodePoolInstanceTypeMap, queue, disruption.GracefulDisruptionClass)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("nodeclaim providerID doesn't match its node or instance"))
+	})
 	It("should not consider candidates that aren't yet initialized", func() {
 		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
 			ObjectMeta: metav1.ObjectMeta{
@@ -2137,3 +2284,212 @@ func NewTestingQueue(kubeClient client.Client, recorder events.Recorder, cluster
 	q.RateLimitingInterface = test.NewRateLimitingInterface(workqueue.QueueConfig{Name: "disruption.workqueue"})
 	return q
 }
+
+var _ = Describe("Admission Webhook", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var requests chan disruption.AdmissionRequest
+	var response disruption.AdmissionResponse
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					ConsolidateAfter:    v1.MustParseNillableDuration("0s"),
+					ConsolidationPolicy: v1.ConsolidationPolicyWhenEmpty,
+					Budgets:             []v1.Budget{{Nodes: "100%"}},
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: leastExpensiveSpotInstance.Name,
+					v1.CapacityTypeLabelKey:        leastExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       leastExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+
+		requests = make(chan disruption.AdmissionRequest, 10)
+		response = disruption.AdmissionResponse{Allowed: true}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			request := disruption.AdmissionRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+			requests <- request
+			Expect(json.NewEncoder(w).Encode(response)).To(Succeed())
+		}))
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionAdmissionWebhookURL: lo.ToPtr(server.URL)}))
+
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should disrupt nodes when the webhook allows the command", func() {
+		wg := sync.WaitGroup{}
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		var request disruption.AdmissionRequest
+		Expect(requests).To(Receive(&request))
+		Expect(request.Reason).To(Equal("empty"))
+		Expect(request.Decision).To(Equal(disruption.DeleteDecision))
+		Expect(request.Candidates).To(HaveLen(1))
+		Expect(request.Candidates[0].NodeClaim).To(Equal(nodeClaim.Name))
+		Expect(request.Candidates[0].Node).To(Equal(node.Name))
+		Expect(request.Candidates[0].NodePool).To(Equal(nodePool.Name))
+
+		ExpectSingletonReconciled(ctx, queue)
+		ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim, node)
+	})
+	It("should not disrupt nodes when the webhook denies the command", func() {
+		response = disruption.AdmissionResponse{Message: "change freeze"}
+		wg := sync.WaitGroup{}
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		Expect(requests).To(HaveLen(1))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+		Expect(recorder.DetectedEvent("Cannot disrupt NodeClaim: denied by admission webhook, change freeze")).To(BeTrue())
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should not disrupt nodes when the webhook is unavailable", func() {
+		server.Close()
+		wg := sync.WaitGroup{}
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+		ExpectExists(ctx, env.Client, nodeClaim)
+	})
+	It("should hold off disruption until the webhook's retry time", func() {
+		response = disruption.AdmissionResponse{RetryAfterSeconds: 300}
+		wg := sync.WaitGroup{}
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+		Expect(requests).To(HaveLen(1))
+
+		// The webhook isn't consulted again until the retry time has passed
+		response = disruption.AdmissionResponse{Allowed: true}
+		ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(requests).To(HaveLen(1))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+
+		fakeClock.Step(5 * time.Minute)
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+		Expect(requests).To(HaveLen(2))
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Observe Only", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					ConsolidateAfter:    v1.MustParseNillableDuration("0s"),
+					ConsolidationPolicy: v1.ConsolidationPolicyWhenEmpty,
+					Budgets:             []v1.Budget{{Nodes: "100%"}},
+					Mode:                v1.DisruptionModeObserveOnly,
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: leastExpensiveSpotInstance.Name,
+					v1.CapacityTypeLabelKey:        leastExpensiveOffering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+					corev1.LabelTopologyZone:       leastExpensiveOffering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+				},
+			},
+			Status: v1.NodeClaimStatus{
+				Allocatable: map[corev1.ResourceName]resource.Quantity{
+					corev1.ResourceCPU:  resource.MustParse("32"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
+		metrics.NodeClaimsDisruptionObservedTotal.Reset()
+	})
+	It("should report the nodes it would disrupt without tainting or deleting them", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		wg := sync.WaitGroup{}
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+		node = ExpectNodeExists(ctx, env.Client, node.Name)
+		Expect(node.Spec.Taints).ToNot(ContainElement(v1.DisruptedNoScheduleTaint))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved).IsTrue()).To(BeTrue())
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved).Reason).To(Equal(string(v1.DisruptionReasonEmpty)))
+		Expect(recorder.Calls("DisruptionObserved")).To(Equal(2))
+		ExpectMetricCounterValue(metrics.NodeClaimsDisruptionObservedTotal, 1, map[string]string{
+			metrics.ReasonLabel:   "empty",
+			metrics.NodePoolLabel: nodePool.Name,
+		})
+	})
+	It("should report the nodes it would disrupt when disruption is observe-only for every NodePool", func() {
+		nodePool.Spec.Disruption.Mode = v1.DisruptionModeEnforce
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionObserveOnly: lo.ToPtr(true)}))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		wg := sync.WaitGroup{}
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeFalse())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved).IsTrue()).To(BeTrue())
+	})
+	It("should disrupt nodes of NodePools that enforce disruption", func() {
+		nodePool.Spec.Disruption.Mode = v1.DisruptionModeEnforce
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		wg := sync.WaitGroup{}
+		ExpectToWait(fakeClock, &wg)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		Expect(queue.HasAny(nodeClaim.Status.ProviderID)).To(BeTrue())
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved)).To(BeNil())
+	})
+})
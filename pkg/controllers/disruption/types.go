@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	disruptionutils "sigs.k8s.io/karpenter/pkg/utils/disruption"
 	"sigs.k8s.io/karpenter/pkg/utils/pdb"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
@@ -63,6 +64,9 @@ type Candidate struct {
 	capacityType      string
 	disruptionCost    float64
 	reschedulablePods []*corev1.Pod
+	// empty is set when none of the reschedulable pods keep the candidate from being considered empty, since pods
+	// matching the emptiness-ignored-pods selectors don't count towards a node's emptiness
+	empty bool
 }
 
 //nolint:gocyclo
@@ -70,7 +74,7 @@ func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events
 	nodePoolMap map[string]*v1.NodePool, nodePoolToInstanceTypesMap map[string]map[string]*cloudprovider.InstanceType, queue *orchestration.Queue, disruptionClass string) (*Candidate, error) {
 	var err error
 	var pods []*corev1.Pod
-	if err = node.ValidateNodeDisruptable(ctx, kubeClient); err != nil {
+	if err = node.ValidateNodeDisruptable(ctx, clk, kubeClient); err != nil {
 		// Only emit an event if the NodeClaim is not nil, ensuring that we only emit events for Karpenter-managed nodes
 		if node.NodeClaim != nil {
 			recorder.Publish(disruptionevents.Blocked(node.Node, node.NodeClaim, err.Error())...)
@@ -102,15 +106,24 @@ func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events
 			return nil, err
 		}
 	}
+	ignoredPods, err := options.ParsePodSelectors(options.FromContext(ctx).EmptinessIgnoredPods)
+	if err != nil {
+		return nil, fmt.Errorf("parsing emptiness ignored pods, %w", err)
+	}
+	reschedulablePods := lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return pod.IsReschedulable(p) })
 	return &Candidate{
 		StateNode:         node.DeepCopy(),
 		instanceType:      instanceType,
 		nodePool:          nodePool,
 		capacityType:      node.Labels()[v1.CapacityTypeLabelKey],
 		zone:              node.Labels()[corev1.LabelTopologyZone],
-		reschedulablePods: lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return pod.IsReschedulable(p) }),
+		reschedulablePods: reschedulablePods,
+		empty: lo.EveryBy(reschedulablePods, func(p *corev1.Pod) bool {
+			return lo.ContainsBy(ignoredPods, func(s options.PodSelector) bool { return s.Matches(p.Namespace, p.Labels) })
+		}),
 		// We get the disruption cost from all pods in the candidate, not just the reschedulable pods
-		disruptionCost: disruptionutils.ReschedulingCost(ctx, pods) * disruptionutils.LifetimeRemaining(clk, nodePool, node.NodeClaim),
+		disruptionCost: (disruptionutils.ReschedulingCost(ctx, pods) + disruptionutils.JobProgressCost(ctx, kubeClient, clk, pods)) *
+			disruptionutils.LifetimeRemaining(clk, nodePool, node.NodeClaim),
 	}, nil
 }
 
@@ -132,6 +132,13 @@ func (v *Validation) ValidateCandidates(ctx context.Context, candidates ...*Cand
 	if err != nil {
 		return nil, fmt.Errorf("building disruption budgets, %w", err)
 	}
+	resourceBudgetMapping, err := BuildResourceDisruptionBudgetMapping(ctx, v.cluster, v.clock, v.kubeClient, v.cloudProvider, v.reason)
+	if err != nil {
+		return nil, fmt.Errorf("building resource disruption budgets, %w", err)
+	}
+	if len(fitsResourceBudgets(Command{candidates: validatedCandidates}, resourceBudgetMapping).candidates) != len(validatedCandidates) {
+		return nil, NewValidationError(fmt.Errorf("candidates can no longer be disrupted without violating resource budgets"))
+	}
 	// Return nil if any candidate meets either of the following conditions:
 	//  a. A pod was nominated to the candidate
 	//  b. Disrupting the candidate would violate node disruption budgets
@@ -0,0 +1,203 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	pscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+// ZoneRebalance is a subreconciler that replaces nodes in zones which are over-weighted within their NodePool. This is
+// the skew that a zone outage leaves behind, since capacity is launched in the remaining zones until the zone's
+// offerings become available again.
+type ZoneRebalance struct {
+	kubeClient    client.Client
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+}
+
+func NewZoneRebalance(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *ZoneRebalance {
+	return &ZoneRebalance{
+		kubeClient:    kubeClient,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+// ShouldDisrupt is a predicate used to filter candidates
+func (z *ZoneRebalance) ShouldDisrupt(ctx context.Context, c *Candidate) bool {
+	return options.FromContext(ctx).FeatureGates.ZoneRebalance && c.zone != ""
+}
+
+// ComputeCommand generates a disruption command given candidates. Only a single candidate is replaced at a time so
+// that the NodePool is rebalanced gradually.
+func (z *ZoneRebalance) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, pscheduling.Results, error) {
+	zoneCounts := z.zoneCounts()
+	excess := map[string]map[string]int{} // map[nodepool][zone] -> nodes above the least populated zone
+	targetZones := map[string][]string{}  // map[nodepool] -> least populated zones that replacements launch into
+	for _, nodePool := range lo.UniqBy(lo.Map(candidates, func(c *Candidate, _ int) *v1.NodePool { return c.nodePool }), func(np *v1.NodePool) string { return np.Name }) {
+		zones, err := z.availableZones(ctx, nodePool)
+		if err != nil {
+			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", nodePool.Name)).Error(err, "skipping zone rebalance, unable to resolve instance types")
+			continue
+		}
+		excess[nodePool.Name], targetZones[nodePool.Name] = zoneSkew(zoneCounts[nodePool.Name], zones)
+	}
+	candidates = lo.Filter(candidates, func(c *Candidate, _ int) bool {
+		return excess[c.nodePool.Name][c.zone] > 0 && disruptionBudgetMapping[c.nodePool.Name] > 0
+	})
+	// Candidates from the most over-weighted zones go first, and within a zone, the cheapest to disrupt
+	sort.Slice(candidates, func(i, j int) bool {
+		if ei, ej := excess[candidates[i].nodePool.Name][candidates[i].zone], excess[candidates[j].nodePool.Name][candidates[j].zone]; ei != ej {
+			return ei > ej
+		}
+		return candidates[i].disruptionCost < candidates[j].disruptionCost
+	})
+	for _, candidate := range candidates {
+		results, err := SimulateScheduling(ctx, z.kubeClient, z.cluster, z.provisioner, candidate)
+		if err != nil {
+			// if a candidate is now deleting, just retry
+			if errors.Is(err, errCandidateDeleting) {
+				continue
+			}
+			return Command{}, pscheduling.Results{}, err
+		}
+		if !results.AllNonPendingPodsScheduled() {
+			continue
+		}
+		// Replacements must launch in the least populated zones, otherwise the skew isn't reduced
+		if !constrainToZones(results.NewNodeClaims, targetZones[candidate.nodePool.Name]) {
+			continue
+		}
+		return Command{
+			candidates:   []*Candidate{candidate},
+			replacements: results.NewNodeClaims,
+		}, results, nil
+	}
+	return Command{}, pscheduling.Results{}, nil
+}
+
+// zoneCounts returns the number of nodes in each zone, keyed by NodePool. Nodes which are being disrupted aren't
+// counted, since their replacements have already been launched.
+func (z *ZoneRebalance) zoneCounts() map[string]map[string]int {
+	counts := map[string]map[string]int{}
+	for _, node := range z.cluster.Nodes() {
+		if !node.Managed() || node.MarkedForDeletion() {
+			continue
+		}
+		nodePool, zone := node.Labels()[v1.NodePoolLabelKey], node.Labels()[corev1.LabelTopologyZone]
+		if zone == "" {
+			continue
+		}
+		if counts[nodePool] == nil {
+			counts[nodePool] = map[string]int{}
+		}
+		counts[nodePool][zone]++
+	}
+	return counts
+}
+
+// availableZones returns the zones that the NodePool allows, which have available offerings. Zones in the middle of
+// an outage have no available offerings, so nodes are only rebalanced into zones once they've recovered.
+func (z *ZoneRebalance) availableZones(ctx context.Context, nodePool *v1.NodePool) (sets.Set[string], error) {
+	its, err := z.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, err
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	requirements.Add(scheduling.NewLabelRequirements(nodePool.Spec.Template.Labels).Values()...)
+	zones := sets.New[string]()
+	for _, it := range its {
+		for _, o := range it.Offerings.Available().Compatible(requirements) {
+			if zone := o.Requirements.Get(corev1.LabelTopologyZone).Any(); zone != "" {
+				zones.Insert(zone)
+			}
+		}
+	}
+	return zones, nil
+}
+
+// zoneSkew compares the number of nodes in each available zone against the least populated one. It returns how many
+// nodes each over-weighted zone has above the least populated zone, along with the least populated zones. A zone is
+// only over-weighted if it has at least two more nodes, since moving a node can't improve a skew of one.
+func zoneSkew(counts map[string]int, zones sets.Set[string]) (map[string]int, []string) {
+	if zones.Len() < 2 {
+		return nil, nil
+	}
+	least := lo.Min(lo.Map(zones.UnsortedList(), func(zone string, _ int) int { return counts[zone] }))
+	excess := map[string]int{}
+	for zone := range zones {
+		if counts[zone]-least > 1 {
+			excess[zone] = counts[zone] - least
+		}
+	}
+	return excess, lo.Filter(sets.List(zones), func(zone string, _ int) bool { return counts[zone] == least })
+}
+
+// constrainToZones requires the replacements to launch in one of the zones, returning false if any of them can't
+func constrainToZones(replacements []*pscheduling.NodeClaim, zones []string) bool {
+	zoneRequirement := scheduling.NewRequirements(scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zones...))
+	for _, replacement := range replacements {
+		if err := replacement.Requirements.Compatible(zoneRequirement, scheduling.AllowUndefinedWellKnownLabels); err != nil {
+			return false
+		}
+		replacement.Requirements.Add(zoneRequirement.Values()...)
+		replacement.InstanceTypeOptions = lo.Filter(replacement.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+			return it.Offerings.Available().HasCompatible(replacement.Requirements)
+		})
+		if len(replacement.InstanceTypeOptions) == 0 {
+			return false
+		}
+		if _, err := replacement.InstanceTypeOptions.SatisfiesMinValues(replacement.Requirements); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (z *ZoneRebalance) Reason() v1.DisruptionReason {
+	return v1.DisruptionReasonRebalanced
+}
+
+func (z *ZoneRebalance) Class() string {
+	return GracefulDisruptionClass
+}
+
+func (z *ZoneRebalance) ConsolidationType() string {
+	return ""
+}
@@ -0,0 +1,175 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Zone Rebalance", func() {
+	var nodePool *v1.NodePool
+	var nodeClaims []*v1.NodeClaim
+	var nodes []*corev1.Node
+	var nodeSelector map[string]string
+
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{ZoneRebalance: lo.ToPtr(true)}}))
+		nodePool = test.NodePool(v1.NodePool{
+			Spec: v1.NodePoolSpec{
+				Disruption: v1.Disruption{
+					ConsolidateAfter: v1.MustParseNillableDuration("Never"),
+					Budgets: []v1.Budget{{
+						Nodes: "100%",
+					}},
+				},
+			},
+		})
+		nodeClaims, nodes, nodeSelector = nil, nil, nil
+	})
+	// applyNodes creates a node with a pod for each of the zones. The pods are large enough that they can't share a node,
+	// so rebalancing a node requires a replacement.
+	applyNodes := func(zones ...string) {
+		GinkgoHelper()
+		rs := test.ReplicaSet()
+		ExpectApplied(ctx, env.Client, rs, nodePool)
+		Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+		for _, zone := range zones {
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: mostExpensiveInstance.Name,
+						v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+						corev1.LabelTopologyZone:       zone,
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					Allocatable: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceCPU:  resource.MustParse("32"),
+						corev1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+			pod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         lo.ToPtr(true),
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					},
+				},
+				NodeSelector:         nodeSelector,
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20")}},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			nodeClaims = append(nodeClaims, nodeClaim)
+			nodes = append(nodes, node)
+		}
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+	}
+	expectReplacementZones := func(zones ...string) {
+		GinkgoHelper()
+		replacements := lo.Reject(ExpectNodeClaims(ctx, env.Client), func(nc *v1.NodeClaim, _ int) bool {
+			return lo.ContainsBy(nodeClaims, func(existing *v1.NodeClaim) bool { return existing.Name == nc.Name })
+		})
+		Expect(replacements).To(HaveLen(1))
+		requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(replacements[0].Spec.Requirements...)
+		Expect(requirements.Get(corev1.LabelTopologyZone).Values()).To(ConsistOf(zones))
+	}
+
+	It("should replace a node in an over-weighted zone with one in the least populated zones", func() {
+		applyNodes("test-zone-1", "test-zone-1", "test-zone-1")
+
+		var wg sync.WaitGroup
+		ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		expectReplacementZones("test-zone-2", "test-zone-3")
+		Expect(lo.CountBy(ExpectNodes(ctx, env.Client), func(n *corev1.Node) bool {
+			_, tainted := lo.Find(n.Spec.Taints, func(t corev1.Taint) bool { return t.MatchTaint(&v1.DisruptedNoScheduleTaint) })
+			return tainted
+		})).To(Equal(1))
+	})
+	It("should only rebalance into zones with available offerings", func() {
+		for _, it := range cloudProvider.InstanceTypes {
+			for i := range it.Offerings {
+				if it.Offerings[i].Requirements.Get(corev1.LabelTopologyZone).Has("test-zone-3") {
+					it.Offerings[i].Available = false
+				}
+			}
+		}
+		applyNodes("test-zone-1", "test-zone-1", "test-zone-1")
+
+		var wg sync.WaitGroup
+		ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+		ExpectSingletonReconciled(ctx, disruptionController)
+		wg.Wait()
+
+		expectReplacementZones("test-zone-2")
+	})
+	It("should not rebalance when the zones are skewed by a single node", func() {
+		applyNodes("test-zone-1", "test-zone-1", "test-zone-2", "test-zone-3")
+
+		ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(4))
+	})
+	It("should not rebalance when the feature gate is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		applyNodes("test-zone-1", "test-zone-1", "test-zone-1")
+
+		ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+	})
+	It("should not rebalance when the disruption budget doesn't allow it", func() {
+		nodePool.Spec.Disruption.Budgets = []v1.Budget{{
+			Nodes:   "0",
+			Reasons: []v1.DisruptionReason{v1.DisruptionReasonRebalanced},
+		}}
+		applyNodes("test-zone-1", "test-zone-1", "test-zone-1")
+
+		ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+	})
+	It("should not rebalance nodes whose pods can't move to the least populated zones", func() {
+		nodeSelector = map[string]string{corev1.LabelTopologyZone: "test-zone-1"}
+		applyNodes("test-zone-1", "test-zone-1", "test-zone-1")
+
+		ExpectSingletonReconciled(ctx, disruptionController)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+	})
+})
@@ -0,0 +1,235 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroom
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+const (
+	headroomLabel = "cluster_headroom"
+	shapeLabel    = "shape"
+	nodePoolLabel = "nodepool"
+)
+
+var (
+	ClusterHeadroomPods = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "cluster",
+			Name:      "headroom_pods",
+			Help:      "Number of additional pods of a shape that could schedule onto the cluster's existing nodes. Labeled by ClusterHeadroom name and shape name.",
+		},
+		[]string{headroomLabel, shapeLabel},
+	)
+	NodePoolHeadroomPods = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodePoolSubsystem,
+			Name:      "headroom_pods",
+			Help:      "Number of additional pods of a shape that could schedule onto the nodepool's existing nodes. Labeled by ClusterHeadroom name, shape name and nodepool name.",
+		},
+		[]string{headroomLabel, shapeLabel, nodePoolLabel},
+	)
+)
+
+// Controller computes how many more pods of common shapes could schedule onto the existing nodes, per NodePool and
+// cluster-wide, and publishes it to the status of each ClusterHeadroom and as metrics
+type Controller struct {
+	clock       clock.Clock
+	kubeClient  client.Client
+	cluster     *state.Cluster
+	metricStore *metrics.Store
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client, cluster *state.Cluster) *Controller {
+	return &Controller{
+		clock:       clk,
+		kubeClient:  kubeClient,
+		cluster:     cluster,
+		metricStore: metrics.NewStore(),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "metrics.headroom")
+
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	headrooms := &v1alpha1.ClusterHeadroomList{}
+	if err := c.kubeClient.List(ctx, headrooms); err != nil {
+		// The ClusterHeadroom CRD is optional, so there's no headroom to report when it isn't installed
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("listing clusterheadrooms, %w", err)
+	}
+	available := c.availableByNodePool()
+	metricsMap := map[string][]*metrics.StoreMetric{}
+	var errs error
+	for i := range headrooms.Items {
+		headroom := &headrooms.Items[i]
+		stored := headroom.DeepCopy()
+		headroom.Status = computeStatus(headroom.PodShapes(), available)
+		headroom.Status.LastUpdateTime = metav1.NewTime(c.clock.Now())
+		if err := c.kubeClient.Status().Patch(ctx, headroom, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			errs = multierr.Append(errs, fmt.Errorf("patching clusterheadroom %q, %w", headroom.Name, err))
+		}
+		metricsMap[headroom.Name] = buildMetrics(headroom)
+	}
+	c.metricStore.ReplaceAll(metricsMap)
+	if errs != nil {
+		return reconcile.Result{}, errs
+	}
+	return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("metrics.headroom").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
+
+// availableNode is the resources that are available to new pods on a schedulable node, along with the labels and
+// taints that decide which pod shapes can schedule onto it
+type availableNode struct {
+	labels    map[string]string
+	taints    scheduling.Taints
+	available corev1.ResourceList
+}
+
+// availableByNodePool returns the schedulable nodes keyed by the node's NodePool. Nodes that aren't owned by a NodePool
+// are keyed by the empty string and only count towards the cluster-wide headroom.
+func (c *Controller) availableByNodePool() map[string][]availableNode {
+	available := map[string][]availableNode{}
+	c.cluster.ForEachNode(func(n *state.StateNode) bool {
+		if n.Node == nil || !n.Initialized() || n.MarkedForDeletion() || n.Node.Spec.Unschedulable {
+			return true
+		}
+		nodePool := n.Labels()[v1.NodePoolLabelKey]
+		available[nodePool] = append(available[nodePool], availableNode{
+			labels:    n.Labels(),
+			taints:    scheduling.Taints(n.Taints()).Required(),
+			available: n.Available(),
+		})
+		return true
+	})
+	return available
+}
+
+func computeStatus(shapes []v1alpha1.PodShape, available map[string][]availableNode) v1alpha1.ClusterHeadroomStatus {
+	status := v1alpha1.ClusterHeadroomStatus{}
+	total := make([]int64, len(shapes))
+	for _, nodePool := range lo.Keys(available) {
+		nodePoolHeadroom := v1alpha1.NodePoolHeadroom{NodePool: nodePool}
+		for i, shape := range shapes {
+			pods := lo.SumBy(available[nodePool], func(n availableNode) int64 {
+				if !schedulesOnto(shape, n) {
+					return 0
+				}
+				return fits(shape.Requests, n.available)
+			})
+			total[i] += pods
+			nodePoolHeadroom.Shapes = append(nodePoolHeadroom.Shapes, v1alpha1.ShapeHeadroom{Name: shape.Name, Pods: pods})
+		}
+		if nodePool != "" {
+			status.NodePools = append(status.NodePools, nodePoolHeadroom)
+		}
+	}
+	sort.Slice(status.NodePools, func(i, j int) bool { return status.NodePools[i].NodePool < status.NodePools[j].NodePool })
+	for i, shape := range shapes {
+		status.Shapes = append(status.Shapes, v1alpha1.ShapeHeadroom{Name: shape.Name, Pods: total[i]})
+	}
+	return status
+}
+
+// schedulesOnto returns whether pods of the shape can schedule onto the node, which requires the node to match the
+// shape's node selector and the shape to tolerate the node's taints
+func schedulesOnto(shape v1alpha1.PodShape, n availableNode) bool {
+	if !labels.SelectorFromSet(shape.NodeSelector).Matches(labels.Set(n.labels)) {
+		return false
+	}
+	return n.taints.Tolerates(&corev1.Pod{Spec: corev1.PodSpec{Tolerations: shape.Tolerations}}) == nil
+}
+
+// fits returns how many pods with the given requests fit into the available resources, including the node's pod limit
+func fits(requests, available corev1.ResourceList) int64 {
+	count := int64(math.MaxInt64)
+	if pods, ok := available[corev1.ResourcePods]; ok {
+		count = pods.Value()
+	}
+	for name, request := range requests {
+		if request.IsZero() {
+			continue
+		}
+		quantity := available[name]
+		count = min(count, quantity.MilliValue()/request.MilliValue())
+	}
+	return max(count, 0)
+}
+
+func buildMetrics(headroom *v1alpha1.ClusterHeadroom) (res []*metrics.StoreMetric) {
+	for _, shape := range headroom.Status.Shapes {
+		res = append(res, &metrics.StoreMetric{
+			GaugeMetric: ClusterHeadroomPods,
+			Value:       float64(shape.Pods),
+			Labels:      map[string]string{headroomLabel: headroom.Name, shapeLabel: shape.Name},
+		})
+	}
+	for _, nodePool := range headroom.Status.NodePools {
+		for _, shape := range nodePool.Shapes {
+			res = append(res, &metrics.StoreMetric{
+				GaugeMetric: NodePoolHeadroomPods,
+				Value:       float64(shape.Pods),
+				Labels:      map[string]string{headroomLabel: headroom.Name, shapeLabel: shape.Name, nodePoolLabel: nodePool.NodePool},
+			})
+		}
+	}
+	return res
+}
@@ -0,0 +1,192 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroom_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/metrics/headroom"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	testv1alpha1 "sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+var nodeClaimStateController *informer.NodeClaimController
+var nodeStateController *informer.NodeController
+var podStateController *informer.PodController
+var controller *headroom.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HeadroomMetrics")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(testv1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	podStateController = informer.NewPodController(env.Client, cluster)
+	controller = headroom.NewController(fakeClock, env.Client, cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	cluster.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Headroom", func() {
+	var nodePool *v1.NodePool
+	var clusterHeadroom *v1alpha1.ClusterHeadroom
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var pod *corev1.Pod
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		clusterHeadroom = &v1alpha1.ClusterHeadroom{ObjectMeta: test.ObjectMeta()}
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+			Status: v1.NodeClaimStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+					corev1.ResourcePods:   resource.MustParse("10"),
+				},
+			},
+		})
+		pod = test.Pod(test.PodOptions{
+			NodeName: node.Name,
+			ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1"),
+			}},
+		})
+	})
+	applyNode := func() {
+		GinkgoHelper()
+		ExpectApplied(ctx, env.Client, nodePool, clusterHeadroom, nodeClaim, node, pod)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		ExpectReconcileSucceeded(ctx, podStateController, client.ObjectKeyFromObject(pod))
+	}
+	It("should report the headroom for the default pod shapes", func() {
+		applyNode()
+		ExpectSingletonReconciled(ctx, controller)
+
+		clusterHeadroom = ExpectExists(ctx, env.Client, clusterHeadroom)
+		// The node's pod limit bounds the small shape, and its remaining 3 CPUs bound the medium and large shapes
+		expected := []v1alpha1.ShapeHeadroom{{Name: "small", Pods: 9}, {Name: "medium", Pods: 3}, {Name: "large", Pods: 0}}
+		Expect(clusterHeadroom.Status.Shapes).To(Equal(expected))
+		Expect(clusterHeadroom.Status.NodePools).To(Equal([]v1alpha1.NodePoolHeadroom{{NodePool: nodePool.Name, Shapes: expected}}))
+		Expect(clusterHeadroom.Status.LastUpdateTime.IsZero()).To(BeFalse())
+	})
+	It("should report the headroom for the configured pod shapes", func() {
+		clusterHeadroom.Spec.Shapes = []v1alpha1.PodShape{{Name: "memory-heavy", Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("3Gi")}}}
+		applyNode()
+		ExpectSingletonReconciled(ctx, controller)
+
+		clusterHeadroom = ExpectExists(ctx, env.Client, clusterHeadroom)
+		Expect(clusterHeadroom.Status.Shapes).To(Equal([]v1alpha1.ShapeHeadroom{{Name: "memory-heavy", Pods: 2}}))
+	})
+	It("should not count nodes that are marked for deletion", func() {
+		applyNode()
+		cluster.MarkForDeletion(nodeClaim.Status.ProviderID)
+		ExpectSingletonReconciled(ctx, controller)
+
+		clusterHeadroom = ExpectExists(ctx, env.Client, clusterHeadroom)
+		Expect(clusterHeadroom.Status.Shapes).To(ContainElement(v1alpha1.ShapeHeadroom{Name: "small", Pods: 0}))
+		Expect(clusterHeadroom.Status.NodePools).To(BeEmpty())
+	})
+	It("should not count nodes with taints that the pod shape doesn't tolerate", func() {
+		nodeClaim.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+		node.Spec.Taints = nodeClaim.Spec.Taints
+		clusterHeadroom.Spec.Shapes = []v1alpha1.PodShape{
+			{Name: "untolerating", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			{Name: "tolerating", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, Tolerations: []corev1.Toleration{
+				{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+			}},
+		}
+		applyNode()
+		ExpectSingletonReconciled(ctx, controller)
+
+		clusterHeadroom = ExpectExists(ctx, env.Client, clusterHeadroom)
+		Expect(clusterHeadroom.Status.Shapes).To(Equal([]v1alpha1.ShapeHeadroom{{Name: "untolerating", Pods: 0}, {Name: "tolerating", Pods: 3}}))
+	})
+	It("should only count nodes that match the pod shape's node selector", func() {
+		clusterHeadroom.Spec.Shapes = []v1alpha1.PodShape{
+			{Name: "matching", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, NodeSelector: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+			{Name: "mismatching", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, NodeSelector: map[string]string{v1.NodePoolLabelKey: "other"}},
+		}
+		applyNode()
+		ExpectSingletonReconciled(ctx, controller)
+
+		clusterHeadroom = ExpectExists(ctx, env.Client, clusterHeadroom)
+		Expect(clusterHeadroom.Status.Shapes).To(Equal([]v1alpha1.ShapeHeadroom{{Name: "matching", Pods: 3}, {Name: "mismatching", Pods: 0}}))
+	})
+	It("should publish the headroom as metrics", func() {
+		applyNode()
+		ExpectSingletonReconciled(ctx, controller)
+
+		metric, found := FindMetricWithLabelValues("karpenter_cluster_headroom_pods", map[string]string{
+			"cluster_headroom": clusterHeadroom.Name,
+			"shape":            "medium",
+		})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 3))
+		metric, found = FindMetricWithLabelValues("karpenter_nodepools_headroom_pods", map[string]string{
+			"cluster_headroom": clusterHeadroom.Name,
+			"shape":            "small",
+			"nodepool":         nodePool.Name,
+		})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 9))
+	})
+})
@@ -63,7 +63,7 @@ var _ = BeforeSuite(func() {
 	fakeClock = clock.NewFakeClock(time.Now())
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	nodeController = informer.NewNodeController(env.Client, cluster)
-	metricsStateController = node.NewController(cluster)
+	metricsStateController = node.NewController(fakeClock, cluster)
 })
 
 var _ = AfterSuite(func() {
@@ -101,6 +101,7 @@ var _ = Describe("Node Metrics", func() {
 
 		ExpectApplied(ctx, env.Client, node)
 		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		ExpectClockStepped(fakeClock, time.Minute)
 		ExpectSingletonReconciled(ctx, metricsStateController)
 
 		metric, found := FindMetricWithLabelValues("karpenter_nodes_current_lifetime_seconds", map[string]string{
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -39,6 +40,8 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
 const (
@@ -108,6 +111,28 @@ var (
 		},
 		[]string{podName, podNamespace},
 	)
+	PodNodeClaimCreatedDurationSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.PodSubsystem,
+			Name:      "nodeclaim_created_duration_seconds",
+			Help:      "The time from pod creation until the nodeclaim that the pod was bound to was created, for pods that were pending when the nodeclaim was created. Labeled by the owning nodepool.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{metrics.NodePoolLabel},
+	)
+	PodNodeReadyBoundDurationSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.PodSubsystem,
+			Name:      "node_ready_bound_duration_seconds",
+			Help:      "The time from the node becoming ready until the pod is bound to it, for pods that were pending when the node's nodeclaim was created. Labeled by the owning nodepool.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{metrics.NodePoolLabel},
+	)
 	// Stage: alpha
 	PodProvisioningBoundDurationSeconds = opmetrics.NewPrometheusHistogram(
 		crmetrics.Registry,
@@ -169,6 +194,7 @@ var (
 
 // Controller for the resource
 type Controller struct {
+	clock       clock.Clock
 	kubeClient  client.Client
 	metricStore *metrics.Store
 	cluster     *state.Cluster
@@ -194,8 +220,9 @@ func labelNames() []string {
 }
 
 // NewController constructs a podController instance
-func NewController(kubeClient client.Client, cluster *state.Cluster) *Controller {
+func NewController(clk clock.Clock, kubeClient client.Client, cluster *state.Cluster) *Controller {
 	return &Controller{
+		clock:           clk,
 		kubeClient:      kubeClient,
 		metricStore:     metrics.NewStore(),
 		pendingPods:     sets.New[string](),
@@ -254,7 +281,9 @@ func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	// Get the time for when we Karpenter first thought the pod was schedulable. This should be zero if we didn't simulate for this pod.
 	schedulableTime := c.cluster.PodSchedulingSuccessTime(types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace})
 	c.recordPodStartupMetric(pod, schedulableTime)
-	c.recordPodBoundMetric(pod, schedulableTime)
+	if err := c.recordPodBoundMetric(ctx, pod, schedulableTime); err != nil {
+		return reconcile.Result{}, err
+	}
 	// Requeue every 30s for pods that are stuck without a state change
 	return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
 }
@@ -271,7 +300,7 @@ func (c *Controller) recordPodSchedulingUndecidedMetric(pod *corev1.Pod) {
 	}
 	// If we haven't made a decision, get the time that we ACK'd the pod and emit the metric based on that
 	if podAckTime := c.cluster.PodAckTime(nn); !podAckTime.IsZero() {
-		PodSchedulingUndecidedTimeSeconds.Set(time.Since(podAckTime).Seconds(), map[string]string{
+		PodSchedulingUndecidedTimeSeconds.Set(c.clock.Since(podAckTime).Seconds(), map[string]string{
 			podName:      pod.Name,
 			podNamespace: pod.Namespace,
 		})
@@ -282,12 +311,12 @@ func (c *Controller) recordPodSchedulingUndecidedMetric(pod *corev1.Pod) {
 func (c *Controller) recordPodStartupMetric(pod *corev1.Pod, schedulableTime time.Time) {
 	key := client.ObjectKeyFromObject(pod).String()
 	if pod.Status.Phase == corev1.PodPending {
-		PodUnstartedTimeSeconds.Set(time.Since(pod.CreationTimestamp.Time).Seconds(), map[string]string{
+		PodUnstartedTimeSeconds.Set(c.clock.Since(pod.CreationTimestamp.Time).Seconds(), map[string]string{
 			podName:      pod.Name,
 			podNamespace: pod.Namespace,
 		})
 		if !schedulableTime.IsZero() {
-			PodProvisioningUnstartedTimeSeconds.Set(time.Since(schedulableTime).Seconds(), map[string]string{
+			PodProvisioningUnstartedTimeSeconds.Set(c.clock.Since(schedulableTime).Seconds(), map[string]string{
 				podName:      pod.Name,
 				podNamespace: pod.Namespace,
 			})
@@ -300,12 +329,12 @@ func (c *Controller) recordPodStartupMetric(pod *corev1.Pod, schedulableTime tim
 	})
 	if c.pendingPods.Has(key) {
 		if !ok || cond.Status != corev1.ConditionTrue {
-			PodUnstartedTimeSeconds.Set(time.Since(pod.CreationTimestamp.Time).Seconds(), map[string]string{
+			PodUnstartedTimeSeconds.Set(c.clock.Since(pod.CreationTimestamp.Time).Seconds(), map[string]string{
 				podName:      pod.Name,
 				podNamespace: pod.Namespace,
 			})
 			if !schedulableTime.IsZero() {
-				PodProvisioningUnstartedTimeSeconds.Set(time.Since(schedulableTime).Seconds(), map[string]string{
+				PodProvisioningUnstartedTimeSeconds.Set(c.clock.Since(schedulableTime).Seconds(), map[string]string{
 					podName:      pod.Name,
 					podNamespace: pod.Namespace,
 				})
@@ -330,7 +359,7 @@ func (c *Controller) recordPodStartupMetric(pod *corev1.Pod, schedulableTime tim
 		}
 	}
 }
-func (c *Controller) recordPodBoundMetric(pod *corev1.Pod, schedulableTime time.Time) {
+func (c *Controller) recordPodBoundMetric(ctx context.Context, pod *corev1.Pod, schedulableTime time.Time) error {
 	key := client.ObjectKeyFromObject(pod).String()
 	cond, ok := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool {
 		return c.Type == corev1.PodScheduled
@@ -338,19 +367,19 @@ func (c *Controller) recordPodBoundMetric(pod *corev1.Pod, schedulableTime time.
 	if pod.Status.Phase == corev1.PodPending {
 		if !ok || cond.Status != corev1.ConditionTrue {
 			// If the podScheduled condition does not exist, or it exists and is not set to true, we emit pod_current_unbound_time_seconds metric.
-			PodUnboundTimeSeconds.Set(time.Since(pod.CreationTimestamp.Time).Seconds(), map[string]string{
+			PodUnboundTimeSeconds.Set(c.clock.Since(pod.CreationTimestamp.Time).Seconds(), map[string]string{
 				podName:      pod.Name,
 				podNamespace: pod.Namespace,
 			})
 			if !schedulableTime.IsZero() {
-				PodProvisioningUnboundTimeSeconds.Set(time.Since(schedulableTime).Seconds(), map[string]string{
+				PodProvisioningUnboundTimeSeconds.Set(c.clock.Since(schedulableTime).Seconds(), map[string]string{
 					podName:      pod.Name,
 					podNamespace: pod.Namespace,
 				})
 			}
 		}
 		c.unscheduledPods.Insert(key)
-		return
+		return nil
 	}
 	if c.unscheduledPods.Has(key) && ok && cond.Status == corev1.ConditionTrue {
 		// Delete the unbound metric since the pod is now bound
@@ -367,8 +396,100 @@ func (c *Controller) recordPodBoundMetric(pod *corev1.Pod, schedulableTime time.
 		if !schedulableTime.IsZero() {
 			PodProvisioningBoundDurationSeconds.Observe(cond.LastTransitionTime.Sub(schedulableTime).Seconds(), nil)
 		}
+		if err := c.recordProvisionedPodMetrics(ctx, pod, cond.LastTransitionTime.Time); err != nil {
+			return err
+		}
 		c.unscheduledPods.Delete(key)
 	}
+	return nil
+}
+
+// recordProvisionedPodMetrics records the metrics for a workload pod that was bound to a node launched by Karpenter.
+// DaemonSet and static pods are ignored since they're scheduled as soon as the node registers.
+func (c *Controller) recordProvisionedPodMetrics(ctx context.Context, pod *corev1.Pod, scheduledTime time.Time) error {
+	if !isWorkload(pod) {
+		return nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	nodeClaim, err := nodeutils.NodeClaimForNode(ctx, c.kubeClient, node)
+	if err != nil {
+		if nodeutils.IsNodeClaimNotFoundError(err) || nodeutils.IsDuplicateNodeClaimError(err) {
+			return nil
+		}
+		return err
+	}
+	recordProvisioningLatencyMetrics(pod, node, nodeClaim, scheduledTime)
+	return c.recordFirstPodScheduledMetric(ctx, pod, node, nodeClaim, scheduledTime)
+}
+
+// recordProvisioningLatencyMetrics breaks down how long a pod waited for the capacity that it was bound to into the
+// time until its NodeClaim was created and the time from its node being ready until the pod was bound. The time from
+// the NodeClaim being created until the node is ready is observed once per NodeClaim by NodeClaimsNodeReadyDurationSeconds.
+// Only pods that were already pending when the NodeClaim was created are counted, since other pods didn't wait for it.
+func recordProvisioningLatencyMetrics(pod *corev1.Pod, node *corev1.Node, nodeClaim *v1.NodeClaim, scheduledTime time.Time) {
+	if nodeClaim.CreationTimestamp.Time.Before(pod.CreationTimestamp.Time) {
+		return
+	}
+	labels := map[string]string{metrics.NodePoolLabel: nodeClaim.Labels[v1.NodePoolLabelKey]}
+	PodNodeClaimCreatedDurationSeconds.Observe(nodeClaim.CreationTimestamp.Sub(pod.CreationTimestamp.Time).Seconds(), labels)
+	ready, ok := lo.Find(node.Status.Conditions, func(c corev1.NodeCondition) bool {
+		return c.Type == corev1.NodeReady
+	})
+	if ok && ready.Status == corev1.ConditionTrue && !scheduledTime.Before(ready.LastTransitionTime.Time) {
+		PodNodeReadyBoundDurationSeconds.Observe(scheduledTime.Sub(ready.LastTransitionTime.Time).Seconds(), labels)
+	}
+}
+
+// recordFirstPodScheduledMetric observes how long it took for the NodeClaim that the pod was bound to to receive its
+// first workload. Pods that were bound after another workload pod on the same node aren't counted, which also keeps
+// pods that are bound to existing nodes after a restart from being counted. The NodeClaim is annotated when the metric
+// is observed so that it's only observed once per NodeClaim, even if the first pod is reconciled again.
+func (c *Controller) recordFirstPodScheduledMetric(ctx context.Context, pod *corev1.Pod, node *corev1.Node, nodeClaim *v1.NodeClaim, scheduledTime time.Time) error {
+	if _, ok := nodeClaim.Annotations[v1.NodeClaimFirstPodScheduledAnnotationKey]; ok {
+		return nil
+	}
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return err
+	}
+	if lo.ContainsBy(pods, func(p *corev1.Pod) bool {
+		if p.UID == pod.UID || !isWorkload(p) {
+			return false
+		}
+		t := scheduledAt(p)
+		return !t.IsZero() && (t.Before(scheduledTime) || (t.Equal(scheduledTime) && p.UID < pod.UID))
+	}) {
+		return nil
+	}
+	// The optimistic lock keeps concurrent reconciles of the pod from both observing the metric
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimFirstPodScheduledAnnotationKey: scheduledTime.Format(time.RFC3339)})
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	metrics.NodeClaimsFirstPodScheduledDurationSeconds.Observe(scheduledTime.Sub(nodeClaim.CreationTimestamp.Time).Seconds(), map[string]string{
+		metrics.NodePoolLabel:       nodeClaim.Labels[v1.NodePoolLabelKey],
+		metrics.InstanceFamilyLabel: metrics.InstanceFamily(node.Labels[corev1.LabelInstanceTypeStable]),
+	})
+	return nil
+}
+
+func isWorkload(pod *corev1.Pod) bool {
+	return !podutils.IsOwnedByDaemonSet(pod) && !podutils.IsOwnedByNode(pod)
+}
+
+// scheduledAt returns when the pod was bound to its node, or the zero time if it hasn't been
+func scheduledAt(pod *corev1.Pod) time.Time {
+	cond, ok := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool {
+		return c.Type == corev1.PodScheduled
+	})
+	if !ok || cond.Status != corev1.ConditionTrue {
+		return time.Time{}
+	}
+	return cond.LastTransitionTime.Time
 }
 
 // makeLabels creates the makeLabels using the current state of the pod
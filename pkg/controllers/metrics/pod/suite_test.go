@@ -29,6 +29,7 @@ import (
 	clock "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/metrics/pod"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
@@ -55,7 +56,7 @@ var _ = BeforeSuite(func() {
 	fakeClock = clock.NewFakeClock(time.Now())
 	cloudProvider = fake.NewCloudProvider()
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
-	podController = pod.NewController(env.Client, cluster)
+	podController = pod.NewController(fakeClock, env.Client, cluster)
 })
 
 var _ = AfterEach(func() {
@@ -365,6 +366,127 @@ var _ = Describe("Pod Metrics", func() {
 		})
 		Expect(found).To(BeFalse())
 	})
+	It("should record how long it took for the first workload pod to be scheduled to a nodeclaim", func() {
+		nodePool := test.NodePool()
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "first-pod.large",
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+		daemonSetPod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "apps/v1",
+			Kind:       "DaemonSet",
+			Name:       "daemonset",
+			UID:        "daemonset-uid",
+		}}}})
+		first := test.Pod()
+		second := test.Pod()
+		for i, p := range []*corev1.Pod{daemonSetPod, first, second} {
+			p.Status.Phase = corev1.PodPending
+			ExpectApplied(ctx, env.Client, p)
+			ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+
+			ExpectManualBinding(ctx, env.Client, p, node)
+			p = ExpectExists(ctx, env.Client, p)
+			p.Status.Phase = corev1.PodRunning
+			p.Status.Conditions = []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(nodeClaim.CreationTimestamp.Add(time.Duration(i+1) * time.Minute)),
+			}}
+			ExpectApplied(ctx, env.Client, p)
+		}
+		// Pods are reconciled out of order, so the later pod is seen bound first
+		for _, p := range []*corev1.Pod{second, daemonSetPod, first} {
+			ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+		}
+
+		metric, found := FindMetricWithLabelValues("karpenter_nodeclaims_first_pod_scheduled_duration_seconds", map[string]string{
+			"nodepool":        nodePool.Name,
+			"instance_family": "first-pod",
+		})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+		Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("==", (2 * time.Minute).Seconds()))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKey(v1.NodeClaimFirstPodScheduledAnnotationKey))
+	})
+	It("should not record the first pod scheduled duration for a nodeclaim that it's already been recorded for", func() {
+		nodePool := test.NodePool()
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "first-pod.large",
+				},
+				Annotations: map[string]string{v1.NodeClaimFirstPodScheduledAnnotationKey: time.Now().Format(time.RFC3339)},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+		p := test.Pod()
+		p.Status.Phase = corev1.PodPending
+		ExpectApplied(ctx, env.Client, p)
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+
+		ExpectManualBinding(ctx, env.Client, p, node)
+		p = ExpectExists(ctx, env.Client, p)
+		p.Status.Phase = corev1.PodRunning
+		p.Status.Conditions = []corev1.PodCondition{{
+			Type:               corev1.PodScheduled,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(nodeClaim.CreationTimestamp.Add(time.Minute)),
+		}}
+		ExpectApplied(ctx, env.Client, p)
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+
+		_, found := FindMetricWithLabelValues("karpenter_nodeclaims_first_pod_scheduled_duration_seconds", map[string]string{
+			"nodepool":        nodePool.Name,
+			"instance_family": "first-pod",
+		})
+		Expect(found).To(BeFalse())
+	})
+	It("should record how long a pending pod waited for its nodeclaim to be created and for its node to be bound to", func() {
+		nodePool := test.NodePool()
+		p := test.Pod()
+		p.Status.Phase = corev1.PodPending
+		ExpectApplied(ctx, env.Client, nodePool, p)
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+
+		readyTime := metav1.NewTime(time.Now().Add(time.Minute).Truncate(time.Second))
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+			},
+		})
+		node.Status.Conditions = []corev1.NodeCondition{{
+			Type:               corev1.NodeReady,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: readyTime,
+		}}
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+		ExpectManualBinding(ctx, env.Client, p, node)
+		p = ExpectExists(ctx, env.Client, p)
+		p.Status.Phase = corev1.PodRunning
+		p.Status.Conditions = []corev1.PodCondition{{
+			Type:               corev1.PodScheduled,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(readyTime.Add(30 * time.Second)),
+		}}
+		ExpectApplied(ctx, env.Client, p)
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(p))
+
+		ExpectMetricHistogramSampleCountValue("karpenter_pods_nodeclaim_created_duration_seconds", 1, map[string]string{"nodepool": nodePool.Name})
+		metric, found := FindMetricWithLabelValues("karpenter_pods_node_ready_bound_duration_seconds", map[string]string{"nodepool": nodePool.Name})
+		Expect(found).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+		Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("==", (30 * time.Second).Seconds()))
+	})
 	It("should delete the pod state metric on pod delete", func() {
 		p := test.Pod()
 		ExpectApplied(ctx, env.Client, p)
@@ -0,0 +1,176 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	provisioningscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// Controller adopts pre-existing Nodes that weren't launched by Karpenter, such as Nodes launched by the
+// cluster-autoscaler, by creating NodeClaims for them. Nodes opt in to adoption with the karpenter.sh/adopt annotation
+// and are adopted by the highest weighted NodePool that they match, so that they can be managed by Karpenter without
+// being replaced.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Node", klog.KRef(node.Namespace, node.Name)))
+
+	if !isAdoptable(node) {
+		return reconcile.Result{}, nil
+	}
+	if _, err := nodeutils.NodeClaimForNode(ctx, c.kubeClient, node); !nodeutils.IsNodeClaimNotFoundError(err) {
+		return reconcile.Result{}, nodeutils.IgnoreDuplicateNodeClaimError(err)
+	}
+	nodePools, err := nodepoolutils.ListManaged(ctx, c.kubeClient, c.cloudProvider)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodepoolutils.OrderByWeight(nodePools)
+	nodePool, ok := lo.Find(nodePools, func(np *v1.NodePool) bool { return matches(np, node) })
+	if !ok {
+		log.FromContext(ctx).V(1).Info("no nodepool matches the node to adopt")
+		return reconcile.Result{}, nil
+	}
+	nodeClaim := adoptingNodeClaim(nodePool, node)
+	if err = c.kubeClient.Create(ctx, nodeClaim); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("creating nodeclaim, %w", err)
+	}
+	log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool), "NodeClaim", klog.KObj(nodeClaim)).Info("adopting node")
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Name() string {
+	return "node.adoption"
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&corev1.Node{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return isAdoptable(o.(*corev1.Node))
+		}))).
+		Watches(&v1.NodePool{}, c.nodePoolEventHandler()).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 10,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// nodePoolEventHandler enqueues the Nodes waiting to be adopted when a NodePool changes, since they may now match it
+func (c *Controller) nodePoolEventHandler() handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, _ client.Object) []reconcile.Request {
+		nodeList := &corev1.NodeList{}
+		if err := c.kubeClient.List(ctx, nodeList); err != nil {
+			return nil
+		}
+		return lo.FilterMap(nodeList.Items, func(n corev1.Node, _ int) (reconcile.Request, bool) {
+			return reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&n)}, isAdoptable(&n)
+		})
+	})
+}
+
+// isAdoptable returns whether the Node opted in to adoption and isn't already owned by a NodePool
+func isAdoptable(node *corev1.Node) bool {
+	if node.Annotations[v1.AdoptAnnotationKey] != "true" || node.Spec.ProviderID == "" || !node.DeletionTimestamp.IsZero() {
+		return false
+	}
+	_, ok := node.Labels[v1.NodePoolLabelKey]
+	return !ok
+}
+
+// matches returns whether the Node could have been launched by the NodePool. The Node's labels must satisfy the
+// NodePool's requirements and the Node must already have the NodePool's taints, so that adopting the Node doesn't taint
+// it out from under the pods that are running on it.
+func matches(nodePool *v1.NodePool, node *corev1.Node) bool {
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	if !scheduling.NewLabelRequirements(node.Labels).IsCompatible(requirements, scheduling.AllowUndefinedWellKnownLabels) {
+		return false
+	}
+	return lo.EveryBy(nodePool.Spec.Template.Spec.Taints, func(t corev1.Taint) bool {
+		return lo.ContainsBy(node.Spec.Taints, func(nt corev1.Taint) bool { return nt.MatchTaint(&t) })
+	})
+}
+
+// adoptingNodeClaim builds the NodeClaim that adopts the Node for the NodePool. The NodeClaim is named after the Node so
+// that it's only ever created once, and it carries the Node's provider ID so that it's linked to the existing instance
+// rather than launching a new one. Startup taints are dropped since the Node has already started up.
+func adoptingNodeClaim(nodePool *v1.NodePool, node *corev1.Node) *v1.NodeClaim {
+	nct := provisioningscheduling.NewNodeClaimTemplate(nodePool)
+	nodeClaim := &v1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: node.Name,
+			Annotations: lo.Assign(nct.Annotations, map[string]string{
+				v1.AdoptedProviderIDAnnotationKey: node.Spec.ProviderID,
+			}),
+			Labels: nct.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         object.GVK(nodePool).GroupVersion().String(),
+					Kind:               object.GVK(nodePool).Kind,
+					Name:               nodePool.Name,
+					UID:                nodePool.UID,
+					BlockOwnerDeletion: lo.ToPtr(true),
+				},
+			},
+		},
+		Spec: nct.Spec,
+	}
+	nodeClaim.Spec.Requirements = nct.Requirements.NodeSelectorRequirements()
+	nodeClaim.Spec.StartupTaints = nil
+	return nodeClaim
+}
@@ -0,0 +1,134 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/adoption"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var adoptionController *adoption.Controller
+var env *test.Environment
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Adoption")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx), test.NodeClaimProviderIDFieldIndexer(ctx)))
+	ctx = options.ToContext(ctx, test.Options())
+
+	cloudProvider = fake.NewCloudProvider()
+	adoptionController = adoption.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Adoption", func() {
+	var nodePool *v1.NodePool
+	var node *corev1.Node
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		node = test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.AdoptAnnotationKey: "true"},
+				Labels:      map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+			},
+			ProviderID: test.RandomProviderID(),
+		})
+	})
+	It("should create a NodeClaim for a Node that opted in to adoption", func() {
+		ExpectApplied(ctx, env.Client, nodePool, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+
+		nodeClaim := ExpectExists(ctx, env.Client, &v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Name: node.Name}})
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.AdoptedProviderIDAnnotationKey, node.Spec.ProviderID))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, nodePool.Name))
+		Expect(nodeClaim.OwnerReferences).To(HaveLen(1))
+		Expect(nodeClaim.OwnerReferences[0].Name).To(Equal(nodePool.Name))
+		Expect(nodeClaim.Spec.StartupTaints).To(BeEmpty())
+	})
+	It("should ignore Nodes that haven't opted in to adoption", func() {
+		delete(node.Annotations, v1.AdoptAnnotationKey)
+		ExpectApplied(ctx, env.Client, nodePool, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+	})
+	It("should ignore Nodes that already belong to a NodePool", func() {
+		node.Labels[v1.NodePoolLabelKey] = nodePool.Name
+		ExpectApplied(ctx, env.Client, nodePool, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+	})
+	It("should ignore Nodes that don't match the NodePool's requirements", func() {
+		nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-2"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+	})
+	It("should ignore Nodes that don't have the NodePool's taints", func() {
+		nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+		ExpectApplied(ctx, env.Client, nodePool, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+	})
+	It("should adopt the Node into the highest weighted NodePool that it matches", func() {
+		other := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr[int32](10)}})
+		ExpectApplied(ctx, env.Client, nodePool, other, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+
+		nodeClaim := ExpectExists(ctx, env.Client, &v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Name: node.Name}})
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, other.Name))
+	})
+	It("should not create another NodeClaim for a Node that's already being adopted", func() {
+		ExpectApplied(ctx, env.Client, nodePool, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+		ExpectObjectReconciled(ctx, env.Client, adoptionController, node)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,115 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exclusive_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/exclusive"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var exclusiveController *exclusive.Controller
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Exclusive")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ExclusiveNodeTTL: lo.ToPtr(time.Hour)}))
+
+	cloudProvider = fake.NewCloudProvider()
+	exclusiveController = exclusive.NewController(fakeClock, env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Exclusive", func() {
+	var node *corev1.Node
+	var taint corev1.Taint
+
+	BeforeEach(func() {
+		fakeClock.SetTime(time.Now())
+		taint = v1.ExclusiveNoScheduleTaint(types.UID("exclusive-pod-uid"))
+		node = test.Node(test.NodeOptions{Taints: []corev1.Taint{taint}})
+	})
+
+	It("should keep the exclusive taint before the ttl has elapsed", func() {
+		ExpectApplied(ctx, env.Client, node)
+		result := ExpectObjectReconciled(ctx, env.Client, exclusiveController, node)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(result.RequeueAfter).To(BeNumerically("<=", time.Hour))
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ContainElement(taint))
+	})
+	It("should remove the exclusive taint after the ttl has elapsed", func() {
+		ExpectApplied(ctx, env.Client, node)
+		fakeClock.Step(time.Hour + time.Minute)
+		result := ExpectObjectReconciled(ctx, env.Client, exclusiveController, node)
+		Expect(result.RequeueAfter).To(BeZero())
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).ToNot(ContainElement(taint))
+	})
+	It("should not modify other taints", func() {
+		other := corev1.Taint{Key: "test.sh/taint", Effect: corev1.TaintEffectNoSchedule}
+		node.Spec.Taints = append(node.Spec.Taints, other)
+		ExpectApplied(ctx, env.Client, node)
+		fakeClock.Step(time.Hour + time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, exclusiveController, node)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ConsistOf(other))
+	})
+	It("should ignore nodes without the exclusive taint", func() {
+		node.Spec.Taints = nil
+		ExpectApplied(ctx, env.Client, node)
+		result := ExpectObjectReconciled(ctx, env.Client, exclusiveController, node)
+		Expect(result.RequeueAfter).To(BeZero())
+	})
+})
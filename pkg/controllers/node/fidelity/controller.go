@@ -0,0 +1,137 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fidelity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/awslabs/operatorpkg/reasonable"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+)
+
+// Controller verifies that registered nodes keep the labels and taints of their NodeClaim. Labels and taints that are
+// changed or removed out-of-band are restored, unless the NodePool's metadata fidelity policy says to ignore them or to
+// drift the node instead, which is handled by the NodeClaim disruption controller.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, c.Name())
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Node", klog.KRef(node.Namespace, node.Name)))
+
+	// Registration is what applies the NodeClaim's labels and taints, so there's nothing to verify before it completes
+	if node.Labels[v1.NodeRegisteredLabelKey] != "true" || !node.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	nodeClaim, err := nodeutils.NodeClaimForNode(ctx, c.kubeClient, node)
+	if err != nil {
+		if nodeutils.IsNodeClaimNotFoundError(err) || nodeutils.IsDuplicateNodeClaimError(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	labels, taints := nodeutils.MetadataMismatches(node, nodeClaim)
+	if len(labels) == 0 && len(taints) == 0 {
+		return reconcile.Result{}, nil
+	}
+	nodePool := &v1.NodePool{}
+	if name, ok := nodeClaim.Labels[v1.NodePoolLabelKey]; ok {
+		if err = c.kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodePool); err != nil {
+			if errors.IsNotFound(err) {
+				return reconcile.Result{}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	labels = lo.Filter(labels, func(k string, _ int) bool {
+		return nodePool.MetadataFidelityAction(k) == v1.MetadataFidelityActionRestore
+	})
+	taints = lo.Filter(taints, func(t corev1.Taint, _ int) bool {
+		return nodePool.MetadataFidelityAction(t.Key) == v1.MetadataFidelityActionRestore
+	})
+	if len(labels) == 0 && len(taints) == 0 {
+		return reconcile.Result{}, nil
+	}
+
+	stored := node.DeepCopy()
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	for _, k := range labels {
+		node.Labels[k] = nodeClaim.Labels[k]
+	}
+	for _, t := range taints {
+		// A taint whose value was changed is replaced rather than duplicated
+		node.Spec.Taints = append(lo.Reject(node.Spec.Taints, func(nt corev1.Taint, _ int) bool { return nt.MatchTaint(&t) }), t)
+	}
+	// The taint list is replaced in full by the merge patch, so a concurrent taint update would otherwise be lost
+	if err = c.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	taintKeys := lo.Map(taints, func(t corev1.Taint, _ int) string { return t.Key })
+	log.FromContext(ctx).Info("restored node metadata", "labels", labels, "taints", taintKeys)
+	c.recorder.Publish(MetadataRestored(node, nodeClaim, fmt.Sprintf("Restored labels %v and taints %v that were changed out-of-band", labels, taintKeys))...)
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Name() string {
+	return "node.fidelity"
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named(c.Name()).
+		For(&corev1.Node{}, builder.WithPredicates(nodeutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&v1.NodeClaim{}, nodeutils.NodeClaimEventHandler(c.kubeClient)).
+		WithOptions(controller.Options{
+			RateLimiter:             reasonable.RateLimiter(),
+			MaxConcurrentReconciles: 100,
+		}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
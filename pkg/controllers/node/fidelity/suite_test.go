@@ -0,0 +1,153 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fidelity_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/fidelity"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var fidelityController *fidelity.Controller
+var env *test.Environment
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fidelity")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeClaimProviderIDFieldIndexer(ctx)))
+	cloudProvider = fake.NewCloudProvider()
+	fidelityController = fidelity.NewController(env.Client, cloudProvider, test.NewEventRecorder())
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Fidelity", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var taint corev1.Taint
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		taint = corev1.Taint{Key: "test-taint", Value: "test-value", Effect: corev1.TaintEffectNoSchedule}
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+					"test-label":        "test-value",
+				},
+			},
+			Spec: v1.NodeClaimSpec{
+				Taints: []corev1.Taint{taint},
+			},
+		})
+		node.Labels[v1.NodeRegisteredLabelKey] = "true"
+		node.Spec.Taints = []corev1.Taint{taint}
+	})
+	It("should restore a label that was changed on the node", func() {
+		node.Labels["test-label"] = "other-value"
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue("test-label", "test-value"))
+	})
+	It("should restore a label that was removed from the node", func() {
+		delete(node.Labels, "test-label")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue("test-label", "test-value"))
+	})
+	It("should restore a taint that was removed from the node", func() {
+		node.Spec.Taints = nil
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ConsistOf(taint))
+	})
+	It("should replace a taint whose value was changed on the node", func() {
+		node.Spec.Taints = []corev1.Taint{{Key: taint.Key, Value: "other-value", Effect: taint.Effect}}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ConsistOf(taint))
+	})
+	It("should not restore startup taints or the exclusive taint", func() {
+		nodeClaim.Spec.StartupTaints = []corev1.Taint{{Key: "test-startup-taint", Effect: corev1.TaintEffectNoSchedule}}
+		nodeClaim.Spec.Taints = append(nodeClaim.Spec.Taints, v1.ExclusiveNoScheduleTaint("exclusive-pod-uid"))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Spec.Taints).To(ConsistOf(taint))
+	})
+	DescribeTable("should leave mismatched metadata alone when the NodePool's policy isn't restore",
+		func(action v1.MetadataFidelityAction) {
+			nodePool.Spec.MetadataFidelity = []v1.MetadataFidelityPolicy{
+				{Key: "test-label", Action: action},
+				{Key: taint.Key, Action: action},
+			}
+			node.Labels["test-label"] = "other-value"
+			node.Spec.Taints = nil
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Labels).To(HaveKeyWithValue("test-label", "other-value"))
+			Expect(node.Spec.Taints).To(BeEmpty())
+		},
+		Entry("Drift", v1.MetadataFidelityActionDrift),
+		Entry("Ignore", v1.MetadataFidelityActionIgnore),
+	)
+	It("should not modify nodes that haven't registered", func() {
+		delete(node.Labels, v1.NodeRegisteredLabelKey)
+		delete(node.Labels, "test-label")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).ToNot(HaveKey("test-label"))
+	})
+	It("should not modify nodes without a nodeClaim", func() {
+		delete(node.Labels, "test-label")
+		ExpectApplied(ctx, env.Client, nodePool, node)
+		ExpectObjectReconciled(ctx, env.Client, fidelityController, node)
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).ToNot(HaveKey("test-label"))
+	})
+})
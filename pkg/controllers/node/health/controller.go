@@ -46,6 +46,9 @@ import (
 
 var allowedUnhealthyPercent = intstr.FromString("20%")
 
+// rebootRecoveryDuration is how long a rebooted node has to become healthy again before it's replaced
+const rebootRecoveryDuration = 10 * time.Minute
+
 // Controller for the resource
 type Controller struct {
 	clock         clock.Clock
@@ -84,14 +87,28 @@ func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcil
 	// If a nodeclaim does has a nodepool label, validate the nodeclaims inside the nodepool are healthy (i.e bellow the allowed threshold)
 	// In the case of standalone nodeclaim, validate the nodes inside the cluster are healthy before proceeding
 	// to repair the nodes
+	var nodePool *v1.NodePool
 	nodePoolName, found := nodeClaim.Labels[v1.NodePoolLabelKey]
 	if found {
-		nodePoolHealthy, err := c.isNodePoolHealthy(ctx, nodePoolName)
+		nodePool = &v1.NodePool{}
+		if err = c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return reconcile.Result{}, err
+			}
+			nodePool = nil
+		}
+	}
+	policies := c.repairPolicies(nodePool)
+	if found {
+		nodePoolHealthy, err := c.isNodePoolHealthy(ctx, nodePoolName, policies)
 		if err != nil {
 			return reconcile.Result{}, client.IgnoreNotFound(err)
 		}
 		if !nodePoolHealthy {
-			return reconcile.Result{}, c.publishNodePoolHealthEvent(ctx, node, nodeClaim, nodePoolName)
+			if nodePool != nil {
+				c.recorder.Publish(NodeRepairBlocked(node, nodeClaim, nodePool, fmt.Sprintf("more then %s nodes are unhealthy in the nodepool", allowedUnhealthyPercent.String()))...)
+			}
+			return reconcile.Result{}, nil
 		}
 	} else {
 		clusterHealthy, err := c.isClusterHealthy(ctx)
@@ -104,7 +121,7 @@ func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcil
 		}
 	}
 
-	unhealthyNodeCondition, policyTerminationDuration := c.findUnhealthyConditions(node)
+	unhealthyNodeCondition, policyTerminationDuration := c.findUnhealthyConditions(node, policies)
 	if unhealthyNodeCondition == nil {
 		return reconcile.Result{}, nil
 	}
@@ -116,6 +133,14 @@ func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcil
 		return reconcile.Result{RequeueAfter: terminationTime.Sub(c.clock.Now())}, nil
 	}
 
+	// A NotReady node may only have lost its connection to the API server, so cross-check its instance with the
+	// CloudProvider before replacing it
+	if unhealthyNodeCondition.Type == corev1.NodeReady {
+		if result, recovering, err := c.recoverNotReady(ctx, node, nodeClaim, unhealthyNodeCondition, terminationTime.Add(policyTerminationDuration)); recovering || err != nil {
+			return result, err
+		}
+	}
+
 	// For unhealthy past the tolerationDisruption window we can forcefully terminate the node
 	if err := c.annotateTerminationGracePeriod(ctx, nodeClaim); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
@@ -134,11 +159,66 @@ func (c *Controller) Reconcile(ctx context.Context, node *corev1.Node) (reconcil
 	return reconcile.Result{}, nil
 }
 
+// recoverNotReady decides whether a NotReady node should be given more time to recover instead of being replaced, for
+// CloudProviders that report instance health. Instances that are still healthy are most likely partitioned from the
+// API server, so they're left alone until the replacementTime. Impaired instances are rebooted once, if the
+// CloudProvider supports it, and replaced if they don't recover. Anything else falls back to replacing the node.
+func (c *Controller) recoverNotReady(ctx context.Context, node *corev1.Node, nodeClaim *v1.NodeClaim, condition *corev1.NodeCondition, replacementTime time.Time) (reconcile.Result, bool, error) {
+	healthChecker, ok := cloudprovider.As[cloudprovider.InstanceHealthChecker](c.cloudProvider)
+	if !ok {
+		return reconcile.Result{}, false, nil
+	}
+	health, err := healthChecker.InstanceHealth(ctx, nodeClaim)
+	if err != nil {
+		if !cloudprovider.IsNodeClaimNotFoundError(err) {
+			log.FromContext(ctx).Error(err, "failed checking instance health, replacing node")
+		}
+		return reconcile.Result{}, false, nil
+	}
+	switch health {
+	case cloudprovider.InstanceHealthy:
+		if !c.clock.Now().Before(replacementTime) {
+			return reconcile.Result{}, false, nil
+		}
+		c.recorder.Publish(NodeRepairDeferred(node, nodeClaim, "instance is healthy, waiting for the node to reconnect")...)
+		return reconcile.Result{RequeueAfter: replacementTime.Sub(c.clock.Now())}, true, nil
+	case cloudprovider.InstanceImpaired:
+		rebooter, ok := cloudprovider.As[cloudprovider.Rebooter](c.cloudProvider)
+		if !ok {
+			return reconcile.Result{}, false, nil
+		}
+		// A reboot from before the node became NotReady was for an earlier failure that it recovered from
+		rebootTime, err := time.Parse(time.RFC3339, nodeClaim.Annotations[v1.NodeClaimRebootTimestampAnnotationKey])
+		if err == nil && !rebootTime.Before(condition.LastTransitionTime.Time) {
+			recoveryTime := rebootTime.Add(rebootRecoveryDuration)
+			if !c.clock.Now().Before(recoveryTime) {
+				return reconcile.Result{}, false, nil
+			}
+			return reconcile.Result{RequeueAfter: recoveryTime.Sub(c.clock.Now())}, true, nil
+		}
+		if err := rebooter.Reboot(ctx, nodeClaim); err != nil {
+			if cloudprovider.IsNodeClaimNotFoundError(err) {
+				return reconcile.Result{}, false, nil
+			}
+			return reconcile.Result{}, false, fmt.Errorf("rebooting instance, %w", err)
+		}
+		stored := nodeClaim.DeepCopy()
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimRebootTimestampAnnotationKey: c.clock.Now().Format(time.RFC3339)})
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, false, client.IgnoreNotFound(err)
+		}
+		log.FromContext(ctx).Info("rebooted impaired instance")
+		c.recorder.Publish(NodeRebooted(node, nodeClaim))
+		return reconcile.Result{RequeueAfter: rebootRecoveryDuration}, true, nil
+	}
+	return reconcile.Result{}, false, nil
+}
+
 // Find a node with a condition that matches one of the unhealthy conditions defined by the cloud provider
 // If there are multiple unhealthy status condition we will requeue based on the condition closest to its terminationDuration
-func (c *Controller) findUnhealthyConditions(node *corev1.Node) (nc *corev1.NodeCondition, cpTerminationDuration time.Duration) {
+func (c *Controller) findUnhealthyConditions(node *corev1.Node, policies []cloudprovider.RepairPolicy) (nc *corev1.NodeCondition, cpTerminationDuration time.Duration) {
 	requeueTime := time.Time{}
-	for _, policy := range c.cloudProvider.RepairPolicies() {
+	for _, policy := range policies {
 		// check the status and the type on the condition
 		nodeCondition := nodeutils.GetCondition(node, policy.ConditionType)
 		if nodeCondition.Status == policy.ConditionStatus {
@@ -154,6 +234,25 @@ func (c *Controller) findUnhealthyConditions(node *corev1.Node) (nc *corev1.Node
 	return nc, cpTerminationDuration
 }
 
+// repairPolicies returns the CloudProvider's repair policies, replacing those for the condition types that the
+// NodePool has its own policies for
+func (c *Controller) repairPolicies(nodePool *v1.NodePool) []cloudprovider.RepairPolicy {
+	policies := c.cloudProvider.RepairPolicies()
+	if nodePool == nil || len(nodePool.Spec.Repair) == 0 {
+		return policies
+	}
+	nodePoolPolicies := lo.Map(nodePool.Spec.Repair, func(policy v1.NodeRepairPolicy, _ int) cloudprovider.RepairPolicy {
+		return cloudprovider.RepairPolicy{
+			ConditionType:      policy.ConditionType,
+			ConditionStatus:    policy.ConditionStatus,
+			TolerationDuration: policy.TolerationDuration.Duration,
+		}
+	})
+	return append(lo.Reject(policies, func(policy cloudprovider.RepairPolicy, _ int) bool {
+		return lo.ContainsBy(nodePoolPolicies, func(p cloudprovider.RepairPolicy) bool { return p.ConditionType == policy.ConditionType })
+	}), nodePoolPolicies...)
+}
+
 func (c *Controller) annotateTerminationGracePeriod(ctx context.Context, nodeClaim *v1.NodeClaim) error {
 	stored := nodeClaim.DeepCopy()
 	nodeClaim.ObjectMeta.Annotations = lo.Assign(nodeClaim.ObjectMeta.Annotations, map[string]string{v1.NodeClaimTerminationTimestampAnnotationKey: c.clock.Now().Format(time.RFC3339)})
@@ -170,13 +269,13 @@ func (c *Controller) annotateTerminationGracePeriod(ctx context.Context, nodeCla
 // Up to 20% of Nodes may be unhealthy before the NodePool becomes unhealthy (or the nearest whole number, rounding up).
 // For example, given a NodePool with three nodes, one may be unhealthy without rendering the NodePool unhealthy, even though that's 33% of the total nodes.
 // This is analogous to how minAvailable and maxUnavailable work for PodDisruptionBudgets: https://kubernetes.io/docs/tasks/run-application/configure-pdb/#rounding-logic-when-specifying-percentages.
-func (c *Controller) isNodePoolHealthy(ctx context.Context, nodePoolName string) (bool, error) {
+func (c *Controller) isNodePoolHealthy(ctx context.Context, nodePoolName string, policies []cloudprovider.RepairPolicy) (bool, error) {
 	nodeList := &corev1.NodeList{}
 	if err := c.kubeClient.List(ctx, nodeList, client.MatchingLabels(map[string]string{v1.NodePoolLabelKey: nodePoolName})); err != nil {
 		return false, err
 	}
 
-	return c.isHealthyForNodes(nodeList.Items), nil
+	return c.isHealthyForNodes(nodeList.Items, policies), nil
 }
 
 func (c *Controller) isClusterHealthy(ctx context.Context) (bool, error) {
@@ -185,12 +284,12 @@ func (c *Controller) isClusterHealthy(ctx context.Context) (bool, error) {
 		return false, err
 	}
 
-	return c.isHealthyForNodes(nodeList.Items), nil
+	return c.isHealthyForNodes(nodeList.Items, c.cloudProvider.RepairPolicies()), nil
 }
 
-func (c *Controller) isHealthyForNodes(nodes []corev1.Node) bool {
+func (c *Controller) isHealthyForNodes(nodes []corev1.Node, policies []cloudprovider.RepairPolicy) bool {
 	unhealthyNodeCount := lo.CountBy(nodes, func(node corev1.Node) bool {
-		_, found := lo.Find(c.cloudProvider.RepairPolicies(), func(policy cloudprovider.RepairPolicy) bool {
+		_, found := lo.Find(policies, func(policy cloudprovider.RepairPolicy) bool {
 			nodeCondition := nodeutils.GetCondition(lo.ToPtr(node), policy.ConditionType)
 			return nodeCondition.Status == policy.ConditionStatus
 		})
@@ -200,12 +299,3 @@ func (c *Controller) isHealthyForNodes(nodes []corev1.Node) bool {
 	threshold := lo.Must(intstr.GetScaledValueFromIntOrPercent(lo.ToPtr(allowedUnhealthyPercent), len(nodes), true))
 	return unhealthyNodeCount <= threshold
 }
-
-func (c *Controller) publishNodePoolHealthEvent(ctx context.Context, node *corev1.Node, nodeClaim *v1.NodeClaim, npName string) error {
-	np := &v1.NodePool{}
-	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: npName}, np); err != nil {
-		return client.IgnoreNotFound(err)
-	}
-	c.recorder.Publish(NodeRepairBlocked(node, nodeClaim, np, fmt.Sprintf("more then %s nodes are unhealthy in the nodepool", allowedUnhealthyPercent.String()))...)
-	return nil
-}
@@ -24,6 +24,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clock "k8s.io/utils/clock/testing"
@@ -234,6 +235,165 @@ var _ = Describe("Node Health", func() {
 		})
 	})
 
+	Context("NodePool Repair Policies", func() {
+		It("should delete nodes that are unhealthy by the nodepool", func() {
+			cloudProvider.RepairPolicy = nil
+			nodePool.Spec.Repair = []v1.NodeRepairPolicy{{
+				ConditionType:      "KernelDeadlock",
+				ConditionStatus:    corev1.ConditionTrue,
+				TolerationDuration: metav1.Duration{Duration: 10 * time.Minute},
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "KernelDeadlock",
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(5 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			result := ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Minute*5, time.Second))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+
+			fakeClock.Step(5 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should prefer the nodepool's toleration duration over the cloud provider's for the same condition", func() {
+			nodePool.Spec.Repair = []v1.NodeRepairPolicy{{
+				ConditionType:      "BadNode",
+				ConditionStatus:    corev1.ConditionFalse,
+				TolerationDuration: metav1.Duration{Duration: 5 * time.Minute},
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "BadNode",
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(10 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should not delete nodes when the nodepool's policy doesn't match the condition's status", func() {
+			nodePool.Spec.Repair = []v1.NodeRepairPolicy{{
+				ConditionType:      "BadNode",
+				ConditionStatus:    corev1.ConditionUnknown,
+				TolerationDuration: metav1.Duration{Duration: 5 * time.Minute},
+			}}
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+				Type:               "BadNode",
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			})
+			fakeClock.Step(60 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+		})
+	})
+
+	Context("Instance Health", func() {
+		BeforeEach(func() {
+			cloudProvider.RepairPolicy = []cloudprovider.RepairPolicy{
+				{
+					ConditionType:      corev1.NodeReady,
+					ConditionStatus:    corev1.ConditionUnknown,
+					TolerationDuration: 30 * time.Minute,
+				},
+			}
+			node.Status.Conditions = []corev1.NodeCondition{{
+				Type:               corev1.NodeReady,
+				Status:             corev1.ConditionUnknown,
+				LastTransitionTime: metav1.Time{Time: fakeClock.Now()},
+			}}
+		})
+		It("should wait for a NotReady node to reconnect when its instance is healthy", func() {
+			cloudProvider.Health = cloudprovider.InstanceHealthy
+			fakeClock.Step(40 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			result := ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			Expect(result.RequeueAfter).To(BeNumerically("~", time.Minute*20, time.Second))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+			Expect(cloudProvider.RebootCalls).To(BeEmpty())
+		})
+		It("should replace a NotReady node with a healthy instance once it's been unreachable for twice the toleration duration", func() {
+			cloudProvider.Health = cloudprovider.InstanceHealthy
+			fakeClock.Step(60 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should reboot a NotReady node when its instance is impaired", func() {
+			cloudProvider.Health = cloudprovider.InstanceImpaired
+			fakeClock.Step(31 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			result := ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			Expect(result.RequeueAfter).To(Equal(10 * time.Minute))
+			Expect(cloudProvider.RebootCalls).To(HaveLen(1))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodeClaimRebootTimestampAnnotationKey, fakeClock.Now().Format(time.RFC3339)))
+		})
+		It("should replace a rebooted node that doesn't recover", func() {
+			cloudProvider.Health = cloudprovider.InstanceImpaired
+			fakeClock.Step(31 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+			Expect(cloudProvider.RebootCalls).To(HaveLen(1))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should reboot again when a node becomes NotReady after recovering from an earlier reboot", func() {
+			cloudProvider.Health = cloudprovider.InstanceImpaired
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.NodeClaimRebootTimestampAnnotationKey: fakeClock.Now().Add(-time.Hour).Format(time.RFC3339),
+			})
+			fakeClock.Step(31 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			Expect(cloudProvider.RebootCalls).To(HaveLen(1))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).To(BeNil())
+		})
+		It("should replace a NotReady node when its instance no longer exists", func() {
+			cloudProvider.NextHealthErr = cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance terminated"))
+			fakeClock.Step(31 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			Expect(cloudProvider.RebootCalls).To(BeEmpty())
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+		It("should replace a NotReady node when its instance health is unknown", func() {
+			fakeClock.Step(31 * time.Minute)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, healthController, node)
+
+			Expect(cloudProvider.RebootCalls).To(BeEmpty())
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp).ToNot(BeNil())
+		})
+	})
+
 	Context("Forceful termination", func() {
 		It("should ignore node disruption budgets", func() {
 			// Blocking disruption budgets
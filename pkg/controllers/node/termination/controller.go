@@ -19,6 +19,9 @@ package termination
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
@@ -48,7 +51,9 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	"sigs.k8s.io/karpenter/pkg/utils/pdb"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/termination"
 	volumeutil "sigs.k8s.io/karpenter/pkg/utils/volume"
@@ -112,11 +117,25 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 		}
 		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("tainting node with %s, %w", pretty.Taint(v1.DisruptedNoScheduleTaint), err))
 	}
-	if err = c.terminator.Drain(ctx, node, nodeTerminationTime); err != nil {
+	if err = c.labelDrainingSince(ctx, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("labeling node with %s, %w", v1.NodeDrainingSinceLabelKey, err))
+	}
+	awaitingLoadBalancer, err := c.awaitLoadBalancerDrain(ctx, node, nodeTerminationTime)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("awaiting load balancer drain, %w", err)
+	}
+	if err = c.terminator.Drain(ctx, node, nodeTerminationTime, awaitingLoadBalancer); err != nil {
 		if !terminator.IsNodeDrainError(err) {
 			return reconcile.Result{}, fmt.Errorf("draining node, %w", err)
 		}
 		c.recorder.Publish(terminatorevents.NodeFailedToDrain(node, err))
+		progress, err := c.drainProgress(ctx, node, nodeTerminationTime)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("computing drain progress, %w", err)
+		}
+		if err = c.patchDrainProgress(ctx, node, progress); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching drain progress, %w", err))
+		}
 		// If the underlying NodeClaim no longer exists, we want to delete to avoid trying to gracefully draining
 		// on nodes that are no longer alive. We do a check on the Ready condition of the node since, even
 		// though the CloudProvider says the instance is not around, we know that the kubelet process is still running
@@ -130,12 +149,20 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 				return reconcile.Result{}, fmt.Errorf("getting nodeclaim, %w", err)
 			}
 		}
-
+		// Nodes are reconciled as soon as the load balancer controller signals that it drained them, so we only need to
+		// back off until then rather than poll
+		if awaitingLoadBalancer.Len() > 0 {
+			c.recorder.Publish(terminatorevents.NodeAwaitingLoadBalancerDrain(node, options.FromContext(ctx).LoadBalancerDrainedCondition))
+			return reconcile.Result{Requeue: true}, nil
+		}
 		return reconcile.Result{RequeueAfter: 1 * time.Second}, nil
 	}
 	NodesDrainedTotal.Inc(map[string]string{
 		metrics.NodePoolLabel: node.Labels[v1.NodePoolLabelKey],
 	})
+	if err = c.patchDrainProgress(ctx, node, map[string]string{v1.DrainPodsRemainingAnnotationKey: "0"}); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching drain progress, %w", err))
+	}
 	// In order for Pods associated with PersistentVolumes to smoothly migrate from the terminating Node, we wait
 	// for VolumeAttachments of drain-able Pods to be cleaned up before terminating Node and removing its finalizer.
 	// However, if TerminationGracePeriod is configured for Node, and we are past that period, we will skip waiting.
@@ -175,6 +202,80 @@ func (c *Controller) finalize(ctx context.Context, node *corev1.Node) (reconcile
 	return reconcile.Result{}, nil
 }
 
+// drainProgress returns the annotations which mirror the drain progress onto the node, so that it's visible on the node
+// without having to locate its NodeClaim. The estimated completion is only reported when it's known: either all
+// remaining pods are already terminating or the node has a TerminationGracePeriod that bounds the drain.
+func (c *Controller) drainProgress(ctx context.Context, node *corev1.Node, nodeTerminationTime *time.Time) (map[string]string, error) {
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return nil, fmt.Errorf("listing pods on node, %w", err)
+	}
+	limits, err := pdb.NewLimits(ctx, c.clock, c.kubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
+	}
+	remaining := lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return pod.IsWaitingEviction(p, c.clock) })
+	blocking := map[string]int{} // map[pdb] -> pods that the pdb is blocking from being evicted
+	evicting := 0                // pods that haven't started terminating yet
+	var estimate *time.Time
+	for _, p := range remaining {
+		if pod.IsTerminating(p) {
+			if estimate == nil || p.DeletionTimestamp.Time.After(*estimate) {
+				estimate = lo.ToPtr(p.DeletionTimestamp.Time)
+			}
+			continue
+		}
+		evicting++
+		if key, ok := limits.CanEvictPods([]*corev1.Pod{p}); !ok {
+			blocking[key.String()]++
+		}
+	}
+	// We can't know when pods that haven't been evicted yet will go away, so the TerminationGracePeriod is the best estimate
+	if evicting > 0 || (nodeTerminationTime != nil && estimate != nil && nodeTerminationTime.Before(*estimate)) {
+		estimate = nodeTerminationTime
+	}
+
+	progress := map[string]string{
+		v1.DrainPodsRemainingAnnotationKey: strconv.Itoa(len(remaining)),
+	}
+	if len(blocking) > 0 {
+		keys := lo.Keys(blocking)
+		sort.Strings(keys)
+		progress[v1.DrainBlockingPDBsAnnotationKey] = strings.Join(lo.Map(keys, func(key string, _ int) string {
+			return fmt.Sprintf("%s=%d", key, blocking[key])
+		}), ",")
+	}
+	if estimate != nil {
+		progress[v1.DrainEstimatedCompletionAnnotationKey] = estimate.UTC().Format(time.RFC3339)
+	}
+	return progress, nil
+}
+
+// labelDrainingSince records when Karpenter started draining the node. The label is only set once, so it marks the
+// start of the drain across requeues.
+func (c *Controller) labelDrainingSince(ctx context.Context, node *corev1.Node) error {
+	if _, ok := node.Labels[v1.NodeDrainingSinceLabelKey]; ok {
+		return nil
+	}
+	stored := node.DeepCopy()
+	node.Labels = lo.Assign(node.Labels, map[string]string{v1.NodeDrainingSinceLabelKey: strconv.FormatInt(c.clock.Now().Unix(), 10)})
+	return c.kubeClient.Patch(ctx, node, client.MergeFrom(stored))
+}
+
+// patchDrainProgress replaces the drain progress annotations on the node with the passed progress
+func (c *Controller) patchDrainProgress(ctx context.Context, node *corev1.Node, progress map[string]string) error {
+	stored := node.DeepCopy()
+	node.Annotations = lo.Assign(lo.OmitByKeys(node.Annotations, []string{
+		v1.DrainPodsRemainingAnnotationKey,
+		v1.DrainBlockingPDBsAnnotationKey,
+		v1.DrainEstimatedCompletionAnnotationKey,
+	}), progress)
+	if equality.Semantic.DeepEqual(stored, node) {
+		return nil
+	}
+	return c.kubeClient.Patch(ctx, node, client.MergeFrom(stored))
+}
+
 func (c *Controller) deleteAllNodeClaims(ctx context.Context, nodeClaims ...*v1.NodeClaim) error {
 	for _, nodeClaim := range nodeClaims {
 		// If we still get the NodeClaim, but it's already marked as terminating, we don't need to call Delete again
@@ -256,11 +357,11 @@ func (c *Controller) removeFinalizer(ctx context.Context, n *corev1.Node) error
 		})
 
 		// We use stored.DeletionTimestamp since the api-server may give back a node after the patch without a deletionTimestamp
-		DurationSeconds.Observe(time.Since(stored.DeletionTimestamp.Time).Seconds(), map[string]string{
+		DurationSeconds.Observe(c.clock.Since(stored.DeletionTimestamp.Time).Seconds(), map[string]string{
 			metrics.NodePoolLabel: n.Labels[v1.NodePoolLabelKey],
 		})
 
-		NodeLifetimeDurationSeconds.Observe(time.Since(n.CreationTimestamp.Time).Seconds(), map[string]string{
+		NodeLifetimeDurationSeconds.Observe(c.clock.Since(n.CreationTimestamp.Time).Seconds(), map[string]string{
 			metrics.NodePoolLabel: n.Labels[v1.NodePoolLabelKey],
 		})
 
@@ -0,0 +1,89 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package termination
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	"sigs.k8s.io/karpenter/pkg/utils/pod"
+)
+
+// awaitLoadBalancerDrain returns the pods that shouldn't be evicted while the node waits for an external load balancer
+// controller to finish draining connections to it. Only pods backing a Service of type LoadBalancer wait, so the rest
+// of the node keeps draining, and they never wait past the drain timeout or the node's TerminationGracePeriod.
+func (c *Controller) awaitLoadBalancerDrain(ctx context.Context, node *corev1.Node, nodeTerminationTime *time.Time) (sets.Set[types.UID], error) {
+	signal := options.FromContext(ctx).LoadBalancerDrainedCondition
+	if signal == "" || isLoadBalancerDrained(node, signal) {
+		return nil, nil
+	}
+	deadline := node.DeletionTimestamp.Add(options.FromContext(ctx).LoadBalancerDrainTimeout)
+	if nodeTerminationTime != nil && nodeTerminationTime.Before(deadline) {
+		deadline = *nodeTerminationTime
+	}
+	if !c.clock.Now().Before(deadline) {
+		return nil, nil
+	}
+	backends, err := c.loadBalancerBackends(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	return sets.New(lo.Map(backends, func(p *corev1.Pod, _ int) types.UID { return p.UID })...), nil
+}
+
+// isLoadBalancerDrained returns true if the node carries the configured condition with a True status or the
+// configured label set to "true"
+func isLoadBalancerDrained(node *corev1.Node, signal string) bool {
+	if node.Labels[signal] == "true" {
+		return true
+	}
+	return nodeutils.GetCondition(node, corev1.NodeConditionType(signal)).Status == corev1.ConditionTrue
+}
+
+// loadBalancerBackends returns the pods on the node, which haven't started terminating, that are selected by a Service
+// of type LoadBalancer
+func (c *Controller) loadBalancerBackends(ctx context.Context, node *corev1.Node) ([]*corev1.Pod, error) {
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return nil, fmt.Errorf("listing pods on node, %w", err)
+	}
+	pods = lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return pod.IsEvictable(p) && !pod.IsTerminating(p) })
+	if len(pods) == 0 {
+		return nil, nil
+	}
+	serviceList := &corev1.ServiceList{}
+	if err = c.kubeClient.List(ctx, serviceList); err != nil {
+		return nil, fmt.Errorf("listing services, %w", err)
+	}
+	services := lo.Filter(serviceList.Items, func(s corev1.Service, _ int) bool {
+		return s.Spec.Type == corev1.ServiceTypeLoadBalancer && len(s.Spec.Selector) > 0
+	})
+	return lo.Filter(pods, func(p *corev1.Pod, _ int) bool {
+		return lo.ContainsBy(services, func(s corev1.Service) bool {
+			return s.Namespace == p.Namespace && labels.SelectorFromSet(s.Spec.Selector).Matches(labels.Set(p.Labels))
+		})
+	}), nil
+}
@@ -18,6 +18,7 @@ package termination_test
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -26,6 +27,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	clock "k8s.io/utils/clock/testing"
@@ -37,6 +39,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
@@ -63,9 +66,10 @@ var _ = BeforeSuite(func() {
 	env = test.NewEnvironment(
 		test.WithCRDs(apis.CRDs...),
 		test.WithCRDs(v1alpha1.CRDs...),
-		test.WithFieldIndexers(test.NodeClaimProviderIDFieldIndexer(ctx), test.VolumeAttachmentFieldIndexer(ctx)),
+		test.WithFieldIndexers(test.NodeClaimProviderIDFieldIndexer(ctx), test.NodeProviderIDFieldIndexer(ctx), test.VolumeAttachmentFieldIndexer(ctx), test.NodeClaimEvictionPlanFieldIndexer(ctx)),
 	)
 
+	ctx = options.ToContext(ctx, test.Options())
 	cloudProvider = fake.NewCloudProvider()
 	recorder = test.NewEventRecorder()
 	queue = terminator.NewTestingQueue(env.Client, recorder)
@@ -376,6 +380,190 @@ var _ = Describe("Termination", func() {
 			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
 			ExpectNotFound(ctx, env.Client, node)
 		})
+		It("should label the node with when it started draining", func() {
+			minAvailable := intstr.FromInt32(1)
+			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
+			pdb := test.PodDisruptionBudget(test.PDBOptions{
+				Labels:       labelSelector,
+				MinAvailable: &minAvailable,
+			})
+			podNoEvict := test.Pod(test.PodOptions{
+				NodeName: node.Name,
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:          labelSelector,
+					OwnerReferences: defaultOwnerRefs,
+				},
+				Phase: corev1.PodRunning,
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podNoEvict, pdb)
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			drainingSince := fmt.Sprint(fakeClock.Now().Unix())
+			node = ExpectNodeWithNodeClaimDraining(env.Client, node.Name)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodeDrainingSinceLabelKey, drainingSince))
+
+			// The label marks the start of the drain, so it isn't updated as the drain is retried
+			fakeClock.Step(time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			node = ExpectNodeWithNodeClaimDraining(env.Client, node.Name)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodeDrainingSinceLabelKey, drainingSince))
+		})
+		It("should hold evictions for pods that share a PDB with pods on nodes earlier in the eviction plan", func() {
+			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
+			pdb := test.PodDisruptionBudget(test.PDBOptions{
+				Labels:         labelSelector,
+				MaxUnavailable: lo.ToPtr(intstr.FromInt32(1)),
+			})
+			earlierNodeClaim, earlierNode := test.NodeClaimAndNode(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{
+				Finalizers:  []string{v1.TerminationFinalizer},
+				Annotations: map[string]string{v1.EvictionPlanAnnotationKey: "test-plan", v1.EvictionPlanOrderAnnotationKey: "0"},
+			}})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.EvictionPlanAnnotationKey: "test-plan", v1.EvictionPlanOrderAnnotationKey: "1"})
+			earlierPod := test.Pod(test.PodOptions{
+				NodeName:   earlierNode.Name,
+				ObjectMeta: metav1.ObjectMeta{Labels: labelSelector, OwnerReferences: defaultOwnerRefs},
+				Phase:      corev1.PodRunning,
+			})
+			heldPod := test.Pod(test.PodOptions{
+				NodeName:   node.Name,
+				ObjectMeta: metav1.ObjectMeta{Labels: labelSelector, OwnerReferences: defaultOwnerRefs},
+				Phase:      corev1.PodRunning,
+			})
+			otherPod := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, earlierNode, earlierNodeClaim, earlierPod, heldPod, otherPod, pdb)
+			Expect(env.Client.Delete(ctx, earlierNodeClaim)).To(Succeed())
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(otherPod)).To(BeTrue())
+			Expect(queue.Has(heldPod)).To(BeFalse())
+
+			// Once the earlier node has drained the pod sharing its PDB, the held pod is evicted
+			ExpectDeleted(ctx, env.Client, earlierPod)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(heldPod)).To(BeTrue())
+		})
+		DescribeTable("should not hold evictions for nodes earlier in the eviction plan that were released",
+			func(release func(*v1.NodeClaim)) {
+				labelSelector := map[string]string{test.RandomName(): test.RandomName()}
+				pdb := test.PodDisruptionBudget(test.PDBOptions{
+					Labels:         labelSelector,
+					MaxUnavailable: lo.ToPtr(intstr.FromInt32(1)),
+				})
+				earlierNodeClaim, earlierNode := test.NodeClaimAndNode(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{
+					Finalizers:  []string{v1.TerminationFinalizer},
+					Annotations: map[string]string{v1.EvictionPlanAnnotationKey: "test-plan", v1.EvictionPlanOrderAnnotationKey: "0"},
+				}})
+				nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.EvictionPlanAnnotationKey: "test-plan", v1.EvictionPlanOrderAnnotationKey: "1"})
+				earlierPod := test.Pod(test.PodOptions{
+					NodeName:   earlierNode.Name,
+					ObjectMeta: metav1.ObjectMeta{Labels: labelSelector, OwnerReferences: defaultOwnerRefs},
+					Phase:      corev1.PodRunning,
+				})
+				pod := test.Pod(test.PodOptions{
+					NodeName:   node.Name,
+					ObjectMeta: metav1.ObjectMeta{Labels: labelSelector, OwnerReferences: defaultOwnerRefs},
+					Phase:      corev1.PodRunning,
+				})
+				ExpectApplied(ctx, env.Client, node, nodeClaim, earlierNode, earlierNodeClaim, earlierPod, pod, pdb)
+				release(earlierNodeClaim)
+
+				Expect(env.Client.Delete(ctx, node)).To(Succeed())
+				node = ExpectNodeExists(ctx, env.Client, node.Name)
+				ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+				Expect(queue.Has(pod)).To(BeTrue())
+			},
+			Entry("when the earlier nodeclaim was never deleted", func(*v1.NodeClaim) {}),
+			Entry("when the earlier nodeclaim is gone", func(nc *v1.NodeClaim) {
+				ExpectDeleted(ctx, env.Client, nc)
+				ExpectFinalizersRemoved(ctx, env.Client, nc)
+				ExpectNotFound(ctx, env.Client, nc)
+			}),
+		)
+		It("should not hold evictions for the first node in the eviction plan", func() {
+			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
+			pdb := test.PodDisruptionBudget(test.PDBOptions{
+				Labels:         labelSelector,
+				MaxUnavailable: lo.ToPtr(intstr.FromInt32(1)),
+			})
+			laterNodeClaim, laterNode := test.NodeClaimAndNode(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{
+				Finalizers:  []string{v1.TerminationFinalizer},
+				Annotations: map[string]string{v1.EvictionPlanAnnotationKey: "test-plan", v1.EvictionPlanOrderAnnotationKey: "1"},
+			}})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.EvictionPlanAnnotationKey: "test-plan", v1.EvictionPlanOrderAnnotationKey: "0"})
+			laterPod := test.Pod(test.PodOptions{
+				NodeName:   laterNode.Name,
+				ObjectMeta: metav1.ObjectMeta{Labels: labelSelector, OwnerReferences: defaultOwnerRefs},
+				Phase:      corev1.PodRunning,
+			})
+			pod := test.Pod(test.PodOptions{
+				NodeName:   node.Name,
+				ObjectMeta: metav1.ObjectMeta{Labels: labelSelector, OwnerReferences: defaultOwnerRefs},
+				Phase:      corev1.PodRunning,
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, laterNode, laterNodeClaim, laterPod, pod, pdb)
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(pod)).To(BeTrue())
+		})
+		It("should report drain progress on the node", func() {
+			minAvailable := intstr.FromInt32(1)
+			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
+			pdb := test.PodDisruptionBudget(test.PDBOptions{
+				Labels: labelSelector,
+				// Don't let any pod evict
+				MinAvailable: &minAvailable,
+			})
+			podNoEvict := test.Pod(test.PodOptions{
+				NodeName: node.Name,
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:          labelSelector,
+					OwnerReferences: defaultOwnerRefs,
+				},
+				Phase: corev1.PodRunning,
+			})
+			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podNoEvict, podEvict, pdb)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1.DrainPodsRemainingAnnotationKey, "2"))
+			Expect(node.Annotations).To(HaveKeyWithValue(v1.DrainBlockingPDBsAnnotationKey, fmt.Sprintf("%s/%s=1", pdb.Namespace, pdb.Name)))
+			// Pods haven't started terminating and the node has no TerminationGracePeriod, so completion can't be estimated
+			Expect(node.Annotations).ToNot(HaveKey(v1.DrainEstimatedCompletionAnnotationKey))
+
+			// Delete pod blocked by the PDB to simulate successful eviction
+			ExpectDeleted(ctx, env.Client, podNoEvict)
+			ExpectDeleted(ctx, env.Client, podEvict)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1.DrainPodsRemainingAnnotationKey, "0"))
+			Expect(node.Annotations).ToNot(HaveKey(v1.DrainBlockingPDBsAnnotationKey))
+		})
+		It("should estimate drain completion from the node's TerminationGracePeriod", func() {
+			nodeClaim.Spec.TerminationGracePeriod = &metav1.Duration{Duration: time.Hour}
+			nodeClaim.ObjectMeta.Annotations = map[string]string{v1.NodeClaimTerminationTimestampAnnotationKey: fakeClock.Now().Add(time.Hour).UTC().Format(time.RFC3339)}
+			pod := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, pod)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1.DrainPodsRemainingAnnotationKey, "1"))
+			Expect(node.Annotations).To(HaveKeyWithValue(v1.DrainEstimatedCompletionAnnotationKey, nodeClaim.Annotations[v1.NodeClaimTerminationTimestampAnnotationKey]))
+		})
 		It("should evict pods in order and wait until pods are fully deleted", func() {
 			daemonEvict := test.DaemonSet()
 			daemonNodeCritical := test.DaemonSet(test.DaemonSetOptions{PodOptions: test.PodOptions{PriorityClassName: "system-node-critical"}})
@@ -484,6 +672,72 @@ var _ = Describe("Termination", func() {
 			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
 			ExpectNotFound(ctx, env.Client, node)
 		})
+		It("should evict pods of voluntarily disrupted nodes in the order of their QoS classes", func() {
+			orderCtx := options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionEvictionOrder: lo.ToPtr("BestEffort,Burstable,Guaranteed")}))
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.DisruptionReasonAnnotationKey: string(v1.DisruptionReasonUnderutilized)})
+			podBestEffort := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			podBurstable := test.Pod(test.PodOptions{
+				NodeName:             node.Name,
+				ObjectMeta:           metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+			})
+			podGuaranteed := test.Pod(test.PodOptions{
+				NodeName:   node.Name,
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+				ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")},
+				},
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podBestEffort, podBurstable, podGuaranteed)
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(orderCtx, env.Client, terminationController, node)
+			Expect(queue.Has(podBestEffort)).To(BeTrue())
+			Expect(queue.Has(podBurstable)).To(BeFalse())
+			Expect(queue.Has(podGuaranteed)).To(BeFalse())
+
+			ExpectDeleted(ctx, env.Client, podBestEffort)
+			ExpectObjectReconciled(orderCtx, env.Client, terminationController, node)
+			Expect(queue.Has(podBurstable)).To(BeTrue())
+			Expect(queue.Has(podGuaranteed)).To(BeFalse())
+
+			ExpectDeleted(ctx, env.Client, podBurstable)
+			ExpectObjectReconciled(orderCtx, env.Client, terminationController, node)
+			Expect(queue.Has(podGuaranteed)).To(BeTrue())
+		})
+		It("should evict pods of voluntarily disrupted nodes regardless of their QoS classes by default", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.DisruptionReasonAnnotationKey: string(v1.DisruptionReasonUnderutilized)})
+			podBestEffort := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			podBurstable := test.Pod(test.PodOptions{
+				NodeName:             node.Name,
+				ObjectMeta:           metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podBestEffort, podBurstable)
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(podBestEffort)).To(BeTrue())
+			Expect(queue.Has(podBurstable)).To(BeTrue())
+		})
+		It("should evict pods regardless of their QoS classes when the node isn't voluntarily disrupted", func() {
+			podBestEffort := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			podBurstable := test.Pod(test.PodOptions{
+				NodeName:             node.Name,
+				ObjectMeta:           metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+			})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, podBestEffort, podBurstable)
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(ctx, env.Client, terminationController, node)
+			Expect(queue.Has(podBestEffort)).To(BeTrue())
+			Expect(queue.Has(podBurstable)).To(BeTrue())
+		})
 		It("should not evict static pods", func() {
 			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
 			ExpectApplied(ctx, env.Client, node, nodeClaim, podEvict)
@@ -836,6 +1090,85 @@ var _ = Describe("Termination", func() {
 			})
 		})
 	})
+	Context("Load Balancer Drain", func() {
+		var lbCtx context.Context
+		var service *corev1.Service
+		var backend *corev1.Pod
+		BeforeEach(func() {
+			lbCtx = options.ToContext(ctx, test.Options(test.OptionsFields{LoadBalancerDrainedCondition: lo.ToPtr("example.com/lb-drained")}))
+			recorder.Reset()
+			labels := map[string]string{"app": "web"}
+			service = &corev1.Service{
+				ObjectMeta: test.NamespacedObjectMeta(),
+				Spec: corev1.ServiceSpec{
+					Type:     corev1.ServiceTypeLoadBalancer,
+					Selector: labels,
+					Ports:    []corev1.ServicePort{{Port: 80}},
+				},
+			}
+			backend = test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs, Labels: labels}})
+		})
+		AfterEach(func() {
+			ExpectDeleted(ctx, env.Client, service)
+		})
+		It("should wait for the load balancer to drain the node before evicting pods", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim, service, backend)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			result := ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(result.Requeue).To(BeTrue())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(queue.Has(backend)).To(BeFalse())
+			Expect(recorder.Calls("AwaitingLoadBalancerDrain")).To(Equal(1))
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			node.Labels["example.com/lb-drained"] = "true"
+			ExpectApplied(ctx, env.Client, node)
+			ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(queue.Has(backend)).To(BeTrue())
+		})
+		It("should evict pods that don't back load balancer services while waiting for the load balancer", func() {
+			other := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, service, backend, other)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(queue.Has(backend)).To(BeFalse())
+			Expect(queue.Has(other)).To(BeTrue())
+		})
+		It("should evict pods once the load balancer drained condition is true", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim, service, backend)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(queue.Has(backend)).To(BeFalse())
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{Type: "example.com/lb-drained", Status: corev1.ConditionTrue})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(queue.Has(backend)).To(BeTrue())
+		})
+		It("should evict pods once the load balancer drain timeout has passed", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim, service, backend)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(queue.Has(backend)).To(BeFalse())
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(queue.Has(backend)).To(BeTrue())
+		})
+		It("should not wait on nodes without pods backing load balancer services", func() {
+			service.Spec.Type = corev1.ServiceTypeClusterIP
+			ExpectApplied(ctx, env.Client, node, nodeClaim, service, backend)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectObjectReconciled(lbCtx, env.Client, terminationController, node)
+			Expect(queue.Has(backend)).To(BeTrue())
+		})
+	})
 	Context("Metrics", func() {
 		It("should fire the terminationSummary metric when deleting nodes", func() {
 			ExpectApplied(ctx, env.Client, node, nodeClaim)
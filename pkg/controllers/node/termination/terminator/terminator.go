@@ -19,19 +19,27 @@ package terminator
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	terminatorevents "sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator/events"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	disruptionutils "sigs.k8s.io/karpenter/pkg/utils/disruption"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	"sigs.k8s.io/karpenter/pkg/utils/pdb"
 	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
@@ -91,9 +99,10 @@ func (t *Terminator) Taint(ctx context.Context, node *corev1.Node, taint corev1.
 	return nil
 }
 
-// Drain evicts pods from the node and returns true when all pods are evicted
+// Drain evicts pods from the node and returns true when all pods are evicted. Pods in awaiting aren't evicted yet,
+// but still keep the node from being drained.
 // https://kubernetes.io/docs/concepts/architecture/nodes/#graceful-node-shutdown
-func (t *Terminator) Drain(ctx context.Context, node *corev1.Node, nodeGracePeriodExpirationTime *time.Time) error {
+func (t *Terminator) Drain(ctx context.Context, node *corev1.Node, nodeGracePeriodExpirationTime *time.Time, awaiting sets.Set[types.UID]) error {
 	pods, err := nodeutils.GetPods(ctx, t.kubeClient, node)
 	if err != nil {
 		return fmt.Errorf("listing pods on node, %w", err)
@@ -104,19 +113,102 @@ func (t *Terminator) Drain(ctx context.Context, node *corev1.Node, nodeGracePeri
 	if err := t.DeleteExpiringPods(ctx, podsToDelete, nodeGracePeriodExpirationTime); err != nil {
 		return fmt.Errorf("deleting expiring pods, %w", err)
 	}
+	nodeClaims, err := nodeutils.GetNodeClaims(ctx, t.kubeClient, node)
+	if err != nil {
+		return fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	held, err := t.heldByEvictionPlan(ctx, nodeClaims, pods)
+	if err != nil {
+		return fmt.Errorf("checking eviction plan, %w", err)
+	}
+	// Pods on nodes that Karpenter disrupted voluntarily are evicted in the order of their QoS classes, so that the
+	// pods which are most tolerant of restarts go first
+	var qosOrder []corev1.PodQOSClass
+	if lo.ContainsBy(nodeClaims, func(nc *v1.NodeClaim) bool { return nc.Annotations[v1.DisruptionReasonAnnotationKey] != "" }) {
+		// The order is validated when options are parsed
+		qosOrder, _ = options.ParseQOSOrder(options.FromContext(ctx).DisruptionEvictionOrder)
+	}
 	// Monitor pods in pod groups that either haven't been evicted or are actively evicting
-	podGroups := t.groupPodsByPriority(lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return podutil.IsWaitingEviction(p, t.clock) }))
+	podGroups := t.groupPodsByPriority(lo.Filter(pods, func(p *corev1.Pod, _ int) bool { return podutil.IsWaitingEviction(p, t.clock) }), qosOrder)
 	for _, group := range podGroups {
 		if len(group) > 0 {
 			// Only add pods to the eviction queue that haven't been evicted yet
-			t.evictionQueue.Add(lo.Filter(group, func(p *corev1.Pod, _ int) bool { return podutil.IsEvictable(p) })...)
+			t.evictionQueue.Add(lo.Filter(group, func(p *corev1.Pod, _ int) bool {
+				return podutil.IsEvictable(p) && !held.Has(p.UID) && !awaiting.Has(p.UID)
+			})...)
 			return NewNodeDrainError(fmt.Errorf("%d pods are waiting to be evicted", lo.SumBy(podGroups, func(pods []*corev1.Pod) int { return len(pods) })))
 		}
 	}
 	return nil
 }
 
-func (t *Terminator) groupPodsByPriority(pods []*corev1.Pod) [][]*corev1.Pod {
+// heldByEvictionPlan returns the pods which share a PDB with pods that are still waiting to be evicted from nodes that
+// come before this node in its eviction plan. Those pods are evicted once the earlier nodes have drained, so that
+// nodes which were disrupted together don't compete for the same disruption budgets. Earlier nodes whose NodeClaim is
+// gone or was never deleted, e.g. because the disruption was rolled back, don't hold anything back.
+func (t *Terminator) heldByEvictionPlan(ctx context.Context, nodeClaims []*v1.NodeClaim, pods []*corev1.Pod) (sets.Set[types.UID], error) {
+	nodeClaim, ok := lo.Find(nodeClaims, func(nc *v1.NodeClaim) bool { return nc.Annotations[v1.EvictionPlanAnnotationKey] != "" })
+	if !ok {
+		return nil, nil
+	}
+	position, err := strconv.Atoi(nodeClaim.Annotations[v1.EvictionPlanOrderAnnotationKey])
+	if err != nil || position == 0 {
+		return nil, nil
+	}
+	nodeClaimList := &v1.NodeClaimList{}
+	if err = t.kubeClient.List(ctx, nodeClaimList, nodeclaimutils.ForEvictionPlan(nodeClaim.Annotations[v1.EvictionPlanAnnotationKey])); err != nil {
+		return nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	var earlierPods []*corev1.Pod
+	for i := range nodeClaimList.Items {
+		nc := &nodeClaimList.Items[i]
+		if nc.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if p, err := strconv.Atoi(nc.Annotations[v1.EvictionPlanOrderAnnotationKey]); err != nil || p >= position {
+			continue
+		}
+		nodes, err := nodeclaimutils.AllNodesForNodeClaim(ctx, t.kubeClient, nc)
+		if err != nil {
+			return nil, err
+		}
+		podsOnNodes, err := nodeutils.GetPods(ctx, t.kubeClient, nodes...)
+		if err != nil {
+			return nil, fmt.Errorf("listing pods on node, %w", err)
+		}
+		earlierPods = append(earlierPods, lo.Filter(podsOnNodes, func(p *corev1.Pod, _ int) bool { return podutil.IsWaitingEviction(p, t.clock) })...)
+	}
+	// PDBs only govern pods in their own namespace, so only the namespaces with pods on this node and the earlier nodes
+	// can have PDBs that are shared between them
+	namespaces := sets.New(lo.Map(pods, func(p *corev1.Pod, _ int) string { return p.Namespace })...).
+		Intersection(sets.New(lo.Map(earlierPods, func(p *corev1.Pod, _ int) string { return p.Namespace })...))
+	limits := pdb.Limits{}
+	for _, namespace := range sets.List(namespaces) {
+		l, err := pdb.NewLimits(ctx, t.clock, t.kubeClient, client.InNamespace(namespace))
+		if err != nil {
+			return nil, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
+		}
+		limits = append(limits, l...)
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	pending := limits.Governing(earlierPods...)
+	held := sets.New[types.UID]()
+	for _, p := range pods {
+		if limits.Governing(p).HasAny(pending.UnsortedList()...) {
+			held.Insert(p.UID)
+		}
+	}
+	if held.Len() > 0 {
+		log.FromContext(ctx).V(1).WithValues("eviction-plan", nodeClaim.Annotations[v1.EvictionPlanAnnotationKey], "pods", held.Len()).Info("holding evictions until earlier nodes in the eviction plan drain")
+	}
+	return held, nil
+}
+
+// groupPodsByPriority groups pods in the order they're evicted in. Noncritical, non-daemon pods are further grouped by
+// the position of their QoS class in qosOrder, if it's set.
+func (t *Terminator) groupPodsByPriority(pods []*corev1.Pod, qosOrder []corev1.PodQOSClass) [][]*corev1.Pod {
 	// 1. Prioritize noncritical pods, non-daemon pods https://kubernetes.io/docs/concepts/architecture/nodes/#graceful-node-shutdown
 	var nonCriticalNonDaemon, nonCriticalDaemon, criticalNonDaemon, criticalDaemon []*corev1.Pod
 	for _, pod := range pods {
@@ -134,17 +226,26 @@ func (t *Terminator) groupPodsByPriority(pods []*corev1.Pod) [][]*corev1.Pod {
 			}
 		}
 	}
-	return [][]*corev1.Pod{nonCriticalNonDaemon, nonCriticalDaemon, criticalNonDaemon, criticalDaemon}
+	if len(qosOrder) == 0 {
+		return [][]*corev1.Pod{nonCriticalNonDaemon, nonCriticalDaemon, criticalNonDaemon, criticalDaemon}
+	}
+	// 2. Within noncritical, non-daemon pods, prioritize the QoS classes that come first in the order
+	groups := make([][]*corev1.Pod, len(qosOrder)+1)
+	for _, pod := range nonCriticalNonDaemon {
+		rank := disruptionutils.QOSRank(qosOrder, pod)
+		groups[rank] = append(groups[rank], pod)
+	}
+	return append(groups, nonCriticalDaemon, criticalNonDaemon, criticalDaemon)
 }
 
 func (t *Terminator) DeleteExpiringPods(ctx context.Context, pods []*corev1.Pod, nodeGracePeriodTerminationTime *time.Time) error {
 	for _, pod := range pods {
 		// check if the node has an expiration time and the pod needs to be deleted
 		deleteTime := t.podDeleteTimeWithGracePeriod(nodeGracePeriodTerminationTime, pod)
-		if deleteTime != nil && time.Now().After(*deleteTime) {
+		if deleteTime != nil && t.clock.Now().After(*deleteTime) {
 			// delete pod proactively to give as much of its terminationGracePeriodSeconds as possible for deletion
 			// ensure that we clamp the maximum pod terminationGracePeriodSeconds to the node's remaining expiration time in the delete command
-			gracePeriodSeconds := lo.ToPtr(int64(time.Until(*nodeGracePeriodTerminationTime).Seconds()))
+			gracePeriodSeconds := lo.ToPtr(int64(nodeGracePeriodTerminationTime.Sub(t.clock.Now()).Seconds()))
 			t.recorder.Publish(terminatorevents.DisruptPodDelete(pod, gracePeriodSeconds, nodeGracePeriodTerminationTime))
 			opts := &client.DeleteOptions{
 				GracePeriodSeconds: gracePeriodSeconds,
@@ -71,6 +71,7 @@ func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider clou
 		lastScanned:   cache.New(scanPeriod, 1*time.Minute),
 		checks: []Check{
 			NewNodeShape(),
+			NewNodeFit(),
 		},
 	}
 }
@@ -0,0 +1,60 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistency
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// NodeFit detects nodes whose allocatable resources can't fit the requests that the NodeClaim was launched for. The
+// requests include pod overhead (e.g. from a RuntimeClass), so this catches nodes where the pods we packed onto it
+// don't actually fit once they're bound.
+type NodeFit struct{}
+
+func NewNodeFit() Check {
+	return &NodeFit{}
+}
+
+func (n *NodeFit) Check(_ context.Context, node *corev1.Node, nodeClaim *v1.NodeClaim) ([]Issue, error) {
+	// ignore NodeClaims that are deleting
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	// and NodeClaims that haven't initialized yet
+	if !nodeClaim.StatusConditions().Get(v1.ConditionTypeInitialized).IsTrue() {
+		return nil, nil
+	}
+	var issues []Issue
+	for resourceName, requested := range nodeClaim.Spec.Resources.Requests {
+		allocatable := node.Status.Allocatable[resourceName]
+		// A resource which isn't allocatable at all is either unreported or not registered by its device plugin,
+		// neither of which is a fit issue
+		if requested.IsZero() || allocatable.IsZero() {
+			continue
+		}
+		if requested.Cmp(allocatable) > 0 {
+			issues = append(issues, Issue(fmt.Sprintf("expected %s of resource %s to fit, but only %s is allocatable", requested.String(),
+				resourceName, allocatable.String())))
+		}
+	}
+	return issues, nil
+}
@@ -181,4 +181,43 @@ var _ = Describe("NodeClaimController", func() {
 			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsistentStateFound).IsFalse()).To(BeTrue())
 		})
 	})
+	Context("Node Fit", func() {
+		It("should detect nodes that can't fit the requests they were launched for", func() {
+			nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: "arm-instance-type",
+						v1.NodeInitializedLabelKey:     "true",
+					},
+				},
+				Spec: v1.NodeClaimSpec{
+					Resources: v1.ResourceRequirements{
+						// includes the pod overhead of the pods which were packed onto the node
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("4"),
+						},
+					},
+				},
+				Status: v1.NodeClaimStatus{
+					ProviderID: test.RandomProviderID(),
+					Capacity: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("4"),
+					},
+					Allocatable: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("4"),
+					},
+				},
+			})
+			node.Status.Allocatable = corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("3500m"),
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimConsistencyController, nodeClaim)
+			Expect(recorder.DetectedEvent("expected 4 of resource cpu to fit, but only 3500m is allocatable")).To(BeTrue())
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsistentStateFound).IsFalse()).To(BeTrue())
+		})
+	})
 })
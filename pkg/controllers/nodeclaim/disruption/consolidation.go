@@ -58,6 +58,11 @@ func (c *Consolidation) Reconcile(ctx context.Context, nodePool *v1.NodePool, no
 
 	// If the lastPodEvent is zero, use the time that the nodeclaim was initialized, as that's when Karpenter recognizes that pods could have started scheduling
 	timeToCheck := lo.Ternary(!nodeClaim.Status.LastPodEventTime.IsZero(), nodeClaim.Status.LastPodEventTime.Time, initialized.LastTransitionTime.Time)
+	// Pod events during the NodePool's warmup period are ignored, so the node is considered quiet from the end of the
+	// warmup period at the earliest
+	if warmup := nodePool.Spec.Disruption.WarmupPeriod; warmup != nil {
+		timeToCheck = lo.Latest(timeToCheck, initialized.LastTransitionTime.Add(warmup.Duration))
+	}
 
 	// Consider a node consolidatable by looking at the lastPodEvent status field on the nodeclaim.
 	if c.clock.Since(timeToCheck) < lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration) {
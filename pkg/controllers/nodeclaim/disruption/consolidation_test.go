@@ -113,6 +113,39 @@ var _ = Describe("Underutilized", func() {
 		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
 		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeTrue())
 	})
+	It("should not mark NodeClaims as consolidatable until consolidateAfter passes after the warmup period", func() {
+		nodePool.Spec.Disruption.WarmupPeriod = &metav1.Duration{Duration: 10 * time.Minute}
+		nodeClaim.Status.LastPodEventTime.Time = time.Time{}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		fakeClock.SetTime(nodeClaim.StatusConditions().Get(v1.ConditionTypeInitialized).LastTransitionTime.Time)
+
+		fakeClock.Step(1 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeFalse())
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeTrue())
+	})
+	It("should ignore pod events during the warmup period", func() {
+		nodePool.Spec.Disruption.WarmupPeriod = &metav1.Duration{Duration: 10 * time.Minute}
+		initialized := nodeClaim.StatusConditions().Get(v1.ConditionTypeInitialized).LastTransitionTime.Time
+		nodeClaim.Status.LastPodEventTime.Time = initialized.Add(9 * time.Minute)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+
+		// A pod event at the end of the warmup period doesn't make the node consolidatable before the warmup ends
+		fakeClock.SetTime(initialized.Add(10*time.Minute + 30*time.Second))
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeFalse())
+
+		fakeClock.SetTime(initialized.Add(11 * time.Minute))
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable).IsTrue()).To(BeTrue())
+	})
 	It("should remove the status condition from the nodeClaim when lastPodEvent is too recent", func() {
 		nodeClaim.Status.LastPodEventTime.Time = fakeClock.Now()
 		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeConsolidatable)
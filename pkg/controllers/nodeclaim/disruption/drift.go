@@ -22,24 +22,33 @@ import (
 	"time"
 
 	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 )
 
 const (
 	NodePoolDrifted      cloudprovider.DriftReason = "NodePoolDrifted"
 	RequirementsDrifted  cloudprovider.DriftReason = "RequirementsDrifted"
 	InstanceTypeNotFound cloudprovider.DriftReason = "InstanceTypeNotFound"
+	NodeMetadataDrifted  cloudprovider.DriftReason = "NodeMetadataDrifted"
 )
 
 // Drift is a nodeclaim sub-controller that adds or removes status conditions on drifted nodeclaims
 type Drift struct {
+	clock         clock.Clock
+	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
 }
 
@@ -50,17 +59,19 @@ func (d *Drift) Reconcile(ctx context.Context, nodePool *v1.NodePool, nodeClaim
 	// 1. If NodeClaim is not launched, remove the drift status condition
 	if !nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched).IsTrue() {
 		_ = nodeClaim.StatusConditions().Clear(v1.ConditionTypeDrifted)
+		nodeClaim.Status.DriftedFields = nil
 		if hasDriftedCondition {
 			log.FromContext(ctx).V(1).Info("removing drift status condition, isn't launched")
 		}
 		return reconcile.Result{}, nil
 	}
-	driftedReason, err := d.isDrifted(ctx, nodePool, nodeClaim)
+	driftedReason, driftedFields, err := d.isDrifted(ctx, nodePool, nodeClaim)
 	if err != nil {
 		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting drift, %w", err))
 	}
 	// 2. Otherwise, if the NodeClaim isn't drifted, but has the status condition, remove it.
 	if driftedReason == "" {
+		nodeClaim.Status.DriftedFields = nil
 		if hasDriftedCondition {
 			_ = nodeClaim.StatusConditions().Clear(v1.ConditionTypeDrifted)
 			log.FromContext(ctx).V(1).Info("removing drifted status condition, not drifted")
@@ -69,35 +80,81 @@ func (d *Drift) Reconcile(ctx context.Context, nodePool *v1.NodePool, nodeClaim
 	}
 	// 3. Finally, if the NodeClaim is drifted, but doesn't have status condition, add it.
 	nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDrifted, string(driftedReason), string(driftedReason))
+	nodeClaim.Status.DriftedFields = driftedFields
 	if !hasDriftedCondition {
-		log.FromContext(ctx).V(1).WithValues("reason", string(driftedReason)).Info("marking drifted")
+		log.FromContext(ctx).V(1).WithValues("reason", string(driftedReason), "fields", driftedFields).Info("marking drifted")
 	}
 	// Requeue after 5 minutes for the cache TTL
 	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
 }
 
-// isDrifted will check if a NodeClaim is drifted from the fields in the NodePool Spec and the CloudProvider
-func (d *Drift) isDrifted(ctx context.Context, nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) (cloudprovider.DriftReason, error) {
+// isDrifted will check if a NodeClaim is drifted from the fields in the NodePool Spec and the CloudProvider. Along with
+// the reason, it returns the fields that drifted so that they can be surfaced on the NodeClaim's status.
+func (d *Drift) isDrifted(ctx context.Context, nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) (cloudprovider.DriftReason, []string, error) {
 	// First check for static drift or node requirements have drifted to save on API calls.
-	if reason := lo.FindOrElse([]cloudprovider.DriftReason{areStaticFieldsDrifted(nodePool, nodeClaim), areRequirementsDrifted(nodePool, nodeClaim)}, "", func(i cloudprovider.DriftReason) bool {
-		return i != ""
-	}); reason != "" {
-		return reason, nil
+	if reason := areStaticFieldsDrifted(nodePool, nodeClaim); reason != "" {
+		return reason, nodepoolutils.DriftedFields(nodePool, nodeClaim), nil
+	}
+	// NodeClaims launched from the last resort requirements are only drifted by the template's requirements once the
+	// NodePool's capacity fallback lapses
+	lastResort := nodeClaim.Annotations[v1.CapacityFallbackAnnotationKey] == string(v1.CapacityFallbackStageLastResort) &&
+		nodePool.CapacityFallbackStage(d.clock.Now()) == v1.CapacityFallbackStageLastResort
+	if reason := areRequirementsDrifted(nodePool, nodeClaim); reason != "" && !lastResort {
+		return reason, driftedRequirements(nodePool, nodeClaim), nil
+	}
+	fields, err := d.driftedNodeMetadata(ctx, nodePool, nodeClaim)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(fields) > 0 {
+		return NodeMetadataDrifted, fields, nil
 	}
 	// Include instance type checking separate from the other two to reduce the amount of times we grab the instance types.
 	its, err := d.cloudProvider.GetInstanceTypes(ctx, nodePool)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	if reason := instanceTypeNotFound(its, nodeClaim); reason != "" {
-		return reason, nil
+		return reason, []string{fmt.Sprintf("metadata.labels[%s]", corev1.LabelInstanceTypeStable)}, nil
 	}
 	// Then check if it's drifted from the cloud provider side.
 	driftedReason, err := d.cloudProvider.IsDrifted(ctx, nodeClaim)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	if driftedReason == "" {
+		return "", nil, nil
+	}
+	// The CloudProvider's reason identifies which part of the NodeClass drifted
+	return driftedReason, []string{fmt.Sprintf("%s/%s", lo.FromPtr(nodeClaim.Spec.NodeClassRef).Kind, driftedReason)}, nil
+}
+
+// driftedNodeMetadata returns the node's labels and taints that were changed out-of-band and that the NodePool's
+// metadata fidelity policy drifts on. Every other mismatch is left to the node fidelity controller.
+func (d *Drift) driftedNodeMetadata(ctx context.Context, nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) ([]string, error) {
+	if !nodeClaim.StatusConditions().Get(v1.ConditionTypeRegistered).IsTrue() {
+		return nil, nil
+	}
+	node, err := nodeclaimutils.NodeForNodeClaim(ctx, d.kubeClient, nodeClaim)
+	if err != nil {
+		if nodeclaimutils.IsNodeNotFoundError(err) || nodeclaimutils.IsDuplicateNodeError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	labels, taints := nodeutils.MetadataMismatches(node, nodeClaim)
+	var fields []string
+	for _, k := range labels {
+		if nodePool.MetadataFidelityAction(k) == v1.MetadataFidelityActionDrift {
+			fields = append(fields, fmt.Sprintf("metadata.labels[%s]", k))
+		}
 	}
-	return driftedReason, nil
+	for _, t := range taints {
+		if nodePool.MetadataFidelityAction(t.Key) == v1.MetadataFidelityActionDrift {
+			fields = append(fields, fmt.Sprintf("spec.taints[%s]", t.Key))
+		}
+	}
+	return lo.Uniq(fields), nil
 }
 
 // InstanceType Offerings should return the full list of allowed instance types, even if they're temporarily
@@ -152,3 +209,16 @@ func areRequirementsDrifted(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) clou
 
 	return ""
 }
+
+// driftedRequirements returns the NodePool requirements that the NodeClaim's labels are no longer compatible with
+func driftedRequirements(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) []string {
+	nodepoolReq := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
+	nodeClaimReq := scheduling.NewLabelRequirements(nodeClaim.Labels)
+	keys := lo.Filter(sets.List(nodepoolReq.Keys()), func(key string, _ int) bool {
+		return nodeClaimReq.Compatible(scheduling.NewRequirements(nodepoolReq.Get(key))) != nil
+	})
+	if len(keys) == 0 {
+		return []string{"spec.template.spec.requirements"}
+	}
+	return lo.Map(keys, func(key string, _ int) string { return fmt.Sprintf("spec.template.spec.requirements[%s]", key) })
+}
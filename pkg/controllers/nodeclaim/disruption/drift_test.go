@@ -17,6 +17,7 @@ limitations under the License.
 package disruption_test
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/imdario/mergo"
@@ -33,6 +34,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 )
 
 var _ = Describe("Drift", func() {
@@ -157,6 +159,49 @@ var _ = Describe("Drift", func() {
 		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
 		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).Reason).To(Equal(string(disruption.RequirementsDrifted)))
 	})
+	Context("Capacity Fallback", func() {
+		BeforeEach(func() {
+			cp.Drifted = ""
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+						Key:      corev1.LabelInstanceTypeStable,
+						Operator: corev1.NodeSelectorOpDoesNotExist,
+					},
+				},
+			}
+			nodePool.Spec.CapacityFallback = &v1.CapacityFallback{Window: &metav1.Duration{Duration: 10 * time.Minute}}
+			nodePool.Status.CapacityFallback = &v1.CapacityFallbackStatus{
+				Stage:                        v1.CapacityFallbackStageLastResort,
+				LastInsufficientCapacityTime: metav1.NewTime(fakeClock.Now()),
+			}
+			nodeClaim.Annotations[v1.CapacityFallbackAnnotationKey] = string(v1.CapacityFallbackStageLastResort)
+		})
+		It("should not detect requirement drift for last resort nodeClaims while the fallback is active", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
+		})
+		It("should detect requirement drift for last resort nodeClaims once the fallback lapses", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			fakeClock.Step(10 * time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).Reason).To(Equal(string(disruption.RequirementsDrifted)))
+		})
+		It("should detect requirement drift for nodeClaims that weren't launched from the last resort", func() {
+			delete(nodeClaim.Annotations, v1.CapacityFallbackAnnotationKey)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
+		})
+	})
 	It("should remove the status condition from the nodeClaim when the nodeClaim launch condition is unknown", func() {
 		cp.Drifted = "drifted"
 		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
@@ -199,6 +244,35 @@ var _ = Describe("Drift", func() {
 		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
 		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
 	})
+	It("should record the NodeClass that drifted in the nodeClaim's drifted fields", func() {
+		cp.Drifted = "drifted"
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
+		Expect(nodeClaim.Status.DriftedFields).To(ConsistOf(nodeClaim.Spec.NodeClassRef.Kind + "/drifted"))
+	})
+	It("should record the instance type label in the nodeClaim's drifted fields when the instance type doesn't exist", func() {
+		cp.InstanceTypes = nil
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Status.DriftedFields).To(ConsistOf("metadata.labels[" + corev1.LabelInstanceTypeStable + "]"))
+	})
+	It("should clear the drifted fields from the nodeClaim if the nodeClaim is no longer drifted", func() {
+		cp.Drifted = ""
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeDrifted)
+		nodeClaim.Status.DriftedFields = []string{"spec.template.spec.taints"}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
+		Expect(nodeClaim.Status.DriftedFields).To(BeEmpty())
+	})
 	Context("NodeRequirement Drift", func() {
 		DescribeTable("",
 			func(oldNodePoolReq []v1.NodeSelectorRequirementWithMinValues, newNodePoolReq []v1.NodeSelectorRequirementWithMinValues, labels map[string]string, drifted bool) {
@@ -402,6 +476,7 @@ var _ = Describe("Drift", func() {
 			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaimTwo)
 			nodeClaimTwo = ExpectExists(ctx, env.Client, nodeClaimTwo)
 			Expect(nodeClaimTwo.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
+			Expect(nodeClaimTwo.Status.DriftedFields).To(ConsistOf("spec.template.spec.requirements[" + corev1.LabelOSStable + "]"))
 		})
 
 	})
@@ -477,7 +552,109 @@ var _ = Describe("Drift", func() {
 			Entry("NodeClassRef Name", v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{NodeClassRef: &v1.NodeClassReference{Name: "testName"}}}}}),
 			Entry("ExpireAfter", v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{ExpireAfter: v1.MustParseNillableDuration("100m")}}}}),
 			Entry("TerminationGracePeriod", v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{TerminationGracePeriod: &metav1.Duration{Duration: 100 * time.Minute}}}}}),
+			Entry("PlacementGroup", v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{PlacementGroup: "training"}}}}),
+			Entry("TerminationPolicy", v1.NodePool{Spec: v1.NodePoolSpec{Template: v1.NodeClaimTemplate{Spec: v1.NodeClaimTemplateSpec{TerminationPolicy: v1.TerminationPolicyHold}}}}),
 		)
+		It("should detect drift on changes to the fields covered by a registered drift hasher", func() {
+			nodepoolutils.RegisterDriftHasher(labelHasher("cost-center"))
+			DeferCleanup(nodepoolutils.UnregisterDriftHasher, labelHasher("cost-center").Name())
+
+			nodePool.Labels = lo.Assign(nodePool.Labels, map[string]string{"cost-center": "1234"})
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1.NodePoolHashAnnotationKey:        nodepoolutils.Hash(nodePool),
+				v1.NodePoolHashVersionAnnotationKey: nodepoolutils.HashVersion(),
+				v1.NodePoolFieldHashesAnnotationKey: nodepoolutils.FieldHashesAnnotation(nodePool),
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			nodePool.Labels["cost-center"] = "5678"
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
+			Expect(nodeClaim.Status.DriftedFields).To(ConsistOf("metadata.labels[cost-center]"))
+		})
+		It("should not detect drift on existing nodeclaims when a drift hasher is registered", func() {
+			nodePool.Labels = lo.Assign(nodePool.Labels, map[string]string{"cost-center": "1234"})
+			nodeClaim.Annotations[v1.NodePoolHashVersionAnnotationKey] = v1.NodePoolHashVersion
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+
+			nodepoolutils.RegisterDriftHasher(labelHasher("cost-center"))
+			DeferCleanup(nodepoolutils.UnregisterDriftHasher, labelHasher("cost-center").Name())
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashVersionAnnotationKey, nodepoolutils.HashVersion()))
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
+		})
+		It("should not detect drift on changes to the propagated NodePool labels", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			nodePool.Labels = lo.Assign(nodePool.Labels, map[string]string{"cost-center": "1234"})
+			nodePool.Spec.Template.PropagateNodePoolLabels = []string{"cost-center"}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
+		})
+		It("should record the static fields that drifted", func() {
+			nodeClaim.Annotations[v1.NodePoolFieldHashesAnnotationKey] = nodePool.FieldHashesAnnotation()
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "keytest2taint", Effect: corev1.TaintEffectNoExecute}}
+			nodePool.Spec.Template.Spec.ExpireAfter = v1.MustParseNillableDuration("100m")
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
+			Expect(nodeClaim.Status.DriftedFields).To(Equal([]string{"spec.template.spec.expireAfter", "spec.template.spec.taints"}))
+		})
+		It("should record the termination policy as drifted when it changes on the NodePool", func() {
+			nodeClaim.Annotations[v1.NodePoolFieldHashesAnnotationKey] = nodePool.FieldHashesAnnotation()
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			nodePool.Spec.Template.Spec.TerminationPolicy = v1.TerminationPolicyDelete
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).IsTrue()).To(BeTrue())
+			Expect(nodeClaim.Status.DriftedFields).To(Equal([]string{"spec.template.spec.terminationPolicy"}))
+		})
+		It("should record the whole template as drifted if the nodeClaim doesn't have field hashes", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "keytest2taint", Effect: corev1.TaintEffectNoExecute}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Status.DriftedFields).To(ConsistOf("spec.template"))
+		})
 		It("should not return drifted if karpenter.sh/nodepool-hash annotation is not present on the NodePool", func() {
 			nodePool.ObjectMeta.Annotations = map[string]string{}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
@@ -518,4 +695,59 @@ var _ = Describe("Drift", func() {
 			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
 		})
 	})
+	Context("Node Metadata Drift", func() {
+		BeforeEach(func() {
+			nodeClaim.Labels["test-label"] = "test-value"
+			nodeClaim.Spec.Taints = []corev1.Taint{{Key: "test-taint", Value: "test-value", Effect: corev1.TaintEffectNoSchedule}}
+			nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeRegistered)
+			node = test.NodeClaimLinkedNode(nodeClaim)
+			nodePool.Spec.MetadataFidelity = []v1.MetadataFidelityPolicy{
+				{Key: "test-label", Action: v1.MetadataFidelityActionDrift},
+				{Key: "test-taint", Action: v1.MetadataFidelityActionDrift},
+			}
+		})
+		It("should detect drift when a label with the drift policy is changed on the node", func() {
+			node.Labels["test-label"] = "other-value"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).Reason).To(Equal(string(disruption.NodeMetadataDrifted)))
+			Expect(nodeClaim.Status.DriftedFields).To(ConsistOf("metadata.labels[test-label]"))
+		})
+		It("should detect drift when a taint with the drift policy is removed from the node", func() {
+			node.Spec.Taints = lo.Reject(node.Spec.Taints, func(t corev1.Taint, _ int) bool { return t.Key == "test-taint" })
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted).Reason).To(Equal(string(disruption.NodeMetadataDrifted)))
+			Expect(nodeClaim.Status.DriftedFields).To(ConsistOf("spec.taints[test-taint]"))
+		})
+		It("should not detect drift when the changed label has the restore policy", func() {
+			nodePool.Spec.MetadataFidelity = nil
+			node.Labels["test-label"] = "other-value"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
+		})
+		It("should not detect drift before the nodeClaim is registered", func() {
+			Expect(nodeClaim.StatusConditions().Clear(v1.ConditionTypeRegistered)).To(Succeed())
+			node.Labels["test-label"] = "other-value"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
+		})
+	})
 })
+
+// labelHasher drifts NodeClaims when the value of a label on their NodePool changes
+type labelHasher string
+
+func (h labelHasher) Name() string {
+	return fmt.Sprintf("metadata.labels[%s]", string(h))
+}
+
+func (h labelHasher) Hash(nodePool *v1.NodePool) string {
+	return nodePool.Labels[string(h)]
+}
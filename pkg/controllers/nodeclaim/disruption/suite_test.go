@@ -156,4 +156,26 @@ var _ = Describe("Disruption", func() {
 		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDrifted)).To(BeNil())
 		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeConsolidatable)).To(BeNil())
 	})
+	It("should remove the DisruptionObserved condition once the NodePool enforces disruption", func() {
+		nodePool.Spec.Disruption.Mode = v1.DisruptionModeEnforce
+		nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionObserved, string(v1.DisruptionReasonEmpty), "Would have disrupted")
+
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved)).To(BeNil())
+	})
+	It("should keep the DisruptionObserved condition while the NodePool observes disruption", func() {
+		nodePool.Spec.Disruption.Mode = v1.DisruptionModeObserveOnly
+		nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionObserved, string(v1.DisruptionReasonEmpty), "Would have disrupted")
+
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimDisruptionController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved).IsTrue()).To(BeTrue())
+	})
 })
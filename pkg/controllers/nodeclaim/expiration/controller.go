@@ -18,9 +18,14 @@ package expiration
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/utils/clock"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -31,26 +36,39 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 )
 
-// Expiration is a nodeclaim controller that deletes expired nodeclaims based on expireAfter
+// Expiration is a nodeclaim controller that deletes expired nodeclaims based on expireAfter, or the expire-at override
 type Controller struct {
 	clock         clock.Clock
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	recorder      events.Recorder
 }
 
 // NewController constructs a nodeclaim disruption controller
-func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Controller {
 	return &Controller{
 		clock:         clk,
 		kubeClient:    kubeClient,
 		cloudProvider: cloudProvider,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		recorder:      recorder,
 	}
 }
 
+//nolint:gocyclo
 func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
 	if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) {
 		return reconcile.Result{}, nil
@@ -59,17 +77,54 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (re
 		return reconcile.Result{}, nil
 	}
 	// From here there are three scenarios to handle:
-	// 1. If ExpireAfter is not configured, exit expiration loop
-	if nodeClaim.Spec.ExpireAfter.Duration == nil {
+	// 1. If neither ExpireAfter nor the expire-at override is configured, exit expiration loop
+	expirationTime, err := nodeclaimutils.ExpirationTime(nodeClaim)
+	if err != nil {
+		// An invalid override is ignored in favor of ExpireAfter, rather than expiring the NodeClaim at an unintended time
+		log.FromContext(ctx).Error(err, "ignoring expire-at override")
+		c.recorder.Publish(InvalidExpireAtEvent(nodeClaim, err))
+	}
+	if err = c.labelExpiringAt(ctx, nodeClaim, expirationTime); err != nil {
+		return reconcile.Result{}, fmt.Errorf("labeling node with %s, %w", v1.NodeExpiringAtLabelKey, err)
+	}
+	if expirationTime == nil {
 		return reconcile.Result{}, nil
 	}
-	expirationTime := nodeClaim.CreationTimestamp.Add(*nodeClaim.Spec.ExpireAfter.Duration)
 	// 2. If the NodeClaim isn't expired leave the reconcile loop.
-	if c.clock.Now().Before(expirationTime) {
+	if c.clock.Now().Before(*expirationTime) {
 		// Use t.Sub(clock.Now()) instead of time.Until() to ensure we're using the injected clock.
 		return reconcile.Result{RequeueAfter: expirationTime.Sub(c.clock.Now())}, nil
 	}
-	// 3. Otherwise, if the NodeClaim is expired we can forcefully expire the nodeclaim (by deleting it)
+	// Deleting a NodeClaim whose providerID doesn't match its Node or instance could terminate the wrong instance, so we
+	// wait for the mismatch to be resolved
+	if nodeClaim.StatusConditions().IsTrue(v1.ConditionTypeProviderIDMismatch) {
+		return reconcile.Result{}, nil
+	}
+	// 3. Otherwise, the NodeClaim is expired. NodeClaims of ObserveOnly NodePools are only reported, and the rest have
+	// their termination policy decide what happens to them
+	nodePool := &v1.NodePool{}
+	if err = c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Labels[v1.NodePoolLabelKey]}, nodePool); client.IgnoreNotFound(err) != nil {
+		return reconcile.Result{}, fmt.Errorf("getting nodepool, %w", err)
+	}
+	if nodepoolutils.IsObserveOnly(ctx, nodePool) {
+		return reconcile.Result{RequeueAfter: time.Minute}, c.observe(ctx, nodeClaim)
+	}
+	switch nodeClaim.Spec.TerminationPolicy {
+	case v1.TerminationPolicyHold:
+		return reconcile.Result{}, c.hold(ctx, nodeClaim)
+	case v1.TerminationPolicyDelete:
+		fits, err := c.podsFitElsewhere(ctx, nodeClaim)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !fits {
+			if err = c.setExpired(ctx, nodeClaim, "WaitingForCapacity", "Waiting for the node's pods to fit on other nodes before deleting"); err != nil {
+				return reconcile.Result{}, err
+			}
+			return reconcile.Result{RequeueAfter: time.Minute}, nil
+		}
+	}
+	// We can forcefully expire the nodeclaim (by deleting it)
 	if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
@@ -87,6 +142,115 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (re
 	return reconcile.Result{}, nil
 }
 
+// hold cordons the NodeClaim's node so that no new pods schedule to it and leaves the node for manual action
+func (c *Controller) hold(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	if nodeClaim.Status.NodeName != "" {
+		node := &corev1.Node{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			return err
+		} else if err == nil && !node.Spec.Unschedulable {
+			stored := node.DeepCopy()
+			node.Spec.Unschedulable = true
+			if err = c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			log.FromContext(ctx).Info("cordoned expired node, holding for manual action")
+		}
+	}
+	return c.setExpired(ctx, nodeClaim, "Held", "Node is cordoned and held for manual action")
+}
+
+// observe reports that the NodeClaim would have been expired, without deleting it or cordoning its node
+func (c *Controller) observe(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	const message = "Would have expired the NodeClaim"
+	var node *corev1.Node
+	if nodeClaim.Status.NodeName != "" {
+		node = &corev1.Node{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			node = nil
+		}
+	}
+	c.recorder.Publish(disruptionevents.Observed(node, nodeClaim, message)...)
+	stored := nodeClaim.DeepCopy()
+	if !nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeDisruptionObserved, "Expired", message) {
+		return nil
+	}
+	log.FromContext(ctx).Info("observed expiration of nodeclaim")
+	metrics.NodeClaimsDisruptionObservedTotal.Inc(map[string]string{
+		metrics.ReasonLabel:       strings.ToLower(metrics.ExpiredReason),
+		metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+	})
+	// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	// Here, we are updating the status condition list
+	return client.IgnoreNotFound(c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})))
+}
+
+// labelExpiringAt mirrors the NodeClaim's expiration time onto its node, and removes the label once the NodeClaim no
+// longer expires
+func (c *Controller) labelExpiringAt(ctx context.Context, nodeClaim *v1.NodeClaim, expirationTime *time.Time) error {
+	if nodeClaim.Status.NodeName == "" {
+		return nil
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	stored := node.DeepCopy()
+	if expirationTime == nil {
+		delete(node.Labels, v1.NodeExpiringAtLabelKey)
+	} else {
+		node.Labels = lo.Assign(node.Labels, map[string]string{v1.NodeExpiringAtLabelKey: strconv.FormatInt(expirationTime.Unix(), 10)})
+	}
+	if equality.Semantic.DeepEqual(stored, node) {
+		return nil
+	}
+	return client.IgnoreNotFound(c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)))
+}
+
+func (c *Controller) setExpired(ctx context.Context, nodeClaim *v1.NodeClaim, reason, message string) error {
+	stored := nodeClaim.DeepCopy()
+	if !nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeExpired, reason, message) {
+		return nil
+	}
+	// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+	// can cause races due to the fact that it fully replaces the list on a change
+	// Here, we are updating the status condition list
+	if err := c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	return nil
+}
+
+// podsFitElsewhere simulates scheduling the pods on the NodeClaim's node against the rest of the cluster and returns
+// whether they'd all schedule without launching new capacity
+func (c *Controller) podsFitElsewhere(ctx context.Context, nodeClaim *v1.NodeClaim) (bool, error) {
+	stateNode, ok := lo.Find(c.cluster.Nodes(), func(n *state.StateNode) bool {
+		return n.NodeClaim != nil && n.NodeClaim.Name == nodeClaim.Name
+	})
+	if !ok {
+		return true, nil
+	}
+	pods, err := stateNode.ReschedulablePods(ctx, c.kubeClient)
+	if err != nil {
+		return false, fmt.Errorf("getting reschedulable pods, %w", err)
+	}
+	if len(pods) == 0 {
+		return true, nil
+	}
+	stateNodes := lo.Reject(c.cluster.Nodes().Active(), func(n *state.StateNode, _ int) bool { return n.NodeClaim != nil && n.NodeClaim.Name == nodeClaim.Name })
+	scheduler, err := c.provisioner.NewScheduler(log.IntoContext(ctx, operatorlogging.NopLogger), pods, stateNodes)
+	if err != nil {
+		return false, fmt.Errorf("creating scheduler, %w", err)
+	}
+	results := scheduler.Solve(log.IntoContext(ctx, operatorlogging.NopLogger), pods)
+	return len(results.NewNodeClaims) == 0 && len(results.PodErrors) == 0, nil
+}
+
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("nodeclaim.expiration").
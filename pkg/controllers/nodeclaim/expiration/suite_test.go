@@ -18,11 +18,13 @@ package expiration_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clock "k8s.io/utils/clock/testing"
@@ -31,6 +33,9 @@ import (
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/expiration"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
@@ -44,6 +49,10 @@ var expirationController *expiration.Controller
 var env *test.Environment
 var cp *fake.CloudProvider
 var fakeClock *clock.FakeClock
+var cluster *state.Cluster
+var nodeStateController *informer.NodeController
+var nodeClaimStateController *informer.NodeClaimController
+var recorder *test.EventRecorder
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -56,7 +65,12 @@ var _ = BeforeSuite(func() {
 	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...), test.WithFieldIndexers(test.NodeProviderIDFieldIndexer(ctx)))
 	ctx = options.ToContext(ctx, test.Options())
 	cp = fake.NewCloudProvider()
-	expirationController = expiration.NewController(fakeClock, env.Client, cp)
+	cluster = state.NewCluster(fakeClock, env.Client, cp)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cp, cluster)
+	prov := provisioning.NewProvisioner(env.Client, test.NewEventRecorder(), cp, cluster, fakeClock)
+	recorder = test.NewEventRecorder()
+	expirationController = expiration.NewController(fakeClock, env.Client, cp, cluster, prov, recorder)
 })
 
 var _ = AfterSuite(func() {
@@ -70,6 +84,8 @@ var _ = BeforeEach(func() {
 
 var _ = AfterEach(func() {
 	ExpectCleanedUp(ctx, env.Client)
+	cluster.Reset()
+	recorder.Reset()
 })
 
 var _ = Describe("Expiration", func() {
@@ -87,6 +103,7 @@ var _ = Describe("Expiration", func() {
 			},
 		})
 		metrics.NodeClaimsDisruptedTotal.Reset()
+		metrics.NodeClaimsDisruptionObservedTotal.Reset()
 	})
 	Context("Metrics", func() {
 		It("should fire a karpenter_nodeclaims_disrupted_total metric when expired", func() {
@@ -169,6 +186,23 @@ var _ = Describe("Expiration", func() {
 
 		ExpectNotFound(ctx, env.Client, nodeClaim)
 	})
+	It("should label the node with when the nodeClaim expires", func() {
+		nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("200s")
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.NodeExpiringAtLabelKey, fmt.Sprint(nodeClaim.CreationTimestamp.Add(200*time.Second).Unix())))
+	})
+	It("should remove the expiring-at label from the node when expiration is disabled", func() {
+		nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("Never")
+		node.Labels[v1.NodeExpiringAtLabelKey] = "1700000000"
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).ToNot(HaveKey(v1.NodeExpiringAtLabelKey))
+	})
 	It("should return the requeue interval for the time between now and when the nodeClaim expires", func() {
 		nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("200s")
 		ExpectApplied(ctx, env.Client, nodeClaim, node)
@@ -178,6 +212,117 @@ var _ = Describe("Expiration", func() {
 		result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
 		Expect(result.RequeueAfter).To(BeNumerically("~", time.Second*100, time.Second))
 	})
+	Context("Expire At Override", func() {
+		It("should expire NodeClaims at the override even when expiration is disabled", func() {
+			nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("Never")
+			nodeClaim.Annotations = map[string]string{v1.NodeClaimExpireAtAnnotationKey: fakeClock.Now().Add(-time.Minute).Format(time.RFC3339)}
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should not expire NodeClaims before the override even when ExpireAfter has elapsed", func() {
+			nodeClaim.Annotations = map[string]string{v1.NodeClaimExpireAtAnnotationKey: fakeClock.Now().Add(time.Hour).Format(time.RFC3339)}
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			fakeClock.Step(time.Minute)
+			result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			Expect(result.RequeueAfter).To(BeNumerically("~", 59*time.Minute, time.Second))
+			ExpectExists(ctx, env.Client, nodeClaim)
+		})
+		It("should fall back to ExpireAfter when the override is invalid", func() {
+			nodeClaim.Annotations = map[string]string{v1.NodeClaimExpireAtAnnotationKey: "tomorrow"}
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+			result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			Expect(result.RequeueAfter).To(BeNumerically("~", 30*time.Second, time.Second))
+			Expect(recorder.Calls("InvalidExpireAt")).To(Equal(1))
+
+			fakeClock.Step(time.Minute)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+	})
+	Context("Termination Policy", func() {
+		It("should cordon and hold expired NodeClaims with the Hold policy", func() {
+			nodeClaim.Spec.TerminationPolicy = v1.TerminationPolicyHold
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// step forward to make the node expired
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeExpired).IsTrue()).To(BeTrue())
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeExpired).Reason).To(Equal("Held"))
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Unschedulable).To(BeTrue())
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptedTotal, 0, map[string]string{
+				metrics.ReasonLabel: metrics.ExpiredReason,
+				"nodepool":          nodePool.Name,
+			})
+		})
+		It("should delete expired NodeClaims with the Delete policy when the node is empty", func() {
+			nodeClaim.Spec.TerminationPolicy = v1.TerminationPolicyDelete
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			// step forward to make the node expired
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		})
+		It("should wait to delete expired NodeClaims with the Delete policy until their pods fit elsewhere", func() {
+			nodeClaim.Spec.TerminationPolicy = v1.TerminationPolicyDelete
+			pod := test.Pod()
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+			// step forward to make the node expired
+			fakeClock.Step(60 * time.Second)
+			result := ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeExpired).Reason).To(Equal("WaitingForCapacity"))
+		})
+	})
+	Context("Observe Only", func() {
+		It("should report expired NodeClaims of ObserveOnly NodePools without deleting them", func() {
+			nodePool.Spec.Disruption.Mode = v1.DisruptionModeObserveOnly
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// step forward to make the node expired
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved).IsTrue()).To(BeTrue())
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved).Reason).To(Equal("Expired"))
+			Expect(recorder.Calls("DisruptionObserved")).To(Equal(2))
+			ExpectMetricCounterValue(metrics.NodeClaimsDisruptionObservedTotal, 1, map[string]string{
+				metrics.ReasonLabel: metrics.ExpiredReason,
+				"nodepool":          nodePool.Name,
+			})
+		})
+		It("should report expired NodeClaims without holding them when expiration is observe-only for every NodePool", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionObserveOnly: lo.ToPtr(true)}))
+			nodeClaim.Spec.TerminationPolicy = v1.TerminationPolicyHold
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+			// step forward to make the node expired
+			fakeClock.Step(60 * time.Second)
+			ExpectObjectReconciled(ctx, env.Client, expirationController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeDisruptionObserved).IsTrue()).To(BeTrue())
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeExpired)).To(BeNil())
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Spec.Unschedulable).To(BeFalse())
+		})
+	})
 	It("shouldn't expire the same NodeClaim multiple times", func() {
 		nodeClaim.ObjectMeta.Finalizers = append(nodeClaim.ObjectMeta.Finalizers, "test-finalizer")
 		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
@@ -0,0 +1,157 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+)
+
+// pollPeriod is how often the CloudProvider is asked for interruption events. Spot notices are typically given only
+// a couple of minutes ahead, so this needs to be short.
+const pollPeriod = 5 * time.Second
+
+// Controller drains the NodeClaims of instances that the CloudProvider has given notice of interruption for. It deletes
+// the NodeClaims, which taints and drains their nodes, and brings the termination time forward to the interruption
+// time so that pods are evicted before the instance goes away.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+
+	mu sync.Mutex
+	// pending are the interruptions that failed to be handled, which are retried on the next poll
+	pending []cloudprovider.InterruptionEvent
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.interruption")
+
+	notifier, ok := cloudprovider.As[cloudprovider.InterruptionNotifier](c.cloudProvider)
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	received, err := notifier.InterruptionEvents(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting interruption events, %w", err)
+	}
+	for _, interruption := range received {
+		InterruptionEventsReceivedTotal.Inc(map[string]string{metrics.ReasonLabel: pretty.ToSnakeCase(string(interruption.Kind))})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	interruptions := append(c.pending, received...)
+	errs := make([]error, len(interruptions))
+	workqueue.ParallelizeUntil(ctx, 20, len(interruptions), func(i int) {
+		errs[i] = c.handle(ctx, interruptions[i])
+	})
+	c.pending = lo.Filter(interruptions, func(_ cloudprovider.InterruptionEvent, i int) bool { return errs[i] != nil })
+	for i, err := range errs {
+		if err != nil {
+			log.FromContext(ctx).WithValues("provider-id", interruptions[i].ProviderID).Error(err, "failed handling interruption")
+		}
+	}
+	return reconcile.Result{RequeueAfter: pollPeriod}, nil
+}
+
+// handle deletes the NodeClaims of the interrupted instance
+func (c *Controller) handle(ctx context.Context, interruption cloudprovider.InterruptionEvent) error {
+	nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider, nodeclaimutils.ForProviderID(interruption.ProviderID))
+	if err != nil {
+		return err
+	}
+	for _, nodeClaim := range nodeClaims {
+		if !nodeClaim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		ctx := log.IntoContext(ctx, log.FromContext(ctx).WithValues(
+			"NodeClaim", klog.KRef("", nodeClaim.Name),
+			"provider-id", interruption.ProviderID,
+			"interruption", interruption.Kind,
+		))
+		if err := c.annotateTerminationTime(ctx, nodeClaim, interruption.Time); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if err := c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		log.FromContext(ctx).Info("deleting interrupted nodeclaim")
+		c.recorder.Publish(NodeClaimInterrupted(nodeClaim, interruption))
+		metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
+			metrics.ReasonLabel:       pretty.ToSnakeCase(string(interruption.Kind)),
+			metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
+			metrics.CapacityTypeLabel: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+		})
+	}
+	return nil
+}
+
+// annotateTerminationTime brings the NodeClaim's termination time forward to the interruption time, unless it's
+// already due to be terminated earlier
+func (c *Controller) annotateTerminationTime(ctx context.Context, nodeClaim *v1.NodeClaim, interruptionTime time.Time) error {
+	if interruptionTime.IsZero() {
+		return nil
+	}
+	if value, ok := nodeClaim.Annotations[v1.NodeClaimTerminationTimestampAnnotationKey]; ok {
+		if terminationTime, err := time.Parse(time.RFC3339, value); err == nil && !terminationTime.After(interruptionTime) {
+			return nil
+		}
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1.NodeClaimTerminationTimestampAnnotationKey: interruptionTime.Format(time.RFC3339)})
+	// The lifecycle and health controllers also set the termination time, so conflicts are retried rather than overwritten
+	return c.kubeClient.Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{}))
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.interruption").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
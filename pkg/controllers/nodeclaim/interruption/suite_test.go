@@ -0,0 +1,139 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/interruption"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var interruptionController *interruption.Controller
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Interruption")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	cloudProvider = fake.NewCloudProvider()
+	interruptionController = interruption.NewController(fakeClock, env.Client, cloudProvider, events.NewRecorder(&record.FakeRecorder{}))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	fakeClock.SetTime(time.Now())
+	ExpectCleanedUp(ctx, env.Client)
+	cloudProvider.Reset()
+})
+
+var _ = Describe("Interruption", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim = test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+				},
+				Finalizers: []string{v1.TerminationFinalizer},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+	})
+	It("should delete the NodeClaim of an interrupted instance", func() {
+		cloudProvider.Interruptions = []cloudprovider.InterruptionEvent{{ProviderID: nodeClaim.Status.ProviderID, Kind: cloudprovider.InterruptionSpot}}
+		ExpectSingletonReconciled(ctx, interruptionController)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1.NodeClaimTerminationTimestampAnnotationKey))
+	})
+	It("should bring the termination time forward to the interruption time", func() {
+		interruptionTime := fakeClock.Now().Add(2 * time.Minute).Truncate(time.Second)
+		cloudProvider.Interruptions = []cloudprovider.InterruptionEvent{{ProviderID: nodeClaim.Status.ProviderID, Kind: cloudprovider.InterruptionSpot, Time: interruptionTime}}
+		ExpectSingletonReconciled(ctx, interruptionController)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodeClaimTerminationTimestampAnnotationKey, interruptionTime.Format(time.RFC3339)))
+	})
+	It("should keep an earlier termination time", func() {
+		terminationTime := fakeClock.Now().Add(time.Minute).Format(time.RFC3339)
+		nodeClaim.Annotations = map[string]string{v1.NodeClaimTerminationTimestampAnnotationKey: terminationTime}
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		cloudProvider.Interruptions = []cloudprovider.InterruptionEvent{{ProviderID: nodeClaim.Status.ProviderID, Kind: cloudprovider.InterruptionScheduledMaintenance, Time: fakeClock.Now().Add(time.Hour)}}
+		ExpectSingletonReconciled(ctx, interruptionController)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodeClaimTerminationTimestampAnnotationKey, terminationTime))
+	})
+	It("should ignore interruptions for instances without a NodeClaim", func() {
+		cloudProvider.Interruptions = []cloudprovider.InterruptionEvent{{ProviderID: test.RandomProviderID(), Kind: cloudprovider.InterruptionInstanceStopping}}
+		ExpectSingletonReconciled(ctx, interruptionController)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+	})
+	It("should requeue when the CloudProvider fails to return interruption events", func() {
+		cloudProvider.NextInterruptionsErr = fmt.Errorf("failed")
+		_, err := interruptionController.Reconcile(ctx)
+		Expect(err).To(HaveOccurred())
+
+		cloudProvider.Interruptions = []cloudprovider.InterruptionEvent{{ProviderID: nodeClaim.Status.ProviderID, Kind: cloudprovider.InterruptionSpot}}
+		ExpectSingletonReconciled(ctx, interruptionController)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+	})
+	It("should poll for interruption events", func() {
+		result := ExpectSingletonReconciled(ctx, interruptionController)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// launchBatcher launches identical NodeClaims together through a CloudProvider's BatchCreate. The first NodeClaim of
+// its kind is launched on its own, and the identical NodeClaims that are reconciled while that launch is in flight are
+// queued up and launched together once it completes. This keeps a lone NodeClaim from waiting on a batching window,
+// while provisioning hundreds of identical NodeClaims at once only takes a handful of calls to the CloudProvider.
+// batchCreateTimeout bounds a single call to the CloudProvider's BatchCreate
+const batchCreateTimeout = 5 * time.Minute
+
+type launchBatcher struct {
+	creator cloudprovider.BatchCreator
+
+	mu       sync.Mutex
+	pending  map[string][]*launchRequest
+	inflight sets.Set[string]
+}
+
+type launchRequest struct {
+	nodeClaim *v1.NodeClaim
+	created   *v1.NodeClaim
+	err       error
+	done      chan struct{}
+}
+
+func newLaunchBatcher(creator cloudprovider.BatchCreator) *launchBatcher {
+	return &launchBatcher{
+		creator:  creator,
+		pending:  map[string][]*launchRequest{},
+		inflight: sets.New[string](),
+	}
+}
+
+// Create launches the NodeClaim, in a batch with any identical NodeClaims that are waiting to be launched
+func (b *launchBatcher) Create(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
+	key, err := batchKey(nodeClaim)
+	if err != nil {
+		return nil, err
+	}
+	req := &launchRequest{nodeClaim: nodeClaim, done: make(chan struct{})}
+	b.mu.Lock()
+	b.pending[key] = append(b.pending[key], req)
+	if !b.inflight.Has(key) {
+		b.inflight.Insert(key)
+		// Reconciles are only canceled when the manager shuts down, which should stop the batch as well
+		go b.launch(ctx, key)
+	}
+	b.mu.Unlock()
+	select {
+	case <-req.done:
+		return req.created, req.err
+	case <-ctx.Done():
+		// A NodeClaim that's already in an inflight batch may still be launched, but one that's only pending is dropped
+		b.mu.Lock()
+		b.pending[key] = lo.Without(b.pending[key], req)
+		b.mu.Unlock()
+		return nil, fmt.Errorf("waiting for batch launch, %w", ctx.Err())
+	}
+}
+
+// launch creates the pending NodeClaims for the key until there are none left
+func (b *launchBatcher) launch(ctx context.Context, key string) {
+	for {
+		b.mu.Lock()
+		reqs := b.pending[key]
+		delete(b.pending, key)
+		if len(reqs) == 0 {
+			b.inflight.Delete(key)
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+
+		nodeClaims := lo.Map(reqs, func(r *launchRequest, _ int) *v1.NodeClaim { return r.nodeClaim })
+		created, errs := b.batchCreate(ctx, nodeClaims)
+		for i, r := range reqs {
+			if len(created) != len(reqs) || len(errs) != len(reqs) {
+				r.err = fmt.Errorf("batch creating nodeclaims, expected %d results, got %d nodeclaims and %d errors", len(reqs), len(created), len(errs))
+			} else {
+				r.created, r.err = created[i], errs[i]
+			}
+			close(r.done)
+		}
+	}
+}
+
+// batchCreate bounds the CloudProvider's BatchCreate, so that a hung call can't hold up the NodeClaims waiting on it
+func (b *launchBatcher) batchCreate(ctx context.Context, nodeClaims []*v1.NodeClaim) ([]*v1.NodeClaim, []error) {
+	ctx, cancel := context.WithTimeout(ctx, batchCreateTimeout)
+	defer cancel()
+	return b.creator.BatchCreate(ctx, nodeClaims)
+}
+
+// batchKey identifies NodeClaims that are identical apart from their names, so that they can be launched together
+func batchKey(nodeClaim *v1.NodeClaim) (string, error) {
+	key, err := json.Marshal(struct {
+		Labels      map[string]string
+		Annotations map[string]string
+		Spec        v1.NodeClaimSpec
+	}{
+		Labels:      nodeClaim.Labels,
+		Annotations: nodeClaim.Annotations,
+		Spec:        nodeClaim.Spec,
+	})
+	if err != nil {
+		return "", fmt.Errorf("computing launch batch key, %w", err)
+	}
+	return string(key), nil
+}
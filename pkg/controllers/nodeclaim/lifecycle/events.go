@@ -22,6 +22,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 )
 
@@ -35,6 +36,16 @@ func InsufficientCapacityErrorEvent(nodeClaim *v1.NodeClaim, err error) events.E
 	}
 }
 
+func CapacityFallbackEvent(nodePool *v1.NodePool, stage v1.CapacityFallbackStage) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "CapacityFallback",
+		Message:        fmt.Sprintf("Falling back to %s launches after repeated insufficient capacity errors", stage),
+		DedupeValues:   []string{string(nodePool.UID), string(stage)},
+	}
+}
+
 func NodeClassNotReadyEvent(nodeClaim *v1.NodeClaim, err error) events.Event {
 	return events.Event{
 		InvolvedObject: nodeClaim,
@@ -44,3 +55,33 @@ func NodeClassNotReadyEvent(nodeClaim *v1.NodeClaim, err error) events.Event {
 		DedupeValues:   []string{string(nodeClaim.UID)},
 	}
 }
+
+func QuotaExceededEvent(nodeClaim *v1.NodeClaim, err error) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "QuotaExceeded",
+		Message:        fmt.Sprintf("NodeClaim %s event: %s", nodeClaim.Name, truncateMessage(err.Error())),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+func UnauthorizedEvent(nodeClaim *v1.NodeClaim, err error) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "Unauthorized",
+		Message:        fmt.Sprintf("NodeClaim %s event: %s", nodeClaim.Name, truncateMessage(err.Error())),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+func OfferingBlockedEvent(nodeClaim *v1.NodeClaim, key state.OfferingKey) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "OfferingBlocked",
+		Message:        fmt.Sprintf("Blocking offering %s/%s/%s after repeated registration failures", key.InstanceType, key.Zone, key.CapacityType),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
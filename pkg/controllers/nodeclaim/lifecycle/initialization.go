@@ -19,16 +19,20 @@ package lifecycle
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
@@ -36,6 +40,7 @@ import (
 )
 
 type Initialization struct {
+	clock      clock.Clock
 	kubeClient client.Client
 }
 
@@ -43,6 +48,7 @@ type Initialization struct {
 // a) its current status is set to Ready
 // b) all the startup taints have been removed from the node
 // c) all extended resources have been registered
+// Once initialized, the uninitialized startup taint is removed from the node alongside setting the initialized label.
 // This method handles both nil nodepools and nodes without extended resources gracefully.
 func (i *Initialization) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
 	if cond := nodeClaim.StatusConditions().Get(v1.ConditionTypeInitialized); !cond.IsUnknown() {
@@ -60,7 +66,8 @@ func (i *Initialization) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim)
 		return reconcile.Result{}, nil //nolint:nilerr
 	}
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("Node", klog.KRef("", node.Name)))
-	if nodeutils.GetCondition(node, corev1.NodeReady).Status != corev1.ConditionTrue {
+	ready := nodeutils.GetCondition(node, corev1.NodeReady)
+	if ready.Status != corev1.ConditionTrue {
 		nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeInitialized, "NodeNotReady", "Node status is NotReady")
 		return reconcile.Result{}, nil
 	}
@@ -77,13 +84,31 @@ func (i *Initialization) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim)
 		return reconcile.Result{}, nil
 	}
 	stored := node.DeepCopy()
-	node.Labels = lo.Assign(node.Labels, map[string]string{v1.NodeInitializedLabelKey: "true"})
+	node.Labels = lo.Assign(node.Labels, map[string]string{
+		v1.NodeInitializedLabelKey:   "true",
+		v1.NodeInitializedAtLabelKey: lo.CoalesceOrEmpty(node.Labels[v1.NodeInitializedAtLabelKey], strconv.FormatInt(i.clock.Now().Unix(), 10)),
+	})
+	node.Spec.Taints = lo.Reject(node.Spec.Taints, func(t corev1.Taint, _ int) bool {
+		return t.MatchTaint(&v1.UninitializedNoScheduleTaint)
+	})
 	if !equality.Semantic.DeepEqual(stored, node) {
-		if err = i.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the taint list
+		if err = i.kubeClient.Patch(ctx, node, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
 			return reconcile.Result{}, err
 		}
 	}
 	log.FromContext(ctx).WithValues("allocatable", node.Status.Allocatable).Info("initialized nodeclaim")
+	if !ready.LastTransitionTime.IsZero() {
+		metrics.NodeClaimsNodeReadyDurationSeconds.Observe(ready.LastTransitionTime.Sub(nodeClaim.CreationTimestamp.Time).Seconds(), map[string]string{
+			metrics.NodePoolLabel:       nodeClaim.Labels[v1.NodePoolLabelKey],
+			metrics.InstanceFamilyLabel: metrics.InstanceFamily(node.Labels[corev1.LabelInstanceTypeStable]),
+		})
+	}
 	nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeInitialized)
 	return reconcile.Result{}, nil
 }
@@ -102,10 +127,13 @@ func KnownEphemeralTaintsRemoved(node *corev1.Node) (*corev1.Taint, bool) {
 }
 
 // StartupTaintsRemoved returns true if there are no startup taints registered for the nodepool, or if all startup
-// taints have been removed from the node
+// taints have been removed from the node. The uninitialized taint is ignored since it's removed on initialization.
 func StartupTaintsRemoved(node *corev1.Node, nodeClaim *v1.NodeClaim) (*corev1.Taint, bool) {
 	if nodeClaim != nil {
 		for _, startupTaint := range nodeClaim.Spec.StartupTaints {
+			if startupTaint.MatchTaint(&v1.UninitializedNoScheduleTaint) {
+				continue
+			}
 			for i := range node.Spec.Taints {
 				// if the node still has a startup taint applied, it's not ready
 				if startupTaint.MatchTaint(&node.Spec.Taints[i]) {
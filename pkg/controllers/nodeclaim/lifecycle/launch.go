@@ -20,26 +20,45 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
+// defaultThrottleBackoff is how long launches are held off after the CloudProvider throttles a launch without saying
+// how long to wait
+const defaultThrottleBackoff = time.Second * 10
+
 type Launch struct {
+	clock         clock.Clock
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
 	cache         *cache.Cache // exists due to eventual consistency on the cache
 	recorder      events.Recorder
+	// batcher is set when the CloudProvider can launch identical NodeClaims together
+	batcher *launchBatcher
+
+	mu             sync.Mutex
+	throttledUntil time.Time
 }
 
 func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
@@ -57,8 +76,15 @@ func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconc
 	//     patching failed on the status. In this case, we use the in-memory cached value for the created NodeClaim.
 	//  2. It is a standard NodeClaim launch where we should call CloudProvider Create() and fill in details of the launched
 	//     NodeClaim into the NodeClaim CR.
+	//  3. It was created to adopt an existing instance, in which case we fill in the details of that instance rather
+	//     than launching a new one.
 	if ret, ok := l.cache.Get(string(nodeClaim.UID)); ok {
 		created = ret.(*v1.NodeClaim)
+	} else if providerID, ok := nodeClaim.Annotations[v1.AdoptedProviderIDAnnotationKey]; ok {
+		created, err = l.adoptNodeClaim(ctx, nodeClaim, providerID)
+	} else if wait := l.throttledFor(); wait > 0 {
+		// The CloudProvider is throttling launches, so we hold off on all of them rather than adding to its load
+		return reconcile.Result{RequeueAfter: wait}, nil
 	} else {
 		created, err = l.launchNodeClaim(ctx, nodeClaim)
 	}
@@ -73,7 +99,13 @@ func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconc
 }
 
 func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (*v1.NodeClaim, error) {
-	created, err := l.cloudProvider.Create(ctx, nodeClaim)
+	var created *v1.NodeClaim
+	var err error
+	if l.batcher != nil {
+		created, err = l.batcher.Create(ctx, nodeClaim)
+	} else {
+		created, err = l.cloudProvider.Create(ctx, nodeClaim)
+	}
 	if err != nil {
 		switch {
 		case cloudprovider.IsInsufficientCapacityError(err):
@@ -88,7 +120,40 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (
 				metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
 				metrics.CapacityTypeLabel: nodeClaim.Labels[v1.CapacityTypeLabelKey],
 			})
+			if offerings := insufficientCapacityOfferings(nodeClaim, err); len(offerings) > 0 {
+				l.cluster.BlockInsufficientCapacityOfferings(ctx, offerings...)
+				log.FromContext(ctx).WithValues("offerings", len(offerings), "duration", options.FromContext(ctx).UnavailableOfferingsTTL).V(1).Info("blocking offerings with insufficient capacity")
+			}
+			// The NodeClaim is already gone, so failing to record the error only delays the NodePool's fallback
+			if err = l.recordInsufficientCapacity(ctx, nodeClaim); err != nil {
+				log.FromContext(ctx).Error(err, "failed recording insufficient capacity")
+			}
 			return nil, nil
+		case cloudprovider.IsQuotaExceededError(err):
+			l.recorder.Publish(QuotaExceededEvent(nodeClaim, err))
+			log.FromContext(ctx).Error(err, "failed launching nodeclaim")
+			if deleteErr := l.kubeClient.Delete(ctx, nodeClaim); deleteErr != nil {
+				return nil, client.IgnoreNotFound(deleteErr)
+			}
+			metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
+				metrics.ReasonLabel:       "quota_exceeded",
+				metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
+				metrics.CapacityTypeLabel: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+			})
+			// The NodeClaim is already gone, so failing to record the error only means its pods may be scheduled to
+			// the NodePool again
+			if err = l.recordQuotaExceeded(ctx, nodeClaim, err); err != nil {
+				log.FromContext(ctx).Error(err, "failed recording quota exceeded")
+			}
+			return nil, nil
+		case cloudprovider.IsThrottledError(err):
+			l.throttle(err)
+			nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, "Throttled", truncateMessage(err.Error()))
+			return nil, fmt.Errorf("launching nodeclaim, %w", err)
+		case cloudprovider.IsUnauthorizedError(err):
+			l.recorder.Publish(UnauthorizedEvent(nodeClaim, err))
+			nodeClaim.StatusConditions().SetUnknownWithReason(v1.ConditionTypeLaunched, "Unauthorized", truncateMessage(err.Error()))
+			return nil, fmt.Errorf("launching nodeclaim, %w", err)
 		case cloudprovider.IsNodeClassNotReadyError(err):
 			log.FromContext(ctx).Error(err, "failed launching nodeclaim")
 			if err = l.kubeClient.Delete(ctx, nodeClaim); err != nil {
@@ -116,9 +181,144 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim) (
 		"zone", created.Labels[corev1.LabelTopologyZone],
 		"capacity-type", created.Labels[v1.CapacityTypeLabelKey],
 		"allocatable", created.Status.Allocatable).Info("launched nodeclaim")
+	if err := l.clearQuotaExceeded(ctx, nodeClaim); err != nil {
+		log.FromContext(ctx).Error(err, "failed clearing quota exceeded")
+	}
 	return created, nil
 }
 
+// throttledFor returns how much longer launches are held off after the CloudProvider throttled a launch
+func (l *Launch) throttledFor() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.throttledUntil.Sub(l.clock.Now())
+}
+
+// throttle holds off launches for as long as the CloudProvider asked, or defaultThrottleBackoff if it didn't say
+func (l *Launch) throttle(err error) {
+	var throttledErr *cloudprovider.ThrottledError
+	backoff := defaultThrottleBackoff
+	if errors.As(err, &throttledErr) && throttledErr.RetryAfter > 0 {
+		backoff = throttledErr.RetryAfter
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.clock.Now().Add(backoff); until.After(l.throttledUntil) {
+		l.throttledUntil = until
+	}
+}
+
+// insufficientCapacityOfferings returns the offerings that the CloudProvider ran out of capacity for. CloudProviders
+// that don't say fall back to the NodeClaim's offering, if its requirements only allow a single one.
+func insufficientCapacityOfferings(nodeClaim *v1.NodeClaim, err error) []cloudprovider.OfferingKey {
+	var icErr *cloudprovider.InsufficientCapacityError
+	if errors.As(err, &icErr) && len(icErr.Offerings) > 0 {
+		return icErr.Offerings
+	}
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	for _, key := range []string{corev1.LabelInstanceTypeStable, corev1.LabelTopologyZone, v1.CapacityTypeLabelKey} {
+		if reqs.Get(key).Len() != 1 {
+			return nil
+		}
+	}
+	return []cloudprovider.OfferingKey{{
+		InstanceType: reqs.Get(corev1.LabelInstanceTypeStable).Any(),
+		Zone:         reqs.Get(corev1.LabelTopologyZone).Any(),
+		CapacityType: reqs.Get(v1.CapacityTypeLabelKey).Any(),
+	}}
+}
+
+// recordQuotaExceeded marks the NodeClaim's NodePool with the QuotaExceeded condition, which keeps the provisioner from
+// launching from it for a while. The condition is set again if it's already true, so that it's held off for longer.
+func (l *Launch) recordQuotaExceeded(ctx context.Context, nodeClaim *v1.NodeClaim, quotaErr error) error {
+	name, ok := nodeClaim.Labels[v1.NodePoolLabelKey]
+	if !ok {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nodePool := &v1.NodePool{}
+		if err := l.kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodePool); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		stored := nodePool.DeepCopy()
+		// Clearing the condition first resets its transition time
+		_ = nodePool.StatusConditions().Clear(v1.ConditionTypeQuotaExceeded)
+		nodePool.StatusConditions().SetTrueWithReason(v1.ConditionTypeQuotaExceeded, "QuotaExceeded", truncateMessage(quotaErr.Error()))
+		if err := l.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		return nil
+	})
+}
+
+// clearQuotaExceeded removes the QuotaExceeded condition from the NodeClaim's NodePool once it launches successfully
+func (l *Launch) clearQuotaExceeded(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	name, ok := nodeClaim.Labels[v1.NodePoolLabelKey]
+	if !ok {
+		return nil
+	}
+	nodePool := &v1.NodePool{}
+	if err := l.kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodePool); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if nodePool.StatusConditions().Get(v1.ConditionTypeQuotaExceeded) == nil {
+		return nil
+	}
+	stored := nodePool.DeepCopy()
+	_ = nodePool.StatusConditions().Clear(v1.ConditionTypeQuotaExceeded)
+	return client.IgnoreNotFound(l.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})))
+}
+
+// adoptNodeClaim retrieves the existing instance that the NodeClaim was created to adopt. If the CloudProvider doesn't
+// know about the instance, the NodeClaim is deleted since there's nothing for it to adopt.
+func (l *Launch) adoptNodeClaim(ctx context.Context, nodeClaim *v1.NodeClaim, providerID string) (*v1.NodeClaim, error) {
+	retrieved, err := l.cloudProvider.Get(ctx, providerID)
+	if err != nil {
+		if cloudprovider.IsNodeClaimNotFoundError(err) {
+			log.FromContext(ctx).WithValues("provider-id", providerID).Error(err, "failed adopting instance")
+			return nil, client.IgnoreNotFound(l.kubeClient.Delete(ctx, nodeClaim))
+		}
+		return nil, fmt.Errorf("getting instance, %w", err)
+	}
+	log.FromContext(ctx).WithValues(
+		"provider-id", providerID,
+		"instance-type", retrieved.Labels[corev1.LabelInstanceTypeStable],
+		"zone", retrieved.Labels[corev1.LabelTopologyZone],
+		"capacity-type", retrieved.Labels[v1.CapacityTypeLabelKey]).Info("adopted instance")
+	return retrieved, nil
+}
+
+// recordInsufficientCapacity counts the insufficient capacity error towards the capacity fallback of the NodeClaim's
+// NodePool, if it has one
+func (l *Launch) recordInsufficientCapacity(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	name, ok := nodeClaim.Labels[v1.NodePoolLabelKey]
+	if !ok {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nodePool := &v1.NodePool{}
+		if err := l.kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodePool); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if nodePool.Spec.CapacityFallback == nil {
+			return nil
+		}
+		stored := nodePool.DeepCopy()
+		stage := nodePool.CapacityFallbackStage(l.clock.Now())
+		nodePool.RecordInsufficientCapacity(l.clock.Now())
+		// We use client.MergeFromWithOptimisticLock because the counts in the status are read-modify-write, and
+		// concurrent launch failures would otherwise overwrite each other's errors
+		if err := l.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if newStage := nodePool.CapacityFallbackStage(l.clock.Now()); newStage != stage {
+			log.FromContext(ctx).WithValues("NodePool", klog.KObj(nodePool), "stage", newStage).Info("falling back after repeated insufficient capacity errors")
+			l.recorder.Publish(CapacityFallbackEvent(nodePool, newStage))
+		}
+		return nil
+	})
+}
+
 func PopulateNodeClaimDetails(nodeClaim, retrieved *v1.NodeClaim) *v1.NodeClaim {
 	// These are ordered in priority order so that user-defined nodeClaim labels and requirements trump retrieved labels
 	// or the static nodeClaim labels
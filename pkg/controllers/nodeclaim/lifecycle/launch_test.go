@@ -18,14 +18,18 @@ package lifecycle_test
 
 import (
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
@@ -94,6 +98,109 @@ var _ = Describe("Launch", func() {
 		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
 		ExpectNotFound(ctx, env.Client, nodeClaim)
 	})
+	It("should record InsufficientCapacity against the nodepool's capacity fallback", func() {
+		nodePool := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{CapacityFallback: &v1.CapacityFallback{InsufficientCapacityThreshold: 2}}})
+		ExpectApplied(ctx, env.Client, nodePool)
+		for range 2 {
+			cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"))
+			nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+		}
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.CapacityFallback).ToNot(BeNil())
+		Expect(nodePool.Status.CapacityFallback.Stage).To(Equal(v1.CapacityFallbackStageCapacityOptimized))
+	})
+	It("should block the offerings that had insufficient capacity", func() {
+		key := cloudprovider.OfferingKey{InstanceType: "default-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeSpot}
+		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"), key)
+		nodeClaim := test.NodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		Expect(cluster.IsOfferingBlocked(key)).To(BeTrue())
+
+		fakeClock.Step(options.FromContext(ctx).UnavailableOfferingsTTL)
+		Expect(cluster.IsOfferingBlocked(key)).To(BeFalse())
+	})
+	It("should not block offerings with insufficient capacity when the unavailable offerings ttl is zero", func() {
+		noTTLCtx := options.ToContext(ctx, test.Options(test.OptionsFields{UnavailableOfferingsTTL: lo.ToPtr(time.Duration(0))}))
+		key := cloudprovider.OfferingKey{InstanceType: "default-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeSpot}
+		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"), key)
+		nodeClaim := test.NodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(noTTLCtx, env.Client, nodeClaimController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		Expect(cluster.IsOfferingBlocked(key)).To(BeFalse())
+	})
+	It("should block the nodeclaim's offering if the cloudprovider doesn't say which offerings had insufficient capacity", func() {
+		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"))
+		nodeClaim := test.NodeClaim(v1.NodeClaim{
+			Spec: v1.NodeClaimSpec{
+				Requirements: []v1.NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"default-instance-type"}}},
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}},
+					{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeOnDemand}}},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		Expect(cluster.IsOfferingBlocked(cloudprovider.OfferingKey{InstanceType: "default-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand})).To(BeTrue())
+	})
+	It("should delete the nodeclaim and mark its nodepool if QuotaExceeded is returned from the cloudprovider", func() {
+		nodePool := test.NodePool()
+		cloudProvider.NextCreateErr = cloudprovider.NewQuotaExceededError(fmt.Errorf("vcpu limit reached"))
+		nodeClaim := test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := ExpectStatusConditionExists(nodePool, v1.ConditionTypeQuotaExceeded)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("vcpu limit reached"))
+
+		// The condition is cleared once the nodepool launches successfully again
+		nodeClaim = test.NodeClaim(v1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}}})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeQuotaExceeded)).To(BeNil())
+	})
+	It("should hold off launching nodeclaims after the cloudprovider throttles a launch", func() {
+		cloudProvider.NextCreateErr = cloudprovider.NewThrottledError(fmt.Errorf("request limit exceeded"), time.Millisecond)
+		throttled := test.NodeClaim()
+		ExpectApplied(ctx, env.Client, throttled)
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, throttled)
+		throttled = ExpectExists(ctx, env.Client, throttled)
+		Expect(ExpectStatusConditionExists(throttled, v1.ConditionTypeLaunched).Reason).To(Equal("Throttled"))
+
+		nodeClaim := test.NodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		result := ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+
+		fakeClock.Step(time.Second)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeLaunched).Status).To(Equal(metav1.ConditionTrue))
+	})
+	It("should set the launched condition's reason if Unauthorized is returned from the cloudprovider", func() {
+		cloudProvider.NextCreateErr = cloudprovider.NewUnauthorizedError(fmt.Errorf("access denied"))
+		nodeClaim := test.NodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		condition := ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeLaunched)
+		Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+		Expect(condition.Reason).To(Equal("Unauthorized"))
+	})
 	It("should delete the nodeclaim if NodeClassNotReady is returned from the cloudprovider", func() {
 		cloudProvider.NextCreateErr = cloudprovider.NewNodeClassNotReadyError(fmt.Errorf("nodeClass isn't ready"))
 		nodeClaim := test.NodeClaim()
@@ -113,4 +220,87 @@ var _ = Describe("Launch", func() {
 		Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
 		Expect(condition.Message).To(Equal(conditionMessage))
 	})
+	Context("Adoption", func() {
+		It("should fill in the details of the adopted instance rather than launching one", func() {
+			existing := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelInstanceTypeStable: "default-instance-type"}},
+				Status:     v1.NodeClaimStatus{ProviderID: test.RandomProviderID()},
+			})
+			cloudProvider.CreatedNodeClaims[existing.Status.ProviderID] = existing
+			nodeClaim := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+					Annotations: map[string]string{v1.AdoptedProviderIDAnnotationKey: existing.Status.ProviderID},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+			Expect(nodeClaim.Status.ProviderID).To(Equal(existing.Status.ProviderID))
+			Expect(nodeClaim.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "default-instance-type"))
+			ExpectStatusConditionExists(nodeClaim, v1.ConditionTypeLaunched)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched).IsTrue()).To(BeTrue())
+		})
+		It("should delete the nodeclaim if the adopted instance doesn't exist", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+					Annotations: map[string]string{v1.AdoptedProviderIDAnnotationKey: test.RandomProviderID()},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		})
+	})
+	Context("Batch Create", func() {
+		It("should launch identical nodeclaims through the cloudprovider's batch create", func() {
+			other := test.NodePool()
+			nodeClaims := lo.Times(20, func(i int) *v1.NodeClaim {
+				return test.NodeClaim(v1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{v1.NodePoolLabelKey: lo.Ternary(i%2 == 0, nodePool.Name, other.Name)},
+					},
+				})
+			})
+			ExpectApplied(ctx, env.Client, nodePool, other)
+			for _, nodeClaim := range nodeClaims {
+				ExpectApplied(ctx, env.Client, nodeClaim)
+			}
+			workqueue.ParallelizeUntil(ctx, len(nodeClaims), len(nodeClaims), func(i int) {
+				defer GinkgoRecover()
+				ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaims[i])
+			})
+
+			for _, nodeClaim := range nodeClaims {
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched).IsTrue()).To(BeTrue())
+			}
+			Expect(cloudProvider.CreateCalls).To(HaveLen(20))
+			Expect(lo.SumBy(cloudProvider.BatchCreateCalls, func(batch []*v1.NodeClaim) int { return len(batch) })).To(Equal(20))
+			// NodeClaims for different nodepools aren't identical, so they're never launched together
+			for _, batch := range cloudProvider.BatchCreateCalls {
+				Expect(lo.Uniq(lo.Map(batch, func(nc *v1.NodeClaim, _ int) string { return nc.Labels[v1.NodePoolLabelKey] }))).To(HaveLen(1))
+			}
+		})
+		It("should launch a lone nodeclaim on its own", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched).IsTrue()).To(BeTrue())
+			Expect(cloudProvider.BatchCreateCalls).To(HaveLen(1))
+			Expect(cloudProvider.BatchCreateCalls[0]).To(HaveLen(1))
+			Expect(cloudProvider.BatchCreateCalls[0][0].Name).To(Equal(nodeClaim.Name))
+		})
+	})
 })
@@ -18,6 +18,7 @@ package lifecycle
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"k8s.io/utils/clock"
@@ -26,16 +27,24 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
 type Liveness struct {
-	clock      clock.Clock
-	kubeClient client.Client
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	recorder      events.Recorder
 }
 
 // registrationTTL is a heuristic time that we expect the node to register within
 // If we don't see the node within this time, then we should delete the NodeClaim and try again
+// NodeClaims can override this through spec.registrationTimeout
 const registrationTTL = time.Minute * 15
 
 func (l *Liveness) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
@@ -46,16 +55,23 @@ func (l *Liveness) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reco
 	if registered == nil {
 		return reconcile.Result{Requeue: true}, nil
 	}
+	registrationTimeout := registrationTTL
+	if nodeClaim.Spec.RegistrationTimeout != nil {
+		registrationTimeout = nodeClaim.Spec.RegistrationTimeout.Duration
+	}
 	// If the Registered statusCondition hasn't gone True during the TTL since we first updated it, we should terminate the NodeClaim
 	// NOTE: ttl has to be stored and checked in the same place since l.clock can advance after the check causing a race
-	if ttl := registrationTTL - l.clock.Since(registered.LastTransitionTime.Time); ttl > 0 {
+	if ttl := registrationTimeout - l.clock.Since(registered.LastTransitionTime.Time); ttl > 0 {
 		return reconcile.Result{RequeueAfter: ttl}, nil
 	}
+	if err := l.blockFailingOffering(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, err
+	}
 	// Delete the NodeClaim if we believe the NodeClaim won't register since we haven't seen the node
 	if err := l.kubeClient.Delete(ctx, nodeClaim); err != nil {
 		return reconcile.Result{}, client.IgnoreNotFound(err)
 	}
-	log.FromContext(ctx).V(1).WithValues("ttl", registrationTTL).Info("terminating due to registration ttl")
+	log.FromContext(ctx).V(1).WithValues("ttl", registrationTimeout).Info("terminating due to registration ttl")
 	metrics.NodeClaimsDisruptedTotal.Inc(map[string]string{
 		metrics.ReasonLabel:       "liveness",
 		metrics.NodePoolLabel:     nodeClaim.Labels[v1.NodePoolLabelKey],
@@ -64,3 +80,28 @@ func (l *Liveness) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reco
 
 	return reconcile.Result{}, nil
 }
+
+// blockFailingOffering records a registration timeout against the NodeClaim's offering when the cloud provider still
+// reports the instance as running. Instances that run but never join usually point at a problem with the offering
+// itself (e.g. bad userdata or networking), so once the offering hits the registration failure threshold we block it
+// so the relaunch picks a different offering rather than recreating the same failing configuration.
+func (l *Liveness) blockFailingOffering(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	threshold := options.FromContext(ctx).RegistrationFailureThreshold
+	if threshold <= 0 || nodeClaim.Status.ProviderID == "" {
+		return nil
+	}
+	if _, err := l.cloudProvider.Get(ctx, nodeClaim.Status.ProviderID); err != nil {
+		if cloudprovider.IsNodeClaimNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("getting instance, %w", err)
+	}
+	key := state.OfferingKeyForNodeClaim(nodeClaim)
+	if failures := l.cluster.MarkOfferingRegistrationFailure(key); failures < threshold {
+		return nil
+	}
+	l.cluster.BlockOffering(key)
+	log.FromContext(ctx).WithValues("instance-type", key.InstanceType, "zone", key.Zone, "capacity-type", key.CapacityType, "duration", state.OfferingBlockDuration).Info("blocking offering after repeated registration failures")
+	l.recorder.Publish(OfferingBlockedEvent(nodeClaim, key))
+	return nil
+}
@@ -20,12 +20,16 @@ import (
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
@@ -144,4 +148,96 @@ var _ = Describe("Liveness", func() {
 		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
 		ExpectNotFound(ctx, env.Client, nodeClaim)
 	})
+	It("should use the NodeClaim's registration timeout when it's set", func() {
+		nodeClaim := test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+			Spec: v1.NodeClaimSpec{
+				Resources: v1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("50Mi"),
+						corev1.ResourcePods:   resource.MustParse("5"),
+					},
+				},
+				RegistrationTimeout: &metav1.Duration{Duration: time.Hour},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		// The default registration ttl has passed, but the NodeClaim's registration timeout hasn't
+		fakeClock.Step(time.Minute * 20)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		ExpectExists(ctx, env.Client, nodeClaim)
+
+		fakeClock.Step(time.Minute * 45)
+		ExpectObjectReconciled(ctx, env.Client, nodeClaimController, nodeClaim)
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+	})
+	Context("Registration Failure Threshold", func() {
+		var nodeClaim *v1.NodeClaim
+		BeforeEach(func() {
+			nodeClaim = test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey: nodePool.Name,
+					},
+				},
+				Spec: v1.NodeClaimSpec{
+					Resources: v1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("50Mi"),
+							corev1.ResourcePods:   resource.MustParse("5"),
+						},
+					},
+				},
+			})
+		})
+		It("should block the offering once it hits the registration failure threshold", func() {
+			thresholdCtx := options.ToContext(ctx, test.Options(test.OptionsFields{RegistrationFailureThreshold: lo.ToPtr(1)}))
+			ExpectApplied(thresholdCtx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(thresholdCtx, env.Client, nodeClaimController, nodeClaim)
+			nodeClaim = ExpectExists(thresholdCtx, env.Client, nodeClaim)
+			key := state.OfferingKeyForNodeClaim(nodeClaim)
+			Expect(key.InstanceType).ToNot(BeEmpty())
+
+			fakeClock.Step(time.Minute * 20)
+			ExpectObjectReconciled(thresholdCtx, env.Client, nodeClaimController, nodeClaim)
+			ExpectFinalizersRemoved(thresholdCtx, env.Client, nodeClaim)
+			ExpectNotFound(thresholdCtx, env.Client, nodeClaim)
+			Expect(cluster.IsOfferingBlocked(key)).To(BeTrue())
+		})
+		It("should not block the offering before it hits the registration failure threshold", func() {
+			thresholdCtx := options.ToContext(ctx, test.Options(test.OptionsFields{RegistrationFailureThreshold: lo.ToPtr(2)}))
+			ExpectApplied(thresholdCtx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(thresholdCtx, env.Client, nodeClaimController, nodeClaim)
+			nodeClaim = ExpectExists(thresholdCtx, env.Client, nodeClaim)
+
+			fakeClock.Step(time.Minute * 20)
+			ExpectObjectReconciled(thresholdCtx, env.Client, nodeClaimController, nodeClaim)
+			ExpectFinalizersRemoved(thresholdCtx, env.Client, nodeClaim)
+			ExpectNotFound(thresholdCtx, env.Client, nodeClaim)
+			Expect(cluster.IsOfferingBlocked(state.OfferingKeyForNodeClaim(nodeClaim))).To(BeFalse())
+		})
+		It("should not block the offering when the instance no longer exists", func() {
+			thresholdCtx := options.ToContext(ctx, test.Options(test.OptionsFields{RegistrationFailureThreshold: lo.ToPtr(1)}))
+			ExpectApplied(thresholdCtx, env.Client, nodePool, nodeClaim)
+			ExpectObjectReconciled(thresholdCtx, env.Client, nodeClaimController, nodeClaim)
+			nodeClaim = ExpectExists(thresholdCtx, env.Client, nodeClaim)
+			cloudProvider.CreatedNodeClaims = map[string]*v1.NodeClaim{}
+
+			fakeClock.Step(time.Minute * 20)
+			ExpectObjectReconciled(thresholdCtx, env.Client, nodeClaimController, nodeClaim)
+			ExpectFinalizersRemoved(thresholdCtx, env.Client, nodeClaim)
+			ExpectNotFound(thresholdCtx, env.Client, nodeClaim)
+			Expect(cluster.IsOfferingBlocked(state.OfferingKeyForNodeClaim(nodeClaim))).To(BeFalse())
+		})
+	})
 })
@@ -64,7 +64,9 @@ func (r *Registration) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (
 	})
 	// check if sync succeeded but setting the registered status condition failed
 	// if sync succeeded, then the label will be present and the taint will be gone
-	if _, ok := node.Labels[v1.NodeRegisteredLabelKey]; !ok && !hasStartupTaint {
+	// adopted nodes joined the cluster before their NodeClaim existed, so they never had the taint
+	_, adopted := nodeClaim.Annotations[v1.AdoptedProviderIDAnnotationKey]
+	if _, ok := node.Labels[v1.NodeRegisteredLabelKey]; !ok && !hasStartupTaint && !adopted {
 		nodeClaim.StatusConditions().SetFalse(v1.ConditionTypeRegistered, "UnregisteredTaintNotFound", fmt.Sprintf("Invariant violated, %s taint must be present on Karpenter-managed nodes", v1.UnregisteredTaintKey))
 		return reconcile.Result{}, fmt.Errorf("missing required startup taint, %s", v1.UnregisteredTaintKey)
 	}
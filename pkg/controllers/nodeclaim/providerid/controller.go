@@ -0,0 +1,200 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerid
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+)
+
+// validationPeriod is how often we re-validate a NodeClaim's providerID against the CloudProvider
+const validationPeriod = 10 * time.Minute
+
+// Controller backfills a launched NodeClaim's providerID from the CloudProvider when it's missing and validates that
+// the providerID matches both the NodeClaim's Node and the CloudProvider's view of the instance. Mismatches (e.g. a
+// node name that was re-used by another instance) are surfaced through the ProviderIDMismatch condition, which keeps
+// the NodeClaim from being disrupted so that we never terminate the wrong instance.
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	recorder      events.Recorder
+
+	// NodeClaims are validated against a single listing of the CloudProvider's instances, keyed by providerID, which is
+	// refreshed once per validationPeriod rather than getting every NodeClaim's instance on every validation
+	mu        sync.Mutex
+	instances map[string]*v1.NodeClaim
+	listedAt  time.Time
+}
+
+// NewController is a constructor
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1.NodeClaim) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.providerid")
+	if !nodeclaimutils.IsManaged(nodeClaim, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	// The launch controller owns the providerID until the NodeClaim is launched
+	if !nodeClaim.DeletionTimestamp.IsZero() || !nodeClaim.StatusConditions().Get(v1.ConditionTypeLaunched).IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	stored := nodeClaim.DeepCopy()
+	if nodeClaim.Status.ProviderID == "" {
+		if err := c.backfill(ctx, nodeClaim); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if nodeClaim.Status.ProviderID != "" {
+		reason, message, err := c.validate(ctx, nodeClaim)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if reason != "" {
+			if !nodeClaim.StatusConditions().IsTrue(v1.ConditionTypeProviderIDMismatch) {
+				log.FromContext(ctx).WithValues("reason", reason).Error(fmt.Errorf("%s", message), "providerID mismatch")
+			}
+			nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeProviderIDMismatch, reason, message)
+			c.recorder.Publish(ProviderIDMismatchEvent(nodeClaim, message))
+		} else if nodeClaim.StatusConditions().Get(v1.ConditionTypeProviderIDMismatch) != nil {
+			_ = nodeClaim.StatusConditions().Clear(v1.ConditionTypeProviderIDMismatch)
+		}
+	}
+	if !equality.Semantic.DeepEqual(stored, nodeClaim) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the status condition list
+		if err := c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{RequeueAfter: validationPeriod}, nil
+}
+
+// backfill sets the providerID of a launched NodeClaim which lost it from the instance the CloudProvider reports for the
+// NodeClaim. We intentionally don't backfill from the Node since node names can be re-used by other instances.
+func (c *Controller) backfill(ctx context.Context, nodeClaim *v1.NodeClaim) error {
+	// The NodeClaim's instance may have launched since the last listing, so we always list again to backfill
+	instances, err := c.listInstances(ctx)
+	if err != nil {
+		return err
+	}
+	matches := lo.Filter(lo.Values(instances), func(nc *v1.NodeClaim, _ int) bool {
+		return nc.Name == nodeClaim.Name && nc.Status.ProviderID != ""
+	})
+	// We can't tell which instance is the right one if there's more than one, so we leave it for the garbage collector
+	if len(matches) != 1 {
+		return nil
+	}
+	nodeClaim.Status.ProviderID = matches[0].Status.ProviderID
+	log.FromContext(ctx).WithValues("provider-id", nodeClaim.Status.ProviderID).Info("backfilled nodeclaim providerID")
+	return nil
+}
+
+// validate returns a reason and message if the NodeClaim's providerID doesn't match its Node or the CloudProvider
+func (c *Controller) validate(ctx context.Context, nodeClaim *v1.NodeClaim) (string, string, error) {
+	if nodeClaim.Status.NodeName != "" {
+		node := &corev1.Node{}
+		if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodeClaim.Status.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			return "", "", fmt.Errorf("getting node, %w", err)
+		} else if err == nil && node.Spec.ProviderID != "" && node.Spec.ProviderID != nodeClaim.Status.ProviderID {
+			return "NodeProviderIDMismatch", fmt.Sprintf("Node %q has providerID %q, expected %q", node.Name, node.Spec.ProviderID, nodeClaim.Status.ProviderID), nil
+		}
+	}
+	instances, err := c.cachedInstances(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	cloudProviderNodeClaim, ok := instances[nodeClaim.Status.ProviderID]
+	// Instances which no longer exist are handled by the liveness and garbage collection controllers
+	if !ok {
+		return "", "", nil
+	}
+	if cloudProviderNodeClaim.Name != "" && cloudProviderNodeClaim.Name != nodeClaim.Name {
+		return "InstanceOwnerMismatch", fmt.Sprintf("Instance %q belongs to NodeClaim %q", nodeClaim.Status.ProviderID, cloudProviderNodeClaim.Name), nil
+	}
+	return "", "", nil
+}
+
+// cachedInstances returns the CloudProvider's instances keyed by providerID, listing them again if the last listing is
+// older than the validationPeriod
+func (c *Controller) cachedInstances(ctx context.Context) (map[string]*v1.NodeClaim, error) {
+	c.mu.Lock()
+	instances, listedAt := c.instances, c.listedAt
+	c.mu.Unlock()
+	if instances != nil && c.clock.Since(listedAt) < validationPeriod {
+		return instances, nil
+	}
+	return c.listInstances(ctx)
+}
+
+// listInstances lists the CloudProvider's instances keyed by providerID and caches them for later validations
+func (c *Controller) listInstances(ctx context.Context) (map[string]*v1.NodeClaim, error) {
+	cloudProviderNodeClaims, err := c.cloudProvider.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing cloudprovider nodeclaims, %w", err)
+	}
+	instances := lo.SliceToMap(lo.Filter(cloudProviderNodeClaims, func(nc *v1.NodeClaim, _ int) bool { return nc.Status.ProviderID != "" }),
+		func(nc *v1.NodeClaim) (string, *v1.NodeClaim) { return nc.Status.ProviderID, nc })
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instances, c.listedAt = instances, c.clock.Now()
+	return instances, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.providerid").
+		For(&v1.NodeClaim{}, builder.WithPredicates(nodeclaimutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(
+			&corev1.Node{},
+			nodeclaimutils.NodeEventHandler(c.kubeClient, c.cloudProvider),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,175 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerid_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/providerid"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var providerIDController *providerid.Controller
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var recorder *test.EventRecorder
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ProviderID")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(
+		test.WithCRDs(apis.CRDs...),
+		test.WithCRDs(v1alpha1.CRDs...),
+		test.WithFieldIndexers(test.NodeClaimProviderIDFieldIndexer(ctx), test.NodeProviderIDFieldIndexer(ctx)),
+	)
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	recorder = test.NewEventRecorder()
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	recorder.Reset()
+	// The controller caches the cloudprovider's instances, so each test starts with a new one
+	providerIDController = providerid.NewController(fakeClock, env.Client, cloudProvider, recorder)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("ProviderID", func() {
+	var nodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim = test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+			},
+		})
+		nodeClaim.StatusConditions().SetTrue(v1.ConditionTypeLaunched)
+	})
+	It("should not flag a NodeClaim which matches its node and instance", func() {
+		node := test.NodeClaimLinkedNode(nodeClaim)
+		nodeClaim.Status.NodeName = node.Name
+		cloudProvider.CreatedNodeClaims[nodeClaim.Status.ProviderID] = nodeClaim.DeepCopy()
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeProviderIDMismatch)).To(BeNil())
+	})
+	It("should flag a NodeClaim whose node has a different providerID", func() {
+		// The node name was re-used by a different instance
+		node := test.Node(test.NodeOptions{ProviderID: test.RandomProviderID()})
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().IsTrue(v1.ConditionTypeProviderIDMismatch)).To(BeTrue())
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeProviderIDMismatch).Reason).To(Equal("NodeProviderIDMismatch"))
+		Expect(recorder.Calls("ProviderIDMismatch")).To(Equal(1))
+	})
+	It("should flag a NodeClaim whose instance belongs to a different NodeClaim", func() {
+		other := test.NodeClaim(v1.NodeClaim{Status: v1.NodeClaimStatus{ProviderID: nodeClaim.Status.ProviderID}})
+		cloudProvider.CreatedNodeClaims[nodeClaim.Status.ProviderID] = other
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().IsTrue(v1.ConditionTypeProviderIDMismatch)).To(BeTrue())
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeProviderIDMismatch).Reason).To(Equal("InstanceOwnerMismatch"))
+	})
+	It("should validate NodeClaims against a listing of the cloudprovider's instances", func() {
+		nodeClaims := []*v1.NodeClaim{nodeClaim, test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+			},
+		})}
+		nodeClaims[1].StatusConditions().SetTrue(v1.ConditionTypeLaunched)
+		ExpectApplied(ctx, env.Client, nodePool)
+		for _, nc := range nodeClaims {
+			cloudProvider.CreatedNodeClaims[nc.Status.ProviderID] = nc.DeepCopy()
+			ExpectApplied(ctx, env.Client, nc)
+			ExpectObjectReconciled(ctx, env.Client, providerIDController, nc)
+		}
+		Expect(cloudProvider.GetCalls).To(BeEmpty())
+	})
+	It("should only list the cloudprovider's instances again after the validation period", func() {
+		cloudProvider.CreatedNodeClaims[nodeClaim.Status.ProviderID] = nodeClaim.DeepCopy()
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+
+		// The instance is taken over by a different NodeClaim, which isn't seen until the instances are listed again
+		cloudProvider.CreatedNodeClaims[nodeClaim.Status.ProviderID] = test.NodeClaim(v1.NodeClaim{Status: v1.NodeClaimStatus{ProviderID: nodeClaim.Status.ProviderID}})
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeProviderIDMismatch)).To(BeNil())
+
+		fakeClock.Step(10 * time.Minute)
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeProviderIDMismatch).Reason).To(Equal("InstanceOwnerMismatch"))
+	})
+	It("should clear the mismatch once the NodeClaim matches its node again", func() {
+		node := test.NodeClaimLinkedNode(nodeClaim)
+		nodeClaim.Status.NodeName = node.Name
+		nodeClaim.StatusConditions().SetTrueWithReason(v1.ConditionTypeProviderIDMismatch, "NodeProviderIDMismatch", "")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().Get(v1.ConditionTypeProviderIDMismatch)).To(BeNil())
+	})
+	It("should backfill a launched NodeClaim's providerID from the cloudprovider", func() {
+		providerID := nodeClaim.Status.ProviderID
+		cloudProvider.CreatedNodeClaims[providerID] = nodeClaim.DeepCopy()
+		nodeClaim.Status.ProviderID = ""
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, providerIDController, nodeClaim)
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Status.ProviderID).To(Equal(providerID))
+	})
+})
@@ -81,6 +81,10 @@ func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reco
 	stored := nodePool.DeepCopy()
 	// Determine resource usage and update nodepool.status.resources
 	nodePool.Status.Resources = c.resourceCountsFor(v1.NodePoolLabelKey, nodePool.Name)
+	// Determine the lifecycle phase of every node and update nodepool.status.nodeCount and nodepool.status.nodeCounts
+	nodePool.Status.NodeCounts = c.nodeCountsFor(v1.NodePoolLabelKey, nodePool.Name)
+	nodePool.Status.NodeCount = nodePool.Status.NodeCounts.Launching + nodePool.Status.NodeCounts.Registered +
+		nodePool.Status.NodeCounts.Initialized + nodePool.Status.NodeCounts.Draining
 	if !equality.Semantic.DeepEqual(stored, nodePool) {
 		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
 			return reconcile.Result{}, client.IgnoreNotFound(err)
@@ -111,6 +115,27 @@ func (c *Controller) resourceCountsFor(ownerLabel string, ownerName string) core
 	return res
 }
 
+func (c *Controller) nodeCountsFor(ownerLabel string, ownerName string) v1.NodeCounts {
+	counts := v1.NodeCounts{}
+	c.cluster.ForEachNode(func(n *state.StateNode) bool {
+		if n.Labels()[ownerLabel] != ownerName {
+			return true
+		}
+		switch {
+		case n.MarkedForDeletion():
+			counts.Draining++
+		case n.Initialized():
+			counts.Initialized++
+		case n.Registered():
+			counts.Registered++
+		default:
+			counts.Launching++
+		}
+		return true
+	})
+	return counts
+}
+
 func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("nodepool.counter").
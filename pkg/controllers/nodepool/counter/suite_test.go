@@ -238,6 +238,42 @@ var _ = Describe("Counter", func() {
 		expected[corev1.ResourceName("nodes")] = resource.MustParse("1")
 		Expect(nodePool.Status.Resources).To(BeComparableTo(expected))
 	})
+	It("should count nodes by lifecycle phase", func() {
+		nodeClaims, nodes := []*v1.NodeClaim{}, []*corev1.Node{}
+		for range 4 {
+			nc, n := test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					v1.NodePoolLabelKey: nodePool.Name,
+				}},
+			})
+			nodeClaims = append(nodeClaims, nc)
+			nodes = append(nodes, n)
+		}
+		// The first NodeClaim is launching and doesn't have a Node yet
+		ExpectApplied(ctx, env.Client, nodeClaims[0])
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaims[0]))
+		// The second Node is registered, but not initialized
+		nodes[1].Labels[v1.NodeRegisteredLabelKey] = "true"
+		ExpectApplied(ctx, env.Client, nodeClaims[1], nodes[1])
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaims[1]))
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(nodes[1]))
+		// The third and fourth Nodes are initialized, and the fourth Node is draining
+		ExpectApplied(ctx, env.Client, nodeClaims[2], nodes[2], nodeClaims[3], nodes[3])
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, nodes[2:], nodeClaims[2:])
+		cluster.MarkForDeletion(nodeClaims[3].Status.ProviderID)
+
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.NodeCount).To(BeNumerically("==", 4))
+		Expect(nodePool.Status.NodeCounts).To(Equal(v1.NodeCounts{
+			Launching:   1,
+			Registered:  1,
+			Initialized: 1,
+			Draining:    1,
+		}))
+		// Nodes that are draining aren't counted towards the resources of the NodePool
+		Expect(nodePool.Status.Resources[counter.ResourceNode]).To(Equal(resource.MustParse("3")))
+	})
 	It("should zero out the counter when all nodes are deleted", func() {
 		ExpectApplied(ctx, env.Client, node, nodeClaim)
 		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
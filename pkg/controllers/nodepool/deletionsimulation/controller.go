@@ -0,0 +1,108 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deletionsimulation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// Controller simulates deleting a NodePool when it's annotated with karpenter.sh/simulate-deletion. The outcome is
+// written to the karpenter.sh/deletion-simulation annotation and the trigger annotation is removed, so the simulation
+// can be run again by re-applying it.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+	recorder      events.Recorder
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+		provisioner:   provisioner,
+		recorder:      recorder,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.deletionsimulation")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	if _, ok := nodePool.Annotations[v1.SimulateDeletionAnnotationKey]; !ok {
+		return reconcile.Result{}, nil
+	}
+	// Simulating against a partially synced cluster state would under-report the pods that need to be rescheduled
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	simulation, err := c.provisioner.SimulateNodePoolDeletion(ctx, nodePool)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("simulating nodepool deletion, %w", err)
+	}
+	raw, err := json.Marshal(simulation)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("marshaling deletion simulation, %w", err)
+	}
+
+	stored := nodePool.DeepCopy()
+	nodePool.Annotations = lo.Assign(lo.OmitByKeys(nodePool.Annotations, []string{v1.SimulateDeletionAnnotationKey}), map[string]string{
+		v1.DeletionSimulationAnnotationKey: string(raw),
+	})
+	if err = c.kubeClient.Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	log.FromContext(ctx).WithValues("unschedulable-pods", len(simulation.UnschedulablePods), "replacement-cost", simulation.ReplacementCost).Info("simulated nodepool deletion")
+	c.recorder.Publish(DeletionSimulatedEvent(nodePool, simulation))
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.deletionsimulation").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,45 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deletionsimulation
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+// DeletionSimulatedEvent is a warning when removing the NodePool would leave pods without anywhere to schedule
+func DeletionSimulatedEvent(nodePool *v1.NodePool, simulation provisioning.NodePoolDeletionSimulation) events.Event {
+	evt := events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "DeletionSimulated",
+		Message: fmt.Sprintf("Deleting the NodePool would reschedule %d pod(s) from %d node(s) onto %d replacement NodeClaim(s) costing %.4f",
+			simulation.ReschedulablePods, simulation.Nodes, lo.Sum(lo.Values(simulation.ReplacementNodeClaims)), simulation.ReplacementCost),
+		DedupeValues: []string{string(nodePool.UID), simulation.SimulatedAt.String()},
+	}
+	if len(simulation.UnschedulablePods) > 0 {
+		evt.Type = corev1.EventTypeWarning
+		evt.Message = fmt.Sprintf("%s, %d pod(s) would be unschedulable", evt.Message, len(simulation.UnschedulablePods))
+	}
+	return evt
+}
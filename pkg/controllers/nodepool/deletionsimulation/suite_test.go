@@ -0,0 +1,159 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deletionsimulation_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/deletionsimulation"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+var recorder *test.EventRecorder
+var nodeClaimStateController *informer.NodeClaimController
+var nodeStateController *informer.NodeController
+var controller *deletionsimulation.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DeletionSimulation")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	recorder = test.NewEventRecorder()
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+	controller = deletionsimulation.NewController(env.Client, cloudProvider, cluster, prov, recorder)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	cluster.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("DeletionSimulation", func() {
+	var nodePool, otherNodePool *v1.NodePool
+	var nodeClaim *v1.NodeClaim
+	var node *corev1.Node
+	var pod *corev1.Pod
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		otherNodePool = test.NodePool()
+		nodeClaim, node = test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1.NodePoolLabelKey:            nodePool.Name,
+				corev1.LabelInstanceTypeStable: "default-instance-type",
+			}},
+			Status: v1.NodeClaimStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:  resource.MustParse("4"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		pod = test.Pod(test.PodOptions{
+			ObjectMeta:           metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", APIVersion: "apps/v1", Name: "rs", UID: "1234567890", Controller: lo.ToPtr(true)}}},
+			ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		})
+	})
+	expectSimulation := func() provisioning.NodePoolDeletionSimulation {
+		GinkgoHelper()
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.SimulateDeletionAnnotationKey))
+		Expect(nodePool.Annotations).To(HaveKey(v1.DeletionSimulationAnnotationKey))
+		simulation := provisioning.NodePoolDeletionSimulation{}
+		Expect(json.Unmarshal([]byte(nodePool.Annotations[v1.DeletionSimulationAnnotationKey]), &simulation)).To(Succeed())
+		return simulation
+	}
+	It("should report the replacement capacity other NodePools would launch", func() {
+		nodePool.Annotations = map[string]string{v1.SimulateDeletionAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, nodePool, otherNodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		simulation := expectSimulation()
+		Expect(simulation.Nodes).To(Equal(1))
+		Expect(simulation.ReschedulablePods).To(Equal(1))
+		Expect(simulation.UnschedulablePods).To(BeEmpty())
+		Expect(simulation.ReplacementNodeClaims).To(Equal(map[string]int{otherNodePool.Name: 1}))
+		Expect(simulation.ReplacementCost).To(BeNumerically(">", 0))
+		Expect(recorder.Calls("DeletionSimulated")).To(Equal(1))
+	})
+	It("should report pods that couldn't be scheduled without the NodePool", func() {
+		nodePool.Annotations = map[string]string{v1.SimulateDeletionAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		simulation := expectSimulation()
+		Expect(simulation.UnschedulablePods).To(ConsistOf(pod.Namespace + "/" + pod.Name))
+		Expect(simulation.ReplacementNodeClaims).To(BeEmpty())
+	})
+	It("should not simulate NodePools without the simulate-deletion annotation", func() {
+		ExpectApplied(ctx, env.Client, nodePool, otherNodePool, nodeClaim, node, pod)
+		ExpectManualBinding(ctx, env.Client, pod, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.DeletionSimulationAnnotationKey))
+	})
+})
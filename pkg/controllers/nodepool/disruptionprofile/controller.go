@@ -0,0 +1,118 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruptionprofile
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// Controller defaults the disruption settings of NodePools that select a disruption profile through the
+// karpenter.sh/disruption-profile label. Since Karpenter doesn't run admission webhooks, defaulting happens the first
+// time the NodePool is reconciled with a given profile. The applied profile is recorded in an annotation so that
+// values chosen by the NodePool's owner afterwards are never overwritten.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.disruptionprofile")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	profile, ok := nodePool.Labels[v1.DisruptionProfileLabelKey]
+	if !ok || nodePool.Annotations[v1.DisruptionProfileAnnotationKey] == profile {
+		return reconcile.Result{}, nil
+	}
+	profiles, err := options.ParseDisruptionProfiles(options.FromContext(ctx).DisruptionProfiles)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	consolidateAfter, ok := profiles[profile]
+	if !ok {
+		log.FromContext(ctx).V(1).WithValues("profile", profile).Info("ignoring unknown disruption profile")
+		return reconcile.Result{}, nil
+	}
+
+	stored := nodePool.DeepCopy()
+	// consolidateAfter is only defaulted while it holds the CRD default or the value set by the previously applied
+	// profile. A NodePool moved from one profile to another picks up the new profile's value, but a value that was
+	// explicitly set stays put.
+	previous, hasPrevious := profiles[nodePool.Annotations[v1.DisruptionProfileAnnotationKey]]
+	if isDefaultConsolidateAfter(nodePool.Spec.Disruption.ConsolidateAfter) ||
+		(hasPrevious && equalDurations(nodePool.Spec.Disruption.ConsolidateAfter, v1.MustParseNillableDuration(previous))) {
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration(consolidateAfter)
+	}
+	nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{
+		v1.DisruptionProfileAnnotationKey: profile,
+	})
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+		log.FromContext(ctx).WithValues("profile", profile, "consolidate-after", consolidateAfter).Info("applied disruption profile")
+	}
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.disruptionprofile").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// isDefaultConsolidateAfter returns true if consolidateAfter holds the value the CRD defaults it to
+func isDefaultConsolidateAfter(d v1.NillableDuration) bool {
+	return d.Duration != nil && *d.Duration == 0
+}
+
+func equalDurations(a, b v1.NillableDuration) bool {
+	if a.Duration == nil || b.Duration == nil {
+		return a.Duration == nil && b.Duration == nil
+	}
+	return *a.Duration == *b.Duration
+}
@@ -0,0 +1,132 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruptionprofile_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/disruptionprofile"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var controller *disruptionprofile.Controller
+var ctx context.Context
+var env *test.Environment
+var cp *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DisruptionProfile")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionProfiles: lo.ToPtr("batch=Never,service=5m")}))
+	cp = fake.NewCloudProvider()
+	controller = disruptionprofile.NewController(env.Client, cp)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("DisruptionProfile", func() {
+	var nodePool *v1.NodePool
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodePool.Labels = map[string]string{v1.DisruptionProfileLabelKey: "service"}
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("0s")
+	})
+	It("should default consolidateAfter from the NodePool's profile", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration)).To(Equal(5 * time.Minute))
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.DisruptionProfileAnnotationKey, "service"))
+	})
+	It("should default consolidateAfter to Never", func() {
+		nodePool.Labels[v1.DisruptionProfileLabelKey] = "batch"
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Spec.Disruption.ConsolidateAfter.Duration).To(BeNil())
+	})
+	It("should not overwrite an explicitly set consolidateAfter", func() {
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("30m")
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration)).To(Equal(30 * time.Minute))
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.DisruptionProfileAnnotationKey, "service"))
+	})
+	It("should not overwrite consolidateAfter once the profile has been applied", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("0s")
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration)).To(BeZero())
+	})
+	It("should apply the new profile's value when the NodePool switches profiles", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		nodePool.Labels[v1.DisruptionProfileLabelKey] = "batch"
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Spec.Disruption.ConsolidateAfter.Duration).To(BeNil())
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.DisruptionProfileAnnotationKey, "batch"))
+	})
+	It("should ignore unknown profiles", func() {
+		nodePool.Labels[v1.DisruptionProfileLabelKey] = "unknown"
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration)).To(BeZero())
+		Expect(nodePool.Annotations).ToNot(HaveKey(v1.DisruptionProfileAnnotationKey))
+	})
+	It("should ignore NodePools without a profile", func() {
+		nodePool.Labels = nil
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(lo.FromPtr(nodePool.Spec.Disruption.ConsolidateAfter.Duration)).To(BeZero())
+	})
+})
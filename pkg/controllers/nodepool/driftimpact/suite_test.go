@@ -0,0 +1,135 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driftimpact_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/driftimpact"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var driftImpactController *driftimpact.Controller
+var ctx context.Context
+var env *test.Environment
+var cp *fake.CloudProvider
+var recorder *test.EventRecorder
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DriftImpact")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	cp = fake.NewCloudProvider()
+	recorder = test.NewEventRecorder()
+	driftImpactController = driftimpact.NewController(env.Client, cp, recorder)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Drift Impact", func() {
+	var nodePool *v1.NodePool
+	var nodeClaims []*v1.NodeClaim
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaims = nil
+		for range 3 {
+			nodeClaims = append(nodeClaims, test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+					Annotations: map[string]string{
+						v1.NodePoolHashAnnotationKey:        nodePool.Hash(),
+						v1.NodePoolHashVersionAnnotationKey: v1.NodePoolHashVersion,
+					},
+				},
+			}))
+		}
+	})
+	It("should not set DriftPending when the NodePool hasn't changed", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+		ExpectObjectReconciled(ctx, env.Client, driftImpactController, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDriftPending)).To(BeNil())
+		Expect(recorder.Calls("DriftPending")).To(Equal(0))
+	})
+	It("should report how many nodes a NodePool edit will replace", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+		nodePool.Spec.Template.Labels = map[string]string{"team": "a"}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, driftImpactController, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().IsTrue(v1.ConditionTypeDriftPending)).To(BeTrue())
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDriftPending).Message).To(Equal("NodePool changes will replace 3 node(s)"))
+		Expect(recorder.DetectedEvent("NodePool changes will replace 3 node(s)")).To(BeTrue())
+	})
+	It("should report the placement group as the field that a NodePool edit changed", func() {
+		for _, nc := range nodeClaims {
+			nc.Annotations[v1.NodePoolFieldHashesAnnotationKey] = nodePool.FieldHashesAnnotation()
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+		nodePool.Spec.Template.Spec.PlacementGroup = "training"
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, driftImpactController, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDriftPending).Message).To(Equal("NodePool changes will replace 3 node(s), changed spec.template.spec.placementGroup"))
+	})
+	It("should ignore NodeClaims with a different hash version", func() {
+		nodeClaims[0].Annotations[v1.NodePoolHashVersionAnnotationKey] = "test"
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+		nodePool.Spec.Template.Labels = map[string]string{"team": "a"}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, driftImpactController, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDriftPending).Message).To(Equal("NodePool changes will replace 2 node(s)"))
+	})
+	It("should clear DriftPending once the drifted nodes are replaced", func() {
+		nodePool.StatusConditions().SetTrueWithReason(v1.ConditionTypeDriftPending, "NodePoolChanged", "NodePool changes will replace 3 node(s)")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+		ExpectObjectReconciled(ctx, env.Client, driftImpactController, nodePool)
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypeDriftPending)).To(BeNil())
+	})
+})
@@ -0,0 +1,146 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package effective
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// Controller renders the configuration that Karpenter runs each NodePool with into the NodePool's status. Operator-level
+// settings like flags and feature gates can change how a NodePool behaves without touching its spec, so the effective
+// configuration records them, along with the fields of the spec that they override.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.effective")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) {
+		return reconcile.Result{}, nil
+	}
+	stored := nodePool.DeepCopy()
+	nodePool.Status.Effective = Configuration(ctx, nodePool)
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the list of overrides
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// Configuration returns the configuration that Karpenter runs the NodePool with under the operator-level settings in ctx
+func Configuration(ctx context.Context, nodePool *v1.NodePool) *v1.EffectiveConfiguration {
+	opts := options.FromContext(ctx)
+	effective := &v1.EffectiveConfiguration{
+		BinPacking:                 lo.Ternary(nodePool.Spec.BinPacking != "", nodePool.Spec.BinPacking, v1.BinPackingStrategy(opts.BinPackingStrategy)),
+		DisruptionMode:             lo.Ternary(nodepoolutils.IsObserveOnly(ctx, nodePool), v1.DisruptionModeObserveOnly, v1.DisruptionModeEnforce),
+		PreferNewerGenerations:     opts.PreferNewerGenerations,
+		SpotToSpotConsolidation:    opts.FeatureGates.SpotToSpotConsolidation,
+		NodeRepair:                 opts.FeatureGates.NodeRepair,
+		ZoneRebalance:              opts.FeatureGates.ZoneRebalance,
+		MaxNodes:                   int64(opts.MaxNodes),
+		MaxVCPU:                    int64(opts.MaxVCPU),
+		PreemptionSimulation:       opts.PreemptionSimulation,
+		DisruptionAdmissionWebhook: opts.DisruptionAdmissionWebhookURL != "",
+		EmptinessIgnoredPods:       opts.EmptinessIgnoredPods,
+		EvictionOrder:              opts.DisruptionEvictionOrder,
+		MaxSchedulingSafetyMargin:  int64(opts.MaxSchedulingSafetyMargin),
+	}
+	if nodePool.Spec.BinPacking == "" {
+		effective.Overrides = append(effective.Overrides, v1.ConfigurationOverride{
+			Field:   "spec.binPacking",
+			Setting: "bin-packing-strategy",
+			Value:   string(effective.BinPacking),
+		})
+	}
+	if opts.DisruptionObserveOnly && nodePool.Spec.Disruption.Mode != v1.DisruptionModeObserveOnly {
+		effective.Overrides = append(effective.Overrides, v1.ConfigurationOverride{
+			Field:   "spec.disruption.mode",
+			Setting: "disruption-observe-only",
+			Value:   string(effective.DisruptionMode),
+		})
+	}
+	if consolidateAfter, ok := profileConsolidateAfter(ctx, nodePool); ok {
+		effective.Overrides = append(effective.Overrides, v1.ConfigurationOverride{
+			Field:   "spec.disruption.consolidateAfter",
+			Setting: "disruption-profiles",
+			Value:   consolidateAfter,
+		})
+	}
+	return effective
+}
+
+// profileConsolidateAfter returns the consolidateAfter of the disruption profile that the NodePool selects, if the
+// profile was applied to the NodePool and its consolidateAfter still holds the profile's value
+func profileConsolidateAfter(ctx context.Context, nodePool *v1.NodePool) (string, bool) {
+	profile, ok := nodePool.Labels[v1.DisruptionProfileLabelKey]
+	if !ok || nodePool.Annotations[v1.DisruptionProfileAnnotationKey] != profile {
+		return "", false
+	}
+	profiles, err := options.ParseDisruptionProfiles(options.FromContext(ctx).DisruptionProfiles)
+	if err != nil {
+		return "", false
+	}
+	consolidateAfter, ok := profiles[profile]
+	if !ok {
+		return "", false
+	}
+	current, applied := nodePool.Spec.Disruption.ConsolidateAfter.Duration, v1.MustParseNillableDuration(consolidateAfter).Duration
+	if (current == nil) != (applied == nil) || (current != nil && *current != *applied) {
+		return "", false
+	}
+	return consolidateAfter, true
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.effective").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
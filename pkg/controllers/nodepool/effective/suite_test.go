@@ -0,0 +1,196 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package effective_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/effective"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var controller *effective.Controller
+var ctx context.Context
+var env *test.Environment
+var cp *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Effective")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	cp = fake.NewCloudProvider()
+	controller = effective.NewController(env.Client, cp)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Effective", func() {
+	var nodePool *v1.NodePool
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+	})
+	It("should render the effective configuration of the NodePool", func() {
+		nodePool.Spec.BinPacking = v1.BinPackingStrategyLeastWaste
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective).To(Equal(&v1.EffectiveConfiguration{
+			BinPacking:     v1.BinPackingStrategyLeastWaste,
+			DisruptionMode: v1.DisruptionModeEnforce,
+		}))
+	})
+	It("should render the feature gates and flags that change the NodePool's behavior", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			PreferNewerGenerations: lo.ToPtr(true),
+			FeatureGates: test.FeatureGates{
+				SpotToSpotConsolidation: lo.ToPtr(true),
+				NodeRepair:              lo.ToPtr(true),
+			},
+		}))
+		nodePool.Spec.BinPacking = v1.BinPackingStrategyFewestNodes
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective.PreferNewerGenerations).To(BeTrue())
+		Expect(nodePool.Status.Effective.SpotToSpotConsolidation).To(BeTrue())
+		Expect(nodePool.Status.Effective.NodeRepair).To(BeTrue())
+		Expect(nodePool.Status.Effective.ZoneRebalance).To(BeFalse())
+		Expect(nodePool.Status.Effective.Overrides).To(BeEmpty())
+	})
+	It("should record the bin-packing strategy that NodePools without one are defaulted to", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{BinPackingStrategy: lo.ToPtr(string(v1.BinPackingStrategyLowestPrice))}))
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective.BinPacking).To(Equal(v1.BinPackingStrategyLowestPrice))
+		Expect(nodePool.Status.Effective.Overrides).To(ConsistOf(v1.ConfigurationOverride{
+			Field:   "spec.binPacking",
+			Setting: "bin-packing-strategy",
+			Value:   string(v1.BinPackingStrategyLowestPrice),
+		}))
+	})
+	It("should record that disruption is observe-only for every NodePool", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionObserveOnly: lo.ToPtr(true)}))
+		nodePool.Spec.BinPacking = v1.BinPackingStrategyFewestNodes
+		nodePool.Spec.Disruption.Mode = v1.DisruptionModeEnforce
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective.DisruptionMode).To(Equal(v1.DisruptionModeObserveOnly))
+		Expect(nodePool.Status.Effective.Overrides).To(ConsistOf(v1.ConfigurationOverride{
+			Field:   "spec.disruption.mode",
+			Setting: "disruption-observe-only",
+			Value:   string(v1.DisruptionModeObserveOnly),
+		}))
+	})
+	It("should not record an override for NodePools that are already observe-only", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionObserveOnly: lo.ToPtr(true)}))
+		nodePool.Spec.BinPacking = v1.BinPackingStrategyFewestNodes
+		nodePool.Spec.Disruption.Mode = v1.DisruptionModeObserveOnly
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective.DisruptionMode).To(Equal(v1.DisruptionModeObserveOnly))
+		Expect(nodePool.Status.Effective.Overrides).To(BeEmpty())
+	})
+	It("should render the fleet limits and the flags that change how the NodePool is provisioned and disrupted", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			MaxNodes:                      lo.ToPtr(10),
+			MaxVCPU:                       lo.ToPtr(64),
+			PreemptionSimulation:          lo.ToPtr(true),
+			DisruptionAdmissionWebhookURL: lo.ToPtr("http://localhost:8080/admit"),
+			EmptinessIgnoredPods:          lo.ToPtr("monitoring:app=agent"),
+			DisruptionEvictionOrder:       lo.ToPtr("Guaranteed"),
+			MaxSchedulingSafetyMargin:     lo.ToPtr(20),
+		}))
+		nodePool.Spec.BinPacking = v1.BinPackingStrategyFewestNodes
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective.MaxNodes).To(BeNumerically("==", 10))
+		Expect(nodePool.Status.Effective.MaxVCPU).To(BeNumerically("==", 64))
+		Expect(nodePool.Status.Effective.PreemptionSimulation).To(BeTrue())
+		Expect(nodePool.Status.Effective.DisruptionAdmissionWebhook).To(BeTrue())
+		Expect(nodePool.Status.Effective.EmptinessIgnoredPods).To(Equal("monitoring:app=agent"))
+		Expect(nodePool.Status.Effective.EvictionOrder).To(Equal("Guaranteed"))
+		Expect(nodePool.Status.Effective.MaxSchedulingSafetyMargin).To(BeNumerically("==", 20))
+		Expect(nodePool.Status.Effective.Overrides).To(BeEmpty())
+	})
+	It("should record the consolidateAfter that a disruption profile defaulted the NodePool to", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionProfiles: lo.ToPtr("batch=10m")}))
+		nodePool.Labels = lo.Assign(nodePool.Labels, map[string]string{v1.DisruptionProfileLabelKey: "batch"})
+		nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.DisruptionProfileAnnotationKey: "batch"})
+		nodePool.Spec.BinPacking = v1.BinPackingStrategyFewestNodes
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("10m")
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective.Overrides).To(ConsistOf(v1.ConfigurationOverride{
+			Field:   "spec.disruption.consolidateAfter",
+			Setting: "disruption-profiles",
+			Value:   "10m",
+		}))
+	})
+	It("should not record a disruption profile override once the NodePool's consolidateAfter is changed", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionProfiles: lo.ToPtr("batch=10m")}))
+		nodePool.Labels = lo.Assign(nodePool.Labels, map[string]string{v1.DisruptionProfileLabelKey: "batch"})
+		nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1.DisruptionProfileAnnotationKey: "batch"})
+		nodePool.Spec.BinPacking = v1.BinPackingStrategyFewestNodes
+		nodePool.Spec.Disruption.ConsolidateAfter = v1.MustParseNillableDuration("5m")
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective.Overrides).To(BeEmpty())
+	})
+	It("should ignore NodePools which aren't managed by this instance of Karpenter", func() {
+		nodePool.Spec.Template.Spec.NodeClassRef = &v1.NodeClassReference{
+			Group: "karpenter.test.sh",
+			Kind:  "UnmanagedNodeClass",
+			Name:  "default",
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Status.Effective).To(BeNil())
+	})
+})
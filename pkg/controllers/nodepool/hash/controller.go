@@ -18,6 +18,7 @@ package hash
 
 import (
 	"context"
+	"time"
 
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
@@ -27,6 +28,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -50,6 +52,10 @@ func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudPr
 	}
 }
 
+// RehashBatchSize is the most NodeClaims that are re-hashed for a NodePool in a single reconcile. Re-hashing is staged
+// in batches after an upgrade that changes the hash version, so that a large fleet isn't patched all at once.
+var RehashBatchSize = 50
+
 // Reconcile the resource
 func (c *Controller) Reconcile(ctx context.Context, np *v1.NodePool) (reconcile.Result, error) {
 	ctx = injection.WithControllerName(ctx, "nodepool.hash")
@@ -59,14 +65,21 @@ func (c *Controller) Reconcile(ctx context.Context, np *v1.NodePool) (reconcile.
 
 	stored := np.DeepCopy()
 
-	if np.Annotations[v1.NodePoolHashVersionAnnotationKey] != v1.NodePoolHashVersion {
-		if err := c.updateNodeClaimHash(ctx, np); err != nil {
-			return reconcile.Result{}, err
-		}
+	// NodeClaims are re-hashed on every reconcile rather than only when the NodePool's hash version changes, since
+	// NodeClaims can still be launched with a stale hash version by an older controller during a rolling upgrade
+	remaining, err := c.updateNodeClaimHash(ctx, np)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// The NodePool keeps its previous hash and hash version until all of its NodeClaims are re-hashed. NodeClaims whose
+	// hash version doesn't match the NodePool's aren't evaluated for static drift, so neither the re-hashed nor the
+	// pending NodeClaims are considered drifted while the re-hash is staged.
+	if remaining > 0 {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
 	}
 	np.Annotations = lo.Assign(np.Annotations, map[string]string{
-		v1.NodePoolHashAnnotationKey:        np.Hash(),
-		v1.NodePoolHashVersionAnnotationKey: v1.NodePoolHashVersion,
+		v1.NodePoolHashAnnotationKey:        nodepoolutils.Hash(np),
+		v1.NodePoolHashVersionAnnotationKey: nodepoolutils.HashVersion(),
 	})
 
 	if !equality.Semantic.DeepEqual(stored, np) {
@@ -81,6 +94,9 @@ func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 	return controllerruntime.NewControllerManagedBy(m).
 		Named("nodepool.hash").
 		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&v1.NodeClaim{}, nodepoolutils.NodeClaimEventHandler(), builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return o.GetAnnotations()[v1.NodePoolHashVersionAnnotationKey] != nodepoolutils.HashVersion()
+		}))).
 		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
 		Complete(reconcile.AsReconciler(m.GetClient(), c))
 }
@@ -89,36 +105,39 @@ func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 // The `nodepool-hash` annotation on the NodePool will be updated, due to the breaking change, making the `nodepool-hash` on the NodeClaim different from
 // NodePool. Since, we cannot rely on the `nodepool-hash` on the NodeClaims, due to the breaking change, we will need to re-calculate the hash and update the annotation.
 // For more information on the Drift Hash Versioning: https://github.com/kubernetes-sigs/karpenter/blob/main/designs/drift-hash-versioning.md
-func (c *Controller) updateNodeClaimHash(ctx context.Context, np *v1.NodePool) error {
+// At most RehashBatchSize NodeClaims are updated per call, and the number of NodeClaims that still need to be re-hashed is returned.
+func (c *Controller) updateNodeClaimHash(ctx context.Context, np *v1.NodePool) (int, error) {
 	nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider, nodeclaimutils.ForNodePool(np.Name))
 	if err != nil {
-		return err
+		return 0, err
 	}
+	nodeClaims = lo.Filter(nodeClaims, func(nc *v1.NodeClaim, _ int) bool {
+		return nc.Annotations[v1.NodePoolHashVersionAnnotationKey] != nodepoolutils.HashVersion()
+	})
+	batch := nodeClaims[:lo.Min([]int{len(nodeClaims), RehashBatchSize})]
 
-	errs := make([]error, len(nodeClaims))
-	for i, nc := range nodeClaims {
+	errs := make([]error, len(batch))
+	for i, nc := range batch {
 		stored := nc.DeepCopy()
+		nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
+			v1.NodePoolHashVersionAnnotationKey: nodepoolutils.HashVersion(),
+		})
 
-		if nc.Annotations[v1.NodePoolHashVersionAnnotationKey] != v1.NodePoolHashVersion {
+		// Any NodeClaim that is already drifted will remain drifted if the karpenter.sh/nodepool-hash-version doesn't match
+		// Since the hashing mechanism has changed we will not be able to determine if the drifted status of the NodeClaim has changed
+		if nc.StatusConditions().Get(v1.ConditionTypeDrifted) == nil {
 			nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
-				v1.NodePoolHashVersionAnnotationKey: v1.NodePoolHashVersion,
+				v1.NodePoolHashAnnotationKey:        nodepoolutils.Hash(np),
+				v1.NodePoolFieldHashesAnnotationKey: nodepoolutils.FieldHashesAnnotation(np),
 			})
+		}
 
-			// Any NodeClaim that is already drifted will remain drifted if the karpenter.sh/nodepool-hash-version doesn't match
-			// Since the hashing mechanism has changed we will not be able to determine if the drifted status of the NodeClaim has changed
-			if nc.StatusConditions().Get(v1.ConditionTypeDrifted) == nil {
-				nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
-					v1.NodePoolHashAnnotationKey: np.Hash(),
-				})
-			}
-
-			if !equality.Semantic.DeepEqual(stored, nc) {
-				if err := c.kubeClient.Patch(ctx, nc, client.MergeFrom(stored)); err != nil {
-					errs[i] = client.IgnoreNotFound(err)
-				}
+		if !equality.Semantic.DeepEqual(stored, nc) {
+			if err := c.kubeClient.Patch(ctx, nc, client.MergeFrom(stored)); err != nil {
+				errs[i] = client.IgnoreNotFound(err)
 			}
 		}
 	}
 
-	return multierr.Combine(errs...)
+	return len(nodeClaims) - len(batch), multierr.Combine(errs...)
 }
@@ -250,4 +250,66 @@ var _ = Describe("Static Drift Hash", func() {
 		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, "123456"))
 		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashVersionAnnotationKey, v1.NodePoolHashVersion))
 	})
+	It("should re-hash nodeclaims with a stale hash version when the nodepool hash version is up to date", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		// A NodeClaim launched by a controller that hasn't been upgraded yet
+		nodeClaim := test.NodeClaim(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+				Annotations: map[string]string{
+					v1.NodePoolHashAnnotationKey:        "123456",
+					v1.NodePoolHashVersionAnnotationKey: "test",
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.NodePoolHashVersionAnnotationKey, v1.NodePoolHashVersion))
+	})
+	It("should stage re-hashing nodeclaims across reconciles", func() {
+		rehashBatchSize := hash.RehashBatchSize
+		hash.RehashBatchSize = 1
+		DeferCleanup(func() { hash.RehashBatchSize = rehashBatchSize })
+
+		nodePool.Annotations = map[string]string{
+			v1.NodePoolHashAnnotationKey:        "abceduefed",
+			v1.NodePoolHashVersionAnnotationKey: "test",
+		}
+		nodeClaims := lo.Times(2, func(_ int) *v1.NodeClaim {
+			return test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+					Annotations: map[string]string{
+						v1.NodePoolHashAnnotationKey:        "123456",
+						v1.NodePoolHashVersionAnnotationKey: "test",
+					},
+				},
+			})
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1])
+
+		// The NodePool keeps its previous hash version until all of its NodeClaims are re-hashed
+		result := ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		Expect(result.RequeueAfter).ToNot(BeZero())
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.NodePoolHashVersionAnnotationKey, "test"))
+		Expect(lo.CountBy(nodeClaims, func(nc *v1.NodeClaim) bool {
+			return ExpectExists(ctx, env.Client, nc).Annotations[v1.NodePoolHashVersionAnnotationKey] == v1.NodePoolHashVersion
+		})).To(Equal(1))
+
+		result = ExpectObjectReconciled(ctx, env.Client, nodePoolController, nodePool)
+		Expect(result.RequeueAfter).To(BeZero())
+		for _, nc := range nodeClaims {
+			Expect(ExpectExists(ctx, env.Client, nc).Annotations).To(HaveKeyWithValue(v1.NodePoolHashVersionAnnotationKey, v1.NodePoolHashVersion))
+		}
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.NodePoolHashAnnotationKey, nodePool.Hash()))
+		Expect(nodePool.Annotations).To(HaveKeyWithValue(v1.NodePoolHashVersionAnnotationKey, v1.NodePoolHashVersion))
+	})
 })
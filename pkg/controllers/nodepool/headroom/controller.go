@@ -0,0 +1,111 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroom
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// replenishPeriod is how often the headroom is checked. Pods binding to a vacant node don't trigger a reconcile, so
+// this bounds how long it takes to replace headroom that's been filled.
+const replenishPeriod = 15 * time.Second
+
+// Controller launches vacant nodes for NodePools with fewer than their spec.headroom
+type Controller struct {
+	clock         clock.Clock
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+}
+
+// NewController is a constructor
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, provisioner *provisioning.Provisioner) *Controller {
+	return &Controller{
+		clock:         clk,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+		provisioner:   provisioner,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.headroom")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) || nodePool.Spec.Headroom == "" || !nodePool.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	// NodeClaims that are still launching count as vacant, even when they were launched for pending pods. Once those
+	// pods bind, the headroom they used is replenished.
+	vacant, occupied := 0, 0
+	c.cluster.ForEachNode(func(n *state.StateNode) bool {
+		if n.Labels()[v1.NodePoolLabelKey] != nodePool.Name || n.MarkedForDeletion() {
+			return true
+		}
+		if n.Vacant(c.clock) {
+			vacant++
+		} else {
+			occupied++
+		}
+		return true
+	})
+	headroom := nodePool.GetHeadroom(occupied)
+	nodeClaims, err := c.provisioner.PlaceholderNodeClaims(ctx, nodePool, headroom-vacant)
+	if len(nodeClaims) > 0 {
+		names, createErr := c.provisioner.CreateNodeClaims(ctx, nodeClaims, provisioning.WithReason(metrics.HeadroomReason))
+		if createErr != nil {
+			return reconcile.Result{}, fmt.Errorf("launching headroom, %w", createErr)
+		}
+		log.FromContext(ctx).WithValues("vacant-nodes", vacant, "headroom", headroom, "NodeClaims", lo.Compact(names)).Info("launched nodeclaims for nodepool headroom")
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: replenishPeriod}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.headroom").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&v1.NodeClaim{}, nodepoolutils.NodeClaimEventHandler()).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
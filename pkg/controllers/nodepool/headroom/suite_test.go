@@ -0,0 +1,150 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroom_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/headroom"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+var nodeClaimStateController *informer.NodeClaimController
+var nodeStateController *informer.NodeController
+var podStateController *informer.PodController
+var controller *headroom.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Headroom")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	podStateController = informer.NewPodController(env.Client, cluster)
+	prov := provisioning.NewProvisioner(env.Client, test.NewEventRecorder(), cloudProvider, cluster, fakeClock)
+	controller = headroom.NewController(fakeClock, env.Client, cloudProvider, cluster, prov)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	cluster.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Headroom", func() {
+	var nodePool *v1.NodePool
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Headroom: "2"}})
+	})
+	nodePoolNodeClaims := func() []*v1.NodeClaim {
+		GinkgoHelper()
+		return lo.Filter(ExpectNodeClaims(ctx, env.Client), func(nc *v1.NodeClaim, _ int) bool {
+			return nc.Labels[v1.NodePoolLabelKey] == nodePool.Name
+		})
+	}
+	// occupiedNode returns a node in the NodePool with a pod bound to it
+	occupiedNode := func() (*v1.NodeClaim, *corev1.Node, *corev1.Pod) {
+		GinkgoHelper()
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1.NodePoolLabelKey:            nodePool.Name,
+				corev1.LabelInstanceTypeStable: "default-instance-type",
+			}},
+		})
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		return nodeClaim, node, pod
+	}
+	It("should launch vacant nodes up to the NodePool's headroom", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(2))
+
+		// The launching NodeClaims count as vacant, so nothing else is launched
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(2))
+	})
+	It("should replenish the headroom once pods fill it", func() {
+		nodeClaim, node, pod := occupiedNode()
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		ExpectReconcileSucceeded(ctx, podStateController, client.ObjectKeyFromObject(pod))
+
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(3))
+	})
+	It("should scale a percentage headroom with the nodes that have pods", func() {
+		nodePool.Spec.Headroom = "50%"
+		nodeClaim, node, pod := occupiedNode()
+		nodeClaim2, node2, pod2 := occupiedNode()
+		nodeClaim3, node3, pod3 := occupiedNode()
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod, nodeClaim2, node2, pod2, nodeClaim3, node3, pod3)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node, node2, node3}, []*v1.NodeClaim{nodeClaim, nodeClaim2, nodeClaim3})
+		for _, p := range []*corev1.Pod{pod, pod2, pod3} {
+			ExpectReconcileSucceeded(ctx, podStateController, client.ObjectKeyFromObject(p))
+		}
+
+		// 50% of 3 occupied nodes rounds up to 2 vacant nodes
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(5))
+	})
+	It("should not launch nodes for NodePools without headroom", func() {
+		nodePool.Spec.Headroom = ""
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,99 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minnodes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// Controller launches nodes for NodePools with fewer nodes than their spec.minNodes
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
+	provisioner   *provisioning.Provisioner
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, provisioner *provisioning.Provisioner) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		cluster:       cluster,
+		provisioner:   provisioner,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.minnodes")
+	if !nodepoolutils.IsManaged(nodePool, c.cloudProvider) || lo.FromPtr(nodePool.Spec.MinNodes) == 0 || !nodePool.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	// Counting against a partially synced cluster state would launch nodes that the NodePool already has
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	// Nodes that are being disrupted have either been replaced already or are being removed by consolidation, which
+	// leaves the NodePool at its minimum
+	nodes := 0
+	c.cluster.ForEachNode(func(n *state.StateNode) bool {
+		if n.Labels()[v1.NodePoolLabelKey] == nodePool.Name && !n.MarkedForDeletion() {
+			nodes++
+		}
+		return true
+	})
+	nodeClaims, err := c.provisioner.PlaceholderNodeClaims(ctx, nodePool, int(lo.FromPtr(nodePool.Spec.MinNodes))-nodes)
+	if len(nodeClaims) > 0 {
+		names, createErr := c.provisioner.CreateNodeClaims(ctx, nodeClaims, provisioning.WithReason(metrics.MinNodesReason))
+		if createErr != nil {
+			return reconcile.Result{}, fmt.Errorf("launching min nodes, %w", createErr)
+		}
+		log.FromContext(ctx).WithValues("nodes", nodes, "min-nodes", lo.FromPtr(nodePool.Spec.MinNodes), "NodeClaims", lo.Compact(names)).Info("launched nodeclaims for nodepool minimum")
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.minnodes").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		Watches(&v1.NodeClaim{}, nodepoolutils.NodeClaimEventHandler()).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
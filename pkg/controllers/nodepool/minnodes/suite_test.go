@@ -0,0 +1,158 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minnodes_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/minnodes"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+var nodeClaimStateController *informer.NodeClaimController
+var nodeStateController *informer.NodeController
+var controller *minnodes.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MinNodes")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	prov := provisioning.NewProvisioner(env.Client, test.NewEventRecorder(), cloudProvider, cluster, fakeClock)
+	controller = minnodes.NewController(env.Client, cloudProvider, cluster, prov)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	cluster.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("MinNodes", func() {
+	var nodePool *v1.NodePool
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{MinNodes: lo.ToPtr[int32](3)}})
+	})
+	nodePoolNodeClaims := func() []*v1.NodeClaim {
+		GinkgoHelper()
+		return lo.Filter(ExpectNodeClaims(ctx, env.Client), func(nc *v1.NodeClaim, _ int) bool {
+			return nc.Labels[v1.NodePoolLabelKey] == nodePool.Name
+		})
+	}
+	It("should launch nodes up to the NodePool's minimum", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(3))
+
+		// The NodeClaims are tracked by cluster state as soon as they're created, so nothing else is launched
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(3))
+	})
+	It("should only launch the nodes that the NodePool is short of", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1.NodePoolLabelKey:            nodePool.Name,
+				corev1.LabelInstanceTypeStable: "default-instance-type",
+			}},
+			Status: v1.NodeClaimStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:  resource.MustParse("4"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(3))
+	})
+	It("should replace nodes that are being disrupted", func() {
+		nodeClaim, node := test.NodeClaimAndNode(v1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				v1.NodePoolLabelKey:            nodePool.Name,
+				corev1.LabelInstanceTypeStable: "default-instance-type",
+			}},
+		})
+		nodePool.Spec.MinNodes = lo.ToPtr[int32](1)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*corev1.Node{node}, []*v1.NodeClaim{nodeClaim})
+		cluster.MarkForDeletion(nodeClaim.Status.ProviderID)
+
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(2))
+	})
+	It("should launch nodes that tolerate the NodePool's taints", func() {
+		nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(HaveLen(3))
+	})
+	It("should not launch nodes beyond the NodePool's limits", func() {
+		nodePool.Spec.Limits = v1.Limits{corev1.ResourceCPU: resource.MustParse("0")}
+		ExpectApplied(ctx, env.Client, nodePool)
+		_ = ExpectObjectReconcileFailed(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(BeEmpty())
+	})
+	It("should not launch nodes for NodePools without a minimum", func() {
+		nodePool.Spec.MinNodes = nil
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(nodePoolNodeClaims()).To(BeEmpty())
+	})
+})
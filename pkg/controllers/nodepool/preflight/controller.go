@@ -0,0 +1,140 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+const (
+	// successTTL is how long a passing result is trusted before the checks are run again. Results are keyed on the
+	// NodePool template and NodeClass generation, so any change to either triggers a new check regardless.
+	successTTL = time.Hour
+	// failureTTL is how long we wait before re-running checks which failed
+	failureTTL = time.Minute
+)
+
+// Controller runs the CloudProvider's preflight checks for each NodePool/NodeClass combination and surfaces the
+// result on the NodePool's PreflightChecksSucceeded status condition
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	results       *cache.Cache
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+		results:       cache.New(successTTL, time.Minute),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodepool.preflight")
+	stored := nodePool.DeepCopy()
+
+	result, err := c.check(ctx, nodePool)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		// We use client.MergeFromWithOptimisticLock because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// Here, we are updating the status condition list
+		if err = c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFromWithOptions(stored, client.MergeFromWithOptimisticLock{})); client.IgnoreNotFound(err) != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	}
+	return result, nil
+}
+
+func (c *Controller) check(ctx context.Context, nodePool *v1.NodePool) (reconcile.Result, error) {
+	checker, ok := cloudprovider.As[cloudprovider.PreflightChecker](c.cloudProvider)
+	if !ok {
+		nodePool.StatusConditions().SetTrue(v1.ConditionTypePreflightChecksSucceeded)
+		return reconcile.Result{}, nil
+	}
+	nodeClass, ok := lo.Find(c.cloudProvider.GetSupportedNodeClasses(), func(nc status.Object) bool {
+		return object.GVK(nc).GroupKind() == nodePool.Spec.Template.Spec.NodeClassRef.GroupKind()
+	})
+	if !ok {
+		// Ignore NodePools which aren't using a supported NodeClass.
+		return reconcile.Result{}, nil
+	}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		// A missing NodeClass is surfaced through the NodeClassReady condition, we'll check once it's created
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	key := fmt.Sprintf("%s/%s/%s/%d", nodePool.UID, nodePool.Hash(), nodeClass.GetUID(), nodeClass.GetGeneration())
+	if cached, ok := c.results.Get(key); ok {
+		setCondition(nodePool, cached)
+		return reconcile.Result{}, nil
+	}
+	err := checker.PreflightChecks(ctx, nodePool, nodeClass)
+	setCondition(nodePool, err)
+	if err != nil {
+		c.results.Set(key, err, failureTTL)
+		return reconcile.Result{RequeueAfter: failureTTL}, nil
+	}
+	c.results.SetDefault(key, nil)
+	return reconcile.Result{RequeueAfter: successTTL}, nil
+}
+
+func setCondition(nodePool *v1.NodePool, result any) {
+	if err, ok := result.(error); ok && err != nil {
+		nodePool.StatusConditions().SetFalse(v1.ConditionTypePreflightChecksSucceeded, "PreflightChecksFailed", err.Error())
+		return
+	}
+	nodePool.StatusConditions().SetTrue(v1.ConditionTypePreflightChecksSucceeded)
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	b := controllerruntime.NewControllerManagedBy(m).
+		Named("nodepool.preflight").
+		For(&v1.NodePool{}, builder.WithPredicates(nodepoolutils.IsManagedPredicateFuncs(c.cloudProvider))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10})
+	for _, nodeClass := range c.cloudProvider.GetSupportedNodeClasses() {
+		b.Watches(nodeClass, nodepoolutils.NodeClassEventHandler(c.kubeClient))
+	}
+	return b.Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
@@ -0,0 +1,123 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/preflight"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var (
+	controller    *preflight.Controller
+	ctx           context.Context
+	env           *test.Environment
+	cloudProvider *fake.CloudProvider
+	nodePool      *v1.NodePool
+	nodeClass     *v1alpha1.TestNodeClass
+)
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Preflight")
+}
+
+var _ = BeforeSuite(func() {
+	cloudProvider = fake.NewCloudProvider()
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	controller = preflight.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = Describe("Preflight", func() {
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodePool.StatusConditions().SetUnknown(v1.ConditionTypePreflightChecksSucceeded)
+		nodeClass = test.NodeClass(v1alpha1.TestNodeClass{
+			ObjectMeta: metav1.ObjectMeta{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name},
+		})
+		nodePool.Spec.Template.Spec.NodeClassRef.Group = object.GVK(nodeClass).Group
+		nodePool.Spec.Template.Spec.NodeClassRef.Kind = object.GVK(nodeClass).Kind
+	})
+	It("should not run preflight checks until the nodeClass exists", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(cloudProvider.PreflightCalls).To(Equal(0))
+		Expect(nodePool.StatusConditions().Get(v1.ConditionTypePreflightChecksSucceeded).IsUnknown()).To(BeTrue())
+	})
+	It("should set the PreflightChecksSucceeded status condition to true if preflight checks pass", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(cloudProvider.PreflightCalls).To(Equal(1))
+		Expect(nodePool.StatusConditions().IsTrue(v1.ConditionTypePreflightChecksSucceeded)).To(BeTrue())
+		Expect(nodePool.StatusConditions().IsTrue(status.ConditionReady)).To(BeTrue())
+	})
+	It("should set the PreflightChecksSucceeded status condition to false if preflight checks fail", func() {
+		cloudProvider.PreflightErr = fmt.Errorf("image not found")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := nodePool.StatusConditions().Get(v1.ConditionTypePreflightChecksSucceeded)
+		Expect(condition.IsFalse()).To(BeTrue())
+		Expect(condition.Message).To(Equal("image not found"))
+		Expect(nodePool.StatusConditions().Get(status.ConditionReady).IsFalse()).To(BeTrue())
+	})
+	It("should cache preflight results for the same nodePool and nodeClass", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(cloudProvider.PreflightCalls).To(Equal(1))
+	})
+	It("should re-run preflight checks when the nodePool template changes", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		nodePool.Spec.Template.Labels = map[string]string{"test-key": "test-value"}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodePool)
+		Expect(cloudProvider.PreflightCalls).To(Equal(2))
+	})
+})
@@ -18,6 +18,7 @@ package provisioning
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
@@ -31,6 +32,7 @@ import (
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
@@ -52,9 +54,9 @@ func NewPodController(kubeClient client.Client, provisioner *Provisioner, cluste
 
 // Reconcile the resource
 func (c *PodController) Reconcile(ctx context.Context, p *corev1.Pod) (reconcile.Result, error) {
-	ctx = injection.WithControllerName(ctx, "provisioner.trigger.pod") //nolint:ineffassign,staticcheck
+	ctx = injection.WithControllerName(ctx, "provisioner.trigger.pod")
 
-	if !pod.IsProvisionable(p) {
+	if !pod.IsProvisionableBy(p, strings.Split(options.FromContext(ctx).AllowedSchedulerNames, ",")) {
 		return reconcile.Result{}, nil
 	}
 	c.provisioner.Trigger(p.UID)
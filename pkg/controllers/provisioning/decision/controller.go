@@ -0,0 +1,88 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decision
+
+import (
+	"context"
+	"time"
+
+	"github.com/awslabs/operatorpkg/singleton"
+	"go.uber.org/multierr"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// Controller garbage collects ProvisioningDecisions once they're older than --provisioning-decision-ttl
+type Controller struct {
+	clock      clock.Clock
+	kubeClient client.Client
+}
+
+func NewController(clk clock.Clock, kubeClient client.Client) *Controller {
+	return &Controller{
+		clock:      clk,
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "provisioningdecision.garbagecollection")
+
+	ttl := options.FromContext(ctx).ProvisioningDecisionTTL
+	// Decisions aren't listed while recording is disabled so that their informer is never started
+	if ttl <= 0 {
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+	decisions := &v1alpha1.ProvisioningDecisionList{}
+	if err := c.kubeClient.List(ctx, decisions, client.InNamespace(Namespace())); err != nil {
+		return reconcile.Result{}, err
+	}
+	var errs []error
+	deleted := 0
+	for i := range decisions.Items {
+		if c.clock.Since(decisions.Items[i].CreationTimestamp.Time) < ttl {
+			continue
+		}
+		if err := c.kubeClient.Delete(ctx, &decisions.Items[i]); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, err)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		log.FromContext(ctx).V(1).WithValues("count", deleted).Info("garbage collected provisioningdecisions")
+	}
+	if err := multierr.Combine(errs...); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("provisioningdecision.garbagecollection").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
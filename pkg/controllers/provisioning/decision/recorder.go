@@ -0,0 +1,148 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decision
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/utils/env"
+)
+
+// maxItems and maxInstanceTypes bound the lists in a ProvisioningDecision, matching the validation on the CRD
+const (
+	maxItems         = 100
+	maxInstanceTypes = 60
+)
+
+// Namespace is the namespace that ProvisioningDecisions are created in, which is the namespace Karpenter runs in
+func Namespace() string {
+	return env.WithDefaultString("SYSTEM_NAMESPACE", "kube-system")
+}
+
+// Recorder writes a ProvisioningDecision for every provisioning and disruption decision when decision recording is
+// enabled with --provisioning-decision-ttl. Recording is best effort, a failure to record never fails the decision.
+type Recorder struct {
+	kubeClient client.Client
+}
+
+func NewRecorder(kubeClient client.Client) *Recorder {
+	return &Recorder{kubeClient: kubeClient}
+}
+
+// RecordProvisioning records NodeClaims launched for pending pods. nodeClaimNames are the names returned from creating
+// results.NewNodeClaims, with an empty name for each NodeClaim that failed to be created.
+func (r *Recorder) RecordProvisioning(ctx context.Context, reason string, results scheduler.Results, nodeClaimNames []string) {
+	pods := lo.FlatMap(results.NewNodeClaims, func(n *scheduler.NodeClaim, _ int) []*corev1.Pod { return n.Pods })
+	spec := v1alpha1.ProvisioningDecisionSpec{
+		Type:       v1alpha1.DecisionTypeProvisioning,
+		Reason:     reason,
+		NodeClaims: nodeClaims(results.NewNodeClaims, nodeClaimNames),
+		Rejections: rejections(results.PodErrors),
+	}
+	spec.Pods, spec.TruncatedPods = decisionPods(pods)
+	r.record(ctx, spec)
+}
+
+// RecordDisruption records the disruption of candidates, and the replacements launched for the pods rescheduled off
+// of them
+func (r *Recorder) RecordDisruption(ctx context.Context, reason v1.DisruptionReason, candidates []*state.StateNode, pods []*corev1.Pod,
+	replacements []*scheduler.NodeClaim, nodeClaimNames []string) {
+	spec := v1alpha1.ProvisioningDecisionSpec{
+		Type:   v1alpha1.DecisionTypeDisruption,
+		Reason: strings.ToLower(string(reason)),
+		Candidates: lo.Slice(lo.Map(candidates, func(c *state.StateNode, _ int) string {
+			return c.Name()
+		}), 0, maxItems),
+		NodeClaims: nodeClaims(replacements, nodeClaimNames),
+	}
+	spec.Pods, spec.TruncatedPods = decisionPods(pods)
+	r.record(ctx, spec)
+}
+
+func (r *Recorder) record(ctx context.Context, spec v1alpha1.ProvisioningDecisionSpec) {
+	if options.FromContext(ctx).ProvisioningDecisionTTL <= 0 {
+		return
+	}
+	decision := &v1alpha1.ProvisioningDecision{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: strings.ToLower(string(spec.Type)) + "-",
+			Namespace:    Namespace(),
+		},
+		Spec: spec,
+	}
+	if err := r.kubeClient.Create(ctx, decision); err != nil {
+		log.FromContext(ctx).Error(err, "failed recording provisioningdecision")
+	}
+}
+
+func decisionPods(pods []*corev1.Pod) ([]v1alpha1.DecisionPod, int) {
+	return lo.Map(lo.Slice(pods, 0, maxItems), func(p *corev1.Pod, _ int) v1alpha1.DecisionPod {
+		return v1alpha1.DecisionPod{Namespace: p.Namespace, Name: p.Name}
+	}), max(len(pods)-maxItems, 0)
+}
+
+func nodeClaims(nodeClaims []*scheduler.NodeClaim, names []string) []v1alpha1.DecisionNodeClaim {
+	return lo.Map(lo.Slice(nodeClaims, 0, maxItems), func(n *scheduler.NodeClaim, i int) v1alpha1.DecisionNodeClaim {
+		its := n.InstanceTypeOptions.OrderByPrice(n.Requirements)
+		decision := v1alpha1.DecisionNodeClaim{
+			NodePool: n.NodePoolName,
+			InstanceTypes: lo.Map(lo.Slice(its, 0, maxInstanceTypes), func(it *cloudprovider.InstanceType, _ int) string {
+				return it.Name
+			}),
+		}
+		if i < len(names) {
+			decision.Name = names[i]
+		}
+		// The instance types are ordered by their cheapest compatible offering, so the first with one is the cheapest
+		if it, ok := lo.Find(its, func(it *cloudprovider.InstanceType) bool {
+			return it.Offerings.Available().HasCompatible(n.Requirements)
+		}); ok {
+			decision.CheapestInstanceType = it.Name
+			decision.EstimatedPrice = strconv.FormatFloat(it.Offerings.Available().Compatible(n.Requirements).Cheapest().Price, 'f', -1, 64)
+		}
+		return decision
+	})
+}
+
+// rejections are sorted so that the pods recorded are stable when there are more rejections than can be recorded
+func rejections(podErrors map[*corev1.Pod]error) []v1alpha1.DecisionRejection {
+	rejected := lo.MapToSlice(podErrors, func(p *corev1.Pod, err error) v1alpha1.DecisionRejection {
+		return v1alpha1.DecisionRejection{
+			DecisionPod: v1alpha1.DecisionPod{Namespace: p.Namespace, Name: p.Name},
+			Reason:      err.Error(),
+		}
+	})
+	sort.Slice(rejected, func(i, j int) bool {
+		return rejected[i].Namespace+"/"+rejected[i].Name < rejected[j].Namespace+"/"+rejected[j].Name
+	})
+	return lo.Slice(rejected, 0, maxItems)
+}
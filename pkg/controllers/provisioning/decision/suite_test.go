@@ -0,0 +1,206 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decision_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/decision"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var recorder *decision.Recorder
+var controller *decision.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ProvisioningDecision")
+}
+
+var _ = BeforeSuite(func() {
+	fakeClock = clock.NewFakeClock(time.Now())
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...))
+	recorder = decision.NewRecorder(env.Client)
+	controller = decision.NewController(fakeClock, env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ProvisioningDecisionTTL: lo.ToPtr(time.Hour)}))
+})
+
+var _ = AfterEach(func() {
+	fakeClock.SetTime(time.Now())
+	Expect(env.Client.DeleteAllOf(ctx, &v1alpha1.ProvisioningDecision{}, client.InNamespace(decision.Namespace()))).To(Succeed())
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func listDecisions() []v1alpha1.ProvisioningDecision {
+	GinkgoHelper()
+	decisions := &v1alpha1.ProvisioningDecisionList{}
+	Expect(env.Client.List(ctx, decisions, client.InNamespace(decision.Namespace()))).To(Succeed())
+	return decisions.Items
+}
+
+func newNodeClaim(nodePool *v1.NodePool, pods ...*corev1.Pod) *scheduler.NodeClaim {
+	cheap := fake.NewInstanceType(fake.InstanceTypeOptions{Name: "cheap", Offerings: []cloudprovider.Offering{{
+		Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1"}),
+		Price:        0.25,
+		Available:    true,
+	}}})
+	expensive := fake.NewInstanceType(fake.InstanceTypeOptions{Name: "expensive", Offerings: []cloudprovider.Offering{{
+		Requirements: scheduling.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1"}),
+		Price:        1.5,
+		Available:    true,
+	}}})
+	nodeClaim := &scheduler.NodeClaim{NodeClaimTemplate: *scheduler.NewNodeClaimTemplate(nodePool)}
+	nodeClaim.InstanceTypeOptions = cloudprovider.InstanceTypes{expensive, cheap}
+	nodeClaim.Requirements = scheduling.NewRequirements()
+	nodeClaim.Pods = pods
+	return nodeClaim
+}
+
+var _ = Describe("Recorder", func() {
+	var nodePool *v1.NodePool
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+	})
+	It("should record a provisioning decision", func() {
+		pod := test.UnschedulablePod()
+		rejected := test.UnschedulablePod()
+		recorder.RecordProvisioning(ctx, "provisioned", scheduler.Results{
+			NewNodeClaims: []*scheduler.NodeClaim{newNodeClaim(nodePool, pod)},
+			PodErrors:     map[*corev1.Pod]error{rejected: errors.New("incompatible with nodepool")},
+		}, []string{"default-abcde"})
+
+		decisions := listDecisions()
+		Expect(decisions).To(HaveLen(1))
+		Expect(decisions[0].Spec.Type).To(Equal(v1alpha1.DecisionTypeProvisioning))
+		Expect(decisions[0].Spec.Reason).To(Equal("provisioned"))
+		Expect(decisions[0].Spec.Pods).To(ConsistOf(v1alpha1.DecisionPod{Namespace: pod.Namespace, Name: pod.Name}))
+		Expect(decisions[0].Spec.Rejections).To(ConsistOf(v1alpha1.DecisionRejection{
+			DecisionPod: v1alpha1.DecisionPod{Namespace: rejected.Namespace, Name: rejected.Name},
+			Reason:      "incompatible with nodepool",
+		}))
+		Expect(decisions[0].Spec.NodeClaims).To(ConsistOf(v1alpha1.DecisionNodeClaim{
+			Name:                 "default-abcde",
+			NodePool:             nodePool.Name,
+			InstanceTypes:        []string{"cheap", "expensive"},
+			CheapestInstanceType: "cheap",
+			EstimatedPrice:       "0.25",
+		}))
+	})
+	It("should record a disruption decision", func() {
+		pod := test.Pod()
+		recorder.RecordDisruption(ctx, v1.DisruptionReasonUnderutilized, nil, []*corev1.Pod{pod},
+			[]*scheduler.NodeClaim{newNodeClaim(nodePool)}, []string{"default-abcde"})
+
+		decisions := listDecisions()
+		Expect(decisions).To(HaveLen(1))
+		Expect(decisions[0].Spec.Type).To(Equal(v1alpha1.DecisionTypeDisruption))
+		Expect(decisions[0].Spec.Reason).To(Equal("underutilized"))
+		Expect(decisions[0].Spec.Pods).To(ConsistOf(v1alpha1.DecisionPod{Namespace: pod.Namespace, Name: pod.Name}))
+		Expect(decisions[0].Spec.NodeClaims).To(HaveLen(1))
+	})
+	It("should truncate the pods that triggered a decision", func() {
+		pods := lo.Times(150, func(_ int) *corev1.Pod { return test.UnschedulablePod() })
+		recorder.RecordProvisioning(ctx, "provisioned", scheduler.Results{
+			NewNodeClaims: []*scheduler.NodeClaim{newNodeClaim(nodePool, pods...)},
+		}, []string{"default-abcde"})
+
+		decisions := listDecisions()
+		Expect(decisions).To(HaveLen(1))
+		Expect(decisions[0].Spec.Pods).To(HaveLen(100))
+		Expect(decisions[0].Spec.TruncatedPods).To(Equal(50))
+	})
+	It("should leave the name empty for NodeClaims that failed to be created", func() {
+		recorder.RecordProvisioning(ctx, "provisioned", scheduler.Results{
+			NewNodeClaims: []*scheduler.NodeClaim{newNodeClaim(nodePool, test.UnschedulablePod())},
+		}, []string{""})
+
+		decisions := listDecisions()
+		Expect(decisions).To(HaveLen(1))
+		Expect(decisions[0].Spec.NodeClaims[0].Name).To(BeEmpty())
+	})
+	It("should not record decisions when the TTL is 0", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		recorder.RecordProvisioning(ctx, "provisioned", scheduler.Results{
+			NewNodeClaims: []*scheduler.NodeClaim{newNodeClaim(nodePool, test.UnschedulablePod())},
+		}, []string{"default-abcde"})
+		Expect(listDecisions()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("GarbageCollection", func() {
+	var decisionObj *v1alpha1.ProvisioningDecision
+	BeforeEach(func() {
+		decisionObj = &v1alpha1.ProvisioningDecision{
+			ObjectMeta: metav1.ObjectMeta{Name: test.RandomName(), Namespace: decision.Namespace()},
+			Spec: v1alpha1.ProvisioningDecisionSpec{
+				Type:   v1alpha1.DecisionTypeProvisioning,
+				Reason: "provisioned",
+			},
+		}
+	})
+	It("should delete decisions older than the TTL", func() {
+		ExpectApplied(ctx, env.Client, decisionObj)
+		fakeClock.Step(time.Hour + time.Minute)
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectNotFound(ctx, env.Client, decisionObj)
+	})
+	It("should keep decisions younger than the TTL", func() {
+		ExpectApplied(ctx, env.Client, decisionObj)
+		fakeClock.Step(30 * time.Minute)
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectExists(ctx, env.Client, decisionObj)
+	})
+	It("should not delete decisions when the TTL is 0", func() {
+		ExpectApplied(ctx, env.Client, decisionObj)
+		ctx = options.ToContext(ctx, test.Options())
+		fakeClock.Step(24 * time.Hour)
+		ExpectSingletonReconciled(ctx, controller)
+		ExpectExists(ctx, env.Client, decisionObj)
+	})
+})
@@ -0,0 +1,214 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package divergence
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+const (
+	// Window is how long the outcome of a placement prediction counts towards the divergence ratio
+	Window = time.Hour
+	// AdjustmentInterval is the minimum time between adjustments of the safety margin, so that the effect of each
+	// adjustment can be observed before the next one
+	AdjustmentInterval = 10 * time.Minute
+	// MinPredictions is the number of predictions that have to be observed within the window before the safety margin
+	// is adjusted
+	MinPredictions = 20
+	// HighDivergenceRatio is the ratio of resource mismatches above which the safety margin is widened
+	HighDivergenceRatio = 0.1
+	// LowDivergenceRatio is the ratio of resource mismatches below which the safety margin is narrowed
+	LowDivergenceRatio = 0.02
+	// SafetyMarginStep is how much the safety margin is widened or narrowed by in each adjustment
+	SafetyMarginStep = 0.05
+
+	resultLabel = "result"
+	causeLabel  = "cause"
+)
+
+var (
+	PlacementPredictionsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "scheduler",
+			Name:      "placement_predictions_total",
+			Help:      "Number of pods bound after scheduling simulation predicted the node that they'd be bound to. Labeled by whether they were bound to the predicted node.",
+		},
+		[]string{resultLabel},
+	)
+	PlacementDivergencesTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "scheduler",
+			Name:      "placement_divergences_total",
+			Help:      "Number of pods bound to a different node than scheduling simulation predicted. Labeled by the cause of the divergence.",
+		},
+		[]string{causeLabel},
+	)
+	PlacementDivergenceRatio = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "scheduler",
+			Name:      "placement_divergence_ratio",
+			Help:      "Fraction of the pods bound within the last hour that were bound to a different node than scheduling simulation predicted. Labeled by the cause of the divergence.",
+		},
+		[]string{causeLabel},
+	)
+	SafetyMarginRatio = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "scheduler",
+			Name:      "safety_margin_ratio",
+			Help:      "Fraction that the CPU and memory requests of pods are scaled up by when they're packed onto new NodeClaims.",
+		},
+		[]string{},
+	)
+)
+
+// outcome is whether a pod was bound to the node that scheduling simulation predicted, and why not if it wasn't
+type outcome struct {
+	cause    state.DivergenceCause
+	observed time.Time
+}
+
+// Controller compares the nodes that pods are bound to with the nodes that scheduling simulation predicted they'd be
+// bound to. When pods are often bound elsewhere because they didn't fit on the predicted node, the simulation is packing
+// pods more tightly than they actually fit, so the safety margin that pod requests are scaled up by on new NodeClaims is
+// widened. It's narrowed again once they fit. Divergences with other causes aren't fixed by a wider margin, so they're
+// reported but don't move it.
+type Controller struct {
+	clock   clock.Clock
+	cluster *state.Cluster
+
+	mu       sync.Mutex
+	outcomes []outcome
+	adjusted time.Time
+}
+
+// NewController is a constructor
+func NewController(clk clock.Clock, cluster *state.Cluster) *Controller {
+	return &Controller{
+		clock:   clk,
+		cluster: cluster,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, pod *corev1.Pod) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "provisioner.divergence")
+	key := client.ObjectKeyFromObject(pod)
+	prediction, ok := c.cluster.PlacementPrediction(key)
+	if !ok || pod.Spec.NodeName == "" {
+		return reconcile.Result{}, nil
+	}
+	// The prediction was made for an earlier pod with the same name, or the node that the pod was bound to never showed up
+	if prediction.UID != pod.UID || c.clock.Since(prediction.Time) > Window {
+		c.cluster.ForgetPlacementPrediction(key)
+		return reconcile.Result{}, nil
+	}
+	cause, ok := c.cluster.PlacementDivergence(pod, prediction)
+	if !ok {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	c.cluster.ForgetPlacementPrediction(key)
+	if cause == "" {
+		PlacementPredictionsTotal.Inc(map[string]string{resultLabel: "matched"})
+	} else {
+		PlacementPredictionsTotal.Inc(map[string]string{resultLabel: "diverged"})
+		PlacementDivergencesTotal.Inc(map[string]string{causeLabel: string(cause)})
+		log.FromContext(ctx).WithValues("Node", klog.KRef("", pod.Spec.NodeName), "NodeClaim", klog.KRef("", prediction.NodeClaim), "cause", cause).
+			V(1).Info("pod was bound to a different node than predicted")
+	}
+	c.observe(ctx, cause)
+	return reconcile.Result{}, nil
+}
+
+// observe records the outcome of a placement prediction, and adjusts the safety margin if the ratio of resource
+// mismatches has moved outside of its bounds
+func (c *Controller) observe(ctx context.Context, cause state.DivergenceCause) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	c.outcomes = append(lo.Filter(c.outcomes, func(o outcome, _ int) bool { return now.Sub(o.observed) < Window }), outcome{cause: cause, observed: now})
+	causes := lo.CountValuesBy(c.outcomes, func(o outcome) state.DivergenceCause { return o.cause })
+	for _, cause := range []state.DivergenceCause{state.DivergenceCauseNodeRemoved, state.DivergenceCauseResourceMismatch, state.DivergenceCauseUnmodeledPlugin} {
+		PlacementDivergenceRatio.Set(float64(causes[cause])/float64(len(c.outcomes)), map[string]string{causeLabel: string(cause)})
+	}
+	if len(c.outcomes) < MinPredictions || now.Sub(c.adjusted) < AdjustmentInterval {
+		return
+	}
+	ratio := float64(causes[state.DivergenceCauseResourceMismatch]) / float64(len(c.outcomes))
+	maxMargin := float64(options.FromContext(ctx).MaxSchedulingSafetyMargin) / 100
+	current := c.cluster.SchedulingSafetyMargin()
+	margin := current
+	switch {
+	case ratio > HighDivergenceRatio:
+		margin = math.Min(current+SafetyMarginStep, maxMargin)
+	case ratio < LowDivergenceRatio:
+		margin = math.Max(current-SafetyMarginStep, 0)
+	}
+	margin = math.Min(margin, maxMargin)
+	if margin == current {
+		return
+	}
+	c.adjusted = now
+	c.cluster.SetSchedulingSafetyMargin(margin)
+	SafetyMarginRatio.Set(margin, nil)
+	log.FromContext(ctx).WithValues("divergence-ratio", ratio, "safety-margin", margin).Info("adjusted scheduling safety margin")
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("provisioner.divergence").
+		For(&corev1.Pod{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			if o.(*corev1.Pod).Spec.NodeName == "" {
+				return false
+			}
+			_, ok := c.cluster.PlacementPrediction(client.ObjectKeyFromObject(o))
+			return ok
+		}))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
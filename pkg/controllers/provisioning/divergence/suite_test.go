@@ -0,0 +1,199 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package divergence_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/divergence"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+var nodeStateController *informer.NodeController
+var nodeClaimStateController *informer.NodeClaimController
+var controller *divergence.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Divergence")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	nodeStateController = informer.NewNodeController(env.Client, cluster)
+	nodeClaimStateController = informer.NewNodeClaimController(env.Client, cloudProvider, cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	cluster.Reset()
+	controller = divergence.NewController(fakeClock, cluster)
+	divergence.PlacementPredictionsTotal.Reset()
+	divergence.PlacementDivergencesTotal.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Divergence", func() {
+	var predictedNodeClaim, otherNodeClaim *v1.NodeClaim
+	var predictedNode, otherNode *corev1.Node
+	BeforeEach(func() {
+		nodePool := test.NodePool()
+		nodeClaimAndNode := func() (*v1.NodeClaim, *corev1.Node) {
+			return test.NodeClaimAndNode(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: "default-instance-type",
+				}},
+				Status: v1.NodeClaimStatus{
+					ProviderID: test.RandomProviderID(),
+					Allocatable: corev1.ResourceList{
+						corev1.ResourceCPU:  resource.MustParse("4"),
+						corev1.ResourcePods: resource.MustParse("10"),
+					},
+				},
+			})
+		}
+		predictedNodeClaim, predictedNode = nodeClaimAndNode()
+		otherNodeClaim, otherNode = nodeClaimAndNode()
+		ExpectApplied(ctx, env.Client, nodePool, predictedNodeClaim, predictedNode, otherNodeClaim, otherNode)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController,
+			[]*corev1.Node{predictedNode, otherNode}, []*v1.NodeClaim{predictedNodeClaim, otherNodeClaim})
+	})
+	// boundPod creates a pod that's bound to the node after its placement was predicted on the predicted node
+	boundPod := func(node *corev1.Node, cpu string) *corev1.Pod {
+		GinkgoHelper()
+		pod := test.Pod(test.PodOptions{
+			NodeName: node.Name,
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		})
+		ExpectApplied(ctx, env.Client, pod)
+		cluster.PredictPlacement(pod, predictedNodeClaim.Name, "")
+		return pod
+	}
+	It("should count pods that were bound to the predicted node", func() {
+		pod := boundPod(predictedNode, "1")
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+
+		ExpectMetricCounterValue(divergence.PlacementPredictionsTotal, 1, map[string]string{"result": "matched"})
+		_, ok := cluster.PlacementPrediction(client.ObjectKeyFromObject(pod))
+		Expect(ok).To(BeFalse())
+	})
+	It("should classify pods bound elsewhere while the predicted node had room as an unmodeled plugin", func() {
+		pod := boundPod(otherNode, "1")
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+
+		ExpectMetricCounterValue(divergence.PlacementPredictionsTotal, 1, map[string]string{"result": "diverged"})
+		ExpectMetricCounterValue(divergence.PlacementDivergencesTotal, 1, map[string]string{"cause": string(state.DivergenceCauseUnmodeledPlugin)})
+	})
+	It("should classify pods bound elsewhere because the predicted node didn't have room as a resource mismatch", func() {
+		pod := boundPod(otherNode, "5")
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+
+		ExpectMetricCounterValue(divergence.PlacementDivergencesTotal, 1, map[string]string{"cause": string(state.DivergenceCauseResourceMismatch)})
+	})
+	It("should classify pods whose predicted nodeclaim is gone as a removed node", func() {
+		pod := boundPod(otherNode, "1")
+		cluster.PredictPlacement(pod, "deleted-nodeclaim", "")
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+
+		ExpectMetricCounterValue(divergence.PlacementDivergencesTotal, 1, map[string]string{"cause": string(state.DivergenceCauseNodeRemoved)})
+	})
+	It("should ignore predictions made for an earlier pod with the same name", func() {
+		pod := boundPod(otherNode, "1")
+		stale := pod.DeepCopy()
+		stale.UID = types.UID("earlier-pod")
+		cluster.PredictPlacement(stale, predictedNodeClaim.Name, "")
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+
+		_, ok := cluster.PlacementPrediction(client.ObjectKeyFromObject(pod))
+		Expect(ok).To(BeFalse())
+		_, found := FindMetricWithLabelValues("karpenter_scheduler_placement_predictions_total", map[string]string{"result": "diverged"})
+		Expect(found).To(BeFalse())
+	})
+	It("should widen the safety margin while resource mismatches are high and narrow it once they're low", func() {
+		marginCtx := options.ToContext(ctx, test.Options(test.OptionsFields{MaxSchedulingSafetyMargin: lo.ToPtr(20)}))
+		for range divergence.MinPredictions {
+			ExpectObjectReconciled(marginCtx, env.Client, controller, boundPod(otherNode, "5"))
+		}
+		Expect(cluster.SchedulingSafetyMargin()).To(BeNumerically("~", divergence.SafetyMarginStep))
+		ExpectMetricGaugeValue(divergence.SafetyMarginRatio, divergence.SafetyMarginStep, nil)
+
+		// Outcomes within the adjustment interval don't widen it any further
+		ExpectObjectReconciled(marginCtx, env.Client, controller, boundPod(otherNode, "5"))
+		Expect(cluster.SchedulingSafetyMargin()).To(BeNumerically("~", divergence.SafetyMarginStep))
+
+		fakeClock.Step(divergence.Window)
+		for range divergence.MinPredictions {
+			ExpectObjectReconciled(marginCtx, env.Client, controller, boundPod(predictedNode, "0.1"))
+		}
+		Expect(cluster.SchedulingSafetyMargin()).To(BeZero())
+	})
+	It("should not widen the safety margin for divergences that aren't resource mismatches", func() {
+		marginCtx := options.ToContext(ctx, test.Options(test.OptionsFields{MaxSchedulingSafetyMargin: lo.ToPtr(20)}))
+		for range divergence.MinPredictions {
+			ExpectObjectReconciled(marginCtx, env.Client, controller, boundPod(otherNode, "1"))
+		}
+		ExpectMetricGaugeValue(divergence.PlacementDivergenceRatio, 1, map[string]string{"cause": string(state.DivergenceCauseUnmodeledPlugin)})
+		Expect(cluster.SchedulingSafetyMargin()).To(BeZero())
+	})
+	It("should not apply a safety margin by default", func() {
+		for range divergence.MinPredictions {
+			ExpectObjectReconciled(ctx, env.Client, controller, boundPod(otherNode, "5"))
+		}
+		Expect(cluster.SchedulingSafetyMargin()).To(BeZero())
+	})
+})
@@ -0,0 +1,119 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
+)
+
+// ProvisioningSimulation is the outcome of simulating the scheduling of pods, without launching any capacity for them
+type ProvisioningSimulation struct {
+	SimulatedAt metav1.Time `json:"simulatedAt"`
+	// Placements are keyed by the namespace/name of each pod
+	Placements map[string]PodPlacement `json:"placements"`
+}
+
+// PodPlacement is where a pod would be scheduled. It's either an existing node, a new NodeClaim launched from a
+// NodePool, or an error if the pod can't be scheduled.
+type PodPlacement struct {
+	// Node is the existing node that the pod would be scheduled to
+	Node string `json:"node,omitempty"`
+	// NodePool is the NodePool that a NodeClaim would be launched from for the pod
+	NodePool string `json:"nodePool,omitempty"`
+	// InstanceTypes are the instance types that the NodeClaim would be launched with, cheapest first
+	InstanceTypes []string `json:"instanceTypes,omitempty"`
+	// Zones are the zones of the NodeClaim's available offerings
+	Zones []string `json:"zones,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// SimulateProvisioning simulates scheduling the pods onto the cluster's nodes and new NodeClaims, the same as if
+// they were pending, without creating any NodeClaims. The pods aren't modified. Pods without a UID are given one for
+// the simulation, so that pods built from specs can be simulated.
+func (p *Provisioner) SimulateProvisioning(ctx context.Context, pods []*corev1.Pod) (ProvisioningSimulation, error) {
+	simulation := ProvisioningSimulation{
+		SimulatedAt: metav1.NewTime(p.clock.Now()),
+		Placements:  map[string]PodPlacement{},
+	}
+	if len(pods) == 0 {
+		return simulation, nil
+	}
+	pods = lo.Map(pods, func(pod *corev1.Pod, _ int) *corev1.Pod {
+		pod = pod.DeepCopy()
+		if pod.UID == "" {
+			pod.UID = uuid.NewUUID()
+		}
+		return pod
+	})
+	ctx = log.IntoContext(ctx, operatorlogging.NopLogger)
+	s, err := p.newScheduler(ctx, pods, p.cluster.Nodes().Active())
+	if err != nil {
+		if errors.Is(err, ErrNodePoolsNotFound) {
+			for _, pod := range pods {
+				simulation.Placements[client.ObjectKeyFromObject(pod).String()] = PodPlacement{Error: err.Error()}
+			}
+			return simulation, nil
+		}
+		return ProvisioningSimulation{}, fmt.Errorf("creating scheduler, %w", err)
+	}
+	results := s.WithSafetyMargin(p.cluster.SchedulingSafetyMargin()).Solve(ctx, pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	for _, n := range results.ExistingNodes {
+		for _, pod := range n.Pods {
+			simulation.Placements[client.ObjectKeyFromObject(pod).String()] = PodPlacement{Node: n.Name()}
+		}
+	}
+	for _, n := range results.NewNodeClaims {
+		placement := PodPlacement{
+			NodePool:      n.NodePoolName,
+			InstanceTypes: lo.Map(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) string { return it.Name }),
+			Zones:         offeringZones(n),
+		}
+		for _, pod := range n.Pods {
+			simulation.Placements[client.ObjectKeyFromObject(pod).String()] = placement
+		}
+	}
+	for pod, err := range results.PodErrors {
+		simulation.Placements[client.ObjectKeyFromObject(pod).String()] = PodPlacement{Error: err.Error()}
+	}
+	return simulation, nil
+}
+
+// offeringZones returns the zones of the available offerings that the NodeClaim could launch with
+func offeringZones(n *scheduler.NodeClaim) []string {
+	zones := sets.New[string]()
+	for _, it := range n.InstanceTypeOptions {
+		for _, o := range it.Offerings.Available().Compatible(n.Requirements) {
+			zones.Insert(o.Requirements.Get(corev1.LabelTopologyZone).Any())
+		}
+	}
+	zones.Delete("")
+	return sets.List(zones)
+}
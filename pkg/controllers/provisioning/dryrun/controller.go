@@ -0,0 +1,108 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+)
+
+// Controller simulates scheduling for pods with the karpenter.sh/dry-run scheduling gate and writes where each pod
+// would be scheduled to its karpenter.sh/dry-run-result annotation. Every dry-run pod without a result is simulated
+// together, so that pods created at the same time are packed together as they would be if they were pending.
+type Controller struct {
+	kubeClient  client.Client
+	cluster     *state.Cluster
+	provisioner *provisioning.Provisioner
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner) *Controller {
+	return &Controller{
+		kubeClient:  kubeClient,
+		cluster:     cluster,
+		provisioner: provisioner,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, pod *corev1.Pod) (reconcile.Result, error) {
+	ctx = injection.WithControllerName(ctx, "provisioner.dryrun")
+	if !awaitingDryRun(pod) {
+		return reconcile.Result{}, nil
+	}
+	// Simulating against a partially synced cluster state would schedule pods to new capacity that isn't needed
+	if !c.cluster.Synced(ctx) {
+		return reconcile.Result{RequeueAfter: time.Second}, nil
+	}
+	podList := &corev1.PodList{}
+	if err := c.kubeClient.List(ctx, podList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing pods, %w", err)
+	}
+	pods := lo.FilterMap(podList.Items, func(p corev1.Pod, _ int) (*corev1.Pod, bool) { return &p, awaitingDryRun(&p) })
+	simulation, err := c.provisioner.SimulateProvisioning(ctx, pods)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("simulating provisioning, %w", err)
+	}
+	for _, p := range pods {
+		raw, err := json.Marshal(simulation.Placements[client.ObjectKeyFromObject(p).String()])
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("marshaling dry-run result, %w", err)
+		}
+		stored := p.DeepCopy()
+		p.Annotations = lo.Assign(p.Annotations, map[string]string{v1.DryRunResultAnnotationKey: string(raw)})
+		if err = c.kubeClient.Patch(ctx, p, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			return reconcile.Result{}, fmt.Errorf("patching dry-run result, %w", err)
+		}
+	}
+	log.FromContext(ctx).WithValues("pods", len(pods)).Info("simulated provisioning for dry-run pods")
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("provisioner.dryrun").
+		For(&corev1.Pod{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return awaitingDryRun(o.(*corev1.Pod))
+		}))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
+		Complete(reconcile.AsReconciler(m.GetClient(), c))
+}
+
+// awaitingDryRun returns whether the pod is gated for a dry run that hasn't been simulated yet
+func awaitingDryRun(pod *corev1.Pod) bool {
+	_, simulated := pod.Annotations[v1.DryRunResultAnnotationKey]
+	return !simulated && pod.DeletionTimestamp.IsZero() &&
+		lo.ContainsBy(pod.Spec.SchedulingGates, func(g corev1.PodSchedulingGate) bool { return g.Name == v1.DryRunSchedulingGate })
+}
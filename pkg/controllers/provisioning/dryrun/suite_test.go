@@ -0,0 +1,128 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/dryrun"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+)
+
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *fake.CloudProvider
+var cluster *state.Cluster
+var controller *dryrun.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DryRun")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(test.WithCRDs(apis.CRDs...), test.WithCRDs(v1alpha1.CRDs...))
+	ctx = options.ToContext(ctx, test.Options())
+	fakeClock = clock.NewFakeClock(time.Now())
+	cloudProvider = fake.NewCloudProvider()
+	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
+	prov := provisioning.NewProvisioner(env.Client, test.NewEventRecorder(), cloudProvider, cluster, fakeClock)
+	controller = dryrun.NewController(env.Client, cluster, prov)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	cluster.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("DryRun", func() {
+	var nodePool *v1.NodePool
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+	})
+	dryRunPod := func() *corev1.Pod {
+		pod := test.Pod(test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		})
+		pod.Spec.SchedulingGates = []corev1.PodSchedulingGate{{Name: v1.DryRunSchedulingGate}}
+		return pod
+	}
+	placement := func(pod *corev1.Pod) provisioning.PodPlacement {
+		GinkgoHelper()
+		pod = ExpectExists(ctx, env.Client, pod)
+		Expect(pod.Annotations).To(HaveKey(v1.DryRunResultAnnotationKey))
+		result := provisioning.PodPlacement{}
+		Expect(json.Unmarshal([]byte(pod.Annotations[v1.DryRunResultAnnotationKey]), &result)).To(Succeed())
+		return result
+	}
+	It("should write where dry-run pods would be scheduled without launching capacity", func() {
+		pod, pod2 := dryRunPod(), dryRunPod()
+		ExpectApplied(ctx, env.Client, nodePool, pod, pod2)
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+
+		for _, p := range []*corev1.Pod{pod, pod2} {
+			result := placement(p)
+			Expect(result.Error).To(BeEmpty())
+			Expect(result.NodePool).To(Equal(nodePool.Name))
+			Expect(result.InstanceTypes).ToNot(BeEmpty())
+		}
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+	})
+	It("should write an error for dry-run pods that can't be scheduled", func() {
+		pod := dryRunPod()
+		pod.Spec.NodeSelector = map[string]string{corev1.LabelTopologyZone: "unknown-zone"}
+		ExpectApplied(ctx, env.Client, nodePool, pod)
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+		Expect(placement(pod).Error).ToNot(BeEmpty())
+	})
+	It("should ignore pods without the dry-run scheduling gate", func() {
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, nodePool, pod)
+		ExpectObjectReconciled(ctx, env.Client, controller, pod)
+		Expect(ExpectExists(ctx, env.Client, pod).Annotations).ToNot(HaveKey(v1.DryRunResultAnnotationKey))
+	})
+})
@@ -0,0 +1,82 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// FleetLimitsExceededError is returned when creating a NodeClaim would take the cluster past the operator's
+// fleet-wide limits on managed nodes or vCPUs
+type FleetLimitsExceededError struct {
+	msg string
+}
+
+func (e *FleetLimitsExceededError) Error() string {
+	return e.msg
+}
+
+func fleetLimitsEnabled(ctx context.Context) bool {
+	return options.FromContext(ctx).MaxNodes > 0 || options.FromContext(ctx).MaxVCPU > 0
+}
+
+// enforceFleetLimits checks the NodeClaim against the operator's fleet-wide limits. Instance types that would take
+// the cluster past the vCPU limit are dropped from the NodeClaim's options, and an error is returned if none remain
+// or if the remaining options no longer satisfy the NodePool's minValues.
+func (p *Provisioner) enforceFleetLimits(ctx context.Context, n *scheduler.NodeClaim) error {
+	maxNodes, maxVCPU := options.FromContext(ctx).MaxNodes, options.FromContext(ctx).MaxVCPU
+	nodes := lo.Filter(p.cluster.Nodes(), func(sn *state.StateNode, _ int) bool { return sn.Managed() })
+	if maxNodes > 0 && len(nodes) >= maxNodes {
+		return &FleetLimitsExceededError{msg: fmt.Sprintf("fleet-wide node limit of %d reached", maxNodes)}
+	}
+	if maxVCPU <= 0 {
+		return nil
+	}
+	used := resource.Quantity{}
+	for _, node := range nodes {
+		cpu := node.Capacity()[corev1.ResourceCPU]
+		// NodeClaims that haven't launched don't know their capacity yet, so we count their requests instead
+		if cpu.IsZero() && node.NodeClaim != nil {
+			cpu = node.NodeClaim.Spec.Resources.Requests[corev1.ResourceCPU]
+		}
+		used.Add(cpu)
+	}
+	remaining := resource.NewQuantity(int64(maxVCPU), resource.DecimalSI)
+	remaining.Sub(used)
+	fits := lo.Filter(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+		return it.Capacity.Cpu().Cmp(*remaining) <= 0
+	})
+	if len(fits) == 0 {
+		return &FleetLimitsExceededError{msg: fmt.Sprintf("fleet-wide vCPU limit of %d reached, %s vCPU in use", maxVCPU, used.String())}
+	}
+	// Dropping instance types mustn't leave the NodeClaim with fewer options than its NodePool's minValues require
+	if _, err := cloudprovider.InstanceTypes(fits).SatisfiesMinValues(n.Requirements); err != nil {
+		return &FleetLimitsExceededError{msg: fmt.Sprintf("fleet-wide vCPU limit of %d leaves too few instance types, %s", maxVCPU, err)}
+	}
+	n.InstanceTypeOptions = fits
+	return nil
+}
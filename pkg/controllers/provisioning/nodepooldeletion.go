@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
+)
+
+// NodePoolDeletionSimulation is the outcome of simulating the removal of a NodePool along with all of its nodes
+type NodePoolDeletionSimulation struct {
+	SimulatedAt metav1.Time `json:"simulatedAt"`
+	// Nodes is the number of nodes that would be removed with the NodePool
+	Nodes int `json:"nodes"`
+	// ReschedulablePods is the number of pods on those nodes that would need to be rescheduled
+	ReschedulablePods int `json:"reschedulablePods"`
+	// UnschedulablePods are the namespace/name of pods that couldn't be scheduled anywhere else
+	UnschedulablePods []string `json:"unschedulablePods,omitempty"`
+	// ReplacementNodeClaims is the number of NodeClaims other NodePools would launch for the pods, keyed by NodePool
+	ReplacementNodeClaims map[string]int `json:"replacementNodeClaims,omitempty"`
+	// ReplacementCost is the summed price of the cheapest offering for each replacement NodeClaim
+	ReplacementCost float64 `json:"replacementCost"`
+}
+
+// SimulateNodePoolDeletion simulates rescheduling the pods of every node in the NodePool onto the rest of the cluster,
+// without the NodePool being available to launch capacity from.
+func (p *Provisioner) SimulateNodePoolDeletion(ctx context.Context, nodePool *v1.NodePool) (NodePoolDeletionSimulation, error) {
+	nodes := p.cluster.Nodes().Active()
+	inNodePool := func(n *state.StateNode) bool { return n.Labels()[v1.NodePoolLabelKey] == nodePool.Name }
+	removed := state.StateNodes(lo.Filter(nodes, func(n *state.StateNode, _ int) bool { return inNodePool(n) }))
+	remaining := lo.Reject(nodes, func(n *state.StateNode, _ int) bool { return inNodePool(n) })
+
+	pods, err := removed.ReschedulablePods(ctx, p.kubeClient)
+	if err != nil {
+		return NodePoolDeletionSimulation{}, fmt.Errorf("listing reschedulable pods, %w", err)
+	}
+	simulation := NodePoolDeletionSimulation{
+		SimulatedAt:       metav1.NewTime(p.clock.Now()),
+		Nodes:             len(removed),
+		ReschedulablePods: len(pods),
+	}
+	if len(pods) == 0 {
+		return simulation, nil
+	}
+	s, err := p.newScheduler(log.IntoContext(ctx, operatorlogging.NopLogger), pods, remaining, nodePool.Name)
+	if err != nil {
+		// Without any other NodePools, none of the pods can be given new capacity
+		if errors.Is(err, ErrNodePoolsNotFound) {
+			simulation.UnschedulablePods = podKeys(pods)
+			return simulation, nil
+		}
+		return NodePoolDeletionSimulation{}, fmt.Errorf("creating scheduler, %w", err)
+	}
+	results := s.Solve(log.IntoContext(ctx, operatorlogging.NopLogger), pods)
+	simulation.UnschedulablePods = podKeys(lo.Keys(results.PodErrors))
+	for _, n := range results.NewNodeClaims {
+		if simulation.ReplacementNodeClaims == nil {
+			simulation.ReplacementNodeClaims = map[string]int{}
+		}
+		simulation.ReplacementNodeClaims[n.NodePoolName]++
+		simulation.ReplacementCost += cheapestLaunchPrice(n)
+	}
+	return simulation, nil
+}
+
+// cheapestLaunchPrice returns the price of the cheapest available offering that the NodeClaim could launch with
+func cheapestLaunchPrice(n *scheduler.NodeClaim) float64 {
+	price := math.MaxFloat64
+	for _, it := range n.InstanceTypeOptions {
+		if offerings := it.Offerings.Available().Compatible(n.Requirements); len(offerings) > 0 {
+			price = math.Min(price, offerings.Cheapest().Price)
+		}
+	}
+	if price == math.MaxFloat64 {
+		return 0
+	}
+	return price
+}
+
+func podKeys(pods []*corev1.Pod) []string {
+	keys := lo.Map(pods, func(p *corev1.Pod, _ int) string { return client.ObjectKeyFromObject(p).String() })
+	sort.Strings(keys)
+	return keys
+}
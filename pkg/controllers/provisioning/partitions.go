@@ -0,0 +1,152 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// solve schedules the pods against the state nodes. With more than one scheduling worker, the pods are partitioned into
+// groups that don't constrain one another, and each partition is solved by its own scheduler in parallel. Partitions
+// still share existing nodes, NodePool limits and reserved capacity, so if more than one partition used any of them, the
+// pods are solved again by a single scheduler.
+func (p *Provisioner) solve(ctx context.Context, pods []*corev1.Pod, stateNodes []*state.StateNode) (scheduler.Results, error) {
+	if partitions := scheduler.PartitionPods(pods, options.FromContext(ctx).SchedulingWorkers); len(partitions) > 1 {
+		results, err := p.solvePartitions(ctx, partitions, stateNodes)
+		if err != nil {
+			return scheduler.Results{}, err
+		}
+		limited, err := p.limitedNodePools(ctx)
+		if err != nil {
+			return scheduler.Results{}, err
+		}
+		if merged, ok := mergePartitionResults(results, limited); ok {
+			return merged, nil
+		}
+		log.FromContext(ctx).V(1).WithValues("partitions", len(partitions)).Info("scheduling partitions contended for shared capacity, scheduling pods together")
+	}
+	s, err := p.NewScheduler(ctx, pods, stateNodes)
+	if err != nil {
+		return scheduler.Results{}, err
+	}
+	return s.WithSafetyMargin(p.cluster.SchedulingSafetyMargin()).Solve(ctx, pods), nil
+}
+
+func (p *Provisioner) solvePartitions(ctx context.Context, partitions [][]*corev1.Pod, stateNodes []*state.StateNode) ([]scheduler.Results, error) {
+	results := make([]scheduler.Results, len(partitions))
+	errs := make([]error, len(partitions))
+	workqueue.ParallelizeUntil(ctx, len(partitions), len(partitions), func(i int) {
+		s, err := p.NewScheduler(ctx, partitions[i], stateNodes)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		results[i] = s.WithSafetyMargin(p.cluster.SchedulingSafetyMargin()).Solve(ctx, partitions[i])
+	})
+	if err, ok := lo.Find(errs, func(err error) bool { return err != nil }); ok {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("scheduling partitions, %w", ctx.Err())
+	}
+	return results, nil
+}
+
+// limitedNodePools returns the names of the NodePools with limits or zone limits
+func (p *Provisioner) limitedNodePools(ctx context.Context) (sets.Set[string], error) {
+	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	return sets.New(lo.FilterMap(nodePools, func(np *v1.NodePool, _ int) (string, bool) {
+		return np.Name, len(np.Spec.Limits) > 0 || len(np.Spec.ZoneLimits) > 0
+	})...), nil
+}
+
+// mergePartitionResults merges the results of partitions that were solved in parallel. Each partition's scheduler
+// assumed that it had existing nodes, NodePool limits and reserved capacity to itself, so the results can't be merged
+// if more than one partition scheduled pods to the same existing node, launched NodeClaims from the same NodePool with
+// limits, or launched NodeClaims into reserved capacity.
+func mergePartitionResults(results []scheduler.Results, limited sets.Set[string]) (scheduler.Results, bool) {
+	merged := scheduler.Results{PodErrors: map[*corev1.Pod]error{}, NodePoolRejections: map[types.UID]map[string]string{}}
+	existingNodes := map[string]*scheduler.ExistingNode{}
+	var existingNodeOrder []string
+	usedNodes := sets.New[string]()
+	usedNodePools := sets.New[string]()
+	reserved := false
+	for _, r := range results {
+		partitionNodes := sets.New[string]()
+		for _, n := range r.ExistingNodes {
+			id := n.ProviderID()
+			if _, ok := existingNodes[id]; !ok {
+				existingNodeOrder = append(existingNodeOrder, id)
+			}
+			if len(n.Pods) == 0 {
+				if _, ok := existingNodes[id]; !ok {
+					existingNodes[id] = n
+				}
+				continue
+			}
+			if usedNodes.Has(id) {
+				return scheduler.Results{}, false
+			}
+			partitionNodes.Insert(id)
+			existingNodes[id] = n
+		}
+		usedNodes = usedNodes.Union(partitionNodes)
+
+		partitionNodePools := sets.New[string]()
+		partitionReserved := false
+		for _, n := range r.NewNodeClaims {
+			if limited.Has(n.NodePoolName) {
+				partitionNodePools.Insert(n.NodePoolName)
+			}
+			if capacityTypes := n.Requirements.Get(v1.CapacityTypeLabelKey); capacityTypes.Len() == 1 && capacityTypes.Has(v1.CapacityTypeReserved) {
+				partitionReserved = true
+			}
+		}
+		if usedNodePools.HasAny(partitionNodePools.UnsortedList()...) || (reserved && partitionReserved) {
+			return scheduler.Results{}, false
+		}
+		usedNodePools = usedNodePools.Union(partitionNodePools)
+		reserved = reserved || partitionReserved
+
+		merged.NewNodeClaims = append(merged.NewNodeClaims, r.NewNodeClaims...)
+		for pod, err := range r.PodErrors {
+			merged.PodErrors[pod] = err
+		}
+		for uid, rejections := range r.NodePoolRejections {
+			merged.NodePoolRejections[uid] = rejections
+		}
+	}
+	merged.ExistingNodes = lo.Map(existingNodeOrder, func(id string, _ int) *scheduler.ExistingNode { return existingNodes[id] })
+	return merged, true
+}
@@ -0,0 +1,80 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
+)
+
+// placeholderLabelKey labels the placeholder pods that nodes are scheduled for when they're launched ahead of pods,
+// so that they can be kept on separate nodes with pod anti-affinity
+const placeholderLabelKey = apis.Group + "/placeholder"
+
+// PlaceholderNodeClaims returns the NodeClaims to launch for the given number of nodes that the NodePool should have
+// ahead of any pods, e.g. to keep it at spec.minNodes. Each NodeClaim is scheduled for an empty placeholder pod that
+// tolerates everything and can only run on the NodePool, so that it's sized for the NodePool's daemonsets and
+// launched from the same instance types that pods would be.
+func (p *Provisioner) PlaceholderNodeClaims(ctx context.Context, nodePool *v1.NodePool, nodes int) ([]*scheduler.NodeClaim, error) {
+	if nodes <= 0 {
+		return nil, nil
+	}
+	pods := lo.Times(nodes, func(i int) *corev1.Pod { return placeholderPod(nodePool, i) })
+	s, err := p.newScheduler(ctx, pods, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating scheduler, %w", err)
+	}
+	results := s.Solve(log.IntoContext(ctx, operatorlogging.NopLogger), pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	if len(results.PodErrors) > 0 {
+		return results.NewNodeClaims, fmt.Errorf("scheduling %d of %d placeholder nodes, %w", len(results.PodErrors), nodes, multierr.Combine(lo.Values(results.PodErrors)...))
+	}
+	return results.NewNodeClaims, nil
+}
+
+func placeholderPod(nodePool *v1.NodePool, i int) *corev1.Pod {
+	labels := map[string]string{placeholderLabelKey: nodePool.Name}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-placeholder-%d", nodePool.Name, i),
+			Namespace: metav1.NamespaceDefault,
+			UID:       uuid.NewUUID(),
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+			Tolerations:  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Affinity: &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+					TopologyKey:   corev1.LabelHostname,
+				}},
+			}},
+		},
+	}
+}
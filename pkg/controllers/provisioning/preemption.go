@@ -0,0 +1,94 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// withoutPreemptors removes the pending pods that kube-scheduler can schedule by preempting lower priority pods on an
+// existing node. Launching capacity for these pods races with preemption, and the new nodes usually end up unused.
+func (p *Provisioner) withoutPreemptors(ctx context.Context, pods []*corev1.Pod, nodes state.StateNodes) ([]*corev1.Pod, error) {
+	nodePods := map[string][]*corev1.Pod{}
+	// claimed tracks the requests of the preemptors that are expected to schedule on each node, so that a batch of
+	// preemptors isn't held back for capacity that only one of them can get
+	claimed := map[string]corev1.ResourceList{}
+	var remaining []*corev1.Pod
+	for _, pod := range pods {
+		node, err := p.preemptionTarget(ctx, pod, nodes, nodePods, claimed)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			remaining = append(remaining, pod)
+			continue
+		}
+		claimed[node.Name()] = resources.MergeInto(claimed[node.Name()], resources.RequestsForPods(pod))
+		p.recorder.Publish(scheduler.PodAwaitingPreemptionEvent(pod, node.Name()))
+	}
+	return remaining, nil
+}
+
+// preemptionTarget returns the node that the pod can schedule on by preempting lower priority pods, or nil if there
+// isn't one. Pods that kube-scheduler has already nominated a node for aren't pending, so they never reach this.
+func (p *Provisioner) preemptionTarget(ctx context.Context, pod *corev1.Pod, nodes state.StateNodes, nodePods map[string][]*corev1.Pod, claimed map[string]corev1.ResourceList) (*state.StateNode, error) {
+	if lo.FromPtr(pod.Spec.PreemptionPolicy) == corev1.PreemptNever {
+		return nil, nil
+	}
+	priority := lo.FromPtr(pod.Spec.Priority)
+	podRequests := resources.RequestsForPods(pod)
+	podRequirements := scheduling.NewStrictPodRequirements(pod)
+	for _, node := range nodes {
+		if node.Node == nil || !node.Initialized() {
+			continue
+		}
+		if err := scheduling.Taints(node.Taints()).Tolerates(pod); err != nil {
+			continue
+		}
+		if err := scheduling.NewLabelRequirements(node.Labels()).Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
+			continue
+		}
+		if _, ok := nodePods[node.Name()]; !ok {
+			scheduled, err := node.Pods(ctx, p.kubeClient)
+			if err != nil {
+				return nil, fmt.Errorf("listing pods on node, %w", err)
+			}
+			nodePods[node.Name()] = scheduled
+		}
+		// kube-scheduler only preempts pods with a lower priority, so the pod has to fit alongside the rest, and alongside
+		// the other preemptors that we expect to schedule on the node
+		kept, preemptible := lo.FilterReject(nodePods[node.Name()], func(scheduled *corev1.Pod, _ int) bool {
+			return lo.FromPtr(scheduled.Spec.Priority) >= priority
+		})
+		if len(preemptible) == 0 {
+			continue
+		}
+		if resources.Fits(podRequests, resources.Subtract(node.Allocatable(), resources.Merge(resources.RequestsForPods(kept...), claimed[node.Name()]))) {
+			return node, nil
+		}
+	}
+	return nil, nil
+}
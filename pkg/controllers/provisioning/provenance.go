@@ -0,0 +1,158 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/status"
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+)
+
+// provenanceSinkTimeout bounds how long a request to the provenance sink can take
+const provenanceSinkTimeout = 5 * time.Second
+
+// recordProvenance records the provenance of a newly created NodeClaim in its status and publishes it to the
+// provenance sink. Since the NodeClaim already exists, failures are logged rather than failing its creation.
+func (p *Provisioner) recordProvenance(ctx context.Context, nodePool *v1.NodePool, nodeClaim *v1.NodeClaim, pods []*corev1.Pod) {
+	provenance, err := p.provenance(ctx, nodePool, nodeClaim, pods)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed recording provenance")
+		return
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Status.Provenance = provenance
+	if err = p.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		log.FromContext(ctx).Error(err, "failed recording provenance")
+		return
+	}
+	// Publishing happens in the background so that a slow sink doesn't hold up NodeClaim creation, which may be
+	// serialized while fleet limits are configured
+	go p.publishProvenance(context.WithoutCancel(ctx), nodeClaim.DeepCopy())
+}
+
+// provenance builds the record of the inputs that the NodeClaim was created from
+func (p *Provisioner) provenance(ctx context.Context, nodePool *v1.NodePool, nodeClaim *v1.NodeClaim, pods []*corev1.Pod) (*v1.Provenance, error) {
+	nodeClassHash, err := p.nodeClassHash(ctx, nodeClaim.Spec.NodeClassRef)
+	if err != nil {
+		return nil, fmt.Errorf("hashing nodeclass, %w", err)
+	}
+	podKeys := lo.Map(pods, func(pod *corev1.Pod, _ int) string {
+		return fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, pod.UID)
+	})
+	sort.Strings(podKeys)
+	provenance := &v1.Provenance{
+		ControllerVersion:   injection.GetVersion(ctx),
+		NodePoolGeneration:  nodePool.Generation,
+		NodePoolHash:        nodepoolutils.Hash(nodePool),
+		NodeClassHash:       nodeClassHash,
+		RequesterPodsDigest: digest(strings.Join(podKeys, "\n")),
+	}
+	// The digest covers the NodeClaim's UID so that records for different NodeClaims can't be mistaken for one another.
+	// It isn't signed, so it only detects accidental changes to the record.
+	raw, err := json.Marshal(provenance)
+	if err != nil {
+		return nil, err
+	}
+	provenance.Digest = digest(string(nodeClaim.UID) + "\n" + string(raw))
+	return provenance, nil
+}
+
+// nodeClassHash hashes the spec of the NodeClass that the NodeClaim references. NodeClasses that aren't supported by
+// the CloudProvider or don't exist yet have no hash.
+func (p *Provisioner) nodeClassHash(ctx context.Context, ref *v1.NodeClassReference) (string, error) {
+	if ref == nil {
+		return "", nil
+	}
+	supported, ok := lo.Find(p.cloudProvider.GetSupportedNodeClasses(), func(nc status.Object) bool {
+		return object.GVK(nc).GroupKind() == ref.GroupKind()
+	})
+	if !ok {
+		return "", nil
+	}
+	nodeClass := supported.DeepCopyObject().(client.Object)
+	if err := p.kubeClient.Get(ctx, client.ObjectKey{Name: ref.Name}, nodeClass); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(nodeClass)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(lo.Must(hashstructure.Hash(u["spec"], hashstructure.FormatV2, &hashstructure.HashOptions{
+		SlicesAsSets:    true,
+		IgnoreZeroValue: true,
+		ZeroNil:         true,
+	}))), nil
+}
+
+// publishProvenance pushes the NodeClaim's provenance to the configured provenance sink. Failures are logged rather
+// than returned since the NodeClaim has already been created and its provenance is recorded in its status.
+func (p *Provisioner) publishProvenance(ctx context.Context, nodeClaim *v1.NodeClaim) {
+	url := options.FromContext(ctx).ProvenanceSinkURL
+	if url == "" || nodeClaim.Status.Provenance == nil {
+		return
+	}
+	body, err := json.Marshal(map[string]any{
+		"nodeClaim":  nodeClaim.Name,
+		"uid":        nodeClaim.UID,
+		"provenance": nodeClaim.Status.Provenance,
+	})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed marshaling provenance")
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed building provenance request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.provenanceClient.Do(req)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed publishing provenance")
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.FromContext(ctx).Error(fmt.Errorf("unexpected status code %d", resp.StatusCode), "failed publishing provenance")
+	}
+}
+
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
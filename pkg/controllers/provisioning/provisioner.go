@@ -20,16 +20,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/awslabs/operatorpkg/option"
 	"github.com/awslabs/operatorpkg/singleton"
 	"github.com/awslabs/operatorpkg/status"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/multierr"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	nodev1 "k8s.io/api/node/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
@@ -43,14 +50,19 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/decision"
 	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
@@ -79,7 +91,14 @@ type Provisioner struct {
 	cluster        *state.Cluster
 	recorder       events.Recorder
 	cm             *pretty.ChangeMonitor
+	filterCache    *scheduler.InstanceTypeFilterCache
 	clock          clock.Clock
+	decisions      *decision.Recorder
+	// fleetLimitsMu serializes NodeClaim creation while fleet-wide limits are configured so that concurrent
+	// creates can't each see room under the limit
+	fleetLimitsMu sync.Mutex
+	// provenanceClient publishes NodeClaim provenance to the provenance sink
+	provenanceClient *http.Client
 }
 
 func NewProvisioner(kubeClient client.Client, recorder events.Recorder,
@@ -87,14 +106,17 @@ func NewProvisioner(kubeClient client.Client, recorder events.Recorder,
 	clock clock.Clock,
 ) *Provisioner {
 	p := &Provisioner{
-		batcher:        NewBatcher[types.UID](clock),
-		cloudProvider:  cloudProvider,
-		kubeClient:     kubeClient,
-		volumeTopology: scheduler.NewVolumeTopology(kubeClient),
-		cluster:        cluster,
-		recorder:       recorder,
-		cm:             pretty.NewChangeMonitor(),
-		clock:          clock,
+		batcher:          NewBatcher[types.UID](clock),
+		cloudProvider:    cloudProvider,
+		kubeClient:       kubeClient,
+		volumeTopology:   scheduler.NewVolumeTopology(kubeClient, recorder),
+		cluster:          cluster,
+		recorder:         recorder,
+		cm:               pretty.NewChangeMonitor(),
+		filterCache:      scheduler.NewInstanceTypeFilterCache(),
+		clock:            clock,
+		decisions:        decision.NewRecorder(kubeClient),
+		provenanceClient: &http.Client{Timeout: provenanceSinkTimeout},
 	}
 	return p
 }
@@ -133,7 +155,9 @@ func (p *Provisioner) Reconcile(ctx context.Context) (result reconcile.Result, e
 	if len(results.NewNodeClaims) == 0 {
 		return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
 	}
-	if _, err = p.CreateNodeClaims(ctx, results.NewNodeClaims, WithReason(metrics.ProvisionedReason), RecordPodNomination); err != nil {
+	nodeClaimNames, err := p.CreateNodeClaims(ctx, results.NewNodeClaims, WithReason(metrics.ProvisionedReason), RecordPodNomination)
+	p.decisions.RecordProvisioning(ctx, metrics.ProvisionedReason, results, nodeClaimNames)
+	if err != nil {
 		return reconcile.Result{}, err
 	}
 	return reconcile.Result{RequeueAfter: singleton.RequeueImmediately}, nil
@@ -211,17 +235,37 @@ func (p *Provisioner) consolidationWarnings(ctx context.Context, pods []*corev1.
 
 var ErrNodePoolsNotFound = errors.New("no nodepools found")
 
+// QuotaExceededBackoff is how long a NodePool isn't launched from after it exceeded a CloudProvider quota
+const QuotaExceededBackoff = time.Minute * 5
+
 //nolint:gocyclo
 func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stateNodes []*state.StateNode) (*scheduler.Scheduler, error) {
+	return p.newScheduler(ctx, pods, stateNodes)
+}
+
+// newScheduler constructs a scheduler for the pods against the state nodes, leaving out any of the named NodePools
+// from the set of NodePools that new NodeClaims can be launched from
+func (p *Provisioner) newScheduler(ctx context.Context, pods []*corev1.Pod, stateNodes []*state.StateNode, excludedNodePools ...string) (*scheduler.Scheduler, error) {
 	nodePools, err := nodepoolutils.ListManaged(ctx, p.kubeClient, p.cloudProvider)
 	if err != nil {
 		return nil, fmt.Errorf("listing nodepools, %w", err)
 	}
 	nodePools = lo.Filter(nodePools, func(np *v1.NodePool, _ int) bool {
+		if lo.Contains(excludedNodePools, np.Name) {
+			return false
+		}
 		if !np.StatusConditions().IsTrue(status.ConditionReady) {
 			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Error(err, "ignoring nodepool, not ready")
 			return false
 		}
+		if np.StatusConditions().Get(v1.ConditionTypePreflightChecksSucceeded).IsFalse() {
+			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).V(1).Info("ignoring nodepool, preflight checks failed")
+			return false
+		}
+		if p.quotaExceeded(np) {
+			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).V(1).Info("ignoring nodepool, quota exceeded")
+			return false
+		}
 		return np.DeletionTimestamp.IsZero()
 	})
 	if len(nodePools) == 0 {
@@ -237,15 +281,26 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stat
 	domains := map[string]sets.Set[string]{}
 	for _, np := range nodePools {
 		its, err := p.cloudProvider.GetInstanceTypes(ctx, np)
-		if err != nil {
+		if err != nil && !p.staleInstanceTypesUsable(ctx, np, err) {
 			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Error(err, "skipping, unable to resolve instance types")
 			continue
 		}
+		if err == nil {
+			scheduler.InstanceTypesStalenessSeconds.Delete(map[string]string{metrics.NodePoolLabel: np.Name})
+		}
 		if len(its) == 0 {
 			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Info("skipping, no resolved instance types found")
 			continue
 		}
 
+		// Offerings that repeatedly launched instances which never registered are skipped until their block expires
+		its = p.cluster.WithoutBlockedOfferings(its)
+		if np.Spec.BurstableCPU == v1.BurstableCPUPackingBaseline {
+			its = lo.Map(its, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType { return it.WithBaselineCPU() })
+		}
+		if np.Spec.SpotAllocationStrategy == v1.SpotAllocationStrategyPriceCapacityOptimized {
+			its = p.withSpotInterruptionRates(ctx, np, its)
+		}
 		instanceTypes[np.Name] = its
 
 		// Construct Topology Domains
@@ -283,8 +338,15 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stat
 		}
 	}
 
+	// apply the transformations that admins have declared for simulation
+	if err = p.applySimulationPolicies(ctx, pods); err != nil {
+		return nil, fmt.Errorf("applying simulation policies, %w", err)
+	}
 	// inject topology constraints
 	pods = p.injectVolumeTopologyRequirements(ctx, pods)
+	if err = p.injectRuntimeClassOverhead(ctx, pods); err != nil {
+		return nil, fmt.Errorf("injecting runtime class overhead, %w", err)
+	}
 
 	// Calculate cluster topology
 	topology, err := scheduler.NewTopology(ctx, p.kubeClient, p.cluster, domains, pods)
@@ -295,11 +357,33 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*corev1.Pod, stat
 	if err != nil {
 		return nil, fmt.Errorf("getting daemon pods, %w", err)
 	}
-	return scheduler.NewScheduler(ctx, p.kubeClient, nodePools, p.cluster, stateNodes, topology, instanceTypes, daemonSetPods, p.recorder, p.clock), nil
+	if err = p.injectRuntimeClassOverhead(ctx, daemonSetPods); err != nil {
+		return nil, fmt.Errorf("injecting daemon runtime class overhead, %w", err)
+	}
+	return scheduler.NewScheduler(ctx, p.kubeClient, nodePools, p.cluster, stateNodes, topology, instanceTypes, daemonSetPods, p.filterCache, p.recorder, p.clock), nil
+}
+
+// withSpotInterruptionRates sets the interruption rates of the spot offerings of the NodePool's instance types, if the
+// CloudProvider reports them. If the rates can't be retrieved, spot offerings are ordered by price alone.
+func (p *Provisioner) withSpotInterruptionRates(ctx context.Context, np *v1.NodePool, its []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	reporter, ok := cloudprovider.As[cloudprovider.SpotStabilityReporter](p.cloudProvider)
+	if !ok {
+		return its
+	}
+	rates, err := reporter.SpotInterruptionRates(ctx, np)
+	if err != nil {
+		log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Error(err, "failed getting spot interruption rates, ordering spot offerings by price")
+		return its
+	}
+	return lo.Map(its, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+		return it.WithSpotInterruptionRates(rates[it.Name])
+	})
 }
 
 func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
-	defer metrics.Measure(scheduler.DurationSeconds, map[string]string{scheduler.ControllerLabel: injection.GetControllerName(ctx)})()
+	ctx, span := tracing.Tracer().Start(ctx, "Provisioner.Schedule")
+	defer span.End()
+	defer metrics.MeasureContext(ctx, scheduler.DurationSeconds, map[string]string{scheduler.ControllerLabel: injection.GetControllerName(ctx)})()
 	start := time.Now()
 
 	// We collect the nodes with their used capacities before we get the list of pending pods. This ensures that
@@ -316,8 +400,14 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 	// Get pods, exit if nothing to do
 	pendingPods, err := p.GetPendingPods(ctx)
 	if err != nil {
+		span.RecordError(err)
 		return scheduler.Results{}, err
 	}
+	if options.FromContext(ctx).PreemptionSimulation {
+		if pendingPods, err = p.withoutPreemptors(ctx, pendingPods, nodes.Active()); err != nil {
+			return scheduler.Results{}, fmt.Errorf("simulating preemption, %w", err)
+		}
+	}
 
 	// Get pods from nodes that are preparing for deletion
 	// We do this after getting the pending pods so that we undershoot if pods are
@@ -328,19 +418,21 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 		return scheduler.Results{}, err
 	}
 	pods := append(pendingPods, deletingNodePods...)
+	span.SetAttributes(attribute.Int("pods.pending", len(pendingPods)), attribute.Int("pods.deleting", len(deletingNodePods)))
 	// nothing to schedule, so just return success
 	if len(pods) == 0 {
 		return scheduler.Results{}, nil
 	}
-	s, err := p.NewScheduler(ctx, pods, nodes.Active())
+	results, err := p.solve(ctx, pods, nodes.Active())
 	if err != nil {
 		if errors.Is(err, ErrNodePoolsNotFound) {
 			log.FromContext(ctx).Info("no nodepools found")
 			return scheduler.Results{}, nil
 		}
+		span.RecordError(err)
 		return scheduler.Results{}, fmt.Errorf("creating scheduler, %w", err)
 	}
-	results := s.Solve(ctx, pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	results = results.TruncateInstanceTypes(scheduler.MaxInstanceTypes)
 	scheduler.UnschedulablePodsCount.Set(float64(len(results.PodErrors)), map[string]string{scheduler.ControllerLabel: injection.GetControllerName(ctx)})
 	if len(results.NewNodeClaims) > 0 {
 		log.FromContext(ctx).WithValues("Pods", pretty.Slice(lo.Map(pods, func(p *corev1.Pod, _ int) string { return klog.KRef(p.Namespace, p.Name).String() }), 5), "duration", time.Since(start)).Info("found provisionable pod(s)")
@@ -351,6 +443,30 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 	return results, nil
 }
 
+// quotaExceeded returns true if the NodePool recently failed to launch because a CloudProvider quota would have been
+// exceeded. Launching from it again won't succeed until the quota is raised, so it's left out of scheduling for
+// QuotaExceededBackoff, after which it's tried again in case the quota was raised in the meantime.
+func (p *Provisioner) quotaExceeded(np *v1.NodePool) bool {
+	cond := np.StatusConditions().Get(v1.ConditionTypeQuotaExceeded)
+	return cond != nil && cond.IsTrue() && p.clock.Since(cond.LastTransitionTime.Time) < QuotaExceededBackoff
+}
+
+// staleInstanceTypesUsable returns true if the CloudProvider returned cached instance types alongside the error, which
+// are recent enough to keep provisioning from while the CloudProvider can't fully resolve them
+func (p *Provisioner) staleInstanceTypesUsable(ctx context.Context, np *v1.NodePool, err error) bool {
+	var staleErr *cloudprovider.StaleInstanceTypesError
+	if !errors.As(err, &staleErr) {
+		return false
+	}
+	staleness := p.clock.Since(staleErr.LastUpdated)
+	scheduler.InstanceTypesStalenessSeconds.Set(staleness.Seconds(), map[string]string{metrics.NodePoolLabel: np.Name})
+	if staleness > options.FromContext(ctx).MaxInstanceTypeStaleness {
+		return false
+	}
+	log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name), "staleness", staleness).V(1).Info("using stale instance types")
+	return true
+}
+
 func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts ...option.Function[LaunchOptions]) (string, error) {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodePool", klog.KRef("", n.NodePoolName)))
 	options := option.Resolve(opts...)
@@ -361,11 +477,31 @@ func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts .
 	if err := latest.Spec.Limits.ExceededBy(latest.Status.Resources); err != nil {
 		return "", err
 	}
+	if fleetLimitsEnabled(ctx) {
+		p.fleetLimitsMu.Lock()
+		defer p.fleetLimitsMu.Unlock()
+		if err := p.enforceFleetLimits(ctx, n); err != nil {
+			for _, pod := range n.Pods {
+				p.recorder.Publish(scheduler.FleetLimitsExceededEvent(pod, err))
+			}
+			return "", err
+		}
+	}
 	nodeClaim := n.ToNodeClaim()
+	nodeClaim.Spec.TemplateVariables = nodeclaimutils.TemplateVariables(ctx, nodeClaim)
 
+	// Pods that requested a dedicated node are given their toleration before the NodeClaim is created, so that a failure
+	// doesn't leave behind a tainted NodeClaim that no pod can schedule to
+	if err := p.tolerateExclusiveNodeClaim(ctx, n); err != nil {
+		return "", err
+	}
 	if err := p.kubeClient.Create(ctx, nodeClaim); err != nil {
 		return "", err
 	}
+	p.recordProvenance(ctx, latest, nodeClaim, n.Pods)
+	if shortfall := n.SpotDiversificationShortfall(); shortfall != "" {
+		p.recorder.Publish(scheduler.SpotDiversificationBelowFloorEvent(nodeClaim, shortfall))
+	}
 	instanceTypeRequirement, _ := lo.Find(nodeClaim.Spec.Requirements, func(req v1.NodeSelectorRequirementWithMinValues) bool {
 		return req.Key == corev1.LabelInstanceTypeStable
 	})
@@ -386,11 +522,38 @@ func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts .
 	if option.Resolve(opts...).RecordPodNomination {
 		for _, pod := range n.Pods {
 			p.recorder.Publish(scheduler.NominatePodEvent(pod, nil, nodeClaim))
+			p.cluster.PredictPlacement(pod, nodeClaim.Name, "")
 		}
 	}
 	return nodeClaim.Name, nil
 }
 
+// tolerateExclusiveNodeClaim adds the exclusive toleration to any pod that requested a dedicated node so that the
+// kube-scheduler is able to bind the pod to the tainted node that we launched for it
+func (p *Provisioner) tolerateExclusiveNodeClaim(ctx context.Context, n *scheduler.NodeClaim) error {
+	for _, pod := range n.Pods {
+		if !podutils.IsExclusive(pod) {
+			continue
+		}
+		latest := &corev1.Pod{}
+		if err := p.kubeClient.Get(ctx, client.ObjectKeyFromObject(pod), latest); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("getting pod, %w", err)
+		}
+		if podutils.ToleratesExclusiveTaint(latest) {
+			continue
+		}
+		stored := latest.DeepCopy()
+		latest.Spec.Tolerations = append(latest.Spec.Tolerations, v1.ExclusiveToleration(latest.UID))
+		if err := p.kubeClient.Patch(ctx, latest, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("patching pod tolerations, %w", err)
+		}
+	}
+	return nil
+}
+
 func instanceTypeList(names []string) string {
 	var itSb strings.Builder
 	for i, name := range names {
@@ -411,11 +574,19 @@ func (p *Provisioner) getDaemonSetPods(ctx context.Context) ([]*corev1.Pod, erro
 	if err := p.kubeClient.List(ctx, daemonSetList); err != nil {
 		return nil, fmt.Errorf("listing daemonsets, %w", err)
 	}
+	revisions, err := p.currentDaemonSetRevisions(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	return lo.Map(daemonSetList.Items, func(d appsv1.DaemonSet, _ int) *corev1.Pod {
 		pod := p.cluster.GetDaemonSetPod(&d)
 		if pod == nil {
 			pod = &corev1.Pod{Spec: d.Spec.Template.Spec}
+		} else if isDaemonSetPodOutdated(revisions[d.UID], pod) {
+			// The DaemonSet is rolling out a new pod template, so we simulate using the incoming requests to avoid
+			// launching or keeping nodes that become over-committed once the rollout reaches them
+			projectDaemonSetPodRequests(&d, pod)
 		}
 		// Replacing retrieved pod affinity with daemonset pod template required node affinity since this is overridden
 		// by the daemonset controller during pod creation
@@ -433,11 +604,59 @@ func (p *Provisioner) getDaemonSetPods(ctx context.Context) ([]*corev1.Pod, erro
 	}), nil
 }
 
+// currentDaemonSetRevisions returns the hash of the current ControllerRevision of each DaemonSet, keyed by the
+// DaemonSet's UID. The DaemonSet controller labels the pods that it creates with the hash of the revision they're from.
+// Only the DaemonSets' revisions carry that label, so that other revisions, like StatefulSets', are never listed.
+func (p *Provisioner) currentDaemonSetRevisions(ctx context.Context) (map[types.UID]string, error) {
+	revisionList := &appsv1.ControllerRevisionList{}
+	if err := p.kubeClient.List(ctx, revisionList, client.HasLabels{appsv1.DefaultDaemonSetUniqueLabelKey}); err != nil {
+		return nil, fmt.Errorf("listing controllerrevisions, %w", err)
+	}
+	current := map[types.UID]*appsv1.ControllerRevision{}
+	for i := range revisionList.Items {
+		revision := &revisionList.Items[i]
+		owner := metav1.GetControllerOf(revision)
+		if owner == nil || owner.Kind != "DaemonSet" {
+			continue
+		}
+		if c, ok := current[owner.UID]; !ok || revision.Revision > c.Revision {
+			current[owner.UID] = revision
+		}
+	}
+	return lo.MapValues(current, func(r *appsv1.ControllerRevision, _ types.UID) string {
+		return r.Labels[appsv1.DefaultDaemonSetUniqueLabelKey]
+	}), nil
+}
+
+// isDaemonSetPodOutdated returns true if the pod was created from an older revision than the DaemonSet's current one
+func isDaemonSetPodOutdated(currentRevision string, pod *corev1.Pod) bool {
+	revision, ok := pod.Labels[appsv1.DefaultDaemonSetUniqueLabelKey]
+	return ok && currentRevision != "" && revision != currentRevision
+}
+
+// projectDaemonSetPodRequests overwrites the container requests of the pod with the requests from the DaemonSet's pod
+// template. Requests which aren't set in the template are left as-is since they may have been set by an admission
+// controller (e.g. a LimitRange default) and will continue to be set for the new pods.
+func projectDaemonSetPodRequests(d *appsv1.DaemonSet, pod *corev1.Pod) {
+	project := func(containers []corev1.Container, templates []corev1.Container) {
+		for i := range containers {
+			template, ok := lo.Find(templates, func(c corev1.Container) bool { return c.Name == containers[i].Name })
+			if !ok || len(template.Resources.Requests) == 0 {
+				continue
+			}
+			containers[i].Resources.Requests = lo.Assign(containers[i].Resources.Requests, template.Resources.Requests)
+		}
+	}
+	project(pod.Spec.Containers, d.Spec.Template.Spec.Containers)
+	project(pod.Spec.InitContainers, d.Spec.Template.Spec.InitContainers)
+}
+
 func (p *Provisioner) Validate(ctx context.Context, pod *corev1.Pod) error {
 	return multierr.Combine(
 		validateKarpenterManagedLabelCanExist(pod),
 		validateNodeSelector(pod),
 		validateAffinity(pod),
+		p.validateNotTerminating(ctx, pod),
 		p.volumeTopology.ValidatePersistentVolumeClaims(ctx, pod),
 	)
 }
@@ -454,10 +673,65 @@ func validateKarpenterManagedLabelCanExist(p *corev1.Pod) error {
 	return nil
 }
 
+// validateNotTerminating rejects pods whose namespace or owning Job is being torn down. The pods will be deleted along
+// with their owner, so any capacity launched for them would go unused.
+func (p *Provisioner) validateNotTerminating(ctx context.Context, pod *corev1.Pod) error {
+	namespace := &corev1.Namespace{}
+	if err := p.kubeClient.Get(ctx, client.ObjectKey{Name: pod.Namespace}, namespace); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "failed getting namespace", "Namespace", klog.KRef("", pod.Namespace))
+		}
+	} else if !namespace.DeletionTimestamp.IsZero() {
+		return fmt.Errorf("namespace %q is terminating", pod.Namespace)
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.APIVersion != batchv1.SchemeGroupVersion.String() || owner.Kind != "Job" {
+		return nil
+	}
+	job := &batchv1.Job{}
+	if err := p.kubeClient.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: owner.Name}, job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "failed getting job", "Job", klog.KRef(pod.Namespace, owner.Name))
+		}
+		return nil
+	}
+	if !job.DeletionTimestamp.IsZero() {
+		return fmt.Errorf("job %q is terminating", owner.Name)
+	}
+	if jobTTLExpired(job, p.clock.Now()) {
+		return fmt.Errorf("job %q is finished and past its ttlSecondsAfterFinished", owner.Name)
+	}
+	return nil
+}
+
+// jobTTLExpired returns true if the Job has finished and is past its TTL, so it's due to be deleted by the
+// TTL-after-finished controller
+func jobTTLExpired(job *batchv1.Job, now time.Time) bool {
+	if job.Spec.TTLSecondsAfterFinished == nil {
+		return false
+	}
+	condition, ok := lo.Find(job.Status.Conditions, func(c batchv1.JobCondition) bool {
+		return (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue
+	})
+	if !ok {
+		return false
+	}
+	finishedAt := condition.LastTransitionTime.Time
+	if job.Status.CompletionTime != nil {
+		finishedAt = job.Status.CompletionTime.Time
+	}
+	return !now.Before(finishedAt.Add(time.Duration(*job.Spec.TTLSecondsAfterFinished) * time.Second))
+}
+
 func (p *Provisioner) injectVolumeTopologyRequirements(ctx context.Context, pods []*corev1.Pod) []*corev1.Pod {
+	// The offerings that volumes were last attached to are only preferences, so pods are still scheduled without them
+	attachments, err := p.volumeTopology.ListVolumeAttachments(ctx)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed getting volume attachments")
+	}
 	var schedulablePods []*corev1.Pod
 	for _, pod := range pods {
-		if err := p.volumeTopology.Inject(ctx, pod); err != nil {
+		if err := p.volumeTopology.Inject(ctx, pod, attachments); err != nil {
 			log.FromContext(ctx).WithValues("Pod", klog.KRef(pod.Namespace, pod.Name)).Error(err, "failed getting volume topology requirements")
 		} else {
 			schedulablePods = append(schedulablePods, pod)
@@ -466,6 +740,35 @@ func (p *Provisioner) injectVolumeTopologyRequirements(ctx context.Context, pods
 	return schedulablePods
 }
 
+// injectRuntimeClassOverhead sets the overhead of pods which use a RuntimeClass but don't have their overhead set. The
+// RuntimeClass admission controller normally sets it when the pod is created, but pods that we simulate from a DaemonSet
+// template never go through admission, so without this we'd pack them as if the sandbox (e.g. kata) was free.
+func (p *Provisioner) injectRuntimeClassOverhead(ctx context.Context, pods []*corev1.Pod) error {
+	if !lo.ContainsBy(pods, needsRuntimeClassOverhead) {
+		return nil
+	}
+	runtimeClassList := &nodev1.RuntimeClassList{}
+	if err := p.kubeClient.List(ctx, runtimeClassList); err != nil {
+		return fmt.Errorf("listing runtimeclasses, %w", err)
+	}
+	overheads := lo.SliceToMap(runtimeClassList.Items, func(rc nodev1.RuntimeClass) (string, *nodev1.Overhead) {
+		return rc.Name, rc.Overhead
+	})
+	for _, pod := range pods {
+		if !needsRuntimeClassOverhead(pod) {
+			continue
+		}
+		if overhead, ok := overheads[*pod.Spec.RuntimeClassName]; ok && overhead != nil && len(overhead.PodFixed) != 0 {
+			pod.Spec.Overhead = overhead.PodFixed.DeepCopy()
+		}
+	}
+	return nil
+}
+
+func needsRuntimeClassOverhead(pod *corev1.Pod) bool {
+	return pod.Spec.RuntimeClassName != nil && pod.Spec.Overhead == nil
+}
+
 func validateNodeSelector(p *corev1.Pod) (errs error) {
 	terms := lo.MapToSlice(p.Spec.NodeSelector, func(k string, v string) corev1.NodeSelectorTerm {
 		return corev1.NodeSelectorTerm{
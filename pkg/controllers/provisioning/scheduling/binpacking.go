@@ -0,0 +1,125 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// orderForPacking orders the NodeClaims that a pod is tried against. NodeClaims with the fewest pods are tried first,
+// except that NodeClaims of NodePools that pack by LeastWaste or Balanced are ordered among themselves so that the
+// NodeClaim the pod fits most tightly is tried first. They keep the positions that they had, so that the order of the
+// other NodeClaims doesn't depend on the strategy of another NodePool.
+func orderForPacking(nodeClaims []*NodeClaim, podRequests corev1.ResourceList) {
+	sort.Slice(nodeClaims, func(a, b int) bool { return len(nodeClaims[a].Pods) < len(nodeClaims[b].Pods) })
+	var slots []int
+	var waste []float64
+	for i, n := range nodeClaims {
+		if n.BinPacking != v1.BinPackingStrategyLeastWaste && n.BinPacking != v1.BinPackingStrategyBalanced {
+			continue
+		}
+		requests := resources.Merge(n.Spec.Resources.Requests, podRequests)
+		w := math.MaxFloat64
+		if it, _ := cheapestFit(n.InstanceTypeOptions, n.Requirements, requests); it != nil {
+			w = unusedFraction(it, requests)
+		}
+		slots, waste = append(slots, i), append(waste, w)
+	}
+	if len(slots) == 0 {
+		return
+	}
+	bestFit := make([]int, len(slots))
+	for i := range bestFit {
+		bestFit[i] = i
+	}
+	sort.SliceStable(bestFit, func(a, b int) bool { return waste[bestFit[a]] < waste[bestFit[b]] })
+	ordered := make([]*NodeClaim, len(slots))
+	for i, j := range bestFit {
+		ordered[i] = nodeClaims[slots[j]]
+	}
+	for i, slot := range slots {
+		nodeClaims[slot] = ordered[i]
+	}
+}
+
+// admitsByPrice returns whether packing the pod onto the NodeClaim keeps to its NodePool's price bound. NodePools that
+// pack by LowestPrice or Balanced only pack a pod onto a NodeClaim if that raises the price of the cheapest instance
+// type the NodeClaim can launch by no more than the price of a new NodeClaim for the pod. Otherwise, packing the pod
+// could move the NodeClaim onto a much larger instance type than launching a second, smaller one.
+func (n *NodeClaim) admitsByPrice(podRequests corev1.ResourceList) bool {
+	if n.BinPacking != v1.BinPackingStrategyLowestPrice && n.BinPacking != v1.BinPackingStrategyBalanced {
+		return true
+	}
+	_, before := cheapestFit(n.InstanceTypeOptions, n.Requirements, n.Spec.Resources.Requests)
+	it, after := cheapestFit(n.InstanceTypeOptions, n.Requirements, resources.Merge(n.Spec.Resources.Requests, podRequests))
+	if it == nil {
+		// The pod doesn't fit, which NodeClaim.Add reports
+		return true
+	}
+	if it, alone := cheapestFit(n.templateInstanceTypes, n.Requirements, resources.Merge(n.daemonResources, podRequests)); it != nil {
+		return after-before <= alone
+	}
+	return true
+}
+
+// cheapestFit returns the cheapest instance type that fits the requests and has an available offering compatible with
+// the requirements, along with the price of that offering
+func cheapestFit(instanceTypes cloudprovider.InstanceTypes, requirements scheduling.Requirements, requests corev1.ResourceList) (*cloudprovider.InstanceType, float64) {
+	var cheapest *cloudprovider.InstanceType
+	price := math.MaxFloat64
+	for _, it := range instanceTypes {
+		if !fits(it, requests) {
+			continue
+		}
+		offerings := it.Offerings.Available().Compatible(requirements)
+		if len(offerings) == 0 {
+			continue
+		}
+		if p := offerings.Cheapest().Price; p < price {
+			cheapest, price = it, p
+		}
+	}
+	return cheapest, price
+}
+
+// unusedFraction is the fraction of the instance type's allocatable CPU and memory that's left unused by the requests
+func unusedFraction(instanceType *cloudprovider.InstanceType, requests corev1.ResourceList) float64 {
+	allocatable := instanceType.Allocatable()
+	var unused float64
+	var counted int
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		total, ok := allocatable[name]
+		if !ok || total.IsZero() {
+			continue
+		}
+		requested := requests[name]
+		unused += 1 - float64(requested.MilliValue())/float64(total.MilliValue())
+		counted++
+	}
+	if counted == 0 {
+		return 0
+	}
+	return unused / float64(counted)
+}
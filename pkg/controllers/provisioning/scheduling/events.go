@@ -59,3 +59,57 @@ func PodFailedToScheduleEvent(pod *corev1.Pod, err error) events.Event {
 		DedupeTimeout:  5 * time.Minute,
 	}
 }
+
+func PodDeferredByLimitsEvent(pod *corev1.Pod, nodePools []string) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "NodePoolLimitsExceeded",
+		Message:        fmt.Sprintf("Pod deferred, compatible NodePools are at their limits (%s)", strings.Join(nodePools, ", ")),
+		DedupeValues:   []string{string(pod.UID)},
+		DedupeTimeout:  5 * time.Minute,
+	}
+}
+
+func PodAwaitingPreemptionEvent(pod *corev1.Pod, nodeName string) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "AwaitingPreemption",
+		Message:        fmt.Sprintf("Not provisioning for pod, it can schedule on node/%s by preempting lower priority pods", nodeName),
+		DedupeValues:   []string{string(pod.UID)},
+		DedupeTimeout:  5 * time.Minute,
+	}
+}
+
+func FleetLimitsExceededEvent(pod *corev1.Pod, err error) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "FleetLimitsExceeded",
+		Message:        fmt.Sprintf("Cannot launch a node for pod, %s", err),
+		DedupeValues:   []string{string(pod.UID)},
+		DedupeTimeout:  5 * time.Minute,
+	}
+}
+
+func VolumeZoneConflictEvent(pod *corev1.Pod, volumeZones, podZones fmt.Stringer) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "VolumeZoneConflict",
+		Message:        fmt.Sprintf("Pod volumes are pinned to %s, which conflicts with the pod's %s", volumeZones, podZones),
+		DedupeValues:   []string{string(pod.UID)},
+		DedupeTimeout:  5 * time.Minute,
+	}
+}
+
+func SpotDiversificationBelowFloorEvent(nodeClaim *v1.NodeClaim, shortfall string) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "SpotDiversificationBelowFloor",
+		Message:        fmt.Sprintf("Launching with spot offerings across %s", shortfall),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
@@ -20,7 +20,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
@@ -32,15 +34,20 @@ type ExistingNode struct {
 	*state.StateNode
 	cachedAvailable v1.ResourceList // Cache so we don't have to re-subtract resources on the StateNode every time
 	cachedTaints    []v1.Taint      // Cache so we don't hae to re-construct the taints each time we attempt to schedule a pod
-
-	Pods         []*v1.Pod
-	topology     *Topology
-	requests     v1.ResourceList
-	requirements scheduling.Requirements
+	// preferNoScheduleTaints are kept apart from the cached taints since they don't prevent pods from scheduling
+	preferNoScheduleTaints []v1.Taint
+
+	Pods          []*v1.Pod
+	topology      *Topology
+	requests      v1.ResourceList
+	requirements  scheduling.Requirements
+	hostPortUsage *scheduling.HostPortUsage
+	volumeUsage   *scheduling.VolumeUsage
 }
 
 func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint, daemonResources v1.ResourceList) *ExistingNode {
-	// The state node passed in here must be a deep copy from cluster state as we modify it
+	// The state node passed in here is shared with other snapshots of cluster state, so the usage that's modified while
+	// scheduling is copied rather than modified in place.
 	// the remaining daemonResources to schedule are the total daemonResources minus what has already scheduled
 	remainingDaemonResources := resources.Subtract(daemonResources, n.DaemonSetRequests())
 	// If unexpected daemonset pods schedule to the node due to labels appearing on the node which cause the
@@ -55,16 +62,39 @@ func NewExistingNode(n *state.StateNode, topology *Topology, taints []v1.Taint,
 	node := &ExistingNode{
 		StateNode:       n,
 		cachedAvailable: n.Available(),
-		cachedTaints:    taints,
+		cachedTaints:    scheduling.Taints(taints).Required(),
 		topology:        topology,
 		requests:        remainingDaemonResources,
 		requirements:    scheduling.NewLabelRequirements(n.Labels()),
+		hostPortUsage:   n.HostPortUsage().DeepCopy(),
+		volumeUsage:     n.VolumeUsage().DeepCopy(),
 	}
+	node.preferNoScheduleTaints = lo.Filter(taints, func(t v1.Taint, _ int) bool { return t.Effect == v1.TaintEffectPreferNoSchedule })
 	node.requirements.Add(scheduling.NewRequirement(v1.LabelHostname, v1.NodeSelectorOpIn, n.HostName()))
-	topology.Register(v1.LabelHostname, n.HostName())
+	topology.RegisterBounded(v1.LabelHostname, n.HostName())
 	return node
 }
 
+// reserveBurstCPU keeps pods from being packed onto the CPU that the node can only burst to, above its baseline CPU
+func (n *ExistingNode) reserveBurstCPU(baseline resource.Quantity) {
+	burst := n.Capacity()[v1.ResourceCPU]
+	burst.Sub(baseline)
+	if burst.Sign() <= 0 {
+		return
+	}
+	available := n.cachedAvailable[v1.ResourceCPU]
+	available.Sub(burst)
+	if available.Sign() < 0 {
+		available.Set(0)
+	}
+	n.cachedAvailable[v1.ResourceCPU] = available
+}
+
+// PreferNoScheduleCost returns the number of the node's PreferNoSchedule taints that the pod doesn't tolerate
+func (n *ExistingNode) PreferNoScheduleCost(pod *v1.Pod) int {
+	return scheduling.Taints(n.preferNoScheduleTaints).PreferNoScheduleCost(pod)
+}
+
 func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v1.Pod, podRequests v1.ResourceList) error {
 	// Check Taints
 	if err := scheduling.Taints(n.cachedTaints).Tolerates(pod); err != nil {
@@ -77,10 +107,10 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 	}
 	// determine the host ports that will be used if the pod schedules
 	hostPorts := scheduling.GetHostPorts(pod)
-	if err = n.VolumeUsage().ExceedsLimits(volumes); err != nil {
+	if err = n.volumeUsage.ExceedsLimits(volumes); err != nil {
 		return fmt.Errorf("checking volume usage, %w", err)
 	}
-	if err = n.HostPortUsage().Conflicts(pod, hostPorts); err != nil {
+	if err = n.hostPortUsage.Conflicts(pod, hostPorts); err != nil {
 		return fmt.Errorf("checking host port usage, %w", err)
 	}
 
@@ -122,7 +152,7 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 	n.requests = requests
 	n.requirements = nodeRequirements
 	n.topology.Record(pod, nodeRequirements)
-	n.HostPortUsage().Add(pod, hostPorts)
-	n.VolumeUsage().Add(pod, volumes)
+	n.hostPortUsage.Add(pod, hostPorts)
+	n.volumeUsage.Add(pod, volumes)
 	return nil
 }
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	scheduler "sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
@@ -642,6 +643,34 @@ var _ = Describe("Instance Type Selection", func() {
 		node := ExpectScheduled(ctx, env.Client, pod)
 		Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("test-instance1"))
 	})
+	DescribeTable("should break instance type price ties",
+		func(preferNewerGenerations bool, expected string) {
+			tieBreakCtx := options.ToContext(ctx, test.Options(test.OptionsFields{PreferNewerGenerations: lo.ToPtr(preferNewerGenerations)}))
+			cloudProvider.InstanceTypes = lo.Map([]int{4, 5}, func(generation int, _ int) *cloudprovider.InstanceType {
+				return fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:             fmt.Sprintf("gen%d-instance", generation),
+					Architecture:     "amd64",
+					OperatingSystems: sets.New(string(corev1.Linux)),
+					Generation:       generation,
+					Offerings: []cloudprovider.Offering{
+						{Requirements: scheduler.NewLabelRequirements(map[string]string{v1.CapacityTypeLabelKey: v1.CapacityTypeOnDemand, corev1.LabelTopologyZone: "test-zone-1a"}), Price: 1.0, Available: true},
+					},
+				})
+			})
+			// Only launch with the first instance type in the tie-break order
+			scheduling.MaxInstanceTypes = 1
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(tieBreakCtx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(supportedInstanceTypes(cloudProvider.CreateCalls[0])).To(ConsistOf(
+				WithTransform(func(it *cloudprovider.InstanceType) string { return it.Name }, Equal(expected)),
+			))
+		},
+		Entry("by name by default", false, "gen4-instance"),
+		Entry("by newest generation when preferring newer generations", true, "gen5-instance"),
+	)
 	Context("MinValues", func() {
 		It("should schedule respecting the minValues from instance-type requirements", func() {
 			var instanceTypes []*cloudprovider.InstanceType
@@ -1563,4 +1592,245 @@ var _ = Describe("Instance Type Selection", func() {
 			Expect(len(supportedInstanceTypes(cloudProvider.CreateCalls[0]))).To(BeNumerically(">=", 2))
 		})
 	})
+	Context("Reserved Capacity", func() {
+		capacityTypes := func(nodeClaim *v1.NodeClaim) []string {
+			return scheduler.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1.CapacityTypeLabelKey).Values()
+		}
+		BeforeEach(func() {
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+						Key:      v1.CapacityTypeLabelKey,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   []string{v1.CapacityTypeReserved, v1.CapacityTypeOnDemand},
+					},
+				},
+			}
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "reserved-instance-type",
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduler.NewLabelRequirements(map[string]string{
+								v1.CapacityTypeLabelKey:  v1.CapacityTypeReserved,
+								corev1.LabelTopologyZone: "test-zone-1",
+							}),
+							Price:               0.01,
+							Available:           true,
+							ReservationCapacity: 1,
+						},
+						{
+							Requirements: scheduler.NewLabelRequirements(map[string]string{
+								v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+								corev1.LabelTopologyZone: "test-zone-1",
+							}),
+							Price:     1.0,
+							Available: true,
+						},
+					},
+				}),
+			}
+		})
+		It("should launch reserved capacity while the reservation has capacity", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeReserved))
+			Expect(capacityTypes(cloudProvider.CreateCalls[0])).To(ConsistOf(v1.CapacityTypeReserved))
+		})
+		It("should fall back to on-demand capacity once the reservation is full", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := lo.Times(2, func(_ int) *corev1.Pod {
+				return test.UnschedulablePod(test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.5")}},
+				})
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			node1 := ExpectScheduled(ctx, env.Client, pods[0])
+			node2 := ExpectScheduled(ctx, env.Client, pods[1])
+			Expect(node1.Name).ToNot(Equal(node2.Name))
+			Expect([]string{node1.Labels[v1.CapacityTypeLabelKey], node2.Labels[v1.CapacityTypeLabelKey]}).To(ConsistOf(v1.CapacityTypeReserved, v1.CapacityTypeOnDemand))
+		})
+		It("should launch NodeClaims that require reserved capacity on reserved capacity", func() {
+			nodePool.Spec.Template.Spec.Requirements[0].Values = []string{v1.CapacityTypeReserved}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(capacityTypes(cloudProvider.CreateCalls[0])).To(ConsistOf(v1.CapacityTypeReserved))
+		})
+		It("should launch NodeClaims into the reserved offerings that they took capacity from", func() {
+			// The cloudprovider launches into the first compatible offering, which isn't the cheapest reservation
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "reserved-instance-type",
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("2"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduler.NewLabelRequirements(map[string]string{
+								v1.CapacityTypeLabelKey:  v1.CapacityTypeReserved,
+								corev1.LabelTopologyZone: "test-zone-1",
+							}),
+							Price:               0.02,
+							Available:           true,
+							ReservationCapacity: 1,
+						},
+						{
+							Requirements: scheduler.NewLabelRequirements(map[string]string{
+								v1.CapacityTypeLabelKey:  v1.CapacityTypeReserved,
+								corev1.LabelTopologyZone: "test-zone-2",
+							}),
+							Price:               0.01,
+							Available:           true,
+							ReservationCapacity: 1,
+						},
+						{
+							Requirements: scheduler.NewLabelRequirements(map[string]string{
+								v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+								corev1.LabelTopologyZone: "test-zone-1",
+							}),
+							Price:     1.0,
+							Available: true,
+						},
+					},
+				}),
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := lo.Times(3, func(_ int) *corev1.Pod {
+				return test.UnschedulablePod(test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.5")}},
+				})
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodes := lo.Map(pods, func(pod *corev1.Pod, _ int) *corev1.Node { return ExpectScheduled(ctx, env.Client, pod) })
+			Expect(lo.Map(nodes, func(node *corev1.Node, _ int) string {
+				return node.Labels[corev1.LabelTopologyZone] + "/" + node.Labels[v1.CapacityTypeLabelKey]
+			})).To(ConsistOf("test-zone-1/"+v1.CapacityTypeReserved, "test-zone-2/"+v1.CapacityTypeReserved, "test-zone-1/"+v1.CapacityTypeOnDemand))
+		})
+	})
+	Context("Bin Packing", func() {
+		Context("Price", func() {
+			var pods []*corev1.Pod
+			BeforeEach(func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "small-instance-type",
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					}),
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "large-instance-type",
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("16"),
+							corev1.ResourceMemory: resource.MustParse("64Gi"),
+						},
+					}),
+				}
+				pods = lo.Times(2, func(_ int) *corev1.Pod {
+					return test.UnschedulablePod(test.PodOptions{
+						ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.5")}},
+					})
+				})
+			})
+			It("should pack pods onto the fewest nodes by default", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				node1 := ExpectScheduled(ctx, env.Client, pods[0])
+				node2 := ExpectScheduled(ctx, env.Client, pods[1])
+				Expect(node1.Name).To(Equal(node2.Name))
+				Expect(node1.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "large-instance-type"))
+			})
+			DescribeTable("should launch a new node when packing a pod would cost more than the node",
+				func(strategy v1.BinPackingStrategy) {
+					nodePool.Spec.BinPacking = strategy
+					ExpectApplied(ctx, env.Client, nodePool)
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+					node1 := ExpectScheduled(ctx, env.Client, pods[0])
+					node2 := ExpectScheduled(ctx, env.Client, pods[1])
+					Expect(node1.Name).ToNot(Equal(node2.Name))
+					Expect(node1.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "small-instance-type"))
+					Expect(node2.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "small-instance-type"))
+				},
+				Entry("LowestPrice", v1.BinPackingStrategyLowestPrice),
+				Entry("Balanced", v1.BinPackingStrategyBalanced),
+			)
+			It("should use the configured strategy for NodePools that don't set one", func() {
+				lowestPriceCtx := options.ToContext(ctx, test.Options(test.OptionsFields{BinPackingStrategy: lo.ToPtr(string(v1.BinPackingStrategyLowestPrice))}))
+				ExpectApplied(lowestPriceCtx, env.Client, nodePool)
+				ExpectProvisioned(lowestPriceCtx, env.Client, cluster, cloudProvider, prov, pods...)
+				node1 := ExpectScheduled(ctx, env.Client, pods[0])
+				node2 := ExpectScheduled(ctx, env.Client, pods[1])
+				Expect(node1.Name).ToNot(Equal(node2.Name))
+			})
+			It("should pack pods when that's cheaper than a new node", func() {
+				nodePool.Spec.BinPacking = v1.BinPackingStrategyLowestPrice
+				cloudProvider.InstanceTypes = cloudProvider.InstanceTypes[:1]
+				cloudProvider.InstanceTypes = append(cloudProvider.InstanceTypes, fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "medium-instance-type",
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("4"),
+						corev1.ResourceMemory: resource.MustParse("4Gi"),
+					},
+				}))
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				node1 := ExpectScheduled(ctx, env.Client, pods[0])
+				node2 := ExpectScheduled(ctx, env.Client, pods[1])
+				Expect(node1.Name).To(Equal(node2.Name))
+				Expect(node1.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "medium-instance-type"))
+			})
+		})
+		Context("Waste", func() {
+			var pods []*corev1.Pod
+			BeforeEach(func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "default-instance-type",
+						Resources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("4"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					}),
+				}
+				// The first two pods need their own nodes, and the third fits on either of them. The last pod fits both nodes,
+				// but the first node is left with much less unused memory.
+				pods = lo.Map([][]string{{"2", "3.5Gi"}, {"2", "100Mi"}, {"1", "100Mi"}, {"500m", "300Mi"}}, func(r []string, _ int) *corev1.Pod {
+					return test.UnschedulablePod(test.PodOptions{
+						ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(r[0]),
+							corev1.ResourceMemory: resource.MustParse(r[1]),
+						}},
+					})
+				})
+			})
+			It("should pack pods onto the node with the fewest pods by default", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				Expect(ExpectScheduled(ctx, env.Client, pods[3]).Name).To(Equal(ExpectScheduled(ctx, env.Client, pods[1]).Name))
+			})
+			DescribeTable("should pack pods onto the node that they fit most tightly",
+				func(strategy v1.BinPackingStrategy) {
+					nodePool.Spec.BinPacking = strategy
+					ExpectApplied(ctx, env.Client, nodePool)
+					ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+					node := ExpectScheduled(ctx, env.Client, pods[0])
+					Expect(ExpectScheduled(ctx, env.Client, pods[2]).Name).To(Equal(node.Name))
+					Expect(ExpectScheduled(ctx, env.Client, pods[3]).Name).To(Equal(node.Name))
+					Expect(ExpectScheduled(ctx, env.Client, pods[1]).Name).ToNot(Equal(node.Name))
+				},
+				Entry("LeastWaste", v1.BinPackingStrategyLeastWaste),
+				Entry("Balanced", v1.BinPackingStrategyBalanced),
+			)
+		})
+	})
 })
@@ -0,0 +1,103 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// InstanceTypeFilterCacheTTL is how long the instance types selected for a pod shape are reused across scheduling
+// simulations. Cached results are keyed on the instance types and their offerings, so a NodePool or offering change
+// results in a cache miss regardless of the TTL.
+const InstanceTypeFilterCacheTTL = time.Minute
+
+// InstanceTypeFilterCache caches the instance types that are compatible with the first pod scheduled to a new NodeClaim.
+// A large scale-up of identical replicas creates many NodeClaims from the same NodeClaimTemplate, and each of them
+// would otherwise filter the full set of instance types for the same requirements and requests.
+type InstanceTypeFilterCache struct {
+	cache *cache.Cache
+}
+
+func NewInstanceTypeFilterCache() *InstanceTypeFilterCache {
+	return &InstanceTypeFilterCache{
+		cache: cache.New(InstanceTypeFilterCacheTTL, time.Minute),
+	}
+}
+
+type cachedFilterResults struct {
+	filterResults
+	// remaining holds instance type names rather than the instance types themselves since the instance types are
+	// retrieved from the CloudProvider again for every scheduling simulation
+	remaining []string
+}
+
+func (c *InstanceTypeFilterCache) filter(instanceTypesKey string, instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, requests corev1.ResourceList) filterResults {
+	key := hashKey(instanceTypesKey, requirements.Key(), resources.String(requests))
+	if cached, ok := c.cache.Get(key); ok {
+		InstanceTypeFilterCacheRequestsTotal.Inc(map[string]string{resultLabel: cacheHit})
+		entry := cached.(cachedFilterResults)
+		results := entry.filterResults
+		names := sets.New(entry.remaining...)
+		results.remaining = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool { return names.Has(it.Name) })
+		return results
+	}
+	InstanceTypeFilterCacheRequestsTotal.Inc(map[string]string{resultLabel: cacheMiss})
+	results := filterInstanceTypesByRequirements(instanceTypes, requirements, requests)
+	entry := cachedFilterResults{
+		filterResults: results,
+		remaining:     lo.Map(results.remaining, func(it *cloudprovider.InstanceType, _ int) string { return it.Name }),
+	}
+	entry.filterResults.remaining = nil
+	c.cache.SetDefault(key, entry)
+	return results
+}
+
+// instanceTypesKey identifies a set of instance types along with everything that filterInstanceTypesByRequirements
+// considers, including the availability and price of their offerings
+func instanceTypesKey(nodeClaimTemplate *NodeClaimTemplate) string {
+	var parts []string
+	parts = append(parts, nodeClaimTemplate.NodePoolName)
+	for _, it := range nodeClaimTemplate.InstanceTypeOptions {
+		parts = append(parts, it.Name, it.Requirements.Key(), resources.String(it.Allocatable()))
+		for _, o := range it.Offerings {
+			parts = append(parts, o.Requirements.Key(), strconv.FormatFloat(o.Price, 'f', -1, 64), strconv.FormatBool(o.Available))
+		}
+	}
+	return hashKey(parts...)
+}
+
+func hashKey(parts ...string) string {
+	h := fnv.New64a()
+	for _, part := range parts {
+		// Write a separator so that adjacent parts can't run together into the same key
+		_, _ = h.Write([]byte(part))
+		_, _ = h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum64())
+}
@@ -27,7 +27,15 @@ import (
 const (
 	ControllerLabel    = "controller"
 	schedulingIDLabel  = "scheduling_id"
+	boundLabel         = "bound"
+	resultLabel        = "result"
 	schedulerSubsystem = "scheduler"
+
+	candidateNodesBound  = "candidate_nodes"
+	topologyDomainsBound = "topology_domains"
+
+	cacheHit  = "hit"
+	cacheMiss = "miss"
 )
 
 var (
@@ -91,4 +99,90 @@ var (
 			ControllerLabel,
 		},
 	)
+	SimulationExistingNodes = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "simulation_existing_nodes",
+			Help:      "The number of existing nodes considered as scheduling targets in the most recent scheduling simulation.",
+		},
+		[]string{
+			ControllerLabel,
+		},
+	)
+	SimulationTopologyDomains = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "simulation_topology_domains",
+			Help:      "The number of topology domains tracked across all topology groups in the most recent scheduling simulation.",
+		},
+		[]string{
+			ControllerLabel,
+		},
+	)
+	SimulationBoundsReachedTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "simulation_bounds_reached_total",
+			Help:      "The number of scheduling simulations that were degraded because a configured bound was reached. Labeled by the bound that was reached.",
+		},
+		[]string{
+			ControllerLabel,
+			boundLabel,
+		},
+	)
+	PodsDeferredByLimitsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.PodSubsystem,
+			Name:      "deferred_limits_total",
+			Help:      "The number of times a pod wasn't provisioned because every NodePool that could launch capacity for it was at its limits. Labeled by NodePool.",
+		},
+		[]string{
+			metrics.NodePoolLabel,
+		},
+	)
+	PodsRejectedTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.PodSubsystem,
+			Name:      "rejected_total",
+			Help:      "The number of times a NodePool couldn't launch capacity for a pod that was left unschedulable when provisioning. Labeled by NodePool and the reason that the pod was rejected.",
+		},
+		[]string{
+			metrics.NodePoolLabel,
+			metrics.ReasonLabel,
+		},
+	)
+	InstanceTypesStalenessSeconds = opmetrics.NewPrometheusGauge(
+		crmetrics.Registry,
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "instance_types_staleness_seconds",
+			Help:      "The age of the cached instance types that the CloudProvider returned for a NodePool when it couldn't fully resolve them. Labeled by NodePool.",
+		},
+		[]string{
+			metrics.NodePoolLabel,
+		},
+	)
+	InstanceTypeFilterCacheRequestsTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: schedulerSubsystem,
+			Name:      "instance_type_filter_cache_requests_total",
+			Help:      "The number of times instance types were filtered for the first pod on a new NodeClaim. Labeled by whether the result was served from the cache.",
+		},
+		[]string{
+			resultLabel,
+		},
+	)
 )
@@ -17,6 +17,7 @@ limitations under the License.
 package scheduling
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync/atomic"
@@ -24,7 +25,9 @@ import (
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	apisv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
@@ -40,6 +43,13 @@ type NodeClaim struct {
 	hostPortUsage   *scheduling.HostPortUsage
 	daemonResources v1.ResourceList
 	hostname        string
+
+	// filterCache is used to filter instance types for the first pod added to the NodeClaim if it's set, see
+	// InstanceTypeFilterCache
+	filterCache      *InstanceTypeFilterCache
+	instanceTypesKey string
+	// templateInstanceTypes are the instance types that the NodeClaim was created with, before any pods were added
+	templateInstanceTypes cloudprovider.InstanceTypes
 }
 
 var nodeID int64
@@ -61,26 +71,36 @@ func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daem
 		topology:          topology,
 		daemonResources:   daemonResources,
 		hostname:          hostname,
+
+		templateInstanceTypes: instanceTypes,
 	}
 }
 
+// Isolate dedicates the NodeClaim to the passed pod by tainting it so that only that pod can schedule to it
+func (n *NodeClaim) Isolate(pod *v1.Pod) {
+	n.Spec.Taints = append(append([]v1.Taint{}, n.Spec.Taints...), apisv1.ExclusiveNoScheduleTaint(pod.UID))
+	n.Annotations = lo.Assign(n.Annotations, map[string]string{
+		apisv1.ExclusiveNodeAnnotationKey: client.ObjectKeyFromObject(pod).String(),
+	})
+}
+
 func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList) error {
 	// Check Taints
 	if err := scheduling.Taints(n.Spec.Taints).Tolerates(pod); err != nil {
-		return err
+		return rejected(rejectionReasonTaints, err)
 	}
 
 	// exposed host ports on the node
 	hostPorts := scheduling.GetHostPorts(pod)
 	if err := n.hostPortUsage.Conflicts(pod, hostPorts); err != nil {
-		return fmt.Errorf("checking host port usage, %w", err)
+		return rejected(rejectionReasonHostPorts, fmt.Errorf("checking host port usage, %w", err))
 	}
 	nodeClaimRequirements := scheduling.NewRequirements(n.Requirements.Values()...)
 	podRequirements := scheduling.NewPodRequirements(pod)
 
 	// Check NodeClaim Affinity Requirements
 	if err := nodeClaimRequirements.Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
-		return fmt.Errorf("incompatible requirements, %w", err)
+		return rejected(rejectionReasonRequirements, fmt.Errorf("incompatible requirements, %w", err))
 	}
 	nodeClaimRequirements.Add(podRequirements.Values()...)
 
@@ -93,22 +113,22 @@ func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList) error {
 	// Check Topology Requirements
 	topologyRequirements, err := n.topology.AddRequirements(strictPodRequirements, nodeClaimRequirements, pod, scheduling.AllowUndefinedWellKnownLabels)
 	if err != nil {
-		return err
+		return rejected(rejectionReasonTopology, err)
 	}
 	if err = nodeClaimRequirements.Compatible(topologyRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
-		return err
+		return rejected(rejectionReasonTopology, err)
 	}
 	nodeClaimRequirements.Add(topologyRequirements.Values()...)
 
 	// Check instance type combinations
 	requests := resources.Merge(n.Spec.Resources.Requests, podRequests)
 
-	filtered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, nodeClaimRequirements, requests)
+	filtered := n.filterInstanceTypes(nodeClaimRequirements, requests)
 
 	if len(filtered.remaining) == 0 {
 		// log the total resources being requested (daemonset + the pod)
 		cumulativeResources := resources.Merge(n.daemonResources, podRequests)
-		return fmt.Errorf("no instance type satisfied resources %s and requirements %s (%s)", resources.String(cumulativeResources), nodeClaimRequirements, filtered.FailureReason())
+		return rejected(rejectionReasonInstanceTypes, fmt.Errorf("no instance type satisfied resources %s and requirements %s (%s)", resources.String(cumulativeResources), nodeClaimRequirements, filtered.FailureReason()))
 	}
 
 	// Update node
@@ -121,6 +141,53 @@ func (n *NodeClaim) Add(pod *v1.Pod, podRequests v1.ResourceList) error {
 	return nil
 }
 
+// Reasons that a NodePool couldn't launch capacity for a pod, which label PodsRejectedTotal
+const (
+	rejectionReasonLimits        = "limits"
+	rejectionReasonTaints        = "taints"
+	rejectionReasonHostPorts     = "host_ports"
+	rejectionReasonRequirements  = "requirements"
+	rejectionReasonTopology      = "topology"
+	rejectionReasonInstanceTypes = "instance_types"
+	rejectionReasonUnknown       = "unknown"
+)
+
+// rejectionError is returned when a NodeClaim can't add a pod, and records which check the pod failed
+type rejectionError struct {
+	reason string
+	err    error
+}
+
+func rejected(reason string, err error) error {
+	return &rejectionError{reason: reason, err: err}
+}
+
+func (e *rejectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rejectionError) Unwrap() error {
+	return e.err
+}
+
+// rejectionReason returns the check that a pod failed from the error that NodeClaim.Add returned for it
+func rejectionReason(err error) string {
+	rejectionErr := &rejectionError{}
+	if errors.As(err, &rejectionErr) {
+		return rejectionErr.reason
+	}
+	return rejectionReasonUnknown
+}
+
+func (n *NodeClaim) filterInstanceTypes(requirements scheduling.Requirements, requests v1.ResourceList) filterResults {
+	// Only the first pod sees the instance types of the template, after that the options depend on the pods that
+	// have already been added
+	if n.filterCache != nil && len(n.Pods) == 0 {
+		return n.filterCache.filter(n.instanceTypesKey, n.InstanceTypeOptions, requirements, requests)
+	}
+	return filterInstanceTypesByRequirements(n.InstanceTypeOptions, requirements, requests)
+}
+
 func (n *NodeClaim) Destroy() {
 	n.topology.Unregister(v1.LabelHostname, n.hostname)
 }
@@ -144,6 +211,24 @@ func (n *NodeClaim) RemoveInstanceTypeOptionsByPriceAndMinValues(reqs scheduling
 	return n, nil
 }
 
+// SpotDiversificationShortfall describes how the spot offerings that the NodeClaim will be launched with fall short of
+// its NodePool's SpotDiversification. It's empty when the floor is met or the NodeClaim can't launch spot capacity.
+func (n *NodeClaim) SpotDiversificationShortfall() string {
+	d := n.SpotDiversification
+	if d == nil || !n.Requirements.Get(apisv1.CapacityTypeLabelKey).Has(apisv1.CapacityTypeSpot) {
+		return ""
+	}
+	instanceTypes, zones := n.InstanceTypeOptions.SpotDiversity(n.Requirements)
+	var shortfalls []string
+	if instanceTypes < int(d.MinInstanceTypes) {
+		shortfalls = append(shortfalls, fmt.Sprintf("%d instance types, below the minimum of %d", instanceTypes, d.MinInstanceTypes))
+	}
+	if zones < int(d.MinZones) {
+		shortfalls = append(shortfalls, fmt.Sprintf("%d zones, below the minimum of %d", zones, d.MinZones))
+	}
+	return strings.Join(shortfalls, " and ")
+}
+
 func InstanceTypeList(instanceTypeOptions []*cloudprovider.InstanceType) string {
 	var itSb strings.Builder
 	for i, it := range instanceTypeOptions {
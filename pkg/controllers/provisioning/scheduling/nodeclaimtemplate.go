@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/awslabs/operatorpkg/object"
+	"github.com/awslabs/operatorpkg/option"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,6 +29,7 @@ import (
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 )
 
 // MaxInstanceTypes is a constant that restricts the number of instance types to be sent for launch. Note that this
@@ -44,31 +46,73 @@ type NodeClaimTemplate struct {
 	NodePoolUUID        types.UID
 	InstanceTypeOptions cloudprovider.InstanceTypes
 	Requirements        scheduling.Requirements
+	// PreferNewerGenerations breaks ties between instance types of the same price in favor of newer generations
+	PreferNewerGenerations bool
+	SpotDiversification    *v1.SpotDiversification
+	// BinPacking is how pods are packed onto the NodeClaims launched from the template
+	BinPacking v1.BinPackingStrategy
+	// SpotAllocationStrategy is how spot instance types are ordered for the NodeClaims launched from the template
+	SpotAllocationStrategy v1.SpotAllocationStrategy
 }
 
 func NewNodeClaimTemplate(nodePool *v1.NodePool) *NodeClaimTemplate {
 	nct := &NodeClaimTemplate{
-		NodeClaim:    *nodePool.Spec.Template.ToNodeClaim(),
-		NodePoolName: nodePool.Name,
-		NodePoolUUID: nodePool.UID,
-		Requirements: scheduling.NewRequirements(),
+		NodeClaim:              *nodePool.Spec.Template.ToNodeClaim(),
+		NodePoolName:           nodePool.Name,
+		NodePoolUUID:           nodePool.UID,
+		Requirements:           scheduling.NewRequirements(),
+		SpotDiversification:    nodePool.Spec.SpotDiversification,
+		SpotAllocationStrategy: nodePool.Spec.SpotAllocationStrategy,
 	}
 	nct.Annotations = lo.Assign(nct.Annotations, map[string]string{
-		v1.NodePoolHashAnnotationKey:        nodePool.Hash(),
-		v1.NodePoolHashVersionAnnotationKey: v1.NodePoolHashVersion,
+		v1.NodePoolHashAnnotationKey:        nodepoolutils.Hash(nodePool),
+		v1.NodePoolHashVersionAnnotationKey: nodepoolutils.HashVersion(),
+		v1.NodePoolFieldHashesAnnotationKey: nodepoolutils.FieldHashesAnnotation(nodePool),
 	})
-	nct.Labels = lo.Assign(nct.Labels, map[string]string{
+	nct.Labels = lo.Assign(nodePool.PropagatedLabels(), nct.Labels, map[string]string{
 		v1.NodePoolLabelKey: nodePool.Name,
 		v1.NodeClassLabelKey(nodePool.Spec.Template.Spec.NodeClassRef.GroupKind()): nodePool.Spec.Template.Spec.NodeClassRef.Name,
 	})
 	nct.Requirements.Add(scheduling.NewNodeSelectorRequirementsWithMinValues(nct.Spec.Requirements...).Values()...)
 	nct.Requirements.Add(scheduling.NewLabelRequirements(nct.Labels).Values()...)
+	if nct.SpotAllocationStrategy == v1.SpotAllocationStrategyPriceCapacityOptimized {
+		nct.Annotations[v1.AllocationStrategyAnnotationKey] = v1.AllocationStrategyPriceCapacityOptimized
+	}
 	return nct
 }
 
+// applyCapacityFallback launches from the NodePool's active capacity fallback stage. The allocation strategy is hinted
+// to the CloudProvider through an annotation, and the last resort stage also swaps in the last resort requirements.
+func (i *NodeClaimTemplate) applyCapacityFallback(nodePool *v1.NodePool, stage v1.CapacityFallbackStage) {
+	if nodePool.Spec.CapacityFallback == nil {
+		return
+	}
+	if stage != v1.CapacityFallbackStageNone {
+		i.Annotations = lo.Assign(i.Annotations, map[string]string{v1.AllocationStrategyAnnotationKey: v1.AllocationStrategyCapacityOptimized})
+	} else if i.SpotAllocationStrategy != v1.SpotAllocationStrategyPriceCapacityOptimized {
+		i.Annotations = lo.Assign(i.Annotations, map[string]string{v1.AllocationStrategyAnnotationKey: v1.AllocationStrategyPriceOptimized})
+	}
+	if stage != v1.CapacityFallbackStageLastResort {
+		return
+	}
+	i.Annotations[v1.CapacityFallbackAnnotationKey] = string(stage)
+	i.Spec.Requirements = nodePool.Spec.CapacityFallback.LastResortRequirements
+	i.Requirements = scheduling.NewRequirements()
+	i.Requirements.Add(scheduling.NewNodeSelectorRequirementsWithMinValues(i.Spec.Requirements...).Values()...)
+	i.Requirements.Add(scheduling.NewLabelRequirements(i.Labels).Values()...)
+}
+
+// orderOptions are the options that instance types of the NodeClaims launched from the template are ordered by
+func (i *NodeClaimTemplate) orderOptions() []option.Function[cloudprovider.OrderOptions] {
+	return []option.Function[cloudprovider.OrderOptions]{
+		cloudprovider.PreferNewerGenerations(i.PreferNewerGenerations),
+		cloudprovider.PriceCapacityOptimized(i.SpotAllocationStrategy == v1.SpotAllocationStrategyPriceCapacityOptimized),
+	}
+}
+
 func (i *NodeClaimTemplate) ToNodeClaim() *v1.NodeClaim {
 	// Order the instance types by price and only take the first 100 of them to decrease the instance type size in the requirements
-	instanceTypes := lo.Slice(i.InstanceTypeOptions.OrderByPrice(i.Requirements), 0, MaxInstanceTypes)
+	instanceTypes := lo.Slice(i.InstanceTypeOptions.OrderByPrice(i.Requirements, i.orderOptions()...), 0, MaxInstanceTypes)
 	i.Requirements.Add(scheduling.NewRequirementWithFlexibility(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, i.Requirements.Get(corev1.LabelInstanceTypeStable).MinValues, lo.Map(instanceTypes, func(i *cloudprovider.InstanceType, _ int) string {
 		return i.Name
 	})...))
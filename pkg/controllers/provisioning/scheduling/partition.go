@@ -0,0 +1,145 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"sort"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// PartitionPods splits pods into at most n partitions that can be scheduled independently of one another. A pod is
+// kept in the same partition as every pod that its topology spread constraints or pod (anti-)affinities select, since
+// scheduling either of them changes where the other can go. Partitions are balanced by their number of pods, and pods
+// keep their relative order within each partition.
+func PartitionPods(pods []*corev1.Pod, n int) [][]*corev1.Pod {
+	if n <= 1 || len(pods) <= 1 {
+		return [][]*corev1.Pod{pods}
+	}
+	groups := newDisjointSet(len(pods))
+	for i, pod := range pods {
+		for _, s := range podSelectors(pod) {
+			for j, other := range pods {
+				if i != j && s.matches(other) {
+					groups.union(i, j)
+				}
+			}
+		}
+	}
+	members := map[int][]int{}
+	for i := range pods {
+		root := groups.find(i)
+		members[root] = append(members[root], i)
+	}
+	// Assign the largest groups first, each to the partition with the fewest pods so far
+	ordered := lo.Values(members)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if len(ordered[i]) != len(ordered[j]) {
+			return len(ordered[i]) > len(ordered[j])
+		}
+		return ordered[i][0] < ordered[j][0]
+	})
+	partitions := make([][]int, lo.Min([]int{n, len(ordered)}))
+	for _, group := range ordered {
+		smallest := lo.MinBy(lo.Range(len(partitions)), func(a, b int) bool { return len(partitions[a]) < len(partitions[b]) })
+		partitions[smallest] = append(partitions[smallest], group...)
+	}
+	return lo.Map(partitions, func(partition []int, _ int) []*corev1.Pod {
+		sort.Ints(partition)
+		return lo.Map(partition, func(i int, _ int) *corev1.Pod { return pods[i] })
+	})
+}
+
+// podSelector selects the pods that a topology spread constraint or pod (anti-)affinity term of a pod counts
+type podSelector struct {
+	selector labels.Selector
+	// namespaces the selected pods are in, or nil if pods in any namespace can be selected
+	namespaces sets.Set[string]
+}
+
+func (s podSelector) matches(pod *corev1.Pod) bool {
+	return (s.namespaces == nil || s.namespaces.Has(pod.Namespace)) && s.selector.Matches(labels.Set(pod.Labels))
+}
+
+func podSelectors(pod *corev1.Pod) []podSelector {
+	var selectors []podSelector
+	for _, tsc := range pod.Spec.TopologySpreadConstraints {
+		selectors = append(selectors, newPodSelector(tsc.LabelSelector, sets.New(pod.Namespace)))
+	}
+	if pod.Spec.Affinity == nil {
+		return selectors
+	}
+	var terms []corev1.PodAffinityTerm
+	if affinity := pod.Spec.Affinity.PodAffinity; affinity != nil {
+		terms = append(terms, affinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		terms = append(terms, lo.Map(affinity.PreferredDuringSchedulingIgnoredDuringExecution, func(t corev1.WeightedPodAffinityTerm, _ int) corev1.PodAffinityTerm { return t.PodAffinityTerm })...)
+	}
+	if antiAffinity := pod.Spec.Affinity.PodAntiAffinity; antiAffinity != nil {
+		terms = append(terms, antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		terms = append(terms, lo.Map(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, func(t corev1.WeightedPodAffinityTerm, _ int) corev1.PodAffinityTerm { return t.PodAffinityTerm })...)
+	}
+	for _, term := range terms {
+		// A namespace selector can select namespaces by their labels, which aren't known here, so terms with one are
+		// treated as selecting pods in any namespace
+		namespaces := sets.New(term.Namespaces...)
+		if term.NamespaceSelector != nil {
+			namespaces = nil
+		} else if len(namespaces) == 0 {
+			namespaces.Insert(pod.Namespace)
+		}
+		selectors = append(selectors, newPodSelector(term.LabelSelector, namespaces))
+	}
+	return selectors
+}
+
+func newPodSelector(selector *metav1.LabelSelector, namespaces sets.Set[string]) podSelector {
+	// A nil label selector doesn't select any pods, while a selector that can't be parsed is treated as selecting every
+	// pod so that the pods it might select are never scheduled apart from it
+	if selector == nil {
+		return podSelector{selector: labels.Nothing(), namespaces: namespaces}
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return podSelector{selector: labels.Everything()}
+	}
+	return podSelector{selector: s, namespaces: namespaces}
+}
+
+// disjointSet is a union-find over the indices of a slice
+type disjointSet []int
+
+func newDisjointSet(n int) disjointSet {
+	return lo.Range(n)
+}
+
+func (d disjointSet) find(i int) int {
+	for d[i] != i {
+		d[i] = d[d[i]]
+		i = d[i]
+	}
+	return i
+}
+
+func (d disjointSet) union(i, j int) {
+	if ri, rj := d.find(i), d.find(j); ri != rj {
+		d[rj] = ri
+	}
+}
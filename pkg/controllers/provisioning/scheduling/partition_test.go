@@ -0,0 +1,93 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var _ = Describe("PartitionPods", func() {
+	podsFor := func(app string, count int, opts ...test.PodOptions) []*corev1.Pod {
+		options := test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": app}}}
+		if len(opts) > 0 {
+			options = opts[0]
+			options.ObjectMeta.Labels = map[string]string{"app": app}
+		}
+		return test.Pods(count, options)
+	}
+	spreadAcross := func(app string) test.PodOptions {
+		return test.PodOptions{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+		}}}
+	}
+	It("should keep every pod in one partition with a single worker", func() {
+		pods := append(podsFor("a", 2), podsFor("b", 2)...)
+		Expect(scheduling.PartitionPods(pods, 1)).To(Equal([][]*corev1.Pod{pods}))
+	})
+	It("should split pods without topology constraints across the workers", func() {
+		pods := podsFor("a", 4)
+		partitions := scheduling.PartitionPods(pods, 2)
+		Expect(partitions).To(HaveLen(2))
+		Expect(partitions[0]).To(HaveLen(2))
+		Expect(partitions[1]).To(HaveLen(2))
+		Expect(lo.Flatten(partitions)).To(ConsistOf(pods))
+	})
+	It("should keep pods in the same partition as the pods that their topology spread constraints select", func() {
+		spread := podsFor("a", 3, spreadAcross("a"))
+		other := podsFor("b", 3)
+		partitions := scheduling.PartitionPods(append(spread, other...), 4)
+		partition, ok := lo.Find(partitions, func(p []*corev1.Pod) bool { return lo.Contains(p, spread[0]) })
+		Expect(ok).To(BeTrue())
+		Expect(partition).To(ContainElements(spread))
+		Expect(partitions).To(HaveLen(4))
+	})
+	It("should keep pods in the same partition as the pods that their pod anti-affinities select", func() {
+		a := podsFor("a", 2)
+		b := podsFor("b", 2, test.PodOptions{PodAntiRequirements: []corev1.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "a"}},
+			TopologyKey:   corev1.LabelHostname,
+		}}})
+		partitions := scheduling.PartitionPods(append(a, b...), 4)
+		Expect(partitions).To(HaveLen(1))
+		Expect(partitions[0]).To(Equal(append(a, b...)))
+	})
+	It("should not keep pods in the same partition as pods in other namespaces that their selectors match", func() {
+		a := podsFor("a", 1, spreadAcross("a"))
+		b := podsFor("a", 1)
+		b[0].Namespace = "other"
+		Expect(scheduling.PartitionPods(append(a, b...), 2)).To(HaveLen(2))
+	})
+	It("should keep pods in their original order within each partition", func() {
+		pods := append(podsFor("a", 2, spreadAcross("a")), podsFor("b", 2, spreadAcross("b"))...)
+		pods = []*corev1.Pod{pods[0], pods[2], pods[1], pods[3]}
+		partitions := scheduling.PartitionPods(pods, 2)
+		Expect(partitions).To(ConsistOf(
+			[]*corev1.Pod{pods[0], pods[2]},
+			[]*corev1.Pod{pods[1], pods[3]},
+		))
+	})
+})
@@ -0,0 +1,99 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+// ReservationManager tracks how many more instances can be launched into each reserved offering during a scheduling
+// simulation, so that no more NodeClaims are planned onto reserved capacity than the reservations can hold
+type ReservationManager struct {
+	remaining map[string]int
+}
+
+func NewReservationManager(instanceTypes map[string][]*cloudprovider.InstanceType) *ReservationManager {
+	remaining := map[string]int{}
+	for _, its := range instanceTypes {
+		for _, it := range its {
+			for _, o := range it.Offerings.Available().Compatible(cloudprovider.ReservedRequirement) {
+				remaining[reservationKey(it, o)] = o.ReservationCapacity
+			}
+		}
+	}
+	return &ReservationManager{remaining: remaining}
+}
+
+// Reserve prefers reserved capacity for the NodeClaim. If any of its instance types has a compatible reserved offering
+// with capacity left, the NodeClaim is pinned to the cheapest of those offerings by its instance type, zone and capacity
+// type, and takes an instance from it. Pinning makes that offering the only one the NodeClaim can launch into, so the
+// reservation is tracked against the offering it actually lands on. Otherwise, reserved capacity is excluded so that
+// the NodeClaim falls back to the other capacity types that it allows, like on-demand or spot. NodeClaims that can only
+// launch reserved capacity are left as they are.
+func (r *ReservationManager) Reserve(n *NodeClaim) {
+	if len(r.remaining) == 0 || !n.Requirements.Get(v1.CapacityTypeLabelKey).Has(v1.CapacityTypeReserved) {
+		return
+	}
+	reserved := scheduling.NewRequirements(n.Requirements.Values()...)
+	reserved.Add(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, v1.CapacityTypeReserved))
+	var selected *cloudprovider.InstanceType
+	var offering cloudprovider.Offering
+	for _, it := range n.InstanceTypeOptions {
+		for _, o := range it.Offerings.Available().Compatible(reserved) {
+			if r.remaining[reservationKey(it, o)] > 0 && (selected == nil || o.Price < offering.Price) {
+				selected, offering = it, o
+			}
+		}
+	}
+	if selected != nil {
+		pinned := scheduling.NewRequirements(reserved.Values()...)
+		pinned.Add(
+			scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, selected.Name),
+			offering.Requirements.Get(corev1.LabelTopologyZone),
+		)
+		if _, err := cloudprovider.InstanceTypes([]*cloudprovider.InstanceType{selected}).SatisfiesMinValues(pinned); err == nil {
+			r.remaining[reservationKey(selected, offering)]--
+			n.Requirements = pinned
+			n.InstanceTypeOptions = []*cloudprovider.InstanceType{selected}
+			return
+		}
+	}
+	fallback := scheduling.NewRequirements(n.Requirements.Values()...)
+	fallback.Add(scheduling.NewRequirement(v1.CapacityTypeLabelKey, corev1.NodeSelectorOpNotIn, v1.CapacityTypeReserved))
+	instanceTypes := lo.Filter(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+		return it.Offerings.Available().HasCompatible(fallback)
+	})
+	if len(instanceTypes) == 0 {
+		return
+	}
+	if _, err := cloudprovider.InstanceTypes(instanceTypes).SatisfiesMinValues(fallback); err != nil {
+		return
+	}
+	n.Requirements = fallback
+	n.InstanceTypeOptions = instanceTypes
+}
+
+func reservationKey(it *cloudprovider.InstanceType, o cloudprovider.Offering) string {
+	return fmt.Sprintf("%s/%s", it.Name, o.Requirements.Key())
+}
@@ -19,14 +19,21 @@ package scheduling
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"time"
 
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/clock"
@@ -39,15 +46,17 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
-	"sigs.k8s.io/karpenter/pkg/utils/pod"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
 func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1.NodePool,
 	cluster *state.Cluster, stateNodes []*state.StateNode, topology *Topology,
 	instanceTypes map[string][]*cloudprovider.InstanceType, daemonSetPods []*corev1.Pod,
-	recorder events.Recorder, clock clock.Clock) *Scheduler {
+	filterCache *InstanceTypeFilterCache, recorder events.Recorder, clock clock.Clock) *Scheduler {
 
 	// if any of the nodePools add a taint with a prefer no schedule effect, we add a toleration for the taint
 	// during preference relaxation
@@ -62,6 +71,9 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1
 	// Pre-filter instance types eligible for NodePools to reduce work done during scheduling loops for pods
 	templates := lo.FilterMap(nodePools, func(np *v1.NodePool, _ int) (*NodeClaimTemplate, bool) {
 		nct := NewNodeClaimTemplate(np)
+		nct.applyCapacityFallback(np, np.CapacityFallbackStage(clock.Now()))
+		nct.PreferNewerGenerations = options.FromContext(ctx).PreferNewerGenerations
+		nct.BinPacking = lo.Ternary(np.Spec.BinPacking != "", np.Spec.BinPacking, v1.BinPackingStrategy(options.FromContext(ctx).BinPackingStrategy))
 		nct.InstanceTypeOptions = filterInstanceTypesByRequirements(instanceTypes[np.Name], nct.Requirements, corev1.ResourceList{}).remaining
 		if len(nct.InstanceTypeOptions) == 0 {
 			log.FromContext(ctx).WithValues("NodePool", klog.KRef("", np.Name)).Info("skipping, nodepool requirements filtered out all instance types")
@@ -70,38 +82,85 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1
 		return nct, true
 	})
 	s := &Scheduler{
-		id:                 uuid.NewUUID(),
-		kubeClient:         kubeClient,
-		nodeClaimTemplates: templates,
-		topology:           topology,
-		cluster:            cluster,
-		daemonOverhead:     getDaemonOverhead(templates, daemonSetPods),
-		cachedPodRequests:  map[types.UID]corev1.ResourceList{}, // cache pod requests to avoid having to continually recompute this total
-		recorder:           recorder,
-		preferences:        &Preferences{ToleratePreferNoSchedule: toleratePreferNoSchedule},
+		id:                   uuid.NewUUID(),
+		kubeClient:           kubeClient,
+		nodeClaimTemplates:   templates,
+		topology:             topology,
+		cluster:              cluster,
+		daemonOverhead:       getDaemonOverhead(templates, daemonSetPods),
+		cachedPodRequests:    map[types.UID]corev1.ResourceList{}, // cache pod requests to avoid having to continually recompute this total
+		newNodeClaimRequests: map[types.UID]corev1.ResourceList{},
+		rejections:           map[types.UID]map[string]string{},
+		recorder:             recorder,
+		preferences:          &Preferences{ToleratePreferNoSchedule: toleratePreferNoSchedule},
 		remainingResources: lo.SliceToMap(nodePools, func(np *v1.NodePool) (string, corev1.ResourceList) {
 			return np.Name, corev1.ResourceList(np.Spec.Limits)
 		}),
-		clock: clock,
+		zoneLimits:         NewZoneLimits(nodePools),
+		clock:              clock,
+		boundsReached:      sets.New[string](),
+		filterCache:        filterCache,
+		reservationManager: NewReservationManager(instanceTypes),
 	}
-	s.calculateExistingNodeClaims(stateNodes, daemonSetPods)
+	if filterCache != nil {
+		s.instanceTypesKeys = lo.SliceToMap(templates, func(nct *NodeClaimTemplate) (*NodeClaimTemplate, string) {
+			return nct, instanceTypesKey(nct)
+		})
+	}
+	s.calculateExistingNodeClaims(ctx, stateNodes, daemonSetPods, baselineCPU(nodePools, instanceTypes))
 	return s
 }
 
+// baselineCPU returns the baseline CPU of the burstable instance types of each NodePool that packs pods against
+// baseline CPU, keyed by NodePool and then by instance type
+func baselineCPU(nodePools []*v1.NodePool, instanceTypes map[string][]*cloudprovider.InstanceType) map[string]map[string]resource.Quantity {
+	baseline := map[string]map[string]resource.Quantity{}
+	for _, np := range nodePools {
+		if np.Spec.BurstableCPU != v1.BurstableCPUPackingBaseline {
+			continue
+		}
+		baseline[np.Name] = lo.SliceToMap(lo.Filter(instanceTypes[np.Name], func(it *cloudprovider.InstanceType, _ int) bool {
+			return it.BaselineCPU != nil
+		}), func(it *cloudprovider.InstanceType) (string, resource.Quantity) {
+			return it.Name, it.BaselineCPU.DeepCopy()
+		})
+	}
+	return baseline
+}
+
 type Scheduler struct {
-	id                 types.UID // Unique UUID attached to this scheduling loop
-	newNodeClaims      []*NodeClaim
-	existingNodes      []*ExistingNode
-	nodeClaimTemplates []*NodeClaimTemplate
-	remainingResources map[string]corev1.ResourceList // (NodePool name) -> remaining resources for that NodePool
-	daemonOverhead     map[*NodeClaimTemplate]corev1.ResourceList
-	cachedPodRequests  map[types.UID]corev1.ResourceList // (Pod Namespace/Name) -> calculated resource requests for the pod
-	preferences        *Preferences
-	topology           *Topology
-	cluster            *state.Cluster
-	recorder           events.Recorder
-	kubeClient         client.Client
-	clock              clock.Clock
+	id            types.UID // Unique UUID attached to this scheduling loop
+	newNodeClaims []*NodeClaim
+	existingNodes []*ExistingNode
+	// preferNoScheduleTainted is whether any of the existing nodes have PreferNoSchedule taints, since only then do
+	// they need to be ordered for each pod
+	preferNoScheduleTainted bool
+	nodeClaimTemplates      []*NodeClaimTemplate
+	remainingResources      map[string]corev1.ResourceList // (NodePool name) -> remaining resources for that NodePool
+	zoneLimits              *ZoneLimits
+	daemonOverhead          map[*NodeClaimTemplate]corev1.ResourceList
+	cachedPodRequests       map[types.UID]corev1.ResourceList // (Pod Namespace/Name) -> calculated resource requests for the pod
+	newNodeClaimRequests    map[types.UID]corev1.ResourceList // (Pod UID) -> resource requests for the pod, with the safety margin, when it's packed onto a new NodeClaim
+	rejections              map[types.UID]map[string]string   // (Pod UID) -> (NodePool name) -> reason the NodePool couldn't launch capacity for the pod
+	preferences             *Preferences
+	topology                *Topology
+	cluster                 *state.Cluster
+	recorder                events.Recorder
+	kubeClient              client.Client
+	clock                   clock.Clock
+	boundsReached           sets.Set[string] // Simulation bounds that were hit while constructing or running this scheduler
+	filterCache             *InstanceTypeFilterCache
+	instanceTypesKeys       map[*NodeClaimTemplate]string
+	reservationManager      *ReservationManager
+	safetyMargin            float64 // Fraction that the CPU and memory requests of pods are scaled up by on new NodeClaims
+}
+
+// WithSafetyMargin scales up the CPU and memory requests of pods by the margin when they're packed onto new NodeClaims.
+// It's only set when provisioning for pending pods, so that disruption simulates replacements with the requests that
+// pods really have.
+func (s *Scheduler) WithSafetyMargin(margin float64) *Scheduler {
+	s.safetyMargin = margin
+	return s
 }
 
 // Results contains the results of the scheduling operation
@@ -109,6 +168,9 @@ type Results struct {
 	NewNodeClaims []*NodeClaim
 	ExistingNodes []*ExistingNode
 	PodErrors     map[*corev1.Pod]error
+	// NodePoolRejections are the reasons that NodePools couldn't launch capacity for each pod in the pod's final
+	// scheduling attempt, keyed by the pod's UID and then by NodePool
+	NodePoolRejections map[types.UID]map[string]string
 }
 
 // Record sends eventing and log messages back for the results that were produced from a scheduling run
@@ -118,14 +180,35 @@ func (r Results) Record(ctx context.Context, recorder events.Recorder, cluster *
 	// Report failures and nominations
 	for p, err := range r.PodErrors {
 		log.FromContext(ctx).WithValues("Pod", klog.KRef(p.Namespace, p.Name)).Error(err, "could not schedule pod")
+		// Rejections are only counted for pods that were left unschedulable, since pods that were rejected by some
+		// NodePools but scheduled to another one didn't go without capacity
+		for nodePool, reason := range r.NodePoolRejections[p.UID] {
+			PodsRejectedTotal.Inc(map[string]string{metrics.NodePoolLabel: nodePool, metrics.ReasonLabel: reason})
+		}
+		limitsErr := &NodePoolLimitsExceededError{}
+		if errors.As(err, &limitsErr) {
+			recorder.Publish(PodDeferredByLimitsEvent(p, limitsErr.NodePools))
+			for _, nodePool := range limitsErr.NodePools {
+				PodsDeferredByLimitsTotal.Inc(map[string]string{metrics.NodePoolLabel: nodePool})
+			}
+			continue
+		}
 		recorder.Publish(PodFailedToScheduleEvent(p, err))
 	}
 	for _, existing := range r.ExistingNodes {
 		if len(existing.Pods) > 0 {
 			cluster.NominateNodeForPod(ctx, existing.ProviderID())
 		}
+		var nodeClaimName, nodeName string
+		if existing.NodeClaim != nil {
+			nodeClaimName = existing.NodeClaim.Name
+		}
+		if existing.Node != nil {
+			nodeName = existing.Node.Name
+		}
 		for _, p := range existing.Pods {
 			recorder.Publish(NominatePodEvent(p, existing.Node, existing.NodeClaim))
+			cluster.PredictPlacement(p, nodeClaimName, nodeName)
 		}
 	}
 	// Report new nodes, or exit to avoid log spam
@@ -155,14 +238,14 @@ func (r Results) Record(ctx context.Context, recorder events.Recorder, cluster *
 // schedule at all and don't want it to block consolidation.
 func (r Results) AllNonPendingPodsScheduled() bool {
 	return len(lo.OmitBy(r.PodErrors, func(p *corev1.Pod, err error) bool {
-		return pod.IsProvisionable(p)
+		return podutils.IsProvisionable(p)
 	})) == 0
 }
 
 // NonPendingPodSchedulingErrors creates a string that describes why pods wouldn't schedule that is suitable for presentation
 func (r Results) NonPendingPodSchedulingErrors() string {
 	errs := lo.OmitBy(r.PodErrors, func(p *corev1.Pod, err error) bool {
-		return pod.IsProvisionable(p)
+		return podutils.IsProvisionable(p)
 	})
 	if len(errs) == 0 {
 		return "No Pod Scheduling Errors"
@@ -189,7 +272,10 @@ func (r Results) TruncateInstanceTypes(maxInstanceTypes int) Results {
 	for _, newNodeClaim := range r.NewNodeClaims {
 		// The InstanceTypeOptions are truncated due to limitations in sending the number of instances to launch API.
 		var err error
-		newNodeClaim.InstanceTypeOptions, err = newNodeClaim.InstanceTypeOptions.Truncate(newNodeClaim.Requirements, maxInstanceTypes)
+		if d := newNodeClaim.SpotDiversification; d != nil {
+			newNodeClaim.InstanceTypeOptions = newNodeClaim.InstanceTypeOptions.Diversify(newNodeClaim.Requirements, maxInstanceTypes, int(d.MinInstanceTypes), int(d.MinZones), newNodeClaim.orderOptions()...)
+		}
+		newNodeClaim.InstanceTypeOptions, err = newNodeClaim.InstanceTypeOptions.Truncate(newNodeClaim.Requirements, maxInstanceTypes, newNodeClaim.orderOptions()...)
 		if err != nil {
 			// Check if the truncated InstanceTypeOptions in each NewNodeClaim from the results still satisfy the minimum requirements
 			// If number of InstanceTypes in the NodeClaim cannot satisfy the minimum requirements, add its Pods to error map with reason.
@@ -205,7 +291,12 @@ func (r Results) TruncateInstanceTypes(maxInstanceTypes int) Results {
 }
 
 func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
-	defer metrics.Measure(DurationSeconds, map[string]string{ControllerLabel: injection.GetControllerName(ctx)})()
+	ctx, span := tracing.Tracer().Start(ctx, "Scheduler.Solve", trace.WithAttributes(
+		attribute.String("scheduling.id", string(s.id)),
+		attribute.Int("pods", len(pods)),
+	))
+	defer span.End()
+	defer metrics.MeasureContext(ctx, DurationSeconds, map[string]string{ControllerLabel: injection.GetControllerName(ctx)})()
 	// We loop trying to schedule unschedulable pods as long as we are making progress.  This solves a few
 	// issues including pods with affinity to another pod in the batch. We could topo-sort to solve this, but it wouldn't
 	// solve the problem of scheduling pods where a particular order is needed to prevent a max-skew violation. E.g. if we
@@ -217,6 +308,7 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 	QueueDepth.DeletePartialMatch(map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
 	for _, p := range pods {
 		s.cachedPodRequests[p.UID] = resources.RequestsForPods(p)
+		s.newNodeClaimRequests[p.UID] = withSafetyMargin(s.cachedPodRequests[p.UID], s.safetyMargin)
 	}
 	q := NewQueue(pods, s.cachedPodRequests)
 
@@ -254,36 +346,90 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*corev1.Pod) Results {
 	}
 	UnfinishedWorkSeconds.Delete(map[string]string{ControllerLabel: injection.GetControllerName(ctx), schedulingIDLabel: string(s.id)})
 	for _, m := range s.newNodeClaims {
+		s.reservationManager.Reserve(m)
 		m.FinalizeScheduling()
 	}
+	s.recordSimulationSize(ctx)
+	span.SetAttributes(
+		attribute.Int("nodeclaims.new", len(s.newNodeClaims)),
+		attribute.Int("pods.unschedulable", len(errors)),
+	)
 
 	return Results{
-		NewNodeClaims: s.newNodeClaims,
-		ExistingNodes: s.existingNodes,
-		PodErrors:     errors,
+		NewNodeClaims:      s.newNodeClaims,
+		ExistingNodes:      s.existingNodes,
+		PodErrors:          errors,
+		NodePoolRejections: s.rejections,
 	}
 }
 
 func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
+	delete(s.rejections, pod.UID)
+	if podutils.IsExclusive(pod) {
+		return s.addExclusive(ctx, pod)
+	}
 	// first try to schedule against an in-flight real node
-	for _, node := range s.existingNodes {
+	for _, node := range s.existingNodesFor(pod) {
 		if err := node.Add(ctx, s.kubeClient, pod, s.cachedPodRequests[pod.UID]); err == nil {
 			return nil
 		}
 	}
 
 	// Consider using https://pkg.go.dev/container/heap
-	sort.Slice(s.newNodeClaims, func(a, b int) bool { return len(s.newNodeClaims[a].Pods) < len(s.newNodeClaims[b].Pods) })
+	orderForPacking(s.newNodeClaims, s.cachedPodRequests[pod.UID])
 
 	// Pick existing node that we are about to create
 	for _, nodeClaim := range s.newNodeClaims {
-		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID]); err == nil {
+		if !nodeClaim.admitsByPrice(s.cachedPodRequests[pod.UID]) {
+			continue
+		}
+		if err := nodeClaim.Add(pod, s.newNodeClaimRequests[pod.UID]); err == nil {
 			return nil
 		}
 	}
 
-	// Create new node
+	return s.addToNewNodeClaim(ctx, pod, false)
+}
+
+// existingNodesFor returns the existing nodes in the order that the pod should be scheduled against them. Like
+// kube-scheduler, nodes with PreferNoSchedule taints that the pod doesn't tolerate are only used once the pod doesn't
+// fit on any node without them, rather than being ruled out and causing new capacity to be launched.
+func (s *Scheduler) existingNodesFor(pod *corev1.Pod) []*ExistingNode {
+	if !s.preferNoScheduleTainted {
+		return s.existingNodes
+	}
+	costs := lo.SliceToMap(s.existingNodes, func(n *ExistingNode) (*ExistingNode, int) { return n, n.PreferNoScheduleCost(pod) })
+	nodes := slices.Clone(s.existingNodes)
+	sort.SliceStable(nodes, func(i, j int) bool { return costs[nodes[i]] < costs[nodes[j]] })
+	return nodes
+}
+
+// addExclusive schedules a pod that requested a dedicated node. The pod is only allowed to schedule against an existing
+// node that was launched for it or against a new NodeClaim that no other pod can share.
+func (s *Scheduler) addExclusive(ctx context.Context, pod *corev1.Pod) error {
+	// The pod may not have been patched with its toleration yet, so we simulate against a copy that has it
+	pod = pod.DeepCopy()
+	if !podutils.ToleratesExclusiveTaint(pod) {
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, v1.ExclusiveToleration(pod.UID))
+	}
+	exclusiveTaint := v1.ExclusiveNoScheduleTaint(pod.UID)
+	for _, node := range s.existingNodes {
+		if !lo.ContainsBy(node.cachedTaints, func(t corev1.Taint) bool { return t.MatchTaint(&exclusiveTaint) && t.Value == exclusiveTaint.Value }) {
+			continue
+		}
+		if err := node.Add(ctx, s.kubeClient, pod, s.cachedPodRequests[pod.UID]); err == nil {
+			return nil
+		}
+	}
+	return s.addToNewNodeClaim(ctx, pod, true)
+}
+
+func (s *Scheduler) addToNewNodeClaim(ctx context.Context, pod *corev1.Pod, exclusive bool) error {
 	var errs error
+	var limited []string // NodePools that the pod would have scheduled to if they weren't at their limits
+	// unlimited is set when a NodePool that's compatible with the pod rejected it for a reason other than its limits, in
+	// which case the pod isn't waiting on limits alone
+	unlimited := false
 	for _, nodeClaimTemplate := range s.nodeClaimTemplates {
 		instanceTypes := nodeClaimTemplate.InstanceTypeOptions
 		// if limits have been applied to the nodepool, ensure we filter instance types to avoid violating those limits
@@ -291,15 +437,46 @@ func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
 			instanceTypes = filterByRemainingResources(instanceTypes, remaining)
 			if len(instanceTypes) == 0 {
 				errs = multierr.Append(errs, fmt.Errorf("all available instance types exceed limits for nodepool: %q", nodeClaimTemplate.NodePoolName))
+				s.reject(pod, nodeClaimTemplate.NodePoolName, rejectionReasonLimits)
+				if s.compatibleIgnoringLimits(pod, nodeClaimTemplate) {
+					limited = append(limited, nodeClaimTemplate.NodePoolName)
+				}
 				continue
 			} else if len(nodeClaimTemplate.InstanceTypeOptions) != len(instanceTypes) {
 				log.FromContext(ctx).V(1).WithValues("NodePool", klog.KRef("", nodeClaimTemplate.NodePoolName)).Info(fmt.Sprintf("%d out of %d instance types were excluded because they would breach limits",
 					len(nodeClaimTemplate.InstanceTypeOptions)-len(instanceTypes), len(nodeClaimTemplate.InstanceTypeOptions)))
 			}
 		}
+		var zones []string
+		if s.zoneLimits.Limited(nodeClaimTemplate.NodePoolName) {
+			requirements := scheduling.NewRequirements(nodeClaimTemplate.Requirements.Values()...)
+			requirements.Add(scheduling.NewPodRequirements(pod).Values()...)
+			instanceTypes, zones = s.zoneLimits.Filter(nodeClaimTemplate.NodePoolName, requirements, instanceTypes)
+			if len(instanceTypes) == 0 {
+				errs = multierr.Append(errs, fmt.Errorf("all available instance types exceed zone limits for nodepool: %q", nodeClaimTemplate.NodePoolName))
+				s.reject(pod, nodeClaimTemplate.NodePoolName, rejectionReasonLimits)
+				if s.compatibleIgnoringLimits(pod, nodeClaimTemplate) {
+					limited = append(limited, nodeClaimTemplate.NodePoolName)
+				}
+				continue
+			}
+		}
 		nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], instanceTypes)
-		if err := nodeClaim.Add(pod, s.cachedPodRequests[pod.UID]); err != nil {
+		if len(zones) > 0 {
+			nodeClaim.Requirements.Add(scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zones...))
+		}
+		// Instance types that were filtered by limits depend on the NodePool's remaining resources, so we only use the
+		// cache for the full set of the template's instance types
+		if s.filterCache != nil && len(instanceTypes) == len(nodeClaimTemplate.InstanceTypeOptions) {
+			nodeClaim.filterCache, nodeClaim.instanceTypesKey = s.filterCache, s.instanceTypesKeys[nodeClaimTemplate]
+		}
+		if exclusive {
+			nodeClaim.Isolate(pod)
+		}
+		if err := nodeClaim.Add(pod, s.newNodeClaimRequests[pod.UID]); err != nil {
 			nodeClaim.Destroy() // Ensure we cleanup any changes that we made while mocking out a NodeClaim
+			s.reject(pod, nodeClaimTemplate.NodePoolName, rejectionReason(err))
+			unlimited = unlimited || s.compatibleIgnoringLimits(pod, nodeClaimTemplate)
 			errs = multierr.Append(errs, fmt.Errorf("incompatible with nodepool %q, daemonset overhead=%s, %w",
 				nodeClaimTemplate.NodePoolName,
 				resources.String(s.daemonOverhead[nodeClaimTemplate]),
@@ -309,19 +486,119 @@ func (s *Scheduler) add(ctx context.Context, pod *corev1.Pod) error {
 		// we will launch this nodeClaim and need to track its maximum possible resource usage against our remaining resources
 		s.newNodeClaims = append(s.newNodeClaims, nodeClaim)
 		s.remainingResources[nodeClaimTemplate.NodePoolName] = subtractMax(s.remainingResources[nodeClaimTemplate.NodePoolName], nodeClaim.InstanceTypeOptions)
+		s.zoneLimits.Reserve(nodeClaim)
 		return nil
 	}
+	if len(limited) > 0 && !unlimited {
+		return &NodePoolLimitsExceededError{NodePools: limited, err: errs}
+	}
 	return errs
 }
 
-func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, daemonSetPods []*corev1.Pod) {
+// subtractZoneLimits counts the node against the zone limits of its NodePool. NodeClaims that haven't launched yet don't
+// have a zone or capacity, so they're counted as their NodePool's largest compatible instance type in every zone that
+// their requirements allow.
+func (s *Scheduler) subtractZoneLimits(node *state.StateNode) {
+	nodePool := node.Labels()[v1.NodePoolLabelKey]
+	if !s.zoneLimits.Limited(nodePool) {
+		return
+	}
+	if zone, ok := node.Labels()[corev1.LabelTopologyZone]; ok && len(node.Capacity()) > 0 {
+		s.zoneLimits.Subtract(nodePool, scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone), node.Capacity())
+		return
+	}
+	if node.NodeClaim == nil {
+		return
+	}
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(node.NodeClaim.Spec.Requirements...)
+	template, ok := lo.Find(s.nodeClaimTemplates, func(nct *NodeClaimTemplate) bool { return nct.NodePoolName == nodePool })
+	if !ok {
+		return
+	}
+	compatible := lo.Filter(template.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+		return it.Requirements.Compatible(requirements, scheduling.AllowUndefinedWellKnownLabels) == nil
+	})
+	if len(compatible) == 0 {
+		return
+	}
+	s.zoneLimits.Subtract(nodePool, requirements.Get(corev1.LabelTopologyZone), maxCapacity(compatible))
+}
+
+// reject records the reason that a NodePool couldn't launch capacity for the pod
+func (s *Scheduler) reject(pod *corev1.Pod, nodePool string, reason string) {
+	if _, ok := s.rejections[pod.UID]; !ok {
+		s.rejections[pod.UID] = map[string]string{}
+	}
+	s.rejections[pod.UID][nodePool] = reason
+}
+
+// compatibleIgnoringLimits returns whether the pod could have been launched by the NodeClaimTemplate if the NodePool
+// weren't at its limits. This is a cheaper check than NodeClaim.Add since it's only used to classify the scheduling error
+// and ignores topology.
+func (s *Scheduler) compatibleIgnoringLimits(pod *corev1.Pod, nodeClaimTemplate *NodeClaimTemplate) bool {
+	if err := scheduling.Taints(nodeClaimTemplate.Spec.Taints).Tolerates(pod); err != nil {
+		return false
+	}
+	requirements := scheduling.NewRequirements(nodeClaimTemplate.Requirements.Values()...)
+	podRequirements := scheduling.NewPodRequirements(pod)
+	if err := requirements.Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
+		return false
+	}
+	requirements.Add(podRequirements.Values()...)
+	requests := resources.Merge(s.daemonOverhead[nodeClaimTemplate], s.cachedPodRequests[pod.UID])
+	return len(filterInstanceTypesByRequirements(nodeClaimTemplate.InstanceTypeOptions, requirements, requests).remaining) > 0
+}
+
+// NodePoolLimitsExceededError is returned when a pod couldn't be scheduled because every NodePool that could launch
+// capacity for it is at its limits. The pod is deferred until capacity is freed up or the limits are raised.
+type NodePoolLimitsExceededError struct {
+	NodePools []string
+	err       error
+}
+
+func (e *NodePoolLimitsExceededError) Error() string {
+	return e.err.Error()
+}
+
+func (e *NodePoolLimitsExceededError) Unwrap() error {
+	return e.err
+}
+
+// recordSimulationSize reports the size of the simulation state and any bounds that were hit while simulating
+func (s *Scheduler) recordSimulationSize(ctx context.Context) {
+	SimulationExistingNodes.Set(float64(len(s.existingNodes)), map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
+	SimulationTopologyDomains.Set(float64(s.topology.DomainCount()), map[string]string{ControllerLabel: injection.GetControllerName(ctx)})
+	if s.topology.domainsBounded {
+		s.boundsReached.Insert(topologyDomainsBound)
+	}
+	for bound := range s.boundsReached {
+		SimulationBoundsReachedTotal.Inc(map[string]string{ControllerLabel: injection.GetControllerName(ctx), boundLabel: bound})
+	}
+}
+
+func (s *Scheduler) calculateExistingNodeClaims(ctx context.Context, stateNodes []*state.StateNode, daemonSetPods []*corev1.Pod, baseline map[string]map[string]resource.Quantity) {
+	simulated := boundCandidateNodes(stateNodes, options.FromContext(ctx).SimulationMaxCandidateNodes)
+	if len(simulated) < len(stateNodes) {
+		s.boundsReached.Insert(candidateNodesBound)
+		log.FromContext(ctx).V(1).Info(fmt.Sprintf("excluded %d out of %d existing node(s) from scheduling simulation", len(stateNodes)-len(simulated), len(stateNodes)))
+	}
 	// create our existing nodes
 	for _, node := range stateNodes {
+		// We don't use the status field and instead recompute the remaining resources to ensure we have a consistent view
+		// of the cluster during scheduling.  Depending on how node creation falls out, this will also work for cases where
+		// we don't create NodeClaim resources.
+		if _, ok := s.remainingResources[node.Labels()[v1.NodePoolLabelKey]]; ok {
+			s.remainingResources[node.Labels()[v1.NodePoolLabelKey]] = resources.Subtract(s.remainingResources[node.Labels()[v1.NodePoolLabelKey]], node.Capacity())
+		}
+		s.subtractZoneLimits(node)
+		if _, ok := simulated[node]; !ok {
+			continue
+		}
 		// Calculate any daemonsets that should schedule to the inflight node
 		taints := node.Taints()
 		var daemons []*corev1.Pod
 		for _, p := range daemonSetPods {
-			if err := scheduling.Taints(taints).Tolerates(p); err != nil {
+			if err := scheduling.Taints(taints).Required().Tolerates(p); err != nil {
 				continue
 			}
 			if err := scheduling.NewLabelRequirements(node.Labels()).Compatible(scheduling.NewPodRequirements(p)); err != nil {
@@ -329,15 +606,14 @@ func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, d
 			}
 			daemons = append(daemons, p)
 		}
-		s.existingNodes = append(s.existingNodes, NewExistingNode(node, s.topology, taints, resources.RequestsForPods(daemons...)))
-
-		// We don't use the status field and instead recompute the remaining resources to ensure we have a consistent view
-		// of the cluster during scheduling.  Depending on how node creation falls out, this will also work for cases where
-		// we don't create NodeClaim resources.
-		if _, ok := s.remainingResources[node.Labels()[v1.NodePoolLabelKey]]; ok {
-			s.remainingResources[node.Labels()[v1.NodePoolLabelKey]] = resources.Subtract(s.remainingResources[node.Labels()[v1.NodePoolLabelKey]], node.Capacity())
+		existingNode := NewExistingNode(node, s.topology, taints, resources.RequestsForPods(daemons...))
+		// Nodes are packed against the same baseline CPU as the new NodeClaims of their NodePool
+		if cpu, ok := baseline[node.Labels()[v1.NodePoolLabelKey]][node.Labels()[corev1.LabelInstanceTypeStable]]; ok {
+			existingNode.reserveBurstCPU(cpu)
 		}
+		s.existingNodes = append(s.existingNodes, existingNode)
 	}
+	s.preferNoScheduleTainted = lo.SomeBy(s.existingNodes, func(n *ExistingNode) bool { return len(n.preferNoScheduleTaints) > 0 })
 	// Order the existing nodes for scheduling with initialized nodes first
 	// This is done specifically for consolidation where we want to make sure we schedule to initialized nodes
 	// before we attempt to schedule uninitialized ones
@@ -404,6 +680,22 @@ func subtractMax(remaining corev1.ResourceList, instanceTypes []*cloudprovider.I
 	return result
 }
 
+// withSafetyMargin scales up the CPU and memory requests by the margin, so that pods packed onto new NodeClaims leave
+// room for the differences between scheduling simulation and where kube-scheduler binds pods
+func withSafetyMargin(requests corev1.ResourceList, margin float64) corev1.ResourceList {
+	if margin <= 0 {
+		return requests
+	}
+	scaled := requests.DeepCopy()
+	if cpu, ok := requests[corev1.ResourceCPU]; ok {
+		scaled[corev1.ResourceCPU] = *resource.NewMilliQuantity(int64(math.Ceil(float64(cpu.MilliValue())*(1+margin))), cpu.Format)
+	}
+	if memory, ok := requests[corev1.ResourceMemory]; ok {
+		scaled[corev1.ResourceMemory] = *resource.NewQuantity(int64(math.Ceil(float64(memory.Value())*(1+margin))), memory.Format)
+	}
+	return scaled
+}
+
 // filterByRemainingResources is used to filter out instance types that if launched would exceed the nodepool limits
 func filterByRemainingResources(instanceTypes []*cloudprovider.InstanceType, remaining corev1.ResourceList) []*cloudprovider.InstanceType {
 	var filtered []*cloudprovider.InstanceType
@@ -422,3 +714,20 @@ func filterByRemainingResources(instanceTypes []*cloudprovider.InstanceType, rem
 	}
 	return filtered
 }
+
+// boundCandidateNodes returns the set of state nodes that should be considered as scheduling targets given the maximum
+// number of candidate nodes. In-flight nodes are kept first since pending pods are most likely to be nominated for them.
+func boundCandidateNodes(stateNodes []*state.StateNode, maxNodes int) map[*state.StateNode]struct{} {
+	nodes := stateNodes
+	if maxNodes > 0 && len(stateNodes) > maxNodes {
+		nodes = append([]*state.StateNode{}, stateNodes...)
+		sort.Slice(nodes, func(i, j int) bool {
+			if nodes[i].Initialized() != nodes[j].Initialized() {
+				return !nodes[i].Initialized()
+			}
+			return nodes[i].Name() < nodes[j].Name()
+		})
+		nodes = nodes[:maxNodes]
+	}
+	return lo.SliceToMap(nodes, func(n *state.StateNode) (*state.StateNode, struct{}) { return n, struct{}{} })
+}
@@ -37,6 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
+	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 	fakecr "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	ctrl "sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -47,6 +48,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 )
 
@@ -94,6 +96,28 @@ func BenchmarkScheduling5000(b *testing.B) {
 	benchmarkScheduler(b, 400, 5000)
 }
 
+// BenchmarkSchedulingExistingNodes measures the latency of a provisioning round in a cluster that is already at scale.
+// Each round has to consider every existing node before launching new capacity, so it's the scheduling simulation
+// that grows with the size of the cluster rather than with the number of pending pods.
+func BenchmarkSchedulingExistingNodes1000(b *testing.B) {
+	benchmarkSchedulerExistingNodes(b, 1000)
+}
+func BenchmarkSchedulingExistingNodes5000(b *testing.B) {
+	benchmarkSchedulerExistingNodes(b, 5000)
+}
+func BenchmarkSchedulingExistingNodes10000(b *testing.B) {
+	benchmarkSchedulerExistingNodes(b, 10000)
+}
+
+// existingNodesBudget is the longest a single provisioning round can take for each benchmarked cluster size before
+// the benchmark is considered a regression. Budgets are intentionally loose so that they only catch changes that
+// alter how scheduling scales, rather than noise between machines.
+var existingNodesBudget = map[int]time.Duration{
+	1000:  5 * time.Second,
+	5000:  20 * time.Second,
+	10000: 40 * time.Second,
+}
+
 var includeMinValues bool
 
 func init() {
@@ -140,6 +164,7 @@ func TestSchedulingProfile(t *testing.T) {
 func benchmarkScheduler(b *testing.B, instanceCount, podCount int) {
 	// disable logging
 	ctx = ctrl.IntoContext(context.Background(), operatorlogging.NopLogger)
+	ctx = options.ToContext(ctx, test.Options())
 	nodePoolWithMinValues := test.NodePool(v1.NodePool{
 		Spec: v1.NodePoolSpec{
 			Template: v1.NodeClaimTemplate{
@@ -176,7 +201,7 @@ func benchmarkScheduler(b *testing.B, instanceCount, podCount int) {
 	scheduler := scheduling.NewScheduler(ctx, client, []*v1.NodePool{nodePool},
 		cluster, nil, topology,
 		map[string][]*cloudprovider.InstanceType{nodePool.Name: instanceTypes}, nil,
-		events.NewRecorder(&record.FakeRecorder{}), clock)
+		scheduling.NewInstanceTypeFilterCache(), events.NewRecorder(&record.FakeRecorder{}), clock)
 
 	b.ResetTimer()
 	// Pack benchmark
@@ -230,6 +255,62 @@ func benchmarkScheduler(b *testing.B, instanceCount, podCount int) {
 	}
 }
 
+func benchmarkSchedulerExistingNodes(b *testing.B, nodeCount int) {
+	ctx = ctrl.IntoContext(context.Background(), operatorlogging.NopLogger)
+	ctx = options.ToContext(ctx, test.Options())
+	nodePool := test.NodePool()
+	instanceTypes := fake.InstanceTypes(400)
+	cloudProvider = fake.NewCloudProvider()
+	cloudProvider.InstanceTypes = instanceTypes
+
+	// Cluster state looks up the pods bound to each node it tracks
+	client := fakecr.NewClientBuilder().WithIndex(&corev1.Pod{}, "spec.nodeName", func(o crclient.Object) []string {
+		return []string{o.(*corev1.Pod).Spec.NodeName}
+	}).Build()
+	clock := &clock.RealClock{}
+	cluster = state.NewCluster(clock, client, cloudProvider)
+	for i := 0; i < nodeCount; i++ {
+		instanceType := instanceTypes[i%len(instanceTypes)]
+		offering := instanceType.Offerings[i%len(instanceType.Offerings)]
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1.LabelInstanceTypeStable: instanceType.Name,
+					corev1.LabelTopologyZone:       offering.Requirements.Get(corev1.LabelTopologyZone).Any(),
+					v1.CapacityTypeLabelKey:        offering.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+				},
+			},
+			ProviderID:  test.RandomProviderID(),
+			Allocatable: instanceType.Allocatable(),
+		})
+		if err := cluster.UpdateNode(ctx, node); err != nil {
+			b.Fatalf("tracking node, %s", err)
+		}
+	}
+	// A pod for every existing node, so that the topology and affinity constraints of the batch span the whole cluster
+	pods := makeDiversePods(nodeCount)
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		topology, err := scheduling.NewTopology(ctx, client, cluster, map[string]sets.Set[string]{}, pods)
+		if err != nil {
+			b.Fatalf("creating topology, %s", err)
+		}
+		scheduler := scheduling.NewScheduler(ctx, client, []*v1.NodePool{nodePool},
+			cluster, cluster.Nodes(), topology,
+			map[string][]*cloudprovider.InstanceType{nodePool.Name: instanceTypes}, nil,
+			scheduling.NewInstanceTypeFilterCache(), events.NewRecorder(&record.FakeRecorder{}), clock)
+		scheduler.Solve(ctx, pods)
+	}
+	perRound := time.Since(start) / time.Duration(b.N)
+	b.ReportMetric(perRound.Seconds(), "sec/round")
+
+	if budget, ok := existingNodesBudget[nodeCount]; ok && perRound > budget {
+		b.Fatalf("provisioning round with %d existing nodes took %s, expected at most %s", nodeCount, perRound, budget)
+	}
+}
+
 func makeDiversePods(count int) []*corev1.Pod {
 	var pods []*corev1.Pod
 	numTypes := 6
@@ -45,6 +45,7 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/apis"
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	apisv1alpha1 "sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
@@ -1551,6 +1552,44 @@ var _ = Context("Scheduling", func() {
 			// would
 			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("default-instance-type"))
 		})
+		It("should take simulation policies into consideration", func() {
+			ExpectApplied(ctx, env.Client, nodePool, &apisv1alpha1.SimulationPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "limits-as-requests"},
+				Spec: apisv1alpha1.SimulationPolicySpec{
+					Namespaces: []string{"default"},
+					Mutations:  apisv1alpha1.PodMutations{UseLimitsAsRequests: true},
+				},
+			})
+			pod := test.UnschedulablePod(
+				test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+				}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			// the limit of 3 CPUs is simulated as the request, so it won't fit on small-instance-type which it otherwise would
+			Expect(node.Labels[corev1.LabelInstanceTypeStable]).To(Equal("default-instance-type"))
+			// the pod itself isn't mutated
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.Spec.Containers[0].Resources.Requests.Cpu().String()).To(Equal("1"))
+		})
+		It("should add tolerations from simulation policies that select the pod", func() {
+			nodePool.Spec.Template.Spec.Taints = []corev1.Taint{{Key: "example.com/team", Value: "a", Effect: corev1.TaintEffectNoSchedule}}
+			ExpectApplied(ctx, env.Client, nodePool, &apisv1alpha1.SimulationPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+				Spec: apisv1alpha1.SimulationPolicySpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Mutations: apisv1alpha1.PodMutations{
+						Tolerations: []corev1.Toleration{{Key: "example.com/team", Operator: corev1.TolerationOpEqual, Value: "a", Effect: corev1.TaintEffectNoSchedule}},
+					},
+				},
+			})
+			selected := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "a"}}})
+			unselected := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, selected, unselected)
+			ExpectScheduled(ctx, env.Client, selected)
+			ExpectNotScheduled(ctx, env.Client, unselected)
+		})
 		It("should schedule multiple small pods on the smallest possible instance type", func() {
 			opts := test.PodOptions{
 				Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Reason: corev1.PodReasonUnschedulable, Status: corev1.ConditionFalse}},
@@ -1815,6 +1854,51 @@ var _ = Context("Scheduling", func() {
 		})
 	})
 
+	Describe("Exclusive Nodes", func() {
+		It("should launch a dedicated node for a pod with the exclusive-node annotation", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			exclusivePod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.ExclusiveNodeAnnotationKey: "true"},
+			}})
+			pods := []*corev1.Pod{exclusivePod, test.UnschedulablePod(), test.UnschedulablePod()}
+			bindings := ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			node := ExpectScheduled(ctx, env.Client, exclusivePod)
+			Expect(ExpectScheduled(ctx, env.Client, pods[1]).Name).ToNot(Equal(node.Name))
+			Expect(ExpectScheduled(ctx, env.Client, pods[2]).Name).ToNot(Equal(node.Name))
+
+			nodeClaim := bindings.Get(exclusivePod).NodeClaim
+			Expect(nodeClaim.Spec.Taints).To(ContainElement(v1.ExclusiveNoScheduleTaint(exclusivePod.UID)))
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1.ExclusiveNodeAnnotationKey, client.ObjectKeyFromObject(exclusivePod).String()))
+			exclusivePod = ExpectExists(ctx, env.Client, exclusivePod)
+			Expect(exclusivePod.Spec.Tolerations).To(ContainElement(v1.ExclusiveToleration(exclusivePod.UID)))
+		})
+		It("should not schedule other pods to an in-flight exclusive node", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			exclusivePod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.ExclusiveNodeAnnotationKey: "true"},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, exclusivePod)
+			node1 := ExpectScheduled(ctx, env.Client, exclusivePod)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node1))
+
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node2 := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node1.Name).ToNot(Equal(node2.Name))
+		})
+		It("should not launch a second node for an exclusive pod that already has an in-flight node", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			exclusivePod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.ExclusiveNodeAnnotationKey: "true"},
+			}})
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, exclusivePod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, ExpectExists(ctx, env.Client, exclusivePod))
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+	})
 	Describe("In-Flight Nodes", func() {
 		It("should not launch a second node if there is an in-flight node that can support the pod", func() {
 			opts := test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
@@ -2058,6 +2142,38 @@ var _ = Context("Scheduling", func() {
 				node2 := ExpectScheduled(ctx, env.Client, secondPod)
 				Expect(node1.Name).ToNot(Equal(node2.Name))
 			})
+			It("should assume pod will schedule to a node with a PreferNoSchedule taint when no other node fits", func() {
+				opts := test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+					Limits: map[corev1.ResourceName]resource.Quantity{
+						corev1.ResourceCPU: resource.MustParse("8"),
+					},
+				}}
+				ExpectApplied(ctx, env.Client, nodePool)
+				initialPod := test.UnschedulablePod(opts)
+				bindings := ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, initialPod)
+				ExpectScheduled(ctx, env.Client, initialPod)
+
+				nodeClaim1 := bindings.Get(initialPod).NodeClaim
+				node1 := bindings.Get(initialPod).Node
+				nodeClaim1.StatusConditions().SetTrue(v1.ConditionTypeInitialized)
+				node1.Labels = lo.Assign(node1.Labels, map[string]string{v1.NodeInitializedLabelKey: "true"})
+
+				// delete the pod so that the node is empty
+				ExpectDeleted(ctx, env.Client, initialPod)
+				// and softly taint it, which kube-scheduler only avoids when another node fits the pod
+				node1.Spec.Taints = append(node1.Spec.Taints, corev1.Taint{
+					Key:    "foo.com/taint",
+					Value:  "tainted",
+					Effect: corev1.TaintEffectPreferNoSchedule,
+				})
+				ExpectApplied(ctx, env.Client, nodeClaim1, node1)
+				ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node1))
+
+				secondPod := test.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, secondPod)
+				node2 := ExpectScheduled(ctx, env.Client, secondPod)
+				Expect(node1.Name).To(Equal(node2.Name))
+			})
 			It("should assume pod will schedule to a tainted node with a custom startup taint", func() {
 				opts := test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
 					Limits: map[corev1.ResourceName]resource.Quantity{
@@ -3643,6 +3759,92 @@ var _ = Context("Scheduling", func() {
 		})
 	})
 
+	Describe("Simulation Bounds", func() {
+		antiAffinityPods := func(app string, count int) []*corev1.Pod {
+			labels := map[string]string{"app": app}
+			return test.UnschedulablePods(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				PodAntiRequirements: []corev1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+					TopologyKey:   corev1.LabelHostname,
+				}},
+			}, count)
+		}
+		It("should bound the number of existing nodes considered in a simulation", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, antiAffinityPods("existing", 3)...)
+			Expect(cluster.Nodes()).To(HaveLen(3))
+
+			boundedCtx := injection.WithControllerName(options.ToContext(ctx, test.Options(test.OptionsFields{SimulationMaxCandidateNodes: lo.ToPtr(1)})), "bounded")
+			pod := test.UnschedulablePod()
+			s, err := prov.NewScheduler(boundedCtx, []*corev1.Pod{pod}, cluster.Nodes().Active())
+			Expect(err).To(BeNil())
+			results := s.Solve(boundedCtx, []*corev1.Pod{pod})
+			Expect(results.ExistingNodes).To(HaveLen(1))
+			Expect(results.PodErrors).To(BeEmpty())
+
+			m, ok := FindMetricWithLabelValues("karpenter_scheduler_simulation_existing_nodes", map[string]string{"controller": "bounded"})
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(m.Gauge.Value)).To(BeNumerically("==", 1))
+			m, ok = FindMetricWithLabelValues("karpenter_scheduler_simulation_bounds_reached_total", map[string]string{"controller": "bounded", "bound": "candidate_nodes"})
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(m.Counter.Value)).To(BeNumerically(">=", 1))
+		})
+		It("should bound the number of existing node domains tracked by a topology group", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, antiAffinityPods("existing", 3)...)
+			Expect(cluster.Nodes()).To(HaveLen(3))
+
+			boundedCtx := injection.WithControllerName(options.ToContext(ctx, test.Options(test.OptionsFields{SimulationMaxTopologyDomains: lo.ToPtr(1)})), "bounded")
+			pods := antiAffinityPods("nginx", 1)
+			s, err := prov.NewScheduler(boundedCtx, pods, cluster.Nodes().Active())
+			Expect(err).To(BeNil())
+			results := s.Solve(boundedCtx, pods)
+			// The pod still schedules to a new node since only the existing node domains are bounded
+			Expect(results.PodErrors).To(BeEmpty())
+			Expect(results.NewNodeClaims).To(HaveLen(1))
+
+			m, ok := FindMetricWithLabelValues("karpenter_scheduler_simulation_bounds_reached_total", map[string]string{"controller": "bounded", "bound": "topology_domains"})
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(m.Counter.Value)).To(BeNumerically(">=", 1))
+		})
+		It("should bound the number of domains counted for the existing pods of a topology group", func() {
+			labels := map[string]string{"app": "existing"}
+			for i := 0; i < 3; i++ {
+				node := test.Node()
+				ExpectApplied(ctx, env.Client, node, test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, NodeName: node.Name}))
+			}
+			pod := test.UnschedulablePod(test.PodOptions{
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+					TopologyKey:       corev1.LabelHostname,
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+					MaxSkew:           1,
+				}},
+			})
+			topology, err := scheduling.NewTopology(ctx, env.Client, cluster, map[string]sets.Set[string]{}, []*corev1.Pod{pod})
+			Expect(err).To(BeNil())
+			Expect(topology.DomainCount()).To(Equal(3))
+
+			boundedCtx := options.ToContext(ctx, test.Options(test.OptionsFields{SimulationMaxTopologyDomains: lo.ToPtr(1)}))
+			topology, err = scheduling.NewTopology(boundedCtx, env.Client, cluster, map[string]sets.Set[string]{}, []*corev1.Pod{pod})
+			Expect(err).To(BeNil())
+			Expect(topology.DomainCount()).To(Equal(1))
+		})
+		It("should not bound the simulation by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, antiAffinityPods("existing", 3)...)
+
+			unboundedCtx := injection.WithControllerName(ctx, "unbounded")
+			pod := test.UnschedulablePod()
+			s, err := prov.NewScheduler(unboundedCtx, []*corev1.Pod{pod}, cluster.Nodes().Active())
+			Expect(err).To(BeNil())
+			results := s.Solve(unboundedCtx, []*corev1.Pod{pod})
+			Expect(results.ExistingNodes).To(HaveLen(3))
+			_, ok := FindMetricWithLabelValues("karpenter_scheduler_simulation_bounds_reached_total", map[string]string{"controller": "unbounded"})
+			Expect(ok).To(BeFalse())
+		})
+	})
 	Describe("Metrics", func() {
 		It("should surface the queueDepth metric while executing the scheduling loop", func() {
 			nodePool = test.NodePool()
@@ -3679,6 +3881,55 @@ var _ = Context("Scheduling", func() {
 			s.Solve(injection.WithControllerName(ctx, "provisioner"), pods)
 			wg.Wait()
 		})
+		It("should reuse instance type selection for identical pods on new NodeClaims", func() {
+			scheduling.InstanceTypeFilterCacheRequestsTotal.Reset()
+			nodePool = test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			labels := map[string]string{
+				"app": "nginx",
+			}
+			// anti-affinity forces every pod onto a NodeClaim of its own
+			pods := test.Pods(5, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				PodAntiRequirements: []corev1.PodAffinityTerm{
+					{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+						TopologyKey:   corev1.LabelHostname,
+					},
+				},
+			})
+			s, err := prov.NewScheduler(ctx, pods, nil)
+			Expect(err).To(BeNil())
+			results := s.Solve(ctx, pods)
+			Expect(results.PodErrors).To(BeEmpty())
+			Expect(results.NewNodeClaims).To(HaveLen(5))
+			for _, nodeClaim := range results.NewNodeClaims {
+				Expect(nodeClaim.InstanceTypeOptions).To(HaveLen(len(results.NewNodeClaims[0].InstanceTypeOptions)))
+			}
+			ExpectMetricCounterValue(scheduling.InstanceTypeFilterCacheRequestsTotal, 1, map[string]string{"result": "miss"})
+			ExpectMetricCounterValue(scheduling.InstanceTypeFilterCacheRequestsTotal, 4, map[string]string{"result": "hit"})
+		})
+		It("should not reuse instance type selection after offerings change", func() {
+			scheduling.InstanceTypeFilterCacheRequestsTotal.Reset()
+			nodePool = test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			s, err := prov.NewScheduler(ctx, []*corev1.Pod{pod}, nil)
+			Expect(err).To(BeNil())
+			Expect(s.Solve(ctx, []*corev1.Pod{pod}).PodErrors).To(BeEmpty())
+
+			for _, it := range cloudProvider.InstanceTypes {
+				for i := range it.Offerings {
+					it.Offerings[i].Price++
+				}
+			}
+			s, err = prov.NewScheduler(ctx, []*corev1.Pod{pod}, nil)
+			Expect(err).To(BeNil())
+			Expect(s.Solve(ctx, []*corev1.Pod{pod}).PodErrors).To(BeEmpty())
+			ExpectMetricCounterValue(scheduling.InstanceTypeFilterCacheRequestsTotal, 2, map[string]string{"result": "miss"})
+		})
 		It("should surface the UnschedulablePodsCount metric while executing the scheduling loop", func() {
 			nodePool = test.NodePool(v1.NodePool{
 				Spec: v1.NodePoolSpec{
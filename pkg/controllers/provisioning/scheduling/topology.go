@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
@@ -55,6 +56,10 @@ type Topology struct {
 	// moving pods to prevent them from being double counted.
 	excludedPods sets.Set[string]
 	cluster      *state.Cluster
+	// maxDomains bounds the number of domains that existing nodes can register with a single topology group. If the
+	// bound is reached, domainsBounded is set so that the degraded simulation can be reported.
+	maxDomains     int
+	domainsBounded bool
 }
 
 func NewTopology(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, domains map[string]sets.Set[string], pods []*corev1.Pod) (*Topology, error) {
@@ -65,6 +70,7 @@ func NewTopology(ctx context.Context, kubeClient client.Client, cluster *state.C
 		topologies:        map[uint64]*TopologyGroup{},
 		inverseTopologies: map[uint64]*TopologyGroup{},
 		excludedPods:      sets.New[string](),
+		maxDomains:        options.FromContext(ctx).SimulationMaxTopologyDomains,
 	}
 
 	// these are the pods that we intend to schedule, so if they are currently in the cluster we shouldn't count them for
@@ -201,6 +207,40 @@ func (t *Topology) Register(topologyKey string, domain string) {
 	}
 }
 
+// RegisterBounded registers a domain in the same way as Register, but skips any topology group that is already tracking
+// the maximum number of domains. This is used for existing nodes so that the simulation state stays bounded on large
+// clusters. Pods with topology constraints on the given key won't schedule to a domain that was skipped.
+func (t *Topology) RegisterBounded(topologyKey string, domain string) {
+	if t.maxDomains <= 0 {
+		t.Register(topologyKey, domain)
+		return
+	}
+	for _, tgs := range []map[uint64]*TopologyGroup{t.topologies, t.inverseTopologies} {
+		for _, topology := range tgs {
+			if topology.Key != topologyKey {
+				continue
+			}
+			if _, ok := topology.domains[domain]; !ok && len(topology.domains) >= t.maxDomains {
+				t.domainsBounded = true
+				continue
+			}
+			topology.Register(domain)
+		}
+	}
+}
+
+// DomainCount returns the total number of domains tracked across all topology groups
+func (t *Topology) DomainCount() int {
+	count := 0
+	for _, tg := range t.topologies {
+		count += len(tg.domains)
+	}
+	for _, tg := range t.inverseTopologies {
+		count += len(tg.domains)
+	}
+	return count
+}
+
 // Unregister is used to unregister a domain as available across topologies for the given topology key.
 func (t *Topology) Unregister(topologyKey string, domain string) {
 	for _, topology := range t.topologies {
@@ -263,23 +303,27 @@ func (t *Topology) updateInverseAntiAffinity(ctx context.Context, pod *corev1.Po
 
 // countDomains initializes the topology group by registereding any well known domains and performing pod counts
 // against the cluster for any existing pods.
-//
-//nolint:gocyclo
 func (t *Topology) countDomains(ctx context.Context, tg *TopologyGroup) error {
-	podList := &corev1.PodList{}
-
-	// collect the pods from all the specified namespaces (don't see a way to query multiple namespaces
+	// count the pods from each of the specified namespaces in turn (don't see a way to query multiple namespaces
 	// simultaneously)
-	var pods []corev1.Pod
 	for _, ns := range tg.namespaces.UnsortedList() {
-		if err := t.kubeClient.List(ctx, podList, TopologyListOptions(ns, tg.rawSelector)); err != nil {
-			return fmt.Errorf("listing pods, %w", err)
+		if err := t.countNamespaceDomains(ctx, tg, ns); err != nil {
+			return err
 		}
-		pods = append(pods, podList.Items...)
 	}
+	return nil
+}
 
-	for i, p := range pods {
-		if IgnoredForTopology(&pods[i]) {
+// countNamespaceDomains counts the pods of a single namespace that are selected by the topology group
+//
+//nolint:gocyclo
+func (t *Topology) countNamespaceDomains(ctx context.Context, tg *TopologyGroup, namespace string) error {
+	podList := &corev1.PodList{}
+	if err := t.kubeClient.List(ctx, podList, TopologyListOptions(namespace, tg.rawSelector)); err != nil {
+		return fmt.Errorf("listing pods, %w", err)
+	}
+	for i, p := range podList.Items {
+		if IgnoredForTopology(&podList.Items[i]) {
 			continue
 		}
 		// pod is excluded for counting purposes
@@ -315,11 +359,22 @@ func (t *Topology) countDomains(ctx context.Context, tg *TopologyGroup) error {
 		if !tg.nodeFilter.Matches(node) {
 			continue
 		}
-		tg.Record(domain)
+		t.recordBounded(tg, domain)
 	}
 	return nil
 }
 
+// recordBounded records a pod in the domain in the same way as TopologyGroup.Record, but skips domains that the topology
+// group doesn't track yet once it's tracking the maximum number of domains. Like RegisterBounded, this keeps the
+// domains of existing pods from growing the simulation state without bound on large clusters.
+func (t *Topology) recordBounded(tg *TopologyGroup, domain string) {
+	if _, ok := tg.domains[domain]; !ok && t.maxDomains > 0 && len(tg.domains) >= t.maxDomains {
+		t.domainsBounded = true
+		return
+	}
+	tg.Record(domain)
+}
+
 func (t *Topology) newForTopologies(p *corev1.Pod) []*TopologyGroup {
 	var topologyGroups []*TopologyGroup
 	for _, cs := range p.Spec.TopologySpreadConstraints {
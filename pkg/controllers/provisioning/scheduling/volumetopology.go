@@ -19,7 +19,9 @@ package scheduling
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -28,29 +30,85 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 	volumeutil "sigs.k8s.io/karpenter/pkg/utils/volume"
 )
 
-func NewVolumeTopology(kubeClient client.Client) *VolumeTopology {
-	return &VolumeTopology{kubeClient: kubeClient}
+const (
+	// persistentVolumeRequirementsTTL bounds how long we trust a cached PV's node affinity. Node affinity is set when
+	// the volume is provisioned and doesn't change, so this only guards against PVs being recreated under the same name.
+	persistentVolumeRequirementsTTL = 10 * time.Minute
+	// volumeOfferingTTL is how long we remember the offering a volume was last attached to. It needs to outlive the
+	// node so that the offering can still be preferred once the volume's pod is rescheduled.
+	volumeOfferingTTL = 24 * time.Hour
+)
+
+func NewVolumeTopology(kubeClient client.Client, recorder events.Recorder) *VolumeTopology {
+	return &VolumeTopology{
+		kubeClient:                   kubeClient,
+		recorder:                     recorder,
+		persistentVolumeRequirements: cache.New(persistentVolumeRequirementsTTL, time.Minute),
+		volumeOfferings:              cache.New(volumeOfferingTTL, time.Hour),
+	}
 }
 
 type VolumeTopology struct {
 	kubeClient client.Client
+	recorder   events.Recorder
+	// persistentVolumeRequirements caches the requirements derived from a bound PV's node affinity
+	persistentVolumeRequirements *cache.Cache
+	// volumeOfferings caches the instance type and capacity type of the node a bound PV was last attached to
+	volumeOfferings *cache.Cache
+}
+
+// volumeOffering is the offering of the node that a persistent volume was attached to
+type volumeOffering struct {
+	instanceType string
+	capacityType string
+}
+
+// VolumeAttachments maps the name of each attached PV to the nodes that it's attached to
+type VolumeAttachments map[string][]string
+
+// ListVolumeAttachments lists the cluster's VolumeAttachments so that they can be shared by every pod that's injected in
+// a batch, rather than being listed for each of their volumes
+func (v *VolumeTopology) ListVolumeAttachments(ctx context.Context) (VolumeAttachments, error) {
+	volumeAttachments := &storagev1.VolumeAttachmentList{}
+	if err := v.kubeClient.List(ctx, volumeAttachments); err != nil {
+		return nil, fmt.Errorf("listing volume attachments, %w", err)
+	}
+	attachments := VolumeAttachments{}
+	for _, va := range volumeAttachments.Items {
+		if name := lo.FromPtr(va.Spec.Source.PersistentVolumeName); name != "" {
+			attachments[name] = append(attachments[name], va.Spec.NodeName)
+		}
+	}
+	return attachments, nil
 }
 
-func (v *VolumeTopology) Inject(ctx context.Context, pod *v1.Pod) error {
+func (v *VolumeTopology) Inject(ctx context.Context, pod *v1.Pod, attachments VolumeAttachments) error {
 	var requirements []v1.NodeSelectorRequirement
+	var offerings []volumeOffering
 	for _, volume := range pod.Spec.Volumes {
 		req, err := v.getRequirements(ctx, pod, volume)
 		if err != nil {
 			return err
 		}
 		requirements = append(requirements, req...)
+		offering, ok, err := v.getVolumeOffering(ctx, pod, volume, attachments)
+		if err != nil {
+			return err
+		}
+		if ok {
+			offerings = append(offerings, offering)
+		}
 	}
 	if len(requirements) == 0 {
 		return nil
 	}
+	v.detectZoneConflict(pod, requirements)
 	if pod.Spec.Affinity == nil {
 		pod.Spec.Affinity = &v1.Affinity{}
 	}
@@ -71,12 +129,67 @@ func (v *VolumeTopology) Inject(ctx context.Context, pod *v1.Pod) error {
 			pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[i].MatchExpressions, requirements...)
 	}
 
+	// Prefer relaunching on the offering the pod's volumes were last attached to. This keeps StatefulSet pods on the
+	// same kind of capacity that they ran on before, and is relaxed away like any other preference if it can't be met.
+	if len(offerings) > 0 {
+		offering := offerings[0]
+		pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, v1.PreferredSchedulingTerm{
+			Weight: 1,
+			Preference: v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{offering.instanceType}},
+				{Key: karpv1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{offering.capacityType}},
+			}},
+		})
+	}
+
 	log.FromContext(ctx).
 		WithValues("Pod", klog.KRef(pod.Namespace, pod.Name)).
 		V(1).Info(fmt.Sprintf("adding requirements derived from pod volumes, %s", requirements))
 	return nil
 }
 
+// detectZoneConflict publishes an event if the zone that the pod's volumes are pinned to can't satisfy the zones
+// that the pod otherwise requires. The pod can't schedule in this case, so we want to call it out explicitly rather
+// than leaving it to look like a generic scheduling failure.
+func (v *VolumeTopology) detectZoneConflict(pod *v1.Pod, requirements []v1.NodeSelectorRequirement) {
+	volumeRequirements := scheduling.NewNodeSelectorRequirements(requirements...)
+	podRequirements := scheduling.NewStrictPodRequirements(pod)
+	if !volumeRequirements.Has(v1.LabelTopologyZone) || !podRequirements.Has(v1.LabelTopologyZone) {
+		return
+	}
+	if podRequirements.Get(v1.LabelTopologyZone).Intersection(volumeRequirements.Get(v1.LabelTopologyZone)).Len() == 0 {
+		v.recorder.Publish(VolumeZoneConflictEvent(pod, volumeRequirements.Get(v1.LabelTopologyZone), podRequirements.Get(v1.LabelTopologyZone)))
+	}
+}
+
+// getVolumeOffering returns the offering of the node that the volume's PV is, or was last seen, attached to
+func (v *VolumeTopology) getVolumeOffering(ctx context.Context, pod *v1.Pod, volume v1.Volume, attachments VolumeAttachments) (volumeOffering, bool, error) {
+	pvc, err := volumeutil.GetPersistentVolumeClaim(ctx, v.kubeClient, pod, volume)
+	if err != nil {
+		return volumeOffering{}, false, fmt.Errorf("discovering persistent volume claim, %w", err)
+	}
+	if pvc == nil || pvc.Spec.VolumeName == "" {
+		return volumeOffering{}, false, nil
+	}
+	for _, nodeName := range attachments[pvc.Spec.VolumeName] {
+		node := &v1.Node{}
+		if err := v.kubeClient.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return volumeOffering{}, false, fmt.Errorf("getting node %q, %w", nodeName, err)
+			}
+			continue
+		}
+		offering := volumeOffering{instanceType: node.Labels[v1.LabelInstanceTypeStable], capacityType: node.Labels[karpv1.CapacityTypeLabelKey]}
+		if offering.instanceType != "" && offering.capacityType != "" {
+			v.volumeOfferings.SetDefault(volumeCacheKey(pvc), offering)
+		}
+	}
+	if offering, ok := v.volumeOfferings.Get(volumeCacheKey(pvc)); ok {
+		return offering.(volumeOffering), true, nil
+	}
+	return volumeOffering{}, false, nil
+}
+
 func (v *VolumeTopology) getRequirements(ctx context.Context, pod *v1.Pod, volume v1.Volume) ([]v1.NodeSelectorRequirement, error) {
 	pvc, err := volumeutil.GetPersistentVolumeClaim(ctx, v.kubeClient, pod, volume)
 	if err != nil {
@@ -89,7 +202,7 @@ func (v *VolumeTopology) getRequirements(ctx context.Context, pod *v1.Pod, volum
 
 	// Persistent Volume Requirements
 	if pvc.Spec.VolumeName != "" {
-		requirements, err := v.getPersistentVolumeRequirements(ctx, pod, pvc.Spec.VolumeName)
+		requirements, err := v.getPersistentVolumeRequirements(ctx, pod, pvc)
 		if err != nil {
 			return nil, fmt.Errorf("getting existing requirements, %w", err)
 		}
@@ -121,7 +234,25 @@ func (v *VolumeTopology) getStorageClassRequirements(ctx context.Context, storag
 	return requirements, nil
 }
 
-func (v *VolumeTopology) getPersistentVolumeRequirements(ctx context.Context, pod *v1.Pod, volumeName string) ([]v1.NodeSelectorRequirement, error) {
+func (v *VolumeTopology) getPersistentVolumeRequirements(ctx context.Context, pod *v1.Pod, pvc *v1.PersistentVolumeClaim) ([]v1.NodeSelectorRequirement, error) {
+	if requirements, ok := v.persistentVolumeRequirements.Get(volumeCacheKey(pvc)); ok {
+		return requirements.([]v1.NodeSelectorRequirement), nil
+	}
+	requirements, err := v.resolvePersistentVolumeRequirements(ctx, pod, pvc.Spec.VolumeName)
+	if err != nil {
+		return nil, err
+	}
+	v.persistentVolumeRequirements.SetDefault(volumeCacheKey(pvc), requirements)
+	return requirements, nil
+}
+
+// volumeCacheKey identifies a bound volume by both its claim and its PV so that a PV which is recreated under the
+// same name, and bound to a different claim, doesn't pick up stale cache entries
+func volumeCacheKey(pvc *v1.PersistentVolumeClaim) string {
+	return fmt.Sprintf("%s/%s", pvc.UID, pvc.Spec.VolumeName)
+}
+
+func (v *VolumeTopology) resolvePersistentVolumeRequirements(ctx context.Context, pod *v1.Pod, volumeName string) ([]v1.NodeSelectorRequirement, error) {
 	pv := &v1.PersistentVolume{}
 	if err := v.kubeClient.Get(ctx, types.NamespacedName{Name: volumeName, Namespace: pod.Namespace}, pv); err != nil {
 		return nil, fmt.Errorf("getting persistent volume %q, %w", volumeName, err)
@@ -0,0 +1,154 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"math"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// ZoneLimits tracks how much more capacity each NodePool with zone limits can launch into each zone during a
+// scheduling simulation
+type ZoneLimits struct {
+	limits    map[string]corev1.ResourceList            // (NodePool name) -> limits for each of its zones
+	remaining map[string]map[string]corev1.ResourceList // (NodePool name) -> (zone) -> remaining resources
+	pending   map[string][]zoneCharge                   // (NodePool name) -> capacity that's charged to zones as they're tracked
+}
+
+// zoneCharge is capacity that counts against the limits of every zone that the requirement allows
+type zoneCharge struct {
+	zones    *scheduling.Requirement
+	capacity corev1.ResourceList
+}
+
+func NewZoneLimits(nodePools []*v1.NodePool) *ZoneLimits {
+	limited := lo.Filter(nodePools, func(np *v1.NodePool, _ int) bool { return len(np.Spec.ZoneLimits) > 0 })
+	return &ZoneLimits{
+		limits: lo.SliceToMap(limited, func(np *v1.NodePool) (string, corev1.ResourceList) {
+			return np.Name, corev1.ResourceList(np.Spec.ZoneLimits)
+		}),
+		remaining: lo.SliceToMap(limited, func(np *v1.NodePool) (string, map[string]corev1.ResourceList) {
+			return np.Name, map[string]corev1.ResourceList{}
+		}),
+		pending: map[string][]zoneCharge{},
+	}
+}
+
+// Limited returns true if the NodePool has zone limits
+func (z *ZoneLimits) Limited(nodePool string) bool {
+	_, ok := z.limits[nodePool]
+	return ok
+}
+
+// Subtract counts capacity that the NodePool has launched, or is launching, against the limits of every zone that the
+// requirement allows. Capacity that has launched is in a single zone, but NodeClaims that haven't launched yet could
+// still end up in any of the zones that their requirements allow.
+func (z *ZoneLimits) Subtract(nodePool string, zones *scheduling.Requirement, capacity corev1.ResourceList) {
+	if !z.Limited(nodePool) {
+		return
+	}
+	for zone, remaining := range z.remaining[nodePool] {
+		if zones.Has(zone) {
+			z.remaining[nodePool][zone] = resources.Subtract(remaining, capacity)
+		}
+	}
+	// Zones that aren't tracked yet are charged once they are
+	z.pending[nodePool] = append(z.pending[nodePool], zoneCharge{zones: zones, capacity: capacity})
+}
+
+// Filter returns the instance types that the NodePool can launch into a single zone without breaching its zone limits,
+// along with that zone. Of the zones that the requirements allow and that have room for any of the instance types,
+// the zone with the most headroom left is picked. Pinning each NodeClaim to a zone means that it's only counted against
+// the limits of the zone that it launches into. The instance types are returned as they are, without a zone, if they
+// don't offer any zones that the requirements allow.
+func (z *ZoneLimits) Filter(nodePool string, requirements scheduling.Requirements, instanceTypes []*cloudprovider.InstanceType) ([]*cloudprovider.InstanceType, []string) {
+	zoneRequirement := requirements.Get(corev1.LabelTopologyZone)
+	zones := sets.New[string]()
+	for _, it := range instanceTypes {
+		zones.Insert(lo.Filter(it.Offerings.Available().Zones().UnsortedList(), func(zone string, _ int) bool { return zoneRequirement.Has(zone) })...)
+	}
+	if zones.Len() == 0 {
+		return instanceTypes, nil
+	}
+	var bestZone string
+	var bestFit []*cloudprovider.InstanceType
+	bestHeadroom := -1.0
+	for _, zone := range sets.List(zones) {
+		offered := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool { return it.Offerings.Available().Zones().Has(zone) })
+		fit := filterByRemainingResources(offered, z.remainingIn(nodePool, zone))
+		if len(fit) == 0 {
+			continue
+		}
+		if headroom := z.headroom(nodePool, zone); headroom > bestHeadroom {
+			bestZone, bestFit, bestHeadroom = zone, fit, headroom
+		}
+	}
+	if len(bestFit) == 0 {
+		return nil, nil
+	}
+	return bestFit, []string{bestZone}
+}
+
+// Reserve counts the NodeClaim's largest instance type against the limits of the zones that it could launch into.
+// NodeClaims that were filtered by the zone limits are pinned to a single zone.
+func (z *ZoneLimits) Reserve(n *NodeClaim) {
+	if !z.Limited(n.NodePoolName) || len(n.InstanceTypeOptions) == 0 {
+		return
+	}
+	z.Subtract(n.NodePoolName, n.Requirements.Get(corev1.LabelTopologyZone), maxCapacity(n.InstanceTypeOptions))
+}
+
+// remainingIn returns the resources that the NodePool can still launch into the zone
+func (z *ZoneLimits) remainingIn(nodePool string, zone string) corev1.ResourceList {
+	if _, ok := z.remaining[nodePool][zone]; !ok {
+		remaining := z.limits[nodePool]
+		for _, charge := range z.pending[nodePool] {
+			if charge.zones.Has(zone) {
+				remaining = resources.Subtract(remaining, charge.capacity)
+			}
+		}
+		z.remaining[nodePool][zone] = remaining
+	}
+	return z.remaining[nodePool][zone]
+}
+
+// headroom returns the smallest fraction of any of the NodePool's zone limits that's left in the zone
+func (z *ZoneLimits) headroom(nodePool string, zone string) float64 {
+	remaining := z.remainingIn(nodePool, zone)
+	headroom := 1.0
+	for name, limit := range z.limits[nodePool] {
+		if limit.IsZero() {
+			continue
+		}
+		quantity := remaining[name]
+		headroom = math.Min(headroom, quantity.AsApproximateFloat64()/limit.AsApproximateFloat64())
+	}
+	return headroom
+}
+
+// maxCapacity returns the largest quantity of each resource across the instance types
+func maxCapacity(instanceTypes []*cloudprovider.InstanceType) corev1.ResourceList {
+	return resources.MaxResources(lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) corev1.ResourceList { return it.Capacity })...)
+}
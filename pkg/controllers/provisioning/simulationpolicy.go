@@ -0,0 +1,87 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+)
+
+// applySimulationPolicies applies the mutations of each SimulationPolicy to the pods that it selects. The pods are our
+// own copies of the pods in the cluster, so the mutations are never written back.
+func (p *Provisioner) applySimulationPolicies(ctx context.Context, pods []*corev1.Pod) error {
+	policyList := &v1alpha1.SimulationPolicyList{}
+	if err := p.kubeClient.List(ctx, policyList); err != nil {
+		// The SimulationPolicy CRD is optional, so pods are simulated as they are when it isn't installed
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("listing simulationpolicies, %w", err)
+	}
+	for i := range policyList.Items {
+		policy := &policyList.Items[i]
+		selector := labels.Everything()
+		if policy.Spec.Selector != nil {
+			var err error
+			if selector, err = metav1.LabelSelectorAsSelector(policy.Spec.Selector); err != nil {
+				log.FromContext(ctx).WithValues("SimulationPolicy", klog.KRef("", policy.Name)).Error(err, "ignoring simulationpolicy, invalid selector")
+				continue
+			}
+		}
+		namespaces := sets.New(policy.Spec.Namespaces...)
+		for _, pod := range pods {
+			if namespaces.Len() > 0 && !namespaces.Has(pod.Namespace) {
+				continue
+			}
+			if !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			mutatePod(pod, policy.Spec.Mutations)
+		}
+	}
+	return nil
+}
+
+// mutatePod applies the mutations to the pod. Mutations are idempotent since the same pod may be simulated many times.
+func mutatePod(pod *corev1.Pod, mutations v1alpha1.PodMutations) {
+	if mutations.UseLimitsAsRequests {
+		for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+			for i := range containers {
+				if len(containers[i].Resources.Limits) != 0 {
+					containers[i].Resources.Requests = lo.Assign(containers[i].Resources.Requests, containers[i].Resources.Limits)
+				}
+			}
+		}
+	}
+	for i := range mutations.Tolerations {
+		if !lo.ContainsBy(pod.Spec.Tolerations, func(t corev1.Toleration) bool { return t.MatchToleration(&mutations.Tolerations[i]) }) {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, mutations.Tolerations[i])
+		}
+	}
+}
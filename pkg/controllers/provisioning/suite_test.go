@@ -18,20 +18,30 @@ package provisioning_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
 
 	pscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	nodepoolutils "sigs.k8s.io/karpenter/pkg/utils/nodepool"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	nodev1 "k8s.io/api/node/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
@@ -46,11 +56,14 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
 	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	. "sigs.k8s.io/karpenter/pkg/utils/testing"
 )
 
@@ -575,6 +588,43 @@ var _ = Describe("Provisioning", func() {
 		}, node.Status.Capacity)
 	})
 
+	Context("Scheduler Names", func() {
+		var pod *corev1.Pod
+		BeforeEach(func() {
+			// Non-default schedulers may not use the Unschedulable reason that the kube-scheduler sets
+			pod = test.Pod(test.PodOptions{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Reason: "NotEnoughResources", Status: corev1.ConditionFalse}},
+			})
+			pod.Spec.SchedulerName = "custom-scheduler"
+		})
+		AfterEach(func() {
+			delete(podutils.SchedulerPredicates, "custom-scheduler")
+		})
+		It("should not provision for pods of a scheduler that isn't allowed", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should provision for pods of an allowed scheduler", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{AllowedSchedulerNames: lo.ToPtr("other-scheduler,custom-scheduler")}))
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should use the predicate registered for an allowed scheduler", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{AllowedSchedulerNames: lo.ToPtr("custom-scheduler")}))
+			podutils.SchedulerPredicates["custom-scheduler"] = func(p *corev1.Pod) bool {
+				return p.Annotations["custom-scheduler/pending"] == "true"
+			}
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+
+			pod.Annotations = lo.Assign(pod.Annotations, map[string]string{"custom-scheduler/pending": "true"})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
 	Context("Resource Limits", func() {
 		It("should not schedule when limits are exceeded", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
@@ -591,6 +641,63 @@ var _ = Describe("Provisioning", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
+		It("should record pods deferred by limits against the nodepools at their limits", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20")}),
+				},
+				Status: v1.NodePoolStatus{
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100"),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			ExpectMetricCounterValue(pscheduling.PodsDeferredByLimitsTotal, 1, map[string]string{metrics.NodePoolLabel: nodePool.Name})
+		})
+		It("should not record pods as deferred by limits when they are incompatible with the nodepool", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20")}),
+				},
+				Status: v1.NodePoolStatus{
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100"),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "unknown-zone"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			_, found := FindMetricWithLabelValues("karpenter_pods_deferred_limits_total", map[string]string{metrics.NodePoolLabel: nodePool.Name})
+			Expect(found).To(BeFalse())
+		})
+		It("should not record pods as deferred by limits when another compatible nodepool rejected them for a different reason", func() {
+			limitedNodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20")}),
+				},
+				Status: v1.NodePoolStatus{
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100"),
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, limitedNodePool, test.NodePool())
+			// the pod requires affinity to pods that don't exist, so the nodepool without limits can't launch capacity for it either
+			pod := test.UnschedulablePod(test.PodOptions{PodRequirements: []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nonexistent"}},
+				TopologyKey:   corev1.LabelHostname,
+			}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			_, found := FindMetricWithLabelValues("karpenter_pods_deferred_limits_total", map[string]string{metrics.NodePoolLabel: limitedNodePool.Name})
+			Expect(found).To(BeFalse())
+		})
 		It("should schedule if limits would be met", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
 				Spec: v1.NodePoolSpec{
@@ -718,6 +825,570 @@ var _ = Describe("Provisioning", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 	})
+	Context("Safety Margin", func() {
+		It("should scale up pod requests by the safety margin when packing them onto new nodeclaims", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			cluster.SetSchedulingSafetyMargin(0.2)
+			pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					// fits a 2 CPU node without the margin
+					corev1.ResourceCPU: resource.MustParse("1.75"),
+				},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Status.Capacity.Cpu().Cmp(resource.MustParse("2"))).To(BeNumerically(">", 0))
+		})
+		It("should not scale up pod requests when simulating scheduling outside of provisioning", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			cluster.SetSchedulingSafetyMargin(0.2)
+			pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("1.75"),
+				},
+			}})
+			s, err := prov.NewScheduler(ctx, []*corev1.Pod{pod}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			results := s.Solve(ctx, []*corev1.Pod{pod})
+			Expect(results.NewNodeClaims).To(HaveLen(1))
+			Expect(lo.ContainsBy(results.NewNodeClaims[0].InstanceTypeOptions, func(it *cloudprovider.InstanceType) bool {
+				return it.Capacity.Cpu().Cmp(resource.MustParse("2")) == 0
+			})).To(BeTrue())
+		})
+	})
+	Context("Zone Limits", func() {
+		zonalPod := func(zone string) *corev1.Pod {
+			opts := test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					// requires a 2 CPU node, but leaves room for overhead
+					corev1.ResourceCPU: resource.MustParse("1.75"),
+				},
+			}}
+			if zone != "" {
+				opts.NodeSelector = map[string]string{corev1.LabelTopologyZone: zone}
+			}
+			return test.UnschedulablePod(opts)
+		}
+		var nodePool *v1.NodePool
+		BeforeEach(func() {
+			nodePool = test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					ZoneLimits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}),
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+		})
+		It("should schedule to each zone up to its zone limits", func() {
+			pods := []*corev1.Pod{zonalPod("test-zone-1"), zonalPod("test-zone-2")}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			for _, pod := range pods {
+				ExpectScheduled(ctx, env.Client, pod)
+			}
+		})
+		It("should not schedule to a zone if its zone limits would be exceeded", func() {
+			pod := zonalPod("test-zone-1")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			pod = zonalPod("test-zone-1")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should launch into zones that are below their zone limits", func() {
+			pod := zonalPod("test-zone-1")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			pod = zonalPod("")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[corev1.LabelTopologyZone]).ToNot(Equal("test-zone-1"))
+		})
+		It("should pin nodeclaims to a single zone so that they only count against its zone limits", func() {
+			// prevent these pods from scheduling on the same node
+			podAntiRequirements := []corev1.PodAffinityTerm{{
+				TopologyKey:   corev1.LabelHostname,
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+			}}
+			pods := []*corev1.Pod{zonalPod(""), zonalPod("")}
+			for _, pod := range pods {
+				pod.Labels = lo.Assign(pod.Labels, map[string]string{"app": "foo"})
+				pod.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{RequiredDuringSchedulingIgnoredDuringExecution: podAntiRequirements}}
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodes := lo.Map(pods, func(pod *corev1.Pod, _ int) *corev1.Node { return ExpectScheduled(ctx, env.Client, pod) })
+			Expect(nodes[0].Labels[corev1.LabelTopologyZone]).ToNot(Equal(nodes[1].Labels[corev1.LabelTopologyZone]))
+			for _, nodeClaim := range ExpectNodeClaims(ctx, env.Client) {
+				zones, ok := lo.Find(nodeClaim.Spec.Requirements, func(r v1.NodeSelectorRequirementWithMinValues) bool { return r.Key == corev1.LabelTopologyZone })
+				Expect(ok).To(BeTrue())
+				Expect(zones.Values).To(HaveLen(1))
+			}
+		})
+		It("should count nodeclaims that haven't launched against the zones that their requirements allow", func() {
+			nodeClaim := test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.NodePoolLabelKey: nodePool.Name}},
+				Spec: v1.NodeClaimSpec{
+					Requirements: []v1.NodeSelectorRequirementWithMinValues{
+						{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}},
+						{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"small-instance-type"}}},
+					},
+					Taints: []corev1.Taint{{Key: "example.com/taint", Effect: corev1.TaintEffectNoSchedule}},
+				},
+			})
+			cluster.UpdateNodeClaim(nodeClaim)
+			pods := []*corev1.Pod{zonalPod("test-zone-1"), zonalPod("test-zone-2")}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			ExpectNotScheduled(ctx, env.Client, pods[0])
+			ExpectScheduled(ctx, env.Client, pods[1])
+		})
+	})
+	Context("Template Variables", func() {
+		It("should populate template variables on created NodeClaims", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ClusterName: lo.ToPtr("my-cluster")}))
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						Spec: v1.NodeClaimTemplateSpec{
+							Taints: []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Spec.TemplateVariables).To(HaveKeyWithValue(v1.TemplateVariableClusterName, "my-cluster"))
+			Expect(nodeClaims[0].Spec.TemplateVariables).To(HaveKeyWithValue(v1.TemplateVariableNodePool, nodePool.Name))
+			Expect(nodeClaims[0].Spec.TemplateVariables).To(HaveKeyWithValue(v1.TemplateVariableTaints, "dedicated=gpu:NoSchedule"))
+			Expect(nodeClaims[0].Spec.TemplateVariables[v1.TemplateVariableLabels]).To(ContainSubstring(fmt.Sprintf("%s=%s", v1.NodePoolLabelKey, nodePool.Name)))
+		})
+	})
+	Context("Fleet Limits", func() {
+		var nodePool *v1.NodePool
+		var nodeClaim *v1.NodeClaim
+		BeforeEach(func() {
+			// The existing NodeClaim is tainted so that pods can't schedule to it and need new capacity
+			nodeClaim = test.NodeClaim(v1.NodeClaim{
+				Spec: v1.NodeClaimSpec{
+					Taints: []corev1.Taint{{Key: "example.com/taint", Effect: corev1.TaintEffectNoSchedule}},
+				},
+				Status: v1.NodeClaimStatus{
+					ProviderID: test.RandomProviderID(),
+					Capacity:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+				},
+			})
+			cluster.UpdateNodeClaim(nodeClaim)
+			nodePool = test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+		})
+		It("should not create NodeClaims past the fleet-wide node limit", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxNodes: lo.ToPtr(1)}))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should create NodeClaims under the fleet-wide node limit", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxNodes: lo.ToPtr(2)}))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should not create NodeClaims past the fleet-wide vCPU limit", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxVCPU: lo.ToPtr(4)}))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should only launch instance types that fit under the fleet-wide vCPU limit", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxVCPU: lo.ToPtr(8)}))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			for _, name := range lo.Flatten(lo.FilterMap(nodeClaims[0].Spec.Requirements, func(r v1.NodeSelectorRequirementWithMinValues, _ int) ([]string, bool) {
+				return r.Values, r.Key == corev1.LabelInstanceTypeStable
+			})) {
+				Expect(instanceTypeMap[name].Capacity.Cpu().Value()).To(BeNumerically("<=", 4))
+			}
+		})
+		It("should not create NodeClaims when the fleet-wide vCPU limit leaves fewer instance types than minValues", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxVCPU: lo.ToPtr(8)}))
+			// Instance types with 1 to 5 vCPUs, of which only the 4 smallest fit under the remaining 4 vCPUs
+			cloudProvider.InstanceTypes = fake.InstanceTypes(5)
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpExists},
+					MinValues:               lo.ToPtr(5),
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should create NodeClaims when the fleet-wide vCPU limit leaves enough instance types for minValues", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxVCPU: lo.ToPtr(8)}))
+			cloudProvider.InstanceTypes = fake.InstanceTypes(5)
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpExists},
+					MinValues:               lo.ToPtr(4),
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Stale Instance Types", func() {
+		var nodePool *v1.NodePool
+		BeforeEach(func() {
+			nodePool = test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+		})
+		It("should provision from stale instance types within the maximum staleness", func() {
+			cloudProvider.ErrorsForNodePool[nodePool.Name] = cloudprovider.NewStaleInstanceTypesError(fmt.Errorf("throttled"), fakeClock.Now().Add(-5*time.Minute))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			ExpectMetricGaugeValue(pscheduling.InstanceTypesStalenessSeconds, (5 * time.Minute).Seconds(), map[string]string{metrics.NodePoolLabel: nodePool.Name})
+		})
+		It("should not provision from instance types older than the maximum staleness", func() {
+			cloudProvider.ErrorsForNodePool[nodePool.Name] = cloudprovider.NewStaleInstanceTypesError(fmt.Errorf("throttled"), fakeClock.Now().Add(-time.Hour))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			ExpectMetricGaugeValue(pscheduling.InstanceTypesStalenessSeconds, time.Hour.Seconds(), map[string]string{metrics.NodePoolLabel: nodePool.Name})
+		})
+		It("should not provision from stale instance types when the maximum staleness is zero", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxInstanceTypeStaleness: lo.ToPtr(time.Duration(0))}))
+			cloudProvider.ErrorsForNodePool[nodePool.Name] = cloudprovider.NewStaleInstanceTypesError(fmt.Errorf("throttled"), fakeClock.Now().Add(-time.Second))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not provision from NodePools whose instance types failed to resolve", func() {
+			cloudProvider.ErrorsForNodePool[nodePool.Name] = fmt.Errorf("throttled")
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should stop reporting staleness once instance types are resolved", func() {
+			cloudProvider.ErrorsForNodePool[nodePool.Name] = cloudprovider.NewStaleInstanceTypesError(fmt.Errorf("throttled"), fakeClock.Now().Add(-5*time.Minute))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, test.UnschedulablePod())
+			delete(cloudProvider.ErrorsForNodePool, nodePool.Name)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, test.UnschedulablePod())
+			_, ok := FindMetricWithLabelValues("karpenter_scheduler_instance_types_staleness_seconds", map[string]string{metrics.NodePoolLabel: nodePool.Name})
+			Expect(ok).To(BeFalse())
+		})
+	})
+	Context("Preemption Simulation", func() {
+		var nodePool *v1.NodePool
+		var node *corev1.Node
+		var highPriority, lowPriority *schedulingv1.PriorityClass
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PreemptionSimulation: lo.ToPtr(true)}))
+			highPriority = &schedulingv1.PriorityClass{ObjectMeta: test.ObjectMeta(), Value: 1000}
+			lowPriority = &schedulingv1.PriorityClass{ObjectMeta: test.ObjectMeta(), Value: 10}
+			nodePool = test.NodePool()
+			node = test.Node(test.NodeOptions{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:  resource.MustParse("4"),
+					corev1.ResourcePods: resource.MustParse("10"),
+				},
+			})
+			ExpectApplied(ctx, env.Client, highPriority, lowPriority, nodePool, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		})
+		AfterEach(func() {
+			ExpectDeleted(ctx, env.Client, highPriority, lowPriority)
+		})
+		// priorityPod returns a pod in the priority class, with the priority that admission would have resolved for it
+		priorityPod := func(priorityClass *schedulingv1.PriorityClass, cpu string) *corev1.Pod {
+			pod := test.UnschedulablePod(test.PodOptions{
+				PriorityClassName:    priorityClass.Name,
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+			})
+			pod.Spec.Priority = lo.ToPtr(priorityClass.Value)
+			return pod
+		}
+		It("should not provision for pods that can preempt lower priority pods on an existing node", func() {
+			scheduled := priorityPod(lowPriority, "3")
+			ExpectApplied(ctx, env.Client, scheduled)
+			ExpectManualBinding(ctx, env.Client, scheduled, node)
+
+			pod := priorityPod(highPriority, "2")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+		})
+		It("should provision for the pods of a batch that don't fit alongside the other preemptors", func() {
+			scheduled := priorityPod(lowPriority, "3")
+			ExpectApplied(ctx, env.Client, scheduled)
+			ExpectManualBinding(ctx, env.Client, scheduled, node)
+
+			// each of the pods could preempt on the node by itself, but only one of them fits on it
+			pods := []*corev1.Pod{priorityPod(highPriority, "3"), priorityPod(highPriority, "3")}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(lo.CountBy(pods, func(pod *corev1.Pod) bool {
+				return ExpectPodExists(ctx, env.Client, pod.Name, pod.Namespace).Spec.NodeName != ""
+			})).To(Equal(1))
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should provision for pods that can preempt when preemption simulation is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			scheduled := priorityPod(lowPriority, "3")
+			ExpectApplied(ctx, env.Client, scheduled)
+			ExpectManualBinding(ctx, env.Client, scheduled, node)
+
+			pod := priorityPod(highPriority, "2")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should provision for pods that don't fit after preempting every lower priority pod", func() {
+			kept := priorityPod(highPriority, "2")
+			scheduled := priorityPod(lowPriority, "1")
+			ExpectApplied(ctx, env.Client, kept, scheduled)
+			ExpectManualBinding(ctx, env.Client, kept, node)
+			ExpectManualBinding(ctx, env.Client, scheduled, node)
+
+			pod := priorityPod(highPriority, "3")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should provision for pods that can only preempt pods of the same priority", func() {
+			scheduled := priorityPod(highPriority, "3")
+			ExpectApplied(ctx, env.Client, scheduled)
+			ExpectManualBinding(ctx, env.Client, scheduled, node)
+
+			pod := priorityPod(highPriority, "2")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should provision for pods that never preempt", func() {
+			scheduled := priorityPod(lowPriority, "3")
+			ExpectApplied(ctx, env.Client, scheduled)
+			ExpectManualBinding(ctx, env.Client, scheduled, node)
+
+			pod := priorityPod(highPriority, "2")
+			pod.Spec.PreemptionPolicy = lo.ToPtr(corev1.PreemptNever)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should provision for pods that can't tolerate the taints of the node they could preempt on", func() {
+			node.Spec.Taints = []corev1.Taint{{Key: "example.com/taint", Effect: corev1.TaintEffectNoSchedule}}
+			ExpectApplied(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			scheduled := priorityPod(lowPriority, "3")
+			ExpectApplied(ctx, env.Client, scheduled)
+			ExpectManualBinding(ctx, env.Client, scheduled, node)
+
+			pod := priorityPod(highPriority, "2")
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Provenance", func() {
+		It("should record the provenance of the NodeClaim in its status", func() {
+			ctx = injection.WithVersion(ctx, "v1.0.0-test")
+			nodePool := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			provenance := nodeClaims[0].Status.Provenance
+			Expect(provenance).ToNot(BeNil())
+			Expect(provenance.ControllerVersion).To(Equal("v1.0.0-test"))
+			Expect(provenance.NodePoolGeneration).To(Equal(nodePool.Generation))
+			Expect(provenance.NodePoolHash).To(Equal(nodePool.Hash()))
+			Expect(provenance.RequesterPodsDigest).To(HaveLen(64))
+			Expect(provenance.Digest).To(HaveLen(64))
+		})
+		It("should record the NodePool hash that's stamped on the NodeClaim when a drift hasher is registered", func() {
+			nodepoolutils.RegisterDriftHasher(labelDriftHasher("cost-center"))
+			DeferCleanup(nodepoolutils.UnregisterDriftHasher, labelDriftHasher("cost-center").Name())
+			nodePool := test.NodePool(v1.NodePool{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"cost-center": "1234"}}})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Status.Provenance.NodePoolHash).To(Equal(nodeClaims[0].Annotations[v1.NodePoolHashAnnotationKey]))
+			Expect(nodeClaims[0].Status.Provenance.NodePoolHash).ToNot(Equal(nodePool.Hash()))
+		})
+		It("should record different digests for NodeClaims requested by different pods", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := []*corev1.Pod{
+				test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")}}}),
+				test.UnschedulablePod(test.PodOptions{ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10")}}}),
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(2))
+			Expect(nodeClaims[0].Status.Provenance.RequesterPodsDigest).ToNot(Equal(nodeClaims[1].Status.Provenance.RequesterPodsDigest))
+			Expect(nodeClaims[0].Status.Provenance.Digest).ToNot(Equal(nodeClaims[1].Status.Provenance.Digest))
+		})
+		It("should publish the provenance to the provenance sink", func() {
+			received := make(chan map[string]any, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				body := map[string]any{}
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				received <- body
+			}))
+			defer server.Close()
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ProvenanceSinkURL: lo.ToPtr(server.URL)}))
+
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+
+			var body map[string]any
+			Eventually(received).Should(Receive(&body))
+			Expect(body).To(HaveKeyWithValue("nodeClaim", nodeClaims[0].Name))
+			Expect(body).To(HaveKeyWithValue("uid", string(nodeClaims[0].UID)))
+			Expect(body["provenance"]).To(HaveKeyWithValue("digest", nodeClaims[0].Status.Provenance.Digest))
+		})
+		It("should create the NodeClaim when the provenance sink is unavailable", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ProvenanceSinkURL: lo.ToPtr(server.URL)}))
+
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Status.Provenance).ToNot(BeNil())
+		})
+		It("should not wait for the provenance sink to respond before binding pods", func() {
+			done := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+				<-done
+			}))
+			defer server.Close()
+			defer close(done)
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ProvenanceSinkURL: lo.ToPtr(server.URL)}))
+
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+	})
+	Context("Terminating Owners", func() {
+		var nodePool *v1.NodePool
+		var job *batchv1.Job
+		BeforeEach(func() {
+			nodePool = test.NodePool()
+			job = &batchv1.Job{
+				ObjectMeta: test.NamespacedObjectMeta(),
+				Spec: batchv1.JobSpec{
+					TTLSecondsAfterFinished: lo.ToPtr[int32](300),
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers:    []corev1.Container{{Name: "job", Image: "job"}},
+						},
+					},
+				},
+			}
+		})
+		AfterEach(func() {
+			ExpectFinalizersRemoved(ctx, env.Client, job)
+			ExpectDeleted(ctx, env.Client, job)
+		})
+		jobPod := func() *corev1.Pod {
+			return test.UnschedulablePod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: batchv1.SchemeGroupVersion.String(),
+						Kind:       "Job",
+						Name:       job.Name,
+						UID:        job.UID,
+						Controller: lo.ToPtr(true),
+					}},
+				},
+			})
+		}
+		// finish marks the job as completed at the given time
+		finish := func(at time.Time) {
+			job.Status = batchv1.JobStatus{
+				StartTime:      &metav1.Time{Time: at.Add(-time.Minute)},
+				CompletionTime: &metav1.Time{Time: at},
+				Succeeded:      1,
+				Conditions: []batchv1.JobCondition{{
+					Type:               batchv1.JobComplete,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: at},
+				}},
+			}
+			ExpectApplied(ctx, env.Client, job)
+		}
+		It("should not provision for pods in a terminating namespace", func() {
+			namespace := test.Namespace()
+			ExpectApplied(ctx, env.Client, nodePool, namespace)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name}})
+			ExpectApplied(ctx, env.Client, pod)
+			ExpectDeletionTimestampSet(ctx, env.Client, namespace)
+
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+		})
+		It("should not provision for pods owned by a terminating job", func() {
+			ExpectApplied(ctx, env.Client, nodePool, job)
+			pod := jobPod()
+			ExpectApplied(ctx, env.Client, pod)
+			ExpectDeletionTimestampSet(ctx, env.Client, job)
+
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+		})
+		It("should not provision for pods owned by a finished job past its ttl", func() {
+			ExpectApplied(ctx, env.Client, nodePool, job)
+			finish(fakeClock.Now().Add(-10 * time.Minute))
+
+			pod := jobPod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+		})
+		It("should provision for pods owned by a finished job within its ttl", func() {
+			ExpectApplied(ctx, env.Client, nodePool, job)
+			finish(fakeClock.Now().Add(-time.Minute))
+
+			pod := jobPod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+		It("should provision for pods owned by a running job", func() {
+			ExpectApplied(ctx, env.Client, nodePool, job)
+
+			pod := jobPod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
 	Context("Daemonsets", func() {
 		It("should account for daemonsets", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
@@ -780,19 +1451,135 @@ var _ = Describe("Provisioning", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
-		It("should account for overhead using daemonset pod spec instead of daemonset spec", func() {
+		It("should account for the runtime class overhead of daemonsets", func() {
+			runtimeClass := &nodev1.RuntimeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "kata"},
+				Handler:    "kata",
+				Overhead: &nodev1.Overhead{
+					PodFixed: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m"), corev1.ResourceMemory: resource.MustParse("1536Mi")},
+				},
+			}
+			daemonSet := test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("512Mi")}},
+				}},
+			)
+			// The DaemonSet's pod template never goes through the RuntimeClass admission controller, so its overhead is unset
+			daemonSet.Spec.Template.Spec.RuntimeClassName = lo.ToPtr(runtimeClass.Name)
+			ExpectApplied(ctx, env.Client, test.NodePool(), runtimeClass, daemonSet)
+			pod := test.UnschedulablePod(
+				test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("512Mi")}},
+				},
+			)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+
+			// Without the overhead, both pods would fit on the 2Gi instance type
+			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
+			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
+			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+		})
+		It("should account for overhead using daemonset pod spec instead of daemonset spec", func() {
+			nodePool := test.NodePool()
+			// Create a daemonset with large resource requests
+			daemonset := test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")}},
+				}},
+			)
+			ExpectApplied(ctx, env.Client, nodePool, daemonset)
+			// Create the actual daemonSet pod with lower resource requests and expect to use the pod
+			daemonsetPod := test.UnschedulablePod(
+				test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "DaemonSet",
+								Name:               daemonset.Name,
+								UID:                daemonset.UID,
+								Controller:         lo.ToPtr(true),
+								BlockOwnerDeletion: lo.ToPtr(true),
+							},
+						},
+					},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
+				})
+			ExpectApplied(ctx, env.Client, nodePool, daemonsetPod)
+			ExpectReconcileSucceeded(ctx, daemonsetController, client.ObjectKeyFromObject(daemonset))
+			pod := test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+				NodeSelector:         map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+
+			// We expect a smaller instance since the daemonset pod is smaller then daemonset spec
+			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
+			// If we launch with 2Gi, this means the Daemon pod was not respected
+			// If we launch with 4Gi, this means the Daemon pod was respected
+			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
+			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
+			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+		})
+		It("should account for overhead using daemonset spec when the daemonset pod is from an older revision", func() {
+			nodePool := test.NodePool()
+			// Create a daemonset whose updated template has large resource requests
+			daemonset := test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10000"), corev1.ResourceMemory: resource.MustParse("10000Gi")}},
+				}},
+			)
+			ExpectApplied(ctx, env.Client, nodePool, daemonset)
+			ExpectApplied(ctx, env.Client, daemonSetRevision(daemonset, "old", 1), daemonSetRevision(daemonset, "new", 2))
+			// Create a daemonset pod from the previous revision with lower resource requests
+			daemonsetPod := test.UnschedulablePod(
+				test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							appsv1.DefaultDaemonSetUniqueLabelKey: "old",
+						},
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "DaemonSet",
+								Name:               daemonset.Name,
+								UID:                daemonset.UID,
+								Controller:         lo.ToPtr(true),
+								BlockOwnerDeletion: lo.ToPtr(true),
+							},
+						},
+					},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+				})
+			daemonsetPod.Spec.Containers[0].Name = daemonset.Spec.Template.Spec.Containers[0].Name
+			ExpectApplied(ctx, env.Client, nodePool, daemonsetPod)
+			ExpectReconcileSucceeded(ctx, daemonsetController, client.ObjectKeyFromObject(daemonset))
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			// The rollout will replace the daemonset pod with one that can't fit on any instance type
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should account for overhead using the daemonset pod when it's from the current revision", func() {
 			nodePool := test.NodePool()
-			// Create a daemonset with large resource requests
 			daemonset := test.DaemonSet(
 				test.DaemonSetOptions{PodOptions: test.PodOptions{
-					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")}},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10000"), corev1.ResourceMemory: resource.MustParse("10000Gi")}},
 				}},
 			)
 			ExpectApplied(ctx, env.Client, nodePool, daemonset)
-			// Create the actual daemonSet pod with lower resource requests and expect to use the pod
+			ExpectApplied(ctx, env.Client, daemonSetRevision(daemonset, "old", 1), daemonSetRevision(daemonset, "new", 2))
+			// The pod-template-generation label lags behind the generation, but the pod is from the current revision
 			daemonsetPod := test.UnschedulablePod(
 				test.PodOptions{
 					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							appsv1.DefaultDaemonSetUniqueLabelKey:            "new",
+							extensionsv1beta1.DaemonSetTemplateGenerationKey: fmt.Sprint(daemonset.Generation - 1),
+						},
 						OwnerReferences: []metav1.OwnerReference{
 							{
 								APIVersion:         "apps/v1",
@@ -804,24 +1591,16 @@ var _ = Describe("Provisioning", func() {
 							},
 						},
 					},
-					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
 				})
+			daemonsetPod.Spec.Containers[0].Name = daemonset.Spec.Template.Spec.Containers[0].Name
 			ExpectApplied(ctx, env.Client, nodePool, daemonsetPod)
 			ExpectReconcileSucceeded(ctx, daemonsetController, client.ObjectKeyFromObject(daemonset))
 			pod := test.UnschedulablePod(test.PodOptions{
-				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
-				NodeSelector:         map[string]string{v1.NodePoolLabelKey: nodePool.Name},
+				NodeSelector: map[string]string{v1.NodePoolLabelKey: nodePool.Name},
 			})
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
-			node := ExpectScheduled(ctx, env.Client, pod)
-
-			// We expect a smaller instance since the daemonset pod is smaller then daemonset spec
-			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
-			// If we launch with 2Gi, this means the Daemon pod was not respected
-			// If we launch with 4Gi, this means the Daemon pod was respected
-			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
-			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
-			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+			ExpectScheduled(ctx, env.Client, pod)
 		})
 		It("should not schedule if resource requests are not defined and limits (requests) are too large", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
@@ -1102,104 +1881,555 @@ var _ = Describe("Provisioning", func() {
 					},
 				},
 			}
-			ExpectApplied(ctx, env.Client, daemonSet, nodePool)
-			pod := test.UnschedulablePod(
-				test.PodOptions{
-					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+			ExpectApplied(ctx, env.Client, daemonSet, nodePool)
+			pod := test.UnschedulablePod(
+				test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+				},
+			)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+
+			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
+			// If we launch with 2Gi, this means the Daemon pod was not respected
+			// If we launch with 4Gi, this means the Daemon pod was respected
+			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
+			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
+			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+		})
+		It("should consider a daemonset schedulable with an incompatible node affinity preference", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{
+					NodePreferences: []corev1.NodeSelectorRequirement{
+						{
+							Key:      "node",
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"invalid"},
+						},
+					},
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
+				}},
+			))
+			pod := test.UnschedulablePod(
+				test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+				},
+			)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+
+			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
+			// If we launch with 2Gi, this means the Daemon pod was not respected
+			// If we launch with 4Gi, this means the Daemon pod was respected
+			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
+			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
+			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+		})
+		It("should consider a daemonset schedulable with a PreferNoSchedule taint", func() {
+			ExpectApplied(ctx, env.Client,
+				test.NodePool(v1.NodePool{
+					Spec: v1.NodePoolSpec{
+						Template: v1.NodeClaimTemplate{
+							Spec: v1.NodeClaimTemplateSpec{
+								Taints: []corev1.Taint{
+									{
+										Key:    "test",
+										Effect: corev1.TaintEffectPreferNoSchedule,
+									},
+								},
+							},
+						},
+					},
+				}),
+				test.DaemonSet(
+					test.DaemonSetOptions{PodOptions: test.PodOptions{
+						ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
+					}},
+				),
+			)
+			pod := test.UnschedulablePod(
+				test.PodOptions{
+					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+				},
+			)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+
+			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
+			// If we launch with 2Gi, this means the Daemon pod was not respected
+			// If we launch with 4Gi, this means the Daemon pod was respected
+			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
+			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
+			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+		})
+	})
+	Context("Annotations", func() {
+		It("should annotate nodes", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						ObjectMeta: v1.ObjectMeta{
+							Annotations: map[string]string{v1.DoNotDisruptAnnotationKey: "true"},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Annotations).To(HaveKeyWithValue(v1.DoNotDisruptAnnotationKey, "true"))
+		})
+	})
+	Context("Capacity Fallback", func() {
+		var nodePool *v1.NodePool
+		BeforeEach(func() {
+			nodePool = test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					CapacityFallback: &v1.CapacityFallback{
+						LastResortRequirements: []v1.NodeSelectorRequirementWithMinValues{{
+							NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeOnDemand}},
+						}},
+					},
+				},
+			})
+		})
+		It("should hint price-optimized allocation when the nodepool hasn't fallen back", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1.AllocationStrategyAnnotationKey, v1.AllocationStrategyPriceOptimized))
+			Expect(nodeClaims[0].Annotations).ToNot(HaveKey(v1.CapacityFallbackAnnotationKey))
+		})
+		It("should hint capacity-optimized allocation when the nodepool has fallen back", func() {
+			nodePool.Status.CapacityFallback = &v1.CapacityFallbackStatus{
+				Stage:                        v1.CapacityFallbackStageCapacityOptimized,
+				LastInsufficientCapacityTime: metav1.NewTime(fakeClock.Now()),
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1.AllocationStrategyAnnotationKey, v1.AllocationStrategyCapacityOptimized))
+		})
+		It("should launch from the last resort requirements when the nodepool has fallen back to them", func() {
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeSpot}},
+			}}
+			nodePool.Status.CapacityFallback = &v1.CapacityFallbackStatus{
+				Stage:                        v1.CapacityFallbackStageLastResort,
+				LastInsufficientCapacityTime: metav1.NewTime(fakeClock.Now()),
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeOnDemand))
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1.CapacityFallbackAnnotationKey, string(v1.CapacityFallbackStageLastResort)))
+		})
+		It("should launch from the template's requirements once the fallback lapses", func() {
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeSpot}},
+			}}
+			nodePool.Status.CapacityFallback = &v1.CapacityFallbackStatus{
+				Stage:                        v1.CapacityFallbackStageLastResort,
+				LastInsufficientCapacityTime: metav1.NewTime(fakeClock.Now().Add(-time.Hour)),
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeSpot))
+		})
+	})
+	Context("Burstable CPU", func() {
+		instanceType := func(name string, cpu string, baselineCPU *resource.Quantity, price float64) *cloudprovider.InstanceType {
+			return fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:        name,
+				Resources:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				BaselineCPU: baselineCPU,
+				Offerings: []cloudprovider.Offering{{
+					Requirements: scheduling.NewLabelRequirements(map[string]string{
+						v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+						corev1.LabelTopologyZone: "test-zone-1",
+					}),
+					Price:     price,
+					Available: true,
+				}},
+			})
+		}
+		var nodePool *v1.NodePool
+		var pod *corev1.Pod
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				instanceType("burstable", "4", lo.ToPtr(resource.MustParse("1")), 1),
+				instanceType("fixed", "2", nil, 2),
+			}
+			nodePool = test.NodePool()
+			pod = test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m")}},
+			})
+		})
+		launchedInstanceTypes := func() []string {
+			GinkgoHelper()
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			req, ok := lo.Find(nodeClaims[0].Spec.Requirements, func(r v1.NodeSelectorRequirementWithMinValues) bool {
+				return r.Key == corev1.LabelInstanceTypeStable
+			})
+			Expect(ok).To(BeTrue())
+			return req.Values
+		}
+		It("should pack pods against the peak CPU of burstable instance types by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(launchedInstanceTypes()).To(ConsistOf("burstable", "fixed"))
+		})
+		It("should pack pods against the baseline CPU of burstable instance types", func() {
+			nodePool.Spec.BurstableCPU = v1.BurstableCPUPackingBaseline
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(launchedInstanceTypes()).To(ConsistOf("fixed"))
+		})
+		It("should launch burstable instance types that can sustain the pod's requests with baseline packing", func() {
+			nodePool.Spec.BurstableCPU = v1.BurstableCPUPackingBaseline
+			pod.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("500m")
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(launchedInstanceTypes()).To(ConsistOf("burstable", "fixed"))
+		})
+		DescribeTable("should pack pods onto existing burstable nodes against the same CPU as new nodeclaims",
+			func(packing v1.BurstableCPUPacking, existing bool) {
+				nodePool.Spec.BurstableCPU = packing
+				node := test.Node(test.NodeOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+						v1.NodePoolLabelKey:            nodePool.Name,
+						corev1.LabelInstanceTypeStable: "burstable",
+					}},
+					Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+					Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")},
+				})
+				ExpectApplied(ctx, env.Client, nodePool, node)
+				ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduled := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduled.Name == node.Name).To(Equal(existing))
+			},
+			Entry("with peak packing", v1.BurstableCPUPackingPeak, true),
+			Entry("with baseline packing", v1.BurstableCPUPackingBaseline, false),
+		)
+		It("should support selecting against burstable instance types", func() {
+			pod.Spec.NodeSelector = map[string]string{v1.CPUBurstableLabelKey: "false"}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.CPUBurstableLabelKey, "false"))
+			Expect(launchedInstanceTypes()).To(ConsistOf("fixed"))
+		})
+	})
+	Context("Spot Diversification", func() {
+		spotInstanceType := func(name, zone string, price float64) *cloudprovider.InstanceType {
+			return fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: name,
+				Offerings: []cloudprovider.Offering{{
+					Requirements: scheduling.NewLabelRequirements(map[string]string{
+						v1.CapacityTypeLabelKey:  v1.CapacityTypeSpot,
+						corev1.LabelTopologyZone: zone,
+					}),
+					Price:     price,
+					Available: true,
+				}},
+			})
+		}
+		var nodePool *v1.NodePool
+		BeforeEach(func() {
+			maxInstanceTypes := pscheduling.MaxInstanceTypes
+			pscheduling.MaxInstanceTypes = 2
+			DeferCleanup(func() { pscheduling.MaxInstanceTypes = maxInstanceTypes })
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				spotInstanceType("cheapest", "test-zone-1", 1),
+				spotInstanceType("cheaper", "test-zone-1", 2),
+				spotInstanceType("expensive", "test-zone-2", 3),
+			}
+			nodePool = test.NodePool()
+		})
+		launchedInstanceTypes := func() []string {
+			GinkgoHelper()
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			req, ok := lo.Find(nodeClaims[0].Spec.Requirements, func(r v1.NodeSelectorRequirementWithMinValues) bool {
+				return r.Key == corev1.LabelInstanceTypeStable
+			})
+			Expect(ok).To(BeTrue())
+			return req.Values
+		}
+		It("should launch with the cheapest instance types without a floor", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(launchedInstanceTypes()).To(ConsistOf("cheapest", "cheaper"))
+		})
+		It("should launch with the cheapest instance types that span the minimum zones", func() {
+			nodePool.Spec.SpotDiversification = &v1.SpotDiversification{MinZones: 2}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(launchedInstanceTypes()).To(ConsistOf("cheapest", "expensive"))
+		})
+		It("should emit an event when the spot offerings are below the floor", func() {
+			recorder := test.NewEventRecorder()
+			prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+			nodePool.Spec.SpotDiversification = &v1.SpotDiversification{MinInstanceTypes: 2, MinZones: 3}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(recorder.Calls("SpotDiversificationBelowFloor")).To(Equal(1))
+			Expect(recorder.DetectedEvent("Launching with spot offerings across 2 zones, below the minimum of 3")).To(BeTrue())
+		})
+		It("should not emit an event for NodeClaims that can't launch spot capacity", func() {
+			recorder := test.NewEventRecorder()
+			prov := provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock)
+			cloudProvider.InstanceTypes = fake.InstanceTypesAssorted()
+			nodePool.Spec.SpotDiversification = &v1.SpotDiversification{MinZones: 5}
+			nodePool.Spec.Template.Spec.Requirements = []v1.NodeSelectorRequirementWithMinValues{{
+				NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeOnDemand}},
+			}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(recorder.Calls("SpotDiversificationBelowFloor")).To(Equal(0))
+		})
+	})
+	Context("Spot Allocation Strategy", func() {
+		spotInstanceType := func(name string, price float64) *cloudprovider.InstanceType {
+			return fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: name,
+				Offerings: []cloudprovider.Offering{{
+					Requirements: scheduling.NewLabelRequirements(map[string]string{
+						v1.CapacityTypeLabelKey:  v1.CapacityTypeSpot,
+						corev1.LabelTopologyZone: "test-zone-1",
+					}),
+					Price:     price,
+					Available: true,
+				}},
+			})
+		}
+		var nodePool *v1.NodePool
+		BeforeEach(func() {
+			maxInstanceTypes := pscheduling.MaxInstanceTypes
+			pscheduling.MaxInstanceTypes = 1
+			DeferCleanup(func() { pscheduling.MaxInstanceTypes = maxInstanceTypes })
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				spotInstanceType("volatile", 1),
+				spotInstanceType("stable", 1.2),
+			}
+			cloudProvider.InterruptionRates = map[string]map[string]float64{
+				"volatile": {"test-zone-1": 0.5},
+				"stable":   {"test-zone-1": 0.05},
+			}
+			nodePool = test.NodePool()
+		})
+		It("should launch the cheapest spot instance types by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "volatile"))
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Annotations).ToNot(HaveKey(v1.AllocationStrategyAnnotationKey))
+		})
+		It("should launch spot instance types that are less likely to be interrupted with price-capacity-optimized allocation", func() {
+			nodePool.Spec.SpotAllocationStrategy = v1.SpotAllocationStrategyPriceCapacityOptimized
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "stable"))
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1.AllocationStrategyAnnotationKey, v1.AllocationStrategyPriceCapacityOptimized))
+		})
+		It("should prefer cheaper spot instance types when the difference in interruption rates doesn't outweigh the price", func() {
+			nodePool.Spec.SpotAllocationStrategy = v1.SpotAllocationStrategyPriceCapacityOptimized
+			cloudProvider.InterruptionRates["volatile"]["test-zone-1"] = 0.1
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "volatile"))
+		})
+		It("should launch the cheapest spot instance types if the interruption rates can't be retrieved", func() {
+			nodePool.Spec.SpotAllocationStrategy = v1.SpotAllocationStrategyPriceCapacityOptimized
+			cloudProvider.NextInterruptionRatesErr = fmt.Errorf("rates unavailable")
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "volatile"))
+		})
+	})
+	Context("Parallel Scheduling", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SchedulingWorkers: lo.ToPtr(4)}))
+		})
+		It("should schedule independent pods across workers", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pods := test.UnschedulablePods(test.PodOptions{}, 10)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			for _, pod := range pods {
+				ExpectScheduled(ctx, env.Client, pod)
+			}
+		})
+		It("should respect topology spread across pods scheduled in parallel", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			labels := map[string]string{"app": "spread"}
+			pods := test.UnschedulablePods(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+					MaxSkew:           1,
+					TopologyKey:       corev1.LabelTopologyZone,
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				}},
+			}, 3)
+			pods = append(pods, test.UnschedulablePods(test.PodOptions{}, 3)...)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			zones := sets.New[string]()
+			for _, pod := range pods[:3] {
+				zones.Insert(ExpectScheduled(ctx, env.Client, pod).Labels[corev1.LabelTopologyZone])
+			}
+			Expect(zones).To(HaveLen(3))
+		})
+		It("should respect nodepool limits when scheduling in parallel", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}),
 				},
-			)
-			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
-			node := ExpectScheduled(ctx, env.Client, pod)
-
-			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
-			// If we launch with 2Gi, this means the Daemon pod was not respected
-			// If we launch with 4Gi, this means the Daemon pod was respected
-			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
-			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
-			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+			}))
+			pods := test.UnschedulablePods(test.PodOptions{
+				ResourceRequirements: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1.5")},
+				},
+			}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
 		})
-		It("should consider a daemonset schedulable with an incompatible node affinity preference", func() {
-			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
-				test.DaemonSetOptions{PodOptions: test.PodOptions{
-					NodePreferences: []corev1.NodeSelectorRequirement{
-						{
-							Key:      "node",
-							Operator: corev1.NodeSelectorOpIn,
-							Values:   []string{"invalid"},
+	})
+	Context("Rejections", func() {
+		It("should record the reason that a nodepool rejected a pod", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						Spec: v1.NodeClaimTemplateSpec{
+							Taints: []corev1.Taint{{Key: "test-key", Value: "test-value", Effect: corev1.TaintEffectNoSchedule}},
 						},
 					},
-					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
-				}},
-			))
-			pod := test.UnschedulablePod(
-				test.PodOptions{
-					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
 				},
-			)
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
-			node := ExpectScheduled(ctx, env.Client, pod)
-
-			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
-			// If we launch with 2Gi, this means the Daemon pod was not respected
-			// If we launch with 4Gi, this means the Daemon pod was respected
-			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
-			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
-			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+			ExpectNotScheduled(ctx, env.Client, pod)
+			ExpectMetricCounterValue(pscheduling.PodsRejectedTotal, 1, map[string]string{metrics.NodePoolLabel: nodePool.Name, metrics.ReasonLabel: "taints"})
 		})
-		It("should consider a daemonset schedulable with a PreferNoSchedule taint", func() {
-			ExpectApplied(ctx, env.Client,
-				test.NodePool(v1.NodePool{
-					Spec: v1.NodePoolSpec{
-						Template: v1.NodeClaimTemplate{
-							Spec: v1.NodeClaimTemplateSpec{
-								Taints: []corev1.Taint{
-									{
-										Key:    "test",
-										Effect: corev1.TaintEffectPreferNoSchedule,
-									},
+		It("should not record rejections by nodepools that pods were scheduled past", func() {
+			rejecting := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Weight: lo.ToPtr(int32(100)),
+					Template: v1.NodeClaimTemplate{
+						Spec: v1.NodeClaimTemplateSpec{
+							Requirements: []v1.NodeSelectorRequirementWithMinValues{{
+								NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+									Key:      corev1.LabelTopologyZone,
+									Operator: corev1.NodeSelectorOpIn,
+									Values:   []string{"test-zone-1"},
 								},
-							},
+							}},
 						},
 					},
-				}),
-				test.DaemonSet(
-					test.DaemonSetOptions{PodOptions: test.PodOptions{
-						ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")}},
-					}},
-				),
-			)
-			pod := test.UnschedulablePod(
-				test.PodOptions{
-					ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
 				},
-			)
+			})
+			ExpectApplied(ctx, env.Client, rejecting, test.NodePool())
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{corev1.LabelTopologyZone: "test-zone-2"}})
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
-			node := ExpectScheduled(ctx, env.Client, pod)
-
-			// We have multiple instance types that we can launch, one with 2Gi and one with 4Gi
-			// If we launch with 2Gi, this means the Daemon pod was not respected
-			// If we launch with 4Gi, this means the Daemon pod was respected
-			allocatable := instanceTypeMap[node.Labels[corev1.LabelInstanceTypeStable]].Capacity
-			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
-			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+			ExpectScheduled(ctx, env.Client, pod)
+			_, found := FindMetricWithLabelValues("karpenter_pods_rejected_total", map[string]string{metrics.NodePoolLabel: rejecting.Name})
+			Expect(found).To(BeFalse())
 		})
-	})
-	Context("Annotations", func() {
-		It("should annotate nodes", func() {
+		It("should record nodepools at their limits as rejecting the pod", func() {
 			nodePool := test.NodePool(v1.NodePool{
 				Spec: v1.NodePoolSpec{
-					Template: v1.NodeClaimTemplate{
-						ObjectMeta: v1.ObjectMeta{
-							Annotations: map[string]string{v1.DoNotDisruptAnnotationKey: "true"},
-						},
+					Limits: v1.Limits(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20")}),
+				},
+				Status: v1.NodePoolStatus{
+					Resources: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100"),
 					},
 				},
 			})
 			ExpectApplied(ctx, env.Client, nodePool)
 			pod := test.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			ExpectMetricCounterValue(pscheduling.PodsRejectedTotal, 1, map[string]string{metrics.NodePoolLabel: nodePool.Name, metrics.ReasonLabel: "limits"})
+		})
+	})
+	Context("Preflight Checks", func() {
+		It("should not launch from nodepools whose preflight checks failed", func() {
+			failed := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(100))}})
+			failed.StatusConditions().SetFalse(v1.ConditionTypePreflightChecksSucceeded, "PreflightChecksFailed", "image not found")
+			fallback := test.NodePool()
+			ExpectApplied(ctx, env.Client, failed, fallback)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, fallback.Name))
+		})
+		It("should launch from nodepools that haven't run preflight checks", func() {
+			nodePool := test.NodePool()
+			Expect(nodePool.StatusConditions().Clear(v1.ConditionTypePreflightChecksSucceeded)).To(Succeed())
+			Expect(nodePool.StatusConditions().Root().IsTrue()).To(BeTrue())
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Quota Exceeded", func() {
+		It("should not launch from nodepools that recently exceeded a quota", func() {
+			exceeded := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(100))}})
+			exceeded.StatusConditions().SetTrueWithReason(v1.ConditionTypeQuotaExceeded, "QuotaExceeded", "vcpu limit reached")
+			fallback := test.NodePool()
+			ExpectApplied(ctx, env.Client, exceeded, fallback)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			node := ExpectScheduled(ctx, env.Client, pod)
-			Expect(node.Annotations).To(HaveKeyWithValue(v1.DoNotDisruptAnnotationKey, "true"))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, fallback.Name))
+		})
+		It("should launch from nodepools again once the quota exceeded backoff has passed", func() {
+			exceeded := test.NodePool(v1.NodePool{Spec: v1.NodePoolSpec{Weight: lo.ToPtr(int32(100))}})
+			exceeded.StatusConditions().SetTrueWithReason(v1.ConditionTypeQuotaExceeded, "QuotaExceeded", "vcpu limit reached")
+			ExpectApplied(ctx, env.Client, exceeded, test.NodePool())
+			fakeClock.Step(provisioning.QuotaExceededBackoff)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.NodePoolLabelKey, exceeded.Name))
 		})
 	})
 	Context("Labels", func() {
@@ -1236,6 +2466,84 @@ var _ = Describe("Provisioning", func() {
 			Expect(node.Labels).To(HaveKey("test-key-6"))
 			Expect(node.Labels).ToNot(HaveKey("test-key-7"))
 		})
+		It("should propagate selected NodePool labels to nodes", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"cost-center": "1234", "team": "platform", "unselected": "value"},
+				},
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						ObjectMeta: v1.ObjectMeta{
+							PropagateNodePoolLabels: []string{"cost-center", "team", "missing"},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue("cost-center", "1234"))
+			Expect(node.Labels).To(HaveKeyWithValue("team", "platform"))
+			Expect(node.Labels).ToNot(HaveKey("unselected"))
+			Expect(node.Labels).ToNot(HaveKey("missing"))
+		})
+		It("should launch nodes in the NodePool's placement group", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						Spec: v1.NodeClaimTemplateSpec{
+							PlacementGroup: "training",
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			Expect(cloudProvider.CreateCalls[0].Spec.PlacementGroup).To(Equal("training"))
+		})
+		It("should prefer template labels over propagated NodePool labels", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"team": "platform"},
+				},
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						ObjectMeta: v1.ObjectMeta{
+							Labels:                  map[string]string{"team": "data"},
+							PropagateNodePoolLabels: []string{"team"},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue("team", "data"))
+		})
+		It("should not propagate restricted NodePool labels", func() {
+			nodePool := test.NodePool(v1.NodePool{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"kubernetes.io/test": "value"},
+				},
+				Spec: v1.NodePoolSpec{
+					Template: v1.NodeClaimTemplate{
+						ObjectMeta: v1.ObjectMeta{
+							PropagateNodePoolLabels: []string{"kubernetes.io/test"},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).ToNot(HaveKey("kubernetes.io/test"))
+		})
 		It("should label nodes with labels in the LabelDomainExceptions list", func() {
 			for domain := range v1.LabelDomainExceptions {
 				nodePool := test.NodePool(v1.NodePool{
@@ -1610,6 +2918,92 @@ var _ = Describe("Provisioning", func() {
 		BeforeEach(func() {
 			storageClass = test.StorageClass(test.StorageClassOptions{Zones: []string{"test-zone-2", "test-zone-3"}})
 		})
+		It("should prefer the offering that the pod's volume was last attached to", func() {
+			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-3"}})
+			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: persistentVolume.Name, StorageClassName: &storageClass.Name})
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				corev1.LabelInstanceTypeStable: "default-instance-type",
+				v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+			}}})
+			volumeAttachment := test.VolumeAttachment(test.VolumeAttachmentOptions{NodeName: node.Name, VolumeName: persistentVolume.Name})
+			ExpectApplied(ctx, env.Client, test.NodePool(), storageClass, persistentVolumeClaim, persistentVolume, node, volumeAttachment)
+			pod := test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			scheduled := ExpectScheduled(ctx, env.Client, pod)
+			Expect(scheduled.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-3"))
+			Expect(scheduled.Labels).To(HaveKeyWithValue(corev1.LabelInstanceTypeStable, "default-instance-type"))
+			Expect(scheduled.Labels).To(HaveKeyWithValue(v1.CapacityTypeLabelKey, v1.CapacityTypeOnDemand))
+		})
+		It("should relax the preferred volume offering if it can't be launched", func() {
+			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-3"}})
+			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: persistentVolume.Name, StorageClassName: &storageClass.Name})
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				corev1.LabelInstanceTypeStable: "unknown-instance-type",
+				v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+			}}})
+			volumeAttachment := test.VolumeAttachment(test.VolumeAttachmentOptions{NodeName: node.Name, VolumeName: persistentVolume.Name})
+			ExpectApplied(ctx, env.Client, test.NodePool(), storageClass, persistentVolumeClaim, persistentVolume, node, volumeAttachment)
+			pod := test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			scheduled := ExpectScheduled(ctx, env.Client, pod)
+			Expect(scheduled.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-3"))
+		})
+		It("should prefer the offerings of the listed volume attachments for every pod that's injected", func() {
+			volumeTopology := pscheduling.NewVolumeTopology(env.Client, test.NewEventRecorder())
+			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-3"}})
+			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: persistentVolume.Name, StorageClassName: &storageClass.Name})
+			node := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				corev1.LabelInstanceTypeStable: "default-instance-type",
+				v1.CapacityTypeLabelKey:        v1.CapacityTypeOnDemand,
+			}}})
+			volumeAttachment := test.VolumeAttachment(test.VolumeAttachmentOptions{NodeName: node.Name, VolumeName: persistentVolume.Name})
+			ExpectApplied(ctx, env.Client, storageClass, persistentVolumeClaim, persistentVolume, node, volumeAttachment)
+
+			attachments, err := volumeTopology.ListVolumeAttachments(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(attachments).To(HaveKeyWithValue(persistentVolume.Name, ConsistOf(node.Name)))
+			pods := test.UnschedulablePods(test.PodOptions{PersistentVolumeClaims: []string{persistentVolumeClaim.Name}}, 2)
+			for _, pod := range pods {
+				Expect(volumeTopology.Inject(ctx, pod, attachments)).To(Succeed())
+				Expect(pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(ContainElement(corev1.PreferredSchedulingTerm{
+					Weight: 1,
+					Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"default-instance-type"}},
+						{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpIn, Values: []string{v1.CapacityTypeOnDemand}},
+					}},
+				}))
+			}
+		})
+		It("should emit an event when the volume zone conflicts with the pod's zone requirements", func() {
+			recorder := test.NewEventRecorder()
+			volumeTopology := pscheduling.NewVolumeTopology(env.Client, recorder)
+			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-3"}})
+			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: persistentVolume.Name, StorageClassName: &storageClass.Name})
+			ExpectApplied(ctx, env.Client, storageClass, persistentVolumeClaim, persistentVolume)
+			pod := test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+				NodeSelector:           map[string]string{corev1.LabelTopologyZone: "test-zone-1"},
+			})
+			Expect(volumeTopology.Inject(ctx, pod, nil)).To(Succeed())
+			Expect(recorder.Calls("VolumeZoneConflict")).To(Equal(1))
+		})
+		It("should not emit an event when the volume zone matches the pod's zone requirements", func() {
+			recorder := test.NewEventRecorder()
+			volumeTopology := pscheduling.NewVolumeTopology(env.Client, recorder)
+			persistentVolume := test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-3"}})
+			persistentVolumeClaim := test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: persistentVolume.Name, StorageClassName: &storageClass.Name})
+			ExpectApplied(ctx, env.Client, storageClass, persistentVolumeClaim, persistentVolume)
+			pod := test.UnschedulablePod(test.PodOptions{
+				PersistentVolumeClaims: []string{persistentVolumeClaim.Name},
+				NodeSelector:           map[string]string{corev1.LabelTopologyZone: "test-zone-3"},
+			})
+			Expect(volumeTopology.Inject(ctx, pod, nil)).To(Succeed())
+			Expect(recorder.Calls("VolumeZoneConflict")).To(Equal(0))
+		})
 		It("should not schedule if invalid pvc", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool())
 			pod := test.UnschedulablePod(test.PodOptions{
@@ -1999,6 +3393,66 @@ var _ = Describe("Provisioning", func() {
 			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-3"))
 		})
 	})
+	Context("Blocked Offerings", func() {
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "single-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{
+								v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+								corev1.LabelTopologyZone: "test-zone-1",
+							}),
+							Price:     1,
+							Available: true,
+						},
+						{
+							Requirements: scheduling.NewLabelRequirements(map[string]string{
+								v1.CapacityTypeLabelKey:  v1.CapacityTypeOnDemand,
+								corev1.LabelTopologyZone: "test-zone-2",
+							}),
+							Price:     2,
+							Available: true,
+						},
+					},
+				}),
+			}
+		})
+		It("should launch a different offering when the cheapest offering is blocked", func() {
+			cluster.BlockOffering(state.OfferingKey{InstanceType: "single-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand})
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+			// The cloud provider's instance types shouldn't be modified by the block
+			Expect(cloudProvider.InstanceTypes[0].Offerings.Available()).To(HaveLen(2))
+		})
+		It("should launch the blocked offering again once the block expires", func() {
+			cluster.BlockOffering(state.OfferingKey{InstanceType: "single-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand})
+			fakeClock.Step(state.OfferingBlockDuration + time.Minute)
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1"))
+		})
+		It("should skip offerings with insufficient capacity until the unavailable offerings ttl passes", func() {
+			cluster.BlockInsufficientCapacityOfferings(ctx, state.OfferingKey{InstanceType: "single-instance-type", Zone: "test-zone-1", CapacityType: v1.CapacityTypeOnDemand})
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-2"))
+
+			fakeClock.Step(options.FromContext(ctx).UnavailableOfferingsTTL)
+			pod = test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node = ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(corev1.LabelTopologyZone, "test-zone-1"))
+		})
+	})
 	Context("Preferential Fallback", func() {
 		Context("Required", func() {
 			It("should not relax the final term", func() {
@@ -2251,3 +3705,34 @@ func AddInstanceResources(instanceTypes []*cloudprovider.InstanceType, resources
 
 	return instanceTypes
 }
+
+// daemonSetRevision returns a ControllerRevision of the DaemonSet with the given hash
+func daemonSetRevision(daemonSet *appsv1.DaemonSet, hash string, revision int64) *appsv1.ControllerRevision {
+	return &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", daemonSet.Name, hash),
+			Namespace: daemonSet.Namespace,
+			Labels:    map[string]string{appsv1.DefaultDaemonSetUniqueLabelKey: hash},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "DaemonSet",
+				Name:       daemonSet.Name,
+				UID:        daemonSet.UID,
+				Controller: lo.ToPtr(true),
+			}},
+		},
+		Data:     runtime.RawExtension{Raw: []byte("{}")},
+		Revision: revision,
+	}
+}
+
+// labelDriftHasher drifts NodeClaims when the value of a label on their NodePool changes
+type labelDriftHasher string
+
+func (h labelDriftHasher) Name() string {
+	return fmt.Sprintf("metadata.labels[%s]", string(h))
+}
+
+func (h labelDriftHasher) Hash(nodePool *v1.NodePool) string {
+	return nodePool.Labels[string(h)]
+}
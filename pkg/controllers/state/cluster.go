@@ -69,6 +69,24 @@ type Cluster struct {
 	clusterState      time.Time
 	unsyncedStartTime time.Time
 	antiAffinityPods  sync.Map // pod namespaced name -> *corev1.Pod of pods that have required anti affinities
+
+	offeringsMu                  sync.RWMutex
+	offeringRegistrationFailures map[OfferingKey][]time.Time // offering -> times of recent registration timeouts
+	blockedOfferings             map[OfferingKey]time.Time   // offering -> time the block expires
+
+	placementsMu           sync.RWMutex
+	placementPredictions   map[types.NamespacedName]PlacementPrediction // pod namespaced name -> where the pod is expected to be bound
+	schedulingSafetyMargin float64
+
+	snapshotsMu sync.Mutex              // Separate mutex as snapshots are taken while mu is only held for reading
+	snapshots   map[string]nodeSnapshot // provider id -> copy of the node handed out by the last snapshot
+}
+
+// nodeSnapshot is a copy of a state node, along with the version of the node that it was copied from
+type nodeSnapshot struct {
+	source     *StateNode
+	generation uint64
+	node       *StateNode
 }
 
 func NewCluster(clk clock.Clock, client client.Client, cloudProvider cloudprovider.CloudProvider) *Cluster {
@@ -84,6 +102,11 @@ func NewCluster(clk clock.Clock, client client.Client, cloudProvider cloudprovid
 		podAcks:                   sync.Map{},
 		podsSchedulableTimes:      sync.Map{},
 		podsSchedulingAttempted:   sync.Map{},
+
+		offeringRegistrationFailures: map[OfferingKey][]time.Time{},
+		blockedOfferings:             map[OfferingKey]time.Time{},
+		placementPredictions:         map[types.NamespacedName]PlacementPrediction{},
+		snapshots:                    map[string]nodeSnapshot{},
 	}
 }
 
@@ -110,13 +133,15 @@ func (c *Cluster) Synced(ctx context.Context) (synced bool) {
 	defer func() {
 		ClusterStateSynced.Set(lo.Ternary[float64](synced, 1, 0), nil)
 	}()
-	nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider)
+	// We only compare names here, so we skip deep copying every NodeClaim and Node out of the informer cache. This
+	// check runs on every provisioning and disruption loop and would otherwise copy the whole fleet on large clusters.
+	nodeClaims, err := nodeclaimutils.ListManaged(ctx, c.kubeClient, c.cloudProvider, client.UnsafeDisableDeepCopy)
 	if err != nil {
 		log.FromContext(ctx).Error(err, "failed checking cluster state sync")
 		return false
 	}
 	nodeList := &corev1.NodeList{}
-	if err := c.kubeClient.List(ctx, nodeList); err != nil {
+	if err := c.kubeClient.List(ctx, nodeList, client.UnsafeDisableDeepCopy); err != nil {
 		log.FromContext(ctx).Error(err, "failed checking cluster state sync")
 		return false
 	}
@@ -183,15 +208,29 @@ func (c *Cluster) ForEachNode(f func(n *StateNode) bool) {
 	}
 }
 
-// Nodes creates a DeepCopy of all state nodes.
-// NOTE: This is very inefficient so this should only be used when DeepCopying is absolutely necessary
+// Nodes returns a snapshot of all state nodes. Only the nodes that have changed since the last snapshot are deep
+// copied, while the copies of the other nodes are shared with earlier snapshots, so the returned nodes must not be
+// modified.
 func (c *Cluster) Nodes() StateNodes {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-
-	return lo.Map(lo.Values(c.nodes), func(n *StateNode, _ int) *StateNode {
-		return n.DeepCopy()
-	})
+	c.snapshotsMu.Lock()
+	defer c.snapshotsMu.Unlock()
+
+	snapshots := make(map[string]nodeSnapshot, len(c.nodes))
+	nodes := make(StateNodes, 0, len(c.nodes))
+	for id, n := range c.nodes {
+		snapshot, ok := c.snapshots[id]
+		// Nodes are replaced when their Node or NodeClaim is updated and have their generation bumped when they're
+		// changed in place, so a copy is current as long as both match
+		if !ok || snapshot.source != n || snapshot.generation != n.generation {
+			snapshot = nodeSnapshot{source: n, generation: n.generation, node: n.DeepCopy()}
+		}
+		snapshots[id] = snapshot
+		nodes = append(nodes, snapshot.node)
+	}
+	c.snapshots = snapshots
+	return nodes
 }
 
 // IsNodeNominated returns true if the given node was expected to have a pod bound to it during a recent scheduling
@@ -201,7 +240,7 @@ func (c *Cluster) IsNodeNominated(providerID string) bool {
 	defer c.mu.RUnlock()
 
 	if n, ok := c.nodes[providerID]; ok {
-		return n.Nominated()
+		return n.Nominated(c.clock)
 	}
 	return false
 }
@@ -212,7 +251,7 @@ func (c *Cluster) NominateNodeForPod(ctx context.Context, providerID string) {
 	defer c.mu.Unlock()
 
 	if n, ok := c.nodes[providerID]; ok {
-		n.Nominate(ctx) // extends nomination window if already nominated
+		n.Nominate(ctx, c.clock) // extends nomination window if already nominated
 	}
 }
 
@@ -226,6 +265,7 @@ func (c *Cluster) UnmarkForDeletion(providerIDs ...string) {
 	for _, id := range providerIDs {
 		if n, ok := c.nodes[id]; ok {
 			n.markedForDeletion = false
+			n.generation++
 		}
 	}
 }
@@ -240,6 +280,7 @@ func (c *Cluster) MarkForDeletion(providerIDs ...string) {
 	for _, id := range providerIDs {
 		if n, ok := c.nodes[id]; ok {
 			n.markedForDeletion = true
+			n.generation++
 		}
 	}
 }
@@ -382,6 +423,7 @@ func (c *Cluster) DeletePod(podKey types.NamespacedName) {
 	c.antiAffinityPods.Delete(podKey)
 	c.updateNodeUsageFromPodCompletion(podKey)
 	c.ClearPodSchedulingMappings(podKey)
+	c.ForgetPlacementPrediction(podKey)
 	c.MarkUnconsolidated()
 }
 
@@ -432,6 +474,20 @@ func (c *Cluster) Reset() {
 	c.bindings = map[types.NamespacedName]string{}
 	c.antiAffinityPods = sync.Map{}
 	c.daemonSetPods = sync.Map{}
+
+	c.offeringsMu.Lock()
+	defer c.offeringsMu.Unlock()
+	c.offeringRegistrationFailures = map[OfferingKey][]time.Time{}
+	c.blockedOfferings = map[OfferingKey]time.Time{}
+
+	c.placementsMu.Lock()
+	defer c.placementsMu.Unlock()
+	c.placementPredictions = map[types.NamespacedName]PlacementPrediction{}
+	c.schedulingSafetyMargin = 0
+
+	c.snapshotsMu.Lock()
+	defer c.snapshotsMu.Unlock()
+	c.snapshots = map[string]nodeSnapshot{}
 }
 
 func (c *Cluster) GetDaemonSetPod(daemonset *appsv1.DaemonSet) *corev1.Pod {
@@ -504,6 +560,7 @@ func (c *Cluster) cleanupNodeClaim(name string) {
 			delete(c.nodes, id)
 		} else {
 			c.nodes[id].NodeClaim = nil
+			c.nodes[id].generation++
 		}
 		c.MarkUnconsolidated()
 	}
@@ -551,6 +608,7 @@ func (c *Cluster) cleanupNode(name string) {
 			delete(c.nodes, id)
 		} else {
 			c.nodes[id].Node = nil
+			c.nodes[id].generation++
 		}
 		delete(c.nodeNameToProviderID, name)
 		c.MarkUnconsolidated()
@@ -0,0 +1,146 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+const (
+	// registrationFailureWindow is how long a registration timeout counts towards blocking its offering
+	registrationFailureWindow = time.Hour * 3
+	// OfferingBlockDuration is how long an offering stays blocked after it repeatedly failed to register
+	OfferingBlockDuration = time.Hour
+)
+
+// OfferingKey identifies a single offering of an instance type
+type OfferingKey = cloudprovider.OfferingKey
+
+// OfferingKeyForNodeClaim returns the offering that a launched NodeClaim was created with
+func OfferingKeyForNodeClaim(nodeClaim *v1.NodeClaim) OfferingKey {
+	return OfferingKey{
+		InstanceType: nodeClaim.Labels[corev1.LabelInstanceTypeStable],
+		Zone:         nodeClaim.Labels[corev1.LabelTopologyZone],
+		CapacityType: nodeClaim.Labels[v1.CapacityTypeLabelKey],
+	}
+}
+
+// MarkOfferingRegistrationFailure records that an instance launched with the offering never registered and returns
+// the number of registration failures seen for the offering within the failure window
+func (c *Cluster) MarkOfferingRegistrationFailure(key OfferingKey) int {
+	c.offeringsMu.Lock()
+	defer c.offeringsMu.Unlock()
+	now := c.clock.Now()
+	failures := lo.Filter(c.offeringRegistrationFailures[key], func(t time.Time, _ int) bool {
+		return now.Sub(t) < registrationFailureWindow
+	})
+	c.offeringRegistrationFailures[key] = append(failures, now)
+	return len(c.offeringRegistrationFailures[key])
+}
+
+// BlockOffering prevents the provisioner from launching the offering until the block expires. Any registration
+// failures recorded against the offering are reset so that it gets a fresh set of attempts once the block lifts.
+func (c *Cluster) BlockOffering(key OfferingKey) {
+	c.offeringsMu.Lock()
+	defer c.offeringsMu.Unlock()
+	c.blockOffering(key, OfferingBlockDuration)
+	delete(c.offeringRegistrationFailures, key)
+}
+
+// BlockInsufficientCapacityOfferings prevents the provisioner from launching the offerings that the CloudProvider
+// ran out of capacity for until the UnavailableOfferingsTTL has passed. Capacity usually recovers within minutes, so
+// this is much shorter than the block for offerings that fail to register.
+func (c *Cluster) BlockInsufficientCapacityOfferings(ctx context.Context, keys ...OfferingKey) {
+	ttl := options.FromContext(ctx).UnavailableOfferingsTTL
+	if ttl <= 0 {
+		return
+	}
+	c.offeringsMu.Lock()
+	defer c.offeringsMu.Unlock()
+	for _, key := range keys {
+		c.blockOffering(key, ttl)
+	}
+}
+
+// blockOffering blocks the offering for the duration, without shortening a longer block that's already in place.
+// Expired blocks are dropped along the way so offerings that were only briefly out of capacity don't accumulate.
+func (c *Cluster) blockOffering(key OfferingKey, duration time.Duration) {
+	now := c.clock.Now()
+	for k, expiration := range c.blockedOfferings {
+		if !now.Before(expiration) {
+			delete(c.blockedOfferings, k)
+		}
+	}
+	expiration := now.Add(duration)
+	if current, ok := c.blockedOfferings[key]; !ok || current.Before(expiration) {
+		c.blockedOfferings[key] = expiration
+	}
+}
+
+// IsOfferingBlocked returns true if the offering was blocked and the block hasn't expired yet
+func (c *Cluster) IsOfferingBlocked(key OfferingKey) bool {
+	c.offeringsMu.RLock()
+	defer c.offeringsMu.RUnlock()
+	expiration, ok := c.blockedOfferings[key]
+	return ok && c.clock.Now().Before(expiration)
+}
+
+// WithoutBlockedOfferings returns the instance types with any blocked offerings marked as unavailable. Instance types
+// are copied before being modified since they are typically shared with the cloud provider's cache.
+func (c *Cluster) WithoutBlockedOfferings(instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	c.offeringsMu.RLock()
+	defer c.offeringsMu.RUnlock()
+	if len(c.blockedOfferings) == 0 {
+		return instanceTypes
+	}
+	now := c.clock.Now()
+	blocked := func(it *cloudprovider.InstanceType, o cloudprovider.Offering) bool {
+		expiration, ok := c.blockedOfferings[OfferingKey{
+			InstanceType: it.Name,
+			Zone:         o.Requirements.Get(corev1.LabelTopologyZone).Any(),
+			CapacityType: o.Requirements.Get(v1.CapacityTypeLabelKey).Any(),
+		}]
+		return ok && now.Before(expiration)
+	}
+	return lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) *cloudprovider.InstanceType {
+		if !lo.ContainsBy(it.Offerings, func(o cloudprovider.Offering) bool { return o.Available && blocked(it, o) }) {
+			return it
+		}
+		return &cloudprovider.InstanceType{
+			Name:         it.Name,
+			Requirements: it.Requirements,
+			Offerings: lo.Map(it.Offerings, func(o cloudprovider.Offering, _ int) cloudprovider.Offering {
+				if blocked(it, o) {
+					o.Available = false
+				}
+				return o
+			}),
+			Capacity:    it.Capacity,
+			Overhead:    it.Overhead,
+			Generation:  it.Generation,
+			BaselineCPU: it.BaselineCPU,
+		}
+	})
+}
@@ -0,0 +1,132 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// DivergenceCause is why a pod was bound to a different node than scheduling simulation predicted
+type DivergenceCause string
+
+const (
+	// DivergenceCauseNodeRemoved is when the predicted node or NodeClaim was deleted, or marked for deletion, before
+	// the pod was bound
+	DivergenceCauseNodeRemoved DivergenceCause = "node_removed"
+	// DivergenceCauseResourceMismatch is when the predicted node doesn't have room for the pod, e.g. because its
+	// allocatable resources were less than the instance type promised
+	DivergenceCauseResourceMismatch DivergenceCause = "resource_mismatch"
+	// DivergenceCauseUnmodeledPlugin is when the predicted node had room for the pod, but kube-scheduler preferred
+	// another node for reasons that the simulation doesn't model, like scoring plugins or capacity that was freed up
+	// before the predicted node launched
+	DivergenceCauseUnmodeledPlugin DivergenceCause = "unmodeled_plugin"
+)
+
+// PlacementPrediction is where a scheduling simulation expected a pending pod to be bound
+type PlacementPrediction struct {
+	UID types.UID
+	// NodeClaim is the name of the predicted node's NodeClaim, if the node is managed by Karpenter
+	NodeClaim string
+	// Node is the name of the predicted node, if it had registered
+	Node     string
+	Requests corev1.ResourceList
+	Time     time.Time
+}
+
+// PredictPlacement records that scheduling simulation expects the pod to be bound to the node of the NodeClaim, or
+// the node, replacing any earlier prediction for the pod
+func (c *Cluster) PredictPlacement(pod *corev1.Pod, nodeClaim string, node string) {
+	c.placementsMu.Lock()
+	defer c.placementsMu.Unlock()
+	c.placementPredictions[client.ObjectKeyFromObject(pod)] = PlacementPrediction{
+		UID:       pod.UID,
+		NodeClaim: nodeClaim,
+		Node:      node,
+		Requests:  resources.RequestsForPods(pod),
+		Time:      c.clock.Now(),
+	}
+}
+
+// PlacementPrediction returns the latest placement prediction for the pod
+func (c *Cluster) PlacementPrediction(podKey types.NamespacedName) (PlacementPrediction, bool) {
+	c.placementsMu.RLock()
+	defer c.placementsMu.RUnlock()
+	prediction, ok := c.placementPredictions[podKey]
+	return prediction, ok
+}
+
+// ForgetPlacementPrediction removes the placement prediction for the pod
+func (c *Cluster) ForgetPlacementPrediction(podKey types.NamespacedName) {
+	c.placementsMu.Lock()
+	defer c.placementsMu.Unlock()
+	delete(c.placementPredictions, podKey)
+}
+
+// PlacementDivergence compares the node that the pod was bound to with the predicted placement, and returns why they
+// differ. An empty cause is returned if the pod was bound as predicted. False is returned if the node that the pod was
+// bound to isn't tracked yet, so the placement can't be compared.
+func (c *Cluster) PlacementDivergence(pod *corev1.Pod, prediction PlacementPrediction) (DivergenceCause, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	bound, ok := c.nodeNameToProviderID[pod.Spec.NodeName]
+	if !ok {
+		return "", false
+	}
+	providerID, launched := c.nodeClaimNameToProviderID[prediction.NodeClaim]
+	if prediction.NodeClaim == "" {
+		providerID, launched = c.nodeNameToProviderID[prediction.Node]
+	}
+	if !launched {
+		return DivergenceCauseNodeRemoved, true
+	}
+	if providerID == bound {
+		return "", true
+	}
+	// The NodeClaim exists but hasn't launched, so kube-scheduler found room for the pod before the node did
+	if providerID == "" {
+		return DivergenceCauseUnmodeledPlugin, true
+	}
+	predicted, ok := c.nodes[providerID]
+	if !ok || predicted.MarkedForDeletion() {
+		return DivergenceCauseNodeRemoved, true
+	}
+	if !resources.Fits(prediction.Requests, predicted.Available()) {
+		return DivergenceCauseResourceMismatch, true
+	}
+	return DivergenceCauseUnmodeledPlugin, true
+}
+
+// SchedulingSafetyMargin returns the fraction that pod requests are scaled up by when they're packed onto new NodeClaims
+func (c *Cluster) SchedulingSafetyMargin() float64 {
+	c.placementsMu.RLock()
+	defer c.placementsMu.RUnlock()
+	return c.schedulingSafetyMargin
+}
+
+// SetSchedulingSafetyMargin sets the fraction that pod requests are scaled up by when they're packed onto new NodeClaims
+func (c *Cluster) SetSchedulingSafetyMargin(margin float64) {
+	c.placementsMu.Lock()
+	defer c.placementsMu.Unlock()
+	c.schedulingSafetyMargin = margin
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -41,6 +42,8 @@ import (
 
 type PodBlockEvictionError struct {
 	error
+	// PDB is the PodDisruptionBudget blocking eviction, if eviction is blocked by one
+	PDB client.ObjectKey
 }
 
 func NewPodBlockEvictionError(err error) *PodBlockEvictionError {
@@ -55,6 +58,12 @@ func IsPodBlockEvictionError(err error) bool {
 	return errors.As(err, &podBlockEvictionError)
 }
 
+// IsPDBBlockEvictionError returns whether the error is a PodBlockEvictionError caused by a PodDisruptionBudget
+func IsPDBBlockEvictionError(err error) bool {
+	var podBlockEvictionError *PodBlockEvictionError
+	return errors.As(err, &podBlockEvictionError) && podBlockEvictionError.PDB != (client.ObjectKey{})
+}
+
 func IgnorePodBlockEvictionError(err error) error {
 	if IsPodBlockEvictionError(err) {
 		return nil
@@ -131,6 +140,9 @@ type StateNode struct {
 	// of the karpenter.sh/disruption taint to know when a node is marked for deletion.
 	markedForDeletion bool
 	nominatedUntil    metav1.Time
+
+	// generation is bumped whenever the node is changed in place, so that cluster state snapshots know to copy it again
+	generation uint64
 }
 
 func NewNode() *StateNode {
@@ -180,7 +192,7 @@ func (in *StateNode) Pods(ctx context.Context, kubeClient client.Client) ([]*cor
 // ValidateNodeDisruptable takes in a recorder to emit events on the nodeclaims when the state node is not a candidate
 //
 //nolint:gocyclo
-func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, kubeClient client.Client) error {
+func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, clk clock.Clock, kubeClient client.Client) error {
 	if in.NodeClaim == nil {
 		return fmt.Errorf("node is not managed by karpenter")
 	}
@@ -193,8 +205,12 @@ func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, kubeClient cli
 	if in.MarkedForDeletion() {
 		return fmt.Errorf("state node is marked for deletion")
 	}
+	// disrupting a NodeClaim which isn't paired with the right instance could terminate the wrong one
+	if in.NodeClaim.StatusConditions().IsTrue(v1.ConditionTypeProviderIDMismatch) {
+		return fmt.Errorf("nodeclaim providerID doesn't match its node or instance")
+	}
 	// skip the node if it is nominated by a recent provisioning pass to be the target of a pending pod.
-	if in.Nominated() {
+	if in.Nominated(clk) {
 		return fmt.Errorf("state node is nominated for a pending pod")
 	}
 	if in.Annotations()[v1.DoNotDisruptAnnotationKey] == "true" {
@@ -208,7 +224,7 @@ func (in *StateNode) ValidateNodeDisruptable(ctx context.Context, kubeClient cli
 }
 
 // ValidatePodDisruptable returns an error if the StateNode contains a pod that cannot be disrupted
-// This checks associated PDBs and do-not-disrupt annotations for each pod on the node.
+// This checks associated PDBs and do-not-disrupt annotations for each pod on the node and the namespace it's in.
 // ValidatePodDisruptable takes in a recorder to emit events on the nodeclaims when the state node is not a candidate
 //
 //nolint:gocyclo
@@ -224,8 +240,26 @@ func (in *StateNode) ValidatePodsDisruptable(ctx context.Context, kubeClient cli
 			return pods, NewPodBlockEvictionError(fmt.Errorf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po)))
 		}
 	}
+	// DaemonSet and mirror pods aren't protected by their namespace's annotation, otherwise annotating a namespace
+	// like kube-system would block disruption of every node in the cluster
+	protected := map[string]bool{}
+	for _, po := range pods {
+		if !podutils.IsActive(po) || podutils.IsOwnedByDaemonSet(po) || podutils.IsOwnedByNode(po) {
+			continue
+		}
+		if _, ok := protected[po.Namespace]; !ok {
+			namespace := &corev1.Namespace{}
+			if err := kubeClient.Get(ctx, client.ObjectKey{Name: po.Namespace}, namespace); client.IgnoreNotFound(err) != nil {
+				return pods, fmt.Errorf("getting namespace, %w", err)
+			}
+			protected[po.Namespace] = namespace.Annotations[v1.DoNotDisruptAnnotationKey] == "true"
+		}
+		if protected[po.Namespace] {
+			return pods, NewPodBlockEvictionError(fmt.Errorf(`pod %q is in namespace %q, which has the "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po), po.Namespace))
+		}
+	}
 	if pdbKey, ok := pdbs.CanEvictPods(pods); !ok {
-		return pods, NewPodBlockEvictionError(fmt.Errorf("pdb %q prevents pod evictions", pdbKey))
+		return pods, &PodBlockEvictionError{error: fmt.Errorf("pdb %q prevents pod evictions", pdbKey), PDB: pdbKey}
 	}
 
 	return pods, nil
@@ -403,12 +437,19 @@ func (in *StateNode) MarkedForDeletion() bool {
 		(in.Node != nil && in.NodeClaim == nil && !in.Node.DeletionTimestamp.IsZero())
 }
 
-func (in *StateNode) Nominate(ctx context.Context) {
-	in.nominatedUntil = metav1.Time{Time: time.Now().Add(nominationWindow(ctx))}
+func (in *StateNode) Nominate(ctx context.Context, clk clock.Clock) {
+	in.nominatedUntil = metav1.Time{Time: clk.Now().Add(nominationWindow(ctx))}
+	in.generation++
+}
+
+func (in *StateNode) Nominated(clk clock.Clock) bool {
+	return in.nominatedUntil.After(clk.Now())
 }
 
-func (in *StateNode) Nominated() bool {
-	return in.nominatedUntil.After(time.Now())
+// Vacant returns whether the node has no pods other than daemonsets and hasn't been nominated for pending pods, so
+// that new pods can be scheduled to it right away
+func (in *StateNode) Vacant(clk clock.Clock) bool {
+	return len(in.podRequests) == len(in.daemonSetRequests) && !in.Nominated(clk)
 }
 
 func (in *StateNode) Managed() bool {
@@ -431,6 +472,7 @@ func (in *StateNode) updateForPod(ctx context.Context, kubeClient client.Client,
 	}
 	in.hostPortUsage.Add(pod, hostPorts)
 	in.volumeUsage.Add(pod, volumes)
+	in.generation++
 	return nil
 }
 
@@ -441,6 +483,7 @@ func (in *StateNode) cleanupForPod(podKey types.NamespacedName) {
 	delete(in.podLimits, podKey)
 	delete(in.daemonSetRequests, podKey)
 	delete(in.daemonSetLimits, podKey)
+	in.generation++
 }
 
 func nominationWindow(ctx context.Context) time.Duration {
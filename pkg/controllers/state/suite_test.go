@@ -919,11 +919,11 @@ var _ = Describe("Node Resource Level", func() {
 		cluster.NominateNodeForPod(ctx, node.Spec.ProviderID)
 
 		// Expect that the node is now nominated
-		Expect(ExpectStateNodeExists(cluster, node).Nominated()).To(BeTrue())
-		time.Sleep(time.Second * 10) // nomination window is 20s so it should still be nominated
-		Expect(ExpectStateNodeExists(cluster, node).Nominated()).To(BeTrue())
-		time.Sleep(time.Second * 11) // past 20s, node should no longer be nominated
-		Expect(ExpectStateNodeExists(cluster, node).Nominated()).To(BeFalse())
+		Expect(ExpectStateNodeExists(cluster, node).Nominated(fakeClock)).To(BeTrue())
+		ExpectClockStepped(fakeClock, time.Second*10) // nomination window is 20s so it should still be nominated
+		Expect(ExpectStateNodeExists(cluster, node).Nominated(fakeClock)).To(BeTrue())
+		ExpectClockStepped(fakeClock, time.Second*11) // past 20s, node should no longer be nominated
+		Expect(ExpectStateNodeExists(cluster, node).Nominated(fakeClock)).To(BeFalse())
 	})
 	It("should handle a node changing from no providerID to registering a providerID", func() {
 		node := test.Node()
@@ -1496,6 +1496,81 @@ var _ = Describe("Consolidated State", func() {
 	})
 })
 
+var _ = Describe("Snapshots", func() {
+	var nodes []*corev1.Node
+	BeforeEach(func() {
+		nodes = nil
+		for i := 0; i < 2; i++ {
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					v1.NodePoolLabelKey:            nodePool.Name,
+					corev1.LabelInstanceTypeStable: cloudProvider.InstanceTypes[0].Name,
+				}},
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+				ProviderID:  test.RandomProviderID(),
+			})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			nodes = append(nodes, node)
+		}
+	})
+	snapshot := func() map[string]*state.StateNode {
+		return lo.SliceToMap(cluster.Nodes(), func(n *state.StateNode) (string, *state.StateNode) { return n.ProviderID(), n })
+	}
+	It("should share the copies of nodes that haven't changed between snapshots", func() {
+		first := snapshot()
+		second := snapshot()
+		Expect(second).To(HaveLen(2))
+		for _, node := range nodes {
+			Expect(second[node.Spec.ProviderID]).To(BeIdenticalTo(first[node.Spec.ProviderID]))
+		}
+	})
+	It("should copy nodes that pods have been bound to since the last snapshot", func() {
+		first := snapshot()
+		pod := test.UnschedulablePod(test.PodOptions{
+			ResourceRequirements: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		})
+		ExpectApplied(ctx, env.Client, pod)
+		ExpectManualBinding(ctx, env.Client, pod, nodes[0])
+		ExpectReconcileSucceeded(ctx, podController, client.ObjectKeyFromObject(pod))
+
+		second := snapshot()
+		Expect(second[nodes[0].Spec.ProviderID]).ToNot(BeIdenticalTo(first[nodes[0].Spec.ProviderID]))
+		ExpectResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, second[nodes[0].Spec.ProviderID].PodRequests())
+		ExpectResources(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("0")}, first[nodes[0].Spec.ProviderID].PodRequests())
+		Expect(second[nodes[1].Spec.ProviderID]).To(BeIdenticalTo(first[nodes[1].Spec.ProviderID]))
+	})
+	It("should copy nodes that have been updated since the last snapshot", func() {
+		first := snapshot()
+		nodes[0].Labels["test-label"] = "test-value"
+		ExpectApplied(ctx, env.Client, nodes[0])
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(nodes[0]))
+
+		second := snapshot()
+		Expect(second[nodes[0].Spec.ProviderID].Labels()).To(HaveKeyWithValue("test-label", "test-value"))
+		Expect(first[nodes[0].Spec.ProviderID].Labels()).ToNot(HaveKey("test-label"))
+		Expect(second[nodes[1].Spec.ProviderID]).To(BeIdenticalTo(first[nodes[1].Spec.ProviderID]))
+	})
+	It("should copy nodes that have been marked for deletion since the last snapshot", func() {
+		first := snapshot()
+		cluster.MarkForDeletion(nodes[0].Spec.ProviderID)
+
+		second := snapshot()
+		Expect(second[nodes[0].Spec.ProviderID].MarkedForDeletion()).To(BeTrue())
+		Expect(first[nodes[0].Spec.ProviderID].MarkedForDeletion()).To(BeFalse())
+		Expect(second[nodes[1].Spec.ProviderID]).To(BeIdenticalTo(first[nodes[1].Spec.ProviderID]))
+	})
+	It("should drop nodes that have been deleted since the last snapshot", func() {
+		_ = snapshot()
+		ExpectDeleted(ctx, env.Client, nodes[0])
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(nodes[0]))
+
+		second := snapshot()
+		Expect(second).To(HaveLen(1))
+		Expect(second).To(HaveKey(nodes[1].Spec.ProviderID))
+	})
+})
+
 var _ = Describe("Data Races", func() {
 	It("should ensure that calling Synced() is valid while making updates to Nodes", func() {
 		cancelCtx, cancel := context.WithCancel(ctx)
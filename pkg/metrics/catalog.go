@@ -0,0 +1,57 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+//go:generate go run ../../hack/metrics -root ../.. -catalog catalog.json -rules prometheus-rules.yaml
+
+// Metric types in the catalog, matching the Prometheus exposition format
+const (
+	CatalogCounter   = "counter"
+	CatalogGauge     = "gauge"
+	CatalogHistogram = "histogram"
+	CatalogSummary   = "summary"
+)
+
+// CatalogMetric describes a metric emitted by Karpenter
+type CatalogMetric struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Help   string   `json:"help"`
+	Labels []string `json:"labels,omitempty"`
+	// DynamicLabels is set for metrics whose labels are only known at runtime, in addition to any listed Labels
+	DynamicLabels bool `json:"dynamicLabels,omitempty"`
+	// Source is the file that the metric is defined in, relative to the root of the repository
+	Source string `json:"source"`
+}
+
+//go:embed catalog.json
+var catalog []byte
+
+// Catalog returns every metric emitted by Karpenter. It's generated from the metric definitions, along with the
+// recommended recording and alerting rules in prometheus-rules.yaml, so that both change with the metrics.
+func Catalog() ([]CatalogMetric, error) {
+	var metrics []CatalogMetric
+	if err := json.Unmarshal(catalog, &metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
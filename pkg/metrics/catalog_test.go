@@ -0,0 +1,80 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"regexp"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	_ "sigs.k8s.io/karpenter/pkg/cloudprovider/metrics"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/disruption"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/metrics/headroom"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/metrics/node"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/metrics/nodepool"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/metrics/pod"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/node/termination"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/interruption"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/provisioning/divergence"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	_ "sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	_ "sigs.k8s.io/karpenter/pkg/operator"
+)
+
+// descriptor parses the name and labels out of a metric's description, since they aren't otherwise exposed
+var descriptor = regexp.MustCompile(`fqName: "([^"]*)".*variableLabels: \{([^}]*)\}`)
+
+var _ = Describe("Catalog", func() {
+	It("should match the metrics registered by Karpenter", func() {
+		catalog, err := metrics.Catalog()
+		Expect(err).ToNot(HaveOccurred())
+		byName := lo.SliceToMap(catalog, func(m metrics.CatalogMetric) (string, metrics.CatalogMetric) { return m.Name, m })
+
+		descs := make(chan *prometheus.Desc)
+		go func() {
+			crmetrics.Registry.(prometheus.Collector).Describe(descs)
+			close(descs)
+		}()
+		registered := 0
+		for desc := range descs {
+			match := descriptor.FindStringSubmatch(desc.String())
+			Expect(match).ToNot(BeNil())
+			if !strings.HasPrefix(match[1], metrics.Namespace+"_") {
+				continue
+			}
+			registered++
+			m, ok := byName[match[1]]
+			Expect(ok).To(BeTrue(), "metric %s is missing from the catalog, run go generate ./pkg/metrics", match[1])
+			labels := lo.Compact(strings.Split(match[2], ","))
+			if m.DynamicLabels {
+				Expect(labels).To(ContainElements(m.Labels))
+			} else {
+				Expect(labels).To(ConsistOf(m.Labels), "labels for %s don't match the catalog, run go generate ./pkg/metrics", match[1])
+			}
+		}
+		Expect(registered).To(Equal(len(catalog)))
+	})
+})
@@ -17,9 +17,13 @@ limitations under the License.
 package metrics
 
 import (
+	"context"
+	"strings"
 	"time"
 
 	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -30,11 +34,26 @@ const (
 	ReasonLabel       = "reason"
 	CapacityTypeLabel = "capacity_type"
 
+	InstanceFamilyLabel = "instance_family"
+
+	// TraceIDExemplarLabel is the exemplar label that links an observation to the trace it was recorded in
+	TraceIDExemplarLabel = "trace_id"
+
 	// Reasons for CREATE/DELETE shared metrics
 	ProvisionedReason = "provisioned"
+	MinNodesReason    = "min_nodes"
+	HeadroomReason    = "headroom"
 	ExpiredReason     = "expired"
 )
 
+// InstanceFamily returns the family that an instance type belongs to, so that metrics can be broken down by the
+// hardware or image a node is launched with without a series per instance size. Most cloud providers name instance
+// types <family>.<size>; names without a "." are their own family.
+func InstanceFamily(instanceType string) string {
+	family, _, _ := strings.Cut(instanceType, ".")
+	return family
+}
+
 // DurationBuckets returns a []float64 of default threshold values for duration histograms.
 // Each returned slice is new and may be modified without impacting other bucket definitions.
 func DurationBuckets() []float64 {
@@ -62,3 +81,27 @@ func Measure(observer opmetrics.ObservationMetric, labels map[string]string) fun
 	start := time.Now()
 	return func() { observer.Observe(time.Since(start).Seconds(), labels) }
 }
+
+// MeasureContext is like Measure, but also attaches the trace ID of the span in the context to the observation as an
+// exemplar when the span is sampled. Exemplars are only kept by Prometheus histograms and are only served to scrapers
+// that request the OpenMetrics format.
+func MeasureContext(ctx context.Context, observer opmetrics.ObservationMetric, labels map[string]string) func() {
+	start := time.Now()
+	return func() { ObserveWithExemplar(ctx, observer, time.Since(start).Seconds(), labels) }
+}
+
+// ObserveWithExemplar observes the value, attaching the trace ID of the sampled span in the context as an exemplar
+func ObserveWithExemplar(ctx context.Context, observer opmetrics.ObservationMetric, v float64, labels map[string]string) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	histogram, ok := observer.(*opmetrics.PrometheusHistogram)
+	if !ok || !spanContext.IsSampled() {
+		observer.Observe(v, labels)
+		return
+	}
+	exemplarObserver, ok := histogram.HistogramVec.With(labels).(prometheus.ExemplarObserver)
+	if !ok {
+		observer.Observe(v, labels)
+		return
+	}
+	exemplarObserver.ObserveWithExemplar(v, prometheus.Labels{TraceIDExemplarLabel: spanContext.TraceID().String()})
+}
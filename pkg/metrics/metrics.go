@@ -71,6 +71,48 @@ var (
 			CapacityTypeLabel,
 		},
 	)
+	NodeClaimsDisruptionObservedTotal = opmetrics.NewPrometheusCounter(
+		crmetrics.Registry,
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: NodeClaimSubsystem,
+			Name:      "disruption_observed_total",
+			Help:      "Number of disruption decisions Karpenter reported rather than performed for nodeclaims of ObserveOnly nodepools. Labeled by reason the nodeclaim would have been disrupted and the owning nodepool.",
+		},
+		[]string{
+			ReasonLabel,
+			NodePoolLabel,
+			CapacityTypeLabel,
+		},
+	)
+	NodeClaimsNodeReadyDurationSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: NodeClaimSubsystem,
+			Name:      "node_ready_duration_seconds",
+			Help:      "The time from nodeclaim creation until its node is ready. Labeled by the owning nodepool and the instance family of the node.",
+			Buckets:   DurationBuckets(),
+		},
+		[]string{
+			NodePoolLabel,
+			InstanceFamilyLabel,
+		},
+	)
+	NodeClaimsFirstPodScheduledDurationSeconds = opmetrics.NewPrometheusHistogram(
+		crmetrics.Registry,
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: NodeClaimSubsystem,
+			Name:      "first_pod_scheduled_duration_seconds",
+			Help:      "The time from nodeclaim creation until the first pod that isn't a daemonset pod is scheduled to its node. Labeled by the owning nodepool and the instance family of the node.",
+			Buckets:   DurationBuckets(),
+		},
+		[]string{
+			NodePoolLabel,
+			InstanceFamilyLabel,
+		},
+	)
 	NodesCreatedTotal = opmetrics.NewPrometheusCounter(
 		crmetrics.Registry,
 		prometheus.CounterOpts{
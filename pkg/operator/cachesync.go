@@ -0,0 +1,83 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	clientfeatures "k8s.io/client-go/features"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// clusterStateObjects are the resources that cluster state is built from. These are the largest informers on big
+// clusters, so their sync progress is reported individually by the readiness check.
+var clusterStateObjects = []client.Object{&corev1.Node{}, &corev1.Pod{}, &v1.NodeClaim{}, &v1.NodePool{}, &appsv1.DaemonSet{}}
+
+// streamInitialLists enables client-go's WatchList support so that informers stream their initial state from the API
+// server's watch cache rather than issuing a single unbounded list. Informers fall back to listing if the API server
+// doesn't support it. This has to be called before any informers are created since client-go resolves the gate then.
+func streamInitialLists() {
+	clientfeatures.ReplaceFeatureGates(&overriddenFeatureGates{
+		Gates:     clientfeatures.FeatureGates(),
+		overrides: map[clientfeatures.Feature]bool{clientfeatures.WatchListClient: true},
+	})
+}
+
+type overriddenFeatureGates struct {
+	clientfeatures.Gates
+	overrides map[clientfeatures.Feature]bool
+}
+
+func (g *overriddenFeatureGates) Enabled(key clientfeatures.Feature) bool {
+	if enabled, ok := g.overrides[key]; ok {
+		return enabled
+	}
+	return g.Gates.Enabled(key)
+}
+
+// CacheSyncCheck reports which of the cluster state informers are still syncing so that a slow startup on a large
+// cluster shows which resources are holding it up, and then waits on the rest of the cache
+func CacheSyncCheck(c cache.Informers) healthz.Checker {
+	return func(req *http.Request) error {
+		var syncing []string
+		for _, obj := range clusterStateObjects {
+			informer, err := c.GetInformer(req.Context(), obj, cache.BlockUntilSynced(false))
+			if err != nil {
+				return fmt.Errorf("getting informer, %w", err)
+			}
+			if !informer.HasSynced() {
+				gvk := lo.Must(apiutil.GVKForObject(obj, scheme.Scheme))
+				syncing = append(syncing, gvk.Kind)
+			}
+		}
+		if len(syncing) > 0 {
+			return fmt.Errorf("waiting for caches to sync, %s", strings.Join(syncing, ", "))
+		}
+		return lo.Ternary(c.WaitForCacheSync(req.Context()), nil, fmt.Errorf("failed to sync caches"))
+	}
+}
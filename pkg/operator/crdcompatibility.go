@@ -0,0 +1,81 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/awslabs/operatorpkg/object"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+)
+
+// requiredCRDs are the CRDs that karpenter can't run without. The remaining CRDs back optional features, which are
+// disabled when their CRD isn't installed.
+var requiredCRDs = sets.New(
+	object.Unmarshal[apiextensionsv1.CustomResourceDefinition](apis.NodePoolCRD).Name,
+	object.Unmarshal[apiextensionsv1.CustomResourceDefinition](apis.NodeClaimCRD).Name,
+)
+
+// CheckCRDCompatibility verifies that the CRDs installed in the cluster can be used by this binary. It returns an error
+// describing every incompatibility that was found. Optional CRDs that aren't installed are skipped.
+func CheckCRDCompatibility(ctx context.Context, reader client.Reader) error {
+	var errs error
+	for _, expected := range apis.CRDs {
+		installed := &apiextensionsv1.CustomResourceDefinition{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: expected.Name}, installed); err != nil {
+			if errors.IsNotFound(err) && !requiredCRDs.Has(expected.Name) {
+				continue
+			}
+			errs = multierr.Append(errs, fmt.Errorf("getting crd %q, %w", expected.Name, err))
+			continue
+		}
+		errs = multierr.Append(errs, ValidateCRDCompatibility(expected, installed))
+	}
+	return errs
+}
+
+// ValidateCRDCompatibility returns an error if the installed CRD doesn't serve every version that the binary's CRD
+// serves, or if the installed CRD stores objects at a version that the binary doesn't know about. The latter happens
+// when the binary is rolled back after a newer version of the CRD was installed.
+func ValidateCRDCompatibility(expected, installed *apiextensionsv1.CustomResourceDefinition) error {
+	var errs error
+	known := sets.New(lo.Map(expected.Spec.Versions, func(v apiextensionsv1.CustomResourceDefinitionVersion, _ int) string { return v.Name })...)
+	served := sets.New(lo.FilterMap(installed.Spec.Versions, func(v apiextensionsv1.CustomResourceDefinitionVersion, _ int) (string, bool) {
+		return v.Name, v.Served
+	})...)
+	for _, v := range expected.Spec.Versions {
+		if v.Served && !served.Has(v.Name) {
+			errs = multierr.Append(errs, fmt.Errorf("crd %q doesn't serve version %q", installed.Name, v.Name))
+		}
+	}
+	if storage, ok := lo.Find(installed.Spec.Versions, func(v apiextensionsv1.CustomResourceDefinitionVersion) bool { return v.Storage }); ok && !known.Has(storage.Name) {
+		errs = multierr.Append(errs, fmt.Errorf("crd %q stores version %q, which is newer than this version of karpenter supports", installed.Name, storage.Name))
+	}
+	if unknown := sets.New(installed.Status.StoredVersions...).Difference(known); unknown.Len() > 0 {
+		errs = multierr.Append(errs, fmt.Errorf("crd %q has objects stored at version(s) %s, which this version of karpenter doesn't support", installed.Name, strings.Join(sets.List(unknown), ", ")))
+	}
+	return errs
+}
@@ -42,6 +42,22 @@ func GetControllerName(ctx context.Context) string {
 	return name.(string)
 }
 
+type versionKeyType struct{}
+
+var versionKey = versionKeyType{}
+
+func WithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionKey, version)
+}
+
+func GetVersion(ctx context.Context) string {
+	version := ctx.Value(versionKey)
+	if version == nil {
+		return ""
+	}
+	return version.(string)
+}
+
 func WithOptionsOrDie(ctx context.Context, opts ...options.Injectable) context.Context {
 	fs := &options.FlagSet{
 		FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
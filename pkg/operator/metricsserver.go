@@ -0,0 +1,152 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	// metricsSnapshotInterval is how often the metrics that are served are refreshed from the registry. It's well
+	// under typical scrape intervals so that scrapes see close to live values.
+	metricsSnapshotInterval = 5 * time.Second
+	// metricsGatherTimeout bounds how long a refresh waits on the registry before giving up and leaving the previous
+	// snapshot in place
+	metricsGatherTimeout = 30 * time.Second
+)
+
+var MetricsSnapshotTimestampSeconds = opmetrics.NewPrometheusGauge(
+	crmetrics.Registry,
+	prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "metrics",
+		Name:      "snapshot_timestamp_seconds",
+		Help:      "The unix time at which the served metrics were gathered. Metrics are stale when this falls behind the current time.",
+	},
+	[]string{},
+)
+
+// MetricsServer serves the metrics registry from a snapshot that's refreshed in the background, rather than gathering
+// from the registry on every scrape. A collector that's blocked behind a wedged controller would otherwise fail or
+// hang the scrape, leaving a gap in every metric. Instead, the last snapshot keeps being served and
+// karpenter_metrics_snapshot_timestamp_seconds shows how old it is. It doesn't need leader election, so standby
+// replicas and a replica that's re-electing keep serving too.
+type MetricsServer struct {
+	addr     string
+	gatherer prometheus.Gatherer
+	handlers map[string]http.Handler
+	// openMetrics serves the OpenMetrics format to scrapers that request it, which is needed to scrape exemplars
+	openMetrics bool
+
+	mu        sync.RWMutex
+	snapshot  []*dto.MetricFamily
+	gathering atomic.Bool
+}
+
+func NewMetricsServer(addr string, gatherer prometheus.Gatherer, extraHandlers map[string]http.Handler, openMetrics bool) *MetricsServer {
+	return &MetricsServer{
+		addr:        addr,
+		gatherer:    gatherer,
+		handlers:    extraHandlers,
+		openMetrics: openMetrics,
+	}
+}
+
+func (s *MetricsServer) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *MetricsServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.Handler())
+	for path, handler := range s.handlers {
+		mux.Handle(path, handler)
+	}
+	server := &http.Server{Addr: s.addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, metricsGatherTimeout)
+		defer cancel()
+		s.Refresh(ctx)
+	}, metricsSnapshotInterval)
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+	log.FromContext(ctx).Info("serving metrics", "address", s.addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving metrics, %w", err)
+	}
+	return nil
+}
+
+// Handler serves the latest snapshot. It fails until the first snapshot has been gathered.
+func (s *MetricsServer) Handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if s.snapshot == nil {
+			return nil, fmt.Errorf("metrics haven't been gathered yet")
+		}
+		return s.snapshot, nil
+	}), promhttp.HandlerOpts{ErrorHandling: promhttp.HTTPErrorOnError, EnableOpenMetrics: s.openMetrics})
+}
+
+// Refresh gathers a new snapshot from the registry, waiting until the context is done at the latest. A gather that's
+// still running when the context is done is left to finish in the background, and no new gather is started until
+// it has, so a blocked collector doesn't pile up goroutines.
+func (s *MetricsServer) Refresh(ctx context.Context) {
+	if !s.gathering.CompareAndSwap(false, true) {
+		log.FromContext(ctx).V(1).Info("skipping metrics refresh, previous gather hasn't completed")
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer s.gathering.Store(false)
+		MetricsSnapshotTimestampSeconds.Set(float64(time.Now().Unix()), map[string]string{})
+		families, err := s.gatherer.Gather()
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed gathering metrics, serving the previous snapshot")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.snapshot = families
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.FromContext(ctx).Error(ctx.Err(), "timed out gathering metrics, serving the previous snapshot")
+	}
+}
@@ -25,17 +25,24 @@ import (
 	"runtime"
 	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/awslabs/operatorpkg/controller"
 	opmetrics "github.com/awslabs/operatorpkg/metrics"
 	"github.com/go-logr/zapr"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	appsv1 "k8s.io/api/apps/v1"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
@@ -53,17 +60,23 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/decision"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	"sigs.k8s.io/karpenter/pkg/operator/logging"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 )
 
 const (
 	appName   = "karpenter"
 	component = "controller"
+
+	// crdCompatibilityPollInterval is how often we check whether incompatible CRDs have been updated
+	crdCompatibilityPollInterval = 30 * time.Second
 )
 
 var (
@@ -83,6 +96,7 @@ var (
 var Version = "unspecified"
 
 func init() {
+	lo.Must0(apiextensionsv1.AddToScheme(scheme.Scheme))
 	opmetrics.RegisterClientMetrics(crmetrics.Registry)
 
 	BuildInfo.Set(1, map[string]string{
@@ -99,6 +113,15 @@ type Operator struct {
 	KubernetesInterface kubernetes.Interface
 	EventRecorder       events.Recorder
 	Clock               clock.Clock
+
+	// tracerProvider is flushed once the manager stops so that spans from the final reconciles are exported
+	tracerProvider *sdktrace.TracerProvider
+
+	mu sync.RWMutex
+	// crdCompatibilityErr is set while the installed CRDs can't be used by this binary. Controllers aren't registered
+	// until it's cleared, so Karpenter doesn't make any changes to the cluster in the meantime.
+	crdCompatibilityErr error
+	pendingControllers  []controller.Controller
 }
 
 // NewOperator instantiates a controller manager or panics
@@ -108,6 +131,7 @@ func NewOperator() (context.Context, *Operator) {
 
 	// Options
 	ctx = injection.WithOptionsOrDie(ctx, options.Injectables...)
+	ctx = injection.WithVersion(ctx, Version)
 
 	// Make the Karpenter binary aware of the container memory limit
 	// https://pkg.go.dev/runtime/debug#SetMemoryLimit
@@ -131,6 +155,17 @@ func NewOperator() (context.Context, *Operator) {
 
 	log.FromContext(ctx).WithValues("version", Version).V(1).Info("discovered karpenter version")
 
+	if options.FromContext(ctx).StreamInitialLists {
+		streamInitialLists()
+	}
+
+	// Tracing
+	var tracerProvider *sdktrace.TracerProvider
+	if endpoint := options.FromContext(ctx).TracingEndpoint; endpoint != "" {
+		tp, err := tracing.NewTracerProvider(ctx, endpoint, Version)
+		tracerProvider = lo.Must(tp, err, "failed to setup tracing")
+	}
+
 	// Manager
 	mgrOpts := ctrl.Options{
 		Logger:                        logging.IgnoreDebugEvents(logger),
@@ -139,8 +174,9 @@ func NewOperator() (context.Context, *Operator) {
 		LeaderElectionNamespace:       options.FromContext(ctx).LeaderElectionNamespace,
 		LeaderElectionResourceLock:    resourcelock.LeasesResourceLock,
 		LeaderElectionReleaseOnCancel: true,
+		// Metrics are served from a snapshot by MetricsServer instead, so that scrapes succeed while controllers are wedged
 		Metrics: server.Options{
-			BindAddress: fmt.Sprintf(":%d", options.FromContext(ctx).MetricsPort),
+			BindAddress: "0",
 		},
 		HealthProbeBindAddress: fmt.Sprintf(":%d", options.FromContext(ctx).HealthProbePort),
 		BaseContext: func() context.Context {
@@ -149,18 +185,30 @@ func NewOperator() (context.Context, *Operator) {
 			return ctx
 		},
 		Cache: cache.Options{
+			// Managed fields aren't used by any controller and account for a large share of the memory held by
+			// informers on big clusters
+			DefaultTransform: cache.TransformStripManagedFields(),
 			ByObject: map[client.Object]cache.ByObject{
 				&coordinationv1.Lease{}: {
 					Field: fields.SelectorFromSet(fields.Set{"metadata.namespace": "kube-node-lease"}),
 				},
+				// Only the DaemonSets' revisions are read, so that the revisions of every StatefulSet aren't cached as well
+				&appsv1.ControllerRevision{}: {
+					Label: labels.NewSelector().Add(lo.FromPtr(lo.Must(labels.NewRequirement(appsv1.DefaultDaemonSetUniqueLabelKey, selection.Exists, nil)))),
+				},
+				// Karpenter is only permitted to read the ProvisioningDecisions in its own namespace
+				&v1alpha1.ProvisioningDecision{}: {
+					Namespaces: map[string]cache.Config{decision.Namespace(): {}},
+				},
 			},
 		},
 	}
+	metricsHandlers := map[string]http.Handler{}
 	if options.FromContext(ctx).EnableProfiling {
 		// TODO @joinnis: Investigate the mgrOpts.PprofBindAddress that would allow native support for pprof
 		// On initial look, it seems like this native pprof doesn't support some of the routes that we have here
 		// like "/debug/pprof/heap" or "/debug/pprof/block"
-		mgrOpts.Metrics.ExtraHandlers = lo.Assign(mgrOpts.Metrics.ExtraHandlers, map[string]http.Handler{
+		metricsHandlers = lo.Assign(metricsHandlers, map[string]http.Handler{
 			"/debug/pprof/":             http.HandlerFunc(pprof.Index),
 			"/debug/pprof/cmdline":      http.HandlerFunc(pprof.Cmdline),
 			"/debug/pprof/profile":      http.HandlerFunc(pprof.Profile),
@@ -177,9 +225,32 @@ func NewOperator() (context.Context, *Operator) {
 	mgr = lo.Must(mgr, err, "failed to setup manager")
 
 	setupIndexers(ctx, mgr)
+	lo.Must0(mgr.Add(NewMetricsServer(fmt.Sprintf(":%d", options.FromContext(ctx).MetricsPort), crmetrics.Registry, metricsHandlers,
+		// Exemplars link latency histograms to traces, so they're only served when traces are exported
+		options.FromContext(ctx).TracingEndpoint != "")), "failed to setup metrics server")
+
+	o := &Operator{
+		Manager:             mgr,
+		KubernetesInterface: kubernetesInterface,
+		EventRecorder:       events.NewRecorder(mgr.GetEventRecorderFor(appName)),
+		Clock:               clock.RealClock{},
+		tracerProvider:      tracerProvider,
+	}
+	// Rather than crash looping when the installed CRDs don't match this binary, e.g. after the binary is rolled back
+	// during an upgrade, we start in a read-only mode and wait for the CRDs to be updated
+	if err := CheckCRDCompatibility(ctx, mgr.GetAPIReader()); err != nil {
+		log.FromContext(ctx).Error(err, "installed crds are incompatible with this version of karpenter, running in read-only mode until they're updated")
+		o.crdCompatibilityErr = err
+	}
 
-	lo.Must0(mgr.AddReadyzCheck("manager", func(req *http.Request) error {
-		return lo.Ternary(mgr.GetCache().WaitForCacheSync(req.Context()), nil, fmt.Errorf("failed to sync caches"))
+	lo.Must0(mgr.AddReadyzCheck("manager", CacheSyncCheck(mgr.GetCache())))
+	lo.Must0(mgr.AddReadyzCheck("crd-compatibility", func(_ *http.Request) error {
+		o.mu.RLock()
+		defer o.mu.RUnlock()
+		if o.crdCompatibilityErr != nil {
+			return fmt.Errorf("running in read-only mode, %w", o.crdCompatibilityErr)
+		}
+		return nil
 	}))
 	lo.Must0(mgr.AddReadyzCheck("crd", func(_ *http.Request) error {
 		objects := []client.Object{&v1.NodePool{}, &v1.NodeClaim{}}
@@ -197,15 +268,17 @@ func NewOperator() (context.Context, *Operator) {
 	lo.Must0(mgr.AddHealthzCheck("healthz", healthz.Ping))
 	lo.Must0(mgr.AddReadyzCheck("readyz", healthz.Ping))
 
-	return ctx, &Operator{
-		Manager:             mgr,
-		KubernetesInterface: kubernetesInterface,
-		EventRecorder:       events.NewRecorder(mgr.GetEventRecorderFor(appName)),
-		Clock:               clock.RealClock{},
-	}
+	return ctx, o
 }
 
 func (o *Operator) WithControllers(ctx context.Context, controllers ...controller.Controller) *Operator {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.crdCompatibilityErr != nil {
+		// These are registered once the CRDs are compatible, see waitForCompatibleCRDs
+		o.pendingControllers = append(o.pendingControllers, controllers...)
+		return o
+	}
 	for _, c := range controllers {
 		lo.Must0(c.Register(ctx, o.Manager))
 	}
@@ -219,7 +292,41 @@ func (o *Operator) Start(ctx context.Context) {
 		defer wg.Done()
 		lo.Must0(o.Manager.Start(ctx))
 	}()
+	o.mu.RLock()
+	degraded := o.crdCompatibilityErr != nil
+	o.mu.RUnlock()
+	if degraded {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.waitForCompatibleCRDs(ctx)
+		}()
+	}
 	wg.Wait()
+	if o.tracerProvider != nil {
+		if err := o.tracerProvider.Shutdown(context.Background()); err != nil {
+			log.FromContext(ctx).Error(err, "failed to flush traces")
+		}
+	}
+}
+
+// waitForCompatibleCRDs polls the installed CRDs until they're compatible and then registers the controllers that
+// were held back while running in read-only mode
+func (o *Operator) waitForCompatibleCRDs(ctx context.Context) {
+	_ = wait.PollUntilContextCancel(ctx, crdCompatibilityPollInterval, false, func(ctx context.Context) (bool, error) {
+		if err := CheckCRDCompatibility(ctx, o.GetAPIReader()); err != nil {
+			log.FromContext(ctx).V(1).Info(fmt.Sprintf("installed crds are still incompatible, %s", err))
+			return false, nil
+		}
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		log.FromContext(ctx).Info("installed crds are compatible, leaving read-only mode")
+		for _, c := range o.pendingControllers {
+			lo.Must0(c.Register(ctx, o.Manager))
+		}
+		o.crdCompatibilityErr, o.pendingControllers = nil, nil
+		return true, nil
+	})
 }
 
 func setupIndexers(ctx context.Context, mgr manager.Manager) {
@@ -255,6 +362,9 @@ func setupIndexers(ctx context.Context, mgr manager.Manager) {
 	handleCRDIndexerError(mgr.GetFieldIndexer().IndexField(ctx, &v1.NodeClaim{}, "spec.nodeClassRef.name", func(o client.Object) []string {
 		return []string{o.(*v1.NodeClaim).Spec.NodeClassRef.Name}
 	}), "failed to setup nodeclaim nodeclassref name indexer")
+	handleCRDIndexerError(mgr.GetFieldIndexer().IndexField(ctx, &v1.NodeClaim{}, "metadata.annotations.evictionPlan", func(o client.Object) []string {
+		return lo.Compact([]string{o.GetAnnotations()[v1.EvictionPlanAnnotationKey]})
+	}), "failed to setup nodeclaim eviction plan indexer")
 
 	handleCRDIndexerError(mgr.GetFieldIndexer().IndexField(ctx, &v1.NodePool{}, "spec.template.spec.nodeClassRef.group", func(o client.Object) []string {
 		return []string{o.(*v1.NodePool).Spec.Template.Spec.NodeClassRef.Group}
@@ -22,16 +22,21 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 	cliflag "k8s.io/component-base/cli/flag"
 
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 )
 
 var (
-	validLogLevels = []string{"", "debug", "info", "error"}
+	validLogLevels            = []string{"", "debug", "info", "error"}
+	validBinPackingStrategies = []string{"FewestNodes", "LowestPrice", "LeastWaste", "Balanced"}
 
 	Injectables = []Injectable{&Options{}}
 )
@@ -43,26 +48,54 @@ type FeatureGates struct {
 
 	SpotToSpotConsolidation bool
 	NodeRepair              bool
+	ZoneRebalance           bool
 }
 
 // Options contains all CLI flags / env vars for karpenter-core. It adheres to the options.Injectable interface.
 type Options struct {
-	ServiceName             string
-	MetricsPort             int
-	HealthProbePort         int
-	KubeClientQPS           int
-	KubeClientBurst         int
-	EnableProfiling         bool
-	DisableLeaderElection   bool
-	LeaderElectionName      string
-	LeaderElectionNamespace string
-	MemoryLimit             int64
-	LogLevel                string
-	LogOutputPaths          string
-	LogErrorOutputPaths     string
-	BatchMaxDuration        time.Duration
-	BatchIdleDuration       time.Duration
-	FeatureGates            FeatureGates
+	ServiceName                   string
+	MetricsPort                   int
+	HealthProbePort               int
+	KubeClientQPS                 int
+	KubeClientBurst               int
+	EnableProfiling               bool
+	DisableLeaderElection         bool
+	LeaderElectionName            string
+	LeaderElectionNamespace       string
+	MemoryLimit                   int64
+	LogLevel                      string
+	LogOutputPaths                string
+	LogErrorOutputPaths           string
+	BatchMaxDuration              time.Duration
+	BatchIdleDuration             time.Duration
+	ExclusiveNodeTTL              time.Duration
+	SimulationMaxCandidateNodes   int
+	SimulationMaxTopologyDomains  int
+	AllowedSchedulerNames         string
+	PreferNewerGenerations        bool
+	RegistrationFailureThreshold  int
+	MaxNodes                      int
+	MaxVCPU                       int
+	ClusterName                   string
+	StreamInitialLists            bool
+	DisruptionProfiles            string
+	LoadBalancerDrainedCondition  string
+	LoadBalancerDrainTimeout      time.Duration
+	ProvenanceSinkURL             string
+	MaxInstanceTypeStaleness      time.Duration
+	PreemptionSimulation          bool
+	DisruptionAdmissionWebhookURL string
+	EmptinessIgnoredPods          string
+	ProvisioningDecisionTTL       time.Duration
+	DisruptionObserveOnly         bool
+	DisruptionEvictionOrder       string
+	BinPackingStrategy            string
+	DisruptionEvaluationWorkers   int
+	SchedulingWorkers             int
+	TracingEndpoint               string
+	UnavailableOfferingsTTL       time.Duration
+	MaxSchedulingSafetyMargin     int
+	FeatureGates                  FeatureGates
 }
 
 type FlagSet struct {
@@ -98,7 +131,34 @@ func (o *Options) AddFlags(fs *FlagSet) {
 	fs.StringVar(&o.LogErrorOutputPaths, "log-error-output-paths", env.WithDefaultString("LOG_ERROR_OUTPUT_PATHS", "stderr"), "Optional comma separated paths for logging error output")
 	fs.DurationVar(&o.BatchMaxDuration, "batch-max-duration", env.WithDefaultDuration("BATCH_MAX_DURATION", 10*time.Second), "The maximum length of a batch window. The longer this is, the more pods we can consider for provisioning at one time which usually results in fewer but larger nodes.")
 	fs.DurationVar(&o.BatchIdleDuration, "batch-idle-duration", env.WithDefaultDuration("BATCH_IDLE_DURATION", time.Second), "The maximum amount of time with no new pending pods that if exceeded ends the current batching window. If pods arrive faster than this time, the batching window will be extended up to the maxDuration. If they arrive slower, the pods will be batched separately.")
-	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "NodeRepair=false,SpotToSpotConsolidation=false"), "Optional features can be enabled / disabled using feature gates. Current options are: SpotToSpotConsolidation")
+	fs.DurationVar(&o.ExclusiveNodeTTL, "exclusive-node-ttl", env.WithDefaultDuration("EXCLUSIVE_NODE_TTL", time.Hour), "The duration that a node launched for a pod with the karpenter.sh/exclusive-node annotation stays tainted against other pods.")
+	fs.IntVar(&o.SimulationMaxCandidateNodes, "simulation-max-candidate-nodes", env.WithDefaultInt("SIMULATION_MAX_CANDIDATE_NODES", 0), "The maximum number of existing nodes considered as scheduling targets in a single scheduling simulation. When exceeded, in-flight nodes are kept first and the remaining nodes are dropped from the simulation. Set to 0 for no limit.")
+	fs.IntVar(&o.SimulationMaxTopologyDomains, "simulation-max-topology-domains", env.WithDefaultInt("SIMULATION_MAX_TOPOLOGY_DOMAINS", 0), "The maximum number of domains tracked per topology group for existing nodes in a single scheduling simulation. Pods with topology constraints won't be simulated against existing nodes whose domains exceed this bound. Set to 0 for no limit.")
+	fs.StringVar(&o.AllowedSchedulerNames, "allowed-scheduler-names", env.WithDefaultString("ALLOWED_SCHEDULER_NAMES", ""), "Optional comma separated names of non-default schedulers whose pending pods Karpenter should provision capacity for, using the pending pod semantics registered for each scheduler")
+	fs.BoolVarWithEnv(&o.PreferNewerGenerations, "prefer-newer-generations", "PREFER_NEWER_GENERATIONS", false, "When instance types are the same price, prefer newer instance generations over older ones before comparing names.")
+	fs.IntVar(&o.RegistrationFailureThreshold, "registration-failure-threshold", env.WithDefaultInt("REGISTRATION_FAILURE_THRESHOLD", 0), "The number of registration timeouts for instances the cloud provider reports as running before their offering (instance type, zone and capacity type) is blocked from launches for an hour. Set to 0 to always relaunch the same offering.")
+	fs.IntVar(&o.MaxNodes, "max-nodes", env.WithDefaultInt("MAX_NODES", 0), "The maximum number of Karpenter-managed nodes across all NodePools. NodeClaims that would exceed it aren't created. Set to 0 for no limit.")
+	fs.IntVar(&o.MaxVCPU, "max-vcpu", env.WithDefaultInt("MAX_VCPU", 0), "The maximum number of vCPUs of Karpenter-managed nodes across all NodePools. NodeClaims that would exceed it aren't created. Set to 0 for no limit.")
+	fs.StringVar(&o.ClusterName, "cluster-name", env.WithDefaultString("CLUSTER_NAME", ""), "The name of the cluster, passed to CloudProviders as a NodeClaim template variable.")
+	fs.BoolVarWithEnv(&o.StreamInitialLists, "stream-initial-lists", "STREAM_INITIAL_LISTS", false, "Stream the initial state of watched resources from the API server's watch cache rather than issuing a single large list. This bounds the memory used by both the API server and the controller while syncing large clusters, and falls back to listing if the API server doesn't support it.")
+	fs.StringVar(&o.DisruptionProfiles, "disruption-profiles", env.WithDefaultString("DISRUPTION_PROFILES", ""), "Optional comma separated disruption profiles of the form <name>=<consolidateAfter>. NodePools labeled with karpenter.sh/disruption-profile=<name> that leave consolidateAfter at its default of 0s have it set to the profile's value.")
+	fs.StringVar(&o.LoadBalancerDrainedCondition, "load-balancer-drained-condition", env.WithDefaultString("LOAD_BALANCER_DRAINED_CONDITION", ""), "Optional node condition type or label key set by an external load balancer controller once it has finished draining connections to a node. When set, nodes running pods that back Services of type LoadBalancer aren't drained until the condition is True or the label is \"true\".")
+	fs.DurationVar(&o.LoadBalancerDrainTimeout, "load-balancer-drain-timeout", env.WithDefaultDuration("LOAD_BALANCER_DRAIN_TIMEOUT", 5*time.Minute), "The maximum amount of time to wait for the load-balancer-drained-condition after a node starts terminating before draining it anyway.")
+	fs.StringVar(&o.ProvenanceSinkURL, "provenance-sink-url", env.WithDefaultString("PROVENANCE_SINK_URL", ""), "Optional URL that the provenance of every created NodeClaim is POSTed to as JSON, for recording in an external store. The provenance isn't signed.")
+	fs.DurationVar(&o.MaxInstanceTypeStaleness, "max-instance-type-staleness", env.WithDefaultDuration("MAX_INSTANCE_TYPE_STALENESS", 30*time.Minute), "The maximum age of cached instance types that provisioning will launch from when the CloudProvider can only partially resolve them. Set to 0s to stop provisioning from NodePools whose instance types are stale.")
+	fs.BoolVarWithEnv(&o.PreemptionSimulation, "preemption-simulation", "PREEMPTION_SIMULATION", false, "Simulate kube-scheduler preemption and skip provisioning for pending pods which can schedule by preempting lower priority pods on existing nodes.")
+	fs.StringVar(&o.DisruptionAdmissionWebhookURL, "disruption-admission-webhook-url", env.WithDefaultString("DISRUPTION_ADMISSION_WEBHOOK_URL", ""), "Optional URL that every planned disruption command is POSTed to as JSON before it's executed. The webhook can deny or delay the command, and commands are denied if it can't be reached.")
+	fs.StringVar(&o.EmptinessIgnoredPods, "emptiness-ignored-pods", env.WithDefaultString("EMPTINESS_IGNORED_PODS", ""), "Optional semicolon separated pod selectors of the form [<namespace>:]<label-selector>. Reschedulable pods matching any selector, like monitoring agents deployed as Deployments, don't keep a node from being considered empty. Omitting the namespace matches pods in every namespace, and an empty label selector matches every pod in the namespace.")
+	fs.DurationVar(&o.ProvisioningDecisionTTL, "provisioning-decision-ttl", env.WithDefaultDuration("PROVISIONING_DECISION_TTL", 0), "How long ProvisioningDecisions recording each provisioning and disruption decision are kept in Karpenter's namespace before they're garbage collected. Set to 0s to stop recording decisions.")
+	fs.BoolVarWithEnv(&o.DisruptionObserveOnly, "disruption-observe-only", "DISRUPTION_OBSERVE_ONLY", false, "Run disruption for every NodePool as if its disruption mode were ObserveOnly. Karpenter reports the nodes it would consolidate, drift or expire through events, metrics and NodeClaim conditions, but never taints or deletes them.")
+	fs.StringVar(&o.DisruptionEvictionOrder, "disruption-eviction-order", env.WithDefaultString("DISRUPTION_EVICTION_ORDER", ""), "Comma separated pod QoS classes in the order that pods are evicted from nodes drained for voluntary disruption, like consolidation and drift. Each QoS class is evicted once the pods of the previous ones are gone, and consolidation prefers disrupting nodes whose pods' QoS classes are evicted first. Pods of unlisted QoS classes are evicted last. Defaults to evicting pods regardless of their QoS class.")
+	fs.StringVar(&o.BinPackingStrategy, "bin-packing-strategy", env.WithDefaultString("BIN_PACKING_STRATEGY", "FewestNodes"), "How the scheduler packs pods onto the NodeClaims that it launches for NodePools that don't set spec.binPacking. Can be one of 'FewestNodes', 'LowestPrice', 'LeastWaste', or 'Balanced'.")
+	fs.IntVar(&o.DisruptionEvaluationWorkers, "disruption-evaluation-workers", env.WithDefaultInt("DISRUPTION_EVALUATION_WORKERS", 10), "The maximum number of disruption candidates that are evaluated at once, both when building candidates from nodes and when simulating single-node consolidation. Candidates are still considered in the same order, so this only changes how long a disruption cycle takes.")
+	fs.IntVar(&o.SchedulingWorkers, "scheduling-workers", env.WithDefaultInt("SCHEDULING_WORKERS", 1), "The maximum number of schedulers that solve a provisioning batch at once. Pending pods are partitioned into groups whose topology spread constraints and pod affinities don't select each other, and each group is scheduled in parallel. Pods in different groups aren't packed onto the same new nodes, and the batch is scheduled again as a whole if groups contend for existing nodes, NodePool limits or reserved capacity. Set to 1 to schedule every batch as a whole.")
+	fs.StringVar(&o.TracingEndpoint, "tracing-endpoint", env.WithDefaultString("TRACING_ENDPOINT", ""), "Optional OTLP/HTTP endpoint URL, like http://otel-collector:4318, that OpenTelemetry traces of provisioning and disruption are exported to. Sampling can be configured with the standard OTEL_TRACES_SAMPLER and OTEL_TRACES_SAMPLER_ARG environment variables, and scrapers that request the OpenMetrics format are served provisioning and disruption latency histograms with exemplars that link to sampled traces. Tracing is disabled when unset.")
+	fs.DurationVar(&o.UnavailableOfferingsTTL, "unavailable-offerings-ttl", env.WithDefaultDuration("UNAVAILABLE_OFFERINGS_TTL", 3*time.Minute), "How long an offering, the combination of an instance type, zone and capacity type, is skipped by scheduling after the CloudProvider reports insufficient capacity for it. Set to 0s to keep retrying offerings that were out of capacity.")
+	fs.IntVar(&o.MaxSchedulingSafetyMargin, "max-scheduling-safety-margin", env.WithDefaultInt("MAX_SCHEDULING_SAFETY_MARGIN", 0), "The maximum percentage that the CPU and memory requests of pods are scaled up by when they're packed onto new NodeClaims. The margin is widened while pods are often bound to different nodes than scheduling simulation predicted because they didn't fit, and narrowed again once they do. Defaults to 0, which never applies a margin.")
+	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "NodeRepair=false,SpotToSpotConsolidation=false,ZoneRebalance=false"), "Optional features can be enabled / disabled using feature gates. Current options are: SpotToSpotConsolidation, ZoneRebalance")
 }
 
 func (o *Options) Parse(fs *FlagSet, args ...string) error {
@@ -112,11 +172,38 @@ func (o *Options) Parse(fs *FlagSet, args ...string) error {
 	if !lo.Contains(validLogLevels, o.LogLevel) {
 		return fmt.Errorf("validating cli flags / env vars, invalid LOG_LEVEL %q", o.LogLevel)
 	}
+	if !lo.Contains(validBinPackingStrategies, o.BinPackingStrategy) {
+		return fmt.Errorf("validating cli flags / env vars, invalid BIN_PACKING_STRATEGY %q", o.BinPackingStrategy)
+	}
+	if o.DisruptionEvaluationWorkers < 1 {
+		return fmt.Errorf("validating cli flags / env vars, DISRUPTION_EVALUATION_WORKERS must be at least 1, got %d", o.DisruptionEvaluationWorkers)
+	}
+	if o.SchedulingWorkers < 1 {
+		return fmt.Errorf("validating cli flags / env vars, SCHEDULING_WORKERS must be at least 1, got %d", o.SchedulingWorkers)
+	}
+	if o.MaxSchedulingSafetyMargin < 0 || o.MaxSchedulingSafetyMargin > 100 {
+		return fmt.Errorf("validating cli flags / env vars, MAX_SCHEDULING_SAFETY_MARGIN must be between 0 and 100, got %d", o.MaxSchedulingSafetyMargin)
+	}
+	if o.SimulationMaxCandidateNodes < 0 {
+		return fmt.Errorf("validating cli flags / env vars, SIMULATION_MAX_CANDIDATE_NODES must be at least 0, got %d", o.SimulationMaxCandidateNodes)
+	}
+	if o.SimulationMaxTopologyDomains < 0 {
+		return fmt.Errorf("validating cli flags / env vars, SIMULATION_MAX_TOPOLOGY_DOMAINS must be at least 0, got %d", o.SimulationMaxTopologyDomains)
+	}
 	gates, err := ParseFeatureGates(o.FeatureGates.inputStr)
 	if err != nil {
 		return fmt.Errorf("parsing feature gates, %w", err)
 	}
 	o.FeatureGates = gates
+	if _, err := ParseDisruptionProfiles(o.DisruptionProfiles); err != nil {
+		return fmt.Errorf("parsing disruption profiles, %w", err)
+	}
+	if _, err := ParsePodSelectors(o.EmptinessIgnoredPods); err != nil {
+		return fmt.Errorf("parsing emptiness ignored pods, %w", err)
+	}
+	if _, err := ParseQOSOrder(o.DisruptionEvictionOrder); err != nil {
+		return fmt.Errorf("parsing disruption eviction order, %w", err)
+	}
 	return nil
 }
 
@@ -139,10 +226,88 @@ func ParseFeatureGates(gateStr string) (FeatureGates, error) {
 	if val, ok := gateMap["SpotToSpotConsolidation"]; ok {
 		gates.SpotToSpotConsolidation = val
 	}
+	if val, ok := gateMap["ZoneRebalance"]; ok {
+		gates.ZoneRebalance = val
+	}
 
 	return gates, nil
 }
 
+// ParseDisruptionProfiles parses a comma separated list of <name>=<consolidateAfter> profiles, where consolidateAfter
+// is either a duration or "Never"
+func ParseDisruptionProfiles(profileStr string) (map[string]string, error) {
+	profiles := map[string]string{}
+	if err := cliflag.NewMapStringString(&profiles).Set(profileStr); err != nil {
+		return nil, err
+	}
+	for name, consolidateAfter := range profiles {
+		if consolidateAfter == "Never" {
+			continue
+		}
+		if _, err := time.ParseDuration(consolidateAfter); err != nil {
+			return nil, fmt.Errorf("invalid consolidateAfter %q for profile %q, %w", consolidateAfter, name, err)
+		}
+	}
+	return profiles, nil
+}
+
+// PodSelector selects pods by namespace and labels. An empty Namespace selects pods in every namespace.
+type PodSelector struct {
+	Namespace string
+	Selector  labels.Selector
+}
+
+func (p PodSelector) Matches(namespace string, podLabels map[string]string) bool {
+	return (p.Namespace == "" || p.Namespace == namespace) && p.Selector.Matches(labels.Set(podLabels))
+}
+
+// ParsePodSelectors parses a semicolon separated list of [<namespace>:]<label-selector> pod selectors. Semicolons are
+// used as the separator since label selectors are themselves comma separated.
+func ParsePodSelectors(selectorStr string) ([]PodSelector, error) {
+	var selectors []PodSelector
+	for _, term := range strings.Split(selectorStr, ";") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		namespace, selector, found := strings.Cut(term, ":")
+		if !found {
+			namespace, selector = "", term
+		}
+		if namespace != "" {
+			if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid namespace %q in pod selector %q, %s", namespace, term, strings.Join(errs, ", "))
+			}
+		}
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector in pod selector %q, %w", term, err)
+		}
+		selectors = append(selectors, PodSelector{Namespace: namespace, Selector: parsed})
+	}
+	return selectors, nil
+}
+
+// ParseQOSOrder parses a comma separated list of distinct pod QoS classes
+func ParseQOSOrder(orderStr string) ([]corev1.PodQOSClass, error) {
+	var order []corev1.PodQOSClass
+	for _, class := range strings.Split(orderStr, ",") {
+		class = strings.TrimSpace(class)
+		if class == "" {
+			continue
+		}
+		qosClass := corev1.PodQOSClass(class)
+		if !lo.Contains([]corev1.PodQOSClass{corev1.PodQOSBestEffort, corev1.PodQOSBurstable, corev1.PodQOSGuaranteed}, qosClass) {
+			return nil, fmt.Errorf("invalid QoS class %q", class)
+		}
+		if lo.Contains(order, qosClass) {
+			return nil, fmt.Errorf("duplicate QoS class %q", class)
+		}
+		order = append(order, qosClass)
+	}
+	return order, nil
+}
+
 func ToContext(ctx context.Context, opts *Options) context.Context {
 	return context.WithValue(ctx, optionsKey{}, opts)
 }
@@ -26,6 +26,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
 
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
@@ -58,6 +59,33 @@ var _ = Describe("Options", func() {
 		"LOG_ERROR_OUTPUT_PATHS",
 		"BATCH_MAX_DURATION",
 		"BATCH_IDLE_DURATION",
+		"EXCLUSIVE_NODE_TTL",
+		"SIMULATION_MAX_CANDIDATE_NODES",
+		"SIMULATION_MAX_TOPOLOGY_DOMAINS",
+		"ALLOWED_SCHEDULER_NAMES",
+		"PREFER_NEWER_GENERATIONS",
+		"REGISTRATION_FAILURE_THRESHOLD",
+		"MAX_NODES",
+		"MAX_VCPU",
+		"CLUSTER_NAME",
+		"STREAM_INITIAL_LISTS",
+		"DISRUPTION_PROFILES",
+		"LOAD_BALANCER_DRAINED_CONDITION",
+		"LOAD_BALANCER_DRAIN_TIMEOUT",
+		"PROVENANCE_SINK_URL",
+		"MAX_INSTANCE_TYPE_STALENESS",
+		"PREEMPTION_SIMULATION",
+		"DISRUPTION_ADMISSION_WEBHOOK_URL",
+		"EMPTINESS_IGNORED_PODS",
+		"PROVISIONING_DECISION_TTL",
+		"DISRUPTION_OBSERVE_ONLY",
+		"DISRUPTION_EVICTION_ORDER",
+		"BIN_PACKING_STRATEGY",
+		"DISRUPTION_EVALUATION_WORKERS",
+		"SCHEDULING_WORKERS",
+		"TRACING_ENDPOINT",
+		"UNAVAILABLE_OFFERINGS_TTL",
+		"MAX_SCHEDULING_SAFETY_MARGIN",
 		"FEATURE_GATES",
 	}
 
@@ -95,24 +123,52 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr(""),
-				MetricsPort:             lo.ToPtr(8080),
-				HealthProbePort:         lo.ToPtr(8081),
-				KubeClientQPS:           lo.ToPtr(200),
-				KubeClientBurst:         lo.ToPtr(300),
-				EnableProfiling:         lo.ToPtr(false),
-				DisableLeaderElection:   lo.ToPtr(false),
-				LeaderElectionName:      lo.ToPtr("karpenter-leader-election"),
-				LeaderElectionNamespace: lo.ToPtr(""),
-				MemoryLimit:             lo.ToPtr[int64](-1),
-				LogLevel:                lo.ToPtr("info"),
-				LogOutputPaths:          lo.ToPtr("stdout"),
-				LogErrorOutputPaths:     lo.ToPtr("stderr"),
-				BatchMaxDuration:        lo.ToPtr(10 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(time.Second),
+				ServiceName:                   lo.ToPtr(""),
+				MetricsPort:                   lo.ToPtr(8080),
+				HealthProbePort:               lo.ToPtr(8081),
+				KubeClientQPS:                 lo.ToPtr(200),
+				KubeClientBurst:               lo.ToPtr(300),
+				EnableProfiling:               lo.ToPtr(false),
+				DisableLeaderElection:         lo.ToPtr(false),
+				LeaderElectionName:            lo.ToPtr("karpenter-leader-election"),
+				LeaderElectionNamespace:       lo.ToPtr(""),
+				MemoryLimit:                   lo.ToPtr[int64](-1),
+				LogLevel:                      lo.ToPtr("info"),
+				LogOutputPaths:                lo.ToPtr("stdout"),
+				LogErrorOutputPaths:           lo.ToPtr("stderr"),
+				BatchMaxDuration:              lo.ToPtr(10 * time.Second),
+				BatchIdleDuration:             lo.ToPtr(time.Second),
+				ExclusiveNodeTTL:              lo.ToPtr(time.Hour),
+				SimulationMaxCandidateNodes:   lo.ToPtr(0),
+				SimulationMaxTopologyDomains:  lo.ToPtr(0),
+				AllowedSchedulerNames:         lo.ToPtr(""),
+				PreferNewerGenerations:        lo.ToPtr(false),
+				RegistrationFailureThreshold:  lo.ToPtr(0),
+				MaxNodes:                      lo.ToPtr(0),
+				MaxVCPU:                       lo.ToPtr(0),
+				ClusterName:                   lo.ToPtr(""),
+				StreamInitialLists:            lo.ToPtr(false),
+				DisruptionProfiles:            lo.ToPtr(""),
+				LoadBalancerDrainedCondition:  lo.ToPtr(""),
+				LoadBalancerDrainTimeout:      lo.ToPtr(5 * time.Minute),
+				ProvenanceSinkURL:             lo.ToPtr(""),
+				MaxInstanceTypeStaleness:      lo.ToPtr(30 * time.Minute),
+				PreemptionSimulation:          lo.ToPtr(false),
+				DisruptionAdmissionWebhookURL: lo.ToPtr(""),
+				EmptinessIgnoredPods:          lo.ToPtr(""),
+				ProvisioningDecisionTTL:       lo.ToPtr(time.Duration(0)),
+				DisruptionObserveOnly:         lo.ToPtr(false),
+				DisruptionEvictionOrder:       lo.ToPtr(""),
+				BinPackingStrategy:            lo.ToPtr("FewestNodes"),
+				DisruptionEvaluationWorkers:   lo.ToPtr(10),
+				SchedulingWorkers:             lo.ToPtr(1),
+				TracingEndpoint:               lo.ToPtr(""),
+				UnavailableOfferingsTTL:       lo.ToPtr(3 * time.Minute),
+				MaxSchedulingSafetyMargin:     lo.ToPtr(0),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(false),
 					SpotToSpotConsolidation: lo.ToPtr(false),
+					ZoneRebalance:           lo.ToPtr(false),
 				},
 			}))
 		})
@@ -137,25 +193,79 @@ var _ = Describe("Options", func() {
 				"--log-error-output-paths", "/etc/k8s/testerror",
 				"--batch-max-duration", "5s",
 				"--batch-idle-duration", "5s",
+				"--exclusive-node-ttl", "5m",
+				"--simulation-max-candidate-nodes", "100",
+				"--simulation-max-topology-domains", "1000",
+				"--allowed-scheduler-names", "volcano,yunikorn",
+				"--prefer-newer-generations",
+				"--registration-failure-threshold", "3",
+				"--max-nodes", "100",
+				"--max-vcpu", "1000",
+				"--cluster-name", "my-cluster",
+				"--stream-initial-lists",
+				"--disruption-profiles", "batch=1h,service=5m",
+				"--load-balancer-drained-condition", "example.com/lb-drained",
+				"--load-balancer-drain-timeout", "2m",
+				"--provenance-sink-url", "https://attestation.example.com/nodeclaims",
+				"--max-instance-type-staleness", "10m",
+				"--preemption-simulation",
+				"--disruption-admission-webhook-url", "https://change-management.example.com/disruptions",
+				"--emptiness-ignored-pods", "monitoring:app=agent",
+				"--provisioning-decision-ttl", "24h",
+				"--disruption-observe-only",
+				"--disruption-eviction-order", "Guaranteed,Burstable",
+				"--bin-packing-strategy", "LeastWaste",
+				"--disruption-evaluation-workers", "4",
+				"--scheduling-workers", "4",
+				"--tracing-endpoint", "http://otel-collector:4318",
+				"--unavailable-offerings-ttl", "10m",
+				"--max-scheduling-safety-margin", "30",
 				"--feature-gates", "SpotToSpotConsolidation=true,NodeRepair=true",
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("cli"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-controller"),
-				LeaderElectionNamespace: lo.ToPtr("karpenter"),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                   lo.ToPtr("cli"),
+				MetricsPort:                   lo.ToPtr(0),
+				HealthProbePort:               lo.ToPtr(0),
+				KubeClientQPS:                 lo.ToPtr(0),
+				KubeClientBurst:               lo.ToPtr(0),
+				EnableProfiling:               lo.ToPtr(true),
+				DisableLeaderElection:         lo.ToPtr(true),
+				LeaderElectionName:            lo.ToPtr("karpenter-controller"),
+				LeaderElectionNamespace:       lo.ToPtr("karpenter"),
+				MemoryLimit:                   lo.ToPtr[int64](0),
+				LogLevel:                      lo.ToPtr("debug"),
+				LogOutputPaths:                lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:           lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:              lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:             lo.ToPtr(5 * time.Second),
+				ExclusiveNodeTTL:              lo.ToPtr(5 * time.Minute),
+				SimulationMaxCandidateNodes:   lo.ToPtr(100),
+				SimulationMaxTopologyDomains:  lo.ToPtr(1000),
+				AllowedSchedulerNames:         lo.ToPtr("volcano,yunikorn"),
+				PreferNewerGenerations:        lo.ToPtr(true),
+				RegistrationFailureThreshold:  lo.ToPtr(3),
+				MaxNodes:                      lo.ToPtr(100),
+				MaxVCPU:                       lo.ToPtr(1000),
+				ClusterName:                   lo.ToPtr("my-cluster"),
+				StreamInitialLists:            lo.ToPtr(true),
+				DisruptionProfiles:            lo.ToPtr("batch=1h,service=5m"),
+				LoadBalancerDrainedCondition:  lo.ToPtr("example.com/lb-drained"),
+				LoadBalancerDrainTimeout:      lo.ToPtr(2 * time.Minute),
+				ProvenanceSinkURL:             lo.ToPtr("https://attestation.example.com/nodeclaims"),
+				MaxInstanceTypeStaleness:      lo.ToPtr(10 * time.Minute),
+				PreemptionSimulation:          lo.ToPtr(true),
+				DisruptionAdmissionWebhookURL: lo.ToPtr("https://change-management.example.com/disruptions"),
+				EmptinessIgnoredPods:          lo.ToPtr("monitoring:app=agent"),
+				ProvisioningDecisionTTL:       lo.ToPtr(24 * time.Hour),
+				DisruptionObserveOnly:         lo.ToPtr(true),
+				DisruptionEvictionOrder:       lo.ToPtr("Guaranteed,Burstable"),
+				BinPackingStrategy:            lo.ToPtr("LeastWaste"),
+				DisruptionEvaluationWorkers:   lo.ToPtr(4),
+				SchedulingWorkers:             lo.ToPtr(4),
+				TracingEndpoint:               lo.ToPtr("http://otel-collector:4318"),
+				UnavailableOfferingsTTL:       lo.ToPtr(10 * time.Minute),
+				MaxSchedulingSafetyMargin:     lo.ToPtr(30),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(true),
 					SpotToSpotConsolidation: lo.ToPtr(true),
@@ -179,6 +289,33 @@ var _ = Describe("Options", func() {
 			os.Setenv("LOG_ERROR_OUTPUT_PATHS", "/etc/k8s/testerror")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
+			os.Setenv("EXCLUSIVE_NODE_TTL", "5m")
+			os.Setenv("SIMULATION_MAX_CANDIDATE_NODES", "100")
+			os.Setenv("SIMULATION_MAX_TOPOLOGY_DOMAINS", "1000")
+			os.Setenv("ALLOWED_SCHEDULER_NAMES", "volcano,yunikorn")
+			os.Setenv("PREFER_NEWER_GENERATIONS", "true")
+			os.Setenv("REGISTRATION_FAILURE_THRESHOLD", "3")
+			os.Setenv("MAX_NODES", "100")
+			os.Setenv("MAX_VCPU", "1000")
+			os.Setenv("CLUSTER_NAME", "my-cluster")
+			os.Setenv("STREAM_INITIAL_LISTS", "true")
+			os.Setenv("DISRUPTION_PROFILES", "batch=1h,service=5m")
+			os.Setenv("LOAD_BALANCER_DRAINED_CONDITION", "example.com/lb-drained")
+			os.Setenv("LOAD_BALANCER_DRAIN_TIMEOUT", "2m")
+			os.Setenv("PROVENANCE_SINK_URL", "https://attestation.example.com/nodeclaims")
+			os.Setenv("MAX_INSTANCE_TYPE_STALENESS", "10m")
+			os.Setenv("PREEMPTION_SIMULATION", "true")
+			os.Setenv("DISRUPTION_ADMISSION_WEBHOOK_URL", "https://change-management.example.com/disruptions")
+			os.Setenv("EMPTINESS_IGNORED_PODS", "monitoring:app=agent")
+			os.Setenv("PROVISIONING_DECISION_TTL", "24h")
+			os.Setenv("DISRUPTION_OBSERVE_ONLY", "true")
+			os.Setenv("DISRUPTION_EVICTION_ORDER", "Guaranteed,Burstable")
+			os.Setenv("BIN_PACKING_STRATEGY", "LeastWaste")
+			os.Setenv("DISRUPTION_EVALUATION_WORKERS", "4")
+			os.Setenv("SCHEDULING_WORKERS", "4")
+			os.Setenv("TRACING_ENDPOINT", "http://otel-collector:4318")
+			os.Setenv("UNAVAILABLE_OFFERINGS_TTL", "10m")
+			os.Setenv("MAX_SCHEDULING_SAFETY_MARGIN", "30")
 			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
@@ -187,21 +324,48 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("env"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-controller"),
-				LeaderElectionNamespace: lo.ToPtr("karpenter"),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                   lo.ToPtr("env"),
+				MetricsPort:                   lo.ToPtr(0),
+				HealthProbePort:               lo.ToPtr(0),
+				KubeClientQPS:                 lo.ToPtr(0),
+				KubeClientBurst:               lo.ToPtr(0),
+				EnableProfiling:               lo.ToPtr(true),
+				DisableLeaderElection:         lo.ToPtr(true),
+				LeaderElectionName:            lo.ToPtr("karpenter-controller"),
+				LeaderElectionNamespace:       lo.ToPtr("karpenter"),
+				MemoryLimit:                   lo.ToPtr[int64](0),
+				LogLevel:                      lo.ToPtr("debug"),
+				LogOutputPaths:                lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:           lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:              lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:             lo.ToPtr(5 * time.Second),
+				ExclusiveNodeTTL:              lo.ToPtr(5 * time.Minute),
+				SimulationMaxCandidateNodes:   lo.ToPtr(100),
+				SimulationMaxTopologyDomains:  lo.ToPtr(1000),
+				AllowedSchedulerNames:         lo.ToPtr("volcano,yunikorn"),
+				PreferNewerGenerations:        lo.ToPtr(true),
+				RegistrationFailureThreshold:  lo.ToPtr(3),
+				MaxNodes:                      lo.ToPtr(100),
+				MaxVCPU:                       lo.ToPtr(1000),
+				ClusterName:                   lo.ToPtr("my-cluster"),
+				StreamInitialLists:            lo.ToPtr(true),
+				DisruptionProfiles:            lo.ToPtr("batch=1h,service=5m"),
+				LoadBalancerDrainedCondition:  lo.ToPtr("example.com/lb-drained"),
+				LoadBalancerDrainTimeout:      lo.ToPtr(2 * time.Minute),
+				ProvenanceSinkURL:             lo.ToPtr("https://attestation.example.com/nodeclaims"),
+				MaxInstanceTypeStaleness:      lo.ToPtr(10 * time.Minute),
+				PreemptionSimulation:          lo.ToPtr(true),
+				DisruptionAdmissionWebhookURL: lo.ToPtr("https://change-management.example.com/disruptions"),
+				EmptinessIgnoredPods:          lo.ToPtr("monitoring:app=agent"),
+				ProvisioningDecisionTTL:       lo.ToPtr(24 * time.Hour),
+				DisruptionObserveOnly:         lo.ToPtr(true),
+				DisruptionEvictionOrder:       lo.ToPtr("Guaranteed,Burstable"),
+				BinPackingStrategy:            lo.ToPtr("LeastWaste"),
+				DisruptionEvaluationWorkers:   lo.ToPtr(4),
+				SchedulingWorkers:             lo.ToPtr(4),
+				TracingEndpoint:               lo.ToPtr("http://otel-collector:4318"),
+				UnavailableOfferingsTTL:       lo.ToPtr(10 * time.Minute),
+				MaxSchedulingSafetyMargin:     lo.ToPtr(30),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(true),
 					SpotToSpotConsolidation: lo.ToPtr(true),
@@ -220,6 +384,33 @@ var _ = Describe("Options", func() {
 			os.Setenv("LOG_LEVEL", "debug")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
+			os.Setenv("EXCLUSIVE_NODE_TTL", "5m")
+			os.Setenv("SIMULATION_MAX_CANDIDATE_NODES", "100")
+			os.Setenv("SIMULATION_MAX_TOPOLOGY_DOMAINS", "1000")
+			os.Setenv("ALLOWED_SCHEDULER_NAMES", "volcano,yunikorn")
+			os.Setenv("PREFER_NEWER_GENERATIONS", "true")
+			os.Setenv("REGISTRATION_FAILURE_THRESHOLD", "3")
+			os.Setenv("MAX_NODES", "100")
+			os.Setenv("MAX_VCPU", "1000")
+			os.Setenv("CLUSTER_NAME", "my-cluster")
+			os.Setenv("STREAM_INITIAL_LISTS", "true")
+			os.Setenv("DISRUPTION_PROFILES", "batch=1h,service=5m")
+			os.Setenv("LOAD_BALANCER_DRAINED_CONDITION", "example.com/lb-drained")
+			os.Setenv("LOAD_BALANCER_DRAIN_TIMEOUT", "2m")
+			os.Setenv("PROVENANCE_SINK_URL", "https://attestation.example.com/nodeclaims")
+			os.Setenv("MAX_INSTANCE_TYPE_STALENESS", "10m")
+			os.Setenv("PREEMPTION_SIMULATION", "true")
+			os.Setenv("DISRUPTION_ADMISSION_WEBHOOK_URL", "https://change-management.example.com/disruptions")
+			os.Setenv("EMPTINESS_IGNORED_PODS", "monitoring:app=agent")
+			os.Setenv("PROVISIONING_DECISION_TTL", "24h")
+			os.Setenv("DISRUPTION_OBSERVE_ONLY", "true")
+			os.Setenv("DISRUPTION_EVICTION_ORDER", "Guaranteed,Burstable")
+			os.Setenv("BIN_PACKING_STRATEGY", "LeastWaste")
+			os.Setenv("DISRUPTION_EVALUATION_WORKERS", "4")
+			os.Setenv("SCHEDULING_WORKERS", "4")
+			os.Setenv("TRACING_ENDPOINT", "http://otel-collector:4318")
+			os.Setenv("UNAVAILABLE_OFFERINGS_TTL", "10m")
+			os.Setenv("MAX_SCHEDULING_SAFETY_MARGIN", "30")
 			os.Setenv("FEATURE_GATES", "SpotToSpotConsolidation=true,NodeRepair=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
@@ -233,21 +424,48 @@ var _ = Describe("Options", func() {
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:             lo.ToPtr("cli"),
-				MetricsPort:             lo.ToPtr(0),
-				HealthProbePort:         lo.ToPtr(0),
-				KubeClientQPS:           lo.ToPtr(0),
-				KubeClientBurst:         lo.ToPtr(0),
-				EnableProfiling:         lo.ToPtr(true),
-				DisableLeaderElection:   lo.ToPtr(true),
-				LeaderElectionName:      lo.ToPtr("karpenter-leader-election"),
-				LeaderElectionNamespace: lo.ToPtr(""),
-				MemoryLimit:             lo.ToPtr[int64](0),
-				LogLevel:                lo.ToPtr("debug"),
-				LogOutputPaths:          lo.ToPtr("/etc/k8s/test"),
-				LogErrorOutputPaths:     lo.ToPtr("/etc/k8s/testerror"),
-				BatchMaxDuration:        lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:       lo.ToPtr(5 * time.Second),
+				ServiceName:                   lo.ToPtr("cli"),
+				MetricsPort:                   lo.ToPtr(0),
+				HealthProbePort:               lo.ToPtr(0),
+				KubeClientQPS:                 lo.ToPtr(0),
+				KubeClientBurst:               lo.ToPtr(0),
+				EnableProfiling:               lo.ToPtr(true),
+				DisableLeaderElection:         lo.ToPtr(true),
+				LeaderElectionName:            lo.ToPtr("karpenter-leader-election"),
+				LeaderElectionNamespace:       lo.ToPtr(""),
+				MemoryLimit:                   lo.ToPtr[int64](0),
+				LogLevel:                      lo.ToPtr("debug"),
+				LogOutputPaths:                lo.ToPtr("/etc/k8s/test"),
+				LogErrorOutputPaths:           lo.ToPtr("/etc/k8s/testerror"),
+				BatchMaxDuration:              lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:             lo.ToPtr(5 * time.Second),
+				ExclusiveNodeTTL:              lo.ToPtr(5 * time.Minute),
+				SimulationMaxCandidateNodes:   lo.ToPtr(100),
+				SimulationMaxTopologyDomains:  lo.ToPtr(1000),
+				AllowedSchedulerNames:         lo.ToPtr("volcano,yunikorn"),
+				PreferNewerGenerations:        lo.ToPtr(true),
+				RegistrationFailureThreshold:  lo.ToPtr(3),
+				MaxNodes:                      lo.ToPtr(100),
+				MaxVCPU:                       lo.ToPtr(1000),
+				ClusterName:                   lo.ToPtr("my-cluster"),
+				StreamInitialLists:            lo.ToPtr(true),
+				DisruptionProfiles:            lo.ToPtr("batch=1h,service=5m"),
+				LoadBalancerDrainedCondition:  lo.ToPtr("example.com/lb-drained"),
+				LoadBalancerDrainTimeout:      lo.ToPtr(2 * time.Minute),
+				ProvenanceSinkURL:             lo.ToPtr("https://attestation.example.com/nodeclaims"),
+				MaxInstanceTypeStaleness:      lo.ToPtr(10 * time.Minute),
+				PreemptionSimulation:          lo.ToPtr(true),
+				DisruptionAdmissionWebhookURL: lo.ToPtr("https://change-management.example.com/disruptions"),
+				EmptinessIgnoredPods:          lo.ToPtr("monitoring:app=agent"),
+				ProvisioningDecisionTTL:       lo.ToPtr(24 * time.Hour),
+				DisruptionObserveOnly:         lo.ToPtr(true),
+				DisruptionEvictionOrder:       lo.ToPtr("Guaranteed,Burstable"),
+				BinPackingStrategy:            lo.ToPtr("LeastWaste"),
+				DisruptionEvaluationWorkers:   lo.ToPtr(4),
+				SchedulingWorkers:             lo.ToPtr(4),
+				TracingEndpoint:               lo.ToPtr("http://otel-collector:4318"),
+				UnavailableOfferingsTTL:       lo.ToPtr(10 * time.Minute),
+				MaxSchedulingSafetyMargin:     lo.ToPtr(30),
 				FeatureGates: test.FeatureGates{
 					NodeRepair:              lo.ToPtr(true),
 					SpotToSpotConsolidation: lo.ToPtr(true),
@@ -281,6 +499,64 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--log-level", "hello")
 			Expect(err).ToNot(BeNil())
 		})
+		It("should parse disruption profiles with durations and Never", func() {
+			err := opts.Parse(fs, "--disruption-profiles", "batch=Never,service=5m")
+			Expect(err).To(BeNil())
+			profiles, err := options.ParseDisruptionProfiles(opts.DisruptionProfiles)
+			Expect(err).To(BeNil())
+			Expect(profiles).To(Equal(map[string]string{"batch": "Never", "service": "5m"}))
+		})
+		It("should error with an invalid disruption profile", func() {
+			err := opts.Parse(fs, "--disruption-profiles", "batch=sometimes")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should parse emptiness ignored pods with and without namespaces", func() {
+			err := opts.Parse(fs, "--emptiness-ignored-pods", "monitoring:app=agent,tier in (logging,metrics);kube-system:;team=observability")
+			Expect(err).To(BeNil())
+			selectors, err := options.ParsePodSelectors(opts.EmptinessIgnoredPods)
+			Expect(err).To(BeNil())
+			Expect(selectors).To(HaveLen(3))
+			Expect(selectors[0].Matches("monitoring", map[string]string{"app": "agent", "tier": "logging"})).To(BeTrue())
+			Expect(selectors[0].Matches("default", map[string]string{"app": "agent", "tier": "logging"})).To(BeFalse())
+			Expect(selectors[0].Matches("monitoring", map[string]string{"app": "agent"})).To(BeFalse())
+			Expect(selectors[1].Matches("kube-system", map[string]string{})).To(BeTrue())
+			Expect(selectors[2].Matches("default", map[string]string{"team": "observability"})).To(BeTrue())
+		})
+		It("should error with an invalid emptiness ignored pod selector", func() {
+			Expect(opts.Parse(fs, "--emptiness-ignored-pods", "Monitoring:app=agent")).ToNot(BeNil())
+			Expect(opts.Parse(fs, "--emptiness-ignored-pods", "monitoring:app in (")).ToNot(BeNil())
+		})
+		It("should parse the disruption eviction order", func() {
+			err := opts.Parse(fs, "--disruption-eviction-order", "Guaranteed, BestEffort")
+			Expect(err).To(BeNil())
+			order, err := options.ParseQOSOrder(opts.DisruptionEvictionOrder)
+			Expect(err).To(BeNil())
+			Expect(order).To(Equal([]corev1.PodQOSClass{corev1.PodQOSGuaranteed, corev1.PodQOSBestEffort}))
+		})
+		It("should error with an invalid disruption eviction order", func() {
+			Expect(opts.Parse(fs, "--disruption-eviction-order", "BestEffort,Critical")).ToNot(BeNil())
+			Expect(opts.Parse(fs, "--disruption-eviction-order", "BestEffort,BestEffort")).ToNot(BeNil())
+		})
+		It("should error with an invalid bin-packing strategy", func() {
+			Expect(opts.Parse(fs, "--bin-packing-strategy", "MostExpensive")).ToNot(BeNil())
+			Expect(opts.Parse(fs, "--bin-packing-strategy", "")).ToNot(BeNil())
+		})
+		It("should error when disruption evaluation workers is less than 1", func() {
+			Expect(opts.Parse(fs, "--disruption-evaluation-workers", "0")).ToNot(BeNil())
+		})
+		It("should error when scheduling workers is less than 1", func() {
+			Expect(opts.Parse(fs, "--scheduling-workers", "0")).ToNot(BeNil())
+		})
+		It("should error when the max scheduling safety margin isn't a percentage", func() {
+			Expect(opts.Parse(fs, "--max-scheduling-safety-margin", "-1")).ToNot(BeNil())
+			Expect(opts.Parse(fs, "--max-scheduling-safety-margin", "101")).ToNot(BeNil())
+		})
+		It("should error when the simulation max candidate nodes is negative", func() {
+			Expect(opts.Parse(fs, "--simulation-max-candidate-nodes", "-1")).ToNot(BeNil())
+		})
+		It("should error when the simulation max topology domains is negative", func() {
+			Expect(opts.Parse(fs, "--simulation-max-topology-domains", "-1")).ToNot(BeNil())
+		})
 	})
 })
 
@@ -304,5 +580,33 @@ func expectOptionsMatch(optsA, optsB *options.Options) {
 	Expect(optsA.LogErrorOutputPaths).To(Equal(optsB.LogErrorOutputPaths))
 	Expect(optsA.BatchMaxDuration).To(Equal(optsB.BatchMaxDuration))
 	Expect(optsA.BatchIdleDuration).To(Equal(optsB.BatchIdleDuration))
+	Expect(optsA.ExclusiveNodeTTL).To(Equal(optsB.ExclusiveNodeTTL))
+	Expect(optsA.SimulationMaxCandidateNodes).To(Equal(optsB.SimulationMaxCandidateNodes))
+	Expect(optsA.SimulationMaxTopologyDomains).To(Equal(optsB.SimulationMaxTopologyDomains))
+	Expect(optsA.AllowedSchedulerNames).To(Equal(optsB.AllowedSchedulerNames))
+	Expect(optsA.PreferNewerGenerations).To(Equal(optsB.PreferNewerGenerations))
+	Expect(optsA.RegistrationFailureThreshold).To(Equal(optsB.RegistrationFailureThreshold))
+	Expect(optsA.MaxNodes).To(Equal(optsB.MaxNodes))
+	Expect(optsA.MaxVCPU).To(Equal(optsB.MaxVCPU))
+	Expect(optsA.ClusterName).To(Equal(optsB.ClusterName))
+	Expect(optsA.StreamInitialLists).To(Equal(optsB.StreamInitialLists))
+	Expect(optsA.DisruptionProfiles).To(Equal(optsB.DisruptionProfiles))
+	Expect(optsA.LoadBalancerDrainedCondition).To(Equal(optsB.LoadBalancerDrainedCondition))
+	Expect(optsA.LoadBalancerDrainTimeout).To(Equal(optsB.LoadBalancerDrainTimeout))
+	Expect(optsA.ProvenanceSinkURL).To(Equal(optsB.ProvenanceSinkURL))
+	Expect(optsA.MaxInstanceTypeStaleness).To(Equal(optsB.MaxInstanceTypeStaleness))
+	Expect(optsA.PreemptionSimulation).To(Equal(optsB.PreemptionSimulation))
+	Expect(optsA.DisruptionAdmissionWebhookURL).To(Equal(optsB.DisruptionAdmissionWebhookURL))
+	Expect(optsA.EmptinessIgnoredPods).To(Equal(optsB.EmptinessIgnoredPods))
+	Expect(optsA.ProvisioningDecisionTTL).To(Equal(optsB.ProvisioningDecisionTTL))
+	Expect(optsA.DisruptionObserveOnly).To(Equal(optsB.DisruptionObserveOnly))
+	Expect(optsA.DisruptionEvictionOrder).To(Equal(optsB.DisruptionEvictionOrder))
+	Expect(optsA.BinPackingStrategy).To(Equal(optsB.BinPackingStrategy))
+	Expect(optsA.DisruptionEvaluationWorkers).To(Equal(optsB.DisruptionEvaluationWorkers))
+	Expect(optsA.SchedulingWorkers).To(Equal(optsB.SchedulingWorkers))
+	Expect(optsA.TracingEndpoint).To(Equal(optsB.TracingEndpoint))
+	Expect(optsA.UnavailableOfferingsTTL).To(Equal(optsB.UnavailableOfferingsTTL))
+	Expect(optsA.MaxSchedulingSafetyMargin).To(Equal(optsB.MaxSchedulingSafetyMargin))
 	Expect(optsA.FeatureGates.SpotToSpotConsolidation).To(Equal(optsB.FeatureGates.SpotToSpotConsolidation))
+	Expect(optsA.FeatureGates.ZoneRebalance).To(Equal(optsB.FeatureGates.ZoneRebalance))
 }
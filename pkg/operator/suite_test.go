@@ -17,13 +17,37 @@ limitations under the License.
 package operator_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	opmetrics "github.com/awslabs/operatorpkg/metrics"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
 	prometheusmodel "github.com/prometheus/client_model/go"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/yaml"
 
+	"sigs.k8s.io/karpenter/pkg/apis"
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 )
 
@@ -43,3 +67,218 @@ var _ = Describe("Operator", func() {
 		}
 	})
 })
+
+var _ = Describe("CRD Compatibility", func() {
+	var expected, installed *apiextensionsv1.CustomResourceDefinition
+	BeforeEach(func() {
+		expected = apis.CRDs[0].DeepCopy()
+		installed = apis.CRDs[0].DeepCopy()
+		installed.Status.StoredVersions = []string{"v1"}
+	})
+	It("should accept the CRD that the binary was built with", func() {
+		Expect(operator.ValidateCRDCompatibility(expected, installed)).To(Succeed())
+	})
+	It("should reject a CRD which doesn't serve a version the binary uses", func() {
+		installed.Spec.Versions[0].Served = false
+		Expect(operator.ValidateCRDCompatibility(expected, installed)).To(MatchError(ContainSubstring(`doesn't serve version "v1"`)))
+	})
+	It("should reject a CRD which stores a newer version", func() {
+		installed.Spec.Versions[0].Storage = false
+		installed.Spec.Versions = append(installed.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: "v2", Served: true, Storage: true})
+		Expect(operator.ValidateCRDCompatibility(expected, installed)).To(MatchError(ContainSubstring(`stores version "v2"`)))
+	})
+	It("should reject a CRD with objects stored at a newer version", func() {
+		installed.Status.StoredVersions = []string{"v1", "v2"}
+		Expect(operator.ValidateCRDCompatibility(expected, installed)).To(MatchError(ContainSubstring("stored at version(s) v2")))
+	})
+	Context("Installed CRDs", func() {
+		var scheme *runtime.Scheme
+		BeforeEach(func() {
+			scheme = runtime.NewScheme()
+			Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		})
+		installedCRDs := func(crds ...*apiextensionsv1.CustomResourceDefinition) client.Reader {
+			return fake.NewClientBuilder().WithScheme(scheme).WithObjects(lo.Map(crds, func(crd *apiextensionsv1.CustomResourceDefinition, _ int) client.Object {
+				crd = crd.DeepCopy()
+				crd.Status.StoredVersions = lo.FilterMap(crd.Spec.Versions, func(v apiextensionsv1.CustomResourceDefinitionVersion, _ int) (string, bool) {
+					return v.Name, v.Storage
+				})
+				return crd
+			})...).Build()
+		}
+		It("should accept a cluster with every CRD installed", func() {
+			Expect(operator.CheckCRDCompatibility(context.Background(), installedCRDs(apis.CRDs...))).To(Succeed())
+		})
+		It("should accept a cluster without the optional CRDs", func() {
+			Expect(operator.CheckCRDCompatibility(context.Background(), installedCRDs(apis.CRDs[0], apis.CRDs[1]))).To(Succeed())
+		})
+		It("should reject a cluster without the NodePool or NodeClaim CRD", func() {
+			err := operator.CheckCRDCompatibility(context.Background(), installedCRDs(apis.CRDs[2:]...))
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("getting crd %q", apis.CRDs[0].Name))))
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("getting crd %q", apis.CRDs[1].Name))))
+		})
+		It("should reject an incompatible optional CRD when it's installed", func() {
+			optional := apis.CRDs[2].DeepCopy()
+			optional.Spec.Versions[0].Served = false
+			Expect(operator.CheckCRDCompatibility(context.Background(), installedCRDs(apis.CRDs[0], apis.CRDs[1], optional))).To(MatchError(ContainSubstring("doesn't serve version")))
+		})
+	})
+})
+
+var _ = Describe("ClusterRole", func() {
+	var role rbacv1.ClusterRole
+
+	BeforeEach(func() {
+		role = ExpectClusterRole("../../kwok/charts/templates/clusterrole.yaml")
+	})
+	DescribeTable("should grant the verbs the controllers rely on",
+		func(group, resource, verb string) {
+			Expect(lo.ContainsBy(role.Rules, func(r rbacv1.PolicyRule) bool {
+				return lo.Contains(r.APIGroups, group) && lo.Contains(r.Resources, resource) && lo.Contains(r.Verbs, verb)
+			})).To(BeTrue(), "expected %s on %q %s", verb, group, resource)
+		},
+		Entry("evicting pods", "", "pods/eviction", "create"),
+		Entry("deleting pods", "", "pods", "delete"),
+		Entry("patching pods with exclusive tolerations", "", "pods", "patch"),
+		Entry("patching pods with dry-run results", "", "pods", "patch"),
+	)
+})
+
+var _ = Describe("Cache Sync Check", func() {
+	var informers *informertest.FakeInformers
+	BeforeEach(func() {
+		informers = &informertest.FakeInformers{}
+	})
+	It("should succeed once every informer has synced", func() {
+		for _, obj := range []client.Object{&corev1.Node{}, &corev1.Pod{}, &v1.NodeClaim{}, &v1.NodePool{}, &appsv1.DaemonSet{}} {
+			informer, err := informers.FakeInformerFor(context.Background(), obj)
+			Expect(err).ToNot(HaveOccurred())
+			informer.Synced = true
+		}
+		Expect(operator.CacheSyncCheck(informers)(&http.Request{})).To(Succeed())
+	})
+	It("should report the cluster state informers that are still syncing", func() {
+		for _, obj := range []client.Object{&corev1.Pod{}, &v1.NodeClaim{}, &v1.NodePool{}, &appsv1.DaemonSet{}} {
+			informer, err := informers.FakeInformerFor(context.Background(), obj)
+			Expect(err).ToNot(HaveOccurred())
+			informer.Synced = true
+		}
+		Expect(operator.CacheSyncCheck(informers)(&http.Request{})).To(MatchError("waiting for caches to sync, Node"))
+	})
+	It("should wait on the rest of the cache once the cluster state informers have synced", func() {
+		for _, obj := range []client.Object{&corev1.Node{}, &corev1.Pod{}, &v1.NodeClaim{}, &v1.NodePool{}, &appsv1.DaemonSet{}} {
+			informer, err := informers.FakeInformerFor(context.Background(), obj)
+			Expect(err).ToNot(HaveOccurred())
+			informer.Synced = true
+		}
+		informers.Synced = lo.ToPtr(false)
+		Expect(operator.CacheSyncCheck(informers)(&http.Request{})).To(MatchError("failed to sync caches"))
+	})
+})
+
+var _ = Describe("Metrics Server", func() {
+	var registry *prometheus.Registry
+	var gauge prometheus.Gauge
+	var server *operator.MetricsServer
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+		gauge = prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge", Help: "A gauge for testing."})
+		registry.MustRegister(gauge)
+		server = operator.NewMetricsServer(":0", registry, nil, false)
+	})
+	scrape := func() *httptest.ResponseRecorder {
+		GinkgoHelper()
+		recorder := httptest.NewRecorder()
+		server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return recorder
+	}
+	It("should fail scrapes until metrics have been gathered", func() {
+		Expect(scrape().Code).To(Equal(http.StatusInternalServerError))
+	})
+	It("should serve the metrics that were last gathered", func() {
+		gauge.Set(1)
+		server.Refresh(context.Background())
+		gauge.Set(2)
+		Expect(scrape().Body.String()).To(ContainSubstring("test_gauge 1"))
+
+		server.Refresh(context.Background())
+		Expect(scrape().Body.String()).To(ContainSubstring("test_gauge 2"))
+	})
+	It("should keep serving the previous metrics while gathering is blocked", func() {
+		gauge.Set(1)
+		server.Refresh(context.Background())
+
+		blocked, release := make(chan struct{}), make(chan struct{})
+		registry.MustRegister(blockingCollector{blocked: blocked, release: release})
+		DeferCleanup(func() { close(release) })
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			defer GinkgoRecover()
+			<-blocked
+			cancel()
+		}()
+		gauge.Set(2)
+		server.Refresh(ctx)
+		Expect(scrape().Code).To(Equal(http.StatusOK))
+		Expect(scrape().Body.String()).To(ContainSubstring("test_gauge 1"))
+	})
+	It("should serve exemplars linking observations to traces to OpenMetrics scrapers", func() {
+		histogram := opmetrics.NewPrometheusHistogram(registry, prometheus.HistogramOpts{Name: "test_histogram", Help: "A histogram for testing."}, []string{})
+		traceID := trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     trace.SpanID{0x01},
+			TraceFlags: trace.FlagsSampled,
+		}))
+		metrics.ObserveWithExemplar(ctx, histogram, 0.5, map[string]string{})
+
+		server = operator.NewMetricsServer(":0", registry, nil, true)
+		server.Refresh(context.Background())
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		request.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+		server.Handler().ServeHTTP(recorder, request)
+		Expect(recorder.Body.String()).To(ContainSubstring(fmt.Sprintf(`trace_id="%s"`, traceID)))
+
+		// Scrapers that don't request OpenMetrics are served the text format, which can't hold exemplars
+		Expect(scrape().Body.String()).ToNot(ContainSubstring("trace_id"))
+	})
+	It("should record when metrics were gathered", func() {
+		server = operator.NewMetricsServer(":0", crmetrics.Registry, nil, false)
+		start := time.Now().Unix()
+		server.Refresh(context.Background())
+		m, found := FindMetricWithLabelValues("karpenter_metrics_snapshot_timestamp_seconds", map[string]string{})
+		Expect(found).To(BeTrue())
+		Expect(m.GetGauge().GetValue()).To(BeNumerically(">=", start))
+	})
+})
+
+// blockingCollector simulates a collector that's stuck behind a wedged controller
+type blockingCollector struct {
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (c blockingCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c blockingCollector) Collect(chan<- prometheus.Metric) {
+	close(c.blocked)
+	<-c.release
+}
+
+// ExpectClusterRole parses the ClusterRole out of a helm template, dropping the lines that contain template directives
+func ExpectClusterRole(path string) rbacv1.ClusterRole {
+	GinkgoHelper()
+	raw, err := os.ReadFile(path)
+	Expect(err).ToNot(HaveOccurred())
+	for _, doc := range strings.Split(string(raw), "\n---\n") {
+		lines := lo.Reject(strings.Split(doc, "\n"), func(l string, _ int) bool { return strings.Contains(l, "{{") })
+		role := rbacv1.ClusterRole{}
+		Expect(yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &role)).To(Succeed())
+		if role.Kind == "ClusterRole" {
+			return role
+		}
+	}
+	Fail(fmt.Sprintf("no ClusterRole found in %s", path))
+	return rbacv1.ClusterRole{}
+}
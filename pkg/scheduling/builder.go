@@ -0,0 +1,135 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// RequirementsBuilder builds a set of requirements programmatically, e.g.
+//
+//	NewRequirementsBuilder().In(corev1.LabelTopologyZone, "zone-a", "zone-b").WithMinValues(2).GtQuantity(key, q).Build()
+//
+// The requirements are validated the same way NodePool requirements are when they are built.
+type RequirementsBuilder struct {
+	requirements []v1.NodeSelectorRequirementWithMinValues
+	errs         error
+}
+
+func NewRequirementsBuilder() *RequirementsBuilder {
+	return &RequirementsBuilder{}
+}
+
+// In adds a requirement that the label has one of the given values
+func (b *RequirementsBuilder) In(key string, values ...string) *RequirementsBuilder {
+	return b.add(key, corev1.NodeSelectorOpIn, values...)
+}
+
+// NotIn adds a requirement that the label doesn't have any of the given values
+func (b *RequirementsBuilder) NotIn(key string, values ...string) *RequirementsBuilder {
+	return b.add(key, corev1.NodeSelectorOpNotIn, values...)
+}
+
+// Exists adds a requirement that the label is set
+func (b *RequirementsBuilder) Exists(key string) *RequirementsBuilder {
+	return b.add(key, corev1.NodeSelectorOpExists)
+}
+
+// DoesNotExist adds a requirement that the label isn't set
+func (b *RequirementsBuilder) DoesNotExist(key string) *RequirementsBuilder {
+	return b.add(key, corev1.NodeSelectorOpDoesNotExist)
+}
+
+// Gt adds a requirement that the label is an integer greater than value
+func (b *RequirementsBuilder) Gt(key string, value int) *RequirementsBuilder {
+	return b.add(key, corev1.NodeSelectorOpGt, strconv.Itoa(value))
+}
+
+// Lt adds a requirement that the label is an integer less than value
+func (b *RequirementsBuilder) Lt(key string, value int) *RequirementsBuilder {
+	return b.add(key, corev1.NodeSelectorOpLt, strconv.Itoa(value))
+}
+
+// GtQuantity adds a requirement that the label is an integer greater than the quantity. Fractional quantities are
+// rounded down since label values are integers, so a label value of 2 still satisfies a quantity of 1.5.
+func (b *RequirementsBuilder) GtQuantity(key string, quantity resource.Quantity) *RequirementsBuilder {
+	return b.Gt(key, int(math.Floor(quantity.AsApproximateFloat64())))
+}
+
+// LtQuantity adds a requirement that the label is an integer less than the quantity. Fractional quantities are
+// rounded up since label values are integers, so a label value of 1 still satisfies a quantity of 1.5.
+func (b *RequirementsBuilder) LtQuantity(key string, quantity resource.Quantity) *RequirementsBuilder {
+	return b.Lt(key, int(math.Ceil(quantity.AsApproximateFloat64())))
+}
+
+// WithMinValues sets the minimum number of unique values for the most recently added requirement
+func (b *RequirementsBuilder) WithMinValues(minValues int) *RequirementsBuilder {
+	if len(b.requirements) == 0 {
+		b.errs = multierr.Append(b.errs, fmt.Errorf("minValues %d set before any requirement was added", minValues))
+		return b
+	}
+	// This matches the bounds enforced on minValues by the NodePool CRD
+	if minValues < 1 || minValues > 50 {
+		b.errs = multierr.Append(b.errs, fmt.Errorf("minValues %d for key %s must be between 1 and 50", minValues, b.requirements[len(b.requirements)-1].Key))
+		return b
+	}
+	b.requirements[len(b.requirements)-1].MinValues = &minValues
+	return b
+}
+
+// Build validates and returns the requirements in the order they were added
+func (b *RequirementsBuilder) Build() ([]v1.NodeSelectorRequirementWithMinValues, error) {
+	errs := b.errs
+	for _, requirement := range b.requirements {
+		if err := v1.ValidateRequirement(requirement); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return b.requirements, nil
+}
+
+// Requirements validates and returns the requirements as a Requirements, where requirements on the same key are
+// intersected with each other
+func (b *RequirementsBuilder) Requirements() (Requirements, error) {
+	requirements, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return NewNodeSelectorRequirementsWithMinValues(requirements...), nil
+}
+
+func (b *RequirementsBuilder) add(key string, operator corev1.NodeSelectorOperator, values ...string) *RequirementsBuilder {
+	b.requirements = append(b.requirements, v1.NodeSelectorRequirementWithMinValues{
+		NodeSelectorRequirement: corev1.NodeSelectorRequirement{
+			Key:      key,
+			Operator: operator,
+			Values:   values,
+		},
+	})
+	return b
+}
@@ -0,0 +1,105 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+var _ = Describe("RequirementsBuilder", func() {
+	It("should build requirements in the order they were added", func() {
+		requirements, err := NewRequirementsBuilder().
+			In(corev1.LabelTopologyZone, "test-zone-1", "test-zone-2").
+			NotIn(corev1.LabelInstanceTypeStable, "small").
+			Exists(v1.CapacityTypeLabelKey).
+			DoesNotExist("example.com/foo").
+			Gt("example.com/cpu", 2).
+			Lt("example.com/memory", 8).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requirements).To(Equal([]v1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelTopologyZone, Operator: corev1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: corev1.LabelInstanceTypeStable, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"small"}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: v1.CapacityTypeLabelKey, Operator: corev1.NodeSelectorOpExists}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: "example.com/foo", Operator: corev1.NodeSelectorOpDoesNotExist}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: "example.com/cpu", Operator: corev1.NodeSelectorOpGt, Values: []string{"2"}}},
+			{NodeSelectorRequirement: corev1.NodeSelectorRequirement{Key: "example.com/memory", Operator: corev1.NodeSelectorOpLt, Values: []string{"8"}}},
+		}))
+	})
+	It("should set minValues on the most recently added requirement", func() {
+		requirements, err := NewRequirementsBuilder().
+			In(corev1.LabelTopologyZone, "test-zone-1", "test-zone-2").
+			In(corev1.LabelInstanceTypeStable, "small", "medium", "large").WithMinValues(2).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requirements[0].MinValues).To(BeNil())
+		Expect(requirements[1].MinValues).To(Equal(lo.ToPtr(2)))
+	})
+	It("should round quantities so that fractional bounds are respected", func() {
+		requirements, err := NewRequirementsBuilder().
+			GtQuantity("example.com/cpu", resource.MustParse("1.5")).
+			LtQuantity("example.com/memory", resource.MustParse("7.5")).
+			Requirements()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requirements.Get("example.com/cpu").Has("2")).To(BeTrue())
+		Expect(requirements.Get("example.com/cpu").Has("1")).To(BeFalse())
+		Expect(requirements.Get("example.com/memory").Has("7")).To(BeTrue())
+		Expect(requirements.Get("example.com/memory").Has("8")).To(BeFalse())
+	})
+	It("should intersect requirements on the same key", func() {
+		requirements, err := NewRequirementsBuilder().
+			In(corev1.LabelTopologyZone, "test-zone-1", "test-zone-2").
+			NotIn(corev1.LabelTopologyZone, "test-zone-2").
+			Requirements()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requirements.Get(corev1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-1"))
+	})
+	It("should fail if an In requirement has no values", func() {
+		_, err := NewRequirementsBuilder().In(corev1.LabelTopologyZone).Build()
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail if an In requirement has fewer values than minValues", func() {
+		_, err := NewRequirementsBuilder().In(corev1.LabelTopologyZone, "test-zone-1").WithMinValues(2).Build()
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail if minValues is out of bounds", func() {
+		_, err := NewRequirementsBuilder().In(corev1.LabelTopologyZone, "test-zone-1").WithMinValues(0).Build()
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail if minValues is set before any requirement", func() {
+		_, err := NewRequirementsBuilder().WithMinValues(1).In(corev1.LabelTopologyZone, "test-zone-1").Build()
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail if Gt is negative", func() {
+		_, err := NewRequirementsBuilder().Gt("example.com/cpu", -1).Build()
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail for restricted labels", func() {
+		_, err := NewRequirementsBuilder().In("kubernetes.io/custom-label", "default").Build()
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail for invalid label values", func() {
+		_, err := NewRequirementsBuilder().In(corev1.LabelTopologyZone, "not a valid value").Build()
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -265,6 +265,21 @@ func (r *Requirement) String() string {
 	return s
 }
 
+// key returns a string that uniquely identifies the requirement, see Requirements.Key
+func (r *Requirement) key() string {
+	s := fmt.Sprintf("%s %t %v", r.Key, r.complement, sets.List(r.values))
+	if r.greaterThan != nil {
+		s += fmt.Sprintf(" >%d", *r.greaterThan)
+	}
+	if r.lessThan != nil {
+		s += fmt.Sprintf(" <%d", *r.lessThan)
+	}
+	if r.MinValues != nil {
+		s += fmt.Sprintf(" minValues %d", *r.MinValues)
+	}
+	return s
+}
+
 func withinIntPtrs(valueAsString string, greaterThan, lessThan *int) bool {
 	if greaterThan == nil && lessThan == nil {
 		return true
@@ -332,3 +332,14 @@ func (r Requirements) String() string {
 	slices.Sort(stringRequirements)
 	return strings.Join(stringRequirements, ", ")
 }
+
+// Key returns a string that uniquely identifies the requirements, so that it can be used to cache results computed
+// from them. Unlike String, values aren't truncated. Restricted labels are ignored the same way they are by String.
+func (r Requirements) Key() string {
+	requirements := lo.Reject(r.Values(), func(requirement *Requirement, _ int) bool {
+		return v1.RestrictedLabels.Has(requirement.Key)
+	})
+	keys := lo.Map(requirements, func(requirement *Requirement, _ int) string { return requirement.key() })
+	slices.Sort(keys)
+	return strings.Join(keys, ";")
+}
@@ -0,0 +1,65 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulator simulates where Karpenter would schedule pods, without launching any capacity. It's meant for
+// validating NodePool changes before they're rolled out, e.g. in CI, by simulating a representative set of pods
+// against the changed NodePools.
+package simulator
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+// Simulation is the outcome of a simulation, with the placement of each pod keyed by its namespace/name
+type Simulation = provisioning.ProvisioningSimulation
+
+// Placement is where a pod would be scheduled
+type Placement = provisioning.PodPlacement
+
+// Simulator schedules pods against the NodePools in a cluster, which may be a real cluster or a fake client built from
+// manifests. Only the cluster's NodePools, along with any daemonsets, pods and other objects that scheduling depends
+// on, are read from the client. Existing nodes aren't considered, so every pod is placed on new capacity.
+type Simulator struct {
+	provisioner *provisioning.Provisioner
+}
+
+// New constructs a Simulator that launches capacity from the instance types of the CloudProvider
+func New(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, clk clock.Clock) *Simulator {
+	cluster := state.NewCluster(clk, kubeClient, cloudProvider)
+	recorder := events.NewRecorder(&record.FakeRecorder{})
+	return &Simulator{provisioner: provisioning.NewProvisioner(kubeClient, recorder, cloudProvider, cluster, clk)}
+}
+
+// Simulate returns where each of the pods would be scheduled. Like provisioning, only NodePools that are Ready are
+// launched from. The context must carry Karpenter's options, see options.ToContext.
+func (s *Simulator) Simulate(ctx context.Context, pods ...*corev1.Pod) (Simulation, error) {
+	simulation, err := s.provisioner.SimulateProvisioning(ctx, pods)
+	if err != nil {
+		return Simulation{}, fmt.Errorf("simulating pods, %w", err)
+	}
+	return simulation, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/awslabs/operatorpkg/status"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	fakecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/scheduling/simulator"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var ctx context.Context
+
+func TestSimulator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Simulator")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = test.Options().ToContext(context.Background())
+})
+
+var _ = Describe("Simulator", func() {
+	var nodePool *v1.NodePool
+	var kubeClient client.Client
+	var sim *simulator.Simulator
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodePool.StatusConditions().SetTrue(status.ConditionReady)
+	})
+	JustBeforeEach(func() {
+		kubeClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodePool).Build()
+		sim = simulator.New(kubeClient, fakecloudprovider.NewCloudProvider(), clock.RealClock{})
+	})
+	It("should place pods on new capacity from a NodePool", func() {
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			ResourceRequirements: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		})
+		simulation, err := sim.Simulate(ctx, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(simulation.Placements).To(HaveKey("default/pod"))
+		placement := simulation.Placements["default/pod"]
+		Expect(placement.Error).To(BeEmpty())
+		Expect(placement.NodePool).To(Equal(nodePool.Name))
+		Expect(placement.InstanceTypes).ToNot(BeEmpty())
+		Expect(placement.Zones).ToNot(BeEmpty())
+
+		nodeClaims := &v1.NodeClaimList{}
+		Expect(kubeClient.List(ctx, nodeClaims)).To(Succeed())
+		Expect(nodeClaims.Items).To(BeEmpty())
+	})
+	It("should report pods that can't be scheduled", func() {
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta:   metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			NodeSelector: map[string]string{corev1.LabelTopologyZone: "unknown-zone"},
+		})
+		simulation, err := sim.Simulate(ctx, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(simulation.Placements["default/pod"].Error).ToNot(BeEmpty())
+		Expect(simulation.Placements["default/pod"].NodePool).To(BeEmpty())
+	})
+	Context("Unready NodePools", func() {
+		BeforeEach(func() {
+			nodePool.StatusConditions().SetFalse(status.ConditionReady, "NotReady", "NotReady")
+		})
+		It("should not launch capacity from them", func() {
+			pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}})
+			simulation, err := sim.Simulate(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(simulation.Placements["default/pod"].Error).ToNot(BeEmpty())
+		})
+	})
+})
@@ -57,6 +57,22 @@ func (ts Taints) Tolerates(pod *corev1.Pod) (errs error) {
 	return errs
 }
 
+// Required returns the taints that kube-scheduler won't schedule a pod past without a toleration. PreferNoSchedule
+// taints are excluded since kube-scheduler only scores nodes down for them.
+func (ts Taints) Required() Taints {
+	return lo.Reject(ts, func(t corev1.Taint, _ int) bool { return t.Effect == corev1.TaintEffectPreferNoSchedule })
+}
+
+// PreferNoScheduleCost returns the number of PreferNoSchedule taints that the pod doesn't tolerate. kube-scheduler
+// prefers nodes with fewer of these, but still schedules to a node that has them when no other node fits the pod.
+func (ts Taints) PreferNoScheduleCost(pod *corev1.Pod) int {
+	return lo.CountBy(ts, func(taint corev1.Taint) bool {
+		return taint.Effect == corev1.TaintEffectPreferNoSchedule && !lo.ContainsBy(pod.Spec.Tolerations, func(t corev1.Toleration) bool {
+			return t.ToleratesTaint(&taint)
+		})
+	})
+}
+
 // Merge merges in taints with the passed in taints.
 func (ts Taints) Merge(with Taints) Taints {
 	res := lo.Map(ts, func(t corev1.Taint, _ int) corev1.Taint {
@@ -0,0 +1,44 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Taints", func() {
+	taints := Taints{
+		{Key: "hard", Value: "true", Effect: v1.TaintEffectNoSchedule},
+		{Key: "soft-a", Value: "true", Effect: v1.TaintEffectPreferNoSchedule},
+		{Key: "soft-b", Value: "true", Effect: v1.TaintEffectPreferNoSchedule},
+	}
+	It("should only require the taints that kube-scheduler enforces", func() {
+		Expect(taints.Required()).To(Equal(Taints{{Key: "hard", Value: "true", Effect: v1.TaintEffectNoSchedule}}))
+		pod := &v1.Pod{Spec: v1.PodSpec{Tolerations: []v1.Toleration{{Key: "hard", Operator: v1.TolerationOpExists}}}}
+		Expect(taints.Tolerates(pod)).ToNot(Succeed())
+		Expect(taints.Required().Tolerates(pod)).To(Succeed())
+	})
+	It("should count the PreferNoSchedule taints that the pod doesn't tolerate", func() {
+		Expect(taints.PreferNoScheduleCost(&v1.Pod{})).To(Equal(2))
+		pod := &v1.Pod{Spec: v1.PodSpec{Tolerations: []v1.Toleration{{Key: "soft-a", Operator: v1.TolerationOpExists}}}}
+		Expect(taints.PreferNoScheduleCost(pod)).To(Equal(1))
+		pod.Spec.Tolerations = []v1.Toleration{{Operator: v1.TolerationOpExists, Effect: v1.TaintEffectPreferNoSchedule}}
+		Expect(taints.PreferNoScheduleCost(pod)).To(Equal(0))
+	})
+})
@@ -102,6 +102,14 @@ func NodeClaimNodeClassRefFieldIndexer(ctx context.Context) func(cache.Cache) er
 	}
 }
 
+func NodeClaimEvictionPlanFieldIndexer(ctx context.Context) func(cache.Cache) error {
+	return func(c cache.Cache) error {
+		return c.IndexField(ctx, &v1.NodeClaim{}, "metadata.annotations.evictionPlan", func(obj client.Object) []string {
+			return lo.Compact([]string{obj.GetAnnotations()[v1.EvictionPlanAnnotationKey]})
+		})
+	}
+}
+
 func VolumeAttachmentFieldIndexer(ctx context.Context) func(cache.Cache) error {
 	return func(c cache.Cache) error {
 		return c.IndexField(ctx, &storagev1.VolumeAttachment{}, "spec.nodeName", func(obj client.Object) []string {
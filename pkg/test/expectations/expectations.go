@@ -55,6 +55,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	apisv1alpha1 "sigs.k8s.io/karpenter/pkg/apis/v1alpha1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
@@ -148,6 +149,15 @@ func ExpectToWait(fakeClock *testing.FakeClock, wg *sync.WaitGroup) {
 	}()
 }
 
+// ExpectClockStepped advances the clock that is shared by every controller under test, so that TTLs like expiration,
+// consolidateAfter and the nomination window elapse together. The clock can only move forward, since controllers
+// compare against timestamps that they've already recorded.
+func ExpectClockStepped(fakeClock *testing.FakeClock, d time.Duration) {
+	GinkgoHelper()
+	Expect(d).To(BeNumerically(">=", 0), "fake clock can't be stepped backwards")
+	fakeClock.Step(d)
+}
+
 func ExpectApplied(ctx context.Context, c client.Client, objects ...client.Object) {
 	GinkgoHelper()
 	for _, object := range objects {
@@ -245,12 +255,16 @@ func ExpectCleanedUp(ctx context.Context, c client.Client) {
 		&corev1.Pod{},
 		&corev1.Node{},
 		&appsv1.DaemonSet{},
+		&appsv1.ControllerRevision{},
 		&nodev1.RuntimeClass{},
 		&policyv1.PodDisruptionBudget{},
 		&corev1.PersistentVolumeClaim{},
 		&corev1.PersistentVolume{},
 		&storagev1.StorageClass{},
+		&storagev1.VolumeAttachment{},
 		&v1.NodePool{},
+		&apisv1alpha1.SimulationPolicy{},
+		&apisv1alpha1.ClusterHeadroom{},
 		&v1alpha1.TestNodeClass{},
 		&v1.NodeClaim{},
 	} {
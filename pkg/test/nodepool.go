@@ -57,6 +57,7 @@ func NodePool(overrides ...v1.NodePool) *v1.NodePool {
 	if override.Status.Conditions == nil {
 		override.StatusConditions().SetTrue(v1.ConditionTypeValidationSucceeded)
 		override.StatusConditions().SetTrue(v1.ConditionTypeNodeClassReady)
+		override.StatusConditions().SetTrue(v1.ConditionTypePreflightChecksSucceeded)
 	}
 	np := &v1.NodePool{
 		ObjectMeta: ObjectMeta(override.ObjectMeta),
@@ -28,27 +28,55 @@ import (
 
 type OptionsFields struct {
 	// Vendor Neutral
-	ServiceName             *string
-	MetricsPort             *int
-	HealthProbePort         *int
-	KubeClientQPS           *int
-	KubeClientBurst         *int
-	EnableProfiling         *bool
-	DisableLeaderElection   *bool
-	LeaderElectionName      *string
-	LeaderElectionNamespace *string
-	MemoryLimit             *int64
-	LogLevel                *string
-	LogOutputPaths          *string
-	LogErrorOutputPaths     *string
-	BatchMaxDuration        *time.Duration
-	BatchIdleDuration       *time.Duration
-	FeatureGates            FeatureGates
+	ServiceName                   *string
+	MetricsPort                   *int
+	HealthProbePort               *int
+	KubeClientQPS                 *int
+	KubeClientBurst               *int
+	EnableProfiling               *bool
+	DisableLeaderElection         *bool
+	LeaderElectionName            *string
+	LeaderElectionNamespace       *string
+	MemoryLimit                   *int64
+	LogLevel                      *string
+	LogOutputPaths                *string
+	LogErrorOutputPaths           *string
+	BatchMaxDuration              *time.Duration
+	BatchIdleDuration             *time.Duration
+	ExclusiveNodeTTL              *time.Duration
+	SimulationMaxCandidateNodes   *int
+	SimulationMaxTopologyDomains  *int
+	AllowedSchedulerNames         *string
+	PreferNewerGenerations        *bool
+	RegistrationFailureThreshold  *int
+	MaxNodes                      *int
+	MaxVCPU                       *int
+	ClusterName                   *string
+	StreamInitialLists            *bool
+	DisruptionProfiles            *string
+	LoadBalancerDrainedCondition  *string
+	LoadBalancerDrainTimeout      *time.Duration
+	ProvenanceSinkURL             *string
+	MaxInstanceTypeStaleness      *time.Duration
+	PreemptionSimulation          *bool
+	DisruptionAdmissionWebhookURL *string
+	EmptinessIgnoredPods          *string
+	ProvisioningDecisionTTL       *time.Duration
+	DisruptionObserveOnly         *bool
+	DisruptionEvictionOrder       *string
+	BinPackingStrategy            *string
+	DisruptionEvaluationWorkers   *int
+	SchedulingWorkers             *int
+	TracingEndpoint               *string
+	UnavailableOfferingsTTL       *time.Duration
+	MaxSchedulingSafetyMargin     *int
+	FeatureGates                  FeatureGates
 }
 
 type FeatureGates struct {
 	NodeRepair              *bool
 	SpotToSpotConsolidation *bool
+	ZoneRebalance           *bool
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -60,22 +88,50 @@ func Options(overrides ...OptionsFields) *options.Options {
 	}
 
 	return &options.Options{
-		ServiceName:           lo.FromPtrOr(opts.ServiceName, ""),
-		MetricsPort:           lo.FromPtrOr(opts.MetricsPort, 8080),
-		HealthProbePort:       lo.FromPtrOr(opts.HealthProbePort, 8081),
-		KubeClientQPS:         lo.FromPtrOr(opts.KubeClientQPS, 200),
-		KubeClientBurst:       lo.FromPtrOr(opts.KubeClientBurst, 300),
-		EnableProfiling:       lo.FromPtrOr(opts.EnableProfiling, false),
-		DisableLeaderElection: lo.FromPtrOr(opts.DisableLeaderElection, false),
-		MemoryLimit:           lo.FromPtrOr(opts.MemoryLimit, -1),
-		LogLevel:              lo.FromPtrOr(opts.LogLevel, ""),
-		LogOutputPaths:        lo.FromPtrOr(opts.LogOutputPaths, "stdout"),
-		LogErrorOutputPaths:   lo.FromPtrOr(opts.LogErrorOutputPaths, "stderr"),
-		BatchMaxDuration:      lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
-		BatchIdleDuration:     lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		ServiceName:                   lo.FromPtrOr(opts.ServiceName, ""),
+		MetricsPort:                   lo.FromPtrOr(opts.MetricsPort, 8080),
+		HealthProbePort:               lo.FromPtrOr(opts.HealthProbePort, 8081),
+		KubeClientQPS:                 lo.FromPtrOr(opts.KubeClientQPS, 200),
+		KubeClientBurst:               lo.FromPtrOr(opts.KubeClientBurst, 300),
+		EnableProfiling:               lo.FromPtrOr(opts.EnableProfiling, false),
+		DisableLeaderElection:         lo.FromPtrOr(opts.DisableLeaderElection, false),
+		MemoryLimit:                   lo.FromPtrOr(opts.MemoryLimit, -1),
+		LogLevel:                      lo.FromPtrOr(opts.LogLevel, ""),
+		LogOutputPaths:                lo.FromPtrOr(opts.LogOutputPaths, "stdout"),
+		LogErrorOutputPaths:           lo.FromPtrOr(opts.LogErrorOutputPaths, "stderr"),
+		BatchMaxDuration:              lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
+		BatchIdleDuration:             lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		ExclusiveNodeTTL:              lo.FromPtrOr(opts.ExclusiveNodeTTL, time.Hour),
+		SimulationMaxCandidateNodes:   lo.FromPtrOr(opts.SimulationMaxCandidateNodes, 0),
+		SimulationMaxTopologyDomains:  lo.FromPtrOr(opts.SimulationMaxTopologyDomains, 0),
+		AllowedSchedulerNames:         lo.FromPtrOr(opts.AllowedSchedulerNames, ""),
+		PreferNewerGenerations:        lo.FromPtrOr(opts.PreferNewerGenerations, false),
+		RegistrationFailureThreshold:  lo.FromPtrOr(opts.RegistrationFailureThreshold, 0),
+		MaxNodes:                      lo.FromPtrOr(opts.MaxNodes, 0),
+		MaxVCPU:                       lo.FromPtrOr(opts.MaxVCPU, 0),
+		ClusterName:                   lo.FromPtrOr(opts.ClusterName, ""),
+		StreamInitialLists:            lo.FromPtrOr(opts.StreamInitialLists, false),
+		DisruptionProfiles:            lo.FromPtrOr(opts.DisruptionProfiles, ""),
+		LoadBalancerDrainedCondition:  lo.FromPtrOr(opts.LoadBalancerDrainedCondition, ""),
+		LoadBalancerDrainTimeout:      lo.FromPtrOr(opts.LoadBalancerDrainTimeout, 5*time.Minute),
+		ProvenanceSinkURL:             lo.FromPtrOr(opts.ProvenanceSinkURL, ""),
+		MaxInstanceTypeStaleness:      lo.FromPtrOr(opts.MaxInstanceTypeStaleness, 30*time.Minute),
+		PreemptionSimulation:          lo.FromPtrOr(opts.PreemptionSimulation, false),
+		DisruptionAdmissionWebhookURL: lo.FromPtrOr(opts.DisruptionAdmissionWebhookURL, ""),
+		EmptinessIgnoredPods:          lo.FromPtrOr(opts.EmptinessIgnoredPods, ""),
+		ProvisioningDecisionTTL:       lo.FromPtrOr(opts.ProvisioningDecisionTTL, 0),
+		DisruptionObserveOnly:         lo.FromPtrOr(opts.DisruptionObserveOnly, false),
+		DisruptionEvictionOrder:       lo.FromPtrOr(opts.DisruptionEvictionOrder, ""),
+		BinPackingStrategy:            lo.FromPtrOr(opts.BinPackingStrategy, "FewestNodes"),
+		DisruptionEvaluationWorkers:   lo.FromPtrOr(opts.DisruptionEvaluationWorkers, 10),
+		SchedulingWorkers:             lo.FromPtrOr(opts.SchedulingWorkers, 1),
+		TracingEndpoint:               lo.FromPtr(opts.TracingEndpoint),
+		UnavailableOfferingsTTL:       lo.FromPtrOr(opts.UnavailableOfferingsTTL, 3*time.Minute),
+		MaxSchedulingSafetyMargin:     lo.FromPtrOr(opts.MaxSchedulingSafetyMargin, 0),
 		FeatureGates: options.FeatureGates{
 			NodeRepair:              lo.FromPtrOr(opts.FeatureGates.NodeRepair, false),
 			SpotToSpotConsolidation: lo.FromPtrOr(opts.FeatureGates.SpotToSpotConsolidation, false),
+			ZoneRebalance:           lo.FromPtrOr(opts.FeatureGates.ZoneRebalance, false),
 		},
 	}
 }
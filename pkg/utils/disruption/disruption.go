@@ -21,30 +21,53 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/samber/lo"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
-// lifetimeRemaining calculates the fraction of node lifetime remaining in the range [0.0, 1.0].  If the ExpireAfter
-// is non-zero, we use it to scale down the disruption costs of candidates that are going to expire.  Just after creation, the
+// lifetimeRemaining calculates the fraction of node lifetime remaining in the range [0.0, 1.0].  If the NodeClaim
+// expires, we use its expiration time to scale down the disruption costs of candidates that are going to expire.  Just after creation, the
 // disruption cost is highest, and it approaches zero as the node ages towards its expiration time.
 func LifetimeRemaining(clock clock.Clock, nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) float64 {
 	remaining := 1.0
-	if nodeClaim.Spec.ExpireAfter.Duration != nil {
-		ageInSeconds := clock.Since(nodeClaim.CreationTimestamp.Time).Seconds()
-		totalLifetimeSeconds := nodeClaim.Spec.ExpireAfter.Duration.Seconds()
-		lifetimeRemainingSeconds := totalLifetimeSeconds - ageInSeconds
+	// An invalid expire-at annotation is surfaced by the expiration controller, which falls back to ExpireAfter
+	expirationTime, _ := nodeclaimutils.ExpirationTime(nodeClaim)
+	if expirationTime != nil {
+		totalLifetimeSeconds := expirationTime.Sub(nodeClaim.CreationTimestamp.Time).Seconds()
+		if totalLifetimeSeconds <= 0 {
+			return 0.0
+		}
+		lifetimeRemainingSeconds := expirationTime.Sub(clock.Now()).Seconds()
 		remaining = lo.Clamp(lifetimeRemainingSeconds/totalLifetimeSeconds, 0.0, 1.0)
 	}
 	return remaining
 }
 
+// qosCostStep is how much each position in the QoS eviction order adds to the cost of evicting a pod
+const qosCostStep = 0.25
+
+// QOSRank returns the position of the pod's QoS class in the order that pods are evicted in during voluntary
+// disruption. Pods of QoS classes that aren't in the order come last.
+func QOSRank(order []corev1.PodQOSClass, p *corev1.Pod) int {
+	if i := lo.IndexOf(order, podutil.QOSClass(p)); i >= 0 {
+		return i
+	}
+	return len(order)
+}
+
 // EvictionCost returns the disruption cost computed for evicting the given pod.
 func EvictionCost(ctx context.Context, p *corev1.Pod) float64 {
 	cost := 1.0
@@ -65,6 +88,10 @@ func EvictionCost(ctx context.Context, p *corev1.Pod) float64 {
 		cost += float64(*p.Spec.Priority) / math.Pow(2, 25)
 	}
 
+	// pods of QoS classes that are evicted later during voluntary disruption are less tolerant of restarts
+	order, _ := options.ParseQOSOrder(options.FromContext(ctx).DisruptionEvictionOrder)
+	cost += qosCostStep * float64(QOSRank(order, p))
+
 	// overall we clamp the pod cost to the range [-10.0, 10.0] with the default being 1.0
 	return lo.Clamp(cost, -10.0, 10.0)
 }
@@ -76,3 +103,52 @@ func ReschedulingCost(ctx context.Context, pods []*corev1.Pod) float64 {
 	}
 	return cost
 }
+
+// jobProgressCostWeight is the most that a Job's progress adds to the cost of evicting one of its pods. A pod whose Job
+// is nearly complete costs about as much to evict as ten other pods, since we'd be throwing away nearly all of its work.
+const jobProgressCostWeight = 9.0
+
+// JobProgressCost returns the additional disruption cost of evicting pods whose Jobs are making progress, so that
+// consolidation prefers nodes whose Jobs just started over nodes whose Jobs are nearly complete.
+func JobProgressCost(ctx context.Context, kubeClient client.Client, clk clock.Clock, pods []*corev1.Pod) float64 {
+	cost := 0.0
+	for _, p := range pods {
+		cost += jobProgressCostWeight * JobProgress(ctx, kubeClient, clk, p)
+	}
+	return cost
+}
+
+// JobProgress estimates how far along the work of the pod is in the range [0.0, 1.0]. Pods can set the time they
+// expect to complete with the karpenter.sh/expected-completion-time annotation, otherwise we use the fraction of
+// completions of the Job that owns the pod. Pods which aren't part of a Job haven't made any progress.
+func JobProgress(ctx context.Context, kubeClient client.Client, clk clock.Clock, p *corev1.Pod) float64 {
+	if expectedCompletionStr, ok := p.Annotations[v1.ExpectedCompletionTimeAnnotationKey]; ok && p.Status.StartTime != nil {
+		expectedCompletion, err := time.Parse(time.RFC3339, expectedCompletionStr)
+		if err != nil {
+			log.FromContext(ctx).Error(err, fmt.Sprintf("failed parsing %s=%s from pod %s",
+				v1.ExpectedCompletionTimeAnnotationKey, expectedCompletionStr, client.ObjectKeyFromObject(p)))
+		} else {
+			total := expectedCompletion.Sub(p.Status.StartTime.Time).Seconds()
+			if total <= 0 {
+				return 1.0
+			}
+			return lo.Clamp(clk.Since(p.Status.StartTime.Time).Seconds()/total, 0.0, 1.0)
+		}
+	}
+	owner := metav1.GetControllerOf(p)
+	if owner == nil || owner.APIVersion != batchv1.SchemeGroupVersion.String() || owner.Kind != "Job" {
+		return 0.0
+	}
+	job := &batchv1.Job{}
+	if err := kubeClient.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: owner.Name}, job); err != nil {
+		if !errors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, fmt.Sprintf("failed getting job for pod %s", client.ObjectKeyFromObject(p)))
+		}
+		return 0.0
+	}
+	// A Job with a single completion doesn't report any progress until it's done
+	if job.Spec.Completions == nil || *job.Spec.Completions <= 1 {
+		return 0.0
+	}
+	return lo.Clamp(float64(job.Status.Succeeded)/float64(*job.Spec.Completions), 0.0, 1.0)
+}
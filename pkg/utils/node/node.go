@@ -20,6 +20,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/awslabs/operatorpkg/status"
@@ -33,6 +35,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	nodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
 )
@@ -144,8 +147,9 @@ func GetProvisionablePods(ctx context.Context, kubeClient client.Client) ([]*cor
 	if err := kubeClient.List(ctx, &podList, client.MatchingFields{"spec.nodeName": ""}); err != nil {
 		return nil, fmt.Errorf("listing pods, %w", err)
 	}
+	allowedSchedulerNames := strings.Split(options.FromContext(ctx).AllowedSchedulerNames, ",")
 	return lo.FilterMap(podList.Items, func(p corev1.Pod, _ int) (*corev1.Pod, bool) {
-		return &p, pod.IsProvisionable(&p)
+		return &p, pod.IsProvisionableBy(&p, allowedSchedulerNames)
 	}), nil
 }
 
@@ -167,6 +171,25 @@ func GetCondition(n *corev1.Node, match corev1.NodeConditionType) corev1.NodeCon
 	return corev1.NodeCondition{}
 }
 
+// MetadataMismatches returns the keys of the NodeClaim's labels that the node no longer has with the same value, along
+// with the NodeClaim's taints that are missing from the node. Startup taints are expected to be removed, as is the
+// exclusive taint once its TTL elapses, so neither is reported.
+func MetadataMismatches(node *corev1.Node, nodeClaim *v1.NodeClaim) ([]string, []corev1.Taint) {
+	var labels []string
+	for k, v := range nodeClaim.Labels {
+		if value, ok := node.Labels[k]; !ok || value != v {
+			labels = append(labels, k)
+		}
+	}
+	taints := lo.Filter(nodeClaim.Spec.Taints, func(t corev1.Taint, _ int) bool {
+		return t.Key != v1.ExclusiveTaintKey && !lo.ContainsBy(node.Spec.Taints, func(nt corev1.Taint) bool {
+			return nt.MatchTaint(&t) && nt.Value == t.Value
+		})
+	})
+	sort.Strings(labels)
+	return labels, taints
+}
+
 func IsManaged(node *corev1.Node, cp cloudprovider.CloudProvider) bool {
 	return lo.ContainsBy(cp.GetSupportedNodeClasses(), func(nodeClass status.Object) bool {
 		_, ok := node.Labels[v1.NodeClassLabelKey(object.GVK(nodeClass).GroupKind())]
@@ -20,6 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/awslabs/operatorpkg/object"
 	"github.com/awslabs/operatorpkg/status"
@@ -34,8 +37,28 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
+// ExpirationTime returns when the NodeClaim expires, or nil if it never does. The karpenter.sh/expire-at annotation
+// overrides the NodeClaim's ExpireAfter. If the annotation isn't an RFC3339 timestamp, the expiration from ExpireAfter
+// is returned along with the error.
+func ExpirationTime(nodeClaim *v1.NodeClaim) (*time.Time, error) {
+	var expirationTime *time.Time
+	if nodeClaim.Spec.ExpireAfter.Duration != nil {
+		expirationTime = lo.ToPtr(nodeClaim.CreationTimestamp.Add(*nodeClaim.Spec.ExpireAfter.Duration))
+	}
+	value, ok := nodeClaim.Annotations[v1.NodeClaimExpireAtAnnotationKey]
+	if !ok {
+		return expirationTime, nil
+	}
+	expireAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return expirationTime, fmt.Errorf("parsing %s annotation, %w", v1.NodeClaimExpireAtAnnotationKey, err)
+	}
+	return &expireAt, nil
+}
+
 func IsManaged(nodeClaim *v1.NodeClaim, cp cloudprovider.CloudProvider) bool {
 	return lo.ContainsBy(cp.GetSupportedNodeClasses(), func(nodeClass status.Object) bool {
 		return object.GVK(nodeClass).GroupKind() == nodeClaim.Spec.NodeClassRef.GroupKind()
@@ -53,6 +76,10 @@ func ForProviderID(providerID string) client.ListOption {
 	return client.MatchingFields{"status.providerID": providerID}
 }
 
+func ForEvictionPlan(id string) client.ListOption {
+	return client.MatchingFields{"metadata.annotations.evictionPlan": id}
+}
+
 func ForNodePool(nodePoolName string) client.ListOption {
 	return client.MatchingLabels(map[string]string{v1.NodePoolLabelKey: nodePoolName})
 }
@@ -236,3 +263,31 @@ func UpdateNodeOwnerReferences(nodeClaim *v1.NodeClaim, node *corev1.Node) *core
 	})
 	return node
 }
+
+// TemplateVariables resolves the variables that CloudProviders can interpolate into the NodeClaim's bootstrap
+// configuration. It's expected to be called once the NodeClaim's labels and taints are finalized. Labels that the
+// kubelet would refuse through --node-labels are left out, since they'd stop the kubelet from starting; they're
+// applied to the Node during registration instead.
+func TemplateVariables(ctx context.Context, nodeClaim *v1.NodeClaim) map[string]string {
+	taintsToString := func(taints []corev1.Taint) string {
+		return strings.Join(lo.Map(taints, func(t corev1.Taint, _ int) string { return t.ToString() }), ",")
+	}
+	labels := lo.Filter(lo.Keys(nodeClaim.Labels), func(k string, _ int) bool { return v1.IsKubeletLabel(k) })
+	sort.Strings(labels)
+	return map[string]string{
+		v1.TemplateVariableClusterName:   options.FromContext(ctx).ClusterName,
+		v1.TemplateVariableNodePool:      nodeClaim.Labels[v1.NodePoolLabelKey],
+		v1.TemplateVariableLabels:        strings.Join(lo.Map(labels, func(k string, _ int) string { return fmt.Sprintf("%s=%s", k, nodeClaim.Labels[k]) }), ","),
+		v1.TemplateVariableTaints:        taintsToString(nodeClaim.Spec.Taints),
+		v1.TemplateVariableStartupTaints: taintsToString(nodeClaim.Spec.StartupTaints),
+	}
+}
+
+// ExpandTemplateVariables replaces {{karpenter.<variable>}} placeholders in s with the NodeClaim's template variables.
+// A distinct placeholder syntax is used so that shell variables and other templating in bootstrap configuration are
+// left untouched. Placeholders for unknown variables are left as-is.
+func ExpandTemplateVariables(s string, nodeClaim *v1.NodeClaim) string {
+	return strings.NewReplacer(lo.Flatten(lo.MapToSlice(nodeClaim.Spec.TemplateVariables, func(k, v string) []string {
+		return []string{fmt.Sprintf("{{karpenter.%s}}", k), v}
+	}))...).Replace(s)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -34,6 +35,7 @@ import (
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
@@ -222,4 +224,70 @@ var _ = Describe("NodeClaimUtils", func() {
 			Expect(res[0].Name).To(Equal(managed.Name))
 		})
 	})
+	Context("Template Variables", func() {
+		var nodeClaim *v1.NodeClaim
+		BeforeEach(func() {
+			nodeClaim = test.NodeClaim(v1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.NodePoolLabelKey: "default",
+						"team":              "a",
+					},
+				},
+				Spec: v1.NodeClaimSpec{
+					Taints:        []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}},
+					StartupTaints: []corev1.Taint{{Key: "example.com/not-ready", Effect: corev1.TaintEffectNoExecute}},
+				},
+			})
+		})
+		It("should resolve template variables from the NodeClaim and options", func() {
+			variablesCtx := options.ToContext(ctx, test.Options(test.OptionsFields{ClusterName: lo.ToPtr("my-cluster")}))
+			Expect(nodeclaimutils.TemplateVariables(variablesCtx, nodeClaim)).To(Equal(map[string]string{
+				v1.TemplateVariableClusterName:   "my-cluster",
+				v1.TemplateVariableNodePool:      "default",
+				v1.TemplateVariableLabels:        "karpenter.sh/nodepool=default,team=a",
+				v1.TemplateVariableTaints:        "dedicated=gpu:NoSchedule",
+				v1.TemplateVariableStartupTaints: "example.com/not-ready:NoExecute",
+			}))
+		})
+		It("should leave labels that the kubelet refuses out of the labels template variable", func() {
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+				"node-role.kubernetes.io/worker":          "",
+				"node-restriction.kubernetes.io/team":     "a",
+				"kops.k8s.io/instancegroup":               "nodes",
+				corev1.LabelTopologyZone:                  "test-zone-1",
+				corev1.LabelNamespaceSuffixNode + "/pool": "a",
+			})
+			Expect(nodeclaimutils.TemplateVariables(ctx, nodeClaim)[v1.TemplateVariableLabels]).To(Equal("karpenter.sh/nodepool=default,node.kubernetes.io/pool=a,team=a,topology.kubernetes.io/zone=test-zone-1"))
+		})
+		It("should expand template variable placeholders", func() {
+			nodeClaim.Spec.TemplateVariables = map[string]string{v1.TemplateVariableClusterName: "my-cluster", v1.TemplateVariableNodePool: "default"}
+			Expect(nodeclaimutils.ExpandTemplateVariables("--cluster={{karpenter.clusterName}} --pool={{karpenter.nodePool}} --home=$HOME {{karpenter.unknown}}", nodeClaim)).
+				To(Equal("--cluster=my-cluster --pool=default --home=$HOME {{karpenter.unknown}}"))
+		})
+	})
+	Context("Expiration Time", func() {
+		var nodeClaim *v1.NodeClaim
+		BeforeEach(func() {
+			nodeClaim = test.NodeClaim(v1.NodeClaim{Spec: v1.NodeClaimSpec{ExpireAfter: v1.MustParseNillableDuration("1h")}})
+			nodeClaim.CreationTimestamp = metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		})
+		It("should expire after ExpireAfter", func() {
+			Expect(nodeclaimutils.ExpirationTime(nodeClaim)).To(Equal(lo.ToPtr(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC))))
+		})
+		It("should not expire when ExpireAfter is disabled", func() {
+			nodeClaim.Spec.ExpireAfter = v1.MustParseNillableDuration("Never")
+			Expect(nodeclaimutils.ExpirationTime(nodeClaim)).To(BeNil())
+		})
+		It("should prefer the expire-at override", func() {
+			nodeClaim.Annotations = map[string]string{v1.NodeClaimExpireAtAnnotationKey: "2024-01-01T00:30:00Z"}
+			Expect(nodeclaimutils.ExpirationTime(nodeClaim)).To(Equal(lo.ToPtr(time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC))))
+		})
+		It("should fall back to ExpireAfter when the expire-at override is invalid", func() {
+			nodeClaim.Annotations = map[string]string{v1.NodeClaimExpireAtAnnotationKey: "2024-01-01"}
+			expirationTime, err := nodeclaimutils.ExpirationTime(nodeClaim)
+			Expect(err).To(HaveOccurred())
+			Expect(expirationTime).To(Equal(lo.ToPtr(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC))))
+		})
+	})
 })
@@ -0,0 +1,142 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"github.com/samber/lo"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+)
+
+// DriftHasher contributes an additional value to a NodePool's static drift hash, so that providers and operators can
+// drift NodeClaims on fields that aren't part of the NodePool's template (e.g. custom labels or annotations on the
+// NodePool) without changing the drift controller. Hashers must be deterministic and only depend on the NodePool,
+// since the hash is recomputed whenever the NodePool changes. Drift on the NodeClass is still surfaced through
+// CloudProvider.IsDrifted.
+type DriftHasher interface {
+	// Name identifies the hasher. It's surfaced in a drifted NodeClaim's status.driftedFields, so it should read as
+	// the path of the field that the hasher covers.
+	Name() string
+	// Hash returns the hasher's value for the NodePool. An empty value doesn't contribute to the hash.
+	Hash(*v1.NodePool) string
+}
+
+var (
+	driftHashersMu sync.RWMutex
+	driftHashers   = map[string]DriftHasher{}
+)
+
+// RegisterDriftHasher adds a hasher to the static drift hash of every NodePool. Registering a hasher changes the hash
+// version, so the hash controller re-hashes existing NodeClaims rather than drifting them. Hashers should be
+// registered before the operator's controllers are started.
+func RegisterDriftHasher(hasher DriftHasher) {
+	driftHashersMu.Lock()
+	defer driftHashersMu.Unlock()
+	if _, ok := driftHashers[hasher.Name()]; ok {
+		panic(fmt.Sprintf("drift hasher %q is already registered", hasher.Name()))
+	}
+	driftHashers[hasher.Name()] = hasher
+}
+
+// UnregisterDriftHasher removes a hasher that was registered with RegisterDriftHasher
+func UnregisterDriftHasher(name string) {
+	driftHashersMu.Lock()
+	defer driftHashersMu.Unlock()
+	delete(driftHashers, name)
+}
+
+// HashVersion returns the NodePool hash version, suffixed with the registered hashers so that registering or
+// removing a hasher is treated like a change to the hash calculation
+func HashVersion() string {
+	driftHashersMu.RLock()
+	defer driftHashersMu.RUnlock()
+	if len(driftHashers) == 0 {
+		return v1.NodePoolHashVersion
+	}
+	return fmt.Sprintf("%s-%s", v1.NodePoolHashVersion, hash(lo.Keys(driftHashers)))
+}
+
+// Hash returns the NodePool's static drift hash, including the values of the registered hashers
+func Hash(nodePool *v1.NodePool) string {
+	values := hasherValues(nodePool)
+	if len(values) == 0 {
+		return nodePool.Hash()
+	}
+	return hash(struct {
+		NodePool string
+		Hashers  map[string]string
+	}{
+		NodePool: nodePool.Hash(),
+		Hashers:  values,
+	})
+}
+
+// FieldHashes returns the NodePool's field hashes along with a hash for each registered hasher, keyed by its name
+func FieldHashes(nodePool *v1.NodePool) map[string]string {
+	return lo.Assign(nodePool.FieldHashes(), lo.MapValues(hasherValues(nodePool), func(v string, _ string) string { return hash(v) }))
+}
+
+// FieldHashesAnnotation returns the value of the field hashes annotation that's recorded on NodeClaims
+func FieldHashesAnnotation(nodePool *v1.NodePool) string {
+	return string(lo.Must(json.Marshal(FieldHashes(nodePool))))
+}
+
+// DriftedFields compares the field hashes recorded on the NodeClaim when it was created against the NodePool's
+// current field hashes. NodeClaims created before field hashes were recorded can only be attributed to the template.
+func DriftedFields(nodePool *v1.NodePool, nodeClaim *v1.NodeClaim) []string {
+	fieldHashes := map[string]string{}
+	if err := json.Unmarshal([]byte(nodeClaim.Annotations[v1.NodePoolFieldHashesAnnotationKey]), &fieldHashes); err != nil || len(fieldHashes) == 0 {
+		return []string{"spec.template"}
+	}
+	var fields []string
+	for field, hash := range FieldHashes(nodePool) {
+		if fieldHashes[field] != hash {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return []string{"spec.template"}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func hasherValues(nodePool *v1.NodePool) map[string]string {
+	driftHashersMu.RLock()
+	defer driftHashersMu.RUnlock()
+	values := map[string]string{}
+	for name, hasher := range driftHashers {
+		if value := hasher.Hash(nodePool); value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+func hash(v any) string {
+	return fmt.Sprint(lo.Must(hashstructure.Hash(v, hashstructure.FormatV2, &hashstructure.HashOptions{
+		SlicesAsSets:    true,
+		IgnoreZeroValue: true,
+		ZeroNil:         true,
+	})))
+}
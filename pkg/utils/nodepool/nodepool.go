@@ -31,6 +31,7 @@ import (
 
 	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
 func IsManaged(nodePool *v1.NodePool, cp cloudprovider.CloudProvider) bool {
@@ -39,6 +40,12 @@ func IsManaged(nodePool *v1.NodePool, cp cloudprovider.CloudProvider) bool {
 	})
 }
 
+// IsObserveOnly returns whether Karpenter only reports the disruption decisions it makes for the NodePool, either because
+// of the NodePool's disruption mode or because disruption is observe-only for every NodePool
+func IsObserveOnly(ctx context.Context, nodePool *v1.NodePool) bool {
+	return options.FromContext(ctx).DisruptionObserveOnly || nodePool.Spec.Disruption.Mode == v1.DisruptionModeObserveOnly
+}
+
 // IsManagedPredicateFuncs is used to filter controller-runtime NodeClaim watches to NodeClaims managed by the given cloudprovider.
 func IsManagedPredicateFuncs(cp cloudprovider.CloudProvider) predicate.Funcs {
 	return predicate.NewPredicateFuncs(func(o client.Object) bool {
@@ -23,6 +23,7 @@ import (
 	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -32,11 +33,11 @@ import (
 // Limits is used to evaluate if evicting a list of pods is possible.
 type Limits []*pdbItem
 
-func NewLimits(ctx context.Context, clk clock.Clock, kubeClient client.Client) (Limits, error) {
+func NewLimits(ctx context.Context, clk clock.Clock, kubeClient client.Client, opts ...client.ListOption) (Limits, error) {
 	pdbs := []*pdbItem{}
 
 	var pdbList policyv1.PodDisruptionBudgetList
-	if err := kubeClient.List(ctx, &pdbList); err != nil {
+	if err := kubeClient.List(ctx, &pdbList, opts...); err != nil {
 		return nil, err
 	}
 	for _, pdb := range pdbList.Items {
@@ -86,6 +87,23 @@ func (l Limits) CanEvictPods(pods []*v1.Pod) (client.ObjectKey, bool) {
 	return client.ObjectKey{}, true
 }
 
+// Governing returns the PDBs that the eviction of any of the pods is subject to. Pods that won't be evicted through
+// the eviction API aren't governed by PDBs.
+func (l Limits) Governing(pods ...*v1.Pod) sets.Set[client.ObjectKey] {
+	keys := sets.New[client.ObjectKey]()
+	for _, pod := range pods {
+		if !podutil.IsEvictable(pod) {
+			continue
+		}
+		for _, pdb := range l {
+			if pdb.key.Namespace == pod.ObjectMeta.Namespace && pdb.selector.Matches(labels.Set(pod.Labels)) {
+				keys.Insert(pdb.key)
+			}
+		}
+	}
+	return keys
+}
+
 type pdbItem struct {
 	key                         client.ObjectKey
 	selector                    labels.Selector
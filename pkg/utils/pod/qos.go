@@ -0,0 +1,65 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// qosResources are the resources that a pod's QoS class is determined from
+var qosResources = sets.New(corev1.ResourceCPU, corev1.ResourceMemory)
+
+// QOSClass returns the pod's QoS class. The API server sets it on the pod's status when the pod is created, and we
+// compute it from the pod's containers the same way if it's missing.
+func QOSClass(pod *corev1.Pod) corev1.PodQOSClass {
+	if pod.Status.QOSClass != "" {
+		return pod.Status.QOSClass
+	}
+	hasResources := false
+	guaranteed := true
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		for name, quantity := range c.Resources.Requests {
+			if !qosResources.Has(name) || quantity.IsZero() {
+				continue
+			}
+			hasResources = true
+			// Requests default to limits, so containers are only guaranteed if any requests they set match their limits
+			if limit, ok := c.Resources.Limits[name]; !ok || limit.Cmp(quantity) != 0 {
+				guaranteed = false
+			}
+		}
+		limits := 0
+		for name, quantity := range c.Resources.Limits {
+			if qosResources.Has(name) && !quantity.IsZero() {
+				hasResources = true
+				limits++
+			}
+		}
+		if limits != qosResources.Len() {
+			guaranteed = false
+		}
+	}
+	switch {
+	case !hasResources:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	default:
+		return corev1.PodQOSBurstable
+	}
+}
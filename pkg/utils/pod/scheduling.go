@@ -19,6 +19,7 @@ package pod
 import (
 	"time"
 
+	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/clock"
@@ -96,6 +97,32 @@ func IsProvisionable(pod *corev1.Pod) bool {
 		!IsOwnedByNode(pod)
 }
 
+// SchedulerPredicate returns true if the scheduler responsible for the pod has given up on scheduling it to the
+// existing nodes in the cluster and the pod needs new capacity.
+type SchedulerPredicate func(*corev1.Pod) bool
+
+// SchedulerPredicates models the scheduling semantics of non-default schedulers, keyed by schedulerName. They're only
+// used for schedulers which are allowed through the --allowed-scheduler-names option, and schedulers that don't have a
+// predicate registered fall back to NotScheduled.
+var SchedulerPredicates = map[string]SchedulerPredicate{}
+
+// IsProvisionableBy checks if a pod needs to be scheduled to new capacity by Karpenter, like IsProvisionable, but
+// recognizes pods handled by one of the allowed non-default schedulers using that scheduler's SchedulerPredicate.
+func IsProvisionableBy(pod *corev1.Pod, allowedSchedulerNames []string) bool {
+	if !lo.Contains(allowedSchedulerNames, pod.Spec.SchedulerName) {
+		return IsProvisionable(pod)
+	}
+	predicate, ok := SchedulerPredicates[pod.Spec.SchedulerName]
+	if !ok {
+		predicate = NotScheduled
+	}
+	return predicate(pod) &&
+		!IsScheduled(pod) &&
+		!IsPreempting(pod) &&
+		!IsOwnedByDaemonSet(pod) &&
+		!IsOwnedByNode(pod)
+}
+
 // IsDisruptable checks if a pod can be disrupted based on validating the `karpenter.sh/do-not-disrupt` annotation on the pod.
 // It checks whether the following is true for the pod:
 // - Has the `karpenter.sh/do-not-disrupt` annotation
@@ -119,6 +146,17 @@ func FailedToSchedule(pod *corev1.Pod) bool {
 	return false
 }
 
+// NotScheduled is a more lenient FailedToSchedule for schedulers which mark the "PodScheduled" status condition as
+// false with a reason other than "Unschedulable"
+func NotScheduled(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			return true
+		}
+	}
+	return false
+}
+
 func IsScheduled(pod *corev1.Pod) bool {
 	return pod.Spec.NodeName != ""
 }
@@ -180,6 +218,19 @@ func HasDoNotDisrupt(pod *corev1.Pod) bool {
 	return pod.Annotations[v1.DoNotDisruptAnnotationKey] == "true"
 }
 
+// IsExclusive returns true if the pod has requested a dedicated node through the `karpenter.sh/exclusive-node` annotation
+func IsExclusive(pod *corev1.Pod) bool {
+	if pod.Annotations == nil {
+		return false
+	}
+	return pod.Annotations[v1.ExclusiveNodeAnnotationKey] == "true"
+}
+
+// ToleratesExclusiveTaint returns true if the pod tolerates the exclusive taint that is keyed to its own UID
+func ToleratesExclusiveTaint(pod *corev1.Pod) bool {
+	return scheduling.Taints([]corev1.Taint{v1.ExclusiveNoScheduleTaint(pod.UID)}).Tolerates(pod) == nil
+}
+
 // ToleratesDisruptedNoScheduleTaint returns true if the pod tolerates karpenter.sh/disrupted:NoSchedule taint
 func ToleratesDisruptedNoScheduleTaint(pod *corev1.Pod) bool {
 	return scheduling.Taints([]corev1.Taint{v1.DisruptedNoScheduleTaint}).Tolerates(pod) == nil
@@ -0,0 +1,138 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package perf_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/object"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	v1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/test"
+	envutils "sigs.k8s.io/karpenter/pkg/utils/env"
+)
+
+// scaleBudget is how long Karpenter has to converge at a given cluster size before the run is considered a regression
+type scaleBudget struct {
+	Provisioning time.Duration
+	Disruption   time.Duration
+}
+
+// scaleBudgets are the defaults for each cluster size. They can be overridden with the PERF_PROVISIONING_BUDGET and
+// PERF_DISRUPTION_BUDGET environment variables when validating a configuration that is expected to be slower, like
+// one with many NodePools or a restricted set of instance types.
+var scaleBudgets = map[int]scaleBudget{
+	1000:  {Provisioning: 10 * time.Minute, Disruption: 20 * time.Minute},
+	5000:  {Provisioning: 20 * time.Minute, Disruption: 45 * time.Minute},
+	10000: {Provisioning: 30 * time.Minute, Disruption: 90 * time.Minute},
+}
+
+// The scale tests are labeled with their cluster size so that a single size can be run with, for example,
+// --ginkgo.label-filter=scale-1k. They're skipped unless selected that way, since they take hours. Setting PERF_NODEPOOL to the path of a NodePool manifest runs them against that
+// NodePool, rather than the suite's default, so that users can check how their own configuration scales.
+var _ = Describe("Scale", Label("scale"), func() {
+	BeforeEach(func() {
+		if !strings.Contains(GinkgoLabelFilter(), "scale") {
+			Skip("scale tests only run when selected with a label filter")
+		}
+		if path, ok := os.LookupEnv("PERF_NODEPOOL"); ok {
+			nodePool = userNodePool(path)
+		}
+	})
+	DescribeTable("should provision and drift nodes within budget",
+		func(nodeCount int) {
+			budget := scaleBudgets[nodeCount]
+			budget.Provisioning = envutils.WithDefaultDuration("PERF_PROVISIONING_BUDGET", budget.Provisioning)
+			budget.Disruption = envutils.WithDefaultDuration("PERF_DISRUPTION_BUDGET", budget.Disruption)
+
+			// Pods share a host port so that each of them needs a node of its own
+			deployment := test.Deployment(test.DeploymentOptions{
+				Replicas: int32(nodeCount),
+				PodOptions: test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: testLabels,
+					},
+					HostPorts: []int32{8080},
+					ResourceRequirements: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("100m"),
+						},
+					},
+				}})
+			env.ExpectCreated(nodePool, nodeClass, deployment)
+
+			start := env.TimeIntervalCollector.Start("Provisioning")
+			env.EventuallyExpectHealthyPodCountWithTimeout(budget.Provisioning, labelSelector, nodeCount)
+			env.TimeIntervalCollector.End("Provisioning")
+			reportThroughput("Provisioning", nodeCount, time.Since(start), budget.Provisioning)
+
+			nodeClaims := &v1.NodeClaimList{}
+			Expect(env.Client.List(env, nodeClaims, client.MatchingLabels{v1.NodePoolLabelKey: nodePool.Name})).To(Succeed())
+			provisioned := lo.Map(nodeClaims.Items, func(nc v1.NodeClaim, _ int) client.Object { return nc.DeepCopy() })
+
+			start = env.TimeIntervalCollector.Start("Drift")
+			nodePool.Spec.Template.ObjectMeta.Labels = lo.Assign(nodePool.Spec.Template.ObjectMeta.Labels, map[string]string{
+				"test-drift": "true",
+			})
+			env.ExpectUpdated(nodePool)
+			env.EventuallyExpectNotFoundAssertion(provisioned...).WithTimeout(budget.Disruption).Should(Succeed(),
+				fmt.Sprintf("expected %d drifted nodes to be replaced within %s", len(provisioned), budget.Disruption))
+			env.EventuallyExpectHealthyPodCountWithTimeout(budget.Disruption-time.Since(start), labelSelector, nodeCount)
+			env.TimeIntervalCollector.End("Drift")
+			reportThroughput("Disruption", len(provisioned), time.Since(start), budget.Disruption)
+		},
+		Entry("1k nodes", Label("scale-1k"), 1000),
+		Entry("5k nodes", Label("scale-5k"), 5000),
+		Entry("10k nodes", Label("scale-10k"), 10000),
+	)
+})
+
+// reportThroughput records how quickly a stage converged in the suite's report, so that runs can be compared even
+// when they're within budget, and fails the test if the stage took longer than its budget
+func reportThroughput(stage string, nodeCount int, took time.Duration, budget time.Duration) {
+	GinkgoHelper()
+	AddReportEntry(fmt.Sprintf("%s throughput", stage), fmt.Sprintf("%d nodes in %s, %.1f nodes/min", nodeCount, took, float64(nodeCount)/took.Minutes()))
+	Expect(took).To(BeNumerically("<=", budget), fmt.Sprintf("%s of %d nodes took %s, expected at most %s", stage, nodeCount, took, budget))
+}
+
+// userNodePool reads a NodePool manifest to run the scale tests against. It's pointed at the suite's node class and
+// keeps the suite's discovery label so that its nodes are cleaned up along with everything else the tests create.
+func userNodePool(path string) *v1.NodePool {
+	GinkgoHelper()
+	raw, err := os.ReadFile(path)
+	Expect(err).ToNot(HaveOccurred())
+	np := &v1.NodePool{}
+	Expect(yaml.Unmarshal(raw, np)).To(Succeed())
+	np.ObjectMeta = metav1.ObjectMeta{Name: nodePool.Name, Labels: lo.Assign(np.Labels, testLabels)}
+	np.Spec.Template.Spec.NodeClassRef = &v1.NodeClassReference{
+		Name:  nodeClass.Name,
+		Kind:  object.GVK(nodeClass).Kind,
+		Group: object.GVK(nodeClass).Group,
+	}
+	return np
+}